@@ -0,0 +1,67 @@
+"""
+Sphinx extension serving the dark variants of images to readers preferring
+a dark color scheme.
+
+_script/darkimages writes the dark variant of an image next to it, with
+-dark before the extension. In the HTML output, an image with such a
+variant becomes a picture element with the variant as the source for
+(prefers-color-scheme: dark), matching the dark colors in custom.css.
+"""
+
+import os
+import posixpath
+from urllib.parse import quote
+
+from docutils import nodes
+
+
+def dark_uri(uri):
+    base, ext = posixpath.splitext(uri)
+    return base + '-dark' + ext
+
+
+def doctree_read(app, doctree):
+    env = app.env
+    for node in doctree.traverse(nodes.image):
+        uri = node['uri']
+        if '://' in uri or uri.startswith('data:') or uri.endswith('.*'):
+            continue
+        dark = dark_uri(uri)
+        if os.path.isfile(os.path.join(app.srcdir, dark)):
+            node['dark_uri'] = dark
+            env.images.add_file(env.docname, dark)
+            env.note_dependency(dark)
+
+
+def doctree_resolved(app, doctree, docname):
+    # The builder only copies the images of image nodes; the variants are
+    # added to them.
+    if app.builder.format != 'html':
+        return
+    for node in doctree.traverse(nodes.image):
+        dark = node.get('dark_uri')
+        if dark in app.env.images:
+            app.builder.images[dark] = app.env.images[dark][1]
+
+
+def visit_image(self, node):
+    dark = node.get('dark_uri')
+    if dark in self.builder.images:
+        src = posixpath.join(self.builder.imgpath, quote(self.builder.images[dark]))
+        self.body.append('<picture><source srcset="%s" media="(prefers-color-scheme: dark)" />' % src)
+    type(self).visit_image(self, node)
+
+
+def depart_image(self, node):
+    type(self).depart_image(self, node)
+    if node.get('dark_uri') in self.builder.images:
+        self.body.append('</picture>')
+
+
+def setup(app):
+    # After the image collector, which makes the URIs relative to the
+    # source directory.
+    app.connect('doctree-read', doctree_read, priority=600)
+    app.connect('doctree-resolved', doctree_resolved)
+    app.add_node(nodes.image, override=True, html=(visit_image, depart_image))
+    return {'parallel_read_safe': True}
@@ -0,0 +1,52 @@
+"""
+Sphinx extension to point links to dead external pages at their archived
+copies, as recorded in the link archive manifest maintained by
+_script/linkarchive.
+
+Rewriting is off unless link_archive_rewrite is set.
+"""
+
+import csv
+import os
+
+from docutils import nodes
+from sphinx.util import logging
+
+
+logger = logging.getLogger(__name__)
+
+
+def load_archive(app):
+    path = os.path.join(app.srcdir, app.config.link_archive_file)
+    archived = {}
+    if not os.path.exists(path):
+        logger.warning('link archive manifest %s not found', path)
+        return archived
+    with open(path, newline='', encoding='utf-8') as f:
+        for row in csv.DictReader(f):
+            if row['Dead'] and row['Archived']:
+                archived[row['URL']] = row['Archived']
+    return archived
+
+
+def builder_inited(app):
+    app.link_archive = load_archive(app) if app.config.link_archive_rewrite else {}
+
+
+def doctree_resolved(app, doctree, docname):
+    archived = getattr(app, 'link_archive', {})
+    if not archived:
+        return
+    for ref in doctree.traverse(nodes.reference):
+        uri = ref.get('refuri')
+        if uri in archived:
+            ref['refuri'] = archived[uri]
+            logger.info('%s: linking to the archived copy of %s', docname, uri)
+
+
+def setup(app):
+    app.add_config_value('link_archive_file', 'links-archive.csv', 'env')
+    app.add_config_value('link_archive_rewrite', False, 'env')
+    app.connect('builder-inited', builder_inited)
+    app.connect('doctree-resolved', doctree_resolved)
+    return {'parallel_read_safe': True}
@@ -0,0 +1,109 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./advisories > ../users/security-advisories.rst
+//
+// Fetches the published GitHub security advisories of the Syncthing
+// repositories and writes a page listing them, with the affected and
+// fixed versions. For advisories about Syncthing itself the affected
+// range is also given in terms of the releases in releases.csv, the
+// table on the releases page, so it's easy to see whether a version is
+// affected. Set GITHUB_TOKEN to avoid the rate limit for unauthenticated
+// requests.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/versions"
+)
+
+// advisory is a repository security advisory, as returned by the GitHub
+// API.
+type advisory struct {
+	GHSA        string    `json:"ghsa_id"`
+	CVE         string    `json:"cve_id"`
+	URL         string    `json:"html_url"`
+	Summary     string    `json:"summary"`
+	Severity    string    `json:"severity"`
+	PublishedAt time.Time `json:"published_at"`
+	// Vulnerabilities are the affected packages, usually one.
+	Vulnerabilities []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		// VulnerableRange is like ">= 1.0.0, < 1.2.3".
+		VulnerableRange string `json:"vulnerable_version_range"`
+		// PatchedVersions is like "1.2.3", or empty.
+		PatchedVersions string `json:"patched_versions"`
+	} `json:"vulnerabilities"`
+
+	// Repo is the repository the advisory was published in.
+	Repo string `json:"-"`
+}
+
+func main() {
+	log.SetFlags(0)
+	repos := flag.String("repos", "syncthing/syncthing,syncthing/syncthing-android,syncthing/syncthing-macos", "Comma separated repositories to take the advisories from")
+	versionsFile := flag.String("versions", "../users/releases.csv", "Versions table of the releases page")
+	flag.Parse()
+
+	table, err := versions.Load(*versionsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx := context.Background()
+	client := relnotes.Client()
+	var advs []advisory
+	for _, repo := range strings.Split(*repos, ",") {
+		as, err := listAdvisories(ctx, client, repo)
+		if err != nil {
+			log.Fatalf("%s: %v", repo, err)
+		}
+		advs = append(advs, as...)
+	}
+	sort.SliceStable(advs, func(a, b int) bool { return advs[a].PublishedAt.After(advs[b].PublishedAt) })
+
+	if err := writePage(os.Stdout, advs, table.Versions()); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// listAdvisories returns the published advisories of the repository
+// ("owner/name").
+func listAdvisories(ctx context.Context, client *github.Client, repo string) ([]advisory, error) {
+	var res []advisory
+	page := 1
+	for page != 0 {
+		u := fmt.Sprintf("repos/%s/security-advisories?state=published&per_page=100&page=%d", repo, page)
+		req, err := client.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		var advs []advisory
+		resp, err := client.Do(ctx, req, &advs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range advs {
+			advs[i].Repo = repo
+		}
+		res = append(res, advs...)
+		page = resp.NextPage
+	}
+	return res, nil
+}
@@ -0,0 +1,184 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+)
+
+// corePackage is the package name of advisories about Syncthing itself,
+// whose affected versions are in the versions table.
+const corePackage = "github.com/syncthing/syncthing"
+
+func writePage(w io.Writer, advs []advisory, versions []relnotes.Version) error {
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/advisories; do not edit.\n\n")
+	sb.WriteString(rst.Heading("Security Advisories", '='))
+	sb.WriteString("These are the security advisories published for Syncthing and its\n")
+	sb.WriteString("apps, newest first. To see whether you're affected, compare your\n")
+	sb.WriteString("version to the affected ones; the :ref:`releases table <historical-releases>`\n")
+	sb.WriteString("lists every release. Report security issues as described in the\n")
+	sb.WriteString(rst.Link("security policy", "https://github.com/syncthing/syncthing/security/policy") + ".\n\n")
+
+	if len(advs) == 0 {
+		sb.WriteString("No advisories have been published.\n")
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+
+	t := rst.Table{
+		Header: []string{"Advisory", "Published", "Severity", "Affected", "Fixed in", "Summary"},
+		Widths: []int{20, 12, 10, 20, 13, 25},
+	}
+	for _, a := range advs {
+		id := rst.Link(a.GHSA, a.URL)
+		if a.CVE != "" {
+			id += "\n\n" + rst.Link(a.CVE, "https://www.cve.org/CVERecord?id="+a.CVE)
+		}
+		var affected, fixed []string
+		for _, v := range a.Vulnerabilities {
+			aff := rst.Literal(v.VulnerableRange)
+			if v.Package.Name == corePackage {
+				if desc := affectedReleases(v.VulnerableRange, versions); desc != "" {
+					aff += "\n\n" + desc
+				}
+			}
+			if len(a.Vulnerabilities) > 1 {
+				aff = rst.Escape(v.Package.Name) + ": " + aff
+			}
+			affected = append(affected, aff)
+			if v.PatchedVersions != "" {
+				fixed = append(fixed, fixedLink(a.Repo, v.PatchedVersions))
+			}
+		}
+		if len(fixed) == 0 {
+			fixed = append(fixed, "not yet")
+		}
+		t.Rows = append(t.Rows, []string{
+			id,
+			a.PublishedAt.Format("2006-01-02"),
+			rst.Escape(a.Severity),
+			strings.Join(affected, "\n\n"),
+			strings.Join(fixed, ", "),
+			rst.Escape(a.Summary),
+		})
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+	_, err := t.WriteTo(w)
+	return err
+}
+
+// fixedLink links the patched version to its release.
+func fixedLink(repo, patched string) string {
+	tag := patched
+	if !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+	if _, ok := relnotes.ParseVersion(tag); !ok {
+		return rst.Escape(patched)
+	}
+	return rst.Link(tag, fmt.Sprintf("https://github.com/%s/releases/tag/%s", repo, tag))
+}
+
+// affectedReleases describes the releases in the range, such as "v1.0.0
+// to v1.18.5 (96 releases)", or returns the empty string if the range
+// can't be parsed or matches none.
+func affectedReleases(vrange string, versions []relnotes.Version) string {
+	cs, ok := parseRange(vrange)
+	if !ok {
+		return ""
+	}
+	var matched []relnotes.Version
+	for _, v := range versions {
+		if cs.match(v) {
+			matched = append(matched, v)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return ""
+	case 1:
+		return "release " + matched[0].String()
+	default:
+		return fmt.Sprintf("%s to %s (%d releases)", matched[0], matched[len(matched)-1], len(matched))
+	}
+}
+
+type constraint struct {
+	op string
+	v  relnotes.Version
+}
+
+type constraints []constraint
+
+// parseRange parses a vulnerable version range, comma separated
+// constraints like ">= 1.0.0" or "< 1.2".
+func parseRange(s string) (constraints, bool) {
+	var cs constraints
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		op := strings.TrimRight(part[:len(part)-len(strings.TrimLeft(part, "<>=!"))], " ")
+		if op == "" {
+			op = "="
+		}
+		v, ok := parseLoose(strings.TrimSpace(part[len(op):]))
+		if !ok {
+			return nil, false
+		}
+		cs = append(cs, constraint{op, v})
+	}
+	return cs, len(cs) > 0
+}
+
+// parseLoose parses versions like "1.2.3", "v1.2" or "1".
+func parseLoose(s string) (relnotes.Version, bool) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) > 3 {
+		return relnotes.Version{}, false
+	}
+	var ns [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return relnotes.Version{}, false
+		}
+		ns[i] = n
+	}
+	return relnotes.Version{Major: ns[0], Minor: ns[1], Patch: ns[2]}, true
+}
+
+func (cs constraints) match(v relnotes.Version) bool {
+	for _, c := range cs {
+		var ok bool
+		switch c.op {
+		case "<":
+			ok = v.Less(c.v)
+		case "<=":
+			ok = !c.v.Less(v)
+		case ">":
+			ok = c.v.Less(v)
+		case ">=":
+			ok = !v.Less(c.v)
+		case "=", "==":
+			ok = v == c.v
+		case "!=":
+			ok = v != c.v
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
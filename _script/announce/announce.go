@@ -0,0 +1,182 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./announce [-version vX.Y.Z] [-target forum|discussion] > announcement.md
+//
+// Writes a draft announcement of a release, by default the latest, for
+// the release manager to edit rather than write from scratch: the items
+// of its release notes sorted into breaking changes, new features, fixes
+// and other changes, the Go version it was built with from the versions
+// table, the operating system versions raised since the previous
+// release, and how to upgrade. The draft is Markdown from
+// templates/announcement.md.tmpl, for a forum post or, with -target
+// discussion, the body of a GitHub Discussions post, where issue numbers
+// and @mentions link by themselves.
+package main
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/template"
+
+	"syncthing.net/docs/internal/platform"
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/versions"
+)
+
+//go:embed templates/announcement.md.tmpl
+var templateFS embed.FS
+
+const (
+	targetForum      = "forum"
+	targetDiscussion = "discussion"
+)
+
+// draft is what the template is executed with.
+type draft struct {
+	Target   string
+	Release  relnotes.Release
+	Previous *relnotes.Release
+	// NewSeries is set for the first release of a minor series.
+	NewSeries bool
+	// Runtime is the Go version, when the versions table has the
+	// release.
+	Runtime    string
+	Sections   []section
+	Raised     []raise
+	CompareURL string
+	Docs       string
+}
+
+type section struct {
+	Title string
+	Items []string
+}
+
+type raise struct {
+	OS, From, To string
+}
+
+func main() {
+	log.SetFlags(0)
+	version := flag.String("version", "", "Release to announce (default the latest)")
+	target := flag.String("target", targetForum, "Where the announcement is posted, forum or discussion")
+	repo := flag.String("repo", "syncthing/syncthing", "Repository to take the releases from")
+	versionsFile := flag.String("versions", versions.File, "Versions table of the releases page")
+	overridesFile := flag.String("overrides", platform.OverridesFile, "Curated platform overrides")
+	docs := flag.String("docs", "https://docs.syncthing.net", "Base URL of the documentation")
+	flag.Parse()
+	if *target != targetForum && *target != targetDiscussion {
+		log.Fatalf("-target must be %s or %s", targetForum, targetDiscussion)
+	}
+
+	rels, err := relnotes.List(context.Background(), relnotes.Client(), *repo)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	idx := -1
+	for i, rel := range rels {
+		if *version == "" || rel.Tag == *version {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		log.Fatalln("no release", *version)
+	}
+	table, err := versions.Load(*versionsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	overrides, err := platform.ReadOverrides(*overridesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	d := draft{
+		Target:  *target,
+		Release: rels[idx],
+		Docs:    *docs,
+	}
+	d.NewSeries = d.Release.Version.Patch == 0
+	conv := relnotes.Converter{Repo: *repo}
+	d.Sections = sections(conv, d.Release, *target == targetForum)
+	if idx+1 < len(rels) {
+		d.Previous = &rels[idx+1]
+		d.CompareURL = fmt.Sprintf("https://github.com/%s/compare/%s...%s", *repo, d.Previous.Tag, d.Release.Tag)
+	}
+	if r, ok := table.Release(d.Release.Tag); ok {
+		d.Runtime = r.Runtime
+		if d.Previous != nil {
+			if prev, ok := table.Release(d.Previous.Tag); ok {
+				_, from := platform.For(prev.Version, prev.Runtime, overrides)
+				_, to := platform.For(r.Version, r.Runtime, overrides)
+				d.Raised = raised(from, to)
+			}
+		}
+	} else {
+		log.Printf("%s isn't in %s yet; leaving out the Go version and platform requirements", d.Release.Tag, *versionsFile)
+	}
+
+	tpl, err := template.ParseFS(templateFS, "templates/announcement.md.tmpl")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "announcement.md.tmpl", d); err != nil {
+		log.Fatalln(err)
+	}
+	os.Stdout.Write(buf.Bytes())
+}
+
+// sections returns the items of the release notes by category, with the
+// issue numbers and @mentions linked if they aren't linked by themselves
+// where the announcement is posted.
+func sections(conv relnotes.Converter, rel relnotes.Release, link bool) []section {
+	res := make([]section, len(relnotes.CategoryTitles))
+	for i, title := range relnotes.CategoryTitles {
+		res[i].Title = title
+	}
+	for _, it := range rel.Items() {
+		text := it.Text
+		if link {
+			text = conv.MarkdownLinks(text)
+		}
+		res[it.Category].Items = append(res[it.Category].Items, text)
+	}
+	var nonEmpty []section
+	for _, s := range res {
+		if len(s.Items) > 0 {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		// Release notes without a list, to be summarized by hand.
+		nonEmpty = []section{{Title: relnotes.CategoryTitles[relnotes.Other], Items: []string{"TODO: summarize the changes"}}}
+	}
+	return nonEmpty
+}
+
+// raised returns the operating systems whose oldest supported version
+// differs between the requirements, when both are known.
+func raised(from, to platform.Minimum) []raise {
+	var res []raise
+	for _, c := range []raise{
+		{"Windows", from.Windows, to.Windows},
+		{"macOS", from.MacOS, to.MacOS},
+		{"Linux kernel", from.Linux, to.Linux},
+	} {
+		if c.From != "" && c.To != "" && c.From != c.To {
+			res = append(res, c)
+		}
+	}
+	return res
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./apidrift -recorded dir [-release v1.28.0] [-out report.txt]
+//
+// Compares the fields of REST API responses recorded with restrecord
+// against the fields the reference pages document, and writes a drift
+// report for the release the responses were recorded with. A field is
+// documented when it's a term in the page's list of fields, mentioned as
+// a literal in the text, or present in one of the page's examples. The
+// report lists the recorded fields the page doesn't document, and the
+// fields the page lists or shows that the release no longer returns.
+//
+// Record into a directory of its own, such as
+//
+//	go run ./restrecord -syncthing syncthing-v1.28.0 -out /tmp/drift
+//
+// as the examples in ../includes/rest are part of what's documented.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pageDrift is the difference between the recorded response for a page
+// and what the page documents.
+type pageDrift struct {
+	Page string
+	// Undocumented are the paths of recorded fields, such as
+	// "total.inBytesTotal".
+	Undocumented []string
+	// Absent are the documented names that weren't in the response.
+	Absent []string
+}
+
+func main() {
+	log.SetFlags(0)
+	recorded := flag.String("recorded", "", "Directory of responses recorded with restrecord (required)")
+	pages := flag.String("pages", "../rest", "Directory of the per-endpoint reference pages")
+	release := flag.String("release", "", "Syncthing version the responses were recorded with")
+	out := flag.String("out", "", "File to write the report to, instead of stdout")
+	flag.Parse()
+	if *recorded == "" {
+		log.Fatalln("-recorded is required")
+	}
+
+	files, err := filepath.Glob(filepath.Join(*recorded, "*.json"))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(files) == 0 {
+		log.Fatalln("no recorded responses in", *recorded)
+	}
+	sort.Strings(files)
+
+	var drift []pageDrift
+	for _, file := range files {
+		page := strings.TrimSuffix(filepath.Base(file), ".json")
+		fields, err := recordedFields(file)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		docs, err := documentedFields(filepath.Join(*pages, page+".rst"))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		d := compare(fields, docs)
+		if len(d.Undocumented)+len(d.Absent) > 0 {
+			d.Page = page
+			drift = append(drift, d)
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		fd, err := os.Create(*out)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer fd.Close()
+		w = fd
+	}
+	if err := writeReport(w, *release, len(files), drift); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func writeReport(w io.Writer, release string, checked int, drift []pageDrift) error {
+	if release == "" {
+		release = "unknown release"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "REST API drift for %s: %d of %d pages differ\n", release, len(drift), checked)
+	for _, d := range drift {
+		fmt.Fprintf(&sb, "\n%s.rst\n", d.Page)
+		for _, f := range d.Undocumented {
+			fmt.Fprintf(&sb, "  undocumented: %s\n", f)
+		}
+		for _, f := range d.Absent {
+			fmt.Fprintf(&sb, "  not returned: %s\n", f)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
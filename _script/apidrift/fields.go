@@ -0,0 +1,222 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Object keys that aren't names, such as device and folder IDs or file
+// names, are data rather than fields. They show as * in field paths.
+var nameExp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var (
+	literalExp        = regexp.MustCompile("``([A-Za-z_][A-Za-z0-9_]*)``")
+	keyExp            = regexp.MustCompile(`"([^"\\]+)"\s*:`)
+	codeBlockExp      = regexp.MustCompile(`^(\s*)\.\.\s+code-block::\s+json\s*$`)
+	literalIncludeExp = regexp.MustCompile(`^\s*\.\.\s+literalinclude::\s+(\S+\.json)\s*$`)
+	termPartExp       = regexp.MustCompile(`^[A-Za-z_*][A-Za-z0-9_*.]*$`)
+)
+
+type field struct {
+	Path string
+	Name string
+}
+
+// recordedFields returns the fields of a recorded response, each path
+// once.
+func recordedFields(file string) ([]field, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(bs, &v); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	seen := make(map[string]bool)
+	var fields []field
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, e := range v {
+				name := k
+				if !nameExp.MatchString(k) {
+					name = "*"
+				}
+				p := name
+				if prefix != "" {
+					p = prefix + "." + name
+				}
+				if name != "*" && !seen[p] {
+					seen[p] = true
+					fields = append(fields, field{Path: p, Name: name})
+				}
+				walk(p, e)
+			}
+		case []any:
+			for _, e := range v {
+				walk(prefix, e)
+			}
+		}
+	}
+	walk("", v)
+	sort.Slice(fields, func(a, b int) bool { return fields[a].Path < fields[b].Path })
+	return fields, nil
+}
+
+// docFields are the field names a page documents.
+type docFields struct {
+	// Terms are the terms of the page's list of fields, which may have
+	// wildcards: "global*" stands for globalBytes, globalFiles and so on.
+	Terms []string
+	// Literals are the names mentioned as inline literals.
+	Literals map[string]bool
+	// Shown are the keys in the page's JSON examples.
+	Shown map[string]bool
+}
+
+// documentedFields reads the fields documented by a page, including the
+// JSON files it includes.
+func documentedFields(file string) (*docFields, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	docs := &docFields{Literals: make(map[string]bool), Shown: make(map[string]bool)}
+	lines := strings.Split(strings.ReplaceAll(string(bs), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		for _, m := range literalExp.FindAllStringSubmatch(line, -1) {
+			docs.Literals[m[1]] = true
+		}
+		if m := literalIncludeExp.FindStringSubmatch(line); m != nil {
+			inc := filepath.Join(filepath.Dir(file), filepath.FromSlash(m[1]))
+			bs, err := os.ReadFile(inc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			docs.addShown(string(bs))
+			continue
+		}
+		if m := codeBlockExp.FindStringSubmatch(line); m != nil {
+			j := i + 1
+			for ; j < len(lines); j++ {
+				if strings.TrimSpace(lines[j]) != "" && indent(lines[j]) <= len(m[1]) {
+					break
+				}
+			}
+			docs.addShown(strings.Join(lines[i+1:j], "\n"))
+			i = j - 1
+			continue
+		}
+		if terms := definitionTerms(lines, i); terms != nil {
+			docs.Terms = append(docs.Terms, terms...)
+		}
+	}
+	return docs, nil
+}
+
+func (d *docFields) addShown(example string) {
+	for _, m := range keyExp.FindAllStringSubmatch(example, -1) {
+		if nameExp.MatchString(m[1]) {
+			d.Shown[m[1]] = true
+		}
+	}
+}
+
+// definitionTerms returns the field names of the definition list term at
+// lines[i], as in
+//
+//	``inSync*``, ``pullErrors``:
+//	  Description...
+//
+// or nil if the line isn't a term. Dotted names are reduced to their last
+// part.
+func definitionTerms(lines []string, i int) []string {
+	line := lines[i]
+	if strings.TrimSpace(line) == "" || indent(line) > 0 || strings.HasPrefix(line, "..") {
+		return nil
+	}
+	if i > 0 && strings.TrimSpace(lines[i-1]) != "" {
+		return nil
+	}
+	if i+1 >= len(lines) || strings.TrimSpace(lines[i+1]) == "" || indent(lines[i+1]) == 0 {
+		return nil
+	}
+	var terms []string
+	for _, part := range strings.Split(strings.TrimSuffix(strings.TrimSpace(line), ":"), ",") {
+		part = strings.Trim(strings.TrimSpace(part), "`")
+		if !termPartExp.MatchString(part) {
+			return nil
+		}
+		if idx := strings.LastIndexByte(part, '.'); idx >= 0 {
+			part = part[idx+1:]
+		}
+		terms = append(terms, part)
+	}
+	return terms
+}
+
+func indent(l string) int {
+	return len(l) - len(strings.TrimLeft(l, " \t"))
+}
+
+// compare returns the drift between the recorded fields and those the
+// page documents.
+func compare(fields []field, docs *docFields) pageDrift {
+	var d pageDrift
+	names := make(map[string]bool)
+	for _, f := range fields {
+		names[f.Name] = true
+		if docs.Literals[f.Name] || docs.Shown[f.Name] || matchAny(docs.Terms, f.Name) {
+			continue
+		}
+		d.Undocumented = append(d.Undocumented, f.Path)
+	}
+
+	absent := make(map[string]bool)
+	for _, term := range docs.Terms {
+		found := false
+		for name := range names {
+			if ok, _ := path.Match(term, name); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			absent[term] = true
+		}
+	}
+	for name := range docs.Shown {
+		if !names[name] {
+			absent[name] = true
+		}
+	}
+	for name := range absent {
+		d.Absent = append(d.Absent, name)
+	}
+	sort.Strings(d.Absent)
+	return d
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./apigen -tag v1.27.0 > ../includes/rest-endpoints.rst
+//
+// Extracts the REST endpoints from the route registrations in the
+// Syncthing source and writes a reference table of them. With -pages,
+// reference pages are also written for the endpoints that don't have one
+// yet; with -check, the endpoints are compared to the existing pages
+// instead and the differences reported.
+//
+// The query parameters of each endpoint are read from its handler, with
+// the annotation on the registration saying which are required. With
+// -params, a table of them is written per endpoint for the pages to
+// include.
+//
+// With -openapi, an OpenAPI 3 document of the endpoints is written
+// instead of the table. -check-spec compares such a document to the
+// pages, without needing the source.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	pages := flag.String("pages", "../rest", "Directory of the per-endpoint reference pages")
+	write := flag.Bool("write-pages", false, "Write pages for undocumented endpoints")
+	params := flag.String("params", "", "Directory to write the parameter tables to")
+	check := flag.Bool("check", false, "Report undocumented endpoints and stale pages")
+	openapi := flag.Bool("openapi", false, "Write an OpenAPI document instead of the table")
+	checkSpecFile := flag.String("check-spec", "", "Compare the OpenAPI document to the pages")
+	flag.Parse()
+
+	docs, err := scanPages(*pages)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *checkSpecFile != "" {
+		doc, err := readOpenAPI(*checkSpecFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		undocumented, unspecified := checkSpec(doc, docs)
+		for _, title := range undocumented {
+			fmt.Printf("not documented: %s\n", title)
+		}
+		for _, title := range unspecified {
+			fmt.Printf("not in spec: %s\n", title)
+		}
+		if len(undocumented)+len(unspecified) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	pkg, err := stsource.ParseDir(root, apiDir)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	eps := extractRoutes(pkg)
+	if len(eps) == 0 {
+		log.Fatalln("no REST routes found in", apiDir)
+	}
+
+	if *openapi {
+		version := *tag
+		if version == "" {
+			version = "unknown"
+		}
+		if err := writeOpenAPI(os.Stdout, buildOpenAPI(eps, version)); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	if *check {
+		missing, stale := checkPages(eps, docs)
+		for _, ep := range missing {
+			fmt.Printf("undocumented: %s (%s)\n", ep.title(), ep.Source)
+		}
+		for _, title := range stale {
+			fmt.Printf("no longer exists: %s\n", title)
+		}
+		if len(missing)+len(stale) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *params != "" {
+		if err := writeParamTables(*params, eps); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if *write {
+		missing, _ := checkPages(eps, docs)
+		for _, ep := range missing {
+			if err := createPage(filepath.Join(*pages, pageName(ep)+".rst"), ep); err != nil {
+				log.Fatalln(err)
+			}
+			log.Println("wrote", pageName(ep)+".rst")
+		}
+		if docs, err = scanPages(*pages); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if err := writeIndex(os.Stdout, eps, docs); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func createPage(path string, ep endpoint) error {
+	fd, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := writePage(fd, ep); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// writeParamTables writes a table for each endpoint taking parameters,
+// named for its own page, removing the tables of endpoints that no longer
+// take any.
+func writeParamTables(dir string, eps []endpoint) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	old, err := filepath.Glob(filepath.Join(dir, "*.rst"))
+	if err != nil {
+		return err
+	}
+	stale := make(map[string]bool)
+	for _, file := range old {
+		stale[file] = true
+	}
+	for _, ep := range eps {
+		if len(ep.Params) == 0 {
+			continue
+		}
+		file := filepath.Join(dir, endpointName(ep)+".rst")
+		delete(stale, file)
+		fd, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		if err := writeParams(fd, ep); err != nil {
+			fd.Close()
+			return err
+		}
+		if err := fd.Close(); err != nil {
+			return err
+		}
+	}
+	for file := range stale {
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
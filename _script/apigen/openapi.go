@@ -0,0 +1,241 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type openAPI struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       openAPIInfo                     `json:"info"`
+	Servers    []openAPIServer                 `json:"servers"`
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components openAPIComponents               `json:"components"`
+	Security   []map[string][]string           `json:"security"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIComponents struct {
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type   string `json:"type"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+type operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+}
+
+type parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Explode     *bool  `json:"explode,omitempty"`
+	Schema      schema `json:"schema"`
+}
+
+type schema struct {
+	Type   string  `json:"type"`
+	Format string  `json:"format,omitempty"`
+	Items  *schema `json:"items,omitempty"`
+}
+
+type response struct {
+	Description string `json:"description"`
+}
+
+var routeParamExp = regexp.MustCompile(`:([a-zA-Z]+)`)
+
+// buildOpenAPI describes the endpoints as an OpenAPI 3 document. There are
+// no response schemas as only the routes are extracted from source.
+func buildOpenAPI(eps []endpoint, version string) *openAPI {
+	doc := &openAPI{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: "Syncthing REST API", Version: version},
+		Servers: []openAPIServer{{URL: "http://localhost:8384"}},
+		Paths:   make(map[string]map[string]operation),
+		Components: openAPIComponents{SecuritySchemes: map[string]securityScheme{
+			"apiKey": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			"bearer": {Type: "http", Scheme: "bearer"},
+		}},
+		Security: []map[string][]string{{"apiKey": {}}, {"bearer": {}}},
+	}
+
+	for _, ep := range eps {
+		// httprouter parameters, :name, are {name} in OpenAPI.
+		path := routeParamExp.ReplaceAllString(ep.Path, "{$1}")
+		op := operation{
+			OperationID: ep.Handler,
+			Summary:     summary(ep.Doc),
+			Description: ep.Doc,
+			Tags:        []string{tag(ep.Path)},
+			Responses:   map[string]response{"200": {Description: "OK"}},
+		}
+		for _, m := range routeParamExp.FindAllStringSubmatch(ep.Path, -1) {
+			op.Parameters = append(op.Parameters, parameter{Name: m[1], In: "path", Required: true, Schema: schema{Type: "string"}})
+		}
+		for _, p := range ep.Params {
+			op.Parameters = append(op.Parameters, queryParameter(p))
+		}
+		if ep.NoAuth() {
+			// An empty requirement overrides the document default.
+			op.Security = []map[string][]string{{}}
+		}
+
+		methods := []string{ep.Method}
+		if ep.Method == "" {
+			methods = []string{"GET", "POST"}
+		}
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]operation)
+		}
+		for _, m := range methods {
+			op := op
+			if len(methods) > 1 || op.OperationID == "" {
+				op.OperationID = operationID(m, ep.Path)
+			}
+			doc.Paths[path][strings.ToLower(m)] = op
+		}
+	}
+	uniqueOperationIDs(doc)
+	return doc
+}
+
+// queryParameter describes a query parameter, with the schema of its
+// type.
+func queryParameter(p param) parameter {
+	par := parameter{Name: p.Name, In: "query", Description: p.Note, Required: !p.Optional, Schema: schema{Type: "string"}}
+	switch p.Type {
+	case typeInteger:
+		par.Schema.Type = "integer"
+	case typeBoolean:
+		par.Schema.Type = "boolean"
+	case typeTime:
+		par.Schema.Format = "date-time"
+	case typeList, typeRepeated:
+		// Lists are a single comma separated value, unlike
+		// repeated parameters.
+		explode := p.Type == typeRepeated
+		par.Explode = &explode
+		par.Schema = schema{Type: "array", Items: &schema{Type: "string"}}
+	}
+	return par
+}
+
+// tag groups the operations as the reference does, by the first path
+// element after /rest.
+func tag(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/rest/"), "/")
+	return parts[0]
+}
+
+func summary(doc string) string {
+	if i := strings.Index(doc, ". "); i >= 0 {
+		doc = doc[:i+1]
+	}
+	return strings.Join(strings.Fields(doc), " ")
+}
+
+func operationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(strings.TrimPrefix(path, "/rest/"), func(r rune) bool {
+		return r == '/' || r == '.' || r == ':' || r == '-'
+	}) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+// uniqueOperationIDs replaces handler names shared by several operations,
+// such as one handler serving both GET and POST, with generated ones.
+func uniqueOperationIDs(doc *openAPI) {
+	count := make(map[string]int)
+	for _, ops := range doc.Paths {
+		for _, op := range ops {
+			count[op.OperationID]++
+		}
+	}
+	for path, ops := range doc.Paths {
+		for method, op := range ops {
+			if count[op.OperationID] > 1 {
+				op.OperationID = operationID(method, strings.NewReplacer("{", ":", "}", "").Replace(path))
+				ops[method] = op
+			}
+		}
+	}
+}
+
+func writeOpenAPI(w io.Writer, doc *openAPI) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func readOpenAPI(path string) (*openAPI, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc openAPI
+	if err := json.Unmarshal(bs, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &doc, nil
+}
+
+// checkSpec compares the operations in a spec to the documented
+// endpoints, returning the operations without documentation and the
+// documented endpoints missing from the spec.
+func checkSpec(doc *openAPI, docs *docSet) (undocumented, unspecified []string) {
+	inSpec := make(map[string]bool)
+	for path, ops := range doc.Paths {
+		path = strings.NewReplacer("{", ":", "}", "").Replace(path)
+		for method := range ops {
+			title := strings.ToUpper(method) + " " + path
+			inSpec[title] = true
+			ep := endpoint{Method: strings.ToUpper(method), Path: path}
+			if docs.page(ep) == "" {
+				undocumented = append(undocumented, title)
+			}
+		}
+	}
+	for title, page := range docs.pages {
+		if !inSpec[title] {
+			unspecified = append(unspecified, title+" ("+page+".rst)")
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(unspecified)
+	return undocumented, unspecified
+}
@@ -0,0 +1,233 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+// groupPages are the prefixes documented together on one page rather
+// than a page per endpoint.
+var groupPages = map[string]string{
+	"/rest/config":  "config",
+	"/rest/debug/":  "debug",
+	"/rest/config/": "config",
+}
+
+// pageName returns the name of the page documenting the endpoint, without
+// the .rst extension: /rest/db/completion with GET is db-completion-get.
+func pageName(ep endpoint) string {
+	for prefix, page := range groupPages {
+		if ep.Path == prefix || (strings.HasSuffix(prefix, "/") && strings.HasPrefix(ep.Path, prefix)) {
+			return page
+		}
+	}
+	return endpointName(ep)
+}
+
+// endpointName returns the name of the endpoint's own page, even when it
+// is documented on a shared one.
+func endpointName(ep endpoint) string {
+	name := strings.TrimPrefix(ep.Path, "/rest/")
+	name = strings.ToLower(strings.ReplaceAll(strings.Trim(name, "/"), "/", "-"))
+	method := ep.Method
+	if method == "" {
+		method = "get"
+	}
+	return name + "-" + strings.ToLower(method)
+}
+
+func (e endpoint) title() string {
+	method := e.Method
+	if method == "" {
+		method = "GET, POST"
+	}
+	return method + " " + e.Path
+}
+
+// writePage writes a reference page for an endpoint in the same layout as
+// the hand written ones, to be filled in with examples.
+func writePage(w io.Writer, ep endpoint) error {
+	var sb strings.Builder
+	sb.WriteString(rst.Heading(ep.title(), '='))
+	if ep.Doc != "" {
+		sb.WriteString(ep.Doc + "\n\n")
+	}
+	if len(ep.Params) > 0 {
+		fmt.Fprintf(&sb, ".. include:: ../includes/rest-params/%s.rst\n\n", endpointName(ep))
+	}
+	if ep.NoAuth() {
+		sb.WriteString("This endpoint does not require an API key.\n\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeParams writes the table of the endpoint's query parameters, for
+// inclusion in its page.
+func writeParams(w io.Writer, ep endpoint) error {
+	t := rst.Table{
+		Header: []string{"Parameter", "Type", "Required", "Notes"},
+		Widths: []int{20, 15, 10, 55},
+	}
+	for _, p := range ep.Params {
+		typ := p.Type
+		if typ == "" {
+			typ = typeString
+		}
+		req := "yes"
+		if p.Optional {
+			req = "no"
+		}
+		// Comments from the source are plain text, the shared
+		// notes markup.
+		note := rst.Escape(p.Note)
+		if n, ok := paramNotes[p.Name]; ok && n == p.Note {
+			note = n
+		}
+		t.Rows = append(t.Rows, []string{rst.Literal(p.Name), typ, req, note})
+	}
+	if _, err := fmt.Fprint(w, ".. This file is generated by _script/apigen; do not edit.\n\n"); err != nil {
+		return err
+	}
+	_, err := t.WriteTo(w)
+	return err
+}
+
+// titleExp matches the section titles of the reference pages, such as
+// "GET /rest/db/completion" or "GET /rest/system/config (DEPRECATED)".
+var titleExp = regexp.MustCompile(`^((?:GET|POST|PUT|PATCH|DELETE)(?:, ?(?:GET|POST|PUT|PATCH|DELETE))*) (/rest/\S+)(?: \(.*\))?$`)
+
+// docSet records which endpoints the existing pages document, by their
+// section titles.
+type docSet struct {
+	pages  map[string]string   // title to page
+	titles map[string][]string // page to titles
+}
+
+// scanPages reads the section titles of the pages in the directory.
+func scanPages(dir string) (*docSet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.rst"))
+	if err != nil {
+		return nil, err
+	}
+	ds := &docSet{pages: make(map[string]string), titles: make(map[string][]string)}
+	for _, file := range files {
+		bs, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		page := strings.TrimSuffix(filepath.Base(file), ".rst")
+		ds.titles[page] = nil
+		lines := strings.Split(string(bs), "\n")
+		for i := 0; i+1 < len(lines); i++ {
+			line := strings.TrimSpace(lines[i])
+			m := titleExp.FindStringSubmatch(line)
+			if m == nil || !isUnderline(lines[i+1]) {
+				continue
+			}
+			for _, method := range strings.Split(m[1], ",") {
+				title := strings.TrimSpace(method) + " " + m[2]
+				ds.pages[title] = page
+				ds.titles[page] = append(ds.titles[page], title)
+			}
+		}
+	}
+	return ds, nil
+}
+
+func isUnderline(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) > 3 && strings.Trim(line, "=-~^") == ""
+}
+
+// page returns the page documenting the endpoint, or the empty string.
+func (ds *docSet) page(ep endpoint) string {
+	methods := []string{ep.Method}
+	if ep.Method == "" {
+		methods = []string{"GET", "POST"}
+	}
+	for _, m := range methods {
+		if page, ok := ds.pages[m+" "+ep.Path]; ok {
+			return page
+		}
+	}
+	for prefix, page := range groupPages {
+		if ep.Path == prefix || (strings.HasSuffix(prefix, "/") && strings.HasPrefix(ep.Path, prefix)) {
+			if _, ok := ds.titles[page]; ok {
+				return page
+			}
+		}
+	}
+	return ""
+}
+
+// writeIndex writes the table of all endpoints, linking to the pages
+// documenting them.
+func writeIndex(w io.Writer, eps []endpoint, docs *docSet) error {
+	t := rst.Table{
+		Header: []string{"Method", "Endpoint", "Parameters", "Authentication"},
+		Widths: []int{10, 40, 30, 20},
+	}
+	for _, ep := range eps {
+		method := ep.Method
+		if method == "" {
+			method = "any"
+		}
+		path := rst.Literal(ep.Path)
+		if page := docs.page(ep); page != "" {
+			path = fmt.Sprintf(":doc:`%s </rest/%s>`", ep.Path, page)
+		}
+		var params []string
+		for _, p := range ep.Params {
+			if p.Optional {
+				params = append(params, "["+rst.Literal(p.Name)+"]")
+			} else {
+				params = append(params, rst.Literal(p.Name))
+			}
+		}
+		auth := "API key"
+		if ep.NoAuth() {
+			auth = "none"
+		}
+		t.Rows = append(t.Rows, []string{method, path, strings.Join(params, " "), auth})
+	}
+	_, err := t.WriteTo(w)
+	return err
+}
+
+// checkPages compares the endpoints to the pages, returning the endpoints
+// without documentation and the documented endpoints that no longer
+// exist.
+func checkPages(eps []endpoint, docs *docSet) (missing []endpoint, stale []string) {
+	known := make(map[string]bool)
+	for _, ep := range eps {
+		if docs.page(ep) == "" {
+			missing = append(missing, ep)
+		}
+		known[ep.title()] = true
+		if ep.Method == "" {
+			known["GET "+ep.Path] = true
+			known["POST "+ep.Path] = true
+		}
+	}
+	for title, page := range docs.pages {
+		if !known[title] {
+			stale = append(stale, title+" ("+page+".rst)")
+		}
+	}
+	sort.Strings(stale)
+	return missing, stale
+}
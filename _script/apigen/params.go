@@ -0,0 +1,349 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// The types of query parameters, as shown in the tables.
+const (
+	typeString   = "string"
+	typeInteger  = "integer"
+	typeBoolean  = "boolean"
+	typeFlag     = "flag" // any nonempty value
+	typeDuration = "duration"
+	typeTime     = "time"
+	typeDeviceID = "device ID"
+	typeList     = "list"       // comma separated
+	typeRepeated = "repeatable" // given once per value
+)
+
+// converters are the functions parsing a parameter value, giving its
+// type.
+var converters = map[string]string{
+	"strconv.Atoi":                typeInteger,
+	"strconv.ParseInt":            typeInteger,
+	"strconv.ParseBool":           typeBoolean,
+	"time.ParseDuration":          typeDuration,
+	"time.Parse":                  typeTime,
+	"protocol.DeviceIDFromString": typeDeviceID,
+	"strings.Split":               typeList,
+}
+
+// paramNotes describe parameters shared by several endpoints, read in
+// helpers without comments of their own.
+var paramNotes = map[string]string{
+	"page":    "Page of the results, from 1. See :ref:`rest-pagination`.",
+	"perpage": "Results per page. See :ref:`rest-pagination`.",
+}
+
+// queryFunc is what a function reads of the query, directly and through
+// the functions it passes the request or query to.
+type queryFunc struct {
+	params []param
+	calls  []string
+}
+
+// handlerParams finds the query parameters read by each function of the
+// package, following the request and its query into the functions
+// they're passed to. Parameters are read as
+//
+//	qs := r.URL.Query()
+//	folder := qs.Get("folder") // note
+//
+// or with r.URL.Query().Get, r.FormValue or qs["name"], and typed by the
+// function converting the value, if any.
+func handlerParams(pkg *stsource.Package) map[string][]param {
+	funcs := make(map[string]*queryFunc)
+	for _, f := range pkg.SortedFiles() {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			funcs[fn.Name.Name] = readQuery(pkg, f, fn)
+		}
+	}
+
+	res := make(map[string][]param)
+	for name := range funcs {
+		res[name] = resolveParams(funcs, name, make(map[string]bool))
+	}
+	return res
+}
+
+func resolveParams(funcs map[string]*queryFunc, name string, seen map[string]bool) []param {
+	qf, ok := funcs[name]
+	if !ok || seen[name] {
+		return nil
+	}
+	seen[name] = true
+	params := append([]param(nil), qf.params...)
+	for _, callee := range qf.calls {
+		for _, p := range resolveParams(funcs, callee, seen) {
+			if indexParam(params, p.Name) < 0 {
+				params = append(params, p)
+			}
+		}
+	}
+	return params
+}
+
+func readQuery(pkg *stsource.Package, f *ast.File, fn *ast.FuncDecl) *queryFunc {
+	qf := new(queryFunc)
+	requests := make(map[string]bool) // *http.Request variables
+	queries := make(map[string]bool)  // url.Values variables
+	values := make(map[string]string) // variables holding a parameter value
+	addSources(fn.Type, requests, queries)
+
+	add := func(name, typ string, n ast.Node) {
+		if i := indexParam(qf.params, name); i >= 0 {
+			if qf.params[i].Type == typeString {
+				qf.params[i].Type = typ
+			}
+			return
+		}
+		note := sentence(pkg.LineComment(f, n))
+		if note == "" {
+			note = paramNotes[name]
+		}
+		qf.params = append(qf.params, param{Name: name, Optional: true, Type: typ, Note: note})
+	}
+	isQuery := func(e ast.Expr) bool {
+		if id, ok := e.(*ast.Ident); ok {
+			return queries[id.Name]
+		}
+		return isQueryCall(e, requests)
+	}
+
+	var stack []ast.Node
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		var parent ast.Node
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+		stack = append(stack, n)
+
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Handlers returned by a constructor.
+			addSources(n.Type, requests, queries)
+
+		case *ast.AssignStmt:
+			for i, rhs := range n.Rhs {
+				id, ok := n.Lhs[i].(*ast.Ident)
+				if !ok || len(n.Lhs) != len(n.Rhs) {
+					continue
+				}
+				if isQueryCall(rhs, requests) {
+					queries[id.Name] = true
+				} else if name, ok := queryRead(rhs, isQuery, requests); ok {
+					values[id.Name] = name
+				}
+			}
+
+		case *ast.IndexExpr:
+			if !isQuery(n.X) {
+				break
+			}
+			if name, ok := stsource.StringLit(n.Index); ok {
+				add(name, typeRepeated, n)
+			}
+
+		case *ast.CallExpr:
+			if name, ok := queryRead(n, isQuery, requests); ok {
+				add(name, contextType(parent, n), n)
+				break
+			}
+			typ, converts := converters[calleeName(n.Fun)]
+			for _, arg := range n.Args {
+				id, ok := arg.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				if name, ok := values[id.Name]; ok && converts {
+					add(name, typ, n)
+				}
+				if requests[id.Name] || queries[id.Name] {
+					qf.calls = append(qf.calls, shortName(n.Fun))
+				}
+			}
+		}
+		return true
+	})
+	return qf
+}
+
+// addSources records the parameters of a function that are a request or
+// a query.
+func addSources(ft *ast.FuncType, requests, queries map[string]bool) {
+	for _, field := range ft.Params.List {
+		var target map[string]bool
+		switch typeName(field.Type) {
+		case "*http.Request":
+			target = requests
+		case "url.Values":
+			target = queries
+		default:
+			continue
+		}
+		for _, name := range field.Names {
+			target[name.Name] = true
+		}
+	}
+}
+
+func typeName(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.StarExpr:
+		return "*" + typeName(e.X)
+	case *ast.SelectorExpr:
+		return typeName(e.X) + "." + e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// isQueryCall recognises r.URL.Query() for a request r.
+func isQueryCall(e ast.Expr, requests map[string]bool) bool {
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Query" {
+		return false
+	}
+	u, ok := sel.X.(*ast.SelectorExpr)
+	if !ok || u.Sel.Name != "URL" {
+		return false
+	}
+	id, ok := u.X.(*ast.Ident)
+	return ok && requests[id.Name]
+}
+
+// queryRead recognises reading a parameter, returning its name.
+func queryRead(e ast.Expr, isQuery func(ast.Expr) bool, requests map[string]bool) (string, bool) {
+	call, ok := e.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	switch sel.Sel.Name {
+	case "Get", "Has":
+		if !isQuery(sel.X) {
+			return "", false
+		}
+	case "FormValue":
+		id, ok := sel.X.(*ast.Ident)
+		if !ok || !requests[id.Name] {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return stsource.StringLit(call.Args[0])
+}
+
+// contextType returns the type of a parameter from the expression reading
+// it: the function converting it, or a comparison to the empty string.
+func contextType(parent ast.Node, read *ast.CallExpr) string {
+	switch p := parent.(type) {
+	case *ast.CallExpr:
+		if typ, ok := converters[calleeName(p.Fun)]; ok {
+			return typ
+		}
+	case *ast.BinaryExpr:
+		if s, ok := stsource.StringLit(p.Y); ok && s == "" {
+			return typeFlag
+		}
+	}
+	if sel, ok := read.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Has" {
+		return typeFlag
+	}
+	return typeString
+}
+
+// calleeName returns the package qualified name of a function, such as
+// strconv.Atoi.
+func calleeName(e ast.Expr) string {
+	sel, ok := e.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	return pkg.Name + "." + sel.Sel.Name
+}
+
+// shortName returns the name of a called function or method, as keyed in
+// the package.
+func shortName(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// sentence capitalises a comment and ends it with a period.
+func sentence(s string) string {
+	if s == "" {
+		return ""
+	}
+	s = strings.ToUpper(s[:1]) + s[1:]
+	if !strings.HasSuffix(s, ".") {
+		s += "."
+	}
+	return s
+}
+
+func indexParam(params []param, name string) int {
+	for i, p := range params {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeParams combines the parameters read by the handler with the
+// annotation of the registration, which says which are required. The
+// handler's are used when found, as the annotations go stale.
+func mergeParams(read, annotated []param) []param {
+	if len(read) == 0 {
+		return annotated
+	}
+	params := append([]param(nil), read...)
+	for i := range params {
+		if j := indexParam(annotated, params[i].Name); j >= 0 {
+			params[i].Optional = annotated[j].Optional
+		}
+	}
+	// Required parameters first, as in the annotations.
+	sort.SliceStable(params, func(a, b int) bool {
+		return !params[a].Optional && params[b].Optional
+	})
+	return params
+}
@@ -0,0 +1,315 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/ast"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// apiDir is the package registering the REST routes.
+const apiDir = "lib/api"
+
+type param struct {
+	Name     string
+	Optional bool
+	Type     string // empty when only annotated
+	Note     string
+}
+
+type endpoint struct {
+	Method  string // empty when the handler accepts any method
+	Path    string
+	Params  []param
+	Handler string
+	Doc     string
+	Source  string // file:line of the registration
+}
+
+// NoAuth returns true for the endpoints that don't require an API key or
+// session.
+func (e endpoint) NoAuth() bool {
+	return strings.HasPrefix(e.Path, "/rest/noauth/")
+}
+
+// routeTemplate is a registration inside a helper function whose path is
+// built from one of the helper's parameters, such as the config routes.
+type routeTemplate struct {
+	endpoint
+	arg    int    // index of the path parameter
+	suffix string // appended to the parameter value
+}
+
+var methodNames = map[string]string{
+	"MethodGet":    http.MethodGet,
+	"MethodPost":   http.MethodPost,
+	"MethodPut":    http.MethodPut,
+	"MethodPatch":  http.MethodPatch,
+	"MethodDelete": http.MethodDelete,
+}
+
+// extractRoutes finds the REST route registrations in the API package.
+// The routes are registered on an httprouter with
+//
+//	mux.HandlerFunc(http.MethodGet, "/rest/...", s.handler) // [param] ...
+//
+// or on a plain ServeMux without a method (the debug endpoints).
+func extractRoutes(pkg *stsource.Package) []endpoint {
+	docs := handlerDocs(pkg)
+	read := handlerParams(pkg)
+
+	var eps []endpoint
+	templates := make(map[string][]routeTemplate)
+	for _, f := range pkg.SortedFiles() {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			params := funcParams(fn)
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				ep, pathExpr, ok := registration(call)
+				if !ok {
+					return true
+				}
+				file, line := pkg.Position(call)
+				ep.Source = file + ":" + strconv.Itoa(line)
+				ep.Params = mergeParams(read[ep.Handler], parseParams(pkg.LineComment(f, call)))
+				ep.Doc = docs[ep.Handler]
+
+				if path, ok := stsource.StringLit(pathExpr); ok {
+					ep.Path = path
+					// The catch-alls dispatch to the other muxes.
+					if strings.HasPrefix(path, "/rest/") && path != "/rest/" && !strings.Contains(path, "/*") {
+						eps = append(eps, ep)
+					}
+					return true
+				}
+				// path + "/suffix" inside a helper
+				if arg, suffix, ok := paramPath(pathExpr, params); ok {
+					templates[fn.Name.Name] = append(templates[fn.Name.Name], routeTemplate{endpoint: ep, arg: arg, suffix: suffix})
+				}
+				return true
+			})
+		}
+	}
+
+	// Expand the templates at the helpers' call sites.
+	for _, f := range pkg.SortedFiles() {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			for _, t := range templates[sel.Sel.Name] {
+				if t.arg >= len(call.Args) {
+					continue
+				}
+				base, ok := stsource.StringLit(call.Args[t.arg])
+				if !ok {
+					continue
+				}
+				ep := t.endpoint
+				ep.Path = base + t.suffix
+				if ep.Doc == "" {
+					ep.Doc = pkg.LineComment(f, call)
+				}
+				eps = append(eps, ep)
+			}
+			return true
+		})
+	}
+
+	sort.SliceStable(eps, func(a, b int) bool {
+		if eps[a].Path != eps[b].Path {
+			return eps[a].Path < eps[b].Path
+		}
+		return eps[a].Method < eps[b].Method
+	})
+	return dedupe(eps)
+}
+
+// registration recognises a route registration call, returning the
+// endpoint without its path and the path expression.
+func registration(call *ast.CallExpr) (endpoint, ast.Expr, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return endpoint{}, nil, false
+	}
+	var ep endpoint
+	var pathExpr, handler ast.Expr
+	switch sel.Sel.Name {
+	case "HandlerFunc", "Handle", "Handler":
+		if len(call.Args) == 3 {
+			method, ok := methodName(call.Args[0])
+			if !ok {
+				return endpoint{}, nil, false
+			}
+			ep.Method = method
+			pathExpr, handler = call.Args[1], call.Args[2]
+			break
+		}
+		if len(call.Args) != 2 {
+			return endpoint{}, nil, false
+		}
+		pathExpr, handler = call.Args[0], call.Args[1]
+	case "HandleFunc":
+		if len(call.Args) != 2 {
+			return endpoint{}, nil, false
+		}
+		pathExpr, handler = call.Args[0], call.Args[1]
+	default:
+		return endpoint{}, nil, false
+	}
+	ep.Handler = handlerName(handler)
+	return ep, pathExpr, true
+}
+
+func methodName(e ast.Expr) (string, bool) {
+	if sel, ok := e.(*ast.SelectorExpr); ok {
+		m, ok := methodNames[sel.Sel.Name]
+		return m, ok
+	}
+	if s, ok := stsource.StringLit(e); ok {
+		return strings.ToUpper(s), true
+	}
+	return "", false
+}
+
+// handlerName returns the name of the handler method, looking through
+// wrappers such as s.whenDebugging(s.getFoo).
+func handlerName(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	case *ast.CallExpr:
+		for _, arg := range e.Args {
+			// Not the arguments of constructors, such as
+			// s.makeDevicePauseHandler(true).
+			if _, ok := arg.(*ast.Ident); ok {
+				continue
+			}
+			if name := handlerName(arg); name != "" {
+				return name
+			}
+		}
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok {
+			return sel.Sel.Name
+		}
+	}
+	return ""
+}
+
+func funcParams(fn *ast.FuncDecl) []string {
+	var names []string
+	for _, field := range fn.Type.Params.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// paramPath recognises param + "suffix" where param is one of the
+// function's parameters.
+func paramPath(e ast.Expr, params []string) (int, string, bool) {
+	var id *ast.Ident
+	suffix := ""
+	switch e := e.(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.BinaryExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok {
+			return 0, "", false
+		}
+		s, ok := stsource.StringLit(e.Y)
+		if !ok {
+			return 0, "", false
+		}
+		id, suffix = x, s
+	default:
+		return 0, "", false
+	}
+	for i, p := range params {
+		if p == id.Name {
+			return i, suffix, true
+		}
+	}
+	return 0, "", false
+}
+
+// handlerDocs returns the doc comments of the package's methods and
+// functions, by name.
+func handlerDocs(pkg *stsource.Package) map[string]string {
+	docs := make(map[string]string)
+	for _, f := range pkg.SortedFiles() {
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				if doc := stsource.DocText(fn.Doc); doc != "" {
+					docs[fn.Name.Name] = doc
+				}
+			}
+		}
+	}
+	return docs
+}
+
+var paramExp = regexp.MustCompile(`^\[?([a-zA-Z][a-zA-Z0-9_]*)(?:\.\.\.)?\]?$`)
+
+// parseParams parses the parameter annotation following a registration,
+// such as "folder [device]" where bracketed parameters are optional and
+// "-" means none. A request body, "<body>", and remarks in parentheses
+// are skipped. Comments that aren't annotations give no parameters.
+func parseParams(comment string) []param {
+	fields := strings.Fields(comment)
+	if len(fields) == 0 || fields[0] == "-" {
+		return nil
+	}
+	params := make([]param, 0, len(fields))
+	for _, f := range fields {
+		if strings.HasPrefix(f, "(") {
+			break
+		}
+		if strings.HasPrefix(f, "<") && strings.HasSuffix(f, ">") {
+			continue
+		}
+		m := paramExp.FindStringSubmatch(f)
+		if m == nil || (strings.HasPrefix(f, "[") != strings.HasSuffix(f, "]")) {
+			return nil
+		}
+		params = append(params, param{Name: m[1], Optional: strings.HasPrefix(f, "[")})
+	}
+	return params
+}
+
+func dedupe(eps []endpoint) []endpoint {
+	out := eps[:0]
+	for i, ep := range eps {
+		if i > 0 && ep.Path == eps[i-1].Path && ep.Method == eps[i-1].Method {
+			continue
+		}
+		out = append(out, ep)
+	}
+	return out
+}
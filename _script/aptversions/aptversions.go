@@ -0,0 +1,374 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./aptversions > ../includes/apt-versions.rst
+//        go run ./aptversions -check
+//
+// This script reads the package indices of the APT repository and prints
+// a table of what each channel offers per suite and architecture. With
+// -check it instead compares the channels to the latest GitHub releases
+// and exits non-zero if they have drifted apart.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/httpclient"
+	"syncthing.net/docs/internal/rst"
+)
+
+// channelVersions maps suite -> component -> architecture -> newest
+// version of the package.
+type channelVersions map[string]map[string]map[string]string
+
+func main() {
+	repoURL := flag.String("url", "https://apt.syncthing.net/", "APT repository base URL")
+	suites := flag.String("suites", "syncthing", "Comma separated list of suites (distribution codenames)")
+	pkg := flag.String("package", "syncthing", "Package to report on")
+	check := flag.Bool("check", false, "Compare the channels against GitHub releases instead of printing a table")
+	flag.Parse()
+
+	base := strings.TrimSuffix(*repoURL, "/")
+	versions := make(channelVersions)
+	for _, suite := range strings.Split(*suites, ",") {
+		comps, arches, err := readRelease(base, suite)
+		if err != nil {
+			log.Fatalln("Reading release file:", err)
+		}
+		versions[suite] = make(map[string]map[string]string)
+		for _, comp := range comps {
+			versions[suite][comp] = make(map[string]string)
+			for _, arch := range arches {
+				v, err := newestVersion(base, suite, comp, arch, *pkg)
+				if err != nil {
+					log.Printf("%s/%s/%s: %v", suite, comp, arch, err)
+					continue
+				}
+				if v != "" {
+					versions[suite][comp][arch] = v
+				}
+			}
+		}
+	}
+
+	if *check {
+		problems, err := checkDrift(versions)
+		if err != nil {
+			log.Fatalln("Checking against GitHub:", err)
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	printTable(versions)
+}
+
+// readRelease returns the components and architectures listed in the
+// suite's Release file.
+func readRelease(base, suite string) (comps, arches []string, err error) {
+	body, err := get(fmt.Sprintf("%s/dists/%s/Release", base, suite))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close()
+	err = readStanzas(body, func(fields map[string]string) {
+		if v, ok := fields["Components"]; ok {
+			comps = strings.Fields(v)
+		}
+		if v, ok := fields["Architectures"]; ok {
+			for _, a := range strings.Fields(v) {
+				if a != "all" && a != "source" {
+					arches = append(arches, a)
+				}
+			}
+		}
+	})
+	if err == nil && (len(comps) == 0 || len(arches) == 0) {
+		err = fmt.Errorf("%s: no components or architectures listed", suite)
+	}
+	return comps, arches, err
+}
+
+// newestVersion returns the highest version of the package in the given
+// index, or the empty string if it's not there.
+func newestVersion(base, suite, comp, arch, pkg string) (string, error) {
+	url := fmt.Sprintf("%s/dists/%s/%s/binary-%s/Packages.gz", base, suite, comp, arch)
+	body, err := get(url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	gr, err := gzip.NewReader(body)
+	if err != nil {
+		return "", err
+	}
+
+	newest := ""
+	err = readStanzas(gr, func(fields map[string]string) {
+		if fields["Package"] != pkg {
+			return
+		}
+		if v := fields["Version"]; newest == "" || compareDebian(v, newest) > 0 {
+			newest = v
+		}
+	})
+	return newest, err
+}
+
+// readStanzas parses a Debian control file, calling fn for each
+// paragraph. Continuation lines are appended to the preceding field.
+func readStanzas(r io.Reader, fn func(map[string]string)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	fields := make(map[string]string)
+	last := ""
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			if len(fields) > 0 {
+				fn(fields)
+				fields = make(map[string]string)
+			}
+		case line[0] == ' ' || line[0] == '\t':
+			if last != "" {
+				fields[last] += "\n" + strings.TrimSpace(line)
+			}
+		default:
+			k, v, ok := strings.Cut(line, ":")
+			if ok {
+				last = k
+				fields[k] = strings.TrimSpace(v)
+			}
+		}
+	}
+	if len(fields) > 0 {
+		fn(fields)
+	}
+	return sc.Err()
+}
+
+func printTable(versions channelVersions) {
+	fmt.Println(".. This file is generated by _script/aptversions; do not edit.")
+	fmt.Println()
+	for _, suite := range sortedKeys(versions) {
+		if len(versions) > 1 {
+			// Below the APT section of the releases page.
+			fmt.Print(rst.Heading("Suite "+rst.Literal(suite), '"'))
+		}
+		var arches []string
+		seen := make(map[string]bool)
+		for _, comp := range versions[suite] {
+			for arch := range comp {
+				if !seen[arch] {
+					seen[arch] = true
+					arches = append(arches, arch)
+				}
+			}
+		}
+		sort.Strings(arches)
+
+		t := rst.Table{
+			Title:  "Packages in the " + rst.Literal(suite) + " suite",
+			Header: append([]string{"Channel"}, arches...),
+		}
+		for _, comp := range sortedKeys(versions[suite]) {
+			row := []string{rst.Literal(comp)}
+			for _, arch := range arches {
+				row = append(row, versions[suite][comp][arch])
+			}
+			t.Rows = append(t.Rows, row)
+		}
+		if _, err := t.WriteTo(os.Stdout); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+// checkDrift compares the stable and candidate channels against the
+// latest and latest pre-release GitHub releases.
+func checkDrift(versions channelVersions) ([]string, error) {
+	var releases []struct {
+		TagName    string `json:"tag_name"`
+		Prerelease bool   `json:"prerelease"`
+	}
+	body, err := get("https://api.github.com/repos/syncthing/syncthing/releases?per_page=20")
+	if err != nil {
+		return nil, err
+	}
+	err = json.NewDecoder(body).Decode(&releases)
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[string]string)
+	for _, rel := range releases {
+		v := debianVersion(rel.TagName)
+		if !rel.Prerelease && (expected["stable"] == "" || compareDebian(v, expected["stable"]) > 0) {
+			expected["stable"] = v
+		}
+		if expected["candidate"] == "" || compareDebian(v, expected["candidate"]) > 0 {
+			expected["candidate"] = v
+		}
+	}
+
+	var problems []string
+	for _, suite := range sortedKeys(versions) {
+		for _, comp := range sortedKeys(versions[suite]) {
+			want, ok := expected[comp]
+			if !ok {
+				continue
+			}
+			for _, arch := range sortedKeys(versions[suite][comp]) {
+				if have := versions[suite][comp][arch]; compareDebian(have, want) < 0 {
+					problems = append(problems, fmt.Sprintf("%s/%s/%s: has %s, GitHub has %s", suite, comp, arch, have, want))
+				}
+			}
+		}
+	}
+	return problems, nil
+}
+
+// debianVersion converts a release tag into the corresponding package
+// version: v1.28.0-rc.1 becomes 1.28.0~rc.1.
+func debianVersion(tag string) string {
+	return strings.Replace(strings.TrimPrefix(tag, "v"), "-", "~", 1)
+}
+
+// compareDebian compares package versions using the dpkg algorithm.
+func compareDebian(a, b string) int {
+	aEpoch, aRest := splitEpoch(a)
+	bEpoch, bRest := splitEpoch(b)
+	if aEpoch != bEpoch {
+		return aEpoch - bEpoch
+	}
+	aUp, aRev := splitRevision(aRest)
+	bUp, bRev := splitRevision(bRest)
+	if c := compareDebianPart(aUp, bUp); c != 0 {
+		return c
+	}
+	return compareDebianPart(aRev, bRev)
+}
+
+func splitEpoch(v string) (int, string) {
+	if e, rest, ok := strings.Cut(v, ":"); ok {
+		n := 0
+		fmt.Sscanf(e, "%d", &n)
+		return n, rest
+	}
+	return 0, v
+}
+
+func splitRevision(v string) (string, string) {
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		return v[:i], v[i+1:]
+	}
+	return v, ""
+}
+
+func compareDebianPart(a, b string) int {
+	for a != "" || b != "" {
+		// Non-digit prefix, compared by the modified lexical ordering.
+		var an, bn string
+		an, a = splitFunc(a, isNotDigit)
+		bn, b = splitFunc(b, isNotDigit)
+		if c := compareLexical(an, bn); c != 0 {
+			return c
+		}
+		// Digit prefix, compared numerically.
+		var ad, bd string
+		ad, a = splitFunc(a, isDigit)
+		bd, b = splitFunc(b, isDigit)
+		if c := compareNumeric(ad, bd); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func isDigit(c byte) bool    { return c >= '0' && c <= '9' }
+func isNotDigit(c byte) bool { return !isDigit(c) }
+
+func splitFunc(s string, fn func(byte) bool) (string, string) {
+	i := 0
+	for i < len(s) && fn(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// lexicalOrder gives tilde sorting before everything (even the end of the
+// string), then the end of the string, then letters, then other
+// characters.
+func lexicalOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	c := s[i]
+	switch {
+	case c == '~':
+		return -1
+	case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+func compareLexical(a, b string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if d := lexicalOrder(a, i) - lexicalOrder(b, i); d != 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func compareNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func get(url string) (io.ReadCloser, error) {
+	resp, err := httpclient.Default.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,281 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./benchreport [-add] [results.json...] > ../includes/benchmark-results.rst
+//
+// Writes the performance page tables from the results of the periodic
+// benchmark runs, kept as one JSON file per run in users/benchmarks: how
+// the latest release compares to the one before it, and the history of
+// each metric per release. With -add, the given result files are checked
+// and copied into the results directory first. The graph data, each
+// metric per scenario and release, is written as JSON to -graph for
+// plotting.
+//
+// A results file looks like this, with any of the metrics left out when
+// not measured:
+//
+//	{
+//	  "version": "v1.27.0",
+//	  "date": "2024-01-02T03:04:05Z",
+//	  "host": "bench-1",
+//	  "scenarios": [
+//	    {"name": "10k-small-files", "syncThroughputMiBs": 45.2, "scanSeconds": 3.1, "peakMemoryMiB": 120}
+//	  ]
+//	}
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/relnotes"
+)
+
+// run is the results of one benchmark run.
+type run struct {
+	Version   string     `json:"version"`
+	Date      time.Time  `json:"date"`
+	Host      string     `json:"host"`
+	Scenarios []scenario `json:"scenarios"`
+}
+
+// scenario is the results of a benchmark scenario. Metrics that weren't
+// measured are nil.
+type scenario struct {
+	Name               string   `json:"name"`
+	SyncThroughputMiBs *float64 `json:"syncThroughputMiBs,omitempty"`
+	ScanSeconds        *float64 `json:"scanSeconds,omitempty"`
+	PeakMemoryMiB      *float64 `json:"peakMemoryMiB,omitempty"`
+}
+
+// metric is a measurement reported on the page.
+type metric struct {
+	key   string
+	title string
+	unit  string
+	// higherBetter is whether an increase is an improvement.
+	higherBetter bool
+	value        func(scenario) *float64
+}
+
+var metrics = []metric{
+	{"syncThroughput", "Sync Throughput", "MiB/s", true, func(s scenario) *float64 { return s.SyncThroughputMiBs }},
+	{"scanTime", "Scan Time", "s", false, func(s scenario) *float64 { return s.ScanSeconds }},
+	{"peakMemory", "Peak Memory", "MiB", false, func(s scenario) *float64 { return s.PeakMemoryMiB }},
+}
+
+func main() {
+	log.SetFlags(0)
+	dir := flag.String("results", "../users/benchmarks", "Directory of benchmark results")
+	add := flag.Bool("add", false, "Add the result files given as arguments to the results directory")
+	graph := flag.String("graph", "../_static/benchmarks.json", "File to write the graph data to, or empty for none")
+	flag.Parse()
+
+	if *add {
+		for _, file := range flag.Args() {
+			if err := addRun(*dir, file); err != nil {
+				log.Fatalln(err)
+			}
+		}
+	}
+
+	runs, err := loadRuns(*dir)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	h := newHistory(runs)
+	if *graph != "" {
+		if err := writeGraph(*graph, h); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if err := writeReport(os.Stdout, h); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// addRun checks the results file and copies it into the results
+// directory, named after the version and date of the run.
+func addRun(dir, file string) error {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	r, err := parseRun(bs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	name := fmt.Sprintf("%s-%s-%s.json", r.Version, r.Date.UTC().Format("20060102T150405"), r.Host)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name), bs, 0o644)
+}
+
+// loadRuns reads the results in the directory. A missing directory has
+// no results.
+func loadRuns(dir string) ([]run, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var runs []run
+	for _, file := range files {
+		bs, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		r, err := parseRun(bs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, nil
+}
+
+func parseRun(bs []byte) (run, error) {
+	var r run
+	if err := json.Unmarshal(bs, &r); err != nil {
+		return r, err
+	}
+	if _, ok := relnotes.ParseVersion(r.Version); !ok {
+		return r, fmt.Errorf("bad version %q", r.Version)
+	}
+	if r.Date.IsZero() {
+		return r, errors.New("missing date")
+	}
+	if r.Host == "" {
+		return r, errors.New("missing host")
+	}
+	for _, s := range r.Scenarios {
+		if s.Name == "" {
+			return r, errors.New("scenario without a name")
+		}
+	}
+	return r, nil
+}
+
+// history is the results per release, with the median of the runs of
+// each release.
+type history struct {
+	// versions are the releases with results, newest first.
+	versions  []relnotes.Version
+	scenarios []string
+	// values are by metric key, scenario and release.
+	values map[string]map[string]map[relnotes.Version]float64
+	runs   map[relnotes.Version]int
+}
+
+func newHistory(runs []run) *history {
+	samples := make(map[string]map[string]map[relnotes.Version][]float64)
+	h := &history{
+		values: make(map[string]map[string]map[relnotes.Version]float64),
+		runs:   make(map[relnotes.Version]int),
+	}
+	seenScenario := make(map[string]bool)
+	for _, r := range runs {
+		v, _ := relnotes.ParseVersion(r.Version)
+		if h.runs[v] == 0 {
+			h.versions = append(h.versions, v)
+		}
+		h.runs[v]++
+		for _, s := range r.Scenarios {
+			if !seenScenario[s.Name] {
+				seenScenario[s.Name] = true
+				h.scenarios = append(h.scenarios, s.Name)
+			}
+			for _, m := range metrics {
+				val := m.value(s)
+				if val == nil {
+					continue
+				}
+				if samples[m.key] == nil {
+					samples[m.key] = make(map[string]map[relnotes.Version][]float64)
+				}
+				if samples[m.key][s.Name] == nil {
+					samples[m.key][s.Name] = make(map[relnotes.Version][]float64)
+				}
+				samples[m.key][s.Name][v] = append(samples[m.key][s.Name][v], *val)
+			}
+		}
+	}
+	sort.Slice(h.versions, func(a, b int) bool { return h.versions[b].Less(h.versions[a]) })
+	sort.Strings(h.scenarios)
+	for key, byScenario := range samples {
+		h.values[key] = make(map[string]map[relnotes.Version]float64)
+		for name, byVersion := range byScenario {
+			h.values[key][name] = make(map[relnotes.Version]float64)
+			for v, vals := range byVersion {
+				h.values[key][name][v] = median(vals)
+			}
+		}
+	}
+	return h
+}
+
+// value returns the median of the metric for the scenario in the
+// release, if measured.
+func (h *history) value(m metric, scenario string, v relnotes.Version) (float64, bool) {
+	val, ok := h.values[m.key][scenario][v]
+	return val, ok
+}
+
+func median(vals []float64) float64 {
+	sort.Float64s(vals)
+	n := len(vals)
+	if n%2 == 1 {
+		return vals[n/2]
+	}
+	return (vals[n/2-1] + vals[n/2]) / 2
+}
+
+// writeGraph writes the history as series per metric and scenario, oldest
+// release first, for plotting.
+func writeGraph(file string, h *history) error {
+	type point struct {
+		Version string  `json:"version"`
+		Value   float64 `json:"value"`
+	}
+	type series struct {
+		Metric   string  `json:"metric"`
+		Unit     string  `json:"unit"`
+		Scenario string  `json:"scenario"`
+		Points   []point `json:"points"`
+	}
+	res := []series{}
+	for _, m := range metrics {
+		for _, name := range h.scenarios {
+			s := series{Metric: m.key, Unit: m.unit, Scenario: name}
+			for i := len(h.versions) - 1; i >= 0; i-- {
+				if val, ok := h.value(m, name, h.versions[i]); ok {
+					s.Points = append(s.Points, point{h.versions[i].String(), val})
+				}
+			}
+			if len(s.Points) > 0 {
+				res = append(res, s)
+			}
+		}
+	}
+	bs, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(bs, '\n'), 0o644)
+}
+
+func formatValue(val float64) string {
+	s := fmt.Sprintf("%.1f", val)
+	return strings.TrimSuffix(s, ".0")
+}
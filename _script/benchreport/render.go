@@ -0,0 +1,141 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+)
+
+// significant is the relative change, in percent, below which a
+// difference is considered noise.
+const significant = 5
+
+// writeReport writes the comparison of the latest two releases and the
+// history of each metric.
+func writeReport(w io.Writer, h *history) error {
+	fmt.Fprintln(w, ".. This file is generated by _script/benchreport; do not edit.")
+	fmt.Fprintln(w)
+	if len(h.versions) == 0 {
+		fmt.Fprintln(w, "There are no benchmark results yet.")
+		return nil
+	}
+
+	fmt.Fprint(w, rst.Heading("Latest Release", '-'))
+	latest := h.versions[0]
+	if len(h.versions) == 1 {
+		fmt.Fprintf(w, "Results for %s, from %s. There are no earlier results to compare with.\n\n", latest, runCount(h.runs[latest]))
+	} else {
+		prev := h.versions[1]
+		fmt.Fprintf(w, "Results for %s compared to %s, from %s and %s respectively. Changes of less than %d%% are within the variation between runs.\n\n",
+			latest, prev, runCount(h.runs[latest]), runCount(h.runs[prev]), significant)
+		if err := writeComparison(w, h, latest, prev); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(w, rst.Heading("History", '-'))
+	for _, m := range metrics {
+		if len(h.values[m.key]) == 0 {
+			continue
+		}
+		if err := writeMetricHistory(w, h, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeComparison(w io.Writer, h *history, latest, prev relnotes.Version) error {
+	tbl := rst.Table{
+		Title:  fmt.Sprintf("%s Compared to %s", latest, prev),
+		Header: []string{"Scenario", "Metric", prev.String(), latest.String(), "Change"},
+		Widths: []int{30, 20, 15, 15, 20},
+	}
+	for _, name := range h.scenarios {
+		for _, m := range metrics {
+			cur, okCur := h.value(m, name, latest)
+			old, okOld := h.value(m, name, prev)
+			if !okCur && !okOld {
+				continue
+			}
+			row := []string{rst.Escape(name), fmt.Sprintf("%s (%s)", m.title, m.unit), "-", "-", "-"}
+			if okOld {
+				row[2] = formatValue(old)
+			}
+			if okCur {
+				row[3] = formatValue(cur)
+			}
+			if okCur && okOld {
+				row[4] = change(m, old, cur)
+			}
+			tbl.Rows = append(tbl.Rows, row)
+		}
+	}
+	_, err := tbl.WriteTo(w)
+	return err
+}
+
+// change describes the relative change from old to cur.
+func change(m metric, old, cur float64) string {
+	if old == 0 {
+		return "-"
+	}
+	pct := (cur - old) / old * 100
+	if math.Abs(pct) < significant {
+		return fmt.Sprintf("%+.1f%%", pct)
+	}
+	if (pct > 0) == m.higherBetter {
+		return fmt.Sprintf("%+.1f%% (better)", pct)
+	}
+	return fmt.Sprintf("%+.1f%% (worse)", pct)
+}
+
+// writeMetricHistory writes a table of the metric with a row per release
+// and a column per scenario it was measured in.
+func writeMetricHistory(w io.Writer, h *history, m metric) error {
+	tbl := rst.Table{
+		Title:  fmt.Sprintf("%s (%s)", m.title, m.unit),
+		Header: []string{"Release"},
+	}
+	var scenarios []string
+	for _, name := range h.scenarios {
+		if len(h.values[m.key][name]) > 0 {
+			scenarios = append(scenarios, name)
+			tbl.Header = append(tbl.Header, rst.Escape(name))
+		}
+	}
+	for _, v := range h.versions {
+		row := []string{v.String()}
+		measured := false
+		for _, name := range scenarios {
+			val, ok := h.value(m, name, v)
+			if !ok {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, formatValue(val))
+			measured = true
+		}
+		if measured {
+			tbl.Rows = append(tbl.Rows, row)
+		}
+	}
+	_, err := tbl.WriteTo(w)
+	return err
+}
+
+func runCount(n int) string {
+	if n == 1 {
+		return "1 run"
+	}
+	return fmt.Sprintf("%d runs", n)
+}
@@ -0,0 +1,435 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./build [-tag v1.27.0 | -src dir] [-network] [-n] [-force] [stage...]
+//
+// Builds the docs: runs the generators writing includes and data files,
+// then Sphinx, then the post-processing of the HTML output (search index,
+// redirect pages, sitemap). Only the given stages and those they depend
+// on are considered, or all of them without arguments; -list lists them.
+// The PDF and EPUB manual needs LaTeX and is only built when asked for,
+// with the manual stage, which publishes them in the HTML output.
+//
+// Forks and the website add stages of their own in -extensions, a JSON
+// array of stages with a name, the command run in the docs root, and
+// optionally the stdout, inputs, exclude, outputs, deps and network
+// settings of the built in stages. Stages of the "generate" phase, the
+// default, run before Sphinx; those of the "postprocess" phase after it,
+// on its output.
+//
+// The translations listed in -languages are built each into their own
+// directory, html-<code>, from gettext catalogs in _locale/<code> or a
+// translated copy of the sources in _translations/<code>. A language is
+// only built once enough of it is translated: the share of the messages
+// in its catalogs, or of the documents in its copy. The languages built
+// are listed in languages.json in the HTML output for the language
+// switcher, which expects each on the site under /<code>/.
+//
+// A stage runs when its inputs or command changed since it last
+// succeeded, when one of its outputs is missing, or when a stage it
+// depends on ran. The state is kept in the build directory. Stages
+// generating from the Syncthing source are skipped without -tag or -src,
+// and those reading from the network, such as the versions table, without
+// -network, leaving their checked in output as is.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const stateFile = ".buildstate.json"
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	buildDir := flag.String("builddir", "_build", "Build directory, relative to the root")
+	sphinx := flag.String("sphinx", "sphinx-build", "Sphinx build command")
+	tag := flag.String("tag", "", "Syncthing version to generate the source stages from")
+	src := flag.String("src", "", "Syncthing source directory to generate the source stages from")
+	network := flag.Bool("network", false, "Also run the stages reading from the network")
+	dryRun := flag.Bool("n", false, "Print the stages that would run without running them")
+	force := flag.Bool("force", false, "Run the stages even if they are up to date")
+	list := flag.Bool("list", false, "List the stages and exit")
+	languagesFile := flag.String("languages", "build/languages.json", "Translations to build")
+	extensionsFile := flag.String("extensions", "build/extensions.json", "Stages added by forks and the website, if the file exists")
+	flag.Parse()
+
+	if *tag != "" && *src != "" {
+		log.Fatalln("-tag and -src are exclusive")
+	}
+	if filepath.IsAbs(*buildDir) || strings.HasPrefix(filepath.Clean(*buildDir), "..") {
+		log.Fatalln("-builddir must be inside the root")
+	}
+	s := settings{BuildDir: filepath.ToSlash(filepath.Clean(*buildDir)), Sphinx: *sphinx}
+	var srcDir string
+	switch {
+	case *tag != "":
+		s.SourceArgs = []string{"-tag", *tag}
+	case *src != "":
+		abs, err := filepath.Abs(*src)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		srcDir = abs
+		s.SourceArgs = []string{"-src", abs}
+	}
+	langs, err := readLanguages(*languagesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := langs.measure(*root); err != nil {
+		log.Fatalln(err)
+	}
+	for _, l := range langs.Languages {
+		if l.Completeness < l.Threshold {
+			log.Printf("%s: not published, %d%% translated, below %d%%", l.Code, l.Completeness, l.Threshold)
+		}
+	}
+	s.Languages = langs.published()
+	if s.Generate, s.Postprocess, err = readExtensions(*extensionsFile); err != nil {
+		log.Fatalln(err)
+	}
+
+	all := stages(s)
+	if err := check(all); err != nil {
+		log.Fatalln(err)
+	}
+
+	if *list {
+		for _, st := range all {
+			opt := ""
+			if st.Optional {
+				opt = " (optional)"
+			}
+			fmt.Printf("%-18s %s%s\n", st.Name, strings.Join(st.Deps, ", "), opt)
+		}
+		return
+	}
+
+	selected, err := selectStages(all, flag.Args())
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	b := &builder{
+		root:    *root,
+		source:  len(s.SourceArgs) > 0,
+		srcDir:  srcDir,
+		dryRun:  *dryRun,
+		force:   *force,
+		network: *network,
+		state:   make(map[string]string),
+		ran:     make(map[string]bool),
+	}
+	b.stateFile = filepath.Join(b.root, filepath.FromSlash(s.BuildDir), stateFile)
+	if err := b.loadState(); err != nil {
+		log.Fatalln(err)
+	}
+	for _, st := range all {
+		if !selected[st.Name] {
+			continue
+		}
+		if err := b.run(st); err != nil {
+			log.Fatalf("%s: %v", st.Name, err)
+		}
+	}
+
+	if len(langs.Languages) > 0 && !b.dryRun {
+		manifest := filepath.Join(b.root, filepath.FromSlash(s.BuildDir), "html", "languages.json")
+		if err := writeManifest(manifest, s.Languages); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+// check checks that each stage comes after its dependencies.
+func check(stages []*stage) error {
+	seen := make(map[string]bool)
+	for _, st := range stages {
+		for _, d := range st.Deps {
+			if !seen[d] {
+				return fmt.Errorf("%s: dependency %s is not an earlier stage", st.Name, d)
+			}
+		}
+		seen[st.Name] = true
+	}
+	return nil
+}
+
+// selectStages returns the named stages and those they depend on, or
+// all of them when none are named.
+func selectStages(stages []*stage, names []string) (map[string]bool, error) {
+	byName := make(map[string]*stage)
+	for _, st := range stages {
+		byName[st.Name] = st
+	}
+	selected := make(map[string]bool)
+	if len(names) == 0 {
+		for _, st := range stages {
+			selected[st.Name] = !st.Optional
+		}
+		return selected, nil
+	}
+	var add func(name string) error
+	add = func(name string) error {
+		st, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("no stage %q (see -list)", name)
+		}
+		if selected[name] {
+			return nil
+		}
+		selected[name] = true
+		for _, d := range st.Deps {
+			if err := add(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range names {
+		if err := add(name); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
+
+type builder struct {
+	root string
+	// source is whether the Syncthing source was given, and srcDir the
+	// directory with -src.
+	source    bool
+	srcDir    string
+	dryRun    bool
+	force     bool
+	network   bool
+	stateFile string
+	// state is the fingerprint of each stage when it last succeeded.
+	state map[string]string
+	ran   map[string]bool
+}
+
+func (b *builder) run(st *stage) error {
+	switch {
+	case st.Source && !b.source:
+		log.Printf("%s: skipped, no -tag or -src", st.Name)
+		return nil
+	case st.Network && !b.network:
+		log.Printf("%s: skipped, no -network", st.Name)
+		return nil
+	}
+
+	inputs := st.Inputs
+	if st.Source && b.srcDir != "" {
+		inputs = append(inputs[:len(inputs):len(inputs)], b.srcDir)
+	}
+	fp, err := b.fingerprint(st, inputs)
+	if err != nil {
+		return err
+	}
+	reason, err := b.reason(st, fp)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		log.Printf("%s: up to date", st.Name)
+		return nil
+	}
+	log.Printf("%s: running, %s", st.Name, reason)
+	b.ran[st.Name] = true
+	if b.dryRun {
+		return nil
+	}
+
+	t0 := time.Now()
+	if err := b.exec(st); err != nil {
+		return err
+	}
+	log.Printf("%s: done in %v", st.Name, time.Since(t0).Round(time.Millisecond))
+
+	// The stage may have changed its own inputs, such as histver the
+	// versions table, so the fingerprint is taken again.
+	if fp, err = b.fingerprint(st, inputs); err != nil {
+		return err
+	}
+	b.state[st.Name] = fp
+	return b.saveState()
+}
+
+// reason returns why the stage needs to run, or the empty string if it
+// is up to date.
+func (b *builder) reason(st *stage, fp string) (string, error) {
+	if b.force {
+		return "forced", nil
+	}
+	for _, d := range st.Deps {
+		if b.ran[d] {
+			return d + " ran", nil
+		}
+	}
+	outputs := st.Outputs
+	if st.Stdout != "" {
+		outputs = append(outputs[:len(outputs):len(outputs)], st.Stdout)
+	}
+	for _, out := range outputs {
+		matches, err := filepath.Glob(filepath.Join(b.root, filepath.FromSlash(out)))
+		if err != nil {
+			return "", err
+		}
+		if len(matches) == 0 {
+			return out + " is missing", nil
+		}
+	}
+	switch prev, ok := b.state[st.Name]; {
+	case !ok:
+		return "not run before", nil
+	case prev != fp:
+		return "inputs changed", nil
+	}
+	return "", nil
+}
+
+// exec runs the command of the stage.
+func (b *builder) exec(st *stage) error {
+	if st.Func != nil {
+		return st.Func(b.root)
+	}
+	var cmd *exec.Cmd
+	if st.Tool != "" {
+		cmd = exec.Command("go", append([]string{"run", "./" + st.Tool}, st.Args...)...)
+		cmd.Dir = filepath.Join(b.root, "_script")
+	} else {
+		cmd = exec.Command(st.Args[0], st.Args[1:]...)
+		cmd.Dir = b.root
+	}
+	cmd.Stderr = os.Stderr
+	if st.Stdout == "" {
+		cmd.Stdout = os.Stdout
+		return cmd.Run()
+	}
+
+	// The output is only written once the command succeeded, so a
+	// failure doesn't leave a partial file behind.
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+	out := filepath.Join(b.root, filepath.FromSlash(st.Stdout))
+	tmp := out + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, out)
+}
+
+// fingerprint returns a hash of the command of the stage and the
+// contents of its inputs, including the tool and the packages shared by
+// the tools.
+func (b *builder) fingerprint(st *stage, inputs []string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %q %q\n", st.Tool, st.Args, st.Stdout)
+	if st.Tool != "" {
+		inputs = append([]string{"_script/" + st.Tool, "_script/internal", "_script/go.mod", "_script/go.sum"}, inputs...)
+	}
+	exclude := make(map[string]bool)
+	for _, ex := range st.Exclude {
+		exclude[filepath.Join(b.root, filepath.FromSlash(ex))] = true
+	}
+	for _, in := range inputs {
+		dir := in
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(b.root, filepath.FromSlash(in))
+		}
+		var files []string
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if exclude[p] || p != dir && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Type().IsRegular() {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(h, "%s missing\n", in)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(files)
+		for _, f := range files {
+			sum, err := hashFile(f)
+			if err != nil {
+				return "", err
+			}
+			rel, _ := filepath.Rel(dir, f)
+			fmt.Fprintf(h, "%s/%s %s\n", in, filepath.ToSlash(rel), sum)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (b *builder) loadState() error {
+	bs, err := os.ReadFile(b.stateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bs, &b.state); err != nil {
+		return fmt.Errorf("%s: %w", b.stateFile, err)
+	}
+	return nil
+}
+
+func (b *builder) saveState() error {
+	bs, err := json.MarshalIndent(b.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.stateFile), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(b.stateFile, append(bs, '\n'), 0o644)
+}
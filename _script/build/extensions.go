@@ -0,0 +1,79 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// extension is a stage added by the extensions file, for forks and the
+// website to generate or post-process more than the build does without
+// changing it. The command is run in the docs root, and the paths are
+// relative to it, as for the built in stages.
+type extension struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+	Stdout  string   `json:"stdout"`
+	Inputs  []string `json:"inputs"`
+	Exclude []string `json:"exclude"`
+	Outputs []string `json:"outputs"`
+	Deps    []string `json:"deps"`
+	Network bool     `json:"network"`
+	// Phase is "generate", for stages writing sources, which run before
+	// Sphinx, or "postprocess", for stages working on the HTML output,
+	// which run after it.
+	Phase string `json:"phase"`
+}
+
+const (
+	phaseGenerate    = "generate"
+	phasePostprocess = "postprocess"
+)
+
+// readExtensions returns the stages of the extensions file, by phase. The
+// file is optional.
+func readExtensions(name string) (generate, postprocess []*stage, err error) {
+	bs, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	var exts []extension
+	if err := json.Unmarshal(bs, &exts); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, e := range exts {
+		if e.Name == "" || len(e.Command) == 0 {
+			return nil, nil, fmt.Errorf("%s: extension without name or command", name)
+		}
+		st := &stage{
+			Name:    e.Name,
+			Args:    e.Command,
+			Stdout:  e.Stdout,
+			Inputs:  e.Inputs,
+			Exclude: e.Exclude,
+			Outputs: e.Outputs,
+			Deps:    e.Deps,
+			Network: e.Network,
+		}
+		switch e.Phase {
+		case phaseGenerate, "":
+			generate = append(generate, st)
+		case phasePostprocess:
+			st.Deps = append(st.Deps, "sphinx")
+			postprocess = append(postprocess, st)
+		default:
+			return nil, nil, fmt.Errorf("%s: %s: unknown phase %q", name, e.Name, e.Phase)
+		}
+	}
+	return generate, postprocess, nil
+}
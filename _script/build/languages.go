@@ -0,0 +1,256 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// The directories of the translations, relative to the root: gettext
+// catalogs in localeDir/<code>/LC_MESSAGES, and translated copies of the
+// sources in translationsDir/<code>.
+const (
+	localeDir       = "_locale"
+	translationsDir = "_translations"
+)
+
+// language is a translation of the docs, from either gettext catalogs or
+// a translated copy of the sources.
+type language struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// Catalogs is whether the translation is in gettext catalogs rather
+	// than a source directory.
+	Catalogs bool `json:"catalogs"`
+	// Threshold is the percentage translated required to publish the
+	// language, overriding the default.
+	Threshold int `json:"threshold,omitempty"`
+
+	// Completeness is the percentage translated.
+	Completeness int `json:"-"`
+}
+
+// Dir returns the directory of the translation, relative to the root.
+func (l *language) Dir() string {
+	if l.Catalogs {
+		return localeDir + "/" + l.Code
+	}
+	return translationsDir + "/" + l.Code
+}
+
+type languages struct {
+	// Threshold is the default percentage translated required to publish
+	// a language.
+	Threshold int         `json:"threshold"`
+	Languages []*language `json:"languages"`
+}
+
+func readLanguages(name string) (*languages, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var ls languages
+	if err := json.Unmarshal(bs, &ls); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	seen := make(map[string]bool)
+	for _, l := range ls.Languages {
+		if l.Code == "" || l.Name == "" {
+			return nil, fmt.Errorf("%s: language without code or name", name)
+		}
+		if seen[l.Code] {
+			return nil, fmt.Errorf("%s: %s listed twice", name, l.Code)
+		}
+		seen[l.Code] = true
+		if l.Threshold == 0 {
+			l.Threshold = ls.Threshold
+		}
+	}
+	return &ls, nil
+}
+
+// measure sets the completeness of the languages: the share of the
+// messages translated in the catalogs, or of the documents present in a
+// source directory.
+func (ls *languages) measure(root string) error {
+	var tree *rstdoc.Tree
+	for _, l := range ls.Languages {
+		dir := filepath.Join(root, filepath.FromSlash(l.Dir()))
+		if l.Catalogs {
+			total, done, err := catalogStats(filepath.Join(dir, "LC_MESSAGES"))
+			if err != nil {
+				return fmt.Errorf("%s: %w", l.Code, err)
+			}
+			l.Completeness = percent(done, total)
+			continue
+		}
+		if tree == nil {
+			var err error
+			if tree, err = rstdoc.Load(root); err != nil {
+				return err
+			}
+		}
+		done := 0
+		for _, d := range tree.Docs {
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(d.File))); err == nil {
+				done++
+			}
+		}
+		l.Completeness = percent(done, len(tree.Docs))
+	}
+	return nil
+}
+
+// published returns the languages translated enough to publish.
+func (ls *languages) published() []*language {
+	var res []*language
+	for _, l := range ls.Languages {
+		if l.Completeness >= l.Threshold {
+			res = append(res, l)
+		}
+	}
+	return res
+}
+
+func percent(n, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return n * 100 / total
+}
+
+// catalogStats returns the number of messages in the gettext catalogs
+// in the directory, and how many of them are translated and not fuzzy.
+func catalogStats(dir string) (total, done int, err error) {
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != ".po" {
+			return err
+		}
+		t, n, err := poStats(p)
+		total += t
+		done += n
+		return err
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	return total, done, err
+}
+
+// poStats counts the messages of a catalog and the translated ones,
+// leaving out the header and obsolete messages.
+func poStats(name string) (total, done int, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	var (
+		fuzzy, inMsgid     bool
+		msgid, translation string
+		started            bool
+	)
+	flush := func() {
+		if started && msgid != "" {
+			total++
+			if translation != "" && !fuzzy {
+				done++
+			}
+		}
+		fuzzy, inMsgid, started = false, false, false
+		msgid, translation = "", ""
+	}
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		switch {
+		case text == "":
+			flush()
+		case strings.HasPrefix(text, "#,"):
+			if strings.Contains(text, "fuzzy") {
+				fuzzy = true
+			}
+		case strings.HasPrefix(text, "#"):
+		case strings.HasPrefix(text, "msgid "):
+			started, inMsgid = true, true
+			s, err := unquote(name, line, strings.TrimPrefix(text, "msgid "))
+			if err != nil {
+				return 0, 0, err
+			}
+			msgid += s
+		case strings.HasPrefix(text, "msgid_plural "):
+			inMsgid = false
+		case strings.HasPrefix(text, "msgstr"):
+			inMsgid = false
+			_, q, _ := strings.Cut(text, " ")
+			s, err := unquote(name, line, q)
+			if err != nil {
+				return 0, 0, err
+			}
+			translation += s
+		case strings.HasPrefix(text, `"`):
+			s, err := unquote(name, line, text)
+			if err != nil {
+				return 0, 0, err
+			}
+			if inMsgid {
+				msgid += s
+			} else {
+				translation += s
+			}
+		}
+	}
+	flush()
+	return total, done, sc.Err()
+}
+
+func unquote(name string, line int, s string) (string, error) {
+	u, err := strconv.Unquote(strings.TrimSpace(s))
+	if err != nil {
+		return "", fmt.Errorf("%s:%d: bad string %s", name, line, s)
+	}
+	return u, nil
+}
+
+// manifestLanguage is an entry of the language switcher manifest.
+type manifestLanguage struct {
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	Path         string `json:"path"`
+	Completeness int    `json:"completeness"`
+}
+
+// writeManifest writes the languages published, English first, with
+// where each is on the site, for the language switcher.
+func writeManifest(file string, published []*language) error {
+	entries := []manifestLanguage{{Code: "en", Name: "English", Path: "/", Completeness: 100}}
+	for _, l := range published {
+		entries = append(entries, manifestLanguage{Code: l.Code, Name: l.Name, Path: "/" + l.Code + "/", Completeness: l.Completeness})
+	}
+	bs, err := json.MarshalIndent(map[string]any{"languages": entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(bs, '\n'), 0o644)
+}
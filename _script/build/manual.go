@@ -0,0 +1,56 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// The manual is the whole documentation as one PDF and EPUB, named as
+// in latex_documents and epub_basename in conf.py, and published in
+// manualDir of the HTML output so each version of the site has its own.
+const (
+	manualName = "Syncthing"
+	manualDir  = "manual"
+)
+
+// publishManual copies the PDF and EPUB built into the HTML output.
+func publishManual(root, buildDir string) error {
+	build := filepath.Join(root, filepath.FromSlash(buildDir))
+	dst := filepath.Join(build, "html", manualDir)
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	for _, src := range []string{
+		filepath.Join(build, "latex", manualName+".pdf"),
+		filepath.Join(build, "epub", manualName+".epub"),
+	} {
+		if err := copyFile(src, filepath.Join(dst, filepath.Base(src))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
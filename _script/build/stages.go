@@ -0,0 +1,264 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "path"
+
+// stage is a step of the build. All paths are relative to the docs root
+// and use slashes.
+type stage struct {
+	Name string
+	// Tool is the program under _script to go run, with Args relative to
+	// _script; when empty, Args is the command, run in the docs root.
+	Tool string
+	Args []string
+	// Stdout is the file the output of the command is written to, if any.
+	Stdout string
+	// Inputs are the files and directories the stage reads, besides the
+	// tool itself, leaving out Exclude; Outputs are the files it writes,
+	// possibly as glob patterns.
+	Inputs  []string
+	Exclude []string
+	Outputs []string
+	Deps    []string
+	// Source stages need the Syncthing source, given by -tag or -src.
+	Source bool
+	// Network stages read from the network, so their inputs can't tell
+	// whether they need to run; they only run when asked to.
+	Network bool
+	// Optional stages need more than the HTML build, such as LaTeX, and
+	// only run when named or needed by a named stage.
+	Optional bool
+	// Func, when set, is run instead of a command, with the docs root.
+	Func func(root string) error
+}
+
+// settings are what the stages are built from.
+type settings struct {
+	BuildDir string
+	Sphinx   string
+	// SourceArgs selects the Syncthing source for the source stages.
+	SourceArgs []string
+	// Languages are the translations to build besides English.
+	Languages []*language
+	// Generate and Postprocess are the stages of the extensions, which
+	// run before and after Sphinx.
+	Generate, Postprocess []*stage
+}
+
+// generators are the stages writing sources for Sphinx.
+var generators = []string{
+	"histver",
+	"releases-page",
+	"release-checksums",
+	"upgrade-paths",
+	"platforms",
+	"requirements",
+	"release-history",
+	"dark-images",
+	"rest-endpoints",
+	"openapi",
+	"config-reference",
+	"advanced-options",
+}
+
+// stages returns the stages of the build, each after its dependencies.
+func stages(s settings) []*stage {
+	html := path.Join(s.BuildDir, "html")
+	src := func(args ...string) []string {
+		return append(append([]string(nil), s.SourceArgs...), args...)
+	}
+	sphinxExclude := []string{s.BuildDir, "_script", "_site", "_syncthing", localeDir, translationsDir}
+	// The generators of the extensions are waited for as the built in
+	// ones.
+	generators := append([]string(nil), generators...)
+	for _, st := range s.Generate {
+		generators = append(generators, st.Name)
+	}
+	res := []*stage{
+		{
+			Name:    "histver",
+			Tool:    "histver",
+			Args:    []string{"-file", "../users/releases.csv", "-config", "histver/docs.json", "-audit", "histver/audit.json"},
+			Outputs: []string{"users/releases.csv"},
+			Network: true,
+		},
+		{
+			Name:    "releases-page",
+			Tool:    "releasespage",
+			Stdout:  "users/releases.rst",
+			Inputs:  []string{"users/releases.csv"},
+			Network: true,
+			Deps:    []string{"histver"},
+		},
+		{
+			Name:    "release-checksums",
+			Tool:    "checksums",
+			Stdout:  "includes/release-checksums.rst",
+			Inputs:  []string{"dev/release-keys.csv"},
+			Network: true,
+		},
+		{
+			Name:   "upgrade-paths",
+			Tool:   "upgradepaths",
+			Stdout: "includes/upgrade-paths.rst",
+			Inputs: []string{"users/releases.csv", "users/upgrade-rules.csv"},
+			Deps:   []string{"histver"},
+		},
+		{
+			Name:   "platforms",
+			Tool:   "platforms",
+			Stdout: "includes/platform-support.rst",
+			Inputs: []string{"users/releases.csv", "users/release-platforms.csv", "users/platform-overrides.csv"},
+			Deps:   []string{"histver"},
+		},
+		{
+			Name:   "requirements",
+			Tool:   "sysreqs",
+			Stdout: "users/requirements.rst",
+			Inputs: []string{"users/releases.csv", "users/release-platforms.csv", "users/platform-overrides.csv", "users/memory-guidance.csv"},
+			Deps:   []string{"histver"},
+		},
+		{
+			Name:    "release-history",
+			Tool:    "releasecharts",
+			Stdout:  "includes/release-history.rst",
+			Inputs:  []string{"users/releases.csv"},
+			Outputs: []string{"dev/release-history-*.svg"},
+			Deps:    []string{"histver"},
+		},
+		{
+			Name:    "dark-images",
+			Tool:    "darkimages",
+			Inputs:  []string{"dev/release-history-per-year.svg", "dev/release-history-go.svg", "dev/release-history-schedule.svg"},
+			Outputs: []string{"dev/release-history-*-dark.svg"},
+			Deps:    []string{"release-history"},
+		},
+		{
+			Name:    "rest-endpoints",
+			Tool:    "apigen",
+			Args:    src("-params", "../includes/rest-params"),
+			Stdout:  "includes/rest-endpoints.rst",
+			Inputs:  []string{"rest"},
+			Outputs: []string{"includes/rest-params"},
+			Source:  true,
+		},
+		{
+			Name:   "openapi",
+			Tool:   "apigen",
+			Args:   src("-openapi"),
+			Stdout: "_static/openapi.json",
+			Inputs: []string{"rest"},
+			Source: true,
+		},
+		{
+			Name:    "config-reference",
+			Tool:    "configref",
+			Args:    src("-out", "../includes"),
+			Inputs:  []string{"users/config.rst"},
+			Outputs: []string{"includes/config-*.rst"},
+			Source:  true,
+		},
+		{
+			Name:   "advanced-options",
+			Tool:   "configref",
+			Args:   src("-advanced"),
+			Stdout: "includes/advanced-options.rst",
+			Inputs: []string{"users/config.rst", "advanced"},
+			Source: true,
+		},
+	}
+	res = append(res, s.Generate...)
+	res = append(res, []*stage{
+		{
+			Name:    "sphinx",
+			Args:    []string{s.Sphinx, "-b", "html", "-d", path.Join(s.BuildDir, "doctrees"), ".", html},
+			Inputs:  []string{"."},
+			Exclude: sphinxExclude,
+			Outputs: []string{path.Join(html, "index.html")},
+			Deps:    generators,
+		},
+		{
+			Name:   "search-index",
+			Tool:   "searchindex",
+			Args:   []string{"-html", path.Join("..", html)},
+			Stdout: path.Join(html, "search-index.json"),
+			Deps:   []string{"sphinx"},
+		},
+		{
+			Name: "redirects",
+			Tool: "redirects",
+			Args: []string{"-git", "-stubs", path.Join("..", html)},
+			Deps: []string{"sphinx"},
+		},
+		{
+			Name:    "sitemap",
+			Tool:    "sitemap",
+			Args:    []string{"-site", path.Join("..", html)},
+			Outputs: []string{path.Join(html, "sitemap.xml"), path.Join(html, "robots.txt")},
+			Deps:    []string{"sphinx"},
+		},
+	}...)
+	res = append(res, s.Postprocess...)
+	res = append(res, []*stage{
+		{
+			Name:     "pdf",
+			Args:     []string{s.Sphinx, "-M", "latexpdf", ".", s.BuildDir},
+			Inputs:   []string{"."},
+			Exclude:  sphinxExclude,
+			Outputs:  []string{path.Join(s.BuildDir, "latex", manualName+".pdf")},
+			Deps:     generators,
+			Optional: true,
+		},
+		{
+			Name:     "epub",
+			Args:     []string{s.Sphinx, "-b", "epub", "-d", path.Join(s.BuildDir, "doctrees"), ".", path.Join(s.BuildDir, "epub")},
+			Inputs:   []string{"."},
+			Exclude:  sphinxExclude,
+			Outputs:  []string{path.Join(s.BuildDir, "epub", manualName+".epub")},
+			Deps:     generators,
+			Optional: true,
+		},
+		{
+			Name: "manual",
+			Func: func(root string) error {
+				return publishManual(root, s.BuildDir)
+			},
+			Inputs:   []string{path.Join(s.BuildDir, "latex", manualName+".pdf"), path.Join(s.BuildDir, "epub", manualName+".epub")},
+			Outputs:  []string{path.Join(html, manualDir, manualName+".pdf"), path.Join(html, manualDir, manualName+".epub")},
+			Deps:     []string{"pdf", "epub"},
+			Optional: true,
+		},
+	}...)
+
+	// Each language is built into its own output directory, with its
+	// own search index; the redirects and sitemap are for English only.
+	for _, l := range s.Languages {
+		out := path.Join(s.BuildDir, "html-"+l.Code)
+		args := []string{s.Sphinx, "-b", "html", "-d", path.Join(s.BuildDir, "doctrees-"+l.Code), "-D", "language=" + l.Code}
+		if l.Catalogs {
+			args = append(args, "-D", "locale_dirs="+localeDir, ".", out)
+		} else {
+			args = append(args, "-c", ".", l.Dir(), out)
+		}
+		res = append(res, &stage{
+			Name:    "sphinx-" + l.Code,
+			Args:    args,
+			Inputs:  []string{".", l.Dir()},
+			Exclude: sphinxExclude,
+			Outputs: []string{path.Join(out, "index.html")},
+			Deps:    generators,
+		}, &stage{
+			Name:   "search-index-" + l.Code,
+			Tool:   "searchindex",
+			Args:   []string{"-html", path.Join("..", out)},
+			Stdout: path.Join(out, "search-index.json"),
+			Deps:   []string{"sphinx-" + l.Code},
+		})
+	}
+	return res
+}
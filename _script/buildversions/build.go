@@ -0,0 +1,122 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// stampFile records the commit a version was built from, in its output
+// directory.
+const stampFile = ".commit"
+
+type builder struct {
+	repo   string
+	out    string
+	sphinx string
+	force  bool
+	// git serialises the worktree operations, which lock the
+	// repository.
+	git sync.Mutex
+}
+
+func (b *builder) build(tag string) error {
+	commit, err := b.output("rev-parse", tag+"^{commit}")
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(b.out, tag)
+	if !b.force {
+		if bs, err := os.ReadFile(filepath.Join(dst, stampFile)); err == nil && strings.TrimSpace(string(bs)) == commit {
+			log.Printf("%s: up to date", tag)
+			return nil
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "buildversions-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	wt := filepath.Join(tmp, "docs")
+	if _, err := b.output("worktree", "add", "--detach", wt, commit); err != nil {
+		return err
+	}
+	defer b.output("worktree", "remove", "--force", wt)
+
+	// conf.py takes the version from these instead of asking git.
+	for name, content := range map[string]string{"RELEASE": tag, "TAG": tag} {
+		if err := os.WriteFile(filepath.Join(wt, name), []byte(content+"\n"), 0o644); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("%s: building", tag)
+	html := filepath.Join(tmp, "html")
+	cmd := exec.Command(b.sphinx, "-b", "html", "-q", "-d", filepath.Join(tmp, "doctrees"), ".", html)
+	cmd.Dir = wt
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w\n%s", b.sphinx, err, stderr.String())
+	}
+	if err := os.WriteFile(filepath.Join(html, stampFile), []byte(commit+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	// Replace the old output only once the new one is complete.
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	if err := os.Rename(html, dst); err != nil {
+		return copyDir(html, dst)
+	}
+	return nil
+}
+
+func (b *builder) output(args ...string) (string, error) {
+	b.git.Lock()
+	defer b.git.Unlock()
+	cmd := exec.Command("git", append([]string{"-C", b.repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// copyDir copies a directory tree, for when the output is on another
+// file system than the temporary directory.
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		bs, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, bs, info.Mode().Perm())
+	})
+}
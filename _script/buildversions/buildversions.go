@@ -0,0 +1,109 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./buildversions -out ../_site [-since v1.20.0] [-only v1.27.0,...]
+//
+// Builds the documentation for each release tag into a directory of its
+// own under -out, and writes the versions.json listing them that the
+// version switcher reads. Each tag is built in a git worktree, and
+// versions already built from the same commit are skipped unless -force
+// is given, so only new or moved tags are rebuilt.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+func main() {
+	log.SetFlags(0)
+	repo := flag.String("repo", "..", "Documentation git repository")
+	out := flag.String("out", "../_site", "Output directory")
+	pattern := flag.String("tags", `^v1\.\d+\.\d+$`, "Pattern for the release tags to build, as scv_whitelist_tags in conf.py")
+	since := flag.String("since", "", "Oldest version to build")
+	only := flag.String("only", "", "Comma separated versions to build, instead of all")
+	force := flag.Bool("force", false, "Rebuild versions that are up to date")
+	parallel := flag.Int("parallel", runtime.NumCPU(), "Number of versions to build at the same time")
+	sphinx := flag.String("sphinx", "sphinx-build", "Sphinx build command")
+	flag.Parse()
+
+	tagExp, err := regexp.Compile(*pattern)
+	if err != nil {
+		log.Fatalln("tags:", err)
+	}
+	tags, err := releaseTags(*repo, tagExp)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *since != "" {
+		tags = newerTags(tags, *since)
+	}
+	if *only != "" {
+		tags = selectTags(tags, strings.Split(*only, ","))
+	}
+
+	b := &builder{
+		repo:   *repo,
+		out:    *out,
+		sphinx: *sphinx,
+		force:  *force,
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalln(err)
+	}
+
+	var wg sync.WaitGroup
+	var mut sync.Mutex
+	var failed []string
+	sem := make(chan struct{}, *parallel)
+	for _, tag := range tags {
+		wg.Add(1)
+		go func(tag string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := b.build(tag); err != nil {
+				log.Printf("%s: %v", tag, err)
+				mut.Lock()
+				failed = append(failed, tag)
+				mut.Unlock()
+			}
+		}(tag)
+	}
+	wg.Wait()
+
+	// The list includes the versions built before, so it's complete even
+	// when only some were rebuilt.
+	versions, err := builtVersions(*out, tagExp)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := writeVersions(filepath.Join(*out, "versions.json"), versions); err != nil {
+		log.Fatalln(err)
+	}
+	if len(failed) > 0 {
+		log.Fatalln("failed to build:", strings.Join(failed, ", "))
+	}
+}
+
+func writeVersions(name string, versions []string) error {
+	bs, err := json.Marshal(map[string][]string{"entries": versions})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(name, append(bs, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing versions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/versions"
+)
+
+// releaseTags returns the tags in the repository matching the pattern,
+// oldest first.
+func releaseTags(repo string, exp *regexp.Regexp) ([]string, error) {
+	cmd := exec.Command("git", "-C", repo, "tag", "--list")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %w", err)
+	}
+	var tags []string
+	for _, tag := range strings.Fields(string(out)) {
+		if exp.MatchString(tag) {
+			tags = append(tags, tag)
+		}
+	}
+	sortVersions(tags)
+	return tags, nil
+}
+
+// builtVersions returns the versions with an output directory.
+func builtVersions(out string, exp *regexp.Regexp) ([]string, error) {
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() && exp.MatchString(e.Name()) {
+			versions = append(versions, e.Name())
+		}
+	}
+	sortVersions(versions)
+	return versions, nil
+}
+
+func newerTags(tags []string, since string) []string {
+	var res []string
+	for _, tag := range tags {
+		if versions.Compare(tag, since) >= 0 {
+			res = append(res, tag)
+		}
+	}
+	return res
+}
+
+func selectTags(tags, only []string) []string {
+	want := make(map[string]bool)
+	for _, t := range only {
+		want[strings.TrimSpace(t)] = true
+	}
+	var res []string
+	for _, tag := range tags {
+		if want[tag] {
+			res = append(res, tag)
+		}
+	}
+	return res
+}
+
+func sortVersions(vs []string) {
+	sort.Slice(vs, func(a, b int) bool {
+		return versions.Compare(vs[a], vs[b]) < 0
+	})
+}
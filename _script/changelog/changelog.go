@@ -0,0 +1,88 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./changelog [-since v1.0.0] > ../users/changelog.rst
+//
+// Fetches the release notes of every Syncthing release from GitHub and
+// writes a changelog page of them, newest first, with a section per
+// minor version. The notes are converted from Markdown, with issue
+// numbers and @mentions linked. Each release gets a label such as
+// changelog-v1.27.1 for other pages to refer to. Set GITHUB_TOKEN to
+// avoid the rate limit for unauthenticated requests.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+)
+
+func main() {
+	log.SetFlags(0)
+	repo := flag.String("repo", "syncthing/syncthing", "Repository to take the releases from")
+	since := flag.String("since", "", "Oldest version to include")
+	flag.Parse()
+
+	var oldest relnotes.Version
+	if *since != "" {
+		var ok bool
+		if oldest, ok = relnotes.ParseVersion(*since); !ok {
+			log.Fatalf("%q is not a vX.Y.Z version", *since)
+		}
+	}
+
+	rels, err := relnotes.List(context.Background(), relnotes.Client(), *repo)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var included []relnotes.Release
+	for _, rel := range rels {
+		if !rel.Version.Less(oldest) {
+			included = append(included, rel)
+		}
+	}
+	if len(included) == 0 {
+		log.Fatalln("no releases found")
+	}
+
+	if err := writePage(os.Stdout, relnotes.Converter{Repo: *repo}, included); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// writePage writes the changelog of the releases, which are newest
+// first.
+func writePage(w io.Writer, conv relnotes.Converter, rels []relnotes.Release) error {
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/changelog; do not edit.\n\n")
+	sb.WriteString(rst.Heading("Changelog", '='))
+	fmt.Fprintf(&sb, "The release notes of each release, as published on %s.\n\n",
+		rst.Link("GitHub", "https://github.com/"+conv.Repo+"/releases"))
+
+	series := ""
+	for _, rel := range rels {
+		if s := rel.Version.Series(); s != series {
+			series = s
+			fmt.Fprintf(&sb, ".. _changelog-%s:\n\n", series)
+			sb.WriteString(rst.Heading(series, '-'))
+		}
+		fmt.Fprintf(&sb, ".. _changelog-%s:\n\n", rel.Tag)
+		sb.WriteString(rst.Heading(rel.Tag, '~'))
+		fmt.Fprintf(&sb, "Released %s (%s).\n\n", rel.Published.Format("2006-01-02"), rst.Link("release page", rel.URL))
+		if notes := conv.Convert(rel.Notes); notes != "" {
+			sb.WriteString(notes + "\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
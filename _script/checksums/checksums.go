@@ -0,0 +1,106 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./checksums [-n 3] > ../includes/release-checksums.rst
+//
+// Writes the SHA-256 checksums of the artifacts of the most recent
+// releases, for the release signing page, so that downloads can be
+// verified without going through the release assets. The checksums are
+// taken from the sha256sum.txt.asc asset of each release, after verifying
+// its signature with the Release Management GPG key, as in the signedsums
+// package.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/signedsums"
+)
+
+// release is a release with its verified checksums.
+type release struct {
+	relnotes.Release
+	SumsURL string
+	Sums    []signedsums.Sum
+}
+
+func main() {
+	log.SetFlags(0)
+	n := flag.Int("n", 3, "Number of releases, newest first")
+	repo := flag.String("repo", "syncthing/syncthing", "Repository to take the releases from")
+	manifest := flag.String("manifest", signedsums.Manifest, "Pinned key manifest")
+	flag.Parse()
+
+	keyring, err := signedsums.Keyring(*manifest)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	rels, err := relnotes.List(context.Background(), relnotes.Client(), *repo)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var res []release
+	for _, rel := range rels {
+		if len(res) == *n {
+			break
+		}
+		if !hasAsset(rel, signedsums.Asset) {
+			log.Printf("%s: no %s; skipping", rel.Tag, signedsums.Asset)
+			continue
+		}
+		url := fmt.Sprintf("https://github.com/%s/releases/download/%s/%s", *repo, rel.Tag, signedsums.Asset)
+		sums, err := signedsums.Fetch(keyring, url)
+		if err != nil {
+			log.Fatalf("%s: %s: %v", rel.Tag, signedsums.Asset, err)
+		}
+		res = append(res, release{Release: rel, SumsURL: url, Sums: sums})
+	}
+
+	if err := writeChecksums(os.Stdout, res); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func hasAsset(rel relnotes.Release, name string) bool {
+	for _, a := range rel.Assets {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+func writeChecksums(w io.Writer, rels []release) error {
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/checksums; do not edit.\n\n")
+	for _, rel := range rels {
+		sb.WriteString(rst.Heading(rel.Tag, '~'))
+		fmt.Fprintf(&sb, "Released %s. From %s, with a good signature by the Release Management GPG key.\n\n",
+			rel.Published.UTC().Format(time.DateOnly), rst.Link(signedsums.Asset, rel.SumsURL))
+		t := rst.Table{
+			Header: []string{"Artifact", "SHA-256"},
+			Widths: []int{2, 3},
+		}
+		for _, s := range rel.Sums {
+			t.Rows = append(t.Rows, []string{rst.Literal(s.Name), rst.Literal(s.SHA256)})
+		}
+		if _, err := t.WriteTo(&sb); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
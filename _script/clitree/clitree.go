@@ -0,0 +1,129 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./clitree -tag v1.27.0 > ../includes/cli-reference.rst
+//
+// Reads the command definitions of "syncthing cli" from the Syncthing
+// source and writes a reference of the whole command tree: every command
+// with its arguments, description and flags, grouped by the top level
+// command groups. The commands under "config" are built at runtime from
+// the configuration of the running instance, so only the group itself is
+// listed.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/stsource"
+)
+
+// cliDir is the package defining the cli command tree.
+const cliDir = "cmd/syncthing/cli"
+
+// rootName is the top command of the tree, run as "syncthing cli".
+const rootName = "cli"
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	flag.Parse()
+
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+	pkg, err := stsource.ParseDir(root, cliDir)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	tree := commandTree(pkg, rootName)
+	if tree == nil {
+		log.Fatalf("%s: no %q command found", cliDir, rootName)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	writeTree(w, tree)
+	if err := w.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func writeTree(w io.Writer, tree *command) {
+	fmt.Fprint(w, ".. This file is generated by _script/clitree; do not edit.\n\n")
+	if len(tree.Flags) > 0 {
+		fmt.Fprint(w, "These options apply to all commands, given before the command:\n\n")
+		writeFlags(w, tree.Flags, "")
+	}
+	for _, group := range tree.Subcommands {
+		title := group.Name
+		if strings.Trim(title, "-") == "" {
+			// The command to read commands from stdin is a dash.
+			title = rst.Literal(title)
+		}
+		fmt.Fprint(w, rst.Heading(title, '^'))
+		writeCommand(w, group, []string{"syncthing", "cli"})
+	}
+}
+
+// writeCommand writes the command and, after it, its subcommands.
+func writeCommand(w io.Writer, cmd *command, parents []string) {
+	path := append(parents[:len(parents):len(parents)], cmd.Name)
+	usage := strings.Join(path, " ")
+	if cmd.Args != "" {
+		usage += " " + cmd.Args
+	}
+	fmt.Fprintf(w, "%s\n", rst.Literal(usage))
+	text := rst.Escape(cmd.Usage)
+	if text == "" {
+		text = "(No description.)"
+	}
+	fmt.Fprintf(w, "    %s\n\n", text)
+	if len(cmd.Aliases) > 0 {
+		fmt.Fprintf(w, "    Also available as %s.\n\n", literals(cmd.Aliases))
+	}
+	if cmd.Dynamic {
+		fmt.Fprint(w, "    The subcommands are generated from the configuration of the running instance; use ``--help`` on each level to list them.\n\n")
+	}
+	writeFlags(w, cmd.Flags, "    ")
+	for _, sub := range cmd.Subcommands {
+		writeCommand(w, sub, path)
+	}
+}
+
+func writeFlags(w io.Writer, flags []cmdFlag, indent string) {
+	for _, f := range flags {
+		names := []string{"--" + f.Name}
+		for _, a := range f.Aliases {
+			if len(a) == 1 {
+				names = append(names, "-"+a)
+			} else {
+				names = append(names, "--"+a)
+			}
+		}
+		fmt.Fprintf(w, "%s%s\n", indent, literals(names))
+		if f.Usage != "" {
+			fmt.Fprintf(w, "%s    %s\n", indent, rst.Escape(f.Usage))
+		}
+		fmt.Fprint(w, "\n")
+	}
+}
+
+func literals(ss []string) string {
+	ls := make([]string, len(ss))
+	for i, s := range ss {
+		ls[i] = rst.Literal(s)
+	}
+	return strings.Join(ls, ", ")
+}
@@ -0,0 +1,203 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/ast"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// command is a urfave/cli command definition.
+type command struct {
+	Name        string
+	Aliases     []string
+	Usage       string
+	Args        string
+	Flags       []cmdFlag
+	Subcommands []*command
+	// Dynamic is set when the subcommands are built at runtime.
+	Dynamic bool
+}
+
+type cmdFlag struct {
+	Name    string
+	Aliases []string
+	Usage   string
+}
+
+// definitions are the command literals of a package.
+type definitions struct {
+	vars   map[string]*ast.CompositeLit // package level variables
+	byName map[string]*ast.CompositeLit // any command literal, by command name
+	locals map[string]*ast.CompositeLit // literals assigned to local variables
+}
+
+// commandTree returns the command with the name and everything below it,
+// or nil if there's no such command.
+func commandTree(pkg *stsource.Package, name string) *command {
+	defs := definitions{
+		vars:   make(map[string]*ast.CompositeLit),
+		byName: make(map[string]*ast.CompositeLit),
+		locals: make(map[string]*ast.CompositeLit),
+	}
+	for _, f := range pkg.SortedFiles() {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				for i, n := range vs.Names {
+					if i < len(vs.Values) {
+						if lit, ok := vs.Values[i].(*ast.CompositeLit); ok && isCommandLit(lit) {
+							defs.vars[n.Name] = lit
+						}
+					}
+				}
+			}
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if as, ok := n.(*ast.AssignStmt); ok && len(as.Lhs) == len(as.Rhs) {
+				for i, lhs := range as.Lhs {
+					id, ok := lhs.(*ast.Ident)
+					lit, isLit := as.Rhs[i].(*ast.CompositeLit)
+					if ok && isLit {
+						defs.locals[id.Name] = lit
+					}
+				}
+			}
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok || !isCommandLit(lit) {
+				return true
+			}
+			if s, ok := stsource.StringLit(field(lit, "Name")); ok {
+				if _, seen := defs.byName[s]; !seen {
+					defs.byName[s] = lit
+				}
+			}
+			return true
+		})
+	}
+	lit, ok := defs.byName[name]
+	if !ok {
+		return nil
+	}
+	return defs.command(lit)
+}
+
+// isCommandLit returns whether the literal is a cli.Command, or one with
+// the type left out in a list of them, which is recognized by the Name
+// field along with Usage, Action or Subcommands.
+func isCommandLit(lit *ast.CompositeLit) bool {
+	if sel, ok := lit.Type.(*ast.SelectorExpr); ok {
+		return sel.Sel.Name == "Command"
+	}
+	return lit.Type == nil && field(lit, "Name") != nil &&
+		(field(lit, "Usage") != nil || field(lit, "Action") != nil || field(lit, "Subcommands") != nil)
+}
+
+// field returns the value of the keyed field in the literal, or nil.
+func field(lit *ast.CompositeLit, name string) ast.Expr {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if id, ok := kv.Key.(*ast.Ident); ok && id.Name == name {
+			return kv.Value
+		}
+	}
+	return nil
+}
+
+func (d definitions) command(lit *ast.CompositeLit) *command {
+	var cmd command
+	cmd.Name, _ = stsource.StringLit(field(lit, "Name"))
+	cmd.Usage, _ = stsource.StringLit(field(lit, "Usage"))
+	cmd.Args, _ = stsource.StringLit(field(lit, "ArgsUsage"))
+	cmd.Aliases = stringList(field(lit, "Aliases"))
+	flags := field(lit, "Flags")
+	if id, ok := flags.(*ast.Ident); ok {
+		// Flags shared by commands, or built before the command.
+		flags = d.locals[id.Name]
+	}
+	if fl, ok := flags.(*ast.CompositeLit); ok {
+		for _, elt := range fl.Elts {
+			if f, ok := elt.(*ast.CompositeLit); ok {
+				cmd.Flags = append(cmd.Flags, parseFlag(f))
+			}
+		}
+	}
+
+	switch subs := field(lit, "Subcommands").(type) {
+	case nil:
+	case *ast.CompositeLit:
+		for _, elt := range subs.Elts {
+			if sub := d.resolve(elt); sub != nil {
+				if hidden, ok := field(sub, "Hidden").(*ast.Ident); ok && hidden.Name == "true" {
+					continue
+				}
+				cmd.Subcommands = append(cmd.Subcommands, d.command(sub))
+			}
+		}
+	default:
+		cmd.Dynamic = true
+	}
+	return &cmd
+}
+
+// resolve returns the literal of a subcommand: given directly, as a
+// package variable, or as a local variable named after the command
+// ("configCommand" for the "config" command) built elsewhere.
+func (d definitions) resolve(e ast.Expr) *ast.CompositeLit {
+	switch e := e.(type) {
+	case *ast.CompositeLit:
+		return e
+	case *ast.Ident:
+		if lit, ok := d.vars[e.Name]; ok {
+			return lit
+		}
+		return d.byName[strings.TrimSuffix(e.Name, "Command")]
+	}
+	return nil
+}
+
+func parseFlag(lit *ast.CompositeLit) cmdFlag {
+	var f cmdFlag
+	name, _ := stsource.StringLit(field(lit, "Name"))
+	// Version 1 of urfave/cli takes the aliases in the name: "device, d".
+	names := strings.Split(name, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	f.Name = names[0]
+	f.Aliases = append(names[1:], stringList(field(lit, "Aliases"))...)
+	usage, _ := stsource.StringLit(field(lit, "Usage"))
+	// Backquotes mark the placeholder for the value in the help output.
+	f.Usage = strings.ReplaceAll(usage, "`", "")
+	return f
+}
+
+func stringList(e ast.Expr) []string {
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	var ss []string
+	for _, elt := range lit.Elts {
+		if s, ok := stsource.StringLit(elt); ok {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// abandonedAfter is how long a maintained project can go without a
+// release or commit before it's flagged as possibly abandoned.
+const abandonedAfter = 2 * 365 * 24 * time.Hour
+
+// check fetches the entry's URL and, for GitHub repositories, looks up
+// the latest release or commit. It returns the problems found; the entry
+// is current if there are none.
+func check(ctx context.Context, client *github.Client, e *entry) []string {
+	var problems []string
+	if err := fetch(ctx, e.URL); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	owner, repo, ok := githubRepo(e.URL)
+	if !ok {
+		return problems
+	}
+	last, archived, err := lastActivity(ctx, client, owner, repo)
+	switch {
+	case err != nil:
+		problems = append(problems, err.Error())
+	case archived && e.Status == statusMaintained:
+		problems = append(problems, "repository is archived; mark as unmaintained")
+	case e.Status == statusMaintained && time.Since(last) > abandonedAfter:
+		problems = append(problems, fmt.Sprintf("no release or commit since %s; mark as unmaintained", last.Format(time.DateOnly)))
+	case e.Status == statusUnmaintained && !archived && time.Since(last) < abandonedAfter:
+		problems = append(problems, fmt.Sprintf("active again, last change %s; mark as maintained", last.Format(time.DateOnly)))
+	}
+	return problems
+}
+
+// fetch returns an error if the URL can't be fetched.
+func fetch(ctx context.Context, u string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	// Some sites refuse requests without a browser-like user agent.
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; syncthing-docs-community-check)")
+	resp, err := httpclient.Default.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	return nil
+}
+
+// githubRepo returns the repository a github.com URL is in.
+func githubRepo(u string) (owner, repo string, ok bool) {
+	pu, err := url.Parse(u)
+	if err != nil || pu.Host != "github.com" {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(pu.Path, "/"), "/")
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// lastActivity returns when the repository last had a release, or if it
+// never had one, a push.
+func lastActivity(ctx context.Context, client *github.Client, owner, repo string) (time.Time, bool, error) {
+	r, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	rel, _, err := client.Repositories.GetLatestRelease(ctx, owner, repo)
+	var ghErr *github.ErrorResponse
+	switch {
+	case err == nil:
+		return rel.GetPublishedAt().Time, r.GetArchived(), nil
+	case errors.As(err, &ghErr) && ghErr.Response.StatusCode == http.StatusNotFound:
+		return r.GetPushedAt().Time, r.GetArchived(), nil
+	default:
+		return time.Time{}, false, err
+	}
+}
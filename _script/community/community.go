@@ -0,0 +1,181 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./community > ../users/contrib.rst
+//
+//	go run ./community -check [-verify]
+//
+// Writes the community contributions page from the registry in
+// users/community.yaml, with the entries grouped by category and
+// platform and the unmaintained ones listed last.
+//
+// With -check, fetches every entry's URL instead and, for GitHub
+// repositories, the date of the latest release or commit, and reports
+// the entries whose link is dead, maintained ones that look abandoned
+// and unmaintained ones that are active again. It exits with status 1 if
+// anything was reported. With -verify as well, the verified date of the
+// entries without problems is set to today in the registry. Set
+// GITHUB_TOKEN to avoid the rate limit for unauthenticated requests.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+)
+
+// labels are the section labels other pages link to, by category and
+// platform.
+var labels = map[[2]string]string{
+	{"GUI Wrappers", "Cross-platform"}:    "contrib-all",
+	{"GUI Wrappers", "Windows"}:           "contrib-windows",
+	{"Packages and Bundlings", "Windows"}: "contrib-packages-windows",
+}
+
+// generated are the sections of generated lists that lead a category,
+// by category, for what the registry doesn't track by hand.
+var generated = map[string]struct{ title, include string }{
+	"Packages and Bundlings": {"Distribution Packages", "../includes/packaging-status.rst"},
+}
+
+func main() {
+	log.SetFlags(0)
+	registry := flag.String("registry", "../users/community.yaml", "Community contributions registry")
+	doCheck := flag.Bool("check", false, "Check the entries instead of writing the page")
+	verify := flag.Bool("verify", false, "With -check, set the verified date of the entries without problems")
+	flag.Parse()
+
+	entries, err := readRegistry(*registry)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if !*doCheck {
+		if err := writePage(os.Stdout, entries); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	ctx := context.Background()
+	client := relnotes.Client()
+	var current []*entry
+	failed := false
+	for _, e := range entries {
+		problems := check(ctx, client, e)
+		for _, p := range problems {
+			fmt.Printf("%s:%d: %s: %s\n", *registry, e.line, e.Name, p)
+		}
+		if len(problems) == 0 {
+			current = append(current, e)
+		} else {
+			failed = true
+		}
+	}
+	if *verify && len(current) > 0 {
+		if err := setVerified(*registry, current, time.Now().Format(time.DateOnly)); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func writePage(w io.Writer, entries []*entry) error {
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/community from users/community.yaml;\n")
+	sb.WriteString("   edit that instead.\n\n")
+	sb.WriteString(".. _contributions:\n\n")
+	sb.WriteString(rst.Heading("Community Contributions", '='))
+	sb.WriteString("This page lists integrations, addons and packagings of Syncthing created by\n")
+	sb.WriteString("the community. To add your own or update an entry, edit ``users/community.yaml``\n")
+	sb.WriteString("in the documentation repository.\n\n")
+
+	var categories []string
+	platforms := make(map[string][]string)
+	byPlace := make(map[[2]string][]*entry)
+	var unmaintained []*entry
+	for _, e := range entries {
+		if e.Status == statusUnmaintained {
+			unmaintained = append(unmaintained, e)
+			continue
+		}
+		if _, ok := platforms[e.Category]; !ok {
+			categories = append(categories, e.Category)
+			platforms[e.Category] = nil
+		}
+		place := [2]string{e.Category, e.Platform}
+		if _, ok := byPlace[place]; !ok {
+			platforms[e.Category] = append(platforms[e.Category], e.Platform)
+		}
+		byPlace[place] = append(byPlace[place], e)
+	}
+
+	for _, cat := range categories {
+		sb.WriteString(rst.Heading(cat, '-'))
+		if gen, ok := generated[cat]; ok {
+			sb.WriteString(rst.Heading(gen.title, '~'))
+			fmt.Fprintf(&sb, ".. include:: %s\n\n", gen.include)
+		}
+		for _, plat := range platforms[cat] {
+			place := [2]string{cat, plat}
+			if label, ok := labels[place]; ok {
+				fmt.Fprintf(&sb, ".. _%s:\n\n", label)
+			}
+			if plat != "" {
+				sb.WriteString(rst.Heading(plat, '~'))
+			}
+			for _, e := range byPlace[place] {
+				writeEntry(&sb, e, rst.Link(e.Name, e.URL))
+			}
+		}
+	}
+
+	if len(unmaintained) > 0 {
+		sb.WriteString(rst.Heading("Older, Possibly Unmaintained", '-'))
+		sb.WriteString(".. note::\n")
+		sb.WriteString("   These projects have not been updated in quite a while. They may still be\n")
+		sb.WriteString("   usable, or they may be in disrepair. If you are the maintainer of one of\n")
+		sb.WriteString("   these and you have revived the project, please update this page\n")
+		sb.WriteString("   accordingly.\n\n")
+		for _, e := range unmaintained {
+			kind := e.Category
+			if e.Platform != "" {
+				kind += ", " + e.Platform
+			}
+			writeEntry(&sb, e, rst.Link(e.Name, e.URL)+" ("+rst.Escape(kind)+")")
+		}
+	}
+
+	_, err := io.WriteString(w, strings.TrimSuffix(sb.String(), "\n"))
+	return err
+}
+
+// writeEntry writes the entry as a list item, the description indented
+// below the title.
+func writeEntry(sb *strings.Builder, e *entry, title string) {
+	fmt.Fprintf(sb, "- %s\n\n", title)
+	if e.Description == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(e.Description, "\n"), "\n") {
+		if line == "" {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString("  " + line + "\n")
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// entry is a community contribution in the registry.
+type entry struct {
+	Name        string
+	URL         string
+	Category    string
+	Platform    string
+	Status      string
+	Verified    string
+	Description string
+
+	// line is the registry line the entry starts on, verifiedLine the
+	// one with its verified date, or zero.
+	line, verifiedLine int
+}
+
+const (
+	statusMaintained   = "maintained"
+	statusUnmaintained = "unmaintained"
+)
+
+// readRegistry reads the registry. It's YAML, a list of mappings, but
+// only the part of YAML the registry needs is understood: plain and
+// quoted scalars, literal (|) block scalars and comments.
+func readRegistry(file string) ([]*entry, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(bs), "\n")
+
+	var entries []*entry
+	var cur *entry
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		pos := fmt.Sprintf("%s:%d", file, i+1)
+		switch {
+		case strings.HasPrefix(line, "- "):
+			cur = &entry{Status: statusMaintained, line: i + 1}
+			entries = append(entries, cur)
+			line = "  " + line[2:]
+		case cur == nil || !strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "   "):
+			return nil, fmt.Errorf("%s: expected an entry (- key: value) or a key of one", pos)
+		}
+
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			return nil, fmt.Errorf("%s: expected key: value", pos)
+		}
+		value = strings.TrimSpace(value)
+		if value == "|" {
+			// The block is the following lines indented more than the
+			// key, blank lines included.
+			var block []string
+			for i+1 < len(lines) {
+				next := strings.TrimRight(lines[i+1], " \t\r")
+				if next != "" && !strings.HasPrefix(next, "   ") {
+					break
+				}
+				block = append(block, next)
+				i++
+			}
+			value = dedent(block)
+		} else if value, err = scalar(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", pos, err)
+		}
+
+		switch key {
+		case "name":
+			cur.Name = value
+		case "url":
+			cur.URL = value
+		case "category":
+			cur.Category = value
+		case "platform":
+			cur.Platform = value
+		case "status":
+			if value != statusMaintained && value != statusUnmaintained {
+				return nil, fmt.Errorf("%s: status %q is neither %s nor %s", pos, value, statusMaintained, statusUnmaintained)
+			}
+			cur.Status = value
+		case "verified":
+			if _, err := time.Parse(time.DateOnly, value); err != nil {
+				return nil, fmt.Errorf("%s: verified: %w", pos, err)
+			}
+			cur.Verified = value
+			cur.verifiedLine = i + 1
+		case "description":
+			cur.Description = value
+		default:
+			return nil, fmt.Errorf("%s: unknown key %q", pos, key)
+		}
+	}
+
+	for _, e := range entries {
+		if e.Name == "" || e.URL == "" || e.Category == "" {
+			return nil, fmt.Errorf("%s:%d: entry needs a name, url and category", file, e.line)
+		}
+	}
+	return entries, nil
+}
+
+// scalar returns the value of a plain or quoted scalar.
+func scalar(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+// dedent removes the indentation of the first line from the block and
+// the trailing blank lines, keeping a final newline.
+func dedent(block []string) string {
+	for len(block) > 0 && block[len(block)-1] == "" {
+		block = block[:len(block)-1]
+	}
+	if len(block) == 0 {
+		return ""
+	}
+	indent := len(block[0]) - len(strings.TrimLeft(block[0], " "))
+	var sb strings.Builder
+	for _, l := range block {
+		if len(l) >= indent {
+			l = l[indent:]
+		}
+		sb.WriteString(l)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// setVerified sets the verified date of the entries, editing the
+// registry in place so comments and layout are kept.
+func setVerified(file string, entries []*entry, date string) error {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(bs), "\n")
+	// Lines are inserted after the entry's first, so work from the end
+	// of the file to keep the earlier line numbers valid.
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.verifiedLine > 0 {
+			lines[e.verifiedLine-1] = "  verified: " + date
+			continue
+		}
+		lines = append(lines[:e.line], append([]string{"  verified: " + date}, lines[e.line:]...)...)
+	}
+	return os.WriteFile(file, []byte(strings.Join(lines, "\n")), 0o644)
+}
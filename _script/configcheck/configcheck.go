@@ -0,0 +1,169 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./configcheck -syncthing path/to/syncthing [-version v1.27.0] [-root ..]
+//
+// Validates the full config.xml examples in the documentation against a
+// Syncthing release. Each example is loaded and saved again by the given
+// binary ("syncthing generate" in a temporary home directory); examples
+// that fail to load are reported, as are the elements and attributes
+// that don't survive the round trip, being options that were removed or
+// renamed. With -version, the binary must be that version. Problems are
+// printed as file:line: message, exiting with status 1 if there are any.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+var versionExp = regexp.MustCompile(`^syncthing (\S+)`)
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	binary := flag.String("syncthing", "", "Syncthing binary to load the examples with")
+	version := flag.String("version", "", "Syncthing version the binary must be")
+	flag.Parse()
+
+	if *binary == "" {
+		log.Fatalln("a Syncthing binary is required (-syncthing)")
+	}
+	have, err := binaryVersion(*binary)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *version != "" && have != *version {
+		log.Fatalf("%s is %s, not %s", *binary, have, *version)
+	}
+
+	tree, err := rstdoc.Load(*root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	type located struct {
+		pos rstdoc.Pos
+		msg string
+	}
+	var problems []located
+	n := 0
+	for _, d := range tree.Docs {
+		for _, b := range d.CodeBlocks {
+			text := strings.Join(b.Content, "\n")
+			if !isFullConfig(text) {
+				continue
+			}
+			n++
+			for _, p := range checkExample(*binary, text) {
+				pos := rstdoc.Pos{File: b.Pos.File, Line: b.Pos.Line + p.line - 1}
+				problems = append(problems, located{pos, p.msg})
+			}
+		}
+	}
+	if n == 0 {
+		log.Fatalln("no configuration examples found")
+	}
+	sort.SliceStable(problems, func(a, b int) bool {
+		pa, pb := problems[a].pos, problems[b].pos
+		if pa.File != pb.File {
+			return pa.File < pb.File
+		}
+		return pa.Line < pb.Line
+	})
+	for _, p := range problems {
+		fmt.Printf("%s: %s\n", p.pos, p.msg)
+	}
+	log.Printf("checked %d configuration examples against syncthing %s", n, have)
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+func binaryVersion(binary string) (string, error) {
+	out, err := exec.Command(binary, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s --version: %w", binary, err)
+	}
+	m := versionExp.FindSubmatch(out)
+	if m == nil {
+		return "", fmt.Errorf("%s --version: unrecognised output %q", binary, out)
+	}
+	return string(m[1]), nil
+}
+
+type problem struct {
+	line int // within the example
+	msg  string
+}
+
+// checkExample loads the example with Syncthing and compares what it
+// saves to the original.
+func checkExample(binary, text string) []problem {
+	orig, err := parseConfig([]byte(text))
+	if err != nil {
+		return []problem{{1, fmt.Sprintf("invalid XML: %v", err)}}
+	}
+
+	home, err := os.MkdirTemp("", "configcheck-")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer os.RemoveAll(home)
+	cfgFile := filepath.Join(home, "config.xml")
+	if err := os.WriteFile(cfgFile, []byte(text), 0o600); err != nil {
+		log.Fatalln(err)
+	}
+	cmd := exec.Command(binary, "generate", "--home="+home, "--no-default-folder", "--skip-port-probing")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return []problem{{1, fmt.Sprintf("syncthing can't load the example: %s", lastLine(out.String()))}}
+	}
+	bs, err := os.ReadFile(cfgFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	saved, err := parseConfig(bs)
+	if err != nil {
+		log.Fatalf("reading the saved configuration: %v", err)
+	}
+
+	var res []problem
+	for _, n := range orig.sorted() {
+		if _, ok := saved[n.path]; ok {
+			continue
+		}
+		// Report an element once, not each of its children.
+		if parent, _ := n.parent(); parent != "" {
+			if _, ok := saved[parent]; !ok {
+				continue
+			}
+		}
+		what := "element"
+		if n.attr {
+			what = "attribute"
+		}
+		res = append(res, problem{n.line, fmt.Sprintf("%s %s is not a configuration option (removed or renamed?)", what, n.path)})
+	}
+	return res
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
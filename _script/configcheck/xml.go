@@ -0,0 +1,112 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+)
+
+// node is an element or attribute, by its path from the root element:
+// "configuration/folder/versioning", "configuration/folder@id". Repeated
+// elements share a path.
+type node struct {
+	path string
+	attr bool
+	line int // of the first occurrence
+}
+
+func (n node) parent() (string, bool) {
+	if n.attr {
+		p, _, _ := strings.Cut(n.path, "@")
+		return p, true
+	}
+	i := strings.LastIndex(n.path, "/")
+	if i < 0 {
+		return "", false
+	}
+	return n.path[:i], true
+}
+
+type nodes map[string]node
+
+func (ns nodes) sorted() []node {
+	res := make([]node, 0, len(ns))
+	for _, n := range ns {
+		res = append(res, n)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		if res[a].line != res[b].line {
+			return res[a].line < res[b].line
+		}
+		return res[a].path < res[b].path
+	})
+	return res
+}
+
+// parseConfig returns the elements and attributes of an XML document.
+func parseConfig(bs []byte) (nodes, error) {
+	ns := make(nodes)
+	dec := xml.NewDecoder(bytes.NewReader(bs))
+	var stack []string
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return ns, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			line := 1 + bytes.Count(bs[:offset], []byte("\n"))
+			// The offset is before any whitespace preceding the element.
+			line += bytes.Count(bs[offset:offset+int64(len(leadingSpace(bs[offset:])))], []byte("\n"))
+			stack = append(stack, tok.Name.Local)
+			path := strings.Join(stack, "/")
+			ns.add(node{path: path, line: line})
+			for _, a := range tok.Attr {
+				ns.add(node{path: path + "@" + a.Name.Local, attr: true, line: line})
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+func (ns nodes) add(n node) {
+	if _, ok := ns[n.path]; !ok {
+		ns[n.path] = n
+	}
+}
+
+func leadingSpace(bs []byte) []byte {
+	return bs[:len(bs)-len(bytes.TrimLeft(bs, " \t\r\n"))]
+}
+
+// isFullConfig returns whether the text is a configuration example to
+// check: a configuration element with filled in sections, rather than
+// the outline of one.
+func isFullConfig(text string) bool {
+	ns, err := parseConfig([]byte(text))
+	if err != nil {
+		return strings.Contains(text, "<configuration")
+	}
+	if _, ok := ns["configuration"]; !ok {
+		return false
+	}
+	for path := range ns {
+		if strings.Count(path, "/") >= 2 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./configexample -tag v1.27.0 > ../includes/config-example.rst
+//
+// Builds a complete example config.xml from the configuration structs in
+// the Syncthing source, with the default of every option, and writes it
+// as a code block. Each element is preceded by a comment with the first
+// sentence of the option's description in the configuration page, the
+// attributes described in the comment of their element. Options of the
+// sections without a description are listed on stderr.
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// commentWidth is where the lines of the comments are wrapped.
+const commentWidth = 80
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	doc := flag.String("doc", "../users/config.rst", "Configuration page with the option descriptions")
+	flag.Parse()
+
+	descs, err := readDescriptions(*doc, filepath.Dir(filepath.Dir(*doc)))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+	b, err := newBuilder(root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	cfg, err := b.configuration()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprint(w, ".. This file is generated by _script/configexample; do not edit.\n\n")
+	fmt.Fprint(w, ".. code-block:: xml\n\n")
+	missing := make(map[string]bool)
+	writeElement(w, cfg, "    ", descs, missing)
+	if err := w.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+
+	var names []string
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Printf("no description for %s", name)
+	}
+}
+
+// writeElement writes the element, preceded by the descriptions of it
+// and its attributes, and its children indented below it.
+func writeElement(w io.Writer, el *element, indent string, descs map[string]string, missing map[string]bool) {
+	var notes []string
+	if desc, ok := descs[el.Option]; ok {
+		notes = append(notes, desc)
+	} else if documented(el.Option) {
+		missing[el.Option] = true
+	}
+	for _, a := range el.Attrs {
+		if desc, ok := descs[a.Option]; ok {
+			notes = append(notes, a.Name+": "+desc)
+		} else if documented(a.Option) {
+			missing[a.Option] = true
+		}
+	}
+	writeComment(w, notes, indent)
+
+	fmt.Fprintf(w, "%s<%s", indent, el.Name)
+	for _, a := range el.Attrs {
+		fmt.Fprintf(w, " %s=\"%s\"", a.Name, escape(a.Value))
+	}
+	if len(el.Children) == 0 {
+		fmt.Fprintf(w, ">%s</%s>\n", escape(el.Text), el.Name)
+		return
+	}
+	fmt.Fprint(w, ">\n")
+	for _, c := range el.Children {
+		writeElement(w, c, indent+"    ", descs, missing)
+	}
+	fmt.Fprintf(w, "%s</%s>\n", indent, el.Name)
+}
+
+// documented returns whether the option should have a description: the
+// page describes the options of the sections, not those nested in them,
+// and the sections themselves under their own headings.
+func documented(option string) bool {
+	return strings.Count(option, ".") == 1 && !strings.HasPrefix(option, "configuration.")
+}
+
+// writeComment writes the notes as an XML comment, on one line if it
+// fits and otherwise wrapped. Several notes are set apart by indenting
+// the lines after the first of each.
+func writeComment(w io.Writer, notes []string, indent string) {
+	if len(notes) == 0 {
+		return
+	}
+	for i, n := range notes {
+		// A comment can't contain a double dash.
+		for strings.Contains(n, "--") {
+			n = strings.ReplaceAll(n, "--", "-")
+		}
+		notes[i] = n
+	}
+	if len(notes) == 1 && len(indent)+len(notes[0])+9 <= commentWidth {
+		fmt.Fprintf(w, "%s<!-- %s -->\n", indent, notes[0])
+		return
+	}
+	fmt.Fprintf(w, "%s<!--\n", indent)
+	hanging := ""
+	if len(notes) > 1 {
+		hanging = "    "
+	}
+	for _, n := range notes {
+		lines := wrap(n, commentWidth-len(indent)-len(hanging)-4)
+		fmt.Fprintf(w, "%s    %s\n", indent, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(w, "%s    %s%s\n", indent, hanging, line)
+		}
+	}
+	fmt.Fprintf(w, "%s-->\n", indent)
+}
+
+// wrap splits the text into lines of at most width characters, where
+// the words allow.
+func wrap(s string, width int) []string {
+	var lines []string
+	var cur string
+	for _, word := range strings.Fields(s) {
+		switch {
+		case cur == "":
+			cur = word
+		case len(cur)+1+len(word) > width:
+			lines = append(lines, cur)
+			cur = word
+		default:
+			cur += " " + word
+		}
+	}
+	if cur != "" {
+		lines = append(lines, cur)
+	}
+	return lines
+}
+
+func escape(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
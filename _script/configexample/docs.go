@@ -0,0 +1,207 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// readDescriptions returns the first sentence of the description of each
+// option declared with an option directive in the page, by option name
+// and alias. References without a title are given the title of the page
+// or section they refer to, found in the documentation below root.
+func readDescriptions(page, root string) (map[string]string, error) {
+	lines, err := readLines(page)
+	if err != nil {
+		return nil, err
+	}
+	t, err := readTitles(root)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, page)
+	if err != nil {
+		return nil, err
+	}
+	t.dir = "/" + path.Dir(filepath.ToSlash(rel))
+
+	descs := make(map[string]string)
+	for i := 0; i < len(lines); i++ {
+		name, ok := strings.CutPrefix(lines[i], ".. option::")
+		if !ok {
+			continue
+		}
+		// The names, on the directive line and the ones below it, and
+		// the aliases.
+		names := []string{strings.TrimSpace(name)}
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			line := strings.TrimSpace(lines[i])
+			if aliases, ok := strings.CutPrefix(line, ":aliases:"); ok {
+				names = append(names, strings.Fields(aliases)...)
+			} else if !strings.HasPrefix(line, ":") {
+				names = append(names, line)
+			}
+		}
+		// The body is indented; nested directives such as versionadded
+		// are indented further and skipped.
+		var para []string
+		for i+1 < len(lines) {
+			line := lines[i+1]
+			if strings.TrimSpace(line) == "" {
+				if len(para) > 0 {
+					break
+				}
+				i++
+				continue
+			}
+			if !strings.HasPrefix(line, "    ") {
+				break
+			}
+			i++
+			if strings.HasPrefix(line, "     ") || strings.HasPrefix(strings.TrimSpace(line), "..") {
+				continue
+			}
+			para = append(para, strings.TrimSpace(line))
+		}
+		desc := firstSentence(t.plainText(strings.Join(para, " ")))
+		for _, n := range names {
+			descs[n] = desc
+		}
+	}
+	return descs, nil
+}
+
+func readLines(name string) ([]string, error) {
+	fd, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	var lines []string
+	sc := bufio.NewScanner(fd)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines, sc.Err()
+}
+
+// titles are the titles of the documentation pages, by path without the
+// extension ("/users/config"), and of the labelled sections, by label.
+type titles struct {
+	docs   map[string]string
+	labels map[string]string
+	dir    string // of the page being read, for relative references
+}
+
+var labelExp = regexp.MustCompile(`^\.\. _([^:]+):$`)
+
+func readTitles(root string) (*titles, error) {
+	t := &titles{docs: make(map[string]string), labels: make(map[string]string)}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), "_") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || filepath.Ext(p) != ".rst" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		lines, err := readLines(p)
+		if err != nil {
+			return err
+		}
+		doc := "/" + strings.TrimSuffix(filepath.ToSlash(rel), ".rst")
+		var pending []string // labels waiting for their heading
+		for i, line := range lines {
+			if m := labelExp.FindStringSubmatch(line); m != nil {
+				pending = append(pending, m[1])
+				continue
+			}
+			title := strings.TrimSpace(line)
+			if title == "" || i+1 >= len(lines) || !isUnderline(lines[i+1], len(title)) {
+				continue
+			}
+			if _, ok := t.docs[doc]; !ok {
+				t.docs[doc] = title
+			}
+			for _, l := range pending {
+				t.labels[l] = title
+			}
+			pending = nil
+		}
+		return nil
+	})
+	return t, err
+}
+
+// isUnderline returns whether the line underlines a heading of the length:
+// one punctuation character repeated at least as long.
+func isUnderline(line string, length int) bool {
+	if len(line) < length || len(line) < 2 || !strings.ContainsRune("=-~^*#\"+", rune(line[0])) {
+		return false
+	}
+	return strings.Trim(line, line[:1]) == ""
+}
+
+var (
+	// :ref:`ignore patterns <ignoring-files>`, with an explicit title.
+	titledRoleExp = regexp.MustCompile("(?::[a-z]+)+:`([^`<]*?)\\s*<[^`>]*>`")
+	// :doc:`/users/profiling` and :ref:`releases`, titled by the target.
+	refRoleExp = regexp.MustCompile("(?::std)?:(doc|ref):`([^`]*)`")
+	// :opt:`folder.type` and other roles.
+	roleExp = regexp.MustCompile("(?::[a-z]+)+:`([^`]*)`")
+	// ``literal``, `link`_ and *emphasis*.
+	markupExp   = regexp.MustCompile("``([^`]*)``|`([^`]*)`_*|\\*\\*([^*]*)\\*\\*|\\*([^*]*)\\*")
+	sentenceExp = regexp.MustCompile(`[.!?]\s+[A-Z(]`)
+	spaceExp    = regexp.MustCompile(`\s+`)
+)
+
+// plainText removes the reStructuredText markup from the description.
+func (t *titles) plainText(s string) string {
+	s = titledRoleExp.ReplaceAllString(s, "$1")
+	s = refRoleExp.ReplaceAllStringFunc(s, func(ref string) string {
+		m := refRoleExp.FindStringSubmatch(ref)
+		if m[1] == "ref" {
+			if title, ok := t.labels[m[2]]; ok {
+				return title
+			}
+			return m[2]
+		}
+		target := m[2]
+		if !strings.HasPrefix(target, "/") {
+			target = path.Join(t.dir, target)
+		}
+		if title, ok := t.docs[target]; ok {
+			return title
+		}
+		return m[2]
+	})
+	s = roleExp.ReplaceAllString(s, "$1")
+	s = markupExp.ReplaceAllString(s, "$1$2$3$4")
+	s = strings.ReplaceAll(s, "\\", "")
+	return spaceExp.ReplaceAllString(strings.TrimSpace(s), " ")
+}
+
+// firstSentence returns the text up to the end of the first sentence.
+func firstSentence(s string) string {
+	if loc := sentenceExp.FindStringIndex(s); loc != nil {
+		return s[:loc[0]+1]
+	}
+	return s
+}
@@ -0,0 +1,245 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// configDir is the package with the configuration structs; enumDirs are
+// searched for the values of enumerated types.
+const configDir = "lib/config"
+
+var enumDirs = []string{"lib/config", "lib/fs"}
+
+// rootStruct is the struct of the configuration element.
+const rootStruct = "Configuration"
+
+// exampleDeviceID stands in for the device IDs, which have no default.
+const exampleDeviceID = "S7UKX27-GI7ZTXS-GC6RKUA-7AJGZ44-C6NAYEB-HSKTJQK-KJHU2NO-CWV7EQW"
+
+// examples are the values of options without a default, for the folder
+// and device of the example. The templates in the defaults element keep
+// them empty, as Syncthing does.
+var examples = map[string]string{
+	"folder.id":        "default",
+	"folder.label":     "Default Folder",
+	"folder.device.id": exampleDeviceID,
+	"device.id":        exampleDeviceID,
+	"device.name":      "laptop",
+}
+
+// element is an XML element of the example, with the option names of
+// the element and its attributes for looking up their descriptions.
+type element struct {
+	Name     string
+	Option   string
+	Attrs    []attr
+	Text     string
+	Children []*element
+}
+
+type attr struct {
+	Name, Option, Value string
+}
+
+// builder makes the example elements from the configuration structs.
+type builder struct {
+	config   *stsource.Package
+	structs  map[string]*ast.StructType
+	sections map[string]string // section name by struct name
+	enums    map[string][]string
+}
+
+func newBuilder(root string) (*builder, error) {
+	cfg, err := stsource.ParseDir(root, configDir)
+	if err != nil {
+		return nil, err
+	}
+	b := &builder{
+		config:   cfg,
+		structs:  make(map[string]*ast.StructType),
+		sections: map[string]string{rootStruct: "configuration", "Defaults": "defaults"},
+		enums:    make(map[string][]string),
+	}
+	for _, sec := range stsource.ConfigSections {
+		b.sections[sec.Struct] = sec.Name
+	}
+	for _, f := range cfg.SortedFiles() {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					b.structs[ts.Name.Name] = st
+				}
+			}
+		}
+	}
+	for _, dir := range enumDirs {
+		pkg, err := stsource.ParseDir(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range stsource.EnumValues(pkg) {
+			b.enums[pkg.Name+"."+name] = values
+		}
+	}
+	return b, nil
+}
+
+// configuration returns the example configuration element.
+func (b *builder) configuration() (*element, error) {
+	if b.structs[rootStruct] == nil {
+		return nil, fmt.Errorf("%s: struct %s not found", configDir, rootStruct)
+	}
+	root := &element{Name: "configuration", Option: "configuration"}
+	b.fill(root, rootStruct, "", false)
+	return root, nil
+}
+
+// fill adds the fields of the struct to the element. The options of a
+// section struct are named after the section, those of other structs
+// after the element. A default given for the whole struct, such as "1 %"
+// for a size, is split over its text and attribute.
+func (b *builder) fill(el *element, structName, def string, template bool) {
+	prefix := el.Option
+	if sec, ok := b.sections[structName]; ok {
+		prefix = sec
+	}
+	if structName == "Defaults" {
+		template = true
+	}
+	defs := strings.Fields(def)
+	for _, f := range b.structs[structName].Fields.List {
+		if len(f.Names) != 1 || f.Tag == nil || strings.HasPrefix(f.Names[0].Name, "Deprecated") {
+			continue
+		}
+		tagValue, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+		tags := reflect.StructTag(tagValue)
+		xmlName, xmlOpts, _ := strings.Cut(tags.Get("xml"), ",")
+		jsonName, _, _ := strings.Cut(tags.Get("json"), ",")
+		if xmlName == "-" || jsonName == "-" {
+			continue
+		}
+		fieldDef := tags.Get("default")
+		if fieldDef == "" && len(defs) > 0 {
+			fieldDef, defs = defs[0], defs[1:]
+		}
+		option := prefix + "." + xmlName
+		switch {
+		case xmlOpts == "chardata":
+			el.Text = b.value(f.Type, fieldDef)
+		case strings.Contains(xmlOpts, "attr"):
+			value := b.value(f.Type, fieldDef)
+			if ex, ok := examples[option]; ok && !template {
+				value = ex
+			}
+			if el.Name == "configuration" && xmlName == "version" {
+				value = b.currentVersion()
+			}
+			el.Attrs = append(el.Attrs, attr{xmlName, option, value})
+		case xmlName != "":
+			el.Children = append(el.Children, b.elements(xmlName, option, f.Type, fieldDef, template)...)
+		}
+	}
+}
+
+// elements returns the elements for a field: one for each default of a
+// list, one example of a list of structs, and none for maps, which are
+// written by hand in the configuration code.
+func (b *builder) elements(name, option string, typ ast.Expr, def string, template bool) []*element {
+	switch t := typ.(type) {
+	case *ast.StarExpr:
+		return b.elements(name, option, t.X, def, template)
+	case *ast.MapType:
+		return nil
+	case *ast.ArrayType:
+		if id, ok := t.Elt.(*ast.Ident); ok && b.structs[id.Name] != nil {
+			return b.elements(name, option, t.Elt, "", template)
+		}
+		if def == "" {
+			return []*element{{Name: name, Option: option}}
+		}
+		var els []*element
+		for _, d := range strings.Split(def, ",") {
+			els = append(els, &element{Name: name, Option: option, Text: d})
+		}
+		return els
+	case *ast.Ident:
+		if b.structs[t.Name] != nil {
+			el := &element{Name: name, Option: option}
+			b.fill(el, t.Name, def, template)
+			return []*element{el}
+		}
+	}
+	return []*element{{Name: name, Option: option, Text: b.value(typ, def)}}
+}
+
+// value returns the default, or the zero value of the type as written in
+// the XML.
+func (b *builder) value(typ ast.Expr, def string) string {
+	if def != "" {
+		return def
+	}
+	switch t := typ.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "bool":
+			return "false"
+		case "int", "int32", "int64", "uint32", "uint64", "float32", "float64":
+			return "0"
+		}
+		if values := b.enums[b.config.Name+"."+t.Name]; len(values) > 0 {
+			return values[0]
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			if values := b.enums[pkg.Name+"."+t.Sel.Name]; len(values) > 0 {
+				return values[0]
+			}
+		}
+	}
+	return ""
+}
+
+// currentVersion returns the configuration version of the source.
+func (b *builder) currentVersion() string {
+	for _, f := range b.config.SortedFiles() {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, n := range vs.Names {
+					if n.Name != "CurrentVersion" || i >= len(vs.Values) {
+						continue
+					}
+					if lit, ok := vs.Values[i].(*ast.BasicLit); ok {
+						return lit.Value
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
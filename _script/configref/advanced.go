@@ -0,0 +1,132 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+// guiDir holds the GUI templates. Options bound to a form field in them
+// are set in the normal dialogs, everything else only in the advanced
+// settings dialog.
+const guiDir = "gui/default"
+
+// guiModels maps the scope variables the dialogs edit to the sections.
+// The advanced settings dialog (advancedConfig) binds everything and is
+// deliberately not included.
+var guiModels = map[string]string{
+	"currentFolder": "folder",
+	"currentDevice": "device",
+	"tmpOptions":    "options",
+	"tmpGUI":        "gui",
+}
+
+var ngModelExp = regexp.MustCompile(`ng-model="([a-zA-Z]+)\.([a-zA-Z_]+)`)
+
+// guiOptions returns the options with a form field in the GUI, as
+// section.jsonName.
+func guiOptions(root string) (map[string]bool, error) {
+	opts := make(map[string]bool)
+	err := filepath.WalkDir(filepath.Join(root, guiDir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".html") {
+			return err
+		}
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range ngModelExp.FindAllStringSubmatch(string(bs), -1) {
+			if sec, ok := guiModels[m[1]]; ok {
+				opts[sec+"."+m[2]] = true
+			}
+		}
+		return nil
+	})
+	if len(opts) == 0 && err == nil {
+		err = fmt.Errorf("%s: no form fields found", guiDir)
+	}
+	return opts, err
+}
+
+// advancedFields returns the fields only settable in the advanced
+// settings dialog. The GUI edits some options through helper properties
+// (_addressesStr for addresses); those are matched by prefix.
+func advancedFields(sec section, fields []field, gui map[string]bool) []field {
+	var adv []field
+	for _, f := range fields {
+		if gui[sec.Name+"."+f.JSON] || gui[sec.Name+"._"+f.JSON+"Str"] {
+			continue
+		}
+		adv = append(adv, f)
+	}
+	return adv
+}
+
+// advancedPages maps the normalised names of the pages explaining
+// advanced options (folder-send-xattrs.rst) to the page names.
+func advancedPages(dir string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.rst"))
+	if err != nil {
+		return nil, err
+	}
+	pages := make(map[string]string)
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".rst")
+		pages[normalisePage(name)] = name
+	}
+	return pages, nil
+}
+
+// pageKey returns the normalised page name for an option: options are
+// explained on option-* pages, the others on section-* pages.
+func pageKey(sec section, f field) string {
+	prefix := sec.Name
+	if prefix == "options" {
+		prefix = "option"
+	}
+	return normalisePage(prefix + f.JSON)
+}
+
+func normalisePage(s string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "", ".", "").Replace(s))
+}
+
+func writeAdvanced(w io.Writer, secs []section, fields map[string][]field, pages map[string]string) error {
+	for _, sec := range secs {
+		if len(fields[sec.Name]) == 0 {
+			continue
+		}
+		t := rst.Table{
+			Title:  sec.Title,
+			Header: []string{"Option", "Type", "Default", "Restart", "Since"},
+			Widths: []int{35, 25, 20, 10, 10},
+		}
+		for _, f := range fields[sec.Name] {
+			name := rst.Literal(f.JSON)
+			if page, ok := pages[pageKey(sec, f)]; ok {
+				name = fmt.Sprintf(":doc:`%s </advanced/%s>`", f.JSON, page)
+			}
+			restart := "no"
+			if f.Restart {
+				restart = "yes"
+			}
+			t.Rows = append(t.Rows, []string{name, f.Type, rst.Literal(f.Default), restart, strings.TrimPrefix(f.Since, "v")})
+		}
+		if _, err := t.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
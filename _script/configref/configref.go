@@ -0,0 +1,212 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./configref -tag v1.27.0 -out ../includes
+//
+// Reads the configuration structs from the Syncthing source and writes a
+// reference table per section (config-folder.rst, config-device.rst, ...)
+// with the type, default value and whether a restart is needed for each
+// option. Given -since, a list of older versions, the version introducing
+// each option is included too. With -check, the options are instead
+// compared to the option directives in the configuration page.
+//
+// With -advanced, a table of the options that can only be changed in the
+// advanced settings dialog is written instead, linking to the pages in
+// the advanced directory explaining them; -check then reports the
+// advanced options without such a page.
+//
+// With -coverage, a Markdown report of how many options of each section
+// are documented is written instead, listing the undocumented ones and
+// those documented but no longer in the source. Unlike -check, missing
+// documentation isn't an error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	since := flag.String("since", "", "Comma separated older versions, oldest first, to find when options were added")
+	out := flag.String("out", "", "Directory to write the section tables to")
+	doc := flag.String("doc", "../users/config.rst", "Configuration page with the option directives")
+	check := flag.Bool("check", false, "Report undocumented options and documented options that no longer exist")
+	advanced := flag.Bool("advanced", false, "Write the table of options not in the normal GUI dialogs")
+	advancedDir := flag.String("advanced-dir", "../advanced", "Directory with the advanced option pages")
+	coverage := flag.Bool("coverage", false, "Write a report of the options documented and not")
+	flag.Parse()
+
+	documented, err := readOptions(*doc)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// The older versions are checked out first, leaving a source
+	// directory at the requested version.
+	var history []map[string]bool
+	var historyTags []string
+	if *since != "" {
+		for _, t := range strings.Split(*since, ",") {
+			opts, err := optionsAt(*src, t)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			history = append(history, opts)
+			historyTags = append(historyTags, t)
+		}
+	}
+
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+	s, err := loadSource(root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var gui map[string]bool
+	var pages map[string]string
+	advFields := make(map[string][]field)
+	if *advanced {
+		if gui, err = guiOptions(root); err != nil {
+			log.Fatalln(err)
+		}
+		if pages, err = advancedPages(*advancedDir); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	var problems []string
+	var covs []sectionCoverage
+	for _, sec := range sections {
+		fields, err := s.fields(sec.Struct)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for i := range fields {
+			fields[i].Since = firstSeen(fields[i].Option(sec.Name), history, historyTags)
+		}
+
+		if *advanced {
+			if sec.Name == "defaults" {
+				// Edited in the folder and device dialogs, not as
+				// advanced settings.
+				continue
+			}
+			advFields[sec.Name] = advancedFields(sec, fields, gui)
+			if *check {
+				for _, f := range advFields[sec.Name] {
+					if _, ok := pages[pageKey(sec, f)]; !ok {
+						problems = append(problems, fmt.Sprintf("no advanced page: %s (%s)", f.Option(sec.Name), f.Position))
+					}
+				}
+			}
+			continue
+		}
+
+		if *coverage {
+			covered, missing, stale := compareSection(sec, fields, documented)
+			covs = append(covs, sectionCoverage{sec, covered, missing, stale})
+			continue
+		}
+		if *check {
+			problems = append(problems, checkSection(sec, fields, documented)...)
+			continue
+		}
+
+		w := os.Stdout
+		if *out != "" {
+			fd, err := os.Create(filepath.Join(*out, "config-"+sec.Name+".rst"))
+			if err != nil {
+				log.Fatalln(err)
+			}
+			w = fd
+		} else {
+			fmt.Fprintf(w, ".. %s\n\n", sec.Name)
+		}
+		if err := writeSection(w, sec, fields, documented); err != nil {
+			log.Fatalln(err)
+		}
+		if w != os.Stdout {
+			if err := w.Close(); err != nil {
+				log.Fatalln(err)
+			}
+		}
+	}
+
+	if *coverage {
+		if err := writeCoverage(os.Stdout, *tag, covs); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	if *advanced && !*check {
+		if err := writeAdvanced(os.Stdout, sections, advFields, pages); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// optionsAt returns the option names present in a version.
+func optionsAt(dir, tag string) (map[string]bool, error) {
+	root, cleanup, err := stsource.Open(dir, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	s, err := loadSource(root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	opts := make(map[string]bool)
+	for _, sec := range sections {
+		fields, err := s.fields(sec.Struct)
+		if err != nil {
+			// Sections such as LDAP didn't always exist.
+			continue
+		}
+		for _, f := range fields {
+			opts[f.Option(sec.Name)] = true
+		}
+	}
+	return opts, nil
+}
+
+// firstSeen returns the first version with the option, or the empty
+// string if it's in the oldest one (or there's no history).
+func firstSeen(opt string, history []map[string]bool, tags []string) string {
+	for i, opts := range history {
+		if opts[opt] {
+			if i == 0 {
+				return ""
+			}
+			return tags[i]
+		}
+	}
+	if len(tags) > 0 {
+		// Newer than all of the history.
+		return "after " + tags[len(tags)-1]
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sectionCoverage is how much of a section the configuration page
+// documents.
+type sectionCoverage struct {
+	Section section
+	Covered []field
+	Missing []field
+	Stale   []string
+}
+
+// percent returns the share of the options that are documented.
+func (c sectionCoverage) percent() float64 {
+	total := len(c.Covered) + len(c.Missing)
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(len(c.Covered)) / float64(total)
+}
+
+// writeCoverage writes a Markdown report of the documentation coverage,
+// a summary table followed by the undocumented and removed options of
+// each section.
+func writeCoverage(w io.Writer, version string, secs []sectionCoverage) error {
+	if version == "" {
+		version = "source"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Configuration documentation coverage for %s\n\n", version)
+	sb.WriteString("| Section | Documented | Undocumented | Removed | Coverage |\n")
+	sb.WriteString("|---|--:|--:|--:|--:|\n")
+	var covered, total int
+	for _, c := range secs {
+		fmt.Fprintf(&sb, "| %s | %d | %d | %d | %.0f%% |\n", c.Section.Name, len(c.Covered), len(c.Missing), len(c.Stale), c.percent())
+		covered += len(c.Covered)
+		total += len(c.Covered) + len(c.Missing)
+	}
+	if total > 0 {
+		fmt.Fprintf(&sb, "\nIn total %d of %d options (%.0f%%) are documented.\n", covered, total, 100*float64(covered)/float64(total))
+	}
+
+	for _, c := range secs {
+		if len(c.Missing)+len(c.Stale) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## %s\n", c.Section.Title)
+		if len(c.Missing) > 0 {
+			sb.WriteString("\nUndocumented:\n\n")
+			for _, f := range c.Missing {
+				fmt.Fprintf(&sb, "- `%s` (%s)\n", f.Option(c.Section.Name), f.Position)
+			}
+		}
+		if len(c.Stale) > 0 {
+			sb.WriteString("\nDocumented but no longer in the source:\n\n")
+			for _, opt := range c.Stale {
+				fmt.Fprintf(&sb, "- `%s`\n", opt)
+			}
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,199 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// configDir is the package with the configuration structs; enumDirs are
+// searched for the values of enumerated types.
+const configDir = "lib/config"
+
+var enumDirs = []string{"lib/config", "lib/fs"}
+
+// section is a part of the configuration documented together, named as
+// in the option directives ("folder.rescanIntervalS").
+type section struct {
+	Name   string
+	Struct string
+	Title  string
+}
+
+var sections = []section{
+	{"folder", "FolderConfiguration", "Per Folder Settings"},
+	{"device", "DeviceConfiguration", "Per Device Settings"},
+	{"options", "OptionsConfiguration", "General Settings"},
+	{"gui", "GUIConfiguration", "GUI Settings"},
+	{"ldap", "LDAPConfiguration", "LDAP Settings"},
+	{"defaults", "Defaults", "Defaults for New Folders and Devices"},
+}
+
+type field struct {
+	GoName   string
+	XML      string
+	Attr     bool // XML attribute rather than element
+	JSON     string
+	Type     string
+	Values   []string // of an enumerated type
+	Default  string
+	Restart  bool // changes require a restart
+	Since    string
+	Comment  string
+	Tags     reflect.StructTag
+	Position string
+}
+
+// Option returns the documented name of the field in the section.
+func (f field) Option(sec string) string {
+	return sec + "." + f.XML
+}
+
+// source is the parsed configuration code of one version.
+type source struct {
+	config *stsource.Package
+	enums  map[string][]string // by package qualified type name
+}
+
+func loadSource(root string) (*source, error) {
+	cfg, err := stsource.ParseDir(root, configDir)
+	if err != nil {
+		return nil, err
+	}
+	src := &source{config: cfg, enums: make(map[string][]string)}
+	for _, dir := range enumDirs {
+		pkg, err := stsource.ParseDir(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range stsource.EnumValues(pkg) {
+			src.enums[pkg.Name+"."+name] = values
+		}
+	}
+	return src, nil
+}
+
+// fields returns the documented fields of the struct, in declaration
+// order. Deprecated fields and those not serialised to JSON are skipped,
+// as they can't be set through the API or GUI.
+func (s *source) fields(structName string) ([]field, error) {
+	st := s.findStruct(structName)
+	if st == nil {
+		return nil, fmt.Errorf("%s: struct %s not found", configDir, structName)
+	}
+	var fields []field
+	for _, f := range st.Fields.List {
+		if len(f.Names) != 1 || f.Tag == nil {
+			continue
+		}
+		name := f.Names[0].Name
+		tagValue, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+		tags := reflect.StructTag(tagValue)
+		xmlName, xmlOpts, _ := strings.Cut(tags.Get("xml"), ",")
+		jsonName, _, _ := strings.Cut(tags.Get("json"), ",")
+		if strings.HasPrefix(name, "Deprecated") || jsonName == "-" || xmlName == "-" || xmlName == "" {
+			continue
+		}
+		typ, values := s.typeName(f.Type)
+		fl := field{
+			GoName:  name,
+			XML:     xmlName,
+			Attr:    strings.Contains(xmlOpts, "attr"),
+			JSON:    jsonName,
+			Type:    typ,
+			Values:  values,
+			Default: tags.Get("default"),
+			Restart: tags.Get("restart") != "false",
+			Comment: stsource.DocText(f.Doc),
+			Tags:    tags,
+		}
+		if fl.Comment == "" {
+			fl.Comment = stsource.DocText(f.Comment)
+		}
+		pos, line := s.config.Position(f)
+		fl.Position = pos + ":" + strconv.Itoa(line)
+		fields = append(fields, fl)
+	}
+	return fields, nil
+}
+
+func (s *source) findStruct(name string) *ast.StructType {
+	for _, f := range s.config.SortedFiles() {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || ts.Name.Name != name {
+					continue
+				}
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					return st
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var basicTypes = map[string]string{
+	"string":  "string",
+	"bool":    "boolean",
+	"int":     "integer",
+	"int32":   "integer",
+	"int64":   "integer",
+	"uint32":  "integer",
+	"uint64":  "integer",
+	"float32": "number",
+	"float64": "number",
+	"Size":    "size",
+}
+
+// typeName describes a field type for the reference, returning the
+// values of enumerated types.
+func (s *source) typeName(e ast.Expr) (string, []string) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		if t, ok := basicTypes[e.Name]; ok {
+			return t, nil
+		}
+		if values, ok := s.enums[s.config.Name+"."+e.Name]; ok {
+			return "enum", values
+		}
+		return "element", nil
+	case *ast.SelectorExpr:
+		if pkg, ok := e.X.(*ast.Ident); ok {
+			if values, ok := s.enums[pkg.Name+"."+e.Sel.Name]; ok {
+				return "enum", values
+			}
+		}
+		return "element", nil
+	case *ast.StarExpr:
+		return s.typeName(e.X)
+	case *ast.ArrayType:
+		if id, ok := e.Elt.(*ast.Ident); ok && id.Name == "byte" {
+			return "string", nil
+		}
+		t, values := s.typeName(e.Elt)
+		if t == "element" {
+			return "repeated element", nil
+		}
+		return "list of " + t, values
+	}
+	return "element", nil
+}
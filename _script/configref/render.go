@@ -0,0 +1,132 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+// readOptions returns the option names, including aliases, declared with
+// option directives in the page.
+func readOptions(path string) (map[string]bool, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	opts := make(map[string]bool)
+	inOption := false
+	sc := bufio.NewScanner(fd)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if name, ok := strings.CutPrefix(line, ".. option::"); ok {
+			opts[strings.TrimSpace(name)] = true
+			inOption = true
+			continue
+		}
+		if aliases, ok := strings.CutPrefix(line, ":aliases:"); ok && inOption {
+			for _, a := range strings.Fields(aliases) {
+				opts[a] = true
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, ":") {
+			inOption = false
+		}
+	}
+	return opts, sc.Err()
+}
+
+// writeSection writes the table of the section's options, referring to
+// the section of the configuration page describing them.
+func writeSection(w io.Writer, sec section, fields []field, documented map[string]bool) error {
+	if _, err := fmt.Fprintf(w, "The options are described in :ref:`config-%s`.\n\n", sec.Name); err != nil {
+		return err
+	}
+	t := rst.Table{
+		Title:  sec.Title,
+		Header: []string{"Option", "JSON", "Type", "Default", "Restart", "Since"},
+		Widths: []int{25, 20, 20, 15, 10, 10},
+	}
+	for _, f := range fields {
+		opt := f.Option(sec.Name)
+		name := rst.Literal(opt)
+		if documented[opt] {
+			name = fmt.Sprintf(":opt:`%s`", opt)
+		}
+		typ := f.Type
+		if len(f.Values) > 0 {
+			vs := make([]string, len(f.Values))
+			for i, v := range f.Values {
+				vs[i] = rst.Literal(v)
+			}
+			typ += ": " + strings.Join(vs, ", ")
+		}
+		if f.Attr {
+			typ += " (attribute)"
+		}
+		restart := "no"
+		if f.Restart {
+			restart = "yes"
+		}
+		since := f.Since
+		if since != "" && !strings.HasPrefix(since, "after") {
+			since = strings.TrimPrefix(since, "v")
+		}
+		t.Rows = append(t.Rows, []string{name, rst.Literal(f.JSON), typ, rst.Literal(f.Default), restart, since})
+	}
+	_, err := t.WriteTo(w)
+	return err
+}
+
+// checkSection compares the fields to the documented options.
+func checkSection(sec section, fields []field, documented map[string]bool) []string {
+	_, missing, stale := compareSection(sec, fields, documented)
+	var problems []string
+	for _, f := range missing {
+		problems = append(problems, fmt.Sprintf("undocumented: %s (%s)", f.Option(sec.Name), f.Position))
+	}
+	for _, opt := range stale {
+		problems = append(problems, "no longer exists: "+opt)
+	}
+	return problems
+}
+
+// compareSection returns the fields that are documented and those that
+// aren't, and the documented options of the section that no longer
+// exist.
+func compareSection(sec section, fields []field, documented map[string]bool) (covered, missing []field, stale []string) {
+	inSource := make(map[string]bool)
+	for _, f := range fields {
+		opt := f.Option(sec.Name)
+		inSource[opt] = true
+		// Options are also documented by their JSON name.
+		inSource[sec.Name+"."+f.JSON] = true
+		if documented[opt] || documented[sec.Name+"."+f.JSON] {
+			covered = append(covered, f)
+		} else {
+			missing = append(missing, f)
+		}
+	}
+	for opt := range documented {
+		// Options of child elements (defaults.ignores.lines) aren't
+		// fields of the section.
+		if strings.HasPrefix(opt, sec.Name+".") && strings.Count(opt, ".") == 1 && !inSource[opt] {
+			stale = append(stale, opt)
+		}
+	}
+	sort.Strings(stale)
+	return covered, missing, stale
+}
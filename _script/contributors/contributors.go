@@ -0,0 +1,229 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./contributors -repos ..,../_syncthing -releases ../_syncthing > ../thanks.txt
+//
+// Writes the list of contributors thanked on the front page, from the
+// git histories of the given repositories, most commits first. Authors
+// are identified as the repositories' .mailmap files and the AUTHORS file
+// say, so someone committing under several addresses is listed once,
+// under the name (and nickname) in AUTHORS. Bots are left out.
+//
+// With -releases, a repository with full history and release tags, the
+// contributors whose first commit there was in one of the recent
+// releases are listed by release as well.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+)
+
+// excluded are authors that aren't people, in addition to those named
+// like "dependabot[bot]".
+var excluded = map[string]bool{
+	"Syncthing Release Automation": true,
+	"Weblate":                      true,
+}
+
+func main() {
+	log.SetFlags(0)
+	repos := flag.String("repos", "..", "Comma separated repositories to take the contributors from")
+	authorsFile := flag.String("authors", "../AUTHORS", "AUTHORS file with the names and addresses of authors")
+	releases := flag.String("releases", "", "Repository whose releases to list the new contributors of")
+	recent := flag.Int("recent", 10, "How many of the latest releases to list new contributors for")
+	flag.Parse()
+
+	ids, err := readAuthors(*authorsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	commits := make(map[string]int)
+	for _, repo := range strings.Split(*repos, ",") {
+		authors, err := gitAuthors(repo, "")
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, a := range authors {
+			if name := ids.name(a); name != "" {
+				commits[name]++
+			}
+		}
+	}
+	names := make([]string, 0, len(commits))
+	for name := range commits {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		if commits[names[a]] != commits[names[b]] {
+			return commits[names[a]] > commits[names[b]]
+		}
+		return strings.ToLower(names[a]) < strings.ToLower(names[b])
+	})
+
+	w := bufio.NewWriter(os.Stdout)
+	escaped := make([]string, len(names))
+	for i, n := range names {
+		escaped[i] = rst.Escape(n)
+	}
+	fmt.Fprintln(w, strings.Join(escaped, ", "))
+
+	if *releases != "" {
+		news, err := newContributors(*releases, ids, *recent)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(news) > 0 {
+			fmt.Fprint(w, "\nNew contributors in the latest releases:\n\n")
+			for _, n := range news {
+				for i, name := range n.names {
+					n.names[i] = rst.Escape(name)
+				}
+				fmt.Fprintf(w, "- %s: %s\n", n.tag, strings.Join(n.names, ", "))
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+type release struct {
+	tag   string
+	names []string
+}
+
+// newContributors returns the latest releases with the authors whose
+// first commit was in them, newest first. Releases without new
+// contributors count towards the recent ones but aren't returned.
+func newContributors(repo string, ids identities, recent int) ([]release, error) {
+	out, err := git(repo, "tag", "--list", "v*")
+	if err != nil {
+		return nil, err
+	}
+	var versions []relnotes.Version
+	for _, tag := range strings.Fields(out) {
+		if v, ok := relnotes.ParseVersion(tag); ok {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(a, b int) bool { return versions[a].Less(versions[b]) })
+	if len(versions) <= recent {
+		return nil, fmt.Errorf("%s: need more than %d release tags", repo, recent)
+	}
+
+	// Everyone in the history before the first of the recent releases
+	// is already a contributor.
+	start := len(versions) - recent
+	seen := make(map[string]bool)
+	before, err := gitAuthors(repo, versions[start-1].String())
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range before {
+		seen[ids.name(a)] = true
+	}
+
+	var res []release
+	for i := start; i < len(versions); i++ {
+		authors, err := gitAuthors(repo, versions[i-1].String()+".."+versions[i].String())
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		// Oldest commit first, so the names are in order of arrival.
+		for j := len(authors) - 1; j >= 0; j-- {
+			name := ids.name(authors[j])
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			res = append(res, release{versions[i].String(), names})
+		}
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res, nil
+}
+
+// author is the author of a commit, after the repository's .mailmap.
+type author struct {
+	name, email string
+}
+
+// gitAuthors returns the authors of the commits in the revision range,
+// or all of them, newest first.
+func gitAuthors(repo, revs string) ([]author, error) {
+	args := []string{"log", "--use-mailmap", "--format=%aN%x00%aE"}
+	if revs != "" {
+		args = append(args, revs)
+	}
+	out, err := git(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	var res []author
+	for _, line := range strings.Split(out, "\n") {
+		if name, email, ok := strings.Cut(line, "\x00"); ok {
+			res = append(res, author{strings.TrimSpace(name), strings.ToLower(strings.TrimSpace(email))})
+		}
+	}
+	return res, nil
+}
+
+// identities maps the addresses in AUTHORS to the names there.
+type identities map[string]string
+
+// authorsLineExp matches "Name Name (nickname) <email1> <email2>".
+var authorsLineExp = regexp.MustCompile(`^([^<]+?)\s*((?:<[^>]+>\s*)+)$`)
+
+func readAuthors(file string) (identities, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(identities)
+	for _, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := authorsLineExp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for _, email := range strings.FieldsFunc(m[2], func(r rune) bool { return r == '<' || r == '>' || r == ' ' }) {
+			ids[strings.ToLower(email)] = m[1]
+		}
+	}
+	return ids, nil
+}
+
+// name returns the name to list the author under, or the empty string
+// for bots.
+func (ids identities) name(a author) string {
+	name := a.name
+	if n, ok := ids[a.email]; ok {
+		name = n
+	}
+	if excluded[name] || strings.HasSuffix(name, "[bot]") {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,167 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./darkimages [-syncthing path/to/syncthing] [-check]
+//
+// Writes the dark variants of the images listed in images.json, next to
+// each with -dark before the extension, which the dark_images extension
+// serves to readers preferring a dark color scheme. Diagrams are
+// inverted: their lightness is flipped while the hues are kept, so white
+// backgrounds become the dark page background and dark text light.
+// Screenshots are captured in the dark GUI theme instead, by the
+// screenshots tool, when -syncthing is given; they need the dark theme
+// in shots.json.
+//
+// With -check, nothing is written; diagrams whose variant is missing or
+// out of date and screenshots without one are reported, and the exit
+// status is 1 if there are any.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const darkTheme = "dark"
+
+// imageList is the images with dark variants, relative to the docs root.
+type imageList struct {
+	// Invert are diagrams, inverted.
+	Invert []string `json:"invert"`
+	// Capture are screenshots, captured in the dark theme.
+	Capture []string `json:"capture"`
+}
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	list := flag.String("images", "darkimages/images.json", "Images to write dark variants of")
+	shotsFile := flag.String("shots", "screenshots/shots.json", "Screenshot definitions of the screenshots tool")
+	binary := flag.String("syncthing", "", "Syncthing binary to capture the dark screenshots with")
+	check := flag.Bool("check", false, "Report missing and outdated variants instead of writing them")
+	flag.Parse()
+
+	images, err := readImages(*list)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := checkShots(*shotsFile, images.Capture); err != nil {
+		log.Fatalln(err)
+	}
+
+	var problems []string
+	for _, file := range images.Invert {
+		name := filepath.Join(*root, filepath.FromSlash(file))
+		bs, err := invertFile(name)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		dark := filepath.Join(*root, filepath.FromSlash(darkFile(file)))
+		if *check {
+			cur, err := os.ReadFile(dark)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				problems = append(problems, file+": no dark variant")
+			case err != nil:
+				log.Fatalln(err)
+			case !bytes.Equal(cur, bs):
+				problems = append(problems, file+": dark variant out of date")
+			}
+			continue
+		}
+		if err := os.WriteFile(dark, bs, 0o644); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if *binary != "" && !*check && len(images.Capture) > 0 {
+		cmd := exec.Command("go", "run", "./screenshots", "-syncthing", *binary, "-out", *root, "-only", strings.Join(images.Capture, ","))
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			log.Fatalln("screenshots:", err)
+		}
+		return
+	}
+	for _, file := range images.Capture {
+		if _, err := os.Stat(filepath.Join(*root, filepath.FromSlash(darkFile(file)))); err != nil {
+			problems = append(problems, file+": no dark variant, capture it with -syncthing")
+		}
+	}
+
+	for _, p := range problems {
+		log.Println(p)
+	}
+	if *check && len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+func readImages(name string) (*imageList, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var l imageList
+	if err := json.Unmarshal(bs, &l); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, file := range l.Invert {
+		if !inverters[strings.ToLower(path.Ext(file))] {
+			return nil, fmt.Errorf("%s: %s: can't invert %s images", name, file, path.Ext(file))
+		}
+	}
+	return &l, nil
+}
+
+// checkShots checks that the screenshots are captured in the dark theme
+// by the screenshots tool, under the name darkFile expects.
+func checkShots(name string, files []string) error {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	var l struct {
+		Shots []struct {
+			File   string   `json:"file"`
+			Themes []string `json:"themes"`
+		} `json:"shots"`
+	}
+	if err := json.Unmarshal(bs, &l); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	dark := make(map[string]bool)
+	for _, s := range l.Shots {
+		for _, t := range s.Themes {
+			if t == darkTheme {
+				dark[s.File] = true
+			}
+		}
+	}
+	for _, file := range files {
+		if !dark[file] {
+			return fmt.Errorf("%s: not captured in the %s theme in %s", file, darkTheme, name)
+		}
+	}
+	return nil
+}
+
+// darkFile returns the name of the dark variant of an image, as the
+// screenshots tool names those in the dark theme.
+func darkFile(file string) string {
+	ext := path.Ext(file)
+	return strings.TrimSuffix(file, ext) + "-" + darkTheme + ext
+}
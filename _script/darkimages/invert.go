@@ -0,0 +1,192 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The lightness range of the inverted images: white becomes the page
+// background of the dark color scheme in custom.css, #1c1c1c, and black
+// a light gray rather than white, to not glare.
+const (
+	minLightness = 0.11
+	maxLightness = 0.93
+)
+
+// inverters are the image types that can be inverted.
+var inverters = map[string]bool{
+	".svg":  true,
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+}
+
+// invertFile returns the inverted contents of an image file.
+func invertFile(name string) ([]byte, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".svg" {
+		return invertSVG(bs), nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(bs))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	b := img.Bounds()
+	out := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			out.SetNRGBA(x, y, invert(color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)))
+		}
+	}
+	var buf bytes.Buffer
+	if ext == ".png" {
+		err = png.Encode(&buf, out)
+	} else {
+		err = jpeg.Encode(&buf, out, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// svgColorExp matches the colors of the fill, stroke and similar
+// attributes and style properties.
+var svgColorExp = regexp.MustCompile(`((?:fill|stroke|stop-color|flood-color|color)\s*(?:=\s*["']|:\s*))(#[0-9a-fA-F]{6}\b|#[0-9a-fA-F]{3}\b|rgb\(\s*\d+\s*,\s*\d+\s*,\s*\d+\s*\)|white|black)`)
+
+// invertSVG inverts the colors of an SVG image. Images without a
+// background rectangle stay transparent.
+func invertSVG(bs []byte) []byte {
+	return svgColorExp.ReplaceAllFunc(bs, func(m []byte) []byte {
+		sub := svgColorExp.FindSubmatch(m)
+		c, ok := parseColor(string(sub[2]))
+		if !ok {
+			return m
+		}
+		c = invert(c)
+		return []byte(fmt.Sprintf("%s#%02x%02x%02x", sub[1], c.R, c.G, c.B))
+	})
+}
+
+func parseColor(s string) (color.NRGBA, bool) {
+	switch {
+	case s == "white":
+		return color.NRGBA{255, 255, 255, 255}, true
+	case s == "black":
+		return color.NRGBA{0, 0, 0, 255}, true
+	case strings.HasPrefix(s, "rgb("):
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(s, "rgb("), ")"), ",")
+		var c [3]uint8
+		for i, p := range parts {
+			v, err := strconv.ParseUint(strings.TrimSpace(p), 10, 8)
+			if err != nil {
+				return color.NRGBA{}, false
+			}
+			c[i] = uint8(v)
+		}
+		return color.NRGBA{c[0], c[1], c[2], 255}, true
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, false
+	}
+	return color.NRGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, true
+}
+
+// invert flips the lightness of a color, keeping its hue and
+// saturation.
+func invert(c color.NRGBA) color.NRGBA {
+	h, s, l := toHSL(c)
+	l = minLightness + (1-l)*(maxLightness-minLightness)
+	res := fromHSL(h, s, l)
+	res.A = c.A
+	return res
+}
+
+func toHSL(c color.NRGBA) (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+	switch max {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h / 6, s, l
+}
+
+func fromHSL(h, s, l float64) color.NRGBA {
+	if s == 0 {
+		v := uint8(math.Round(l * 255))
+		return color.NRGBA{v, v, v, 255}
+	}
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	channel := func(t float64) uint8 {
+		switch {
+		case t < 0:
+			t++
+		case t > 1:
+			t--
+		}
+		var v float64
+		switch {
+		case t < 1.0/6:
+			v = p + (q-p)*6*t
+		case t < 1.0/2:
+			v = q
+		case t < 2.0/3:
+			v = p + (q-p)*(2.0/3-t)*6
+		default:
+			v = p
+		}
+		return uint8(math.Round(v * 255))
+	}
+	return color.NRGBA{channel(h + 1.0/3), channel(h), channel(h - 1.0/3), 255}
+}
@@ -0,0 +1,217 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./deprecations [-src ../_syncthing] [-inject] -out ../includes
+//
+// Reads the deprecations manifest, users/deprecations.csv, listing the
+// deprecated configuration options (folder.autoNormalize) and REST
+// endpoints (GET /rest/system/config) with the version they were
+// deprecated in, the version they were removed in if they have been, and
+// what to use instead. Writes the table of all deprecations for the
+// deprecations page, deprecations.rst, and a deprecated directive per
+// entry in the deprecated directory, for the reference sections to
+// include.
+//
+// Given -src, the manifest is first checked against the source: entries
+// not removed must still be there, and removed ones must be gone. With
+// -inject, the includes are added to the option directives in
+// users/config.rst and to the endpoint pages that lack them.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/stsource"
+)
+
+// deprecation is an entry in the manifest.
+type deprecation struct {
+	Name        string // option or method and endpoint path
+	Deprecated  string
+	Removed     string
+	Replacement string // an option, an endpoint or RST text
+	Line        int
+}
+
+var endpointExp = regexp.MustCompile(`^(GET|POST|PUT|PATCH|DELETE) (/rest/\S+)$`)
+
+// endpoint returns the method and path of an endpoint entry.
+func (d deprecation) endpoint() (string, string, bool) {
+	return splitEndpoint(d.Name)
+}
+
+func splitEndpoint(s string) (string, string, bool) {
+	m := endpointExp.FindStringSubmatch(s)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// page returns the name of the page documenting an endpoint, as in the
+// rest directory: system-config-get.
+func page(method, path string) string {
+	name := strings.Trim(strings.TrimPrefix(path, "/rest/"), "/")
+	return strings.ReplaceAll(name, "/", "-") + "-" + strings.ToLower(method)
+}
+
+// include returns the file name, without extension, of the entry's
+// directive in the deprecated directory.
+func (d deprecation) include() string {
+	if method, path, ok := d.endpoint(); ok {
+		return "rest-" + page(method, path)
+	}
+	return "config-" + strings.ReplaceAll(d.Name, ".", "-")
+}
+
+func main() {
+	log.SetFlags(0)
+	manifest := flag.String("manifest", "../users/deprecations.csv", "Deprecations manifest")
+	src := flag.String("src", "", "Syncthing source directory to check the manifest against")
+	out := flag.String("out", "", "Directory to write the includes to")
+	inject := flag.Bool("inject", false, "Add the includes to the reference sections lacking them")
+	root := flag.String("root", "..", "Documentation root, for -inject")
+	flag.Parse()
+
+	deps, err := readManifest(*manifest)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	problems := checkManifest(deps)
+	if *src != "" {
+		p, err := checkSource(*src, deps)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		problems = append(problems, p...)
+	}
+	for _, p := range problems {
+		fmt.Printf("%s: %s\n", *manifest, p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+
+	if *out != "" {
+		if err := writeIncludes(*out, deps); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if *inject {
+		n, err := injectIncludes(*root, deps)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("added %d includes", n)
+	}
+}
+
+func readManifest(file string) ([]deprecation, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	cr := csv.NewReader(fd)
+	cr.FieldsPerRecord = 4
+	var deps []deprecation
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if line == 1 {
+			// Header
+			continue
+		}
+		deps = append(deps, deprecation{
+			Name:        strings.TrimSpace(rec[0]),
+			Deprecated:  strings.TrimSpace(rec[1]),
+			Removed:     strings.TrimSpace(rec[2]),
+			Replacement: strings.TrimSpace(rec[3]),
+			Line:        line,
+		})
+	}
+	return deps, nil
+}
+
+// checkManifest returns the problems with the entries themselves.
+func checkManifest(deps []deprecation) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, d := range deps {
+		if seen[d.Name] {
+			res = append(res, fmt.Sprintf("%d: %s is listed twice", d.Line, d.Name))
+		}
+		seen[d.Name] = true
+		if _, _, ok := d.endpoint(); !ok && !optionExp.MatchString(d.Name) {
+			res = append(res, fmt.Sprintf("%d: %q is neither an option nor a method and endpoint", d.Line, d.Name))
+		}
+		dep, ok := relnotes.ParseVersion("v" + d.Deprecated)
+		if !ok {
+			res = append(res, fmt.Sprintf("%d: %s: deprecated version %q is not a version", d.Line, d.Name, d.Deprecated))
+		}
+		if d.Removed == "" {
+			continue
+		}
+		rem, ok := relnotes.ParseVersion("v" + d.Removed)
+		switch {
+		case !ok:
+			res = append(res, fmt.Sprintf("%d: %s: removed version %q is not a version", d.Line, d.Name, d.Removed))
+		case rem.Less(dep):
+			res = append(res, fmt.Sprintf("%d: %s: removed in %s, before it was deprecated", d.Line, d.Name, d.Removed))
+		}
+	}
+	return res
+}
+
+var optionExp = regexp.MustCompile(`^(folder|device|options|gui|ldap)\.[A-Za-z0-9]+$`)
+
+// checkSource returns the entries that disagree with the source about
+// whether they've been removed.
+func checkSource(root string, deps []deprecation) ([]string, error) {
+	paths, err := stsource.Endpoints(root)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := stsource.Options(root)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool)
+	for _, p := range paths {
+		present[p] = true
+	}
+	for _, o := range opts {
+		present[o] = true
+	}
+
+	var res []string
+	for _, d := range deps {
+		name := d.Name
+		if _, path, ok := d.endpoint(); ok {
+			name = path
+		}
+		switch {
+		case d.Removed == "" && !present[name]:
+			res = append(res, fmt.Sprintf("%d: %s is not in the source; when was it removed?", d.Line, d.Name))
+		case d.Removed != "" && present[name]:
+			res = append(res, fmt.Sprintf("%d: %s is listed as removed in %s but is in the source", d.Line, d.Name, d.Removed))
+		}
+	}
+	return res, nil
+}
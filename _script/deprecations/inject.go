@@ -0,0 +1,86 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configPage is the page with the option directives.
+const configPage = "users/config.rst"
+
+// injectIncludes adds the include of each entry's directive to the
+// option directive or endpoint page documenting it, where it's not
+// already included, and returns how many were added.
+func injectIncludes(root string, deps []deprecation) (int, error) {
+	n := 0
+	for _, d := range deps {
+		inc := "/includes/deprecated/" + d.include() + ".rst"
+		file := configPage
+		if method, path, ok := d.endpoint(); ok {
+			file = "rest/" + page(method, path) + ".rst"
+		}
+		file = filepath.Join(root, filepath.FromSlash(file))
+		bs, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			log.Printf("%s: not documented, no %s", d.Name, file)
+			continue
+		} else if err != nil {
+			return n, err
+		}
+		if strings.Contains(string(bs), inc) {
+			continue
+		}
+
+		lines := strings.Split(string(bs), "\n")
+		var at int
+		var indent string
+		if _, _, ok := d.endpoint(); ok {
+			// After the title and its underline.
+			at = 2
+		} else if at = optionContent(lines, d.Name); at < 0 {
+			log.Printf("%s: no option directive in %s", d.Name, file)
+			continue
+		} else {
+			indent = "    "
+		}
+		if at > len(lines) {
+			at = len(lines)
+		}
+		// The include goes in a paragraph of its own.
+		ins := []string{"", indent + ".. include:: " + inc}
+		if at < len(lines) && strings.TrimSpace(lines[at]) == "" {
+			at++
+			ins = append(ins[1:], "")
+		}
+		lines = append(lines[:at], append(ins, lines[at:]...)...)
+		if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// optionContent returns the line after the option directive and its
+// options, or -1 if there's no directive for the option.
+func optionContent(lines []string, name string) int {
+	for i, l := range lines {
+		if strings.TrimSpace(l) != ".. option:: "+name {
+			continue
+		}
+		i++
+		for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ":") {
+			i++
+		}
+		return i
+	}
+	return -1
+}
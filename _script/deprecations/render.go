@@ -0,0 +1,90 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+const generated = ".. This file is generated by _script/deprecations; do not edit.\n\n"
+
+// writeIncludes writes the table of deprecations and replaces the
+// directives in the deprecated directory.
+func writeIncludes(dir string, deps []deprecation) error {
+	var sb strings.Builder
+	sb.WriteString(generated)
+	t := rst.Table{
+		Title:  "Deprecations",
+		Header: []string{"Option or Endpoint", "Deprecated", "Removed", "Replacement"},
+		Widths: []int{30, 10, 10, 50},
+	}
+	for _, d := range deps {
+		t.Rows = append(t.Rows, []string{reference(d.Name), d.Deprecated, d.Removed, reference(d.Replacement)})
+	}
+	if _, err := t.WriteTo(&sb); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "deprecations.rst"), []byte(sb.String()), 0o644); err != nil {
+		return err
+	}
+
+	depDir := filepath.Join(dir, "deprecated")
+	old, err := filepath.Glob(filepath.Join(depDir, "*.rst"))
+	if err != nil {
+		return err
+	}
+	for _, f := range old {
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(depDir, 0o755); err != nil {
+		return err
+	}
+	for _, d := range deps {
+		if err := os.WriteFile(filepath.Join(depDir, d.include()+".rst"), []byte(generated+directive(d)), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// directive returns the deprecated directive for the entry.
+func directive(d deprecation) string {
+	var text []string
+	if _, _, ok := d.endpoint(); ok && d.Removed == "" {
+		text = append(text, "This endpoint still works as before but is deprecated.")
+	}
+	if d.Removed != "" {
+		text = append(text, fmt.Sprintf("Removed in %s.", d.Removed))
+	}
+	if d.Replacement != "" {
+		text = append(text, fmt.Sprintf("Use %s instead.", reference(d.Replacement)))
+	}
+	s := ".. deprecated:: " + d.Deprecated + "\n"
+	if len(text) > 0 {
+		s += "   " + strings.Join(text, " ") + "\n"
+	}
+	return s
+}
+
+// reference returns an option or endpoint as a link to its
+// documentation; anything else is RST already.
+func reference(s string) string {
+	if method, path, ok := splitEndpoint(s); ok {
+		return fmt.Sprintf(":doc:`%s %s </rest/%s>`", method, path, page(method, path))
+	}
+	if optionExp.MatchString(s) {
+		return ":opt:`" + s + "`"
+	}
+	return s
+}
@@ -0,0 +1,309 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./dockertags > ../includes/docker-tags.rst
+//
+// This script lists the tags of the official Syncthing Docker images, with
+// their digests, platforms and publish dates, and prints them as RST tables
+// for the Docker section of the releases page.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+	"syncthing.net/docs/internal/rst"
+)
+
+type imageTag struct {
+	Name      string
+	Digest    string
+	Platforms []string
+	Pushed    time.Time
+}
+
+var (
+	// Tags that move with each release.
+	rollingNames  = map[string]bool{"latest": true, "rc": true, "nightly": true, "edge": true}
+	rollingSeries = regexp.MustCompile(`^v?\d+(\.\d+)?$`)
+	// Tags that always point at the same release.
+	pinnedVersion = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)$`)
+)
+
+func main() {
+	registry := flag.String("registry", "dockerhub", "Registry to query (dockerhub, ghcr)")
+	repo := flag.String("repo", "syncthing/syncthing", "Image repository")
+	maxPinned := flag.Int("max-pinned", 10, "Number of most recent pinned version tags to list")
+	flag.Parse()
+
+	var tags []imageTag
+	var err error
+	switch *registry {
+	case "dockerhub":
+		tags, err = dockerHubTags(*repo)
+	case "ghcr":
+		tags, err = ghcrTags(*repo)
+	default:
+		log.Fatalln("Unknown registry", *registry)
+	}
+	if err != nil {
+		log.Fatalln("Listing tags:", err)
+	}
+
+	var rolling, pinned []imageTag
+	for _, t := range tags {
+		switch {
+		case rollingNames[t.Name] || rollingSeries.MatchString(t.Name):
+			rolling = append(rolling, t)
+		case pinnedVersion.MatchString(t.Name):
+			pinned = append(pinned, t)
+		}
+	}
+	sort.Slice(rolling, func(a, b int) bool {
+		return rollingRank(rolling[a].Name) < rollingRank(rolling[b].Name) ||
+			rollingRank(rolling[a].Name) == rollingRank(rolling[b].Name) && compareTags(rolling[a].Name, rolling[b].Name) > 0
+	})
+	sort.Slice(pinned, func(a, b int) bool {
+		return compareTags(pinned[a].Name, pinned[b].Name) > 0
+	})
+	if len(pinned) > *maxPinned {
+		pinned = pinned[:*maxPinned]
+	}
+
+	image := *repo
+	if *registry == "ghcr" {
+		image = "ghcr.io/" + image
+	}
+	fmt.Println(".. This file is generated by _script/dockertags; do not edit.")
+	fmt.Println()
+	// The headings go below the Docker section of the releases page.
+	fmt.Print(rst.Heading("Rolling tags", '"'))
+	fmt.Println("These tags move to a new image whenever a matching release is made.")
+	fmt.Println()
+	writeTable(image, "Rolling tags", rolling)
+	fmt.Print(rst.Heading("Pinned tags", '"'))
+	fmt.Println("These tags always refer to the same release.")
+	fmt.Println()
+	writeTable(image, "Pinned tags", pinned)
+}
+
+func writeTable(image, title string, tags []imageTag) {
+	t := rst.Table{
+		Title:  title,
+		Header: []string{"Tag", "Digest", "Platforms", "Published"},
+	}
+	for _, tag := range tags {
+		digest := tag.Digest
+		if len(digest) > 19 {
+			// sha256: plus twelve hex digits is plenty to tell them apart
+			digest = digest[:19]
+		}
+		published := ""
+		if !tag.Pushed.IsZero() {
+			published = tag.Pushed.UTC().Format("2006-01-02")
+		}
+		t.Rows = append(t.Rows, []string{
+			rst.Literal(image + ":" + tag.Name),
+			rst.Literal(digest),
+			strings.Join(tag.Platforms, ", "),
+			published,
+		})
+	}
+	if _, err := t.WriteTo(os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func rollingRank(name string) int {
+	switch name {
+	case "latest":
+		return 0
+	case "rc":
+		return 1
+	case "nightly", "edge":
+		return 2
+	default:
+		return 3
+	}
+}
+
+// compareTags compares version-like tags numerically.
+func compareTags(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, _ := strconv.Atoi(as[i])
+		bn, _ := strconv.Atoi(bs[i])
+		if an != bn {
+			return an - bn
+		}
+	}
+	return len(as) - len(bs)
+}
+
+func dockerHubTags(repo string) ([]imageTag, error) {
+	next := fmt.Sprintf("https://hub.docker.com/v2/repositories/%s/tags?page_size=100", repo)
+	var tags []imageTag
+	for next != "" {
+		var page struct {
+			Next    string `json:"next"`
+			Results []struct {
+				Name       string    `json:"name"`
+				Digest     string    `json:"digest"`
+				LastPushed time.Time `json:"tag_last_pushed"`
+				Images     []struct {
+					OS           string `json:"os"`
+					Architecture string `json:"architecture"`
+					Variant      string `json:"variant"`
+				} `json:"images"`
+			} `json:"results"`
+		}
+		if err := getJSON(next, "", &page); err != nil {
+			return nil, err
+		}
+		for _, r := range page.Results {
+			t := imageTag{Name: r.Name, Digest: r.Digest, Pushed: r.LastPushed}
+			for _, img := range r.Images {
+				t.Platforms = append(t.Platforms, platform(img.OS, img.Architecture, img.Variant))
+			}
+			sort.Strings(t.Platforms)
+			tags = append(tags, t)
+		}
+		next = page.Next
+	}
+	return tags, nil
+}
+
+// ghcrTags lists tags using the registry API with an anonymous token. The
+// registry doesn't report push dates, so those are left empty.
+func ghcrTags(repo string) ([]imageTag, error) {
+	var tok struct {
+		Token string `json:"token"`
+	}
+	if err := getJSON(fmt.Sprintf("https://ghcr.io/token?scope=repository:%s:pull", repo), "", &tok); err != nil {
+		return nil, err
+	}
+	// The list comes in pages, each linking to the next.
+	var names []string
+	next := fmt.Sprintf("https://ghcr.io/v2/%s/tags/list?n=1000", repo)
+	for next != "" {
+		var list struct {
+			Tags []string `json:"tags"`
+		}
+		header, err := fetchJSON(next, tok.Token, "", &list)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, list.Tags...)
+		if next, err = nextLink(next, header); err != nil {
+			return nil, err
+		}
+	}
+
+	var tags []imageTag
+	for _, name := range names {
+		if !rollingNames[name] && !rollingSeries.MatchString(name) && !pinnedVersion.MatchString(name) {
+			continue
+		}
+		var index struct {
+			Manifests []struct {
+				Platform struct {
+					OS           string `json:"os"`
+					Architecture string `json:"architecture"`
+					Variant      string `json:"variant"`
+				} `json:"platform"`
+			} `json:"manifests"`
+		}
+		manifest := fmt.Sprintf("https://ghcr.io/v2/%s/manifests/%s", repo, name)
+		header, err := fetchJSON(manifest, tok.Token, "application/vnd.oci.image.index.v1+json, application/vnd.docker.distribution.manifest.list.v2+json", &index)
+		if err != nil {
+			return nil, err
+		}
+		t := imageTag{Name: name, Digest: header.Get("Docker-Content-Digest")}
+		for _, m := range index.Manifests {
+			if m.Platform.OS == "unknown" {
+				// Attestation manifests
+				continue
+			}
+			t.Platforms = append(t.Platforms, platform(m.Platform.OS, m.Platform.Architecture, m.Platform.Variant))
+		}
+		sort.Strings(t.Platforms)
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+func platform(goos, arch, variant string) string {
+	p := goos + "/" + arch
+	if variant != "" {
+		p += "/" + variant
+	}
+	return p
+}
+
+func getJSON(url, token string, v any) error {
+	_, err := fetchJSON(url, token, "", v)
+	return err
+}
+
+// fetchJSON decodes the response to a GET of the URL into v, sending the
+// bearer token and Accept header when set, and returns its header.
+func fetchJSON(url, token, accept string, v any) (http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := httpclient.Default.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	return resp.Header, nil
+}
+
+// linkNext matches the next page in a Link header.
+var linkNext = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextLink returns the URL of the next page named by the Link header of
+// the response to the URL, resolved against it, or "" on the last page.
+func nextLink(base string, header http.Header) (string, error) {
+	m := linkNext.FindStringSubmatch(header.Get("Link"))
+	if m == nil {
+		return "", nil
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(m[1])
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(ref).String(), nil
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// altTextConfigFile is the waivers of the alttext check, set by a flag.
+var altTextConfigFile = "docscheck/alttext.json"
+
+// altTODO is the alt text stub inserted by -fix-alt, which the alttext
+// check reports like a missing alt text.
+const altTODO = "TODO"
+
+// generatedPrefix starts the files written by the tools in _script.
+const generatedPrefix = ".. This file is generated by"
+
+// altTextConfig waives the alttext check for parts of the docs.
+type altTextConfig struct {
+	// Waivers are what isn't reported, "alt" or "caption", by directory
+	// or file relative to the documentation root.
+	Waivers map[string][]string `json:"waivers"`
+}
+
+// waived reports whether the kind of problem is waived for the file.
+func (c *altTextConfig) waived(file, kind string) bool {
+	for p := file; p != "." && p != "/"; p = filepath.ToSlash(filepath.Dir(p)) {
+		if contains(c.Waivers[p], kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAltText reports images and figures without alt text, or with the
+// stub inserted by -fix-alt, and figures without a caption, unless
+// waived in alttext.json.
+func checkAltText(t *rstdoc.Tree) []problem {
+	cfg, err := readAltTextConfig(altTextConfigFile)
+	if err != nil {
+		return []problem{{rstdoc.Pos{File: altTextConfigFile, Line: 1}, err.Error()}}
+	}
+	var res []problem
+	for _, dir := range imageDirectives(t) {
+		alt := strings.TrimSpace(dir.Options["alt"])
+		switch {
+		case cfg.waived(dir.Pos.File, "alt"):
+		case alt == "":
+			res = append(res, problem{dir.Pos, fmt.Sprintf("%s %q has no alt text", dir.Name, dir.Arg)})
+		case strings.HasPrefix(alt, altTODO):
+			res = append(res, problem{dir.Pos, fmt.Sprintf("%s %q has a TODO alt text", dir.Name, dir.Arg)})
+		}
+		if dir.Name == "figure" && len(dir.Content) == 0 && !cfg.waived(dir.Pos.File, "caption") {
+			res = append(res, problem{dir.Pos, fmt.Sprintf("figure %q has no caption", dir.Arg)})
+		}
+	}
+	return res
+}
+
+func readAltTextConfig(name string) (*altTextConfig, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var cfg altTextConfig
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &cfg, nil
+}
+
+// imageDirectives returns the image and figure directives, including
+// image substitution definitions, once each.
+func imageDirectives(t *rstdoc.Tree) []*rstdoc.Directive {
+	seen := make(map[rstdoc.Pos]bool)
+	var res []*rstdoc.Directive
+	for _, d := range t.Docs {
+		for _, dir := range d.Directives {
+			if (dir.Name == "image" || dir.Name == "figure") && !seen[dir.Pos] {
+				seen[dir.Pos] = true
+				res = append(res, dir)
+			}
+		}
+	}
+	return res
+}
+
+// fixAltText inserts the TODO alt text stub in the images and figures
+// without alt text, except in generated files, returning the number of
+// stubs inserted.
+func fixAltText(t *rstdoc.Tree) (int, error) {
+	byFile := make(map[string][]int)
+	for _, dir := range imageDirectives(t) {
+		if strings.TrimSpace(dir.Options["alt"]) == "" {
+			byFile[dir.Pos.File] = append(byFile[dir.Pos.File], dir.Pos.Line)
+		}
+	}
+
+	fixed := 0
+	for file, lines := range byFile {
+		name := filepath.Join(t.Root, filepath.FromSlash(file))
+		bs, err := os.ReadFile(name)
+		if err != nil {
+			return fixed, err
+		}
+		if bytes.HasPrefix(bs, []byte(generatedPrefix)) {
+			fmt.Printf("%s: generated; fix the alt text in its generator\n", file)
+			continue
+		}
+		src := strings.Split(string(bs), "\n")
+		// From the end, so that the earlier line numbers stay valid.
+		sort.Sort(sort.Reverse(sort.IntSlice(lines)))
+		for _, line := range lines {
+			directive := src[line-1]
+			indent := directive[:len(directive)-len(strings.TrimLeft(directive, " "))]
+			stub := indent + "   :alt: " + altTODO
+			src = append(src[:line], append([]string{stub}, src[line:]...)...)
+			fixed++
+		}
+		if err := os.WriteFile(name, []byte(strings.Join(src, "\n")), 0o644); err != nil {
+			return fixed, err
+		}
+	}
+	return fixed, nil
+}
@@ -0,0 +1,195 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// anchorsBase is the git ref of the published docs the anchors are
+// compared with, set by a flag.
+var anchorsBase = "HEAD"
+
+// movesManifest is the moves manifest of the redirects tool, relative to
+// the documentation root.
+const movesManifest = "_script/redirects/moves.txt"
+
+// checkAnchors reports the pages and section anchors of the docs at the
+// base ref that are gone from the current ones, as links into them from
+// elsewhere break. A page may be moved with a redirect, in the moves
+// manifest or as a rename in git, and an anchor kept with a label of the
+// old name on the section it moved to.
+func checkAnchors(t *rstdoc.Tree) []problem {
+	fail := func(err error) []problem {
+		return []problem{{rstdoc.Pos{File: movesManifest, Line: 1}, err.Error()}}
+	}
+	dir, err := os.MkdirTemp("", "docscheck-anchors")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := extractSources(t.Root, anchorsBase, dir); err != nil {
+		return fail(err)
+	}
+	base, err := rstdoc.Load(dir)
+	if err != nil {
+		return fail(err)
+	}
+	moves, err := readMoves(filepath.Join(t.Root, filepath.FromSlash(movesManifest)))
+	if err != nil {
+		return fail(err)
+	}
+	renames, err := gitRenames(t.Root, anchorsBase)
+	if err != nil {
+		return fail(err)
+	}
+	for from, to := range renames {
+		if _, ok := moves[from]; !ok {
+			moves[from] = to
+		}
+	}
+
+	var res []problem
+	for _, old := range base.Docs {
+		name, anchor := movedTo(t, moves, old.Name)
+		d := t.Doc(name)
+		if d == nil {
+			res = append(res, problem{docPos(old), fmt.Sprintf("page %s at %s is gone without a redirect in %s", old.Name, anchorsBase, movesManifest)})
+			continue
+		}
+		have := anchors(d)
+		if anchor != "" && !have[anchor] {
+			res = append(res, problem{docPos(old), fmt.Sprintf("page %s redirects to unknown anchor %q in %s", old.Name, anchor, d.File)})
+		}
+		for id, pos := range anchorPositions(old) {
+			if have[id] {
+				continue
+			}
+			res = append(res, problem{pos, fmt.Sprintf("anchor %q of %s at %s is gone from %s; add a label for it to the section it moved to", id, old.Name, anchorsBase, d.File)})
+		}
+	}
+	return res
+}
+
+// movedTo returns where a document of the base docs is now, following
+// the moves, with the anchor of a move to a section.
+func movedTo(t *rstdoc.Tree, moves map[string]string, name string) (string, string) {
+	for seen := map[string]bool{}; t.Doc(name) == nil && !seen[name]; {
+		seen[name] = true
+		to, ok := moves[name]
+		if !ok {
+			break
+		}
+		var anchor string
+		name, anchor, _ = strings.Cut(to, "#")
+		if anchor != "" {
+			return name, anchor
+		}
+	}
+	return name, ""
+}
+
+// docPos returns the position of the title of a document.
+func docPos(d *rstdoc.Doc) rstdoc.Pos {
+	if len(d.Sections) > 0 {
+		return d.Sections[0].Pos
+	}
+	return rstdoc.Pos{File: d.File, Line: 1}
+}
+
+// extractSources writes the documents and conf.py of the docs at the git
+// ref to the directory.
+func extractSources(root, ref, dir string) error {
+	cmd := exec.Command("git", "-C", root, "archive", "--format=tar", ref)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	tr := tar.NewReader(out)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg || h.Name != "conf.py" && path.Ext(h.Name) != ".rst" {
+			continue
+		}
+		file := filepath.Join(dir, filepath.FromSlash(h.Name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return err
+		}
+		bs, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, bs, 0o644); err != nil {
+			return err
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git archive %s: %w", ref, err)
+	}
+	return nil
+}
+
+// readMoves returns the moves in the manifest, from the old document
+// name to the new one with any anchor.
+func readMoves(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]string), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && !strings.HasPrefix(fields[0], "#") {
+			res[fields[0]] = fields[1]
+		}
+	}
+	return res, sc.Err()
+}
+
+// gitRenames returns the documents renamed since the ref, including
+// uncommitted renames, by old name.
+func gitRenames(root, ref string) (map[string]string, error) {
+	cmd := exec.Command("git", "-C", root, "diff", "-M", "--name-status", ref, "--", "*.rst")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	res := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) == 3 && strings.HasPrefix(fields[0], "R") {
+			res[strings.TrimSuffix(fields[1], ".rst")] = strings.TrimSuffix(fields[2], ".rst")
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,189 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+var (
+	restPathExp   = regexp.MustCompile(`/rest/[A-Za-z0-9_.*/-]*[A-Za-z0-9_*]`)
+	restMethodExp = regexp.MustCompile(`^(?:(?:GET|POST|PUT|PATCH|DELETE) )?(/rest/\S+)$`)
+	bashErrorExp  = regexp.MustCompile(`^bash: (?:-c: )?line ([0-9]+): (.*)$`)
+
+	// Examples elide uninteresting parts with "...", and shell commands
+	// have <placeholders>.
+	elidedObjectExp = regexp.MustCompile(`\{\s*"?\.\.\."?\s*\}`)
+	elidedArrayExp  = regexp.MustCompile(`\[\s*"?\.\.\."?\s*\]`)
+	elidedLineExp   = regexp.MustCompile(`(?m)^\s*\.\.\.,?\s*$`)
+	placeholderExp  = regexp.MustCompile(`<[A-Za-z][A-Za-z0-9 _-]*>`)
+)
+
+// checkCodeBlocks validates the examples in code blocks: JSON must parse,
+// XML must be well-formed and shell scripts must pass bash -n, when bash
+// is available. REST API paths in any block must be documented in the
+// REST API pages.
+func checkCodeBlocks(t *rstdoc.Tree) []problem {
+	endpoints := restEndpoints(t)
+	_, err := exec.LookPath("bash")
+	haveBash := err == nil
+
+	var res []problem
+	for _, d := range t.Docs {
+		for _, b := range d.CodeBlocks {
+			text := strings.Join(b.Content, "\n")
+			var line int
+			var err error
+			switch strings.ToLower(b.Lang) {
+			case "json":
+				line, err = checkJSON(text)
+			case "xml":
+				line, err = checkXML(text)
+			case "bash", "sh", "shell":
+				if haveBash {
+					line, err = checkShell(text)
+				}
+			}
+			if err != nil {
+				res = append(res, problem{rstdoc.Pos{File: b.Pos.File, Line: b.Pos.Line + line - 1}, fmt.Sprintf("invalid %s: %v", b.Lang, err)})
+			}
+			for i, l := range b.Content {
+				for _, p := range restPathExp.FindAllString(l, -1) {
+					if !endpoints.match(p) {
+						res = append(res, problem{rstdoc.Pos{File: b.Pos.File, Line: b.Pos.Line + i}, fmt.Sprintf("undocumented REST endpoint %s", p)})
+					}
+				}
+			}
+		}
+	}
+	return res
+}
+
+// checkJSON returns the line of the syntax error in the text, if any.
+func checkJSON(text string) (int, error) {
+	text = elidedObjectExp.ReplaceAllString(text, "{}")
+	text = elidedArrayExp.ReplaceAllString(text, "[]")
+	text = elidedLineExp.ReplaceAllString(text, "")
+	var v any
+	err := json.Unmarshal([]byte(text), &v)
+	var serr *json.SyntaxError
+	if errors.As(err, &serr) {
+		return lineAt(text, int(serr.Offset)), err
+	}
+	return 1, err
+}
+
+// checkXML checks the text is a well-formed XML fragment. Elements may
+// be left open at the end, for excerpts.
+func checkXML(text string) (int, error) {
+	dec := xml.NewDecoder(strings.NewReader(text))
+	for {
+		_, err := dec.Token()
+		var serr *xml.SyntaxError
+		if err == io.EOF || errors.As(err, &serr) && serr.Msg == "unexpected EOF" {
+			return 0, nil
+		}
+		if err != nil {
+			return lineAt(text, int(dec.InputOffset())), err
+		}
+	}
+}
+
+// checkShell runs the text through bash -n. Lines starting with a $
+// prompt are taken to be the only commands, the rest being output.
+func checkShell(text string) (int, error) {
+	lines := strings.Split(placeholderExp.ReplaceAllString(text, "x"), "\n")
+	prompted := false
+	for _, l := range lines {
+		if strings.HasPrefix(l, "$ ") {
+			prompted = true
+		}
+	}
+	if prompted {
+		// Keep the line numbers.
+		for i, l := range lines {
+			if strings.HasPrefix(l, "$ ") {
+				lines[i] = l[2:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+	cmd := exec.Command("bash", "-n")
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if cmd.Run() == nil {
+		return 0, nil
+	}
+	msg := strings.TrimSpace(stderr.String())
+	first, _, _ := strings.Cut(msg, "\n")
+	if m := bashErrorExp.FindStringSubmatch(first); m != nil {
+		var line int
+		fmt.Sscan(m[1], &line)
+		return line, errors.New(m[2])
+	}
+	return 1, errors.New(first)
+}
+
+func lineAt(text string, offset int) int {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	return 1 + strings.Count(text[:offset], "\n")
+}
+
+// endpointSet is the documented REST endpoints path patterns, where a *
+// matches a path element.
+type endpointSet []string
+
+// restEndpoints returns the endpoints named in the section titles of the
+// REST API pages.
+func restEndpoints(t *rstdoc.Tree) endpointSet {
+	var res endpointSet
+	for _, d := range t.Docs {
+		if !strings.HasPrefix(d.Name, "rest/") {
+			continue
+		}
+		for _, s := range d.Sections {
+			for _, part := range strings.Split(s.Title, ",") {
+				part = strings.ReplaceAll(strings.TrimSpace(part), `\*`, "*")
+				if m := restMethodExp.FindStringSubmatch(part); m != nil {
+					res = append(res, m[1])
+				}
+			}
+		}
+	}
+	return res
+}
+
+func (s endpointSet) match(p string) bool {
+	if strings.Contains(p, "*") {
+		// A pattern itself, as in the REST API pages.
+		return true
+	}
+	for _, e := range s {
+		// Endpoints ending in * take the rest of the path as is, e.g.
+		// *id* standing for a folder ID containing slashes.
+		pat := strings.ReplaceAll(e, "*id*", "*")
+		if ok, _ := path.Match(pat, p); ok || e == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// configFile says which checks run and how what they find counts, set by
+// a flag.
+var configFile = "docscheck/docscheck.json"
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+type config struct {
+	Checks map[string]checkConfig `json:"checks"`
+}
+
+// checkConfig is the configuration of a check. Checks are enabled, with
+// error severity, unless configured otherwise.
+type checkConfig struct {
+	Enabled *bool `json:"enabled"`
+	// Severity is "error", failing the run, or "warning", only reported.
+	Severity string `json:"severity"`
+	// Command, for checks that aren't built in, is the command running
+	// the check (see extensionInput).
+	Command []string `json:"command"`
+}
+
+func readConfig(name string) (*config, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var cfg config
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for c, cc := range cfg.Checks {
+		switch {
+		case knownCheck(c) && len(cc.Command) > 0:
+			return nil, fmt.Errorf("%s: %s is built in and has no command", name, c)
+		case !knownCheck(c) && len(cc.Command) == 0:
+			return nil, fmt.Errorf("%s: unknown check %q", name, c)
+		}
+		switch cc.Severity {
+		case "", severityError, severityWarning:
+		default:
+			return nil, fmt.Errorf("%s: %s: unknown severity %q", name, c, cc.Severity)
+		}
+	}
+	return &cfg, nil
+}
+
+func (c *config) enabled(check string) bool {
+	e := c.Checks[check].Enabled
+	return e == nil || *e
+}
+
+func (c *config) severity(check string) string {
+	if s := c.Checks[check].Severity; s != "" {
+		return s
+	}
+	return severityError
+}
@@ -0,0 +1,304 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./docscheck [-root ..] [-config docscheck/docscheck.json] [-checks links,refs,...] [-json report.json] [-sarif report.sarif] [-junit report.xml] [-changed-since origin/main]
+//
+// Checks the documentation sources for problems that the Sphinx build
+// doesn't catch, or only warns about, printing each as
+// file:line: [check] message. The checks that run, and whether what they
+// find is an error or a warning, are set in docscheck.json; checks not
+// listed there run with error severity. Listing checks in -checks runs
+// exactly those, enabled or not. A summary of the errors and warnings of
+// each check is written at the end, and -json writes all of them as a
+// report; -sarif writes them as SARIF, for code scanning to show them on
+// pull requests, and -junit writes each check as a test case failing on
+// errors, for CI to show which checks fail. Exits with status 1 if there
+// are errors.
+//
+// The checks are links, refs, orphans, images, codeblocks, glossary,
+// permalinks, versions and roles, which find broken references and
+// markup, and:
+//
+//   - duplicates, which finds prose repeated across pages.
+//   - external, which fetches the external links to find dead ones,
+//     following the policy in external.json and caching the links that
+//     worked between runs (see internal/cache).
+//   - anchors, which compares the pages and section anchors with those of
+//     the published docs at the -anchors-base git ref, and reports those
+//     that are gone without a redirect or label keeping links to them
+//     working.
+//   - metadata, which reports the pages that lack the meta description,
+//     keywords or title label the schema in metadata.json requires, or
+//     have metadata it doesn't know.
+//   - alttext, which reports images without alt text and figures without
+//     a caption, except where alttext.json waives them; -fix-alt inserts
+//     TODO alt texts for the review to fill in.
+//   - terms, which reports the terms that terms.json says to avoid, except
+//     where a ".. termcheck: allow term" or ".. termcheck: off" comment
+//     suppresses them; -fix-terms rewrites them.
+//   - spelling, which reports the words of the prose in neither the system
+//     dictionary nor words.txt; -accept-words adds them to words.txt.
+//   - ignores, which tests the examples in the ignore patterns page
+//     against the matcher of the Syncthing module this tool is built
+//     with.
+//
+// Checks that aren't built in, such as those of forks, are added to
+// docscheck.json with the command running them. The command gets the
+// documentation root and its source files as JSON on stdin, and writes the
+// problems it finds as JSON on stdout:
+//
+//	{"root": "/abs/docs", "files": ["index.rst", ...], "scope": [...]}
+//	[{"file": "users/faq.rst", "line": 12, "message": "..."}]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+type problem struct {
+	Pos rstdoc.Pos
+	Msg string
+}
+
+type check struct {
+	name string
+	fn   func(*rstdoc.Tree) []problem
+}
+
+var checks = []check{
+	{"links", checkLinks},
+	{"refs", checkRefs},
+	{"orphans", checkOrphans},
+	{"images", checkImages},
+	{"codeblocks", checkCodeBlocks},
+	{"glossary", checkGlossary},
+	{"permalinks", checkPermalinks},
+	{"versions", checkVersions},
+	{"roles", checkRoles},
+	{"duplicates", checkDuplicates},
+	{"external", checkExternal},
+	{"anchors", checkAnchors},
+	{"metadata", checkMetadata},
+	{"alttext", checkAltText},
+	{"terms", checkTerms},
+	{"spelling", checkSpelling},
+	{"ignores", checkIgnores},
+}
+
+// finding is a problem found by a check, as reported.
+type finding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+func (f finding) String() string {
+	if f.Severity == severityWarning {
+		return fmt.Sprintf("%s:%d: warning: [%s] %s", f.File, f.Line, f.Check, f.Message)
+	}
+	return fmt.Sprintf("%s:%d: [%s] %s", f.File, f.Line, f.Check, f.Message)
+}
+
+// report is the combined report of the checks written by -json.
+type report struct {
+	Checks   []checkSummary `json:"checks"`
+	Findings []finding      `json:"findings"`
+}
+
+type checkSummary struct {
+	Check    string  `json:"check"`
+	Severity string  `json:"severity"`
+	Count    int     `json:"count"`
+	Seconds  float64 `json:"seconds"`
+}
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	flag.StringVar(&configFile, "config", configFile, "Configuration of the checks")
+	only := flag.String("checks", "", "Comma separated checks to run, instead of those enabled in -config")
+	since := flag.String("changed-since", "", "Only report the problems in the files affected by the changes since the merge base with this git ref")
+	jsonFile := flag.String("json", "", "Write a report of all findings as JSON to this file")
+	sarifFile := flag.String("sarif", "", "Write all findings as SARIF to this file")
+	junitFile := flag.String("junit", "", "Write the checks as JUnit XML test cases to this file")
+	candidates := flag.Int("glossary-candidates", 0, "List the undefined terms used in at least this many documents, instead of checking")
+	flag.StringVar(&syncthingRepo, "syncthing", "", "Clone of the Syncthing repository to check source links against")
+	flag.StringVar(&externalConfigFile, "external-config", externalConfigFile, "Policy for the external check")
+	flag.StringVar(&anchorsBase, "anchors-base", anchorsBase, "Git ref of the published docs for the anchors check")
+	flag.StringVar(&metadataSchemaFile, "metadata-schema", metadataSchemaFile, "Schema of the metadata check")
+	flag.StringVar(&altTextConfigFile, "alttext-config", altTextConfigFile, "Waivers of the alttext check")
+	flag.StringVar(&termsFile, "terms", termsFile, "Rules of the terms check")
+	flag.StringVar(&dictFile, "dict", dictFile, "Dictionary of the spelling check")
+	flag.StringVar(&wordsFile, "words", wordsFile, "Project words of the spelling check")
+	flag.StringVar(&ignoresDoc, "ignores-doc", ignoresDoc, "Ignore patterns page for the ignores check")
+	pin := flag.Bool("pin-permalinks", false, "Rewrite links to lines on a branch to the commit it's at in the -syncthing clone, instead of checking")
+	fixAlt := flag.Bool("fix-alt", false, "Insert TODO alt text in the images and figures without, instead of checking")
+	fixTermsFlag := flag.Bool("fix-terms", false, "Rewrite the terms to avoid, instead of checking")
+	accept := flag.Bool("accept-words", false, "Add the unknown words to the project words, instead of checking")
+	flag.Parse()
+
+	cfg, err := readConfig(configFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	checks = append(checks, extensionChecks(cfg)...)
+	listed := make(map[string]bool)
+	for _, c := range strings.Split(*only, ",") {
+		if c != "" {
+			listed[c] = true
+		}
+	}
+	for c := range listed {
+		if !knownCheck(c) {
+			log.Fatalln("unknown check:", c)
+		}
+	}
+
+	tree, err := rstdoc.Load(*root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *since != "" {
+		if scope, err = changedScope(tree, *since); err != nil {
+			log.Fatalln(err)
+		}
+		if scope != nil {
+			log.Printf("Checking the %d files affected by the changes since %s", len(scope), *since)
+		}
+	}
+
+	if *candidates > 0 {
+		for _, c := range glossaryCandidates(tree, *candidates) {
+			fmt.Printf("%s (%d documents)\n", c.Term, c.Docs)
+		}
+		return
+	}
+
+	if *pin {
+		if syncthingRepo == "" {
+			log.Fatalln("-pin-permalinks needs -syncthing")
+		}
+		n, err := pinPermalinks(tree, syncthingRepo)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Pinned %d links\n", n)
+		return
+	}
+
+	if *fixAlt {
+		n, err := fixAltText(tree)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Added %d alt text stubs\n", n)
+		return
+	}
+
+	if *fixTermsFlag {
+		n, err := fixTerms(tree)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Fixed %d terms\n", n)
+		return
+	}
+
+	if *accept {
+		n, err := acceptWords(tree)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Accepted %d words\n", n)
+		return
+	}
+
+	var rep report
+	errors := 0
+	for _, c := range checks {
+		if len(listed) > 0 && !listed[c.name] || len(listed) == 0 && !cfg.enabled(c.name) {
+			continue
+		}
+		sev := cfg.severity(c.name)
+		start := time.Now()
+		var problems []problem
+		for _, p := range c.fn(tree) {
+			// Problems outside the documents, such as in the
+			// configuration of a check, are always reported.
+			if path.Ext(p.Pos.File) != ".rst" || inScope(p.Pos.File) {
+				problems = append(problems, p)
+			}
+		}
+		took := time.Since(start)
+		for _, p := range problems {
+			rep.Findings = append(rep.Findings, finding{c.name, sev, p.Pos.File, p.Pos.Line, p.Msg})
+		}
+		rep.Checks = append(rep.Checks, checkSummary{c.name, sev, len(problems), took.Seconds()})
+		if sev == severityError {
+			errors += len(problems)
+		}
+	}
+	sort.SliceStable(rep.Findings, func(a, b int) bool {
+		fa, fb := rep.Findings[a], rep.Findings[b]
+		if fa.File != fb.File {
+			return fa.File < fb.File
+		}
+		return fa.Line < fb.Line
+	})
+	for _, f := range rep.Findings {
+		fmt.Println(f)
+	}
+	for _, s := range rep.Checks {
+		if s.Count > 0 {
+			log.Printf("%s: %d found (%s)", s.Check, s.Count, s.Severity)
+		}
+	}
+
+	if *jsonFile != "" {
+		bs, err := json.MarshalIndent(rep, "", "  ")
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := os.WriteFile(*jsonFile, append(bs, '\n'), 0o644); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if *junitFile != "" {
+		if err := writeJUnit(*junitFile, rep); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if *sarifFile != "" {
+		if err := writeSARIF(*sarifFile, rep, *root); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if errors > 0 {
+		os.Exit(1)
+	}
+}
+
+func knownCheck(name string) bool {
+	for _, c := range checks {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+const (
+	// shingleWords is the number of words in a shingle, the overlapping
+	// word sequences that paragraphs are compared by.
+	shingleWords = 5
+	// minDuplicateWords is the length of the shortest paragraph that's
+	// compared, as short ones are similar by chance.
+	minDuplicateWords = 25
+	// minSimilarity is the share of shingles two paragraphs must have in
+	// common to be reported.
+	minSimilarity = 0.5
+)
+
+var wordExp = regexp.MustCompile(`[\p{L}\p{N}]+(?:['.-][\p{L}\p{N}]+)*`)
+
+// paragraph is a paragraph with its shingles, for comparing.
+type paragraph struct {
+	pos      rstdoc.Pos
+	end      int
+	shingles map[uint64]struct{}
+}
+
+// duplicate is a run of paragraphs in one place that are similar to a
+// run in another.
+type duplicate struct {
+	a, b       *paragraph
+	aEnd, bEnd int
+	paragraphs int
+	similarity float64
+}
+
+// checkDuplicates reports prose that's duplicated, exactly or with small
+// differences, in another place, so it can be moved to an include. Runs
+// of similar paragraphs are reported together, at the later place.
+func checkDuplicates(t *rstdoc.Tree) []problem {
+	paras := paragraphs(t)
+
+	// Only paragraphs sharing a shingle can be similar, so an index from
+	// shingle to paragraphs gives the candidates.
+	index := make(map[uint64][]int)
+	for i, p := range paras {
+		for s := range p.shingles {
+			index[s] = append(index[s], i)
+		}
+	}
+	type pair struct{ a, b int }
+	similar := make(map[pair]float64)
+	for i, p := range paras {
+		shared := make(map[int]int)
+		for s := range p.shingles {
+			for _, j := range index[s] {
+				if j > i {
+					shared[j]++
+				}
+			}
+		}
+		for j, n := range shared {
+			q := paras[j]
+			if q.pos.File == p.pos.File {
+				continue
+			}
+			// Jaccard similarity of the shingle sets
+			sim := float64(n) / float64(len(p.shingles)+len(q.shingles)-n)
+			if sim >= minSimilarity {
+				similar[pair{i, j}] = sim
+			}
+		}
+	}
+
+	// Join the pairs of consecutive paragraphs, in the same order in both
+	// places, into runs.
+	pairs := make([]pair, 0, len(similar))
+	for p := range similar {
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(x, y int) bool {
+		if pairs[x].a != pairs[y].a {
+			return pairs[x].a < pairs[y].a
+		}
+		return pairs[x].b < pairs[y].b
+	})
+	continued := make(map[pair]bool)
+	var res []problem
+	for _, p := range pairs {
+		if continued[p] {
+			continue
+		}
+		d := duplicate{a: paras[p.a], b: paras[p.b]}
+		total := 0.0
+		for cur := p; ; cur = (pair{cur.a + 1, cur.b + 1}) {
+			sim, ok := similar[cur]
+			if !ok || cur.a >= len(paras) || cur.b >= len(paras) ||
+				paras[cur.a].pos.File != d.a.pos.File || paras[cur.b].pos.File != d.b.pos.File {
+				break
+			}
+			continued[cur] = true
+			d.aEnd, d.bEnd = paras[cur.a].end, paras[cur.b].end
+			d.paragraphs++
+			total += sim
+		}
+		d.similarity = total / float64(d.paragraphs)
+		res = append(res, d.problem())
+	}
+	return res
+}
+
+func (d duplicate) problem() problem {
+	// Report at the later of the two places, which is likely the copy.
+	here, there := d.b.pos, d.a.pos
+	hereEnd, thereEnd := d.bEnd, d.aEnd
+	if here.File < there.File {
+		here, there = there, here
+		hereEnd, thereEnd = thereEnd, hereEnd
+	}
+	what := "paragraph"
+	if d.paragraphs > 1 {
+		what = fmt.Sprintf("%d paragraphs", d.paragraphs)
+	}
+	how := fmt.Sprintf("are %.0f%% similar to", 100*d.similarity)
+	if d.similarity == 1 {
+		how = "are the same as"
+	}
+	return problem{here, fmt.Sprintf("lines %d-%d (%s) %s %s:%d-%d; consider an include", here.Line, hereEnd, what, how, there.File, there.Line, thereEnd)}
+}
+
+// paragraphs returns the paragraphs long enough to compare, once each
+// even if the file they're in is included in several documents.
+func paragraphs(t *rstdoc.Tree) []*paragraph {
+	seen := make(map[rstdoc.Pos]bool)
+	var res []*paragraph
+	for _, d := range t.Docs {
+		for _, txt := range d.Texts {
+			if seen[txt.Pos] {
+				continue
+			}
+			seen[txt.Pos] = true
+			words := wordExp.FindAllString(strings.ToLower(strings.Join(txt.Prose, " ")), -1)
+			if len(words) < minDuplicateWords {
+				continue
+			}
+			p := &paragraph{
+				pos:      txt.Pos,
+				end:      txt.Pos.Line + len(txt.Lines) - 1,
+				shingles: make(map[uint64]struct{}),
+			}
+			for i := 0; i+shingleWords <= len(words); i++ {
+				h := fnv.New64a()
+				h.Write([]byte(strings.Join(words[i:i+shingleWords], " ")))
+				p.shingles[h.Sum64()] = struct{}{}
+			}
+			res = append(res, p)
+		}
+	}
+	// Paragraphs of a file are consecutive, in order, so runs can be
+	// found by index.
+	sort.SliceStable(res, func(a, b int) bool {
+		if res[a].pos.File != res[b].pos.File {
+			return res[a].pos.File < res[b].pos.File
+		}
+		return res[a].pos.Line < res[b].pos.Line
+	})
+	return res
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// extensionInput is what an extension check gets on stdin: the
+// documentation root, as an absolute path, and the source files of the
+// documents, relative to it. Scope, when set, are the files incremental
+// mode reports problems in, which the check may limit itself to.
+type extensionInput struct {
+	Root  string   `json:"root"`
+	Files []string `json:"files"`
+	Scope []string `json:"scope,omitempty"`
+}
+
+// extensionProblem is a problem found by an extension check, written to
+// stdout as a JSON array of them. The file is relative to the root.
+type extensionProblem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// extensionChecks returns the checks the configuration runs as commands,
+// which may be anything that reads the input and writes the problems as
+// JSON. The command is run in the working directory; exiting with an
+// error is reported as a problem with the check.
+func extensionChecks(cfg *config) []check {
+	var res []check
+	for name, cc := range cfg.Checks {
+		if len(cc.Command) == 0 {
+			continue
+		}
+		name, command := name, cc.Command
+		res = append(res, check{name, func(t *rstdoc.Tree) []problem {
+			return runExtension(t, name, command)
+		}})
+	}
+	sort.Slice(res, func(a, b int) bool { return res[a].name < res[b].name })
+	return res
+}
+
+func runExtension(t *rstdoc.Tree, name string, command []string) []problem {
+	fail := func(err error) []problem {
+		return []problem{{rstdoc.Pos{File: configFile, Line: 1}, fmt.Sprintf("%s: %v", name, err)}}
+	}
+	root, err := filepath.Abs(t.Root)
+	if err != nil {
+		return fail(err)
+	}
+	in := extensionInput{Root: root}
+	files := make(map[string]bool)
+	for _, d := range t.Docs {
+		files[d.File] = true
+	}
+	in.Files = sortedSet(files)
+	if scope != nil {
+		in.Scope = sortedSet(scope)
+	}
+	bs, err := json.Marshal(in)
+	if err != nil {
+		return fail(err)
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(bs)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "DOCSCHECK_CHECK="+name)
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return fail(err)
+	}
+	var found []extensionProblem
+	if err := json.Unmarshal(out, &found); err != nil {
+		return fail(fmt.Errorf("reading output: %w", err))
+	}
+	var res []problem
+	for _, p := range found {
+		res = append(res, problem{rstdoc.Pos{File: filepath.ToSlash(p.File), Line: p.Line}, p.Message})
+	}
+	return res
+}
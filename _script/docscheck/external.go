@@ -0,0 +1,281 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"syncthing.net/docs/internal/cache"
+	"syncthing.net/docs/internal/httpclient"
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// externalConfigFile is the policy of the external check, set by a flag.
+var externalConfigFile = "docscheck/external.json"
+
+const externalUserAgent = "Mozilla/5.0 (compatible; syncthing-docs-docscheck)"
+
+// externalConfig is the policy for checking external links.
+type externalConfig struct {
+	// Concurrency is the number of URLs checked at once, and HostDelay
+	// the time between requests to the same host.
+	Concurrency int      `json:"concurrency"`
+	HostDelay   duration `json:"hostDelay"`
+	Timeout     duration `json:"timeout"`
+	// Retries is how many more times a URL is tried after a timeout,
+	// connection error, 429 or 5xx response, waiting twice as long each
+	// time from Backoff, or as long as the response asks.
+	Retries int      `json:"retries"`
+	Backoff duration `json:"backoff"`
+	// CacheAge is how long a URL that worked isn't checked again.
+	CacheAge duration `json:"cacheAge"`
+	// Skip are hosts that aren't checked at all, such as examples.
+	Skip hostList `json:"skip"`
+	// Flaky are hosts known to refuse or time out on automated requests;
+	// only a 404 or 410 from them is reported. Both lists include the
+	// subdomains of the hosts.
+	Flaky hostList `json:"flaky"`
+}
+
+// duration is a time.Duration in JSON as a string such as "1m30s".
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(bs []byte) error {
+	var s string
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// linkResult is the outcome of checking a URL, as cached between runs.
+type linkResult struct {
+	Checked time.Time `json:"checked"`
+	// Status is the HTTP status, or zero if there was no response.
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (r linkResult) ok() bool {
+	return r.Error == ""
+}
+
+// checkExternal fetches the external URLs linked to and reports those
+// that fail. It needs the network, so it only runs when asked for.
+func checkExternal(t *rstdoc.Tree) []problem {
+	cfg, err := readExternalConfig(externalConfigFile)
+	if err != nil {
+		return []problem{{rstdoc.Pos{File: externalConfigFile, Line: 1}, err.Error()}}
+	}
+	uses := externalUses(t, cfg.Skip)
+
+	// Only the URLs that worked are cached, so that those that didn't are
+	// checked again each time.
+	c := cache.Default()
+	results := make(map[string]linkResult)
+	var todo []string
+	for u := range uses {
+		var r linkResult
+		if c.GetJSON(cache.API, linkCacheKey(u), &r) && r.ok() {
+			results[u] = r
+		} else {
+			todo = append(todo, u)
+		}
+	}
+	sort.Strings(todo)
+	for u, r := range newLinkChecker(cfg).checkAll(todo) {
+		results[u] = r
+		if r.ok() {
+			if err := c.PutJSON(cache.API, linkCacheKey(u), r, time.Duration(cfg.CacheAge)); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+	if err := c.Trim(); err != nil {
+		log.Println(err)
+	}
+
+	var res []problem
+	for u, positions := range uses {
+		r := results[u]
+		if r.ok() || cfg.Flaky.has(hostname(u)) && r.Status != http.StatusNotFound && r.Status != http.StatusGone {
+			continue
+		}
+		for _, pos := range positions {
+			res = append(res, problem{pos, fmt.Sprintf("dead link %s: %s", u, r.Error)})
+		}
+	}
+	return res
+}
+
+func readExternalConfig(name string) (*externalConfig, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var cfg externalConfig
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &cfg, nil
+}
+
+// externalUses returns the positions of the http and https links by URL,
+// without the fragment, leaving out the skipped hosts.
+func externalUses(t *rstdoc.Tree, skip hostList) map[string][]rstdoc.Pos {
+	uses := make(map[string][]rstdoc.Pos)
+	seen := make(map[rstdoc.Pos]bool)
+	add := func(link string, pos rstdoc.Pos) {
+		link = strings.Join(strings.Fields(link), "")
+		u, err := url.Parse(link)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" || skip.has(u.Hostname()) || seen[pos] || !inScope(pos.File) {
+			return
+		}
+		seen[pos] = true
+		u.Fragment = ""
+		uses[u.String()] = append(uses[u.String()], pos)
+	}
+	for _, d := range t.Docs {
+		for _, r := range d.Refs {
+			if r.Role == "" && !r.Named {
+				add(r.Target, r.Pos)
+			}
+		}
+		for _, tg := range d.Targets {
+			if tg.URL != "" {
+				add(tg.URL, tg.Pos)
+			}
+		}
+	}
+	return uses
+}
+
+// linkChecker fetches URLs concurrently, with the client spacing the
+// requests to each host and retrying the failures that may be temporary.
+type linkChecker struct {
+	cfg    *externalConfig
+	client *http.Client
+}
+
+func newLinkChecker(cfg *externalConfig) *linkChecker {
+	return &linkChecker{
+		cfg: cfg,
+		client: httpclient.New(httpclient.Options{
+			Timeout:   time.Duration(cfg.Timeout),
+			Retries:   cfg.Retries,
+			Backoff:   time.Duration(cfg.Backoff),
+			HostDelay: time.Duration(cfg.HostDelay),
+			UserAgent: externalUserAgent,
+		}),
+	}
+}
+
+func (c *linkChecker) checkAll(urls []string) map[string]linkResult {
+	res := make(map[string]linkResult, len(urls))
+	var mut sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < c.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range queue {
+				r := c.check(u)
+				mut.Lock()
+				res[u] = r
+				mut.Unlock()
+			}
+		}()
+	}
+	for _, u := range urls {
+		queue <- u
+	}
+	close(queue)
+	wg.Wait()
+	return res
+}
+
+// check requests a URL, with HEAD and then GET if the server doesn't
+// handle HEAD.
+func (c *linkChecker) check(u string) linkResult {
+	status, err := c.request(http.MethodHead, u)
+	if err != nil || status >= 400 {
+		// Many servers refuse HEAD or treat it differently.
+		status, err = c.request(http.MethodGet, u)
+	}
+	r := linkResult{Checked: time.Now().UTC(), Status: status}
+	switch {
+	case err != nil:
+		r.Error = err.Error()
+	case status >= 400:
+		r.Error = fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+	return r
+}
+
+func (c *linkChecker) request(method, u string) (int, error) {
+	req, err := http.NewRequestWithContext(context.Background(), method, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Read a little of the body so the connection can be reused.
+	io.CopyN(io.Discard, resp.Body, 64<<10)
+	return resp.StatusCode, nil
+}
+
+func hostname(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	return pu.Hostname()
+}
+
+type hostList []string
+
+// has reports whether the host or a domain it's in is listed.
+func (l hostList) has(host string) bool {
+	for _, h := range l {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+func linkCacheKey(u string) string {
+	return "docscheck/external:" + u
+}
@@ -0,0 +1,230 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+type glossaryTerm struct {
+	name string
+	doc  *rstdoc.Doc
+	pos  rstdoc.Pos
+}
+
+var (
+	termRoleExp = regexp.MustCompile("(?s):term:`([^`]+)`")
+	// Capitalised phrases, such as "Block Exchange Protocol", and
+	// emphasised ones are candidates for the glossary.
+	phraseExp   = regexp.MustCompile(`\b[A-Z][a-z]+(?: [A-Z][a-z]+)+\b`)
+	emphasisExp = regexp.MustCompile(`(?:^|[\s(])\*([^*\s](?:[^*]*[^*\s])?)\*(?:$|[\s.,;:)])`)
+)
+
+// phraseStarts are words that start a phrase by starting a sentence,
+// and aren't part of the term.
+var phraseStarts = map[string]bool{
+	"A": true, "An": true, "The": true, "This": true, "That": true, "These": true,
+	"In": true, "On": true, "For": true, "If": true, "When": true, "To": true,
+	"With": true, "By": true, "See": true, "Use": true, "Some": true, "Each": true,
+}
+
+// glossaryTerms returns the terms defined by the glossary directives, by
+// lower case name as Sphinx compares them.
+func glossaryTerms(t *rstdoc.Tree) map[string]glossaryTerm {
+	res := make(map[string]glossaryTerm)
+	for _, d := range t.Docs {
+		for _, dir := range d.Directives {
+			if dir.Name != "glossary" {
+				continue
+			}
+			for i, line := range dir.Content {
+				if line == "" || line[0] == ' ' || line[0] == '\t' {
+					continue
+				}
+				// A term may have a classifier, "term : classifier".
+				name, _, _ := strings.Cut(line, " : ")
+				name = strings.TrimSpace(name)
+				res[strings.ToLower(name)] = glossaryTerm{name, d, rstdoc.Pos{File: dir.Pos.File, Line: dir.ContentLine + i}}
+			}
+		}
+	}
+	return res
+}
+
+// checkGlossary reports glossary terms that nothing refers to with
+// :term:, and pages that use a glossary term without linking the first
+// use of it.
+func checkGlossary(t *rstdoc.Tree) []problem {
+	terms := glossaryTerms(t)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	var res []problem
+	for _, d := range t.Docs {
+		for _, r := range d.Refs {
+			if r.Role == "term" {
+				used[strings.ToLower(r.Target)] = true
+			}
+		}
+		res = append(res, unlinkedTerms(d, terms)...)
+	}
+	for name, term := range terms {
+		if !used[name] {
+			res = append(res, problem{term.pos, fmt.Sprintf("glossary term %q is not referred to by any :term:", term.name)})
+		}
+	}
+	return res
+}
+
+// unlinkedTerms reports the terms that the document uses before, or
+// without, linking to them. Section titles are skipped, as they can't
+// have links.
+func unlinkedTerms(d *rstdoc.Doc, terms map[string]glossaryTerm) []problem {
+	titles := make(map[rstdoc.Pos]bool)
+	for _, s := range d.Sections {
+		titles[s.Pos] = true
+	}
+	seen := make(map[string]bool)
+	var res []problem
+	for _, text := range d.Texts {
+		if titles[text.Pos] {
+			continue
+		}
+		for i, line := range text.Lines {
+			type use struct {
+				col    int
+				name   string
+				linked bool
+			}
+			var uses []use
+			for _, m := range termRoleExp.FindAllStringSubmatchIndex(line, -1) {
+				name := line[m[2]:m[3]]
+				if e := strings.LastIndexByte(name, '<'); e >= 0 {
+					name = strings.TrimSuffix(name[e+1:], ">")
+				}
+				uses = append(uses, use{m[0], strings.ToLower(strings.TrimSpace(name)), true})
+			}
+			prose := strings.ToLower(text.Prose[i])
+			for name, term := range terms {
+				if term.doc == d {
+					continue
+				}
+				if col := wordIndex(prose, name); col >= 0 {
+					uses = append(uses, use{col, name, false})
+				}
+			}
+			// The text of a :term: is prose too, after the role.
+			sort.SliceStable(uses, func(a, b int) bool { return uses[a].col < uses[b].col })
+			for _, u := range uses {
+				if seen[u.name] {
+					continue
+				}
+				seen[u.name] = true
+				if !u.linked {
+					pos := rstdoc.Pos{File: text.Pos.File, Line: text.Pos.Line + i}
+					res = append(res, problem{pos, fmt.Sprintf("first use of glossary term %q should be a :term: reference", terms[u.name].name)})
+				}
+			}
+		}
+	}
+	return res
+}
+
+// wordIndex returns the offset of the first use of the words in s as
+// words of their own, or -1.
+func wordIndex(s, words string) int {
+	for start := 0; ; {
+		i := strings.Index(s[start:], words)
+		if i < 0 {
+			return -1
+		}
+		i += start
+		end := i + len(words)
+		if (i == 0 || !isWordByte(s[i-1])) && (end == len(s) || !isWordByte(s[end])) {
+			return i
+		}
+		start = i + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '-' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+type candidate struct {
+	Term string
+	Docs int
+}
+
+// glossaryCandidates returns the capitalised and emphasised phrases used
+// in the text of at least minDocs documents that the glossary doesn't
+// define, most used first.
+func glossaryCandidates(t *rstdoc.Tree, minDocs int) []candidate {
+	terms := glossaryTerms(t)
+	docs := make(map[string]map[*rstdoc.Doc]bool)
+	spelling := make(map[string]string)
+	add := func(d *rstdoc.Doc, phrase string) {
+		key := strings.ToLower(phrase)
+		if _, ok := terms[key]; ok {
+			return
+		}
+		if docs[key] == nil {
+			docs[key] = make(map[*rstdoc.Doc]bool)
+			spelling[key] = phrase
+		}
+		docs[key][d] = true
+	}
+	for _, d := range t.Docs {
+		titles := make(map[rstdoc.Pos]bool)
+		for _, s := range d.Sections {
+			titles[s.Pos] = true
+		}
+		for _, text := range d.Texts {
+			if titles[text.Pos] {
+				continue
+			}
+			for _, p := range phraseExp.FindAllString(strings.Join(text.Prose, " "), -1) {
+				if first, rest, ok := strings.Cut(p, " "); ok && phraseStarts[first] {
+					if !strings.Contains(rest, " ") {
+						continue
+					}
+					p = rest
+				}
+				add(d, p)
+			}
+			// Single lower case words are emphasised for stress more
+			// often than as terms.
+			for _, m := range emphasisExp.FindAllStringSubmatch(strings.Join(text.Lines, " "), -1) {
+				words := strings.Fields(m[1])
+				if len(words) > 1 || strings.ToLower(m[1]) != m[1] {
+					add(d, strings.Join(words, " "))
+				}
+			}
+		}
+	}
+
+	var res []candidate
+	for key, ds := range docs {
+		if len(ds) >= minDocs {
+			res = append(res, candidate{spelling[key], len(ds)})
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		if res[a].Docs != res[b].Docs {
+			return res[a].Docs > res[b].Docs
+		}
+		return res[a].Term < res[b].Term
+	})
+	return res
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// claim is a statement in the text about what a pattern matches.
+type claim struct {
+	pos        rstdoc.Pos
+	pattern    string
+	matches    []string
+	nonMatches []string
+}
+
+var (
+	claimExp   = regexp.MustCompile("``([^`]+)``\\s+(?:to\\s+)?match(?:es)?\\s+(?:the files\\s+|either\\s+|both\\s+)?")
+	literalExp = regexp.MustCompile("^``([^`]+)``")
+	listSepExp = regexp.MustCompile("^(?:,\\s*(?:and\\s+|or\\s+)?|\\s+and\\s+|\\s+or\\s+)``")
+	butNotExp  = regexp.MustCompile("^\\s+but not\\s+")
+)
+
+// findClaims returns the statements in the paragraphs of the text that
+// a literal pattern matches a list of literal names, optionally followed
+// by "but not" and names it doesn't match.
+func findClaims(file string, lines []string) []claim {
+	var res []claim
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i]) == "" {
+			i++
+			continue
+		}
+		start := i
+		var para []string
+		for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+			para = append(para, strings.TrimSpace(lines[i]))
+		}
+		text := strings.Join(para, "\n")
+		for _, m := range claimExp.FindAllStringSubmatchIndex(text, -1) {
+			matches, n := literalList(text[m[1]:])
+			if len(matches) == 0 {
+				continue
+			}
+			c := claim{
+				pos:     rstdoc.Pos{File: file, Line: start + 1 + strings.Count(text[:m[0]], "\n")},
+				pattern: text[m[2]:m[3]],
+				matches: matches,
+			}
+			rest := text[m[1]+n:]
+			if b := butNotExp.FindStringIndex(rest); b != nil {
+				c.nonMatches, _ = literalList(rest[b[1]:])
+			}
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// literalList returns the inline literals at the start of the text
+// separated by commas, "and" or "or", and the length of text they take.
+func literalList(text string) ([]string, int) {
+	var res []string
+	n := 0
+	for {
+		m := literalExp.FindStringSubmatch(text[n:])
+		if m == nil {
+			return res, n
+		}
+		res = append(res, m[1])
+		n += len(m[0])
+		sep := listSepExp.FindStringIndex(text[n:])
+		if sep == nil {
+			return res, n
+		}
+		// Leave the opening quotes of the next literal.
+		n += sep[1] - 2
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// example is a listing of files annotated with whether they are synced
+// by the patterns in the preceding literal block.
+type example struct {
+	patterns    string
+	patternsPos rstdoc.Pos
+	entries     []entry
+}
+
+type entry struct {
+	pos       rstdoc.Pos
+	path      string
+	ignored   bool
+	deletable bool
+	children  []int // indexes of the entries directly below
+}
+
+var resultExp = regexp.MustCompile(`^(\s*)(.+?)\s+# (ignored|synced)\b(.*)$`)
+
+// findExamples returns the code blocks annotated throughout with
+// # ignored and # synced.
+func findExamples(d *rstdoc.Doc) []example {
+	var res []example
+	for i, b := range d.CodeBlocks {
+		if i == 0 {
+			continue
+		}
+		entries, ok := parseResult(b)
+		if !ok {
+			continue
+		}
+		prev := d.CodeBlocks[i-1]
+		res = append(res, example{
+			patterns:    strings.Join(prev.Content, "\n"),
+			patternsPos: prev.Pos,
+			entries:     entries,
+		})
+	}
+	return res
+}
+
+func parseResult(b *rstdoc.CodeBlock) ([]entry, bool) {
+	type level struct {
+		indent int
+		entry  int
+	}
+	var entries []entry
+	var stack []level
+	for i, l := range b.Content {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		m := resultExp.FindStringSubmatch(l)
+		if m == nil {
+			return nil, false
+		}
+		ind := len(m[1])
+		for len(stack) > 0 && stack[len(stack)-1].indent >= ind {
+			stack = stack[:len(stack)-1]
+		}
+		e := entry{
+			pos:       rstdoc.Pos{File: b.Pos.File, Line: b.Pos.Line + i},
+			path:      strings.TrimSuffix(m[2], "/"),
+			ignored:   m[3] == "ignored",
+			deletable: strings.Contains(m[4], "deleted"),
+		}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1].entry
+			e.path = path.Join(entries[parent].path, e.path)
+			entries[parent].children = append(entries[parent].children, len(entries))
+		}
+		stack = append(stack, level{ind, len(entries)})
+		entries = append(entries, e)
+	}
+	return entries, len(entries) > 0
+}
+
+// check compares the annotations to the matcher. As when scanning, a
+// directory is synced if it or anything below it isn't ignored.
+func (ex example) check() ([]problem, error) {
+	m, err := matcher(ex.patterns)
+	if err != nil {
+		return nil, err
+	}
+	var synced func(i int) bool
+	synced = func(i int) bool {
+		if !m.Match(ex.entries[i].path).IsIgnored() {
+			return true
+		}
+		for _, c := range ex.entries[i].children {
+			if synced(c) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var res []problem
+	for i, e := range ex.entries {
+		if synced(i) == e.ignored {
+			state := "synced"
+			if !synced(i) {
+				state = "ignored"
+			}
+			res = append(res, problem{e.pos, fmt.Sprintf("%s is %s", e.path, state)})
+		}
+		if e.deletable && !m.Match(e.path).IsDeletable() {
+			res = append(res, problem{e.pos, e.path + " is not deletable"})
+		}
+	}
+	return res, nil
+}
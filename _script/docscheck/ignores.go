@@ -0,0 +1,82 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/ignore"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// ignoresDoc is the ignore patterns page, set by a flag.
+var ignoresDoc = "users/ignoring"
+
+// checkIgnores checks the examples in the ignore patterns page against the
+// matcher of the Syncthing module this tool is built with (see go.mod).
+// Statements in the text that a pattern "matches a, b but not c", all as
+// inline literals, are tested, as is the example result listing, where
+// each file is annotated as "# ignored" or "# synced" and the patterns are
+// in the literal block before it.
+func checkIgnores(t *rstdoc.Tree) []problem {
+	d := t.Doc(ignoresDoc)
+	if d == nil {
+		return []problem{{rstdoc.Pos{File: ignoresDoc}, "no such document"}}
+	}
+	bs, err := os.ReadFile(filepath.Join(t.Root, filepath.FromSlash(d.File)))
+	if err != nil {
+		return []problem{{rstdoc.Pos{File: d.File}, err.Error()}}
+	}
+
+	claims := findClaims(d.File, strings.Split(string(bs), "\n"))
+	examples := findExamples(d)
+	if len(claims) == 0 && len(examples) == 0 {
+		return []problem{{rstdoc.Pos{File: d.File}, "no ignore pattern examples found"}}
+	}
+
+	var res []problem
+	for _, c := range claims {
+		m, err := matcher(c.pattern)
+		if err != nil {
+			res = append(res, problem{c.pos, err.Error()})
+			continue
+		}
+		for _, file := range c.matches {
+			if !m.Match(file).IsIgnored() {
+				res = append(res, problem{c.pos, fmt.Sprintf("%q does not match %q", c.pattern, file)})
+			}
+		}
+		for _, file := range c.nonMatches {
+			if m.Match(file).IsIgnored() {
+				res = append(res, problem{c.pos, fmt.Sprintf("%q matches %q", c.pattern, file)})
+			}
+		}
+	}
+	for _, ex := range examples {
+		probs, err := ex.check()
+		if err != nil {
+			res = append(res, problem{ex.patternsPos, err.Error()})
+			continue
+		}
+		res = append(res, probs...)
+	}
+	return res
+}
+
+// matcher returns a matcher for the patterns, one per line.
+func matcher(patterns string) (*ignore.Matcher, error) {
+	m := ignore.New(fs.NewFilesystem(fs.FilesystemTypeBasic, os.TempDir()))
+	if err := m.Parse(strings.NewReader(patterns), ".stignore"); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+var imageExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".svg":  true,
+	".webp": true,
+}
+
+// checkImages reports image and figure directives for files that don't
+// exist, and image files in the docs sources that nothing refers to.
+// Links and downloads count as references too, and an image refers to
+// its dark variant, which the dark_images extension serves.
+func checkImages(t *rstdoc.Tree) []problem {
+	used := make(map[string]bool)
+	var res []problem
+	for _, d := range t.Docs {
+		for _, dir := range d.Directives {
+			if dir.Name != "image" && dir.Name != "figure" || schemeExp.MatchString(dir.Arg) {
+				continue
+			}
+			name := resolveFile(d, dir.Arg)
+			used[name] = true
+			used[darkVariant(name)] = true
+			if !fileExists(t, name) {
+				res = append(res, problem{dir.Pos, fmt.Sprintf("%s file %q does not exist", dir.Name, dir.Arg)})
+			}
+		}
+		for _, r := range d.Refs {
+			switch {
+			case r.Role == "download":
+				used[resolveFile(d, r.Target)] = true
+			case r.Role == "" && !r.Named && !schemeExp.MatchString(r.Target):
+				p, _, _ := strings.Cut(r.Target, "#")
+				used[resolveFile(d, p)] = true
+			}
+		}
+	}
+
+	err := filepath.WalkDir(t.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(t.Root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && t.Excluded(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && imageExts[strings.ToLower(path.Ext(rel))] && !used[rel] {
+			res = append(res, problem{rstdoc.Pos{File: rel, Line: 1}, "image is not used by any document"})
+		}
+		return nil
+	})
+	if err != nil {
+		res = append(res, problem{rstdoc.Pos{File: ".", Line: 1}, err.Error()})
+	}
+	return res
+}
+
+// darkVariant returns the name of the dark variant of an image, as
+// written by _script/darkimages.
+func darkVariant(name string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-dark" + ext
+}
@@ -0,0 +1,215 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/cache"
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// scope are the files the findings are reported for in incremental mode,
+// or nil to report them all. Checks that are slow for each file checked,
+// such as external, only look at these files.
+var scope map[string]bool
+
+func inScope(file string) bool {
+	return scope == nil || scope[file]
+}
+
+// docIndex is what incremental mode needs to know of each document to
+// find those a change affects.
+type docIndex struct {
+	// Files are the source files of the documents, their own and those
+	// they include.
+	Files map[string][]string `json:"files"`
+	// Names are what the documents may be linked to by: "doc:" and the
+	// document name, and "label:" and each of their labels.
+	Names map[string][]string `json:"names"`
+	// Links are the names the documents link to.
+	Links map[string][]string `json:"links"`
+}
+
+func indexTree(t *rstdoc.Tree) *docIndex {
+	idx := &docIndex{
+		Files: make(map[string][]string),
+		Names: make(map[string][]string),
+		Links: make(map[string][]string),
+	}
+	for _, d := range t.Docs {
+		files := map[string]bool{d.File: true}
+		for _, tx := range d.Texts {
+			files[tx.Pos.File] = true
+		}
+		for _, dir := range d.Directives {
+			files[dir.Pos.File] = true
+		}
+		for _, b := range d.CodeBlocks {
+			files[b.Pos.File] = true
+		}
+		idx.Files[d.Name] = sortedSet(files)
+
+		names := map[string]bool{"doc:" + d.Name: true}
+		for _, tg := range d.Targets {
+			if tg.URL == "" && !tg.Anonymous && !tg.Inline {
+				names["label:"+strings.ToLower(tg.Name)] = true
+			}
+		}
+		idx.Names[d.Name] = sortedSet(names)
+
+		links := make(map[string]bool)
+		for _, r := range d.Refs {
+			switch r.Role {
+			case "doc":
+				links["doc:"+resolveDoc(d, r.Target)] = true
+			case "ref":
+				links["label:"+strings.ToLower(r.Target)] = true
+			}
+		}
+		for _, dir := range d.Directives {
+			if dir.Name != "toctree" {
+				continue
+			}
+			for _, e := range toctreeEntries(t, d, dir) {
+				if e.glob {
+					for _, doc := range e.docs {
+						links["doc:"+doc.Name] = true
+					}
+				} else {
+					links["doc:"+resolveDoc(d, e.entry)] = true
+				}
+			}
+		}
+		idx.Links[d.Name] = sortedSet(links)
+	}
+	return idx
+}
+
+func sortedSet(set map[string]bool) []string {
+	res := make([]string, 0, len(set))
+	for s := range set {
+		res = append(res, s)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// changedScope returns the files affected by the changes since the merge
+// base of HEAD and the ref, including those not committed: the changed
+// files, the documents they're part of, and the documents linking to
+// those, by the names they had before or have now. It returns nil, for a
+// full check, when something that affects every document changed.
+func changedScope(t *rstdoc.Tree, ref string) (map[string]bool, error) {
+	base, err := gitOutput(t.Root, "merge-base", "HEAD", ref)
+	if err != nil {
+		return nil, err
+	}
+	base = strings.TrimSpace(base)
+	out, err := gitOutput(t.Root, "diff", "--name-only", "--no-renames", base)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string]bool)
+	for _, f := range lines(out) {
+		if f == "conf.py" || strings.HasPrefix(f, "_script/") {
+			log.Printf("%s changed; checking everything", f)
+			return nil, nil
+		}
+		changed[f] = true
+	}
+	// New files aren't in the diff until they're added.
+	out, err = gitOutput(t.Root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range lines(out) {
+		changed[f] = true
+	}
+
+	baseIdx, err := baseIndex(t.Root, base)
+	if err != nil {
+		return nil, err
+	}
+	idx := indexTree(t)
+
+	names := make(map[string]bool)
+	res := make(map[string]bool)
+	for f := range changed {
+		res[f] = true
+	}
+	for _, ix := range []*docIndex{baseIdx, idx} {
+		for doc, files := range ix.Files {
+			if anyIn(files, changed) {
+				for _, n := range ix.Names[doc] {
+					names[n] = true
+				}
+				if ix == idx {
+					addAll(res, files)
+				}
+			}
+		}
+	}
+	for doc, links := range idx.Links {
+		if anyIn(links, names) {
+			addAll(res, idx.Files[doc])
+		}
+	}
+	return res, nil
+}
+
+func lines(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == '\n' })
+}
+
+func anyIn(list []string, set map[string]bool) bool {
+	for _, s := range list {
+		if set[s] {
+			return true
+		}
+	}
+	return false
+}
+
+func addAll(set map[string]bool, list []string) {
+	for _, s := range list {
+		set[s] = true
+	}
+}
+
+// baseIndex returns the index of the docs at the commit, from the shared
+// cache if it was made before; a commit's docs never change.
+func baseIndex(root, commit string) (*docIndex, error) {
+	c := cache.Default()
+	key := "docscheck/index:" + commit
+	var idx docIndex
+	if c.GetJSON(cache.API, key, &idx) {
+		return &idx, nil
+	}
+
+	dir, err := os.MkdirTemp("", "docscheck-base")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := extractSources(root, commit, dir); err != nil {
+		return nil, err
+	}
+	t, err := rstdoc.Load(dir)
+	if err != nil {
+		return nil, fmt.Errorf("docs at %s: %w", commit, err)
+	}
+	res := indexTree(t)
+	if err := c.PutJSON(cache.API, key, res, cache.Never); err != nil {
+		log.Println(err)
+	}
+	return res, nil
+}
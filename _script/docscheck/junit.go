@@ -0,0 +1,88 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// The JUnit XML format as most CI systems read it: a test suite with a
+// test case per check, failing when the check found errors. Warnings are
+// in the output of the test case.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnit(name string, rep report) error {
+	byCheck := make(map[string][]string)
+	for _, f := range rep.Findings {
+		byCheck[f.Check] = append(byCheck[f.Check], fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message))
+	}
+
+	suite := junitSuite{Name: "docscheck"}
+	var total float64
+	for _, s := range rep.Checks {
+		c := junitCase{Name: s.Check, Classname: "docscheck", Time: junitTime(s.Seconds)}
+		text := strings.Join(byCheck[s.Check], "\n")
+		switch {
+		case s.Count == 0:
+		case s.Severity == severityError:
+			c.Failure = &junitFailure{Message: fmt.Sprintf("%d problems", s.Count), Type: s.Severity, Text: text}
+			suite.Failures++
+		default:
+			c.SystemOut = fmt.Sprintf("%d warnings\n%s", s.Count, text)
+		}
+		suite.Cases = append(suite.Cases, c)
+		total += s.Seconds
+	}
+	suite.Tests = len(suite.Cases)
+	suite.Time = junitTime(total)
+
+	bs, err := xml.MarshalIndent(junitSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append([]byte(xml.Header), append(bs, '\n')...), 0o644)
+}
+
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
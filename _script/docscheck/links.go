@@ -0,0 +1,303 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// siteHost is where the docs are published; links to it are checked like
+// relative ones.
+const siteHost = "docs.syncthing.net"
+
+var (
+	schemeExp   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	autoIDExp   = regexp.MustCompile(`^id[0-9]+$`)
+	versionExp  = regexp.MustCompile(`^v[0-9]+(\.[0-9]+)*$`)
+	tocEntryExp = regexp.MustCompile(`^.*<(.+)>$`)
+)
+
+// checkLinks verifies that the internal links, that is :doc: and
+// :download: roles, relative and docs site hyperlinks, named hyperlink
+// references, toctree entries and included files, point at something
+// that exists. Images are left to the images check.
+func checkLinks(t *rstdoc.Tree) []problem {
+	var res []problem
+	for _, d := range t.Docs {
+		for _, r := range d.Refs {
+			if msg := checkRef(t, d, r); msg != "" {
+				res = append(res, problem{r.Pos, msg})
+			}
+		}
+		res = append(res, checkAnonymous(d)...)
+		for _, dir := range d.Directives {
+			res = append(res, checkDirective(t, d, dir)...)
+		}
+	}
+	return res
+}
+
+func checkRef(t *rstdoc.Tree, d *rstdoc.Doc, r *rstdoc.Ref) string {
+	switch {
+	case r.Role == "doc":
+		if t.Doc(resolveDoc(d, r.Target)) == nil {
+			return fmt.Sprintf("unknown document %q", r.Target)
+		}
+	case r.Role == "download":
+		if !fileExists(t, resolveFile(d, r.Target)) {
+			return fmt.Sprintf("download file %q does not exist", r.Target)
+		}
+	case r.Role != "":
+	case r.Named && !r.Anonymous:
+		if !hasName(d, r.Target) {
+			return fmt.Sprintf("unknown target %q", r.Target)
+		}
+	case !r.Named:
+		return checkURL(t, d, r.Target)
+	}
+	return ""
+}
+
+// checkURL checks a relative link or one to the docs site, returning a
+// description of the problem if any.
+func checkURL(t *rstdoc.Tree, d *rstdoc.Doc, link string) string {
+	var p string
+	if schemeExp.MatchString(link) {
+		u, err := url.Parse(link)
+		if err != nil || u.Host != siteHost {
+			return ""
+		}
+		p = strings.TrimPrefix(u.Path, "/")
+		if first, _, _ := strings.Cut(p, "/"); versionExp.MatchString(first) {
+			// A link to the docs of a specific version.
+			return ""
+		}
+		p = "/" + p
+		if u.Fragment != "" {
+			p += "#" + u.Fragment
+		}
+	} else {
+		p = link
+	}
+
+	p, anchor, _ := strings.Cut(p, "#")
+	target := d
+	switch {
+	case p == "":
+	case strings.HasSuffix(p, "/") || strings.HasSuffix(p, ".html"):
+		name := strings.TrimSuffix(p, ".html")
+		if strings.HasSuffix(name, "/") {
+			name += "index"
+		}
+		target = t.Doc(resolveDoc(d, name))
+		if target == nil {
+			return fmt.Sprintf("link to unknown document %q", link)
+		}
+	default:
+		if !fileExists(t, resolveFile(d, p)) {
+			return fmt.Sprintf("link to missing file %q", link)
+		}
+		return ""
+	}
+	if anchor != "" && !autoIDExp.MatchString(anchor) && !anchors(target)[anchor] {
+		return fmt.Sprintf("link to unknown anchor %q in %s", anchor, target.File)
+	}
+	return ""
+}
+
+// checkAnonymous checks there is an anonymous target for each anonymous
+// reference by name.
+func checkAnonymous(d *rstdoc.Doc) []problem {
+	var refs []*rstdoc.Ref
+	for _, r := range d.Refs {
+		if r.Named && r.Anonymous {
+			refs = append(refs, r)
+		}
+	}
+	targets := 0
+	for _, t := range d.Targets {
+		if t.Anonymous {
+			targets++
+		}
+	}
+	if len(refs) <= targets {
+		return nil
+	}
+	return []problem{{refs[targets].Pos, fmt.Sprintf("%d anonymous references but only %d anonymous targets", len(refs), targets)}}
+}
+
+func checkDirective(t *rstdoc.Tree, d *rstdoc.Doc, dir *rstdoc.Directive) []problem {
+	switch dir.Name {
+	case "include":
+		if !fileExists(t, rstdoc.IncludePath(dir.Pos.File, dir.Arg)) {
+			return []problem{{dir.Pos, fmt.Sprintf("included file %q does not exist", dir.Arg)}}
+		}
+	case "literalinclude":
+		if !fileExists(t, resolveFile(d, dir.Arg)) {
+			return []problem{{dir.Pos, fmt.Sprintf("%s file %q does not exist", dir.Name, dir.Arg)}}
+		}
+	case "toctree":
+		return checkToctree(t, d, dir)
+	}
+	return nil
+}
+
+func checkToctree(t *rstdoc.Tree, d *rstdoc.Doc, dir *rstdoc.Directive) []problem {
+	var res []problem
+	for _, e := range toctreeEntries(t, d, dir) {
+		switch {
+		case e.glob && len(e.docs) == 0:
+			res = append(res, problem{e.pos, fmt.Sprintf("toctree pattern %q matches no documents", e.entry)})
+		case len(e.docs) == 0:
+			res = append(res, problem{e.pos, fmt.Sprintf("toctree entry for unknown document %q", e.entry)})
+		}
+	}
+	return res
+}
+
+type tocEntry struct {
+	entry string
+	glob  bool
+	docs  []*rstdoc.Doc
+	pos   rstdoc.Pos
+}
+
+// toctreeEntries returns the document entries of a toctree with the
+// documents they resolve to.
+func toctreeEntries(t *rstdoc.Tree, d *rstdoc.Doc, dir *rstdoc.Directive) []tocEntry {
+	_, glob := dir.Options["glob"]
+	var res []tocEntry
+	for i, entry := range dir.Content {
+		entry = strings.TrimSpace(entry)
+		if m := tocEntryExp.FindStringSubmatch(entry); m != nil {
+			entry = m[1]
+		}
+		if entry == "" || entry == "self" || schemeExp.MatchString(entry) {
+			continue
+		}
+		e := tocEntry{
+			entry: entry,
+			glob:  glob && strings.ContainsAny(entry, "*?["),
+			pos:   rstdoc.Pos{File: dir.Pos.File, Line: dir.ContentLine + i},
+		}
+		name := resolveDoc(d, entry)
+		if e.glob {
+			e.docs = matchDocs(t, name)
+		} else if doc := t.Doc(name); doc != nil {
+			e.docs = []*rstdoc.Doc{doc}
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+// resolveDoc returns the document name a :doc: or toctree target refers
+// to: relative to the referring document, or to the root if absolute.
+func resolveDoc(d *rstdoc.Doc, target string) string {
+	target = strings.TrimSuffix(target, ".rst")
+	if strings.HasPrefix(target, "/") {
+		return path.Clean(strings.TrimPrefix(target, "/"))
+	}
+	return path.Join(d.Dir(), target)
+}
+
+// resolveFile returns a file path relative to the root, for a file
+// referred to by a document.
+func resolveFile(d *rstdoc.Doc, name string) string {
+	if strings.HasPrefix(name, "/") {
+		return path.Clean(strings.TrimPrefix(name, "/"))
+	}
+	return path.Join(d.Dir(), name)
+}
+
+func fileExists(t *rstdoc.Tree, name string) bool {
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(t.Root, filepath.FromSlash(name)))
+	return err == nil
+}
+
+// matchDocs returns the documents matching a glob pattern.
+func matchDocs(t *rstdoc.Tree, pattern string) []*rstdoc.Doc {
+	var res []*rstdoc.Doc
+	for _, d := range t.Docs {
+		if ok, _ := path.Match(pattern, d.Name); ok {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+// hasName reports whether the document has a target or section with the
+// given normalised name.
+func hasName(d *rstdoc.Doc, name string) bool {
+	for _, t := range d.Targets {
+		if !t.Anonymous && rstdoc.NormalizeName(t.Name) == name {
+			return true
+		}
+	}
+	for _, s := range d.Sections {
+		if rstdoc.NormalizeName(s.Title) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// anchors returns the HTML ids in a document, as far as they can be
+// known from the source: those of sections, internal targets and
+// option descriptions.
+func anchors(d *rstdoc.Doc) map[string]bool {
+	ids := make(map[string]bool)
+	for id := range anchorPositions(d) {
+		ids[id] = true
+	}
+	return ids
+}
+
+// anchorPositions returns the HTML ids in a document like anchors, with
+// where each is first defined.
+func anchorPositions(d *rstdoc.Doc) map[string]rstdoc.Pos {
+	ids := make(map[string]rstdoc.Pos)
+	add := func(id string, pos rstdoc.Pos) {
+		if _, ok := ids[id]; !ok {
+			ids[id] = pos
+		}
+	}
+	for _, s := range d.Sections {
+		add(s.ID(), s.Pos)
+	}
+	for _, t := range d.Targets {
+		if t.URL == "" && !t.Anonymous {
+			add(rstdoc.MakeID(t.Name), t.Pos)
+		}
+	}
+	for _, dir := range d.Directives {
+		switch dir.Name {
+		case "option", "stconf:option":
+			add("config-option-"+strings.ToLower(dir.Arg), dir.Pos)
+			for _, alias := range strings.Fields(dir.Options["aliases"]) {
+				add("config-option-"+strings.ToLower(alias), dir.Pos)
+			}
+		case "cmdoption", "std:cmdoption":
+			for _, opt := range strings.Split(dir.Arg, ",") {
+				opt, _, _ = strings.Cut(strings.TrimSpace(opt), "=")
+				add("cmdoption-"+strings.TrimLeft(opt, "-"), dir.Pos)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// metadataSchemaFile is the schema of the metadata check, set by a flag.
+var metadataSchemaFile = "docscheck/metadata.json"
+
+// metadataSchema is what metadata the pages may and must have.
+type metadataSchema struct {
+	// Fields are the known fields of the field list at the start of a
+	// document, such as orphan.
+	Fields []string `json:"fields"`
+	// Meta are the known names in meta directives, which become the
+	// HTML meta tags of the page.
+	Meta []string `json:"meta"`
+	// Description is the length range of the description, in
+	// characters, as search engines show it.
+	Description struct {
+		Min int `json:"min"`
+		Max int `json:"max"`
+	} `json:"description"`
+	// MaxKeywords is the most keywords a page may have for the search
+	// index, or zero for no limit.
+	MaxKeywords int `json:"maxKeywords"`
+	// Require lists the metadata the pages matching the patterns must
+	// have: "description", "keywords", or "label" for a label on the
+	// page title, which links to the page should use.
+	Require []struct {
+		Pages    []string `json:"pages"`
+		Metadata []string `json:"metadata"`
+	} `json:"require"`
+}
+
+// checkMetadata reports the pages missing the metadata required by the
+// schema or with metadata it doesn't know, descriptions of the wrong
+// length, too many or repeated keywords, and orphan markers on pages in a
+// toctree.
+func checkMetadata(t *rstdoc.Tree) []problem {
+	schema, err := readMetadataSchema(metadataSchemaFile)
+	if err != nil {
+		return []problem{{rstdoc.Pos{File: metadataSchemaFile, Line: 1}, err.Error()}}
+	}
+	var reached map[*rstdoc.Doc]bool
+	if root := t.Doc(masterDoc); root != nil {
+		reached = reachable(t, root)
+	}
+
+	var res []problem
+	for _, d := range t.Docs {
+		pos := rstdoc.Pos{File: d.File, Line: 1}
+		for name := range d.Meta {
+			if !contains(schema.Fields, name) {
+				res = append(res, problem{pos, fmt.Sprintf("unknown field %q", name)})
+			}
+		}
+		if _, ok := d.Meta["orphan"]; ok && reached[d] {
+			res = append(res, problem{pos, "page in a toctree is marked :orphan:"})
+		}
+
+		meta := make(map[string]string)
+		for _, dir := range d.Directives {
+			if dir.Name != "meta" {
+				continue
+			}
+			names := make([]string, 0, len(dir.Options))
+			for name := range dir.Options {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				val := dir.Options[name]
+				switch {
+				case !contains(schema.Meta, name):
+					res = append(res, problem{dir.Pos, fmt.Sprintf("unknown meta %q", name)})
+				case meta[name] != "":
+					res = append(res, problem{dir.Pos, fmt.Sprintf("meta %q is set twice", name)})
+				case name == "description":
+					if n := utf8.RuneCountInString(val); n < schema.Description.Min || n > schema.Description.Max {
+						res = append(res, problem{dir.Pos, fmt.Sprintf("description is %d characters; keep it between %d and %d", n, schema.Description.Min, schema.Description.Max)})
+					}
+				case name == "keywords":
+					res = append(res, checkKeywords(dir.Pos, val, schema.MaxKeywords)...)
+				}
+				meta[name] = val
+			}
+		}
+
+		for _, req := range schema.requires(d.Name) {
+			switch {
+			case req == "label":
+				if len(d.Sections) == 0 || len(d.Sections[0].Labels) == 0 {
+					res = append(res, problem{pos, "page title has no label"})
+				}
+			case meta[req] == "":
+				res = append(res, problem{pos, fmt.Sprintf("page has no %s meta", req)})
+			}
+		}
+	}
+	return res
+}
+
+func readMetadataSchema(name string) (*metadataSchema, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var schema metadataSchema
+	if err := json.Unmarshal(bs, &schema); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, r := range schema.Require {
+		for _, p := range r.Pages {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("%s: pattern %q: %w", name, p, err)
+			}
+		}
+	}
+	return &schema, nil
+}
+
+// requires returns the metadata the named document must have.
+func (s *metadataSchema) requires(doc string) []string {
+	var res []string
+	for _, r := range s.Require {
+		for _, p := range r.Pages {
+			if ok, _ := path.Match(p, doc); ok {
+				res = append(res, r.Metadata...)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func checkKeywords(pos rstdoc.Pos, val string, max int) []problem {
+	var res []problem
+	seen := make(map[string]bool)
+	n := 0
+	for _, kw := range strings.Split(val, ",") {
+		kw = strings.ToLower(strings.TrimSpace(kw))
+		if kw == "" {
+			continue
+		}
+		if seen[kw] {
+			res = append(res, problem{pos, fmt.Sprintf("keyword %q is repeated", kw)})
+		}
+		seen[kw] = true
+		n++
+	}
+	if max > 0 && n > max {
+		res = append(res, problem{pos, fmt.Sprintf("%d keywords; use at most %d", n, max)})
+	}
+	return res
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// masterDoc is the root of the toctree, as master_doc in conf.py.
+const masterDoc = "index"
+
+// checkOrphans reports the documents that can't be reached through the
+// toctrees from the master document, and so are missing from the
+// navigation, unless marked :orphan:. Entries for missing documents are
+// reported by the links check.
+func checkOrphans(t *rstdoc.Tree) []problem {
+	root := t.Doc(masterDoc)
+	if root == nil {
+		return []problem{{rstdoc.Pos{File: masterDoc + ".rst", Line: 1}, "master document does not exist"}}
+	}
+
+	reached := reachable(t, root)
+	var res []problem
+	for _, d := range t.Docs {
+		if _, ok := d.Meta["orphan"]; ok || reached[d] {
+			continue
+		}
+		res = append(res, problem{rstdoc.Pos{File: d.File, Line: 1}, fmt.Sprintf("document %q is not in any toctree", d.Name)})
+	}
+	return res
+}
+
+// reachable returns the documents reached through the toctrees from the
+// root document, including the root.
+func reachable(t *rstdoc.Tree, root *rstdoc.Doc) map[*rstdoc.Doc]bool {
+	reached := map[*rstdoc.Doc]bool{root: true}
+	queue := []*rstdoc.Doc{root}
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		for _, dir := range d.Directives {
+			if dir.Name != "toctree" {
+				continue
+			}
+			for _, e := range toctreeEntries(t, d, dir) {
+				for _, doc := range e.docs {
+					if !reached[doc] {
+						reached[doc] = true
+						queue = append(queue, doc)
+					}
+				}
+			}
+		}
+	}
+	return reached
+}
@@ -0,0 +1,173 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// syncthingRepo is a clone of the Syncthing repository to check the
+// source links against, if set.
+var syncthingRepo string
+
+var (
+	// sourceLinkExp matches links to files and lines in the Syncthing
+	// repository on GitHub.
+	sourceLinkExp = regexp.MustCompile(`^https://github\.com/syncthing/syncthing/(?:blob|tree|raw)/([^/]+)/([^#?]*)(?:#L(\d+)(?:-L(\d+))?)?$`)
+	// pinnedRefExp matches the refs that don't move: commits and release
+	// tags.
+	pinnedRefExp = regexp.MustCompile(`^(?:[0-9a-f]{40}|v\d+\.\d+\.\d+)$`)
+)
+
+// sourceLink is a link to a file, directory or lines in the Syncthing
+// repository.
+type sourceLink struct {
+	url       string
+	ref, path string
+	// line is the last line linked to, or zero.
+	line int
+	pos  rstdoc.Pos
+}
+
+// checkPermalinks reports links to lines in the Syncthing source that
+// are to a branch rather than a commit or release, as the lines move, and
+// with -syncthing, links to files that don't exist at the ref or lines
+// past their end. Links to files and directories on a branch are fine,
+// as they're meant to show the current version.
+func checkPermalinks(t *rstdoc.Tree) []problem {
+	var res []problem
+	for _, l := range sourceLinks(t) {
+		if l.line > 0 && !pinnedRefExp.MatchString(l.ref) {
+			res = append(res, problem{l.pos, fmt.Sprintf("link to lines on branch %q; use a permalink to a commit or release (docscheck -pin-permalinks)", l.ref)})
+		}
+		if syncthingRepo == "" {
+			continue
+		}
+		lines, err := sourceLines(syncthingRepo, l.ref, l.path)
+		switch {
+		case err != nil:
+			res = append(res, problem{l.pos, fmt.Sprintf("%s does not exist at %s", l.path, l.ref)})
+		case l.line > lines:
+			res = append(res, problem{l.pos, fmt.Sprintf("%s has only %d lines at %s, not %d", l.path, lines, l.ref, l.line)})
+		}
+	}
+	return res
+}
+
+// pinPermalinks rewrites the links to lines on a branch to the commit the
+// branch is at in the clone, returning the number rewritten.
+func pinPermalinks(t *rstdoc.Tree, repo string) (int, error) {
+	commits := make(map[string]string)
+	pinned := 0
+	for _, l := range sourceLinks(t) {
+		if l.line == 0 || pinnedRefExp.MatchString(l.ref) {
+			continue
+		}
+		commit, ok := commits[l.ref]
+		if !ok {
+			var err error
+			if commit, err = resolveRef(repo, l.ref); err != nil {
+				return pinned, fmt.Errorf("%s: %w", l.pos, err)
+			}
+			commits[l.ref] = commit
+		}
+		file := filepath.Join(t.Root, filepath.FromSlash(l.pos.File))
+		bs, err := os.ReadFile(file)
+		if err != nil {
+			return pinned, err
+		}
+		newURL := strings.Replace(l.url, "/"+l.ref+"/", "/"+commit+"/", 1)
+		bs = bytes.ReplaceAll(bs, []byte(l.url), []byte(newURL))
+		if err := os.WriteFile(file, bs, 0o644); err != nil {
+			return pinned, err
+		}
+		pinned++
+	}
+	return pinned, nil
+}
+
+// sourceLinks returns the links to the Syncthing source, from hyperlinks
+// and targets.
+func sourceLinks(t *rstdoc.Tree) []sourceLink {
+	seen := make(map[rstdoc.Pos]bool)
+	var res []sourceLink
+	add := func(u string, pos rstdoc.Pos) {
+		m := sourceLinkExp.FindStringSubmatch(u)
+		if m == nil || seen[pos] {
+			return
+		}
+		// Included files are seen from each document including them.
+		seen[pos] = true
+		l := sourceLink{url: u, ref: m[1], path: strings.TrimSuffix(m[2], "/"), pos: pos}
+		if m[4] != "" {
+			l.line, _ = strconv.Atoi(m[4])
+		} else if m[3] != "" {
+			l.line, _ = strconv.Atoi(m[3])
+		}
+		res = append(res, l)
+	}
+	for _, d := range t.Docs {
+		for _, r := range d.Refs {
+			if r.Role == "" && !r.Named {
+				add(r.Target, r.Pos)
+			}
+		}
+		for _, tg := range d.Targets {
+			if tg.URL != "" && !tg.Inline {
+				add(tg.URL, tg.Pos)
+			}
+		}
+	}
+	return res
+}
+
+// sourceLines returns the number of lines in the file at the ref, or zero
+// for a directory. It fails if there's no such file or directory.
+func sourceLines(repo, ref, path string) (int, error) {
+	obj := ref + ":" + path
+	typ, err := gitOutput(repo, "cat-file", "-t", obj)
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(typ) != "blob" {
+		return 0, nil
+	}
+	content, err := gitOutput(repo, "cat-file", "blob", obj)
+	if err != nil {
+		return 0, err
+	}
+	return strings.Count(content, "\n"), nil
+}
+
+// resolveRef returns the commit a branch is at, in the clone or its
+// origin.
+func resolveRef(repo, ref string) (string, error) {
+	for _, r := range []string{"origin/" + ref, ref} {
+		if out, err := gitOutput(repo, "rev-parse", "--verify", "--quiet", r+"^{commit}"); err == nil {
+			return strings.TrimSpace(out), nil
+		}
+	}
+	return "", fmt.Errorf("unknown ref %q in %s", ref, repo)
+}
+
+func gitOutput(repo string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
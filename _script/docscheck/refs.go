@@ -0,0 +1,127 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// builtinLabels are provided by Sphinx itself.
+var builtinLabels = map[string]bool{
+	"genindex": true,
+	"modindex": true,
+	"search":   true,
+}
+
+type label struct {
+	target *rstdoc.Target
+	doc    *rstdoc.Doc
+}
+
+// labels returns the internal targets of all documents, by lower case
+// name as Sphinx compares them.
+func labels(t *rstdoc.Tree) map[string][]label {
+	res := make(map[string][]label)
+	for _, d := range t.Docs {
+		for _, tg := range d.Targets {
+			if tg.URL == "" && !tg.Anonymous && !tg.Inline {
+				name := strings.ToLower(tg.Name)
+				res[name] = append(res[name], label{tg, d})
+			}
+		}
+	}
+	return res
+}
+
+// checkRefs verifies that every :ref: refers to a label, that labels used
+// without an explicit title precede a section, and that labels are
+// unique. Unknown labels get the closest existing one suggested.
+func checkRefs(t *rstdoc.Tree) []problem {
+	labels := labels(t)
+	var names []string
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var res []problem
+	for _, name := range names {
+		ls := labels[name]
+		for _, l := range ls[1:] {
+			res = append(res, problem{l.target.Pos, fmt.Sprintf("duplicate label %q, also defined at %s", l.target.Name, ls[0].target.Pos)})
+		}
+	}
+
+	for _, d := range t.Docs {
+		for _, r := range d.Refs {
+			if r.Role != "ref" && r.Role != "std:ref" {
+				continue
+			}
+			name := strings.ToLower(r.Target)
+			ls, ok := labels[name]
+			switch {
+			case builtinLabels[name]:
+			case !ok:
+				msg := fmt.Sprintf("unknown label %q", r.Target)
+				if s := closest(name, names); s != "" {
+					msg += fmt.Sprintf(", did you mean %q?", s)
+				}
+				res = append(res, problem{r.Pos, msg})
+			case r.Text == "" && ls[0].target.Section == nil:
+				res = append(res, problem{r.Pos, fmt.Sprintf("label %q is not before a section and needs an explicit title", r.Target)})
+			}
+		}
+	}
+	return res
+}
+
+// closest returns the candidate nearest to s, if it's near enough to
+// likely be a typo.
+func closest(s string, candidates []string) string {
+	limit := len(s) / 4
+	if limit < 2 {
+		limit = 2
+	}
+	best, bestDist := "", limit+1
+	for _, c := range candidates {
+		if d := distance(s, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
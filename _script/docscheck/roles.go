@@ -0,0 +1,213 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// builtinRoles are the roles of docutils and Sphinx that the docs may
+// use without declaring them.
+var builtinRoles = map[string]bool{
+	"abbr": true, "code": true, "command": true, "dfn": true, "doc": true,
+	"download": true, "emphasis": true, "envvar": true, "file": true,
+	"guilabel": true, "kbd": true, "literal": true, "manpage": true,
+	"math": true, "menuselection": true, "numref": true, "option": true,
+	"pep": true, "program": true, "ref": true, "rfc": true, "samp": true,
+	"strong": true, "sub": true, "sup": true, "term": true,
+}
+
+// extlinkTargetExps are what the targets of the extlinks roles in conf.py
+// look like, as anything else makes a broken link.
+var extlinkTargetExps = map[string]*regexp.Regexp{
+	"issue":  regexp.MustCompile(`^[0-9]+$`),
+	"user":   regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?$`),
+	"commit": regexp.MustCompile(`^[0-9a-f]{7,40}$`),
+}
+
+// configOptionOptions are the options of the configuration option
+// directive of the stconf domain in _ext/syncthing_config.py.
+var configOptionOptions = map[string]bool{
+	"mandatory":       true,
+	"aliases":         true,
+	"noindex":         true,
+	"noindexentry":    true,
+	"nocontentsentry": true,
+}
+
+// restTitleExp matches the title of a REST endpoint page.
+var restTitleExp = regexp.MustCompile(`^(GET|POST|PUT|PATCH|DELETE) (/rest/\S+)( \(DEPRECATED\))?$`)
+
+// checkRoles reports uses of our own roles and directives that Sphinx
+// would render wrong rather than fail on: undeclared roles, extlinks
+// roles with malformed targets, configuration option roles outside the
+// stconf domain or to undefined options, configuration option directives
+// with unknown options, and REST endpoint pages whose title doesn't match
+// their file name or deprecation.
+func checkRoles(t *rstdoc.Tree) []problem {
+	options, sections := configOptions(t)
+
+	var res []problem
+	for _, d := range t.Docs {
+		declared := make(map[string]bool)
+		stconf := false
+		for _, dir := range d.Directives {
+			switch dir.Name {
+			case "role":
+				name, _, _ := strings.Cut(strings.TrimSpace(dir.Arg), "(")
+				declared[strings.TrimSpace(name)] = true
+			case "default-domain":
+				stconf = stconf || strings.TrimSpace(dir.Arg) == "stconf"
+			}
+		}
+
+		for _, r := range d.Refs {
+			switch {
+			case r.Role == "":
+			case r.Role == "opt" && !stconf:
+				res = append(res, problem{r.Pos, "role :opt: outside the stconf domain; use :stconf:opt: or add .. default-domain:: stconf"})
+			case r.Role == "opt" || r.Role == "stconf:opt":
+				if !resolveOption(options, sections, r.Target) {
+					res = append(res, problem{r.Pos, fmt.Sprintf("unknown configuration option %q", r.Target)})
+				}
+			case extlinkTargetExps[r.Role] != nil:
+				if !extlinkTargetExps[r.Role].MatchString(r.Target) {
+					res = append(res, problem{r.Pos, fmt.Sprintf("malformed :%s: target %q", r.Role, r.Target)})
+				}
+			case !builtinRoles[r.Role] && !declared[r.Role]:
+				res = append(res, problem{r.Pos, fmt.Sprintf("unknown role :%s:; declare it with .. role:: in the document", r.Role)})
+			}
+		}
+
+		inDomain := false
+		for _, dir := range d.Directives {
+			if dir.Name == "default-domain" {
+				inDomain = strings.TrimSpace(dir.Arg) == "stconf"
+			}
+			if dir.Name != "stconf:option" && !(dir.Name == "option" && inDomain) {
+				continue
+			}
+			if strings.TrimSpace(dir.Arg) == "" {
+				res = append(res, problem{dir.Pos, "configuration option without a name"})
+			}
+			for name := range dir.Options {
+				if !configOptionOptions[name] {
+					res = append(res, problem{dir.Pos, fmt.Sprintf("unknown configuration option directive option %q", name)})
+				}
+			}
+		}
+
+		if strings.HasPrefix(d.Name, "rest/") {
+			res = append(res, checkRESTPage(d)...)
+		}
+	}
+	return res
+}
+
+// configOptions returns the configuration options defined in the stconf
+// domain, and their sections.
+func configOptions(t *rstdoc.Tree) (map[string]bool, map[string]bool) {
+	options := make(map[string]bool)
+	sections := make(map[string]bool)
+	for _, d := range t.Docs {
+		stconf := false
+		for _, dir := range d.Directives {
+			if dir.Name == "default-domain" {
+				stconf = strings.TrimSpace(dir.Arg) == "stconf"
+			}
+			if dir.Name != "stconf:option" && !(dir.Name == "option" && stconf) {
+				continue
+			}
+			for _, sig := range signatures(dir) {
+				options[sig] = true
+				if section, _, ok := strings.Cut(sig, "."); ok {
+					sections[section] = true
+				}
+			}
+		}
+	}
+	return options, sections
+}
+
+// signatures returns the signatures of an object description directive,
+// one per line of the argument, which continues on the lines after the
+// directive up to a blank line.
+func signatures(dir *rstdoc.Directive) []string {
+	sigs := []string{strings.TrimSpace(dir.Arg)}
+	if len(dir.Options) > 0 || dir.ContentLine != dir.Pos.Line+1 {
+		return sigs
+	}
+	for _, l := range dir.Content {
+		if strings.TrimSpace(l) == "" {
+			break
+		}
+		sigs = append(sigs, strings.TrimSpace(l))
+	}
+	return sigs
+}
+
+// resolveOption reports whether a configuration option role target
+// refers to a defined option, the way the stconf domain resolves it: as
+// is, or without a dot in any of the sections.
+func resolveOption(options, sections map[string]bool, target string) bool {
+	target = strings.TrimPrefix(target, "~")
+	if options[target] {
+		return true
+	}
+	if strings.Contains(target, ".") {
+		return false
+	}
+	for section := range sections {
+		if options[section+"."+target] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRESTPage checks that the title of a REST endpoint page is the
+// method and path its file is named after, and says DEPRECATED when the
+// page includes the deprecation notice.
+func checkRESTPage(d *rstdoc.Doc) []problem {
+	if len(d.Sections) == 0 {
+		return nil
+	}
+	title := d.Sections[0]
+	method, _, _ := strings.Cut(title.Title, " ")
+	if method != strings.ToUpper(method) || !strings.HasPrefix(strings.TrimPrefix(title.Title, method+" "), "/") {
+		// Not an endpoint page.
+		return nil
+	}
+	m := restTitleExp.FindStringSubmatch(title.Title)
+	if m == nil {
+		return []problem{{title.Pos, fmt.Sprintf("REST endpoint title %q is not METHOD /rest/path", title.Title)}}
+	}
+
+	var res []problem
+	want := strings.ReplaceAll(strings.TrimPrefix(m[2], "/rest/"), "/", "-") + "-" + strings.ToLower(m[1])
+	if name := path.Base(d.Name); name != want {
+		res = append(res, problem{title.Pos, fmt.Sprintf("REST endpoint page for %s %s is named %s, not %s", m[1], m[2], name, want)})
+	}
+	deprecated := false
+	for _, dir := range d.Directives {
+		if dir.Name == "include" && strings.Contains(dir.Arg, "/includes/deprecated/") {
+			deprecated = true
+		}
+	}
+	switch {
+	case deprecated && m[3] == "":
+		res = append(res, problem{title.Pos, "deprecated REST endpoint title lacks (DEPRECATED)"})
+	case !deprecated && m[3] != "":
+		res = append(res, problem{title.Pos, "REST endpoint title says DEPRECATED, but the page doesn't include the deprecation notice"})
+	}
+	return res
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// The subset of SARIF 2.1.0 that code scanning reads, see
+// https://docs.github.com/en/code-security/code-scanning/integrating-with-code-scanning/sarif-support-for-code-scanning.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine int `json:"startLine"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// checkDescriptions are the short descriptions of the checks, shown with
+// the findings in code scanning.
+var checkDescriptions = map[string]string{
+	"links":      "Broken internal links",
+	"refs":       "References to undefined labels",
+	"orphans":    "Pages not in any toctree",
+	"images":     "Missing or unused images",
+	"codeblocks": "Invalid code examples",
+	"glossary":   "Glossary terms",
+	"permalinks": "Source links to lines on a branch",
+	"versions":   "Version directives",
+	"roles":      "Unknown or misused roles",
+	"duplicates": "Prose repeated across pages",
+	"external":   "Dead external links",
+	"anchors":    "Published pages and anchors that are gone",
+	"metadata":   "Page metadata",
+	"alttext":    "Images without alt text",
+	"terms":      "Terminology",
+	"spelling":   "Spelling",
+	"ignores":    "Ignore pattern examples",
+}
+
+// writeSARIF writes the report as a SARIF log for code scanning, where
+// the locations are relative to the root of the repository, the
+// documentation root.
+func writeSARIF(name string, rep report, root string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "docscheck",
+			InformationURI: "https://github.com/syncthing/docs/tree/main/_script/docscheck",
+		}},
+		Results: []sarifResult{},
+	}
+	for _, s := range rep.Checks {
+		desc, ok := checkDescriptions[s.Check]
+		if !ok {
+			// An extension check.
+			desc = s.Check
+		}
+		r := sarifRule{ID: s.Check, ShortDescription: sarifMessage{desc}}
+		r.DefaultConfiguration.Level = s.Severity
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, r)
+	}
+	for _, f := range rep.Findings {
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = sarifURI(root, f.File)
+		// Problems with a whole file are at line zero, which SARIF
+		// doesn't have.
+		loc.PhysicalLocation.Region.StartLine = f.Line
+		if f.Line < 1 {
+			loc.PhysicalLocation.Region.StartLine = 1
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.Check,
+			Level:     f.Severity,
+			Message:   sarifMessage{f.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	bs, err := json.MarshalIndent(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(bs, '\n'), 0o644)
+}
+
+// sarifURI returns the file relative to the documentation root. Most are
+// already; the configuration files of the checks are relative to the
+// working directory.
+func sarifURI(root, file string) string {
+	if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(file))); err == nil {
+		return file
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return file
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return file
+	}
+	rel, err := filepath.Rel(absRoot, abs)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
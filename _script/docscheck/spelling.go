@@ -0,0 +1,177 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// The dictionary, one word per line such as the one in the wamerican
+// package, and the project's own words of the spelling check, set by
+// flags.
+var (
+	dictFile  = "/usr/share/dict/words"
+	wordsFile = "docscheck/words.txt"
+)
+
+type misspelling struct {
+	Pos rstdoc.Pos
+	// Col is the byte offset in the line, starting at one.
+	Col  int
+	Word string
+}
+
+// checkSpelling reports the words of the prose that are in neither the
+// dictionary nor the project's words. Literals, code, directives, role
+// targets and URLs aren't prose and aren't checked, nor are words that
+// look like names or acronyms: those with digits or underscores,
+// capitals after the first letter, or all in capitals.
+func checkSpelling(t *rstdoc.Tree) []problem {
+	ms, err := misspellings(t)
+	if err != nil {
+		return []problem{{rstdoc.Pos{File: wordsFile, Line: 1}, err.Error()}}
+	}
+	var res []problem
+	for _, m := range ms {
+		res = append(res, problem{m.Pos, fmt.Sprintf("column %d: unknown word %q", m.Col, m.Word)})
+	}
+	return res
+}
+
+// acceptWords adds the words the spelling check reports to the project's
+// words, returning the number of uses accepted.
+func acceptWords(t *rstdoc.Tree) (int, error) {
+	ms, err := misspellings(t)
+	if err != nil {
+		return 0, err
+	}
+	var words []string
+	for _, m := range ms {
+		words = append(words, m.Word)
+	}
+	return len(words), addWords(wordsFile, words)
+}
+
+func misspellings(tree *rstdoc.Tree) ([]misspelling, error) {
+	dict := make(dictionary)
+	if err := dict.read(dictFile); err != nil {
+		return nil, err
+	}
+	if err := dict.read(wordsFile); err != nil {
+		return nil, err
+	}
+	seen := make(map[rstdoc.Pos]bool)
+	var res []misspelling
+	for _, doc := range tree.Docs {
+		for _, t := range doc.Texts {
+			// Included files are checked once.
+			if seen[t.Pos] {
+				continue
+			}
+			seen[t.Pos] = true
+			res = append(res, dict.check(t)...)
+		}
+	}
+	return res, nil
+}
+
+// spellWordExp matches words, with apostrophes inside them. Hyphenated words
+// are checked as their parts.
+var spellWordExp = regexp.MustCompile(`[\pL\pN_]+(?:['’][\pL]+)*`)
+
+// dictionary is the set of known words, in lower case.
+type dictionary map[string]bool
+
+// read adds the words of a file, one per line, ignoring blank lines and
+// those starting with #.
+func (d dictionary) read(file string) error {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			d[strings.ToLower(line)] = true
+		}
+	}
+	return nil
+}
+
+func (d dictionary) check(t *rstdoc.Text) []misspelling {
+	var res []misspelling
+	for i, line := range t.Prose {
+		for _, m := range spellWordExp.FindAllStringIndex(line, -1) {
+			word := line[m[0]:m[1]]
+			if skipWord(word) || d.known(word) {
+				continue
+			}
+			res = append(res, misspelling{
+				Pos:  rstdoc.Pos{File: t.Pos.File, Line: t.Pos.Line + i},
+				Col:  m[0] + 1,
+				Word: word,
+			})
+		}
+	}
+	return res
+}
+
+// known reports whether the word, or the word without a possessive 's,
+// is in the dictionary.
+func (d dictionary) known(word string) bool {
+	w := strings.ToLower(strings.ReplaceAll(word, "’", "'"))
+	return d[w] || d[strings.TrimSuffix(w, "'s")]
+}
+
+// skipWord reports whether the word looks like a name or acronym rather
+// than a word: with digits or underscores, capitals after the first
+// letter, or all in capitals.
+func skipWord(word string) bool {
+	if strings.IndexFunc(word, func(r rune) bool { return r == '_' || unicode.IsDigit(r) }) >= 0 {
+		return true
+	}
+	_, size := utf8.DecodeRuneInString(word)
+	return strings.IndexFunc(word[size:], unicode.IsUpper) >= 0
+}
+
+// addWords adds the words to the file, keeping it sorted and without
+// duplicates. Comments at the start of the file are kept.
+func addWords(file string, words []string) error {
+	bs, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var header []string
+	set := make(map[string]bool)
+	for _, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#") && len(set) == 0:
+			header = append(header, line)
+		case line != "" && !strings.HasPrefix(line, "#"):
+			set[line] = true
+		}
+	}
+	for _, w := range words {
+		set[strings.TrimSuffix(strings.ToLower(w), "'s")] = true
+	}
+	list := make([]string, 0, len(set))
+	for w := range set {
+		list = append(list, w)
+	}
+	sort.Strings(list)
+	out := append(header, list...)
+	return os.WriteFile(file, []byte(strings.Join(out, "\n")+"\n"), 0o644)
+}
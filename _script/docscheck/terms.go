@@ -0,0 +1,290 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// termsFile is the terms of the terms check, set by a flag.
+var termsFile = "docscheck/terms.json"
+
+// A rule is either a term to avoid, with the one to use instead, or a
+// term to use with the given capitalisation only.
+type rule struct {
+	Avoid string `json:"avoid"`
+	Use   string `json:"use"`
+	// Note is added to the message, such as when the term is fine in
+	// some sense.
+	Note string `json:"note"`
+}
+
+// name is how a suppression comment refers to the rule.
+func (r rule) name() string {
+	if r.Avoid != "" {
+		return strings.ToLower(r.Avoid)
+	}
+	return strings.ToLower(r.Use)
+}
+
+type termUse struct {
+	Pos rstdoc.Pos
+	// Col is the byte offset in the line, starting at one.
+	Col     int
+	Found   string
+	Replace string
+	Rule    rule
+}
+
+func (f termUse) message() string {
+	msg := fmt.Sprintf("column %d: use %q rather than %q", f.Col, f.Replace, f.Found)
+	if f.Rule.Note != "" {
+		msg += " (" + f.Rule.Note + ")"
+	}
+	return msg
+}
+
+var suppressExp = regexp.MustCompile(`^\.\.\s+termcheck:\s+(off|allow\s+(.+))\s*$`)
+
+// checkTerms reports the uses of the terms the project has chosen
+// against in the prose: "device" rather than "node", "Syncthing"
+// capitalised and so on, as listed in terms.json. Literals, code, URLs
+// and role targets aren't prose and aren't checked. A page can allow
+// some terms, or turn the check off, with a comment:
+//
+//	.. termcheck: allow node, repo
+//	.. termcheck: off
+func checkTerms(t *rstdoc.Tree) []problem {
+	findings, err := findTerms(t)
+	if err != nil {
+		return []problem{{rstdoc.Pos{File: termsFile, Line: 1}, err.Error()}}
+	}
+	var res []problem
+	for _, f := range findings {
+		res = append(res, problem{f.Pos, f.message()})
+	}
+	return res
+}
+
+// fixTerms replaces the uses the terms check reports in the sources,
+// returning the number replaced.
+func fixTerms(t *rstdoc.Tree) (int, error) {
+	findings, err := findTerms(t)
+	if err != nil {
+		return 0, err
+	}
+	return len(findings), applyFixes(t.Root, findings)
+}
+
+func findTerms(tree *rstdoc.Tree) ([]termUse, error) {
+	rules, err := readRules(termsFile)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var findings []termUse
+	for _, doc := range tree.Docs {
+		allowed, err := suppressions(tree.Root, doc)
+		if err != nil {
+			return nil, err
+		}
+		if allowed["off"] {
+			continue
+		}
+		for _, t := range doc.Texts {
+			for _, f := range checkText(t, rules) {
+				key := fmt.Sprintf("%s:%d", f.Pos, f.Col)
+				if allowed[f.Rule.name()] || seen[key] {
+					continue
+				}
+				seen[key] = true
+				findings = append(findings, f)
+			}
+		}
+	}
+	sort.Slice(findings, func(a, b int) bool {
+		fa, fb := findings[a], findings[b]
+		if fa.Pos.File != fb.Pos.File {
+			return fa.Pos.File < fb.Pos.File
+		}
+		if fa.Pos.Line != fb.Pos.Line {
+			return fa.Pos.Line < fb.Pos.Line
+		}
+		return fa.Col < fb.Col
+	})
+	return findings, nil
+}
+
+func readRules(file string) ([]rule, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var rules []rule
+	if err := json.Unmarshal(bs, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for _, r := range rules {
+		if r.Use == "" {
+			return nil, fmt.Errorf("%s: rule without a term to use", file)
+		}
+	}
+	return rules, nil
+}
+
+// suppressions returns the rules the document allows, by name, reading
+// the comments in its files. "off" is set if the check is turned off.
+func suppressions(root string, doc *rstdoc.Doc) (map[string]bool, error) {
+	files := map[string]bool{doc.File: true}
+	for _, t := range doc.Texts {
+		files[t.Pos.File] = true
+	}
+	allowed := make(map[string]bool)
+	for file := range files {
+		bs, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(bs), "\n") {
+			m := suppressExp.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				continue
+			}
+			if m[1] == "off" {
+				allowed["off"] = true
+				continue
+			}
+			for _, name := range strings.Split(m[2], ",") {
+				allowed[strings.ToLower(strings.TrimSpace(name))] = true
+			}
+		}
+	}
+	return allowed, nil
+}
+
+// checkText returns the uses of terms the rules are against in the
+// prose of the text.
+func checkText(t *rstdoc.Text, rules []rule) []termUse {
+	var res []termUse
+	for i, line := range t.Prose {
+		lower := strings.ToLower(line)
+		for _, r := range rules {
+			term := r.Avoid
+			if term == "" {
+				term = r.Use
+			}
+			for _, col := range wordIndexes(lower, strings.ToLower(term)) {
+				found := t.Lines[i][col : col+len(term)]
+				replace := r.Use
+				if r.Avoid == "" {
+					if found == r.Use {
+						continue
+					}
+				} else {
+					replace = matchCase(found, r.Use)
+				}
+				res = append(res, termUse{
+					Pos:     rstdoc.Pos{File: t.Pos.File, Line: t.Pos.Line + i},
+					Col:     col + 1,
+					Found:   found,
+					Replace: replace,
+					Rule:    r,
+				})
+			}
+		}
+	}
+	return res
+}
+
+// wordIndexes returns the offsets of term in s where it's a word of its
+// own, not part of a longer word, file name, host name or similar such
+// as "syncthing-inotify" or "syncthing.net".
+func wordIndexes(s, term string) []int {
+	var res []int
+	for start := 0; ; {
+		i := strings.Index(s[start:], term)
+		if i < 0 {
+			return res
+		}
+		i += start
+		end := i + len(term)
+		start = i + 1
+
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		if i > 0 && (isWordRune(before) || strings.ContainsRune("-./\\", before)) {
+			continue
+		}
+		if end < len(s) {
+			after, size := utf8.DecodeRuneInString(s[end:])
+			if isWordRune(after) || strings.ContainsRune("-/\\", after) {
+				continue
+			}
+			if after == '.' && end+size < len(s) {
+				if next, _ := utf8.DecodeRuneInString(s[end+size:]); isWordRune(next) {
+					continue
+				}
+			}
+		}
+		res = append(res, i)
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// matchCase returns the replacement for a term capitalised as the term
+// was.
+func matchCase(found, replace string) string {
+	first, _ := utf8.DecodeRuneInString(found)
+	if !unicode.IsUpper(first) {
+		return replace
+	}
+	r, size := utf8.DecodeRuneInString(replace)
+	return string(unicode.ToUpper(r)) + replace[size:]
+}
+
+// applyFixes replaces the findings in the source files.
+func applyFixes(root string, findings []termUse) error {
+	byFile := make(map[string][]termUse)
+	for _, f := range findings {
+		byFile[f.Pos.File] = append(byFile[f.Pos.File], f)
+	}
+	for file, fs := range byFile {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(string(bs), "\n")
+		// Last first, keeping the columns of the others valid.
+		for i := len(fs) - 1; i >= 0; i-- {
+			f := fs[i]
+			line := lines[f.Pos.Line-1]
+			col := f.Col - 1
+			if !strings.HasPrefix(line[col:], f.Found) {
+				return fmt.Errorf("%s:%d: source changed", f.Pos, f.Col)
+			}
+			lines[f.Pos.Line-1] = line[:col] + f.Replace + line[col+len(f.Found):]
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("%s: fixed %d\n", file, len(fs))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"path/filepath"
+
+	"syncthing.net/docs/internal/rstdoc"
+	"syncthing.net/docs/internal/versions"
+)
+
+// versionsTable is the table of Syncthing releases, relative to the
+// documentation root.
+const versionsTable = "users/releases.csv"
+
+// checkVersions reports versionadded, versionchanged and deprecated
+// directives for versions that aren't in the versions table, such as
+// "1.23.10" for "1.23.1", or that are newer than the latest release.
+func checkVersions(t *rstdoc.Tree) []problem {
+	table, err := versions.Load(filepath.Join(t.Root, filepath.FromSlash(versionsTable)))
+	if err != nil {
+		return []problem{{rstdoc.Pos{File: versionsTable, Line: 1}, err.Error()}}
+	}
+	var res []problem
+	for _, m := range table.Mentions(t) {
+		if m.Problem != "" {
+			res = append(res, problem{m.Pos, m.Problem})
+		}
+	}
+	return res
+}
@@ -0,0 +1,242 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./envvars -tag v1.27.0 -since v1.0.0,v1.10.0 > ../includes/env-vars.rst
+//
+// Reads the environment variables from the Syncthing source and writes the
+// reference of them included in the command line and debugging pages. Variables are described by the help
+// text of "syncthing --help" or of the command line option they stand in
+// for; variables read without either are described in the notes below,
+// and listed on stderr when there is no note for them.
+//
+// Given -since, a list of older versions, variables that were added or
+// removed since are noted with the version of the change.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/stsource"
+)
+
+// notes describe the variables that the source doesn't describe, or not
+// well enough to stand alone in the docs.
+var notes = map[string]string{
+	"STGUIADDRESS":          "Override the GUI listen address. Equivalent to :option:`--gui-address`.",
+	"STGUIAPIKEY":           "Override the API key needed to access the GUI and REST API. Equivalent to :option:`--gui-apikey`.",
+	"STTRACE":               "Used to increase the debugging verbosity in specific or all facilities, generally mapping to a Go package. Enabling any of these also enables microsecond timestamps, file names plus line numbers. Enter a comma-separated string of facilities to trace, or ``all`` for all of them. ``syncthing --help`` always outputs an up-to-date list.",
+	"ALL_PROXY_NO_FALLBACK": "Set to any nonempty value to fail connections that can't be made through the proxy set in ``all_proxy``, instead of falling back to a direct connection.",
+	"LOGGER_DISCARD":        "Hack to completely disable logging, for example when running benchmarks. Set to any nonempty value to use it.",
+	"XDG_CONFIG_HOME":       "On Unix, the directory below which the configuration directory is created, as ``$XDG_CONFIG_HOME/syncthing``.",
+	"XDG_DATA_HOME":         "On Unix, the directory below which the database directory is created, as ``$XDG_DATA_HOME/syncthing``.",
+	"XDG_STATE_HOME":        "On Unix, the directory below which the configuration and database are kept, as ``$XDG_STATE_HOME/syncthing``, when set to an absolute path.",
+}
+
+// ignored are variables read by Syncthing that are the system's own, not
+// settings for Syncthing: where Windows keeps the user's files, and the
+// executable file extensions.
+var ignored = map[string]bool{
+	"AppData":      true,
+	"HomeDrive":    true,
+	"HomePath":     true,
+	"LocalAppData": true,
+	"PATHEXT":      true,
+}
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	since := flag.String("since", "", "Comma separated older versions, oldest first, to find added and removed variables")
+	doc := flag.String("doc", "../users/syncthing.rst", "Command line page with the documented options")
+	flag.Parse()
+
+	documented, err := readCmdOptions(*doc)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// The older versions are checked out first, leaving a source
+	// directory at the requested version.
+	var history []map[string]bool
+	var historyTags []string
+	if *since != "" {
+		for _, t := range strings.Split(*since, ",") {
+			vars, err := variablesAt(*src, t)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			history = append(history, namesOf(vars))
+			historyTags = append(historyTags, t)
+		}
+	}
+
+	vars, err := variablesAt(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(vars) == 0 {
+		log.Fatalln("no environment variables found")
+	}
+	history = append(history, namesOf(vars))
+	historyTags = append(historyTags, *tag)
+
+	w := bufio.NewWriter(os.Stdout)
+	writeReference(w, vars, documented, history, historyTags)
+	if err := w.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// variablesAt returns the variables of a version.
+func variablesAt(dir, tag string) ([]variable, error) {
+	root, cleanup, err := stsource.Open(dir, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	var pkgs []*stsource.Package
+	for _, d := range sourceDirs {
+		ps, err := stsource.ParseTree(root, d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+		pkgs = append(pkgs, ps...)
+	}
+	return variables(pkgs), nil
+}
+
+func namesOf(vars []variable) map[string]bool {
+	names := make(map[string]bool)
+	for _, v := range vars {
+		names[v.Name] = true
+	}
+	return names
+}
+
+// readCmdOptions returns the long command line options documented with
+// cmdoption directives in the page.
+func readCmdOptions(path string) (map[string]bool, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	opts := make(map[string]bool)
+	sc := bufio.NewScanner(fd)
+	for sc.Scan() {
+		rest, ok := strings.CutPrefix(sc.Text(), ".. cmdoption::")
+		if !ok {
+			continue
+		}
+		for _, opt := range strings.Split(rest, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(opt), "=")
+			opts[name] = true
+		}
+	}
+	return opts, sc.Err()
+}
+
+func writeReference(w io.Writer, vars []variable, documented map[string]bool, history []map[string]bool, tags []string) {
+	varChanges := changes(history, tags)
+	fmt.Fprint(w, ".. This file is generated by _script/envvars; do not edit.\n\n")
+	for _, v := range vars {
+		fmt.Fprintf(w, "%s\n", v.Name)
+		writeParagraph(w, description(v, documented))
+		if c := varChanges[v.Name]; len(c) > 0 {
+			writeParagraph(w, strings.Join(c, " "))
+		}
+		if v.Name == "STTRACE" {
+			writeParagraph(w, "The valid facility strings are listed in :ref:`debug-facilities`.")
+		}
+	}
+
+	// Variables that are gone, to explain them in old setups.
+	var removed []string
+	for name := range varChanges {
+		if !history[len(history)-1][name] {
+			removed = append(removed, name)
+		}
+	}
+	sortNames(removed)
+	for _, name := range removed {
+		fmt.Fprintf(w, "%s\n", name)
+		writeParagraph(w, "No longer used. "+strings.Join(varChanges[name], " "))
+	}
+}
+
+func sortNames(ss []string) {
+	sort.Slice(ss, func(a, b int) bool { return varLess(ss[a], ss[b]) })
+}
+
+// description returns the description of the variable: the help text,
+// or a note, followed by the option it's equivalent to.
+func description(v variable, documented map[string]bool) string {
+	if note, ok := notes[v.Name]; ok {
+		return note
+	}
+	text := rst.Escape(v.Usage)
+	if text == "" && v.Help != "" {
+		text = rst.Escape(v.Help)
+	}
+	if text != "" && !strings.HasSuffix(text, ".") {
+		text += "."
+	}
+	if text == "" {
+		log.Printf("no description for %s", v.Name)
+		text = "(No description.)"
+	}
+	if v.Flag != "" {
+		opt := rst.Literal(v.Flag)
+		if documented[v.Flag] {
+			opt = ":option:`" + v.Flag + "`"
+		}
+		text += " Equivalent to " + opt + "."
+	}
+	return text
+}
+
+// writeParagraph writes the text as a paragraph of the definition list,
+// wrapped and indented, followed by a blank line.
+func writeParagraph(w io.Writer, text string) {
+	line := "   "
+	for _, word := range strings.Fields(text) {
+		if len(line)+1+len(word) > 79 && len(line) > 3 {
+			fmt.Fprintf(w, "%s\n", line)
+			line = "   "
+		}
+		line += " " + word
+	}
+	fmt.Fprintf(w, "%s\n\n", line)
+}
+
+// changes returns what changed about the variables between the
+// versions, oldest first, as sentences.
+func changes(history []map[string]bool, tags []string) map[string][]string {
+	res := make(map[string][]string)
+	for i := 1; i < len(history); i++ {
+		prev, cur, tag := history[i-1], history[i], tags[i]
+		for name := range cur {
+			if !prev[name] {
+				res[name] = append(res[name], fmt.Sprintf("Added in %s.", tag))
+			}
+		}
+		for name := range prev {
+			if !cur[name] {
+				res[name] = append(res[name], fmt.Sprintf("Removed in %s.", tag))
+			}
+		}
+	}
+	return res
+}
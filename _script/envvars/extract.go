@@ -0,0 +1,191 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/ast"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// sourceDirs are searched for environment variables.
+var sourceDirs = []string{"cmd/syncthing", "lib"}
+
+// variable is an environment variable read by Syncthing.
+type variable struct {
+	Name string
+	// Usage is the description from the help text, Help that of the
+	// command line option (Flag) read from the variable.
+	Usage string
+	Help  string
+	Flag  string
+}
+
+// variables returns the environment variables, by name: those given in
+// the help text, those set through command line options and those read
+// with os.Getenv or os.LookupEnv. The variables of hidden options are
+// internal and skipped.
+func variables(pkgs []*stsource.Package) []variable {
+	vars := make(map[string]*variable)
+	get := func(name string) *variable {
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		v := &variable{Name: name}
+		vars[name] = v
+		return v
+	}
+	hidden := make(map[string]bool)
+
+	for _, pkg := range pkgs {
+		for _, f := range pkg.SortedFiles() {
+			ast.Inspect(f, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.Field:
+					name, flag, help, isHidden := envOption(n)
+					if name == "" {
+						return true
+					}
+					if isHidden {
+						hidden[name] = true
+						return true
+					}
+					v := get(name)
+					v.Flag, v.Help = flag, help
+				case *ast.ValueSpec:
+					for i, id := range n.Names {
+						if id.Name != "extraUsage" || i >= len(n.Values) {
+							continue
+						}
+						if s, ok := stsource.StringLit(n.Values[i]); ok {
+							for name, usage := range usageVariables(s) {
+								get(name).Usage = usage
+							}
+						}
+					}
+				case *ast.CallExpr:
+					sel, ok := n.Fun.(*ast.SelectorExpr)
+					if !ok || len(n.Args) != 1 || (sel.Sel.Name != "Getenv" && sel.Sel.Name != "LookupEnv") {
+						return true
+					}
+					if x, ok := sel.X.(*ast.Ident); !ok || x.Name != "os" {
+						return true
+					}
+					if name, ok := stsource.StringLit(n.Args[0]); ok && name != "" {
+						get(name)
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	var res []variable
+	for name, v := range vars {
+		if !hidden[name] && !ignored[name] {
+			res = append(res, *v)
+		}
+	}
+	sort.Slice(res, func(a, b int) bool { return varLess(res[a].Name, res[b].Name) })
+	return res
+}
+
+// varLess sorts Syncthing's own variables first.
+func varLess(a, b string) bool {
+	if sa, sb := strings.HasPrefix(a, "ST"), strings.HasPrefix(b, "ST"); sa != sb {
+		return sa
+	}
+	return a < b
+}
+
+// envOption returns the variable of a command line option read from the
+// environment, with the option name and help text.
+func envOption(f *ast.Field) (name, flag, help string, hidden bool) {
+	if f.Tag == nil || len(f.Names) != 1 {
+		return "", "", "", false
+	}
+	tagValue, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return "", "", "", false
+	}
+	tags := reflect.StructTag(tagValue)
+	name = tags.Get("env")
+	if name == "" {
+		return "", "", "", false
+	}
+	flag = tags.Get("name")
+	if flag == "" {
+		flag = kebab(f.Names[0].Name)
+	}
+	return name, "--" + flag, tags.Get("help"), tags.Get("hidden") != ""
+}
+
+// kebab returns the option name for a field name the way the command
+// line parser derives it: DebugProfileCPU becomes debug-profile-cpu.
+func kebab(s string) string {
+	var words []string
+	for i := 0; i < len(s); {
+		j := i + 1
+		switch {
+		case isUpper(s[i]) && j < len(s) && isUpper(s[j]):
+			// An acronym, up to the start of the next word.
+			for j < len(s) && isUpper(s[j]) && (j+1 == len(s) || isUpper(s[j+1]) || !isLower(s[j+1])) {
+				j++
+			}
+		default:
+			for j < len(s) && !isUpper(s[j]) {
+				j++
+			}
+		}
+		words = append(words, strings.ToLower(s[i:j]))
+		i = j
+	}
+	return strings.Join(words, "-")
+}
+
+func isUpper(c byte) bool { return c >= 'A' && c <= 'Z' }
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+
+// usageLineExp matches the start of a variable in the help text, the
+// name indented by a space and followed by its description.
+var usageLineExp = regexp.MustCompile(`^ ([A-Z][A-Z0-9_]+)\s+(\S.*)$`)
+
+// usageVariables returns the variables described in the help text, with
+// their descriptions.
+func usageVariables(s string) map[string]string {
+	res := make(map[string]string)
+	var cur string
+	var text []string
+	flush := func() {
+		if cur != "" {
+			res[cur] = strings.Join(text, " ")
+		}
+		cur, text = "", nil
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if m := usageLineExp.FindStringSubmatch(line); m != nil {
+			flush()
+			cur, text = m[1], []string{strings.TrimSpace(m[2])}
+			continue
+		}
+		if cur != "" && strings.HasPrefix(line, "   ") && strings.TrimSpace(line) != "" {
+			text = append(text, strings.TrimSpace(line))
+			continue
+		}
+		flush()
+	}
+	flush()
+	for name, text := range res {
+		res[name] = strings.Join(strings.Fields(text), " ")
+	}
+	return res
+}
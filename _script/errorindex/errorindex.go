@@ -0,0 +1,226 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./errorindex -tag v1.27.0 > ../includes/error-index.rst
+//
+// Extracts the error values of the user facing packages in the Syncthing
+// source, and the errors containing the phrases listed in targets.json,
+// and writes an index of them by category, linking each to the section
+// of the docs that explains it as given in targets.json. Errors without
+// a section, and targets that don't exist in the docs, are listed on
+// stderr.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/stsource"
+)
+
+type targetList struct {
+	// Include are phrases, mapped to their category, for errors to
+	// index wherever they are created.
+	Include map[string]string `json:"include"`
+	// Internal are errors that aren't shown to users, left out of the
+	// index.
+	Internal []string `json:"internal"`
+	// Targets map the start of error texts to the section explaining
+	// them: a label, a document as /path, or a reference in full such
+	// as :stconf:opt:`folder.minDiskFree`.
+	Targets map[string]string `json:"targets"`
+}
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	targetsFile := flag.String("targets", "errorindex/targets.json", "JSON file mapping errors to docs sections")
+	docs := flag.String("docs", "..", "Docs root, to check the targets")
+	flag.Parse()
+
+	targets, err := readTargets(*targetsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+	failures, err := extract(root, targets.Include)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	failures = removeInternal(failures, targets.Internal)
+	if len(failures) == 0 {
+		log.Fatalln("no errors found")
+	}
+
+	labels, err := readLabels(*docs)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	used := make(map[string]bool)
+	for _, f := range failures {
+		key := targetKey(f.Text, targets.Targets)
+		if key == "" {
+			log.Printf("no documentation: %s (%s)", f.Text, f.Sources[0])
+			continue
+		}
+		used[key] = true
+	}
+	for key, target := range targets.Targets {
+		if !used[key] {
+			log.Printf("no such error: %s", key)
+		}
+		if !targetExists(target, labels, *docs) {
+			log.Printf("no such target: %s", target)
+		}
+	}
+
+	if err := writeIndex(os.Stdout, failures, targets.Targets); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func readTargets(path string) (*targetList, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var targets targetList
+	if err := json.Unmarshal(bs, &targets); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &targets, nil
+}
+
+func removeInternal(failures []failure, internal []string) []failure {
+	skip := make(map[string]bool)
+	for _, text := range internal {
+		skip[text] = true
+	}
+	res := failures[:0]
+	for _, f := range failures {
+		if !skip[f.Text] {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// targetKey returns the longest key of the targets the text starts with,
+// or the empty string.
+func targetKey(text string, targets map[string]string) string {
+	best := ""
+	for key := range targets {
+		if strings.HasPrefix(text, key) && len(key) > len(best) {
+			best = key
+		}
+	}
+	return best
+}
+
+var labelExp = regexp.MustCompile(`^\.\. _([^:]+):\s*$`)
+
+// readLabels returns the reference labels defined in the docs, in lower
+// case as Sphinx matches them.
+func readLabels(root string) (map[string]bool, error) {
+	labels := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (strings.HasPrefix(d.Name(), "_") || strings.HasPrefix(d.Name(), ".")) && path != root {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".rst") {
+			return nil
+		}
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(bs), "\n") {
+			if m := labelExp.FindStringSubmatch(line); m != nil {
+				labels[strings.ToLower(m[1])] = true
+			}
+		}
+		return nil
+	})
+	return labels, err
+}
+
+// targetExists checks that a label or document exists; references in
+// full aren't checked.
+func targetExists(target string, labels map[string]bool, root string) bool {
+	switch {
+	case strings.HasPrefix(target, ":"):
+		return true
+	case strings.HasPrefix(target, "/"):
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(target)+".rst"))
+		return err == nil
+	default:
+		return labels[strings.ToLower(target)]
+	}
+}
+
+// reference returns the markup linking to a target.
+func reference(target string) string {
+	switch {
+	case strings.HasPrefix(target, ":"):
+		return target
+	case strings.HasPrefix(target, "/"):
+		return ":doc:`" + target + "`"
+	default:
+		return ":ref:`" + target + "`"
+	}
+}
+
+func writeIndex(w io.Writer, failures []failure, targets map[string]string) error {
+	if _, err := fmt.Fprint(w, ".. This file is generated by _script/errorindex; do not edit.\n\n"); err != nil {
+		return err
+	}
+	var cats []string
+	byCat := make(map[string][]failure)
+	for _, f := range failures {
+		if _, ok := byCat[f.Category]; !ok {
+			cats = append(cats, f.Category)
+		}
+		byCat[f.Category] = append(byCat[f.Category], f)
+	}
+	sort.Strings(cats)
+	for _, cat := range cats {
+		t := rst.Table{
+			Title:  cat,
+			Header: []string{"Error", "See"},
+			Widths: []int{60, 40},
+		}
+		for _, f := range byCat[cat] {
+			see := "Not yet documented."
+			if key := targetKey(f.Text, targets); key != "" {
+				see = reference(targets[key])
+			}
+			t.Rows = append(t.Rows, []string{rst.Literal(f.Text), see})
+		}
+		if _, err := t.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// sourceDirs are searched for errors.
+var sourceDirs = []string{"lib", "cmd/syncthing"}
+
+// categories are the failure categories of the packages whose errors are
+// shown to users, by directory. Errors of other packages are internal.
+var categories = map[string]string{
+	"lib/config":      "Folders",
+	"lib/model":       "Folders",
+	"lib/db":          "Database",
+	"lib/fs":          "Filesystem",
+	"lib/scanner":     "Scanning",
+	"lib/ignore":      "Ignore Patterns",
+	"lib/versioner":   "Versioning",
+	"lib/connections": "Connections",
+	"cmd/syncthing":   "Startup",
+}
+
+// placeholder replaces the formatted values in messages.
+const placeholder = "<...>"
+
+var verbExp = regexp.MustCompile(`%[-+# 0-9.*]*[a-zA-Z]`)
+
+type failure struct {
+	Text     string
+	Category string
+	Sources  []string
+}
+
+// extract returns the errors in the source tree: the package level error
+// values of the user facing packages, and the errors created anywhere
+// whose text contains one of the included phrases, in the category given
+// for the phrase.
+func extract(root string, include map[string]string) ([]failure, error) {
+	byText := make(map[string]*failure)
+	add := func(pkg *stsource.Package, call *ast.CallExpr, text, category string) {
+		file, line := pkg.Position(call)
+		f, ok := byText[text]
+		if !ok {
+			f = &failure{Text: text, Category: category}
+			byText[text] = f
+		}
+		f.Sources = append(f.Sources, file+":"+strconv.Itoa(line))
+	}
+
+	for _, dir := range sourceDirs {
+		pkgs, err := stsource.ParseTree(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range pkgs {
+			category := categoryOf(pkg.Dir)
+			consts := stringConsts(pkg)
+			errs := errorValues(pkg, consts)
+			for name, f := range pkg.Files {
+				if strings.HasSuffix(name, ".pb.go") {
+					continue
+				}
+				for _, decl := range f.Decls {
+					gd, ok := decl.(*ast.GenDecl)
+					if !ok || gd.Tok != token.VAR {
+						// Errors created in functions, and in
+						// their own variables.
+						ast.Inspect(decl, func(n ast.Node) bool {
+							if call, ok := n.(*ast.CallExpr); ok {
+								if text, ok := errorText(call, consts, errs); ok {
+									if cat := includedCategory(text, include); cat != "" {
+										add(pkg, call, text, cat)
+									}
+								}
+							}
+							return true
+						})
+						continue
+					}
+					// Package level error values.
+					for _, spec := range gd.Specs {
+						for _, v := range spec.(*ast.ValueSpec).Values {
+							call, ok := v.(*ast.CallExpr)
+							if !ok {
+								continue
+							}
+							text, ok := errorText(call, consts, errs)
+							if !ok {
+								continue
+							}
+							cat := includedCategory(text, include)
+							if cat == "" {
+								cat = category
+							}
+							if cat != "" {
+								add(pkg, call, text, cat)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	failures := make([]failure, 0, len(byText))
+	for _, f := range byText {
+		sort.Strings(f.Sources)
+		failures = append(failures, *f)
+	}
+	sort.Slice(failures, func(a, b int) bool {
+		if failures[a].Category != failures[b].Category {
+			return failures[a].Category < failures[b].Category
+		}
+		return strings.ToLower(failures[a].Text) < strings.ToLower(failures[b].Text)
+	})
+	return failures, nil
+}
+
+// categoryOf returns the category of the package in the directory, or of
+// the closest parent with one.
+func categoryOf(dir string) string {
+	for d := dir; d != "." && d != ""; d = parentDir(d) {
+		if c, ok := categories[d]; ok {
+			return c
+		}
+	}
+	return ""
+}
+
+func parentDir(dir string) string {
+	i := strings.LastIndex(dir, "/")
+	if i < 0 {
+		return ""
+	}
+	return dir[:i]
+}
+
+// includedCategory returns the category of the first included phrase in
+// the text, in the order of the phrases.
+func includedCategory(text string, include map[string]string) string {
+	phrases := make([]string, 0, len(include))
+	for p := range include {
+		phrases = append(phrases, p)
+	}
+	sort.Strings(phrases)
+	lower := strings.ToLower(text)
+	for _, p := range phrases {
+		if strings.Contains(lower, strings.ToLower(p)) {
+			return include[p]
+		}
+	}
+	return ""
+}
+
+// stringConsts returns the package level string constants and variables,
+// which error texts are built from.
+func stringConsts(pkg *stsource.Package) map[string]string {
+	consts := make(map[string]string)
+	for _, f := range pkg.Files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || (gd.Tok != token.CONST && gd.Tok != token.VAR) {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if len(vs.Names) != len(vs.Values) {
+					continue
+				}
+				for i, name := range vs.Names {
+					if s, ok := stsource.StringLit(vs.Values[i]); ok {
+						consts[name.Name] = s
+					}
+				}
+			}
+		}
+	}
+	return consts
+}
+
+// errorValues returns the texts of the package level errors created
+// without wrapping others, by name.
+func errorValues(pkg *stsource.Package, consts map[string]string) map[string]string {
+	errs := make(map[string]string)
+	for _, f := range pkg.Files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, v := range vs.Values {
+					call, ok := v.(*ast.CallExpr)
+					if !ok || i >= len(vs.Names) {
+						continue
+					}
+					if text, ok := errorText(call, consts, nil); ok && !strings.Contains(text, placeholder) {
+						errs[vs.Names[i].Name] = text
+					}
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// errorText returns the text of an errors.New or fmt.Errorf call, given
+// the package's constants and error values by name.
+func errorText(call *ast.CallExpr, consts, errs map[string]string) (string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || len(call.Args) == 0 {
+		return "", false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	switch pkg.Name + "." + sel.Sel.Name {
+	case "errors.New", "fmt.Errorf":
+	default:
+		return "", false
+	}
+	text, ok := stringExpr(call.Args[0], consts)
+	if !ok || strings.HasPrefix(text, "proto:") {
+		return "", false
+	}
+	if sel.Sel.Name == "Errorf" {
+		// Wrapped package errors are filled in, the other values
+		// replaced by placeholders.
+		i := 0
+		text = verbExp.ReplaceAllStringFunc(strings.ReplaceAll(text, "%%", "\x00"), func(verb string) string {
+			i++
+			if verb == "%w" && i < len(call.Args) {
+				if id, ok := call.Args[i].(*ast.Ident); ok {
+					if s, ok := errs[id.Name]; ok {
+						return s
+					}
+				}
+			}
+			return placeholder
+		})
+		text = strings.ReplaceAll(text, "\x00", "%")
+	}
+	text = strings.TrimSpace(text)
+	if strings.Trim(strings.ReplaceAll(text, placeholder, ""), " :") == "" {
+		// Only wrapping, such as fmt.Errorf("%s: %w", name, err).
+		return "", false
+	}
+	return text, true
+}
+
+// stringExpr evaluates a string built from literals and the package's
+// constants.
+func stringExpr(e ast.Expr, consts map[string]string) (string, bool) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		s, ok := consts[e.Name]
+		return s, ok
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		a, ok := stringExpr(e.X, consts)
+		if !ok {
+			return "", false
+		}
+		b, ok := stringExpr(e.Y, consts)
+		if !ok {
+			return "", false
+		}
+		return a + b, true
+	case *ast.ParenExpr:
+		return stringExpr(e.X, consts)
+	}
+	return stsource.StringLit(e)
+}
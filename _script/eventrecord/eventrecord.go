@@ -0,0 +1,228 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./eventrecord -syncthing path/to/syncthing [-out ../includes/events]
+//
+// Records example payloads for the event pages. Two Syncthing instances,
+// "local" and "peer", are started with the seed configuration and the
+// steps in scenario.json are run against them: connecting the devices,
+// sharing a folder, syncing files and so on. The event stream of the
+// local instance is recorded throughout, and the first event of each
+// type is saved as a JSON include file for the page of the event,
+// sanitised like the REST API recordings.
+//
+// Documented event types that the scenario didn't produce are listed on
+// stderr.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// waitTimeout is how long a step waits for an event by default.
+const waitTimeout = 60 * time.Second
+
+type scenario struct {
+	Steps []step `json:"steps"`
+}
+
+// step is one of a request, writing or removing a file, or waiting for
+// an event.
+type step struct {
+	// On is the instance to act on, "local" or "peer"; the default is
+	// "local".
+	On string `json:"on"`
+	// Path and Body may refer to {localID}, {peerID}, {localUser},
+	// {peerUser}, {localAddress} and {peerAddress}, the latter being
+	// the sync addresses.
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   any    `json:"body"`
+	// Write is a file to create with random content of the given size,
+	// relative to the user's home directory.
+	Write string `json:"write"`
+	Size  int    `json:"size"`
+	// Remove is a file to remove, relative to the user's home directory.
+	Remove string `json:"remove"`
+	// Wait is an event type the local instance must send after the
+	// previous action, within Timeout seconds.
+	Wait    string `json:"wait"`
+	Timeout int    `json:"timeout"`
+}
+
+func main() {
+	log.SetFlags(0)
+	binary := flag.String("syncthing", "syncthing", "Syncthing binary to run")
+	scenarioFile := flag.String("scenario", "eventrecord/scenario.json", "Steps to run")
+	config := flag.String("config", "eventrecord/seed-config.xml", "Seed configuration template")
+	out := flag.String("out", "../includes/events", "Directory to write the payloads to")
+	docs := flag.String("docs", "../events", "Directory of the event pages")
+	flag.Parse()
+
+	sc, err := readScenario(*scenarioFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	local, err := start(*binary, *config, "local")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	peer, err := start(*binary, *config, "peer")
+	if err != nil {
+		local.stop()
+		log.Fatalln(err)
+	}
+
+	rec := recordEvents(local, "rest/events", "rest/events/disk")
+	err = run(local, peer, rec, sc)
+	events := rec.close()
+	s := newSanitizer(local, peer)
+	peer.stop()
+	local.stop()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	recorded, err := write(events, s, *out)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := reportMissing(*docs, recorded); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func readScenario(path string) (*scenario, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sc scenario
+	if err := json.Unmarshal(bs, &sc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+func run(local, peer *instance, rec *recorder, sc *scenario) error {
+	expand := strings.NewReplacer(
+		"{localID}", local.myID,
+		"{peerID}", peer.myID,
+		"{localUser}", local.user,
+		"{peerUser}", peer.user,
+		"{localAddress}", local.syncAddr,
+		"{peerAddress}", peer.syncAddr,
+	)
+	// Events are looked for from before the last action, as they may
+	// arrive before it returns.
+	mark := 0
+	for i, st := range sc.Steps {
+		inst := local
+		switch st.On {
+		case "", "local":
+		case "peer":
+			inst = peer
+		default:
+			return fmt.Errorf("step %d: no such instance %q", i+1, st.On)
+		}
+
+		if st.Wait != "" {
+			timeout := waitTimeout
+			if st.Timeout > 0 {
+				timeout = time.Duration(st.Timeout) * time.Second
+			}
+			if err := rec.waitFor(st.Wait, mark, timeout); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+
+		mark = rec.count()
+		switch {
+		case st.Write != "":
+			if err := writeFile(filepath.Join(inst.user, filepath.FromSlash(st.Write)), st.Size); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+		case st.Remove != "":
+			if err := os.Remove(filepath.Join(inst.user, filepath.FromSlash(st.Remove))); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+		case st.Path != "":
+			var body []byte
+			if st.Body != nil {
+				bs, err := json.Marshal(st.Body)
+				if err != nil {
+					return err
+				}
+				body = []byte(expand.Replace(string(bs)))
+			}
+			path := strings.TrimPrefix(expand.Replace(st.Path), "/")
+			status, _, err := inst.request(st.Method, path, body)
+			if err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+			if status != http.StatusOK {
+				return fmt.Errorf("step %d: %s %s: %s", i+1, st.Method, st.Path, http.StatusText(status))
+			}
+		default:
+			return fmt.Errorf("step %d: nothing to do", i+1)
+		}
+	}
+	return nil
+}
+
+// write saves the first event of each type, returning the types saved.
+func write(events []event, s *sanitizer, out string) (map[string]bool, error) {
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return nil, err
+	}
+	recorded := make(map[string]bool)
+	for _, ev := range events {
+		if recorded[ev.Type] {
+			continue
+		}
+		recorded[ev.Type] = true
+		bs, err := json.MarshalIndent(s.event(ev), "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(out, strings.ToLower(ev.Type)+".json")
+		if err := os.WriteFile(path, append(bs, '\n'), 0o644); err != nil {
+			return nil, err
+		}
+		log.Println("recorded", ev.Type)
+	}
+	return recorded, nil
+}
+
+// reportMissing lists the event types with a page but no recording; the
+// pages are named for the type in lower case.
+func reportMissing(docs string, recorded map[string]bool) error {
+	have := make(map[string]bool)
+	for typ := range recorded {
+		have[strings.ToLower(typ)] = true
+	}
+	pages, err := filepath.Glob(filepath.Join(docs, "*.rst"))
+	if err != nil {
+		return err
+	}
+	for _, page := range pages {
+		name := strings.TrimSuffix(filepath.Base(page), ".rst")
+		if !have[name] {
+			log.Println("not recorded:", name)
+		}
+	}
+	return nil
+}
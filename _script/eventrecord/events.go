@@ -0,0 +1,111 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// pollTimeout is how long each poll of the event stream waits for new
+// events, in seconds, bounding how long stopping the recording takes.
+const pollTimeout = 1
+
+// event is an event as returned by the API, with the fields in the order
+// the docs show them.
+type event struct {
+	ID       int    `json:"id"`
+	GlobalID int    `json:"globalID"`
+	Type     string `json:"type"`
+	Time     string `json:"time"`
+	Data     any    `json:"data"`
+}
+
+// recorder records the events of an instance from the start, as events
+// are buffered until read.
+type recorder struct {
+	mut    sync.Mutex
+	events []event
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// recordEvents starts polling each of the event endpoints; the default
+// one leaves out the disk events, which have their own.
+func recordEvents(inst *instance, paths ...string) *recorder {
+	r := &recorder{stop: make(chan struct{})}
+	for _, path := range paths {
+		r.wg.Add(1)
+		go r.poll(inst, path)
+	}
+	return r
+}
+
+func (r *recorder) poll(inst *instance, path string) {
+	defer r.wg.Done()
+	since := 0
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		var evs []event
+		if err := inst.get(path+"?since="+strconv.Itoa(since)+"&timeout="+strconv.Itoa(pollTimeout), &evs); err != nil {
+			log.Printf("%s: %v", path, err)
+			time.Sleep(250 * time.Millisecond)
+			continue
+		}
+		r.mut.Lock()
+		for _, ev := range evs {
+			r.events = append(r.events, ev)
+			if ev.ID > since {
+				since = ev.ID
+			}
+		}
+		r.mut.Unlock()
+	}
+}
+
+// close stops the recording and returns the events, in the order they
+// were received.
+func (r *recorder) close() []event {
+	close(r.stop)
+	r.wg.Wait()
+	return r.events
+}
+
+// count returns the number of events so far, to wait for events after
+// that point.
+func (r *recorder) count() int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return len(r.events)
+}
+
+// waitFor waits for an event of the type among those recorded after the
+// first n.
+func (r *recorder) waitFor(typ string, n int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		r.mut.Lock()
+		for _, ev := range r.events[n:] {
+			if ev.Type == typ {
+				r.mut.Unlock()
+				return nil
+			}
+		}
+		r.mut.Unlock()
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for a %s event", typ)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
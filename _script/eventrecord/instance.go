@@ -0,0 +1,207 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+// startTimeout is how long to wait for Syncthing to start serving the
+// API.
+const startTimeout = 30 * time.Second
+
+type instance struct {
+	name     string // "local" or "peer", as in the scenario
+	cmd      *exec.Cmd
+	tmp      string
+	user     string // the user's home directory, containing the folders
+	home     string // Syncthing's
+	addr     string // of the GUI and API
+	syncAddr string // listening for other devices
+	url      string
+	apiKey   string
+	myID     string
+	done     chan struct{}
+}
+
+// start runs Syncthing with the seed configuration, in a temporary
+// directory standing in for the user's home directory.
+func start(binary, configTemplate, name string) (*instance, error) {
+	tpl, err := template.ParseFiles(configTemplate)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "eventrecord-"+name+"-")
+	if err != nil {
+		return nil, err
+	}
+	addr, err := freeAddress()
+	if err != nil {
+		return nil, err
+	}
+	syncAddr, err := freeAddress()
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	user := filepath.Join(tmp, "user")
+	inst := &instance{
+		name:     name,
+		tmp:      tmp,
+		user:     user,
+		home:     filepath.Join(user, ".local", "state", "syncthing"),
+		addr:     addr,
+		syncAddr: syncAddr,
+		url:      "http://" + addr + "/",
+		apiKey:   hex.EncodeToString(key),
+		done:     make(chan struct{}),
+	}
+	if err := os.MkdirAll(inst.home, 0o700); err != nil {
+		return nil, err
+	}
+
+	var cfg bytes.Buffer
+	err = tpl.Execute(&cfg, map[string]string{
+		"Address":     addr,
+		"SyncAddress": syncAddr,
+		"APIKey":      inst.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(inst.home, "config.xml"), cfg.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+
+	inst.cmd = exec.Command(binary, "serve", "--home="+inst.home, "--no-browser", "--no-restart", "--no-upgrade", "--logfile="+filepath.Join(inst.home, "syncthing.log"))
+	inst.cmd.Env = append(os.Environ(), "HOME="+user, "STNODEFAULTFOLDER=1", "STNOUPGRADE=1")
+	if err := inst.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		inst.cmd.Wait()
+		close(inst.done)
+	}()
+
+	if err := inst.waitReady(); err != nil {
+		inst.stop()
+		return nil, err
+	}
+	var status struct {
+		MyID string `json:"myID"`
+	}
+	if err := inst.get("rest/system/status", &status); err != nil {
+		inst.stop()
+		return nil, err
+	}
+	inst.myID = status.MyID
+	return inst, nil
+}
+
+// writeFile writes a file of the given size, with content that doesn't
+// compress or deduplicate to nothing.
+func writeFile(path string, size int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	bs := make([]byte, size)
+	if _, err := rand.Read(bs); err != nil {
+		return err
+	}
+	return os.WriteFile(path, bs, 0o644)
+}
+
+func (i *instance) waitReady() error {
+	deadline := time.Now().Add(startTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-i.done:
+			return fmt.Errorf("%s: syncthing exited, see %s", i.name, filepath.Join(i.home, "syncthing.log"))
+		case <-time.After(250 * time.Millisecond):
+		}
+		if i.get("rest/noauth/health", nil) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: timeout waiting for syncthing to start", i.name)
+}
+
+// stop shuts Syncthing down and removes the temporary directory.
+func (i *instance) stop() {
+	if i.cmd.Process != nil {
+		if _, _, err := i.request(http.MethodPost, "rest/system/shutdown", nil); err != nil {
+			i.cmd.Process.Kill()
+		}
+		select {
+		case <-i.done:
+		case <-time.After(10 * time.Second):
+			i.cmd.Process.Kill()
+			<-i.done
+		}
+	}
+	os.RemoveAll(i.tmp)
+}
+
+// get makes a GET request, decoding the response into res if given.
+func (i *instance) get(path string, res any) error {
+	status, bs, err := i.request(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, http.StatusText(status))
+	}
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(bs, res)
+}
+
+// request makes a REST API request, returning the status code and body
+// of the response.
+func (i *instance) request(method, path string, body []byte) (int, []byte, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, i.url+path, r)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("X-API-Key", i.apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, bs, err
+}
+
+func freeAddress() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return l.Addr().String(), nil
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The fixed values put in place of those that change from run to run,
+// for the local device and the peer.
+const (
+	exampleUser        = "/home/user"
+	examplePeerUser    = "/home/peer"
+	exampleID          = "MFZWI3D-BONSGYC-YLTMRWG-C43ENR5-QXGZDMM-FZWI3DP-BONSGYY-LTMRWAD"
+	examplePeerID      = "I6KAH76-66SLLLB-5PFXSOA-UFJCDZC-YAOMLEK-CP2GB32-BV5RQST-3PSROAU"
+	exampleAddress     = "127.0.0.1:8384"
+	exampleSyncAddress = "127.0.0.1:22000"
+	examplePeerAddress = "192.0.2.42:22000"
+	exampleTime        = "2024-01-01T12:00:00.000000000+01:00"
+)
+
+var timeExp = regexp.MustCompile(`\b\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:\.\d+)?(?:Z|[+-]\d\d:\d\d)`)
+
+type sanitizer struct {
+	replacer *strings.Replacer
+}
+
+func newSanitizer(local, peer *instance) *sanitizer {
+	// Longest first, the home directories being inside the users'.
+	pairs := [][2]string{
+		{local.user, exampleUser},
+		{peer.user, examplePeerUser},
+		{local.myID, exampleID},
+		{local.myID[:7], exampleID[:7]},
+		{peer.myID, examplePeerID},
+		{peer.myID[:7], examplePeerID[:7]},
+		{local.addr, exampleAddress},
+		{local.syncAddr, exampleSyncAddress},
+		{peer.syncAddr, examplePeerAddress},
+		{local.apiKey, "abc123"},
+	}
+	sort.Slice(pairs, func(a, b int) bool { return len(pairs[a][0]) > len(pairs[b][0]) })
+	var args []string
+	for _, p := range pairs {
+		args = append(args, p[0], p[1])
+	}
+	return &sanitizer{strings.NewReplacer(args...)}
+}
+
+func (s *sanitizer) event(ev event) event {
+	ev.Time = exampleTime
+	ev.Data = s.value(ev.Data)
+	return ev
+}
+
+// value returns a sanitised copy of a decoded JSON value, including the
+// keys of objects.
+func (s *sanitizer) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, e := range v {
+			res[s.string(k)] = s.value(e)
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, e := range v {
+			res[i] = s.value(e)
+		}
+		return res
+	case string:
+		return s.string(v)
+	}
+	return v
+}
+
+func (s *sanitizer) string(str string) string {
+	str = s.replacer.Replace(str)
+	return timeExp.ReplaceAllString(str, exampleTime)
+}
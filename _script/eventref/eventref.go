@@ -0,0 +1,180 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./eventref -tag v1.27.0 > ../includes/event-types.rst
+//
+// Reads the event types from the Syncthing events package, and their
+// data fields from the places the events are logged, and writes a table
+// of them. Given -since, a list of older versions, the version
+// introducing each event is included too. With -write-pages, pages with
+// an example event are written for the event types without one, to be
+// filled in; -check reports them and the pages for removed events.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	since := flag.String("since", "", "Comma separated older versions, oldest first, to find when events were added")
+	pages := flag.String("pages", "../events", "Directory of the event pages")
+	write := flag.Bool("write-pages", false, "Write pages for undocumented events")
+	check := flag.Bool("check", false, "Report undocumented events and pages for removed events")
+	flag.Parse()
+
+	// The older versions are checked out first, leaving a source
+	// directory at the requested version.
+	var history []map[string]bool
+	var historyTags []string
+	if *since != "" {
+		for _, t := range strings.Split(*since, ",") {
+			names, err := eventsAt(*src, t)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			history = append(history, names)
+			historyTags = append(historyTags, t)
+		}
+	}
+
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	types, err := loadEvents(root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, et := range types {
+		et.Since = firstSeen(et.Name, history, historyTags)
+	}
+
+	if *check {
+		problems := checkPages(types, *pages)
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *write {
+		for _, et := range types {
+			if pageExists(*pages, et) {
+				continue
+			}
+			if err := createPage(filepath.Join(*pages, pageName(et)+".rst"), et); err != nil {
+				log.Fatalln(err)
+			}
+			log.Println("wrote", pageName(et)+".rst")
+		}
+	}
+
+	if err := writeIndex(os.Stdout, types, *pages); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func loadEvents(root string) ([]*eventType, error) {
+	pkg, err := stsource.ParseDir(root, eventsDir)
+	if err != nil {
+		return nil, err
+	}
+	types := eventTypes(pkg)
+	if len(types) == 0 {
+		return nil, fmt.Errorf("%s: no event types found", eventsDir)
+	}
+	var pkgs []*stsource.Package
+	for _, dir := range eventDirs {
+		ps, err := stsource.ParseTree(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, ps...)
+	}
+	findPayloads(pkgs, types)
+	return types, nil
+}
+
+// eventsAt returns the names of the event types in a version.
+func eventsAt(dir, tag string) (map[string]bool, error) {
+	root, cleanup, err := stsource.Open(dir, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	pkg, err := stsource.ParseDir(root, eventsDir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	names := make(map[string]bool)
+	for _, et := range eventTypes(pkg) {
+		names[et.Name] = true
+	}
+	return names, nil
+}
+
+// firstSeen returns the first version with the event, or the empty
+// string if it's in the oldest one (or there's no history).
+func firstSeen(name string, history []map[string]bool, tags []string) string {
+	for i, names := range history {
+		if names[name] {
+			if i == 0 {
+				return ""
+			}
+			return tags[i]
+		}
+	}
+	if len(tags) > 0 {
+		return "after " + tags[len(tags)-1]
+	}
+	return ""
+}
+
+func checkPages(types []*eventType, dir string) []string {
+	var problems []string
+	known := make(map[string]bool)
+	for _, et := range types {
+		known[pageName(et)] = true
+		if !pageExists(dir, et) {
+			problems = append(problems, fmt.Sprintf("undocumented: %s (%s)", et.Name, strings.Join(et.Sources, ", ")))
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.rst"))
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".rst")
+		if !known[name] {
+			problems = append(problems, "no longer exists: "+name+".rst")
+		}
+	}
+	return problems
+}
+
+func createPage(path string, et *eventType) error {
+	fd, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := writePage(fd, et); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
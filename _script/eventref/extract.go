@@ -0,0 +1,426 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// eventsDir is the package declaring the event types; eventDirs are
+// searched for the places events are logged.
+const eventsDir = "lib/events"
+
+var eventDirs = []string{"lib", "cmd"}
+
+type eventType struct {
+	Name       string // as sent in the event, "FolderSummary"
+	Const      string // the Go constant
+	Deprecated string // the deprecation note, if any
+	Payload    []payloadField
+	// StringData is set when the data is a string rather than an object.
+	StringData bool
+	Sources    []string
+	Since      string
+}
+
+type payloadField struct {
+	Name string
+	Type string // string, number, boolean, array, object or empty if unknown
+}
+
+// eventTypes returns the event types declared in the events package, in
+// declaration order.
+func eventTypes(pkg *stsource.Package) []*eventType {
+	names := stringCases(pkg, "EventType")
+	var types []*eventType
+	for _, f := range pkg.SortedFiles() {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST {
+				continue
+			}
+			inBlock := false
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if id, ok := vs.Type.(*ast.Ident); ok {
+					inBlock = id.Name == "EventType"
+				}
+				if !inBlock {
+					continue
+				}
+				for _, name := range vs.Names {
+					wire, ok := names[name.Name]
+					if !ok {
+						// AllEvents and other masks have no name.
+						continue
+					}
+					et := &eventType{Name: wire, Const: name.Name}
+					if c := stsource.DocText(vs.Comment); strings.Contains(c, "DEPRECATED") {
+						et.Deprecated = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(c, "DEPRECATED"), ","))
+					}
+					types = append(types, et)
+				}
+			}
+		}
+	}
+	return types
+}
+
+// stringCases maps the constants of a type to the names its String
+// method returns for them.
+func stringCases(pkg *stsource.Package, typ string) map[string]string {
+	names := make(map[string]string)
+	for _, f := range pkg.SortedFiles() {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "String" || fn.Body == nil {
+				continue
+			}
+			if recv, ok := fn.Recv.List[0].Type.(*ast.Ident); !ok || recv.Name != typ {
+				continue
+			}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				cc, ok := n.(*ast.CaseClause)
+				if !ok {
+					return true
+				}
+				for _, stmt := range cc.Body {
+					ret, ok := stmt.(*ast.ReturnStmt)
+					if !ok || len(ret.Results) != 1 {
+						continue
+					}
+					if v, ok := stsource.StringLit(ret.Results[0]); ok {
+						for _, e := range cc.List {
+							if id, ok := e.(*ast.Ident); ok {
+								names[id.Name] = v
+							}
+						}
+					}
+				}
+				return false
+			})
+		}
+	}
+	return names
+}
+
+// findPayloads adds the payload fields of the events logged in the
+// packages, from the calls
+//
+//	evLogger.Log(events.FolderSummary, data)
+func findPayloads(pkgs []*stsource.Package, types []*eventType) {
+	byConst := make(map[string]*eventType)
+	for _, et := range types {
+		byConst[et.Const] = et
+	}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.SortedFiles() {
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil {
+					continue
+				}
+				ast.Inspect(fn.Body, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok || len(call.Args) != 2 {
+						return true
+					}
+					sel, ok := call.Fun.(*ast.SelectorExpr)
+					if !ok || sel.Sel.Name != "Log" {
+						return true
+					}
+					consts := eventConsts(fn, call.Args[0])
+					if len(consts) == 0 {
+						return true
+					}
+					fields, isString := payload(pkg, fn, call.Args[1])
+					file, line := pkg.Position(call)
+					for _, c := range consts {
+						et, ok := byConst[c]
+						if !ok {
+							continue
+						}
+						et.Sources = append(et.Sources, file+":"+strconv.Itoa(line))
+						et.StringData = et.StringData || isString
+						et.Payload = mergeFields(et.Payload, fields)
+					}
+					return true
+				})
+			}
+		}
+	}
+}
+
+// eventConsts resolves the event type argument to constant names, also
+// through a local variable assigned one of several types.
+func eventConsts(fn *ast.FuncDecl, e ast.Expr) []string {
+	switch e := e.(type) {
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok && x.Name == "events" {
+			return []string{e.Sel.Name}
+		}
+	case *ast.Ident:
+		var consts []string
+		for _, v := range assignments(fn, e.Name) {
+			if sel, ok := v.(*ast.SelectorExpr); ok {
+				if x, ok := sel.X.(*ast.Ident); ok && x.Name == "events" {
+					consts = append(consts, sel.Sel.Name)
+				}
+			}
+		}
+		return consts
+	}
+	return nil
+}
+
+// assignments returns the values assigned to a local variable in the
+// function.
+func assignments(fn *ast.FuncDecl, name string) []ast.Expr {
+	var values []ast.Expr
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && id.Name == name {
+					values = append(values, n.Rhs[i])
+				}
+			}
+		case *ast.ValueSpec:
+			for i, id := range n.Names {
+				if id.Name == name && i < len(n.Values) {
+					values = append(values, n.Values[i])
+				}
+			}
+		}
+		return true
+	})
+	return values
+}
+
+// payload returns the fields of the event data expression, or true if
+// it's a string.
+func payload(pkg *stsource.Package, fn *ast.FuncDecl, e ast.Expr) ([]payloadField, bool) {
+	switch e := e.(type) {
+	case *ast.UnaryExpr:
+		return payload(pkg, fn, e.X)
+	case *ast.CompositeLit:
+		if _, ok := e.Type.(*ast.MapType); ok {
+			return mapFields(e), false
+		}
+		if id, ok := e.Type.(*ast.Ident); ok {
+			return structFields(pkg, id.Name), false
+		}
+	case *ast.BasicLit:
+		return nil, e.Kind == token.STRING
+	case *ast.CallExpr:
+		return nil, stringCall(e)
+	case *ast.Ident:
+		var fields []payloadField
+		isString := false
+		for _, v := range assignments(fn, e.Name) {
+			if id, ok := v.(*ast.Ident); ok && id.Name == e.Name {
+				continue
+			}
+			fs, s := payload(pkg, fn, v)
+			fields = mergeFields(fields, fs)
+			isString = isString || s
+		}
+		// Keys added after the map literal: data["key"] = value
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			as, ok := n.(*ast.AssignStmt)
+			if !ok || len(as.Lhs) != 1 || len(as.Rhs) != 1 {
+				return true
+			}
+			ix, ok := as.Lhs[0].(*ast.IndexExpr)
+			if !ok {
+				return true
+			}
+			if id, ok := ix.X.(*ast.Ident); ok && id.Name == e.Name {
+				if key, ok := stsource.StringLit(ix.Index); ok {
+					fields = mergeFields(fields, []payloadField{{Name: key, Type: exprType(as.Rhs[0])}})
+				}
+			}
+			return true
+		})
+		return fields, isString
+	}
+	return nil, false
+}
+
+func mapFields(lit *ast.CompositeLit) []payloadField {
+	valueType := ""
+	if mt, ok := lit.Type.(*ast.MapType); ok {
+		valueType = goType(mt.Value)
+	}
+	var fields []payloadField
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := stsource.StringLit(kv.Key)
+		if !ok {
+			continue
+		}
+		typ := valueType
+		if typ == "" || typ == "object" {
+			typ = exprType(kv.Value)
+		}
+		fields = append(fields, payloadField{Name: key, Type: typ})
+	}
+	return fields
+}
+
+// structFields returns the JSON fields of a struct declared in the
+// package.
+func structFields(pkg *stsource.Package, name string) []payloadField {
+	for _, f := range pkg.SortedFiles() {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || ts.Name.Name != name {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					return nil
+				}
+				var fields []payloadField
+				for _, fl := range st.Fields.List {
+					if len(fl.Names) == 0 || !fl.Names[0].IsExported() {
+						continue
+					}
+					jsonName := fl.Names[0].Name
+					if fl.Tag != nil {
+						if tag, err := strconv.Unquote(fl.Tag.Value); err == nil {
+							if n, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ","); n == "-" {
+								continue
+							} else if n != "" {
+								jsonName = n
+							}
+						}
+					}
+					fields = append(fields, payloadField{Name: jsonName, Type: goType(fl.Type)})
+				}
+				return fields
+			}
+		}
+	}
+	return nil
+}
+
+func goType(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "string":
+			return "string"
+		case "bool":
+			return "boolean"
+		case "int", "int32", "int64", "uint32", "uint64", "float64", "float32":
+			return "number"
+		case "interface", "any":
+			return ""
+		}
+		return "object"
+	case *ast.SelectorExpr:
+		switch e.Sel.Name {
+		case "Time":
+			return "string"
+		case "DeviceID", "ShortID":
+			return "string"
+		}
+		return "object"
+	case *ast.StarExpr:
+		return goType(e.X)
+	case *ast.ArrayType:
+		return "array"
+	case *ast.MapType:
+		return "object"
+	case *ast.InterfaceType:
+		return ""
+	}
+	return ""
+}
+
+// exprType guesses the JSON type of a value from its expression.
+func exprType(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			return "string"
+		}
+		return "number"
+	case *ast.Ident:
+		if e.Name == "true" || e.Name == "false" {
+			return "boolean"
+		}
+	case *ast.CallExpr:
+		if stringCall(e) {
+			return "string"
+		}
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && (sel.Sel.Name == "Seconds" || sel.Sel.Name == "Len") {
+			return "number"
+		}
+		if id, ok := e.Fun.(*ast.Ident); ok && id.Name == "len" {
+			return "number"
+		}
+	case *ast.CompositeLit:
+		if _, ok := e.Type.(*ast.ArrayType); ok {
+			return "array"
+		}
+		return "object"
+	case *ast.BinaryExpr:
+		return exprType(e.X)
+	}
+	return ""
+}
+
+// stringCall recognises calls known to return strings.
+func stringCall(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	switch sel.Sel.Name {
+	case "String", "Error", "Sprintf", "Sprint", "GoString":
+		return true
+	}
+	return false
+}
+
+func mergeFields(a, b []payloadField) []payloadField {
+	for _, f := range b {
+		found := false
+		for i := range a {
+			if a[i].Name == f.Name {
+				if a[i].Type == "" {
+					a[i].Type = f.Type
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			a = append(a, f)
+		}
+	}
+	return a
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+func pageName(et *eventType) string {
+	return strings.ToLower(et.Name)
+}
+
+func pageExists(dir string, et *eventType) bool {
+	_, err := os.Stat(filepath.Join(dir, pageName(et)+".rst"))
+	return err == nil
+}
+
+// writeIndex writes the table of event types.
+func writeIndex(w io.Writer, types []*eventType, pagesDir string) error {
+	t := rst.Table{
+		Header: []string{"Event", "Data", "Since"},
+		Widths: []int{30, 55, 15},
+	}
+	for _, et := range types {
+		name := rst.Literal(et.Name)
+		if pageExists(pagesDir, et) {
+			name = fmt.Sprintf(":doc:`%s </events/%s>`", et.Name, pageName(et))
+		}
+		if et.Deprecated != "" {
+			name += " (deprecated)"
+		}
+		var data string
+		switch {
+		case et.StringData:
+			data = "string"
+		case len(et.Payload) > 0:
+			names := make([]string, len(et.Payload))
+			for i, f := range et.Payload {
+				names[i] = rst.Literal(f.Name)
+			}
+			data = strings.Join(names, ", ")
+		}
+		t.Rows = append(t.Rows, []string{name, data, strings.TrimPrefix(et.Since, "v")})
+	}
+	_, err := t.WriteTo(w)
+	return err
+}
+
+// writePage writes a page for an event type in the layout of the existing
+// ones, with an example built from the payload fields to be replaced by
+// a real event.
+func writePage(w io.Writer, et *eventType) error {
+	var sb strings.Builder
+	sb.WriteString(rst.Heading(et.Name, '-'))
+	if et.Since != "" {
+		fmt.Fprintf(&sb, ".. versionadded:: %s\n\n", strings.TrimPrefix(et.Since, "v"))
+	}
+	if et.Deprecated != "" {
+		fmt.Fprintf(&sb, ".. deprecated:: %s\n\n", et.Deprecated)
+	}
+	sb.WriteString(".. Describe when the event is generated and what the fields mean.\n\n")
+	sb.WriteString(".. code-block:: json\n\n")
+	for _, line := range strings.Split(exampleEvent(et), "\n") {
+		sb.WriteString("    " + line + "\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func exampleEvent(et *eventType) string {
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	sb.WriteString("  \"id\": 1,\n")
+	sb.WriteString("  \"globalID\": 1,\n")
+	fmt.Fprintf(&sb, "  \"type\": %q,\n", et.Name)
+	sb.WriteString("  \"time\": \"2024-01-01T12:00:00.000000000+01:00\",\n")
+	switch {
+	case et.StringData:
+		sb.WriteString("  \"data\": \"...\"\n")
+	case len(et.Payload) == 0:
+		sb.WriteString("  \"data\": null\n")
+	default:
+		sb.WriteString("  \"data\": {\n")
+		for i, f := range et.Payload {
+			comma := ","
+			if i == len(et.Payload)-1 {
+				comma = ""
+			}
+			fmt.Fprintf(&sb, "    %q: %s%s\n", f.Name, exampleValue(f.Type), comma)
+		}
+		sb.WriteString("  }\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func exampleValue(typ string) string {
+	switch typ {
+	case "string":
+		return `"..."`
+	case "number":
+		return "0"
+	case "boolean":
+		return "false"
+	case "array":
+		return "[]"
+	case "object":
+		return "{}"
+	}
+	return "null"
+}
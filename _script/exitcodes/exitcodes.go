@@ -0,0 +1,209 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./exitcodes -tag v1.27.0 -since v1.0.0,v1.10.0 > ../includes/exit-codes.rst
+//
+// Reads the exit status constants from the Syncthing source and writes
+// the table of exit codes for the command line page. Codes passed to
+// os.Exit as a plain number in the main package without a constant for
+// them are included too and flagged, as they are easily changed by
+// accident.
+//
+// Given -since, a list of older versions, codes that were added, renamed
+// or removed since are noted with the version of the change.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/stsource"
+)
+
+// meanings are the descriptions of the codes, by constant name, where the
+// name alone doesn't say enough.
+var meanings = map[string]string{
+	"Success":            "Success, or shut down",
+	"NoUpgradeAvailable": "Upgrade not available",
+	"Restart":            "Restarting",
+	"Restarting":         "Restarting",
+	"Upgrade":            "Upgrading",
+	"Upgrading":          "Upgrading",
+}
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	since := flag.String("since", "", "Comma separated older versions, oldest first, to find changes to the codes")
+	flag.Parse()
+
+	// The older versions are checked out first, leaving a source
+	// directory at the requested version.
+	var history []map[int]string
+	var historyTags []string
+	if *since != "" {
+		for _, t := range strings.Split(*since, ",") {
+			codes, err := codesAt(*src, t)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			history = append(history, codes)
+			historyTags = append(historyTags, t)
+		}
+	}
+
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+	codes, err := exitCodes(root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(codes) == 0 {
+		log.Fatalln("no exit codes found")
+	}
+	literals, err := literalExits(root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	current := make(map[int]string)
+	for _, c := range codes {
+		current[c.Code] = c.Name
+	}
+	history = append(history, current)
+	historyTags = append(historyTags, *tag)
+
+	if err := writeCodes(os.Stdout, codes, literals, history, historyTags); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// codesAt returns the exit code names in a version, by code.
+func codesAt(dir, tag string) (map[int]string, error) {
+	root, cleanup, err := stsource.Open(dir, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	codes, err := exitCodes(root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	res := make(map[int]string)
+	for _, c := range codes {
+		res[c.Code] = c.Name
+	}
+	return res, nil
+}
+
+func writeCodes(w io.Writer, codes []exitCode, literals map[int][]string, history []map[int]string, tags []string) error {
+	changed := changes(history, tags)
+	t := rst.Table{
+		Title:  "Exit Codes",
+		Header: []string{"Code", "Meaning", "Changes"},
+		Widths: []int{10, 40, 50},
+	}
+	known := make(map[int]bool)
+	for _, c := range codes {
+		known[c.Code] = true
+		t.Rows = append(t.Rows, []string{fmt.Sprint(c.Code), meaning(c), strings.Join(changed[c.Code], " ")})
+	}
+
+	// Codes that are only used as numbers, or that are gone.
+	var others []int
+	for code := range literals {
+		if !known[code] {
+			others = append(others, code)
+		}
+	}
+	for code := range changed {
+		if !known[code] && literals[code] == nil {
+			others = append(others, code)
+		}
+	}
+	sort.Ints(others)
+	for _, code := range others {
+		var meaning string
+		if pos := literals[code]; pos != nil {
+			meaning = fmt.Sprintf("Used without a constant, at %s.", strings.Join(pos, ", "))
+			log.Printf("exit code %d used without a constant, at %s", code, strings.Join(pos, ", "))
+		}
+		t.Rows = append(t.Rows, []string{fmt.Sprint(code), meaning, strings.Join(changed[code], " ")})
+	}
+
+	if len(tags) < 2 {
+		// No history to compare with.
+		t.Header, t.Widths = t.Header[:2], []int{10, 90}
+		for i := range t.Rows {
+			t.Rows[i] = t.Rows[i][:2]
+		}
+	}
+
+	if _, err := fmt.Fprint(w, ".. This file is generated by _script/exitcodes; do not edit.\n\n"); err != nil {
+		return err
+	}
+	_, err := t.WriteTo(w)
+	return err
+}
+
+// changes returns what changed about each code between the versions,
+// oldest first, as sentences.
+func changes(history []map[int]string, tags []string) map[int][]string {
+	res := make(map[int][]string)
+	for i := 1; i < len(history); i++ {
+		prev, cur := history[i-1], history[i]
+		codes := make(map[int]bool)
+		for c := range prev {
+			codes[c] = true
+		}
+		for c := range cur {
+			codes[c] = true
+		}
+		for c := range codes {
+			was, is := prev[c], cur[c]
+			switch {
+			case was == is:
+			case was == "":
+				res[c] = append(res[c], fmt.Sprintf("Added in %s.", tags[i]))
+			case is == "":
+				res[c] = append(res[c], fmt.Sprintf("%s, removed in %s.", rst.Literal(was), tags[i]))
+			case meaning(exitCode{Name: was}) != meaning(exitCode{Name: is}):
+				// Renames such as exitRestarting to ExitRestart, with
+				// the same meaning, aren't changes to users.
+				res[c] = append(res[c], fmt.Sprintf("Was %s before %s.", rst.Literal(was), tags[i]))
+			}
+		}
+	}
+	return res
+}
+
+var wordExp = regexp.MustCompile(`[A-Z][a-z0-9]*`)
+
+// meaning returns the description of the code, from the known meanings,
+// the comment or the words of the name.
+func meaning(c exitCode) string {
+	if m, ok := meanings[c.Name]; ok {
+		return m
+	}
+	if c.Comment != "" {
+		return rst.Escape(c.Comment)
+	}
+	words := wordExp.FindAllString(c.Name, -1)
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToLower(words[i])
+	}
+	return strings.Join(words, " ")
+}
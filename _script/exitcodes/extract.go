@@ -0,0 +1,116 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// exitCode is an exit status constant.
+type exitCode struct {
+	Code     int
+	Name     string // without the exit prefix: NoUpgradeAvailable
+	Comment  string
+	Position string
+}
+
+// sourceDirs are where the exit codes have been defined over time; they
+// moved from the main package to lib/svcutil.
+var sourceDirs = []string{"lib/svcutil", "cmd/syncthing"}
+
+// exitNameExp matches the constant names, ExitError in lib/svcutil and
+// exitError in older versions of the main package.
+var exitNameExp = regexp.MustCompile(`^[Ee]xit([A-Z]\w*)$`)
+
+// exitCodes returns the exit code constants in the source, by code.
+func exitCodes(root string) ([]exitCode, error) {
+	var codes []exitCode
+	for _, dir := range sourceDirs {
+		pkg, err := stsource.ParseDir(root, dir)
+		if err != nil {
+			// Not every version has both.
+			continue
+		}
+		for _, f := range pkg.SortedFiles() {
+			for _, decl := range f.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.CONST {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					vs := spec.(*ast.ValueSpec)
+					for i, name := range vs.Names {
+						m := exitNameExp.FindStringSubmatch(name.Name)
+						if m == nil || i >= len(vs.Values) {
+							continue
+						}
+						lit, ok := vs.Values[i].(*ast.BasicLit)
+						if !ok || lit.Kind != token.INT {
+							continue
+						}
+						code, err := strconv.Atoi(lit.Value)
+						if err != nil {
+							continue
+						}
+						comment := stsource.DocText(vs.Doc)
+						if comment == "" {
+							comment = stsource.DocText(vs.Comment)
+						}
+						file, line := pkg.Position(vs)
+						codes = append(codes, exitCode{code, m[1], comment, file + ":" + strconv.Itoa(line)})
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(codes, func(a, b int) bool { return codes[a].Code < codes[b].Code })
+	return codes, nil
+}
+
+// literalExits returns the positions of os.Exit calls with an integer
+// literal in the main package, by code: exit codes used without a
+// constant.
+func literalExits(root string) (map[int][]string, error) {
+	pkg, err := stsource.ParseDir(root, "cmd/syncthing")
+	if err != nil {
+		return nil, err
+	}
+	exits := make(map[int][]string)
+	for _, f := range pkg.SortedFiles() {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "Exit" {
+				return true
+			}
+			if x, ok := sel.X.(*ast.Ident); !ok || x.Name != "os" {
+				return true
+			}
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.INT {
+				return true
+			}
+			code, err := strconv.Atoi(lit.Value)
+			if err != nil {
+				return true
+			}
+			file, line := pkg.Position(call)
+			exits[code] = append(exits[code], file+":"+strconv.Itoa(line))
+			return true
+		})
+	}
+	return exits, nil
+}
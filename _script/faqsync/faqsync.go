@@ -0,0 +1,218 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./faqsync [-update]
+//
+// Imports the forum topics listed in topics.json as FAQ entries. Each
+// topic's canonical answer, the post given in the list or else the
+// accepted answer or the first post, is converted to reStructuredText
+// and written with the topic title, a label and a link to the post to
+// includes/faq-forum/<label>.rst, which is included at the end of the
+// FAQ part the list gives. Entries no longer listed are removed.
+//
+// Topics are imported once, and the revision of the post is recorded in
+// the list. Posts edited on the forum since are listed on stderr, for
+// checking whether the entry needs the change too; with -update, they're
+// imported again. Markup that doesn't convert, such as images and
+// tables, is listed too, and the entry should be reviewed by hand.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"syncthing.net/docs/internal/discourse"
+)
+
+// entry is a topic imported as a FAQ entry.
+type entry struct {
+	Topic int `json:"topic"`
+	// Post is the number of the post in the topic with the answer, or
+	// zero for the accepted answer or else the first post.
+	Post int `json:"post,omitempty"`
+	// Part is the FAQ part the entry goes in, as the name of its file
+	// in users/faq-parts.
+	Part  string `json:"part"`
+	Label string `json:"label"`
+	// Title overrides the topic title, for when it isn't phrased as the
+	// question.
+	Title string `json:"title,omitempty"`
+	// Version is the revision of the post that was imported, and
+	// Imported the date it was, both empty until it has been.
+	Version  int    `json:"version,omitempty"`
+	Imported string `json:"imported,omitempty"`
+}
+
+type topicList struct {
+	Forum  string   `json:"forum"`
+	Topics []*entry `json:"topics"`
+}
+
+const (
+	partsDir   = "users/faq-parts"
+	entriesDir = "includes/faq-forum"
+	generated  = ".. This file is generated by _script/faqsync; do not edit.\n\n"
+)
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	topicsFile := flag.String("topics", "faqsync/topics.json", "List of topics to import")
+	update := flag.Bool("update", false, "Import again the posts edited since they were imported")
+	flag.Parse()
+
+	list, err := readTopics(*topicsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := os.MkdirAll(filepath.Join(*root, entriesDir), 0o755); err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx := context.Background()
+	f := discourse.NewClient(list.Forum)
+	today := time.Now().Format(time.DateOnly)
+	failed := false
+	for _, e := range list.Topics {
+		t, p, err := f.Answer(ctx, e.Topic, e.Post)
+		if err != nil {
+			log.Printf("%s: %v", e.Label, err)
+			failed = true
+			continue
+		}
+
+		file := filepath.Join(*root, entriesDir, e.Label+".rst")
+		_, err = os.Stat(file)
+		missing := os.IsNotExist(err)
+		edited := e.Version != 0 && p.Version > e.Version
+		if !missing && e.Version != 0 && !(edited && *update) {
+			if edited {
+				log.Printf("%s: edited since imported on %s (revision %d, now %d): %s", e.Label, e.Imported, e.Version, p.Version, f.PostURL(p))
+			}
+			continue
+		}
+
+		text, warnings := render(f, e, t, p)
+		for _, w := range warnings {
+			log.Printf("%s: %s", e.Label, w)
+		}
+		if err := os.WriteFile(file, []byte(generated+text), 0o644); err != nil {
+			log.Fatalln(err)
+		}
+		e.Version, e.Imported = p.Version, today
+		if err := includeEntry(*root, e); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if err := removeUnlisted(*root, list.Topics); err != nil {
+		log.Fatalln(err)
+	}
+	if err := writeTopics(*topicsFile, list); err != nil {
+		log.Fatalln(err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func readTopics(name string) (*topicList, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var list topicList
+	if err := json.Unmarshal(bs, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	labels := make(map[string]bool)
+	for _, e := range list.Topics {
+		switch {
+		case e.Label == "" || e.Part == "":
+			return nil, fmt.Errorf("%s: topic %d: label and part are required", name, e.Topic)
+		case labels[e.Label]:
+			return nil, fmt.Errorf("%s: topic %d: duplicate label %q", name, e.Topic, e.Label)
+		}
+		labels[e.Label] = true
+	}
+	return &list, nil
+}
+
+func writeTopics(name string, list *topicList) error {
+	bs, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(bs, '\n'), 0o644)
+}
+
+// render returns the FAQ entry for the post, and the warnings from
+// converting it.
+func render(f *discourse.Client, e *entry, t *discourse.Topic, p *discourse.Post) (string, []string) {
+	title := e.Title
+	if title == "" {
+		title = t.Title
+	}
+	c := &converter{base: f.URL}
+	body := c.convert(p.Raw)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, ".. _%s:\n\n", e.Label)
+	fmt.Fprintf(&sb, "%s\n%s\n\n", title, strings.Repeat("-", utf8.RuneCountInString(title)))
+	fmt.Fprintf(&sb, "%s\n\n", body)
+	author := strings.NewReplacer("`", "", "<", "", ">", "").Replace(p.Author())
+	fmt.Fprintf(&sb, "*Adapted from* `an answer by %s <%s>`__ *on the forum.*\n", author, f.PostURL(p))
+	return sb.String(), c.warnings
+}
+
+// includeEntry adds the include of the entry to the end of its FAQ part,
+// unless it's already included.
+func includeEntry(root string, e *entry) error {
+	file := filepath.Join(root, filepath.FromSlash(partsDir), e.Part+".rst")
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	inc := ".. include:: /" + entriesDir + "/" + e.Label + ".rst"
+	if strings.Contains(string(bs), inc) {
+		return nil
+	}
+	text := strings.TrimRight(string(bs), "\n") + "\n\n" + inc + "\n"
+	return os.WriteFile(file, []byte(text), 0o644)
+}
+
+// removeUnlisted removes the entries no longer in the list; their
+// includes are left for removing by hand, and listed.
+func removeUnlisted(root string, entries []*entry) error {
+	listed := make(map[string]bool)
+	for _, e := range entries {
+		listed[e.Label+".rst"] = true
+	}
+	files, err := filepath.Glob(filepath.Join(root, entriesDir, "*.rst"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if listed[filepath.Base(file)] {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+		log.Printf("removed %s; remove its include from %s", filepath.Base(file), partsDir)
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// converter converts the Markdown of a forum post to reStructuredText.
+// Only what's common in support answers is handled: paragraphs, lists,
+// quotes, headings, code and the inline markup. Anything else, such as
+// tables, images and HTML, is kept as a literal block or left out, with
+// a warning to review the entry by hand.
+type converter struct {
+	// base is the forum URL, for links relative to it.
+	base     string
+	warnings []string
+}
+
+var (
+	fenceExp   = regexp.MustCompile("^\\s{0,3}(```+|~~~+)\\s*([\\w+-]*)")
+	headingExp = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*$`)
+	ruleExp    = regexp.MustCompile(`^\s{0,3}(-(\s*-){2,}|\*(\s*\*){2,}|_(\s*_){2,})\s*$`)
+	itemExp    = regexp.MustCompile(`^(\s{0,3})([-*+]|\d{1,9}[.)])(\s+|$)`)
+	quoteExp   = regexp.MustCompile(`^\s{0,3}>\s?`)
+	// Discourse's BBCode style quotes and collapsed sections.
+	bbcodeExp = regexp.MustCompile(`(?m)^\s*\[(quote|details)(=[^\]]*)?\]\s*$|^\s*\[/(quote|details)\]\s*$`)
+)
+
+// convert returns the reStructuredText for the Markdown source.
+func (c *converter) convert(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = c.bbcode(src)
+	return strings.Join(c.blocks(strings.Split(src, "\n")), "\n\n")
+}
+
+// bbcode turns [quote] sections into Markdown quotes, and drops the
+// [details] markers, keeping the content.
+func (c *converter) bbcode(src string) string {
+	lines := strings.Split(src, "\n")
+	var res []string
+	quote := 0
+	for _, l := range lines {
+		m := bbcodeExp.FindStringSubmatch(l)
+		switch {
+		case m == nil:
+			if quote > 0 {
+				l = strings.Repeat("> ", quote) + l
+			}
+			res = append(res, l)
+		case m[1] == "quote":
+			quote++
+		case m[3] == "quote" && quote > 0:
+			quote--
+		}
+	}
+	return strings.Join(res, "\n")
+}
+
+// blocks converts the lines to blocks of reStructuredText.
+func (c *converter) blocks(lines []string) []string {
+	var blocks []string
+	for i := 0; i < len(lines); {
+		l := lines[i]
+		switch {
+		case strings.TrimSpace(l) == "":
+			i++
+
+		case fenceExp.MatchString(l):
+			m := fenceExp.FindStringSubmatch(l)
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				code = append(code, lines[i])
+			}
+			i++
+			blocks = append(blocks, literal(m[2], code))
+
+		case headingExp.MatchString(l):
+			blocks = append(blocks, ".. rubric:: "+c.inline(headingExp.FindStringSubmatch(l)[1]))
+			i++
+
+		case ruleExp.MatchString(l):
+			i++
+
+		case quoteExp.MatchString(l):
+			var quoted []string
+			for ; i < len(lines) && quoteExp.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quoteExp.ReplaceAllString(lines[i], ""))
+			}
+			blocks = append(blocks, indent(strings.Join(c.blocks(quoted), "\n\n"), "    "))
+
+		case itemExp.MatchString(l):
+			var items []string
+			i, items = c.list(lines, i)
+			blocks = append(blocks, strings.Join(items, "\n"))
+
+		case strings.HasPrefix(strings.TrimSpace(l), "|"):
+			var table []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				table = append(table, lines[i])
+			}
+			c.warn("table kept as a literal block")
+			blocks = append(blocks, literal("", table))
+
+		case strings.HasPrefix(l, "    ") || strings.HasPrefix(l, "\t"):
+			var code []string
+			for ; i < len(lines) && (strings.TrimSpace(lines[i]) == "" || strings.HasPrefix(lines[i], "    ") || strings.HasPrefix(lines[i], "\t")); i++ {
+				code = append(code, strings.TrimPrefix(strings.TrimPrefix(lines[i], "    "), "\t"))
+			}
+			blocks = append(blocks, literal("", code))
+
+		case strings.HasPrefix(strings.TrimSpace(l), "<"):
+			c.warn("HTML left out: %s", strings.TrimSpace(l))
+			i++
+
+		default:
+			var para []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && (len(para) == 0 || !interrupts(lines[i])); i++ {
+				para = append(para, strings.TrimSpace(lines[i]))
+			}
+			// Nothing is left of a paragraph of only an image.
+			if text := c.inline(strings.Join(para, "\n")); text != "" {
+				blocks = append(blocks, text)
+			}
+		}
+	}
+	return blocks
+}
+
+// interrupts reports whether the line starts a new block without a blank
+// line before it.
+func interrupts(l string) bool {
+	return fenceExp.MatchString(l) || headingExp.MatchString(l) || quoteExp.MatchString(l) || itemExp.MatchString(l)
+}
+
+// list converts the list starting at line i, returning the line after it
+// and the converted items. Lines indented past the marker, and lazy
+// continuations of a paragraph, belong to the item.
+func (c *converter) list(lines []string, i int) (int, []string) {
+	var items []string
+	kind := ""
+	for i < len(lines) {
+		m := itemExp.FindStringSubmatch(lines[i])
+		if m == nil || kind != "" && listKind(m[2]) != kind {
+			break
+		}
+		kind = listKind(m[2])
+		col := len(m[0])
+		if strings.TrimSpace(m[3]) == "" && m[3] != "" {
+			col = len(m[1]) + len(m[2]) + 1
+		}
+		content := []string{lines[i][col:]}
+		for i++; i < len(lines); i++ {
+			l := lines[i]
+			lead := len(l) - len(strings.TrimLeft(l, " "))
+			if strings.TrimSpace(l) == "" {
+				// A blank line ends the item unless indented content
+				// follows.
+				if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" && len(lines[i+1])-len(strings.TrimLeft(lines[i+1], " ")) >= col {
+					content = append(content, "")
+					continue
+				}
+				break
+			}
+			if lead < col && (itemExp.MatchString(l) || content[len(content)-1] == "" || interrupts(l)) {
+				break
+			}
+			if lead > col {
+				lead = col
+			}
+			content = append(content, l[lead:])
+		}
+
+		marker := "-  "
+		if n := strings.TrimRight(m[2], ".)"); n != m[2] {
+			marker = n + ". "
+		}
+		text := strings.Join(c.blocks(content), "\n\n")
+		items = append(items, marker+indent(text, strings.Repeat(" ", len(marker)))[len(marker):])
+
+		// A blank line between the items doesn't end the list.
+		if i+1 < len(lines) && strings.TrimSpace(lines[i]) == "" && itemExp.MatchString(lines[i+1]) {
+			i++
+		}
+	}
+	return i, items
+}
+
+// listKind returns what distinguishes the items of a list: the bullet,
+// or the delimiter of numbers. Another kind starts another list.
+func listKind(marker string) string {
+	return marker[len(marker)-1:]
+}
+
+// literal returns a code block in the given language, or a literal block
+// without one.
+func literal(lang string, code []string) string {
+	for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+		code = code[:len(code)-1]
+	}
+	head := "::"
+	if lang != "" && lang != "text" {
+		head = ".. code-block:: " + lang
+	}
+	return head + "\n\n" + indent(strings.Join(code, "\n"), "    ")
+}
+
+// indent prefixes the nonempty lines of s.
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inlineExp matches the inline Markdown that differs from
+// reStructuredText.
+var inlineExp = regexp.MustCompile("(?s)" +
+	"`([^`]+)`" + // 1: code
+	"|!\\[([^\\]]*)\\]\\(([^)\\s]*)[^)]*\\)" + // 2, 3: image
+	"|\\[([^\\]]+)\\]\\(([^)\\s]+)[^)]*\\)" + // 4, 5: link
+	"|<(https?://[^>\\s]+)>" + // 6: automatic link
+	"|\\*\\*(\\S.*?)\\*\\*|\\b__(\\S.*?)__\\b" + // 7, 8: strong
+	"|\\*([^*\\s][^*]*?)\\*|\\b_([^_\\s][^_]*?)_\\b" + // 9, 10: emphasis
+	"|\\\\([\\\\`*_{}\\[\\]()#+.!|>-])" + // 11: escape
+	"|(?:^|\\s):[a-z0-9_+-]+:(?:$|\\s)") // emoji
+
+// inline converts the inline markup in text.
+func (c *converter) inline(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range inlineExp.FindAllStringSubmatchIndex(text, -1) {
+		sb.WriteString(escape(text[last:m[0]]))
+		last = m[1]
+		group := func(n int) string {
+			if m[2*n] < 0 {
+				return ""
+			}
+			return text[m[2*n]:m[2*n+1]]
+		}
+		prev, _ := utf8.DecodeLastRuneInString(sb.String())
+		next, _ := utf8.DecodeRuneInString(text[m[1]:])
+		switch {
+		case m[2] >= 0:
+			sb.WriteString(markup(prev, next, "``"+group(1)+"``"))
+		case m[4] >= 0:
+			c.warn("image left out: %s", group(3))
+		case m[8] >= 0:
+			label := strings.NewReplacer("`", "", "*", "", "<", "", ">", "").Replace(group(4))
+			sb.WriteString(markup(prev, next, "`"+label+" <"+c.absolute(group(5))+">`__"))
+		case m[12] >= 0:
+			sb.WriteString(group(6))
+		case m[14] >= 0 || m[16] >= 0:
+			sb.WriteString(markup(prev, next, "**"+escape(group(7)+group(8))+"**"))
+		case m[18] >= 0 || m[20] >= 0:
+			sb.WriteString(markup(prev, next, "*"+escape(group(9)+group(10))+"*"))
+		case m[22] >= 0:
+			sb.WriteString(escape(group(11)))
+		default:
+			// An emoji, with the space around it.
+			sb.WriteString(" ")
+		}
+	}
+	sb.WriteString(escape(text[last:]))
+	return strings.TrimSpace(sb.String())
+}
+
+// markup returns inline markup, escaping the space that
+// reStructuredText requires around it when it's next to a word.
+func markup(prev, next rune, s string) string {
+	if unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+		s = `\ ` + s
+	}
+	if unicode.IsLetter(next) || unicode.IsDigit(next) {
+		s += `\ `
+	}
+	return s
+}
+
+var (
+	specialReplacer  = strings.NewReplacer(`\`, `\\`, "*", `\*`, "`", "\\`", "|", `\|`)
+	underscoreEndExp = regexp.MustCompile(`(\w)_(\W|$)`)
+)
+
+// escape escapes the characters that would be markup in plain text.
+func escape(s string) string {
+	return underscoreEndExp.ReplaceAllString(specialReplacer.Replace(s), `$1\_$2`)
+}
+
+// absolute resolves a link relative to the forum.
+func (c *converter) absolute(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.IsAbs() {
+		return link
+	}
+	base, err := url.Parse(c.base)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(u).String()
+}
+
+func (c *converter) warn(format string, args ...any) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
@@ -9,6 +9,10 @@
 // This script finds all of the metrics in the Syncthing codebase and prints
 // them in Markdown format. It's used to generate the metrics documentation
 // for the Syncthing docs.
+//
+// With -since, the version each metric appeared in is looked up by
+// checking out the given older versions in the source tree. With -scrape,
+// metrics only known at runtime are read from a running instance.
 package main
 
 import (
@@ -22,7 +26,7 @@ import (
 	"strings"
 
 	"golang.org/x/exp/slices"
-	"golang.org/x/tools/go/packages"
+	"syncthing.net/docs/internal/stsource"
 )
 
 type metric struct {
@@ -30,32 +34,97 @@ type metric struct {
 	name      string
 	help      string
 	kind      string
+	labels    []string
+	since     string
 }
 
 func main() {
+	since := flag.String("since", "", "Comma separated older versions, oldest first, to find when metrics were added (the path must be a git clone)")
+	scrape := flag.String("scrape", "", "URL of the metrics endpoint of a running Syncthing, to include metrics not found in the source")
+	apiKey := flag.String("apikey", "", "API key for -scrape")
 	flag.Parse()
 	if flag.NArg() != 1 {
-		fmt.Println("Usage: find-metrics <path>")
+		fmt.Println("Usage: find-metrics [-since <versions>] [-scrape <url>] <path>")
 		os.Exit(1)
 	}
+	root := flag.Arg(0)
 
-	opts := &packages.Config{
-		Dir:  flag.Arg(0),
-		Mode: packages.NeedSyntax | packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+	// Check out the older versions first, then return to what was
+	// checked out.
+	var history []map[string]bool
+	var historyTags []string
+	if *since != "" {
+		head, err := stsource.Head(root)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, tag := range strings.Split(*since, ",") {
+			if _, _, err := stsource.Open(root, tag); err != nil {
+				log.Fatalln(err)
+			}
+			coll, err := collect(root)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			names := make(map[string]bool)
+			for _, m := range coll.metrics {
+				names[m.name] = true
+			}
+			history = append(history, names)
+			historyTags = append(historyTags, tag)
+		}
+		if _, _, err := stsource.Open(root, head); err != nil {
+			log.Fatalln(err)
+		}
 	}
 
-	pkgs, err := packages.Load(opts, "github.com/syncthing/syncthing/...")
+	coll, err := collect(root)
 	if err != nil {
 		log.Fatalln(err)
 	}
+	if *scrape != "" {
+		scraped, err := scrapeMetrics(*scrape, *apiKey)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		coll.merge(scraped)
+	}
+	for i := range coll.metrics {
+		coll.metrics[i].since = firstSeen(coll.metrics[i].name, history, historyTags)
+	}
+	coll.print()
+}
 
+// collect finds the metrics registered in the source tree.
+func collect(root string) (*metricCollector, error) {
+	pkgs, err := stsource.ParseTree(root, ".")
+	if err != nil {
+		return nil, err
+	}
 	var coll metricCollector
 	for _, pkg := range pkgs {
-		for _, file := range pkg.Syntax {
+		for _, file := range pkg.SortedFiles() {
 			ast.Inspect(file, coll.Visit)
 		}
 	}
-	coll.print()
+	return &coll, nil
+}
+
+// firstSeen returns the first version with the metric, or the empty
+// string if it's in the oldest one (or there's no history).
+func firstSeen(name string, history []map[string]bool, tags []string) string {
+	for i, names := range history {
+		if names[name] {
+			if i == 0 {
+				return ""
+			}
+			return tags[i]
+		}
+	}
+	if len(tags) > 0 {
+		return "after " + tags[len(tags)-1]
+	}
+	return ""
 }
 
 type metricCollector struct {
@@ -109,6 +178,14 @@ func (c *metricCollector) Visit(n ast.Node) bool {
 					kind = "counter vector"
 				case "NewGaugeVec":
 					kind = "gauge vector"
+				case "NewHistogram":
+					kind = "histogram"
+				case "NewHistogramVec":
+					kind = "histogram vector"
+				case "NewSummary":
+					kind = "summary"
+				case "NewSummaryVec":
+					kind = "summary vector"
 				default:
 					continue
 				}
@@ -119,9 +196,26 @@ func (c *metricCollector) Visit(n ast.Node) bool {
 				args := make(map[string]string)
 				for _, el := range call.Args[0].(*ast.CompositeLit).Elts {
 					kv := el.(*ast.KeyValueExpr)
-					key := kv.Key.(*ast.Ident).Name       // e.g., "Name"
-					val := kv.Value.(*ast.BasicLit).Value // e.g., `"foo"`
-					args[key], _ = strconv.Unquote(val)
+					key := kv.Key.(*ast.Ident).Name // e.g., "Name"
+					lit, ok := kv.Value.(*ast.BasicLit)
+					if !ok {
+						// Buckets and the like.
+						continue
+					}
+					args[key], _ = strconv.Unquote(lit.Value) // e.g., `"foo"`
+				}
+
+				// The vectors take the label names as the second
+				// argument.
+				var labels []string
+				if len(call.Args) == 2 {
+					if lit, ok := call.Args[1].(*ast.CompositeLit); ok {
+						for _, el := range lit.Elts {
+							if v, ok := stsource.StringLit(el); ok {
+								labels = append(labels, v)
+							}
+						}
+					}
 				}
 
 				// Build the full name of the metric from the namespace +
@@ -144,6 +238,7 @@ func (c *metricCollector) Visit(n ast.Node) bool {
 					name:      fullName,
 					help:      args["Help"],
 					kind:      kind,
+					labels:    labels,
 				})
 			}
 		}
@@ -166,8 +261,19 @@ func (c *metricCollector) print() {
 			prevSubsystem = m.subsystem
 		}
 		fmt.Println(header(fmt.Sprintf("Metric *%v* (%s)", m.name, m.kind), "^"))
+		if m.since != "" && !strings.HasPrefix(m.since, "after") {
+			fmt.Printf(".. versionadded:: %s\n\n", strings.TrimPrefix(m.since, "v"))
+		}
 		fmt.Println(wordwrap(sentenceize(m.help), 72))
 		fmt.Println()
+		if len(m.labels) > 0 {
+			labels := make([]string, len(m.labels))
+			for i, l := range m.labels {
+				labels[i] = "``" + l + "``"
+			}
+			fmt.Println(wordwrap("Labels: "+strings.Join(labels, ", ")+".", 72))
+			fmt.Println()
+		}
 	}
 }
 
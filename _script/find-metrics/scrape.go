@@ -0,0 +1,120 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// scrapeMetrics reads the Syncthing metrics from a metrics endpoint in the
+// Prometheus text format. The Go runtime and process metrics are left out
+// as they're documented elsewhere.
+func scrapeMetrics(url, apiKey string) ([]metric, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	byName := make(map[string]*metric)
+	labels := make(map[string]map[string]bool)
+	get := func(name string) *metric {
+		if m, ok := byName[name]; ok {
+			return m
+		}
+		m := &metric{name: name}
+		if parts := strings.SplitN(name, "_", 3); len(parts) == 3 {
+			m.subsystem = parts[1]
+		}
+		byName[name] = m
+		return m
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "syncthing_") && !strings.HasPrefix(line, "# HELP syncthing_") && !strings.HasPrefix(line, "# TYPE syncthing_") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "# HELP "); ok {
+			name, help, _ := strings.Cut(rest, " ")
+			get(name).help = help
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name, typ, _ := strings.Cut(rest, " ")
+			get(name).kind = typ
+			continue
+		}
+		// A sample: name{label="value",...} value
+		name, rest, ok := strings.Cut(line, "{")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, "_bucket"), "_sum"), "_count")
+		if _, ok := byName[name]; !ok {
+			continue
+		}
+		if labels[name] == nil {
+			labels[name] = make(map[string]bool)
+		}
+		for _, pair := range strings.Split(strings.SplitN(rest, "}", 2)[0], ",") {
+			if l, _, ok := strings.Cut(pair, "="); ok && l != "le" && l != "quantile" {
+				labels[name][l] = true
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	var metrics []metric
+	for name, m := range byName {
+		for l := range labels[name] {
+			m.labels = append(m.labels, l)
+		}
+		sort.Strings(m.labels)
+		if len(m.labels) > 0 && !strings.HasSuffix(m.kind, "vector") {
+			m.kind += " vector"
+		}
+		metrics = append(metrics, *m)
+	}
+	return metrics, nil
+}
+
+// merge adds the scraped metrics not found in the source, and the labels
+// of those found without them.
+func (c *metricCollector) merge(scraped []metric) {
+	known := make(map[string]int)
+	for i, m := range c.metrics {
+		known[m.name] = i
+	}
+	for _, m := range scraped {
+		if i, ok := known[m.name]; ok {
+			if len(c.metrics[i].labels) == 0 {
+				c.metrics[i].labels = m.labels
+			}
+			continue
+		}
+		c.metrics = append(c.metrics, m)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./forumreport [-days 90] [-rst ../includes/common-issues.rst]
+//
+// Reports which problems users ask about most on the forum, to guide
+// which parts of the docs need expanding. The topics created in the
+// support category in the last -days are sorted into the problem areas
+// of areas.json, by their tags and the keywords in their titles, and
+// counted per area with how many are unsolved and how often they were
+// viewed. The report, with the most frequent tags of the topics in no
+// area and the most viewed topics, is written to stdout.
+//
+// With -rst, the table of areas is also written as an include for the
+// docs, linking each area to the page covering it.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"syncthing.net/docs/internal/discourse"
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// area is a kind of problem, with the docs covering it.
+type area struct {
+	Name     string   `json:"name"`
+	Tags     []string `json:"tags"`
+	Keywords []string `json:"keywords"`
+	// Docs is a label or a document as /path.
+	Docs string `json:"docs"`
+}
+
+// matches reports whether the topic is about the area: it has one of the
+// tags, or one of the keywords is a word or phrase of its title.
+func (a *area) matches(t discourse.TopicSummary) bool {
+	for _, tag := range t.Tags {
+		for _, at := range a.Tags {
+			if strings.EqualFold(tag, at) {
+				return true
+			}
+		}
+	}
+	title := strings.ToLower(t.Title)
+	for _, kw := range a.Keywords {
+		if containsWord(title, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether s contains the phrase, not as part of a
+// longer word.
+func containsWord(s, phrase string) bool {
+	for i := 0; i <= len(s)-len(phrase); {
+		j := strings.Index(s[i:], phrase)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(phrase)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		i = start + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// count is the tally of the topics in an area.
+type count struct {
+	name     string
+	docs     string
+	topics   int
+	unsolved int
+	views    int
+}
+
+func main() {
+	log.SetFlags(0)
+	forumURL := flag.String("forum", discourse.DefaultURL, "Forum URL")
+	categories := flag.String("category", "support", "Comma separated slugs of the categories to report on")
+	days := flag.Int("days", 90, "Report on the topics created in this many days")
+	areasFile := flag.String("areas", "forumreport/areas.json", "Problem areas to sort the topics into")
+	root := flag.String("root", "..", "Documentation root, to check the docs of the areas")
+	rstFile := flag.String("rst", "", "Also write the table of areas for the docs to this file")
+	top := flag.Int("top", 10, "Number of tags and topics to list")
+	flag.Parse()
+
+	areas, err := readAreas(*areasFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := checkDocs(*root, areas); err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx := context.Background()
+	client := discourse.NewClient(*forumURL)
+	since := time.Now().AddDate(0, 0, -*days)
+	var topics []discourse.TopicSummary
+	for _, cat := range strings.Split(*categories, ",") {
+		ts, err := client.CategoryTopics(ctx, strings.TrimSpace(cat), since)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		topics = append(topics, ts...)
+	}
+	if len(topics) == 0 {
+		log.Fatalln("no topics found")
+	}
+
+	counts, other, tags := tally(areas, topics)
+	if err := writeReport(os.Stdout, client, since, topics, counts, other, tags, *top); err != nil {
+		log.Fatalln(err)
+	}
+	if *rstFile != "" {
+		f, err := os.Create(*rstFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := writeTable(f, counts, len(topics), since); err != nil {
+			log.Fatalln(err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+func readAreas(name string) ([]*area, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var data struct {
+		Areas []*area `json:"areas"`
+	}
+	if err := json.Unmarshal(bs, &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return data.Areas, nil
+}
+
+// checkDocs checks that the docs of each area exist, so the table links
+// somewhere.
+func checkDocs(root string, areas []*area) error {
+	tree, err := rstdoc.Load(root)
+	if err != nil {
+		return err
+	}
+	labels := make(map[string]bool)
+	for _, d := range tree.Docs {
+		for _, s := range d.Sections {
+			for _, l := range s.Labels {
+				labels[strings.ToLower(l)] = true
+			}
+		}
+	}
+	for _, a := range areas {
+		if name, ok := strings.CutPrefix(a.Docs, "/"); ok {
+			if tree.Doc(name) == nil {
+				return fmt.Errorf("%s: no document %s", a.Name, a.Docs)
+			}
+		} else if !labels[strings.ToLower(a.Docs)] {
+			return fmt.Errorf("%s: no label %s", a.Name, a.Docs)
+		}
+	}
+	return nil
+}
+
+// tally counts the topics per area, most frequent first, and those in no
+// area, with the number of them per tag.
+func tally(areas []*area, topics []discourse.TopicSummary) ([]count, count, map[string]int) {
+	counts := make([]count, len(areas))
+	for i, a := range areas {
+		counts[i] = count{name: a.Name, docs: a.Docs}
+	}
+	other := count{name: "In no area"}
+	tags := make(map[string]int)
+	add := func(c *count, t discourse.TopicSummary) {
+		c.topics++
+		c.views += t.Views
+		if !t.HasAcceptedAnswer {
+			c.unsolved++
+		}
+	}
+	for _, t := range topics {
+		found := false
+		for i, a := range areas {
+			if a.matches(t) {
+				add(&counts[i], t)
+				found = true
+			}
+		}
+		if !found {
+			add(&other, t)
+			for _, tag := range t.Tags {
+				tags[tag]++
+			}
+		}
+	}
+	sort.SliceStable(counts, func(a, b int) bool {
+		return counts[a].topics > counts[b].topics
+	})
+	return counts, other, tags
+}
+
+func writeReport(w io.Writer, client *discourse.Client, since time.Time, topics []discourse.TopicSummary, counts []count, other count, tags map[string]int, top int) error {
+	fmt.Fprintf(w, "%d support topics since %s\n\n", len(topics), since.Format(time.DateOnly))
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Area\tTopics\tUnsolved\tViews\tDocs\n")
+	for _, c := range append(counts, other) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", c.name, c.topics, c.unsolved, c.views, c.docs)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	type tagCount struct {
+		tag string
+		n   int
+	}
+	var tcs []tagCount
+	for tag, n := range tags {
+		tcs = append(tcs, tagCount{tag, n})
+	}
+	sort.Slice(tcs, func(a, b int) bool {
+		if tcs[a].n != tcs[b].n {
+			return tcs[a].n > tcs[b].n
+		}
+		return tcs[a].tag < tcs[b].tag
+	})
+	if len(tcs) > 0 {
+		fmt.Fprintf(w, "\nMost frequent tags of the topics in no area:\n\n")
+		for i, tc := range tcs {
+			if i == top {
+				break
+			}
+			fmt.Fprintf(w, "  %5d  %s\n", tc.n, tc.tag)
+		}
+	}
+
+	byViews := append([]discourse.TopicSummary(nil), topics...)
+	sort.SliceStable(byViews, func(a, b int) bool {
+		return byViews[a].Views > byViews[b].Views
+	})
+	fmt.Fprintf(w, "\nMost viewed topics:\n\n")
+	for i, t := range byViews {
+		if i == top {
+			break
+		}
+		solved := ""
+		if !t.HasAcceptedAnswer {
+			solved = " (unsolved)"
+		}
+		fmt.Fprintf(w, "  %5d  %s%s\n         %s\n", t.Views, t.Title, solved, client.TopicURL(t.ID))
+	}
+	return nil
+}
+
+// writeTable writes the areas with their share of the topics, for the
+// docs.
+func writeTable(w io.Writer, counts []count, total int, since time.Time) error {
+	t := rst.Table{
+		Header: []string{"Problem", "Share of Topics", "Documentation"},
+		Widths: []int{35, 20, 45},
+	}
+	for _, c := range counts {
+		if c.topics == 0 {
+			continue
+		}
+		t.Rows = append(t.Rows, []string{
+			c.name,
+			fmt.Sprintf("%d%%", c.topics*100/total),
+			reference(c.docs),
+		})
+	}
+	if _, err := fmt.Fprint(w, ".. This file is generated by _script/forumreport; do not edit.\n\n"); err != nil {
+		return err
+	}
+	if _, err := t.WriteTo(w); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "From the %d support topics on the forum since %s.\n", total, since.Format(time.DateOnly))
+	return err
+}
+
+// reference returns the markup linking to a label or document.
+func reference(target string) string {
+	if strings.HasPrefix(target, "/") {
+		return ":doc:`" + target + "`"
+	}
+	return ":ref:`" + target + "`"
+}
@@ -0,0 +1,304 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./freshness [-since v1.20.0] [-months 18] > worklist.md
+//
+//	go run ./freshness -mark users/faq -version v1.27.0 [-owner calmh]
+//
+// Tracks when the pages were last reviewed. The manifest, reviews.csv at
+// the root of the docs, lists for each page the Syncthing version and
+// date it was last reviewed against, and who looks after it. The report
+// is a Markdown worklist of the pages that need a review, most urgent
+// first: those never reviewed, those last reviewed against a version
+// older than -since, and those whose source, including what they
+// include, hasn't changed in git for more than -months months.
+//
+// With -mark, the page is recorded as reviewed today against -version
+// instead, keeping the owner unless -owner is given.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// review is a row of the manifest.
+type review struct {
+	page    string
+	version string
+	date    string
+	owner   string
+}
+
+var manifestHeader = []string{"Page", "Version", "Date", "Owner"}
+
+// entry is a page in the worklist.
+type entry struct {
+	page     string
+	review   review
+	changed  time.Time
+	reasons  []string
+	priority int
+}
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	manifest := flag.String("manifest", "../reviews.csv", "Review manifest")
+	since := flag.String("since", "", "List pages last reviewed against a version older than this")
+	months := flag.Int("months", 12, "List pages not changed for this many months (zero to not check)")
+	mark := flag.String("mark", "", "Record this page as reviewed today")
+	version := flag.String("version", "", "Version the page was reviewed against, with -mark")
+	owner := flag.String("owner", "", "Owner of the page, with -mark")
+	flag.Parse()
+
+	tree, err := rstdoc.Load(*root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	reviews, err := readManifest(*manifest)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *mark != "" {
+		if tree.Doc(*mark) == nil {
+			log.Fatalf("%s: no such page", *mark)
+		}
+		if _, ok := relnotes.ParseVersion(*version); !ok {
+			log.Fatalf("-version: bad version %q", *version)
+		}
+		r := reviews[*mark]
+		r.page, r.version, r.date = *mark, *version, time.Now().Format(time.DateOnly)
+		if *owner != "" {
+			r.owner = *owner
+		}
+		reviews[*mark] = r
+		if err := writeManifest(*manifest, tree, reviews); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	var sinceVer relnotes.Version
+	if *since != "" {
+		var ok bool
+		if sinceVer, ok = relnotes.ParseVersion(*since); !ok {
+			log.Fatalf("-since: bad version %q", *since)
+		}
+	}
+	for page := range reviews {
+		if tree.Doc(page) == nil {
+			log.Printf("%s: page in the manifest doesn't exist", page)
+		}
+	}
+
+	changed, err := lastChanged(*root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	cutoff := time.Now().AddDate(0, -*months, 0)
+
+	var list []entry
+	for _, doc := range tree.Docs {
+		e := entry{page: doc.Name, review: reviews[doc.Name]}
+		for _, f := range sourceFiles(doc) {
+			if t := changed[f]; t.After(e.changed) {
+				e.changed = t
+			}
+		}
+
+		// Never reviewed pages come first, then the ones reviewed against
+		// the oldest versions, then the ones that haven't changed for the
+		// longest time.
+		reviewed, ok := relnotes.ParseVersion(e.review.version)
+		switch {
+		case !ok:
+			e.reasons = append(e.reasons, "never reviewed")
+			e.priority = 3
+		case *since != "" && reviewed.Less(sinceVer):
+			e.reasons = append(e.reasons, "reviewed against "+e.review.version)
+			e.priority = 2
+		}
+		if *months > 0 && !e.changed.IsZero() && e.changed.Before(cutoff) {
+			e.reasons = append(e.reasons, fmt.Sprintf("unchanged since %s", e.changed.Format("January 2006")))
+			if e.priority == 0 {
+				e.priority = 1
+			}
+		}
+		if len(e.reasons) > 0 {
+			list = append(list, e)
+		}
+	}
+	sort.SliceStable(list, func(a, b int) bool {
+		ea, eb := list[a], list[b]
+		if ea.priority != eb.priority {
+			return ea.priority > eb.priority
+		}
+		va, _ := relnotes.ParseVersion(ea.review.version)
+		vb, _ := relnotes.ParseVersion(eb.review.version)
+		if va != vb {
+			return va.Less(vb)
+		}
+		if !ea.changed.Equal(eb.changed) {
+			return ea.changed.Before(eb.changed)
+		}
+		return ea.page < eb.page
+	})
+
+	writeWorklist(os.Stdout, list, len(tree.Docs))
+}
+
+// sourceFiles returns the file of the document and the files it
+// includes, relative to the root.
+func sourceFiles(doc *rstdoc.Doc) []string {
+	files := []string{doc.File}
+	for _, d := range doc.Directives {
+		if d.Name == "include" || d.Name == "literalinclude" {
+			files = append(files, rstdoc.IncludePath(d.Pos.File, d.Arg))
+		}
+	}
+	return files
+}
+
+// lastChanged returns the time of the last commit that changed each file
+// in the repository, relative to the root.
+func lastChanged(root string) (map[string]time.Time, error) {
+	prefix, err := git(root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.TrimSpace(prefix)
+	out, err := git(root, "log", "--format=%x00%cI", "--name-only", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]time.Time)
+	var cur time.Time
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "\x00") {
+			if cur, err = time.Parse(time.RFC3339, line[1:]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		file, ok := strings.CutPrefix(line, prefix)
+		if line == "" || !ok {
+			continue
+		}
+		// The log is newest first.
+		if _, seen := res[file]; !seen {
+			res[file] = cur
+		}
+	}
+	return res, nil
+}
+
+func writeWorklist(w io.Writer, list []entry, pages int) {
+	fmt.Fprintf(w, "# Pages needing review\n\n")
+	if len(list) == 0 {
+		fmt.Fprintf(w, "All %d pages are up to date.\n", pages)
+		return
+	}
+	fmt.Fprintf(w, "%d of %d pages need a review, most urgent first.\n\n", len(list), pages)
+	fmt.Fprintln(w, "| Page | Owner | Last reviewed | Last changed | Why |")
+	fmt.Fprintln(w, "|------|-------|---------------|--------------|-----|")
+	for _, e := range list {
+		reviewed := "-"
+		if e.review.version != "" {
+			reviewed = e.review.version + " (" + e.review.date + ")"
+		}
+		changed := "-"
+		if !e.changed.IsZero() {
+			changed = e.changed.Format(time.DateOnly)
+		}
+		owner := e.review.owner
+		if owner == "" {
+			owner = "-"
+		}
+		fmt.Fprintf(w, "| `%s` | %s | %s | %s | %s |\n", e.page, owner, reviewed, changed, strings.Join(e.reasons, ", "))
+	}
+}
+
+// readManifest returns the reviews in the manifest, by page.
+func readManifest(file string) (map[string]review, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	records, err := csv.NewReader(fd).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	res := make(map[string]review)
+	for i, rec := range records {
+		if i == 0 {
+			// Header
+			continue
+		}
+		r := review{page: rec[0], version: rec[1], date: rec[2], owner: rec[3]}
+		if r.version != "" {
+			if _, ok := relnotes.ParseVersion(r.version); !ok {
+				return nil, fmt.Errorf("%s: row %d: bad version %q", file, i+1, r.version)
+			}
+			if _, err := time.Parse(time.DateOnly, r.date); err != nil {
+				return nil, fmt.Errorf("%s: row %d: bad date %q", file, i+1, r.date)
+			}
+		}
+		res[r.page] = r
+	}
+	return res, nil
+}
+
+// writeManifest writes the manifest with a row for every page, in page
+// order.
+func writeManifest(file string, tree *rstdoc.Tree, reviews map[string]review) error {
+	pages := make(map[string]bool)
+	for _, doc := range tree.Docs {
+		pages[doc.Name] = true
+	}
+	for page := range reviews {
+		pages[page] = true
+	}
+	names := make([]string, 0, len(pages))
+	for page := range pages {
+		names = append(names, page)
+	}
+	sort.Strings(names)
+
+	tmp := file + ".tmp"
+	fd, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(fd)
+	_ = cw.Write(manifestHeader)
+	for _, page := range names {
+		r := reviews[page]
+		_ = cw.Write([]string{page, r.version, r.date, r.owner})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		fd.Close()
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Clean(file))
+}
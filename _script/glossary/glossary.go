@@ -0,0 +1,202 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./glossary [-dry-run] export
+//
+//	go run ./glossary import > glossary-review.rst
+//
+// Keeps the terminology of the documentation and the translated GUI
+// consistent by synchronizing the glossary of the documentation, the
+// glossary directives, with the glossary of the Weblate project.
+//
+// export adds the terms of the documentation glossary that the Weblate
+// glossary lacks, with their definitions as explanations for the
+// translators, and reports the terms defined differently in both. It
+// needs WEBLATE_TOKEN; with -dry-run it only prints what it would add.
+//
+// import writes the terms translators added to the Weblate glossary that
+// the documentation glossary lacks as glossary entries, for the docs
+// writers to review and add those worth defining, in their own words.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+	"syncthing.net/docs/internal/weblate"
+)
+
+// entry is an entry of the documentation glossary.
+type entry struct {
+	// Terms are the term and its synonyms, defined together.
+	Terms []string
+	// Definition is plain text, for the translators.
+	Definition string
+	Pos        rstdoc.Pos
+}
+
+var (
+	// :role:`text <target>` and :role:`target`.
+	roleExp     = regexp.MustCompile("(?s):[\\w:+-]+:`([^`<]*?)\\s*(?:<[^`>]*>)?`")
+	literalExp  = regexp.MustCompile("``([^`]+)``")
+	linkExp     = regexp.MustCompile("`([^`<]*?)\\s*(?:<[^`>]*>)?`__?")
+	emphasisExp = regexp.MustCompile(`\*{1,2}([^*]+)\*{1,2}`)
+)
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	api := flag.String("api", weblate.DefaultURL, "Weblate API root")
+	project := flag.String("project", "syncthing", "Weblate project")
+	component := flag.String("component", "glossary", "Glossary component of the project")
+	lang := flag.String("lang", "en", "Source language of the glossary")
+	dryRun := flag.Bool("dry-run", false, "Print the terms export would add instead of adding them")
+	flag.Parse()
+
+	tree, err := rstdoc.Load(*root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	entries := docsGlossary(tree)
+
+	ctx := context.Background()
+	client := weblate.NewClient(*api)
+	units, err := client.Units(ctx, *project, *component, *lang)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	terms := make(map[string]weblate.Unit)
+	for _, u := range units {
+		if len(u.Source) > 0 {
+			terms[strings.ToLower(u.Source[0])] = u
+		}
+	}
+
+	switch flag.Arg(0) {
+	case "export":
+		if len(entries) == 0 {
+			log.Fatalf("%s: no glossary directives", *root)
+		}
+		for _, e := range entries {
+			for _, term := range e.Terms {
+				u, ok := terms[strings.ToLower(term)]
+				switch {
+				case !ok && *dryRun:
+					fmt.Printf("%s: would add %q: %s\n", e.Pos, term, e.Definition)
+				case !ok:
+					if err := client.AddTerm(ctx, *project, *component, *lang, term, e.Definition); err != nil {
+						log.Fatalf("%s: %v", term, err)
+					}
+					fmt.Printf("%s: added %q\n", e.Pos, term)
+				case u.Explanation != "" && u.Explanation != e.Definition:
+					fmt.Printf("%s: %q is explained differently on Weblate: %s\n", e.Pos, term, u.WebURL)
+				}
+			}
+		}
+
+	case "import":
+		defined := make(map[string]bool)
+		for _, e := range entries {
+			for _, term := range e.Terms {
+				defined[strings.ToLower(term)] = true
+			}
+		}
+		var added []weblate.Unit
+		for key, u := range terms {
+			if !defined[key] {
+				added = append(added, u)
+			}
+		}
+		sort.Slice(added, func(a, b int) bool { return strings.ToLower(added[a].Source[0]) < strings.ToLower(added[b].Source[0]) })
+		writeReview(os.Stdout, added)
+
+	default:
+		log.Fatalln("Usage: glossary [flags] export|import")
+	}
+}
+
+// docsGlossary returns the entries of the glossary directives.
+func docsGlossary(t *rstdoc.Tree) []entry {
+	var res []entry
+	for _, d := range t.Docs {
+		for _, dir := range d.Directives {
+			if dir.Name != "glossary" {
+				continue
+			}
+			var cur *entry
+			var def []string
+			flush := func() {
+				if cur != nil {
+					cur.Definition = plain(strings.Join(def, " "))
+					res = append(res, *cur)
+				}
+				cur, def = nil, nil
+			}
+			for i, line := range dir.Content {
+				switch {
+				case line == "":
+				case line[0] != ' ' && line[0] != '\t':
+					// Consecutive terms are synonyms.
+					if cur == nil || len(def) > 0 {
+						flush()
+						cur = &entry{Pos: rstdoc.Pos{File: dir.Pos.File, Line: dir.ContentLine + i}}
+					}
+					// A term may have a classifier, "term : classifier".
+					name, _, _ := strings.Cut(line, " : ")
+					cur.Terms = append(cur.Terms, strings.TrimSpace(name))
+				default:
+					def = append(def, strings.TrimSpace(line))
+				}
+			}
+			flush()
+		}
+	}
+	return res
+}
+
+// plain returns the reStructuredText as plain text, for Weblate.
+func plain(s string) string {
+	s = roleExp.ReplaceAllString(s, "$1")
+	s = literalExp.ReplaceAllString(s, "$1")
+	s = linkExp.ReplaceAllString(s, "$1")
+	s = emphasisExp.ReplaceAllString(s, "$1")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// writeReview writes the terms as glossary entries.
+func writeReview(w io.Writer, units []weblate.Unit) {
+	fmt.Fprintln(w, ".. Terms of the Weblate glossary that the documentation glossary lacks,")
+	fmt.Fprintln(w, "   written by _script/glossary for review: add those worth defining to")
+	fmt.Fprintln(w, "   the glossary, in your own words.")
+	fmt.Fprintln(w)
+	if len(units) == 0 {
+		return
+	}
+	fmt.Fprintln(w, ".. glossary::")
+	for _, u := range units {
+		fmt.Fprintf(w, "\n   %s\n", u.Source[0])
+		def := u.Explanation
+		if def == "" {
+			def = "No explanation on Weblate."
+		}
+		for _, line := range strings.Split(strings.TrimSpace(def), "\n") {
+			fmt.Fprintf(w, "      %s\n", strings.TrimSpace(line))
+		}
+		fmt.Fprintf(w, "\n      .. %s", u.WebURL)
+		if u.ExtraFlags != "" {
+			fmt.Fprintf(w, " (%s)", u.ExtraFlags)
+		}
+		fmt.Fprintln(w)
+	}
+}
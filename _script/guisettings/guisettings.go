@@ -0,0 +1,172 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./guisettings -tag v1.27.0 > ../includes/gui-settings.rst
+//
+// Reads the settings, folder and device dialogs from the GUI templates
+// in the Syncthing source and writes a reference of them, tab by tab:
+// each control with its label, help text and choices, and the
+// configuration option it sets. Options documented on the configuration
+// page are linked.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/stsource"
+)
+
+// dialog is a GUI dialog to document.
+type dialog struct {
+	Title    string
+	Template string
+}
+
+var dialogs = []dialog{
+	{"Settings", "gui/default/syncthing/settings/settingsModalView.html"},
+	{"Edit Folder", "gui/default/syncthing/folder/editFolderModalView.html"},
+	{"Edit Device", "gui/default/syncthing/device/editDeviceModalView.html"},
+}
+
+// guiModels maps the scope variables the dialogs edit to the
+// configuration sections.
+var guiModels = map[string]string{
+	"currentFolder": "folder",
+	"currentDevice": "device",
+	"tmpOptions":    "options",
+	"tmpGUI":        "gui",
+}
+
+// guiFields maps the properties the GUI edits, after removing the
+// helper prefix and suffix, to the options where they differ.
+var guiFields = map[string]string{
+	"guiVersioning": "versioning",
+	"deviceID":      "id",
+}
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	doc := flag.String("doc", "../users/config.rst", "Configuration page with the option directives")
+	flag.Parse()
+
+	documented, err := readOptions(*doc)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprintf(w, ".. This file is generated by _script/guisettings; do not edit.\n\n")
+	for _, d := range dialogs {
+		tabs, err := parseDialog(filepath.Join(root, d.Template))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(tabs) == 0 {
+			log.Fatalf("%s: no tabs found", d.Template)
+		}
+		writeDialog(w, d, tabs, documented)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func writeDialog(w io.Writer, d dialog, tabs []*tab, documented map[string]string) {
+	fmt.Fprint(w, rst.Heading(d.Title, '~'))
+	for _, t := range tabs {
+		fmt.Fprint(w, rst.Heading(t.Title, '^'))
+		if len(t.Controls) == 0 {
+			fmt.Fprint(w, "This tab has no settings.\n\n")
+			continue
+		}
+		for _, c := range t.Controls {
+			fmt.Fprintf(w, "%s\n", rst.Escape(c.Label))
+			for _, h := range c.Help {
+				fmt.Fprintf(w, "    %s\n\n", rst.Escape(h))
+			}
+			var facts []string
+			switch {
+			case c.Option == "":
+				facts = append(facts, "Not stored in the configuration.")
+			case documented[c.Option] != "":
+				facts = append(facts, fmt.Sprintf("Sets :stconf:opt:`%s`.", documented[c.Option]))
+			default:
+				facts = append(facts, fmt.Sprintf("Sets %s.", rst.Literal(c.Option)))
+			}
+			if len(c.Choices) > 0 {
+				for i, ch := range c.Choices {
+					c.Choices[i] = rst.Escape(ch)
+				}
+				facts = append(facts, "Choices: "+strings.Join(c.Choices, ", ")+".")
+			}
+			fmt.Fprintf(w, "    %s\n\n", strings.Join(facts, " "))
+		}
+	}
+}
+
+// optionFor returns the option a control's model sets, such as
+// "options.maxRecvKbps" for "tmpOptions.maxRecvKbps", or the empty
+// string if it's not in the configuration. Helper properties are
+// prefixed with an underscore, and lists edited as a string suffixed
+// with Str; other underscored properties are GUI state.
+func optionFor(model string) string {
+	scope, path, ok := strings.Cut(model, ".")
+	sec, known := guiModels[scope]
+	if !ok || !known {
+		return ""
+	}
+	field, _, _ := strings.Cut(path, ".")
+	if name, ok := strings.CutPrefix(field, "_"); ok {
+		if strings.HasSuffix(name, "Str") {
+			field = strings.TrimSuffix(name, "Str")
+		} else if _, ok := guiFields[name]; ok {
+			field = name
+		} else {
+			return ""
+		}
+	}
+	if f, ok := guiFields[field]; ok {
+		field = f
+	}
+	return sec + "." + field
+}
+
+// readOptions returns the option names declared with option directives
+// in the page, mapped to themselves. Options the GUI edits as lists are
+// documented in the singular, so the plurals are mapped to those too.
+func readOptions(path string) (map[string]string, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	opts := make(map[string]string)
+	for _, line := range strings.Split(string(bs), "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), ".. option::"); ok {
+			name = strings.TrimSpace(name)
+			opts[name] = name
+			if _, ok := opts[name+"s"]; !ok {
+				opts[name+"s"] = name
+				opts[name+"es"] = name
+			}
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,333 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// tab is a tab of a dialog with the controls on it.
+type tab struct {
+	Title    string
+	Controls []*control
+}
+
+// control is a labelled form control, or several bound to the same
+// option in one form group (a number and its unit).
+type control struct {
+	Label   string
+	Model   string
+	Option  string
+	Help    []string
+	Choices []string
+}
+
+// tabHrefExp finds the pane id in tab links, which may be expressions
+// disabling the tab in some states.
+var tabHrefExp = regexp.MustCompile(`#([\w-]+)`)
+
+// parseDialog returns the tabs of a dialog template, in order.
+func parseDialog(file string) ([]*tab, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	doc, err := html.Parse(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	var tabs []*tab
+	for _, a := range findAll(doc, func(n *html.Node) bool {
+		return n.DataAtom == atom.A && attr(n, "data-toggle") == "tab"
+	}) {
+		m := tabHrefExp.FindStringSubmatch(attr(a, "href"))
+		if m == nil {
+			continue
+		}
+		pane := findAll(doc, func(n *html.Node) bool {
+			return attr(n, "id") == m[1] && hasClass(n, "tab-pane")
+		})
+		if len(pane) == 0 {
+			continue
+		}
+		tabs = append(tabs, &tab{
+			Title:    strings.Join(translated(a), " "),
+			Controls: controls(pane[0]),
+		})
+	}
+	return tabs, nil
+}
+
+// controls returns the labelled controls in the pane.
+func controls(pane *html.Node) []*control {
+	var res []*control
+	byGroup := make(map[*html.Node]map[string]*control)
+	for _, n := range findAll(pane, func(n *html.Node) bool {
+		return attr(n, "ng-model") != "" && attr(n, "type") != "hidden"
+	}) {
+		group := formGroup(n, pane)
+		label := labelOf(n, group)
+		if label == "" {
+			continue
+		}
+		model := attr(n, "ng-model")
+		option := optionFor(model)
+
+		// A number and its unit are bound to the same option.
+		key := option
+		if key == "" {
+			key = model
+		}
+		if byGroup[group] == nil {
+			byGroup[group] = make(map[string]*control)
+		}
+		c, ok := byGroup[group][key]
+		if !ok {
+			c = &control{Label: label, Model: model, Option: option, Help: helpOf(n, group)}
+			byGroup[group][key] = c
+			res = append(res, c)
+		}
+		if n.DataAtom == atom.Select {
+			c.Choices = append(c.Choices, choices(n)...)
+		}
+	}
+	return res
+}
+
+// formGroup returns the form group the control is in, or its parent if
+// it's not in one.
+func formGroup(n, pane *html.Node) *html.Node {
+	for p := n.Parent; p != nil && p != pane; p = p.Parent {
+		if hasClass(p, "form-group") {
+			return p
+		}
+	}
+	return n.Parent
+}
+
+// labelOf returns the label of the control: the label it's in
+// (checkboxes), the label for its id, or the closest one.
+func labelOf(n, group *html.Node) string {
+	for p := n.Parent; p != nil && p != group.Parent; p = p.Parent {
+		if p.DataAtom == atom.Label {
+			return labelText(p)
+		}
+	}
+	labels := findAll(group, func(l *html.Node) bool { return l.DataAtom == atom.Label })
+	if id := attr(n, "id"); id != "" {
+		for _, l := range labels {
+			if attr(l, "for") == id {
+				return labelText(l)
+			}
+		}
+	}
+	// Otherwise the closest label around the control, or text next to
+	// it, as in the rows of the device rate limits.
+	for p := n.Parent; p != nil && p != group.Parent; p = p.Parent {
+		if labels := findAll(p, func(l *html.Node) bool { return l.DataAtom == atom.Label }); len(labels) > 0 {
+			return labelText(labels[0])
+		}
+		for c := p.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && hasAttr(c, "translate") && !hasClass(c, "help-block") {
+				return collapse(textOf(c))
+			}
+		}
+	}
+	return ""
+}
+
+// labelText returns the text of a label, without the help text some
+// checkbox labels contain.
+func labelText(l *html.Node) string {
+	var parts []string
+	for c := l.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && hasClass(c, "help-block") {
+			continue
+		}
+		parts = append(parts, translated(c)...)
+	}
+	if hasAttr(l, "translate") {
+		parts = translated(l)
+	}
+	return strings.Join(parts, " ")
+}
+
+// helpOf returns the help texts for the control, leaving out the
+// validation errors and warnings. Controls sharing a form group often
+// have their own column with their own help, so the help is taken from
+// the closest element around the control that has any.
+func helpOf(n, group *html.Node) []string {
+	isHelp := func(n *html.Node) bool { return hasClass(n, "help-block") }
+	scope := group
+	for p := n.Parent; p != nil && p != group; p = p.Parent {
+		if len(findAll(p, isHelp)) > 0 {
+			scope = p
+			break
+		}
+	}
+	var help []string
+	for _, b := range findAll(scope, isHelp) {
+		if isValidation(b) || hasHelpAncestor(b, scope) {
+			continue
+		}
+		for _, text := range translated(b) {
+			// Introductions to lists of runtime data.
+			if !strings.HasSuffix(text, ":") {
+				help = append(help, text)
+			}
+		}
+	}
+	return help
+}
+
+// hasHelpAncestor reports whether the help block is inside another,
+// whose text already includes it.
+func hasHelpAncestor(n, scope *html.Node) bool {
+	for p := n.Parent; p != nil && p != scope; p = p.Parent {
+		if hasClass(p, "help-block") {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidation reports whether the element is only shown when the
+// input is invalid, or is a warning.
+func isValidation(n *html.Node) bool {
+	if hasClass(n, "text-danger") || hasClass(n, "text-warning") {
+		return true
+	}
+	for _, key := range []string{"ng-if", "ng-show"} {
+		cond := attr(n, key)
+		if strings.Contains(cond, "$error") || strings.Contains(cond, "$invalid") || notValidExp.MatchString(cond) {
+			return true
+		}
+	}
+	return false
+}
+
+// notValidExp matches conditions like "!editor.field.$valid".
+var notValidExp = regexp.MustCompile(`!\s*[\w.]+\.\$valid`)
+
+// choices returns the static options of a select.
+func choices(sel *html.Node) []string {
+	var res []string
+	for _, o := range findAll(sel, func(n *html.Node) bool { return n.DataAtom == atom.Option }) {
+		if attr(o, "ng-repeat") != "" {
+			continue
+		}
+		if text := collapse(textOf(o)); text != "" && !strings.Contains(text, "{{") {
+			res = append(res, text)
+		}
+	}
+	return res
+}
+
+// translated returns the translatable texts in the node, in order,
+// leaving out validation messages.
+func translated(n *html.Node) []string {
+	var res []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && isValidation(n) {
+			return
+		}
+		if n.Type == html.ElementNode && hasAttr(n, "translate") {
+			// A sentence continued by runtime data, such as "can be
+			// used as a shortcut for <code>{{system.tilde}}</code>",
+			// makes no sense without it.
+			if text := collapse(textOf(n)); text != "" && !continuedByData(n) {
+				res = append(res, text)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return res
+}
+
+// continuedByData reports whether the element is followed by code
+// showing runtime data.
+func continuedByData(n *html.Node) bool {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s.DataAtom == atom.Code && strings.Contains(textOf(s), "{{")
+		}
+	}
+	return false
+}
+
+func findAll(n *html.Node, match func(*html.Node) bool) []*html.Node {
+	var res []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && match(n) {
+			res = append(res, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return res
+}
+
+func textOf(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
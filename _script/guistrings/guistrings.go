@@ -0,0 +1,129 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./guistrings -tag v1.27.0 > ../includes/gui-strings.rst
+//
+// Reads the catalog of translatable GUI strings, lang-en.json, from the
+// Syncthing source and writes a reference of them for translators: each
+// string's key, its English text, and the templates and scripts using
+// it, linked to the lines in the source at the tag. Strings not found in
+// the templates, such as the theme names, are built by the GUI code.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/stsource"
+)
+
+const (
+	guiDir  = "gui/default"
+	catalog = "gui/default/assets/lang/lang-en.json"
+)
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	flag.Parse()
+	if *tag == "" {
+		fmt.Println("Usage: guistrings [-src <path>] -tag <version>")
+		os.Exit(1)
+	}
+
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	strs, err := readCatalog(filepath.Join(root, filepath.FromSlash(catalog)))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	locs, err := scanGUI(filepath.Join(root, filepath.FromSlash(guiDir)))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	writeStrings(w, *tag, strs, locs)
+	if err := w.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// readCatalog returns the English text of the strings, by key. Nested
+// objects, such as the theme names, have their keys joined with dots as
+// in the GUI code ("theme.name.dark").
+func readCatalog(file string) (map[string]string, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]any
+	if err := json.Unmarshal(bs, &tree); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	strs := make(map[string]string)
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			switch v := v.(type) {
+			case string:
+				strs[prefix+k] = v
+			case map[string]any:
+				flatten(prefix+k+".", v)
+			}
+		}
+	}
+	flatten("", tree)
+	return strs, nil
+}
+
+func writeStrings(w io.Writer, tag string, strs map[string]string, locs map[string][]location) {
+	keys := make([]string, 0, len(strs))
+	for k := range strs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if la, lb := strings.ToLower(keys[a]), strings.ToLower(keys[b]); la != lb {
+			return la < lb
+		}
+		return keys[a] < keys[b]
+	})
+
+	fmt.Fprintf(w, ".. This file is generated by _script/guistrings; do not edit.\n\n")
+	t := rst.Table{
+		Title:  fmt.Sprintf("GUI Strings in %s", tag),
+		Header: []string{"Key", "English", "Used In"},
+		Widths: []int{35, 35, 30},
+	}
+	for _, k := range keys {
+		var used []string
+		seen := make(map[location]bool)
+		for _, l := range locs[k] {
+			if seen[l] {
+				continue
+			}
+			seen[l] = true
+			url := fmt.Sprintf("https://github.com/syncthing/syncthing/blob/%s/%s/%s#L%d", tag, guiDir, l.File, l.Line)
+			used = append(used, rst.Link(fmt.Sprintf("%s:%d", l.File, l.Line), url))
+		}
+		t.Rows = append(t.Rows, []string{rst.Literal(k), rst.Escape(strs[k]), strings.Join(used, ", ")})
+	}
+	t.WriteTo(w)
+}
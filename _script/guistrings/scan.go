@@ -0,0 +1,143 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// location is where a string is used, by file relative to the GUI
+// directory.
+type location struct {
+	File string
+	Line int
+}
+
+// The patterns are those of script/translate.go in the Syncthing source,
+// which builds the catalog.
+var (
+	attrExp     = regexp.MustCompile(`\{\{\s*'([^']+)'\s+\|\s+translate\s*\}\}`)
+	attrCondExp = regexp.MustCompile(`\{\{.+\s+\?\s+'([^']+)'\s+:\s+'([^']+)'\s+\|\s+translate\s*\}\}`)
+	jsExps      = []*regexp.Regexp{
+		regexp.MustCompile(`\$translate\.instant\(\s*"(.+?)"(,.*|\s*)\)`),
+		regexp.MustCompile(`\$translate\.instant\(\s*'(.+?)'(,.*|\s*)\)`),
+	}
+)
+
+// scanGUI returns the locations of the translated strings in the HTML
+// templates and scripts of the GUI, by key.
+func scanGUI(dir string) (map[string][]location, error) {
+	locs := make(map[string][]location)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "vendor" || d.Name() == "lang" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		add := func(key string, line int) {
+			locs[key] = append(locs[key], location{rel, line})
+		}
+		switch filepath.Ext(p) {
+		case ".html":
+			bs, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return scanHTML(bs, add)
+		case ".js":
+			fd, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer fd.Close()
+			return scanJS(fd, add)
+		}
+		return nil
+	})
+	return locs, err
+}
+
+// scanHTML finds the strings in translate elements and attributes, and in
+// translate filters in attribute values.
+func scanHTML(bs []byte, add func(string, int)) error {
+	z := html.NewTokenizer(bytes.NewReader(bs))
+	line := 1
+	translate := false // the next text is to be translated
+	var id string      // the key given in the translate attribute
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() == io.EOF {
+				return nil
+			}
+			return z.Err()
+		}
+		raw := z.Raw()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			translate, id = tok.Data == "translate", ""
+			for _, a := range tok.Attr {
+				if a.Key == "translate" {
+					translate, id = true, a.Val
+					continue
+				}
+				for _, m := range attrExp.FindAllStringSubmatch(a.Val, -1) {
+					add(m[1], line)
+				}
+				for _, m := range attrCondExp.FindAllStringSubmatch(a.Val, -1) {
+					add(m[1], line)
+					add(m[2], line)
+				}
+			}
+		case html.TextToken:
+			if translate {
+				text := string(raw)
+				key := id
+				if key == "" {
+					key = strings.TrimSpace(html.UnescapeString(text))
+				}
+				// The string starts on the first non-blank line.
+				add(key, line+strings.Count(text[:len(text)-len(strings.TrimLeft(text, " \t\r\n"))], "\n"))
+			}
+			translate = false
+		default:
+			translate = false
+		}
+		line += bytes.Count(raw, []byte("\n"))
+	}
+}
+
+func scanJS(r io.Reader, add func(string, int)) error {
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		for _, exp := range jsExps {
+			for _, m := range exp.FindAllStringSubmatch(s.Text(), -1) {
+				add(m[1], line)
+			}
+		}
+	}
+	return s.Err()
+}
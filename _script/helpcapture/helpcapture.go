@@ -0,0 +1,285 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./helpcapture -version v1.27.0 -out ../includes/help
+//
+// Downloads the syncthing, stdiscosrv and strelaysrv release binaries
+// for the version (or builds them from source, with -src) and writes the
+// output of --help for them and their subcommands to text files, for
+// inclusion as literal blocks in the usage pages. The binaries are run
+// with an empty temporary home directory so the output doesn't depend on
+// the machine.
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+	"syncthing.net/docs/internal/stsource"
+)
+
+// programs are captured with their subcommands, down to maxDepth.
+var programs = []string{"syncthing", "stdiscosrv", "strelaysrv"}
+
+const releaseURL = "https://github.com/syncthing/syncthing/releases/download/%[1]s/%[2]s-%[3]s-%[4]s-%[1]s.tar.gz"
+
+func main() {
+	log.SetFlags(0)
+	version := flag.String("version", "", "Syncthing version to capture (required)")
+	out := flag.String("out", "../includes/help", "Directory to write the help output to")
+	binDir := flag.String("bin", "", "Directory with already downloaded or built binaries")
+	src := flag.String("src", "", "Build the binaries from this Syncthing source directory")
+	maxDepth := flag.Int("depth", 3, "How many levels of subcommands to capture")
+	flag.Parse()
+	if *version == "" && *binDir == "" && *src == "" {
+		log.Fatalln("-version is required")
+	}
+
+	work, err := os.MkdirTemp("", "helpcapture")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer os.RemoveAll(work)
+
+	bins := *binDir
+	if bins == "" {
+		bins = filepath.Join(work, "bin")
+		if err := os.Mkdir(bins, 0o755); err != nil {
+			log.Fatalln(err)
+		}
+		if *src != "" {
+			err = buildBinaries(*src, *version, bins)
+		} else {
+			err = downloadBinaries(*version, bins)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalln(err)
+	}
+	home := filepath.Join(work, "home")
+	for _, prog := range programs {
+		c := capturer{bin: filepath.Join(bins, prog), home: home, out: *out, maxDepth: *maxDepth}
+		if err := c.capture([]string{prog}); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+func downloadBinaries(version, dir string) error {
+	if runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
+		return fmt.Errorf("no tar.gz release for %s, use -bin or -src", runtime.GOOS)
+	}
+	for _, prog := range programs {
+		url := fmt.Sprintf(releaseURL, version, prog, runtime.GOOS, runtime.GOARCH)
+		log.Println("downloading", url)
+		resp, err := httpclient.Download.Get(url)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("%s: %s", url, resp.Status)
+		}
+		err = extractBinary(resp.Body, prog, filepath.Join(dir, prog))
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", url, err)
+		}
+	}
+	return nil
+}
+
+// extractBinary writes the program's binary from a release archive,
+// where it's in a directory named after the archive.
+func extractBinary(r io.Reader, prog, dst string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("no %s binary in archive", prog)
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Base(hdr.Name) != prog {
+			continue
+		}
+		fd, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o755)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fd, tr); err != nil {
+			fd.Close()
+			return err
+		}
+		return fd.Close()
+	}
+}
+
+func buildBinaries(src, version, dir string) error {
+	root, cleanup, err := stsource.Open(src, version)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	for _, prog := range programs {
+		// The build script embeds the GUI assets and sets the version,
+		// leaving the binary in the source root.
+		log.Println("building", prog)
+		cmd := exec.Command("go", "run", "build.go", "-no-upgrade", "build", prog)
+		cmd.Dir = root
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("building %s: %w", prog, err)
+		}
+		if err := os.Rename(filepath.Join(root, prog), filepath.Join(dir, prog)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type capturer struct {
+	bin      string
+	home     string
+	out      string
+	maxDepth int
+}
+
+// capture writes the help for the command (the program and its
+// subcommands) and recurses into the subcommands it lists.
+func (c capturer) capture(cmd []string) error {
+	text, err := c.help(cmd[1:])
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(cmd, " "), err)
+	}
+	file := filepath.Join(c.out, strings.Join(cmd, "-")+".txt")
+	if err := os.WriteFile(file, []byte(text), 0o644); err != nil {
+		return err
+	}
+	log.Println("wrote", file)
+
+	if len(cmd) > c.maxDepth {
+		return nil
+	}
+	for _, sub := range subcommands(text) {
+		if err := c.capture(append(cmd[:len(cmd):len(cmd)], sub)); err != nil {
+			// Parts of the cli tree need a running instance; the
+			// rest is still worth having.
+			log.Println("skipping:", err)
+		}
+	}
+	return nil
+}
+
+// help runs the binary with the arguments and --help in an empty home
+// directory and returns the output, with the home directory replaced by
+// ~ in case the defaults mention it.
+func (c capturer) help(args []string) (string, error) {
+	if err := os.RemoveAll(c.home); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(c.home, 0o755); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.bin, append(args, "--help")...)
+	// The usage messages show argv[0].
+	cmd.Args[0] = filepath.Base(c.bin)
+	cmd.Dir = c.home
+	cmd.Env = []string{
+		"HOME=" + c.home,
+		"XDG_CONFIG_HOME=" + filepath.Join(c.home, ".config"),
+		"XDG_STATE_HOME=" + filepath.Join(c.home, ".local", "state"),
+		"XDG_DATA_HOME=" + filepath.Join(c.home, ".local", "share"),
+		"PATH=/usr/bin:/bin",
+		"STNOUPGRADE=1",
+		"STNODEFAULTFOLDER=1",
+		"LANG=C",
+	}
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	if err := cmd.Run(); err != nil {
+		// Programs using the flag package exit non-zero after printing
+		// the help, but a subcommand failing is an error.
+		if len(args) > 0 || buf.Len() == 0 || ctx.Err() != nil {
+			return "", err
+		}
+	}
+	lines := strings.Split(strings.ReplaceAll(buf.String(), c.home, "~"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n", nil
+}
+
+// subcommands returns the commands listed in help output, both in the
+// format of the urfave/cli based tools ("COMMANDS:") and of the kong
+// based main command ("Commands:", with the descriptions on separate,
+// further indented lines).
+func subcommands(text string) []string {
+	var cmds []string
+	inCommands := false
+	indent := -1
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for sc.Scan() {
+		line := sc.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "COMMANDS:" || trimmed == "Commands:":
+			inCommands = true
+			indent = -1
+			continue
+		case !inCommands || trimmed == "":
+			continue
+		case !strings.HasPrefix(line, " "):
+			inCommands = false
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent < 0 {
+			indent = lineIndent
+		}
+		if lineIndent != indent {
+			continue
+		}
+		name := strings.TrimSuffix(strings.Fields(trimmed)[0], ",")
+		if name == "help" || name == "h" || strings.HasPrefix(name, "-") {
+			continue
+		}
+		cmds = append(cmds, name)
+	}
+	return cmds
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+)
+
+var androidWrapper = wrapper{bundle: androidBundle}
+
+// The core is packaged as a native library, once per ABI. They're all
+// built from the same source with the same toolchain; we prefer arm64 as
+// the most common one today.
+var androidABIs = []string{"arm64-v8a", "armeabi-v7a", "x86_64", "x86"}
+
+// androidBundle returns the core binary from the release APK.
+func androidBundle(assets []*github.ReleaseAsset) (*bundle, error) {
+	for _, asset := range assets {
+		if !strings.HasSuffix(strings.ToLower(*asset.Name), ".apk") {
+			continue
+		}
+		bs, err := download(asset)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(bytes.NewReader(bs), int64(len(bs)))
+		if err != nil {
+			return nil, err
+		}
+		bin, err := apkBinary(zr)
+		if err != nil {
+			return nil, err
+		}
+		b := &bundle{binary: bin}
+		if b.version, err = apkVersion(zr); err != nil {
+			log.Printf("%s: %v", asset.GetName(), err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("no APK asset found")
+}
+
+// apkVersion returns the versionName declared by the APK's manifest.
+func apkVersion(zr *zip.Reader) (string, error) {
+	for _, f := range zr.File {
+		if f.Name != "AndroidManifest.xml" {
+			continue
+		}
+		rd, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rd.Close()
+		bs, err := io.ReadAll(rd)
+		if err != nil {
+			return "", err
+		}
+		return axmlAttribute(bs, "manifest", "versionName")
+	}
+	return "", fmt.Errorf("no AndroidManifest.xml")
+}
+
+func apkBinary(zr *zip.Reader) ([]byte, error) {
+	libs := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		// lib/<abi>/libsyncthing.so, or libsyncthingnative.so in newer
+		// releases
+		parts := strings.Split(f.Name, "/")
+		if len(parts) != 3 || parts[0] != "lib" || !strings.HasPrefix(parts[2], "libsyncthing") || !strings.HasSuffix(parts[2], ".so") {
+			continue
+		}
+		libs[parts[1]] = f
+	}
+	for _, abi := range androidABIs {
+		f, ok := libs[abi]
+		if !ok {
+			continue
+		}
+		rd, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rd.Close()
+		return io.ReadAll(rd)
+	}
+	return nil, fmt.Errorf("no bundled syncthing library found")
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// releaseNotesURL is where the notes of a release are.
+const releaseNotesURL = "https://github.com/syncthing/syncthing/releases/tag/"
+
+// renderAnnouncement writes the table pasted into release announcements
+// on the forum and GitHub, in Markdown or, with bbcode, BBCode: the
+// newest release, or Items releases, with a link to the release notes.
+func renderAnnouncement(w io.Writer, out outputConfig, rows []*tableRow, bbcode bool) error {
+	items := out.Items
+	if items == 0 {
+		items = 1
+	}
+	if items > len(rows) {
+		items = len(rows)
+	}
+	if items == 0 {
+		return fmt.Errorf("no versions")
+	}
+
+	header := []string{out.label("Version"), out.label("Date"), out.label("Runtime"), out.label("Release Notes")}
+	var sb strings.Builder
+	if bbcode {
+		sb.WriteString("[table]\n")
+		sb.WriteString("[tr][th]" + strings.Join(header, "[/th][th]") + "[/th][/tr]\n")
+	} else {
+		sb.WriteString("| " + strings.Join(header, " | ") + " |\n")
+		sb.WriteString(strings.Repeat("| --- ", len(header)) + "|\n")
+	}
+	for _, r := range rows[:items] {
+		url := releaseNotesURL + r.Version
+		if bbcode {
+			link := fmt.Sprintf("[url=%s]%s[/url]", url, r.Version)
+			sb.WriteString("[tr][td]" + strings.Join([]string{r.Version, out.formatDate(r.Date), r.Runtime, link}, "[/td][td]") + "[/td][/tr]\n")
+		} else {
+			link := fmt.Sprintf("[%s](%s)", r.Version, url)
+			sb.WriteString("| " + strings.Join([]string{r.Version, out.formatDate(r.Date), r.Runtime, link}, " | ") + " |\n")
+		}
+	}
+	if bbcode {
+		sb.WriteString("[/table]\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/discourse"
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// The releases were announced in this forum category, by its slug, with
+// the version in the topic title.
+const (
+	announceSourceForum = "forum"
+	announceCategory    = "announce/releases"
+)
+
+// announcement is a release announcement found in a forum or feed.
+type announcement struct {
+	Version string
+	Date    string
+	// Source is the link to the announcement.
+	Source string
+}
+
+// announceVersionExp finds the version in an announcement title, such as
+// "Syncthing v0.10.5" or "0.9.0 released". Release candidates, with a
+// suffix, aren't releases.
+var announceVersionExp = regexp.MustCompile(`\bv?(\d+\.\d+(?:\.\d+)?)(-[\w.]+)?\b`)
+
+func announcedVersion(title string) (string, bool) {
+	m := announceVersionExp.FindStringSubmatch(title)
+	if m == nil || m[2] != "" {
+		return "", false
+	}
+	return "v" + m[1], true
+}
+
+// backfillDates sets the dates of the rows of releases that predate the
+// GitHub release metadata, having no GitHub release, to the date they
+// were first announced in any of the sources: "forum", or the URL or
+// file of an RSS or Atom feed, such as an archived copy of an old one.
+// Rows maintained by hand are left alone.
+func backfillDates(rows []*tableRow, releases []*github.RepositoryRelease, sources []string, audit *auditLog) error {
+	onGitHub := make(map[string]bool, len(releases))
+	for _, rel := range releases {
+		onGitHub[versionKey(rel.GetTagName())] = true
+	}
+
+	first := make(map[string]announcement)
+	for _, src := range sources {
+		var anns []announcement
+		var err error
+		if src == announceSourceForum {
+			anns, err = forumAnnouncements(context.Background())
+		} else {
+			anns, err = feedAnnouncements(src)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		for _, a := range anns {
+			key := versionKey(a.Version)
+			if cur, ok := first[key]; !ok || a.Date < cur.Date {
+				first[key] = a
+			}
+		}
+	}
+
+	for _, r := range rows {
+		key := versionKey(r.Version)
+		a, ok := first[key]
+		if !ok || r.Manual || onGitHub[key] || a.Date == r.Date {
+			continue
+		}
+		log.Printf("%s: date %s from the announcement %s, was %q", r.Version, a.Date, a.Source, r.Date)
+		r.Date = a.Date
+		audit.record(r.Version, "Date", a.Date, methodAnnounce, a.Source)
+	}
+	return nil
+}
+
+// versionKey returns the version with three parts, so that v0.2 matches
+// v0.2.0.
+func versionKey(v string) string {
+	parts, ok := parseVersion(v)
+	if !ok {
+		return v
+	}
+	for len(parts) < 3 {
+		parts = append(parts, 0)
+	}
+	return fmt.Sprintf("v%d.%d.%d", parts[0], parts[1], parts[2])
+}
+
+// forumAnnouncements returns the release announcements in the forum
+// category, dated by the creation of their topic.
+func forumAnnouncements(ctx context.Context) ([]announcement, error) {
+	client := discourse.NewClient(discourse.DefaultURL)
+	topics, err := client.CategoryTopics(ctx, announceCategory, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	var res []announcement
+	for _, t := range topics {
+		if v, ok := announcedVersion(t.Title); ok {
+			res = append(res, announcement{v, t.CreatedAt.UTC().Format(dateLayout), client.TopicURL(t.ID)})
+		}
+	}
+	return res, nil
+}
+
+// feed is an RSS or Atom feed; only one of Items and Entries is set.
+type feed struct {
+	Items   []feedItem `xml:"channel>item"`
+	Entries []feedItem `xml:"entry"`
+}
+
+type feedItem struct {
+	Title string `xml:"title"`
+	// Link is the text of an RSS link, or the href of an Atom one.
+	Link struct {
+		Href string `xml:"href,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"link"`
+	PubDate   string `xml:"pubDate"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+var feedDateLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339}
+
+// feedAnnouncements returns the release announcements in the feed at the
+// URL or in the file.
+func feedAnnouncements(src string) ([]announcement, error) {
+	bs, err := readFeed(src)
+	if err != nil {
+		return nil, err
+	}
+	var f feed
+	if err := xml.Unmarshal(bs, &f); err != nil {
+		return nil, err
+	}
+	var res []announcement
+	for _, it := range append(f.Items, f.Entries...) {
+		v, ok := announcedVersion(it.Title)
+		if !ok {
+			continue
+		}
+		date := it.PubDate
+		if date == "" {
+			date = it.Published
+		}
+		if date == "" {
+			date = it.Updated
+		}
+		var t time.Time
+		for _, layout := range feedDateLayouts {
+			if t, err = time.Parse(layout, strings.TrimSpace(date)); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			log.Printf("%s: %q: unknown date %q", src, it.Title, date)
+			continue
+		}
+		link := strings.TrimSpace(it.Link.Text)
+		if link == "" {
+			link = it.Link.Href
+		}
+		if link == "" {
+			link = src
+		}
+		res = append(res, announcement{v, t.UTC().Format(dateLayout), link})
+	}
+	return res, nil
+}
+
+func readFeed(src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.ReadFile(src)
+	}
+	resp, err := httpclient.Default.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// fillAPIAdded sets the REST endpoints added in each minor release, by
+// checking out the release tags in the source directory (a git clone) in
+// turn and comparing the endpoints with the previous minor release's.
+// Only minor releases add endpoints. The oldest minor release, and those
+// before the API code is where stsource looks for it, have nothing to
+// compare with and are left empty. What was checked out is restored
+// afterwards.
+func fillAPIAdded(rows []*tableRow, src string, audit *auditLog) error {
+	var minors []*tableRow
+	for _, r := range rows {
+		if v, ok := parseVersion(r.Version); ok && len(v) >= 3 && v[2] == 0 {
+			minors = append(minors, r)
+		}
+	}
+	sort.Slice(minors, func(a, b int) bool { return compareVersions(minors[a].Version, minors[b].Version) < 0 })
+
+	head, err := stsource.Head(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if _, _, err := stsource.Open(src, head); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	var prev map[string]bool
+	for _, r := range minors {
+		root, _, err := stsource.Open(src, r.Version)
+		if err != nil {
+			return err
+		}
+		paths, err := stsource.Endpoints(root)
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.Version, err)
+		}
+		cur := make(map[string]bool, len(paths))
+		for _, p := range paths {
+			cur[p] = true
+		}
+		var added []string
+		for p := range cur {
+			if len(prev) > 0 && !prev[p] {
+				added = append(added, p)
+			}
+		}
+		sort.Strings(added)
+		if s := strings.Join(added, " "); s != r.APIAdded {
+			r.APIAdded = s
+			audit.record(r.Version, "APIAdded", s, methodSource, r.Version)
+		}
+		prev = cur
+	}
+	return nil
+}
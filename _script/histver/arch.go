@@ -0,0 +1,188 @@
+package main
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// assetArches are the names the release assets use for each Go
+// architecture, in order of preference. ARM builds have been named by the
+// ARM version as well as by GOARCH, and the macOS builds are universal
+// binaries, which contain both amd64 and arm64.
+var assetArches = map[string][]string{
+	"amd64":   {"amd64", "universal"},
+	"arm64":   {"arm64", "aarch64", "universal"},
+	"arm":     {"arm", "armv7", "armv6", "armv5"},
+	"386":     {"386"},
+	"riscv64": {"riscv64"},
+}
+
+// defaultArches is the order in which the architectures are tried after
+// the host's, and the ones the arches audit covers.
+var defaultArches = []string{"amd64", "arm64", "arm", "386", "riscv64"}
+
+// archPreference returns the architectures to derive rows from, in order:
+// those given (comma separated) or else the host's followed by the
+// defaults.
+func archPreference(list string) ([]string, error) {
+	if list != "" {
+		arches := strings.Split(list, ",")
+		for _, a := range arches {
+			if _, ok := assetArches[a]; !ok {
+				return nil, fmt.Errorf("unknown architecture %q", a)
+			}
+		}
+		return arches, nil
+	}
+	arches := []string{runtime.GOARCH}
+	for _, a := range defaultArches {
+		if a != runtime.GOARCH {
+			arches = append(arches, a)
+		}
+	}
+	return arches, nil
+}
+
+// archPrefs are the architectures getReleaseVersion looks for, set by the
+// -arch flag.
+var archPrefs, _ = archPreference("")
+
+// assetOS returns the name the release assets use for the operating
+// system.
+func assetOS(goos string) string {
+	if goos == "darwin" {
+		return "macos"
+	}
+	return goos
+}
+
+// archAssets returns the assets of the release for the architecture,
+// trying its asset names in order, along with the name that matched.
+func archAssets(rel *github.RepositoryRelease, goos, goarch string) ([]*github.ReleaseAsset, string) {
+	for _, name := range assetArches[goarch] {
+		if assets := releaseAssets(defaultAssetRepo, rel, goos, name); len(assets) > 0 {
+			return assets, name
+		}
+	}
+	return nil, ""
+}
+
+// preferredAsset returns the first asset of the release for the
+// architectures in order of preference.
+func preferredAsset(rel *github.RepositoryRelease, goos string, arches []string) (*github.ReleaseAsset, error) {
+	for _, goarch := range arches {
+		if assets, _ := archAssets(rel, goos, goarch); len(assets) > 0 {
+			return assets[0], nil
+		}
+	}
+	return nil, fmt.Errorf("no asset for %s-{%s} matches the asset patterns", goos, strings.Join(arches, ","))
+}
+
+// buildArch returns the architecture the build info says the binary was
+// built for, with the ARM version if set, as in arm/v7.
+func buildArch(info *buildinfo.BuildInfo) string {
+	var goarch, goarm string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "GOARCH":
+			goarch = s.Value
+		case "GOARM":
+			goarm = s.Value
+		}
+	}
+	if goarch == "arm" && goarm != "" {
+		return goarch + "/v" + goarm
+	}
+	return goarch
+}
+
+// auditReleases returns the releases with the given versions, or in the
+// given ranges written as from..to (inclusive, either end may be left
+// out), or the latest release when none are given.
+func auditReleases(releases []*github.RepositoryRelease, versions []string) ([]*github.RepositoryRelease, error) {
+	if len(versions) == 0 {
+		if len(releases) == 0 {
+			return nil, fmt.Errorf("no releases")
+		}
+		return releases[:1], nil
+	}
+	var res []*github.RepositoryRelease
+	for _, v := range versions {
+		from, to, isRange := strings.Cut(v, "..")
+		if !isRange {
+			to = from
+		}
+		n := len(res)
+		for _, rel := range releases {
+			tag := rel.GetTagName()
+			if (from == "" || compareVersions(tag, from) >= 0) && (to == "" || compareVersions(tag, to) <= 0) {
+				res = append(res, rel)
+			}
+		}
+		if len(res) == n {
+			return nil, fmt.Errorf("no release matches %s", v)
+		}
+	}
+	return res, nil
+}
+
+// auditArches checks, for each architecture, that the release has an
+// asset for the operating system, that the syncthing binary can be
+// extracted from it, and that the binary was built for the architecture
+// and reports the release's version. It returns the problems found,
+// after printing the result for each architecture.
+func auditArches(rel *github.RepositoryRelease, goos string, arches []string) []string {
+	var problems []string
+	for _, goarch := range arches {
+		res, err := auditArch(rel, goos, goarch)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s-%s: %v", rel.GetTagName(), goos, goarch, err))
+			continue
+		}
+		fmt.Printf("%s: %s-%s: %s\n", rel.GetTagName(), goos, goarch, res)
+	}
+	return problems
+}
+
+func auditArch(rel *github.RepositoryRelease, goos, goarch string) (string, error) {
+	assets, name := archAssets(rel, goos, goarch)
+	if len(assets) == 0 {
+		return "", fmt.Errorf("no asset matches the asset patterns")
+	}
+	data, err := fetchAsset(assets[0], archiveBudget)
+	if err != nil {
+		return "", err
+	}
+	defer data.Close()
+	spooled, err := archiveBinary(data, data.size)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", assets[0].GetName(), err)
+	}
+	defer spooled.Close()
+	bin := spooled.section()
+	if name == "universal" {
+		bin, err = thinMachOArch(bin, goarch)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", assets[0].GetName(), err)
+		}
+	}
+	info, err := buildinfo.Read(bin)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", assets[0].GetName(), err)
+	}
+	if arch := buildArch(info); strings.Split(arch, "/")[0] != goarch {
+		return "", fmt.Errorf("%s: binary is built for %s", assets[0].GetName(), arch)
+	}
+	version, goVersion, err := buildInfoVersion(bin)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", assets[0].GetName(), err)
+	}
+	if version != rel.GetTagName() {
+		return "", fmt.Errorf("%s: binary reports %s", assets[0].GetName(), version)
+	}
+	return fmt.Sprintf("%s, %s (%s, %s)", assets[0].GetName(), buildArch(info), version, goVersion), nil
+}
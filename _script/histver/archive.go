@@ -0,0 +1,214 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+var (
+	magicZip   = []byte("PK\x03\x04")
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicBzip2 = []byte("BZh")
+	magicXz    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	magicZstd  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// getReleaseVersionArchive inspects a release archive of any supported
+// kind, for the release with the tag.
+func getReleaseVersionArchive(ra io.ReaderAt, size int64, tag string) (*tableRow, error) {
+	bin, err := archiveBinary(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	defer bin.Close()
+	return binaryRow(bin.section(), tag)
+}
+
+// archiveBinary returns the syncthing binary from a release archive of any
+// supported kind. The kind is detected from the contents as file
+// extensions aren't reliable across artifact sources.
+//
+// The binary is recognized by the main package path in its build info,
+// whatever it's called and wherever it is in the archive, so that other
+// binaries and debug symbols bundled with it are told apart. Among
+// several, the one at the best location by name is preferred. Binaries
+// too old to have build info are recognized by name only.
+//
+// Candidates are spooled to disk when they don't fit in archiveBudget.
+// The returned binary must be closed.
+func archiveBinary(ra io.ReaderAt, size int64) (*assetData, error) {
+	var best, named *assetData
+	bestRank, namedRank := -1, -1
+	err := walkArchive(ra, size, func(name string, size int64, r io.Reader) error {
+		nameRank := binaryRank(name)
+		var head [4]byte
+		n, _ := io.ReadFull(r, head[:])
+		if !isExecutable(head[:n]) {
+			return nil
+		}
+		data, err := spoolAsset(io.MultiReader(bytes.NewReader(head[:n]), r), size, archiveBudget)
+		if err != nil {
+			return err
+		}
+		if syncthingBuildInfo(data.section()) != nil {
+			rank := 1000
+			if nameRank >= 0 {
+				rank = nameRank
+			}
+			if bestRank < 0 || rank < bestRank {
+				best.Close()
+				best, bestRank = data, rank
+				return nil
+			}
+		} else if nameRank >= 0 && (namedRank < 0 || nameRank < namedRank) {
+			named.Close()
+			named, namedRank = data, nameRank
+			return nil
+		}
+		data.Close()
+		return nil
+	})
+	switch {
+	case err != nil:
+		best.Close()
+		named.Close()
+		return nil, err
+	case best != nil:
+		named.Close()
+		return best, nil
+	case named != nil:
+		return named, nil
+	default:
+		return nil, fmt.Errorf("no syncthing binary found")
+	}
+}
+
+// walkArchive calls fn for each regular file in the zip or (possibly
+// compressed) tar archive, with its size, until it returns an error.
+func walkArchive(ra io.ReaderAt, size int64, fn func(name string, size int64, r io.Reader) error) error {
+	if !bytes.HasPrefix(readHead(ra), magicZip) {
+		var fnErr error
+		err := walkTar(ra, size, func(hdr *tar.Header, r io.Reader) bool {
+			fnErr = fn(hdr.Name, hdr.Size, r)
+			return fnErr == nil
+		})
+		if err != nil {
+			return err
+		}
+		return fnErr
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rd, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = fn(f.Name, int64(f.UncompressedSize64), rd)
+		rd.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readHead returns the first bytes of the contents, enough to tell the
+// archive and compression formats apart.
+func readHead(ra io.ReaderAt) []byte {
+	head := make([]byte, 8)
+	n, _ := ra.ReadAt(head, 0)
+	return head[:n]
+}
+
+// decompressor returns a reader for the decompressed contents, which are
+// returned as they are when not in a recognized compression format.
+func decompressor(ra io.ReaderAt, size int64) (io.ReadCloser, error) {
+	bs := readHead(ra)
+	br := io.NewSectionReader(ra, 0, size)
+	switch {
+	case bytes.HasPrefix(bs, magicGzip):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(bs, magicBzip2):
+		return io.NopCloser(bzip2.NewReader(br)), nil
+	case bytes.HasPrefix(bs, magicXz):
+		xr, err := xz.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case bytes.HasPrefix(bs, magicZstd):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+// walkTar calls fn for each regular file in the (possibly compressed)
+// archive, until fn returns false.
+func walkTar(ra io.ReaderAt, size int64, fn func(*tar.Header, io.Reader) bool) error {
+	dr, err := decompressor(ra, size)
+	if err != nil {
+		return err
+	}
+	defer dr.Close()
+	tr := tar.NewReader(dr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !fn(hdr, tr) {
+			return nil
+		}
+	}
+}
+
+// binaryRank returns how well an archive member name matches the expected
+// location of the syncthing binary, lower being better, or -1 if it's not
+// a syncthing binary at all. Releases put the binary (syncthing or
+// syncthing.exe) in a single versioned directory, so that is preferred
+// over a top level binary, which in turn is preferred over anything nested
+// deeper.
+func binaryRank(name string) int {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	base := path.Base(name)
+	if base != "syncthing" && !strings.EqualFold(base, "syncthing.exe") {
+		return -1
+	}
+	switch depth := strings.Count(name, "/"); depth {
+	case 1:
+		return 0
+	case 0:
+		return 1
+	default:
+		return depth
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// auditRelease downloads every platform asset of the release and returns
+// the problems with them: assets that can't be read, whose binary has no
+// build info, or whose version or Go version disagree with the row (nil
+// when the release isn't in the table). Unlike the -deep inspection
+// nothing is cached or skipped, so that each asset as published now is
+// checked.
+func (i *assetInspector) auditRelease(rel *github.RepositoryRelease, row *tableRow) []string {
+	tag := rel.GetTagName()
+	var problems []string
+	if row == nil {
+		problems = append(problems, fmt.Sprintf("%s: not in the table", tag))
+	}
+
+	var queue []*github.ReleaseAsset
+	for _, asset := range releaseAssets(i.owner+"/"+i.repo, rel, "*", "*") {
+		name := asset.GetName()
+		switch {
+		case strings.Contains(name, "-source-"):
+		case !isBinaryArchive(name):
+			problems = append(problems, fmt.Sprintf("%s: %s: unreadable: not an archive format we read", tag, name))
+		default:
+			queue = append(queue, asset)
+		}
+	}
+	if len(queue) == 0 {
+		return append(problems, fmt.Sprintf("%s: no platform assets", tag))
+	}
+
+	for dl := range i.downloads(queue) {
+		if dl.err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s: download failed: %v", tag, dl.asset.GetName(), dl.err))
+			continue
+		}
+		problem := auditAsset(dl.data, tag, row)
+		dl.data.Close()
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s: %s", tag, dl.asset.GetName(), problem))
+		} else {
+			log.Printf("%s: %s: ok", tag, dl.asset.GetName())
+		}
+	}
+	return problems
+}
+
+// auditAsset returns what's wrong with a downloaded asset, or the empty
+// string.
+func auditAsset(data *assetData, tag string, row *tableRow) string {
+	bin, err := archiveBinary(data, data.size)
+	if err != nil {
+		return "unreadable: " + err.Error()
+	}
+	defer bin.Close()
+	if syncthingBuildInfo(bin.section()) == nil {
+		return "no build info"
+	}
+	version, goVersion, err := buildInfoVersion(bin.section())
+	switch {
+	case err != nil:
+		return "unreadable build info: " + err.Error()
+	case version != tag:
+		return fmt.Sprintf("binary is %s", version)
+	case row != nil && goVersion != row.Runtime:
+		return fmt.Sprintf("built with %s, table says %s", goVersion, row.Runtime)
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// assetPattern selects the release assets to inspect, for the releases of
+// a repository in a version range.
+type assetPattern struct {
+	// Repo is the repository as owner/name; the default is
+	// syncthing/syncthing.
+	Repo string `json:"repo"`
+	// From and To are the oldest and newest versions the patterns apply
+	// to, inclusive. Either can be left out.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Patterns are shell patterns (as for path.Match) for the asset
+	// names, where {os} and {arch} are replaced by the platform to look
+	// for, as in syncthing-{os}-{arch}-*. An asset matching any of them
+	// is selected.
+	Patterns []string `json:"patterns"`
+}
+
+const defaultAssetRepo = "syncthing/syncthing"
+
+// defaultAssetPatterns are used after the configured ones. The wrapper
+// repositories have none; their assets are selected by type.
+var defaultAssetPatterns = []assetPattern{
+	{Repo: defaultAssetRepo, Patterns: []string{"syncthing-{os}-{arch}-*"}},
+}
+
+// assetPatterns are the configured patterns followed by the defaults.
+var assetPatterns = defaultAssetPatterns
+
+func setAssetPatterns(pats []assetPattern) error {
+	for i, p := range pats {
+		if len(p.Patterns) == 0 {
+			return fmt.Errorf("asset pattern %d: no patterns", i+1)
+		}
+		for _, s := range p.Patterns {
+			if _, err := path.Match(s, ""); err != nil {
+				return fmt.Errorf("asset pattern %q: %w", s, err)
+			}
+		}
+		for _, v := range []string{p.From, p.To} {
+			if _, ok := parseVersion(v); v != "" && !ok {
+				return fmt.Errorf("asset pattern %d: %q is not a valid version", i+1, v)
+			}
+		}
+	}
+	assetPatterns = append(pats[:len(pats):len(pats)], defaultAssetPatterns...)
+	return nil
+}
+
+func (p assetPattern) appliesTo(repo, version string) bool {
+	r := p.Repo
+	if r == "" {
+		r = defaultAssetRepo
+	}
+	if r != repo {
+		return false
+	}
+	if p.From != "" && compareVersions(version, p.From) < 0 {
+		return false
+	}
+	if p.To != "" && compareVersions(version, p.To) > 0 {
+		return false
+	}
+	return true
+}
+
+// releaseAssets returns the assets of the release of the repository that
+// match the first asset pattern applying to it, for the platform given by
+// goos and goarch (which may be * for any). When no pattern applies all
+// assets are returned.
+func releaseAssets(repo string, rel *github.RepositoryRelease, goos, goarch string) []*github.ReleaseAsset {
+	for _, p := range assetPatterns {
+		if !p.appliesTo(repo, rel.GetTagName()) {
+			continue
+		}
+		var res []*github.ReleaseAsset
+		for _, asset := range rel.Assets {
+			for _, s := range p.Patterns {
+				s = strings.NewReplacer("{os}", goos, "{arch}", goarch).Replace(s)
+				if ok, _ := path.Match(s, asset.GetName()); ok {
+					res = append(res, asset)
+					break
+				}
+			}
+		}
+		return res
+	}
+	return rel.Assets
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// How a field was derived, in the audit log.
+const (
+	methodBinary    = "binary"    // the build info or strings of the release binary
+	methodGitHub    = "github"    // the GitHub release metadata
+	methodGoMod     = "gomod"     // the go.mod at the release tag
+	methodGoHistory = "gohistory" // the Go release history
+	methodAnnounce  = "announce"  // a release announcement on the forum or in a feed
+	methodSource    = "source"    // the Syncthing source at the release tag
+	methodSumDB     = "sumdb"     // the Go checksum database
+	methodNormal    = "normalize" // rewritten into the canonical format
+	methodRepaired  = "repair"    // replaced, being broken by an older version of this tool
+)
+
+// provenance records where the value of a field came from.
+type provenance struct {
+	Field  string `json:"field"`
+	Value  string `json:"value"`
+	Method string `json:"method"`
+	// Source is the asset, URL or metadata field the value was taken
+	// from.
+	Source string `json:"source"`
+	Time   string `json:"time"`
+}
+
+// auditLog is the provenance of the table's fields, by version, oldest
+// record first. It's kept in a sidecar file and only added to, so that the
+// history of a value can be followed. A nil log records nothing.
+type auditLog struct {
+	file    string
+	records map[string][]provenance
+}
+
+func loadAuditLog(file string) (*auditLog, error) {
+	a := &auditLog{file: file, records: make(map[string][]provenance)}
+	bs, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return a, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bs, &a.records); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return a, nil
+}
+
+func (a *auditLog) record(version, field, value, method, source string) {
+	if a == nil {
+		return
+	}
+	a.records[version] = append(a.records[version], provenance{
+		Field:  field,
+		Value:  value,
+		Method: method,
+		Source: source,
+		Time:   time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// save writes the log, versions in table order.
+func (a *auditLog) save() error {
+	if a == nil {
+		return nil
+	}
+	versions := make([]string, 0, len(a.records))
+	for v := range a.records {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) > 0 })
+
+	// Written by hand to keep the order; a map would be sorted as
+	// strings.
+	fd, err := os.Create(a.file)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(fd, "{\n")
+	for i, v := range versions {
+		key, _ := json.Marshal(v)
+		recs, err := json.MarshalIndent(a.records[v], "  ", "  ")
+		if err != nil {
+			fd.Close()
+			return err
+		}
+		sep := ","
+		if i == len(versions)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(fd, "  %s: %s%s\n", key, recs, sep)
+	}
+	fmt.Fprint(fd, "}\n")
+	return fd.Close()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Chunk types of Android's binary XML, as compiled into APKs.
+const (
+	axmlStringPool   = 0x0001
+	axmlXML          = 0x0003
+	axmlStartElement = 0x0102
+)
+
+// axmlTypeString is the type of a typed attribute value that's a string
+// in the pool; others, such as references to resources, can't be read
+// without the resource table.
+const axmlTypeString = 0x03
+
+// axmlAttribute returns the string value of the attribute of the first
+// element with the name in a binary XML document, such as versionName of
+// the manifest element in AndroidManifest.xml. It implements just enough
+// of the format to find it.
+func axmlAttribute(bs []byte, element, attr string) (string, error) {
+	le := binary.LittleEndian
+	if len(bs) < 8 || le.Uint16(bs) != axmlXML {
+		return "", fmt.Errorf("binary XML: bad header")
+	}
+	var pool []string
+	for off := int(le.Uint16(bs[2:])); off+8 <= len(bs); {
+		typ, hdr, size := le.Uint16(bs[off:]), int(le.Uint16(bs[off+2:])), int(le.Uint32(bs[off+4:]))
+		if size < 8 || off+size > len(bs) {
+			return "", fmt.Errorf("binary XML: truncated chunk at %d", off)
+		}
+		chunk := bs[off : off+size]
+		switch typ {
+		case axmlStringPool:
+			var err error
+			if pool, err = axmlStrings(chunk); err != nil {
+				return "", err
+			}
+		case axmlStartElement:
+			// The element's name, its attributes' start and size, and
+			// count follow the header and the namespace.
+			if hdr+20 > len(chunk) {
+				return "", fmt.Errorf("binary XML: truncated element at %d", off)
+			}
+			ext := chunk[hdr:]
+			if poolString(pool, le.Uint32(ext[4:])) != element {
+				break
+			}
+			start, asize, count := int(le.Uint16(ext[8:])), int(le.Uint16(ext[10:])), int(le.Uint16(ext[12:]))
+			for i := 0; i < count; i++ {
+				a := hdr + start + i*asize
+				if asize < 20 || a+20 > len(chunk) {
+					return "", fmt.Errorf("binary XML: truncated attribute at %d", off)
+				}
+				if poolString(pool, le.Uint32(chunk[a+4:])) != attr {
+					continue
+				}
+				// The raw value is the string, if the attribute has one;
+				// otherwise the typed value may be.
+				if raw := le.Uint32(chunk[a+8:]); raw != 0xffffffff {
+					return poolString(pool, raw), nil
+				}
+				if chunk[a+15] == axmlTypeString {
+					return poolString(pool, le.Uint32(chunk[a+16:])), nil
+				}
+				return "", fmt.Errorf("binary XML: %s of %s is not a string", attr, element)
+			}
+			return "", fmt.Errorf("binary XML: no %s attribute on %s", attr, element)
+		}
+		off += size
+	}
+	return "", fmt.Errorf("binary XML: no %s element", element)
+}
+
+// axmlStrings returns the strings of a string pool chunk, which are UTF-8
+// or UTF-16 by a flag.
+func axmlStrings(chunk []byte) ([]string, error) {
+	le := binary.LittleEndian
+	if len(chunk) < 28 {
+		return nil, fmt.Errorf("binary XML: truncated string pool")
+	}
+	hdr, count, flags, start := int(le.Uint16(chunk[2:])), int(le.Uint32(chunk[8:])), le.Uint32(chunk[16:]), int(le.Uint32(chunk[20:]))
+	utf8 := flags&(1<<8) != 0
+	if hdr+4*count > len(chunk) {
+		return nil, fmt.Errorf("binary XML: truncated string pool")
+	}
+	res := make([]string, count)
+	for i := range res {
+		p := start + int(le.Uint32(chunk[hdr+4*i:]))
+		var s string
+		var ok bool
+		if utf8 {
+			s, ok = axmlUTF8(chunk, p)
+		} else {
+			s, ok = axmlUTF16(chunk, p)
+		}
+		if !ok {
+			return nil, fmt.Errorf("binary XML: string %d out of bounds", i)
+		}
+		res[i] = s
+	}
+	return res, nil
+}
+
+// axmlUTF8 reads a UTF-8 string: its length in characters and then in
+// bytes, each in one or two bytes, and the bytes.
+func axmlUTF8(bs []byte, p int) (string, bool) {
+	length := func() (int, bool) {
+		if p >= len(bs) {
+			return 0, false
+		}
+		n := int(bs[p])
+		p++
+		if n&0x80 != 0 {
+			if p >= len(bs) {
+				return 0, false
+			}
+			n = (n&0x7f)<<8 | int(bs[p])
+			p++
+		}
+		return n, true
+	}
+	if _, ok := length(); !ok {
+		return "", false
+	}
+	n, ok := length()
+	if !ok || p+n > len(bs) {
+		return "", false
+	}
+	return string(bs[p : p+n]), true
+}
+
+// axmlUTF16 reads a UTF-16 string: its length in code units, in one or
+// two of them, and the code units.
+func axmlUTF16(bs []byte, p int) (string, bool) {
+	le := binary.LittleEndian
+	if p+2 > len(bs) {
+		return "", false
+	}
+	n := int(le.Uint16(bs[p:]))
+	p += 2
+	if n&0x8000 != 0 {
+		if p+2 > len(bs) {
+			return "", false
+		}
+		n = (n&0x7fff)<<16 | int(le.Uint16(bs[p:]))
+		p += 2
+	}
+	if p+2*n > len(bs) {
+		return "", false
+	}
+	units := make([]uint16, n)
+	for i := range units {
+		units[i] = le.Uint16(bs[p+2*i:])
+	}
+	return string(utf16.Decode(units)), true
+}
+
+func poolString(pool []string, i uint32) string {
+	if int(i) < len(pool) && i != 0xffffffff {
+		return pool[i]
+	}
+	return ""
+}
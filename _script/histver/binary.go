@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Binaries are read through an io.SectionReader, so that they can be
+// spooled to disk rather than held in memory.
+
+// bytesSection returns a reader of a binary held in memory.
+func bytesSection(bs []byte) *io.SectionReader {
+	return io.NewSectionReader(bytes.NewReader(bs), 0, int64(len(bs)))
+}
+
+// thinMachO returns a single architecture slice out of a universal ("fat")
+// Mach-O binary, preferring the host architecture as the asset selection
+// does. Anything that isn't a fat binary is returned unchanged.
+func thinMachO(bin *io.SectionReader) (*io.SectionReader, error) {
+	return thinMachOArch(bin, runtime.GOARCH)
+}
+
+// thinMachOArch is thinMachO preferring the given architecture.
+func thinMachOArch(bin *io.SectionReader, goarch string) (*io.SectionReader, error) {
+	var magic [4]byte
+	if _, err := bin.ReadAt(magic[:], 0); err != nil || binary.BigEndian.Uint32(magic[:]) != macho.MagicFat {
+		return bin, nil
+	}
+	ff, err := macho.NewFatFile(bin)
+	if err != nil {
+		return nil, fmt.Errorf("universal binary: %w", err)
+	}
+	defer ff.Close()
+	if len(ff.Arches) == 0 {
+		return nil, fmt.Errorf("universal binary: no architectures")
+	}
+
+	want := map[string]macho.Cpu{
+		"amd64": macho.CpuAmd64,
+		"arm64": macho.CpuArm64,
+	}[goarch]
+	arch := ff.Arches[0]
+	for _, a := range ff.Arches {
+		if a.Cpu == want {
+			arch = a
+			break
+		}
+	}
+	end := uint64(arch.Offset) + uint64(arch.Size)
+	if end > uint64(bin.Size()) {
+		return nil, fmt.Errorf("universal binary: truncated %v slice", arch.Cpu)
+	}
+	return io.NewSectionReader(bin, int64(arch.Offset), int64(arch.Size)), nil
+}
+
+// syncthingMainPath is the main package path of the syncthing binary, as
+// recorded in its build info.
+const syncthingMainPath = "github.com/syncthing/syncthing/cmd/syncthing"
+
+// Executable magic numbers: ELF, PE (the DOS header) and Mach-O, 32 and
+// 64 bit in either byte order, or universal.
+var executableMagics = [][]byte{
+	[]byte("\x7fELF"),
+	[]byte("MZ"),
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe},
+}
+
+func isExecutable(bs []byte) bool {
+	for _, m := range executableMagics {
+		if bytes.HasPrefix(bs, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncthingBuildInfo returns the build info of the binary if it's a
+// syncthing binary, or nil.
+func syncthingBuildInfo(bin *io.SectionReader) *buildinfo.BuildInfo {
+	bin, err := thinMachO(bin)
+	if err != nil {
+		return nil
+	}
+	info, err := buildinfo.Read(bin)
+	if err != nil || info.Path != syncthingMainPath {
+		return nil
+	}
+	return info
+}
+
+// The Syncthing build script sets the version using an -X linker flag,
+// which ends up in the embedded build settings.
+var ldflagsVersionExp = regexp.MustCompile(`lib/build\.Version=(v\d+\.\d+\.\d+[^\s'"]*)`)
+
+// The build time is set the same way, as a Unix timestamp.
+var ldflagsStampExp = regexp.MustCompile(`lib/build\.Stamp=(\d+)`)
+
+// buildInfoVersion returns the Syncthing version and Go runtime version
+// recorded in the build info of a Syncthing binary, without executing it.
+func buildInfoVersion(bin *io.SectionReader) (version, goVersion string, err error) {
+	bin, err = thinMachO(bin)
+	if err != nil {
+		return "", "", err
+	}
+	info, err := buildinfo.Read(bin)
+	if err != nil {
+		return "", "", err
+	}
+	for _, s := range info.Settings {
+		if s.Key != "-ldflags" {
+			continue
+		}
+		if m := ldflagsVersionExp.FindStringSubmatch(s.Value); m != nil {
+			version = m[1]
+		}
+	}
+	if version == "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	if version == "" {
+		return "", "", fmt.Errorf("no version in build info")
+	}
+	return version, info.GoVersion, nil
+}
+
+// binaryRow returns the row for the syncthing binary of the release
+// with the tag, without executing it, so that an asset for any platform
+// will do. The row is read from the build info of builds since Go 1.18,
+// and scanned for in the binary's strings for older ones.
+func binaryRow(bin *io.SectionReader, tag string) (*tableRow, error) {
+	if syncthingBuildInfo(bin) != nil {
+		if row, err := buildInfoRow(bin); err == nil {
+			return row, nil
+		}
+	}
+	return scanRow(bin, tag)
+}
+
+// buildInfoRow returns the row for a Syncthing binary from its build
+// info. The date is the build time set by the build script, when there
+// is one.
+func buildInfoRow(bin *io.SectionReader) (*tableRow, error) {
+	bin, err := thinMachO(bin)
+	if err != nil {
+		return nil, err
+	}
+	version, goVersion, err := buildInfoVersion(bin)
+	if err != nil {
+		return nil, err
+	}
+	info, err := buildinfo.Read(bin)
+	if err != nil {
+		return nil, err
+	}
+	row := &tableRow{Version: version, Runtime: goVersion}
+	for _, s := range info.Settings {
+		if s.Key != "-ldflags" {
+			continue
+		}
+		if m := ldflagsStampExp.FindStringSubmatch(s.Value); m != nil {
+			sec, _ := strconv.ParseInt(m[1], 10, 64)
+			row.Date = time.Unix(sec, 0).UTC().Format(dateLayout)
+		}
+	}
+	return row, nil
+}
+
+// goVersionExp matches the Go versions among the strings of a binary.
+var goVersionExp = regexp.MustCompile(`go1\.\d+(?:\.\d+)?`)
+
+// scanRow returns the row for a Syncthing binary without the build
+// settings of Go 1.18, which can only be checked for the version string
+// the build script set to the tag. The runtime is read from the build
+// info header of Go 1.13 and later; before that it's the newest Go
+// version among the binary's strings, as the runtime's own version is
+// one of them and nothing mentions a later one. There is no build time
+// to read, so the date is left to the release.
+func scanRow(bin *io.SectionReader, tag string) (*tableRow, error) {
+	bin, err := thinMachO(bin)
+	if err != nil {
+		return nil, err
+	}
+	info, infoErr := buildinfo.Read(bin)
+	var hasTag bool
+	var goVersion string
+	err = scanChunks(bin, scanOverlap, func(chunk []byte) {
+		hasTag = hasTag || bytes.Contains(chunk, []byte(tag))
+		if infoErr == nil {
+			return
+		}
+		for _, m := range goVersionExp.FindAll(chunk, -1) {
+			if v := string(m); goVersion == "" || compareGoVersions(v, goVersion) > 0 {
+				goVersion = v
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasTag {
+		return nil, fmt.Errorf("no version %s in the binary", tag)
+	}
+	if infoErr == nil {
+		goVersion = info.GoVersion
+	}
+	if goVersion == "" {
+		return nil, fmt.Errorf("no Go version in the binary")
+	}
+	return &tableRow{Version: tag, Runtime: goVersion}, nil
+}
+
+// scanOverlap is how much of each chunk scanChunks passes on to the next,
+// more than the longest string looked for.
+const scanOverlap = 64
+
+// scanChunks calls fn with the contents of the reader a chunk at a time,
+// each starting with the last overlap bytes of the one before so that
+// no string shorter than that is split between two.
+func scanChunks(r *io.SectionReader, overlap int, fn func([]byte)) error {
+	buf := make([]byte, overlap+1<<20)
+	carry := 0
+	for off := int64(0); off < r.Size(); {
+		n, err := r.ReadAt(buf[carry:], off)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if n == 0 {
+			return io.ErrUnexpectedEOF
+		}
+		off += int64(n)
+		end := carry + n
+		fn(buf[:end])
+		if carry = overlap; carry > end {
+			carry = end
+		}
+		copy(buf, buf[end-carry:end])
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// memBudget bounds the memory used by downloaded archives held at the
+// same time. Archives that don't fit in what's left of it are spooled to
+// disk instead. A nil budget is unlimited.
+type memBudget struct {
+	mut   sync.Mutex
+	limit int64
+	used  int64
+}
+
+// archiveBudget is the budget for all the release archives downloaded.
+var archiveBudget *memBudget
+
+func newMemBudget(limit int64) *memBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &memBudget{limit: limit}
+}
+
+// reserve takes n bytes from the budget, if they're available.
+func (b *memBudget) reserve(n int64) bool {
+	if b == nil {
+		return true
+	}
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	if b.used+n > b.limit {
+		return false
+	}
+	b.used += n
+	return true
+}
+
+func (b *memBudget) release(n int64) {
+	if b == nil {
+		return
+	}
+	b.mut.Lock()
+	b.used -= n
+	b.mut.Unlock()
+}
+
+// assetData is the contents of a downloaded asset, in memory or spooled
+// to a temporary file. It must be closed to give the memory back to the
+// budget or remove the file.
+type assetData struct {
+	io.ReaderAt
+	size int64
+	// digest is the SHA-256 of the contents, as sha256:<hex>.
+	digest string
+
+	file     *os.File
+	budget   *memBudget
+	reserved int64
+}
+
+// section returns a reader of the whole contents.
+func (d *assetData) section() *io.SectionReader {
+	return io.NewSectionReader(d, 0, d.size)
+}
+
+func (d *assetData) Close() error {
+	if d == nil {
+		return nil
+	}
+	d.budget.release(d.reserved)
+	d.reserved = 0
+	if d.file == nil {
+		return nil
+	}
+	d.file.Close()
+	return os.Remove(d.file.Name())
+}
+
+// fetchAsset downloads the asset into memory when its size is known and
+// fits in the budget, otherwise into a temporary file, and checks it
+// against the signed checksums of its release.
+func fetchAsset(asset *github.ReleaseAsset, budget *memBudget) (*assetData, error) {
+	d, err := downloadAsset(asset, budget)
+	if err != nil {
+		return nil, err
+	}
+	if err := assetSums.check(asset, d.digest); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func downloadAsset(asset *github.ReleaseAsset, budget *memBudget) (*assetData, error) {
+	log.Println("Downloading", *asset.Name)
+	resp, err := httpclient.Download.Get(*asset.BrowserDownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", *asset.Name, resp.Status)
+	}
+	return spoolAsset(resp.Body, resp.ContentLength, budget)
+}
+
+// spoolAsset reads the contents, of size n or unknown if negative, into
+// memory when they fit in the budget, otherwise into a temporary file.
+func spoolAsset(r io.Reader, n int64, budget *memBudget) (*assetData, error) {
+	if n >= 0 && budget.reserve(n) {
+		bs, err := io.ReadAll(r)
+		if err != nil {
+			budget.release(n)
+			return nil, err
+		}
+		sum := sha256.Sum256(bs)
+		return &assetData{
+			ReaderAt: bytes.NewReader(bs),
+			size:     int64(len(bs)),
+			digest:   "sha256:" + hex.EncodeToString(sum[:]),
+			budget:   budget,
+			reserved: n,
+		}, nil
+	}
+
+	fd, err := os.CreateTemp("", "histver-asset")
+	if err != nil {
+		return nil, err
+	}
+	d := &assetData{ReaderAt: fd, file: fd}
+	h := sha256.New()
+	if d.size, err = io.Copy(io.MultiWriter(fd, h), r); err != nil {
+		d.Close()
+		return nil, err
+	}
+	d.digest = "sha256:" + hex.EncodeToString(h.Sum(nil))
+	return d, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v49/github"
+	"golang.org/x/crypto/openpgp"
+	"syncthing.net/docs/internal/signedsums"
+)
+
+// releaseSums checks the downloaded assets of the core's releases against
+// the signed checksum file of their release, fetched once per release.
+// Assets of other repositories, whose releases aren't signed, aren't
+// checked. A nil releaseSums checks nothing.
+type releaseSums struct {
+	manifest string
+
+	mut     sync.Mutex
+	keyring openpgp.EntityList
+	byTag   map[string]tagSums
+}
+
+// tagSums are the checksums of a release's assets by name, or why they
+// couldn't be had.
+type tagSums struct {
+	sums map[string]string
+	err  error
+}
+
+// assetSums checks the downloaded assets, unless -insecure.
+var assetSums *releaseSums
+
+func newReleaseSums(manifest string) *releaseSums {
+	return &releaseSums{manifest: manifest, byTag: make(map[string]tagSums)}
+}
+
+// check returns an error unless the digest of the downloaded asset, as
+// sha256:<hex>, is the one in the signed checksums of its release.
+func (s *releaseSums) check(asset *github.ReleaseAsset, digest string) error {
+	if s == nil {
+		return nil
+	}
+	// The assets are at
+	// https://github.com/{owner}/{repo}/releases/download/{tag}/{name},
+	// next to the checksum file.
+	url := asset.GetBrowserDownloadURL()
+	parts := strings.Split(strings.TrimPrefix(url, "https://github.com/"), "/")
+	if len(parts) != 6 || parts[0]+"/"+parts[1] != defaultAssetRepo || parts[2] != "releases" || parts[3] != "download" {
+		return nil
+	}
+	tag := parts[4]
+	sums, err := s.sums(tag, url[:strings.LastIndex(url, "/")+1]+signedsums.Asset)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w (-insecure skips the check)", tag, signedsums.Asset, err)
+	}
+	want, ok := sums[asset.GetName()]
+	if !ok {
+		return fmt.Errorf("%s: not in %s", asset.GetName(), signedsums.Asset)
+	}
+	if got := strings.TrimPrefix(digest, "sha256:"); got != want {
+		return fmt.Errorf("%s: SHA-256 %s, but %s says %s", asset.GetName(), got, signedsums.Asset, want)
+	}
+	return nil
+}
+
+// sums returns the verified checksums of the release.
+func (s *releaseSums) sums(tag, url string) (map[string]string, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if ts, ok := s.byTag[tag]; ok {
+		return ts.sums, ts.err
+	}
+	var ts tagSums
+	if s.keyring == nil {
+		keyring, err := signedsums.Keyring(s.manifest)
+		if err != nil {
+			// Not remembered for the release; the key is for all of them.
+			return nil, err
+		}
+		s.keyring = keyring
+	}
+	list, err := signedsums.Fetch(s.keyring, url)
+	if err != nil {
+		ts.err = err
+	} else {
+		ts.sums = make(map[string]string, len(list))
+		for _, sum := range list {
+			ts.sums[sum.Name] = sum.SHA256
+		}
+	}
+	s.byTag[tag] = ts
+	return ts.sums, ts.err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/platform"
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+)
+
+// releasePlatforms returns the platforms of the binary assets of the
+// release, as in the Platforms column.
+func releasePlatforms(rel *github.RepositoryRelease) string {
+	names := make([]string, 0, len(rel.Assets))
+	for _, asset := range rel.Assets {
+		names = append(names, asset.GetName())
+	}
+	return strings.Join(platform.AssetPlatforms(names), " ")
+}
+
+// fillPlatforms sets the platforms of the rows without them from the
+// assets of their GitHub releases. Rows of releases without binary
+// assets are left empty.
+func fillPlatforms(rows []*tableRow, releases []*github.RepositoryRelease, audit *auditLog) {
+	byTag := make(map[string]*github.RepositoryRelease, len(releases))
+	for _, rel := range releases {
+		byTag[rel.GetTagName()] = rel
+	}
+	for _, r := range rows {
+		rel, ok := byTag[r.Version]
+		if r.Platforms != "" || !ok {
+			continue
+		}
+		if r.Platforms = releasePlatforms(rel); r.Platforms != "" {
+			audit.record(r.Version, "Platforms", r.Platforms, methodGitHub, "assets of release "+rel.GetTagName())
+		}
+	}
+}
+
+// renderCompat writes the compatibility matrix: for each release, the
+// oldest Windows, macOS and Linux kernel versions it runs on, which
+// follow from the Go version it was built with, and the platforms it was
+// released for, newest first.
+func renderCompat(w io.Writer, out outputConfig, rows []*tableRow) error {
+	recs, err := compatRows(out, rows)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(compatHeader(out.renderConfig)); err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderCompatRST writes the compatibility matrix as a list-table.
+func renderCompatRST(w io.Writer, out outputConfig, rows []*tableRow) error {
+	recs, err := compatRows(out, rows)
+	if err != nil {
+		return err
+	}
+	title := out.Title
+	if title == "" {
+		title = out.label("Platform Compatibility")
+	}
+
+	for _, rec := range recs {
+		rec[len(rec)-1] = strings.Join(strings.Fields(rec[len(rec)-1]), ", ")
+	}
+	return writeRSTTable(w, rst.Table{
+		Title:  rst.Escape(title),
+		Header: rstEscapeAll(compatHeader(out.renderConfig)),
+		Rows:   rstEscapeRows(recs),
+	})
+}
+
+func compatHeader(rc renderConfig) []string {
+	return []string{rc.label("Version"), rc.label("Runtime"), rc.label("Windows"), rc.label("macOS"), rc.label("Linux Kernel"), rc.label("Platforms")}
+}
+
+// compatRows returns the rows of the compatibility matrix. The minimum
+// versions are Go's, from the table in the platform package, corrected by
+// the overrides file if the output has one; those of releases built with
+// a Go older than the table are left empty.
+func compatRows(out outputConfig, rows []*tableRow) ([][]string, error) {
+	var overrides []platform.Override
+	if out.Overrides != "" {
+		var err error
+		if overrides, err = platform.ReadOverrides(out.Overrides); err != nil {
+			return nil, err
+		}
+	}
+	shown, _ := applyCutoff(rows, out.Cutoff)
+	var res [][]string
+	for _, r := range shown {
+		var min platform.Minimum
+		if v, ok := relnotes.ParseVersion(r.Version); ok {
+			_, min = platform.For(v, r.Runtime, overrides)
+		} else if minor, ok := platform.GoMinor(r.Runtime); ok {
+			min, _ = platform.ForGo(minor)
+		}
+		res = append(res, []string{r.Version, r.Runtime, min.Windows, min.MacOS, min.Linux, r.Platforms})
+	}
+	return res, nil
+}
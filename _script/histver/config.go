@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Date formats besides Go layouts.
+const (
+	dateFormatISO  = "iso"
+	dateFormatLong = "long"
+)
+
+// config is the optional JSON configuration file, for the settings about
+// what is generated from the data and where it goes, rather than how the
+// data is collected.
+type config struct {
+	// Outputs are the files to generate from the table, all written in
+	// the same run.
+	Outputs []outputConfig `json:"outputs"`
+	// Translations are the header names and captions in other
+	// languages, by language and then English text, for outputs with a
+	// lang set.
+	Translations map[string]map[string]string `json:"translations"`
+	// Assets are the patterns for the release asset names to inspect,
+	// before the built-in ones, for when the naming changes.
+	Assets []assetPattern `json:"assets"`
+	// Repos are the other repositories whose releases are tracked, each
+	// in a versions table of its own, besides those given by -repo.
+	Repos []repoConfig `json:"repos"`
+	// Publish, when a bucket is set, uploads the generated files to
+	// object storage.
+	Publish publishConfig `json:"publish"`
+}
+
+type renderConfig struct {
+	// File is where to write the rendered table; rendering is disabled
+	// when empty.
+	File string `json:"file"`
+	// Cutoff is the oldest version to show. Versions before it are kept
+	// in the data file but left out of the rendered table.
+	Cutoff string `json:"cutoff"`
+	// Collapse replaces the versions before the cutoff with a single
+	// summary row, instead of omitting them.
+	Collapse bool `json:"collapse"`
+	// GroupByYear splits the RST or Markdown table into a section and
+	// table per calendar year, with HeadingChar (default ~) underlining
+	// the RST year headings. YearCounts adds the number of releases in
+	// the year under each heading.
+	GroupByYear bool   `json:"groupByYear"`
+	HeadingChar string `json:"headingChar"`
+	YearCounts  bool   `json:"yearCounts"`
+	// ShowAsset adds the column of the assets the rows were derived
+	// from to the csv, rst and markdown tables, when any are known.
+	ShowAsset bool `json:"showAsset"`
+	// ShowPlatforms likewise adds the column of the platforms of the
+	// release assets, when any are known.
+	ShowPlatforms bool `json:"showPlatforms"`
+	// DateFormat is how dates are shown: "iso" (the default, as
+	// stored), "long" (January 2, 2006) or a Go time layout, such as
+	// "2. January 2006". Month names are translated like labels.
+	DateFormat string `json:"dateFormat"`
+	// Lang selects the translations to use for the header names and
+	// captions.
+	Lang string `json:"lang"`
+	// Labels overrides single header names and captions, by their
+	// English text, over the translations. The texts are the column
+	// names (Version, Runtime, Date, Language, Asset, Platforms, Notes, and Series, First Release
+	// and Last Release in the series summary), the captions ("Syncthing
+	// Versions" for the RST table, "Go Versions by Release Series" for
+	// the series summary, "Syncthing Releases" for the RSS feed,
+	// "Earlier Releases" for the collapsed versions when grouped by
+	// year), "releases" and "release", as in the summary row of
+	// collapsed versions and the release counts, and the month names in
+	// dates.
+	Labels map[string]string `json:"labels"`
+
+	// labels are the resolved translations and overrides.
+	labels map[string]string
+}
+
+// label returns the text to show for the English text s.
+func (rc renderConfig) label(s string) string {
+	if l := rc.labels[s]; l != "" {
+		return l
+	}
+	return s
+}
+
+// formatDate returns the stored ISO date as it's to be shown. Dates that
+// don't parse are shown as they are.
+func (rc renderConfig) formatDate(date string) string {
+	layout := rc.DateFormat
+	switch layout {
+	case "", dateFormatISO:
+		return date
+	case dateFormatLong:
+		layout = "January 2, 2006"
+	}
+	t, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return date
+	}
+	s := t.Format(layout)
+	if strings.Contains(layout, "January") {
+		month := t.Month().String()
+		s = strings.Replace(s, month, rc.label(month), 1)
+	}
+	return s
+}
+
+// header returns the column names to show.
+func (rc renderConfig) header() []string {
+	res := make([]string, len(tableHeader))
+	for i, h := range tableHeader {
+		res[i] = rc.label(h)
+	}
+	return res
+}
+
+func loadConfig(path string) (*config, error) {
+	var cfg config
+	if path == "" {
+		return &cfg, nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, out := range cfg.outputs() {
+		if out.File == "" {
+			return nil, fmt.Errorf("%s: %s output without a file", path, out.Format)
+		}
+		if err := checkFormat(out.Format); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, out.File, err)
+		}
+		if _, ok := cfg.Translations[out.Lang]; out.Lang != "" && !ok {
+			return nil, fmt.Errorf("%s: %s: no translations for %q", path, out.File, out.Lang)
+		}
+		if utf8.RuneCountInString(out.HeadingChar) > 1 {
+			return nil, fmt.Errorf("%s: %s: heading character %q is more than one character", path, out.File, out.HeadingChar)
+		}
+		if f := out.DateFormat; f != "" && f != dateFormatISO && f != dateFormatLong && !strings.Contains(f, "2006") {
+			return nil, fmt.Errorf("%s: %s: date format %q is neither iso, long nor a layout with the year", path, out.File, f)
+		}
+		if c := out.Cutoff; c != "" {
+			if _, ok := parseVersion(c); !ok {
+				return nil, fmt.Errorf("%s: %s: cutoff %q is not a valid version", path, out.File, c)
+			}
+		}
+	}
+	for _, rc := range cfg.Repos {
+		if rc.File == "" {
+			return nil, fmt.Errorf("%s: %s without a file", path, rc.Repo)
+		}
+		if _, err := rc.strategy(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// outputs returns the configured outputs, with their labels resolved.
+func (c *config) outputs() []outputConfig {
+	outs := append([]outputConfig(nil), c.Outputs...)
+	for i := range outs {
+		rc := &outs[i].renderConfig
+		rc.labels = make(map[string]string)
+		for k, v := range c.Translations[rc.Lang] {
+			rc.labels[k] = v
+		}
+		for k, v := range rc.Labels {
+			rc.labels[k] = v
+		}
+	}
+	return outs
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+)
+
+const upgradeMetaURL = "https://upgrades.syncthing.net/meta.json"
+
+// upgradeRelease is the subset of the upgrade server's release metadata
+// that we compare against.
+type upgradeRelease struct {
+	Tag         string    `json:"tag_name"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// crosscheck compares the table to what the upgrade server reports and
+// returns the discrepancies found. The upgrade server only lists recent
+// releases, so table rows older than its oldest release are not expected
+// to be present there.
+func crosscheck(url string, rows []*tableRow, maxDateSkew time.Duration) ([]string, error) {
+	resp, err := httpclient.Default.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	var upstream []upgradeRelease
+	if err := json.NewDecoder(resp.Body).Decode(&upstream); err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+
+	byVersion := make(map[string]*tableRow, len(rows))
+	for _, r := range rows {
+		byVersion[r.Version] = r
+	}
+
+	var problems []string
+	oldest := ""
+	upstreamSeen := make(map[string]bool)
+	for _, rel := range upstream {
+		if rel.Prerelease {
+			continue
+		}
+		if _, ok := parseVersion(rel.Tag); !ok {
+			continue
+		}
+		upstreamSeen[rel.Tag] = true
+		if oldest == "" || compareVersions(rel.Tag, oldest) < 0 {
+			oldest = rel.Tag
+		}
+
+		row, ok := byVersion[rel.Tag]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: listed by the upgrade server but missing from the table", rel.Tag))
+			continue
+		}
+		if rel.PublishedAt.IsZero() {
+			continue
+		}
+		date, err := time.Parse(dateLayout, row.Date)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: unparseable date %q in table", row.Version, row.Date))
+			continue
+		}
+		skew := rel.PublishedAt.UTC().Truncate(24 * time.Hour).Sub(date)
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > maxDateSkew {
+			problems = append(problems, fmt.Sprintf("%s: table date %s, upgrade server says %s", row.Version, row.Date, rel.PublishedAt.UTC().Format(dateLayout)))
+		}
+	}
+
+	if oldest == "" {
+		log.Println("Upgrade server lists no stable releases; nothing to compare")
+		return problems, nil
+	}
+	for _, r := range rows {
+		if compareVersions(r.Version, oldest) >= 0 && !upstreamSeen[r.Version] {
+			problems = append(problems, fmt.Sprintf("%s: in the table but not listed by the upgrade server", r.Version))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// dateLayout is the canonical date format in the versions table: ISO-8601
+// calendar date, in UTC.
+const dateLayout = "2006-01-02"
+
+// Where the date in the table comes from.
+const (
+	dateSourceBuild     = "build"     // build timestamp in the binary
+	dateSourcePublished = "published" // GitHub release publish time
+	dateSourceCreated   = "created"   // GitHub release (tag) creation time
+)
+
+func checkDateSource(src string) error {
+	switch src {
+	case dateSourceBuild, dateSourcePublished, dateSourceCreated:
+		return nil
+	default:
+		return fmt.Errorf("unknown date source %q", src)
+	}
+}
+
+// releaseDate returns the date for the release according to the selected
+// source. The build date, as derived from the binary, is passed in and
+// used as fallback when GitHub lacks the requested timestamp. Binaries
+// built before Go 1.18 have no build date, and take the publish date
+// instead.
+func releaseDate(rel *github.RepositoryRelease, src, buildDate string) string {
+	ts := releaseTimestamp(rel, usedDateSource(rel, src, buildDate))
+	if ts == nil {
+		return normalizeDate(buildDate)
+	}
+	return ts.UTC().Format(dateLayout)
+}
+
+// usedDateSource returns the source releaseDate takes the date from: the
+// selected one, the build date when GitHub lacks the timestamp, or the
+// publish date when the build date is wanted but unknown.
+func usedDateSource(rel *github.RepositoryRelease, src, buildDate string) string {
+	switch {
+	case releaseTimestamp(rel, src) != nil:
+		return src
+	case buildDate == "" && releaseTimestamp(rel, dateSourcePublished) != nil:
+		return dateSourcePublished
+	default:
+		return dateSourceBuild
+	}
+}
+
+// releaseTimestamp returns the GitHub timestamp for the date source, or
+// nil when it's the build date or GitHub lacks it.
+func releaseTimestamp(rel *github.RepositoryRelease, src string) *github.Timestamp {
+	var ts *github.Timestamp
+	switch src {
+	case dateSourcePublished:
+		ts = rel.PublishedAt
+	case dateSourceCreated:
+		ts = rel.CreatedAt
+	}
+	if ts == nil || ts.IsZero() {
+		return nil
+	}
+	return ts
+}
+
+// recordDate records where releaseDate took the date from, given the
+// build date it was passed, the binary being the named asset.
+func recordDate(audit *auditLog, row *tableRow, rel *github.RepositoryRelease, src, buildDate, asset string) {
+	used := usedDateSource(rel, src, buildDate)
+	if used == dateSourceBuild {
+		audit.record(row.Version, "Date", row.Date, methodBinary, asset)
+		return
+	}
+	audit.record(row.Version, "Date", row.Date, methodGitHub, used+"_at of release "+rel.GetTagName())
+}
+
+// Formats we've seen in hand edited or older tables, in order of
+// preference.
+var dateLayouts = []string{
+	dateLayout,
+	time.RFC3339,
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006/01/02",
+	"2006.01.02",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+}
+
+// normalizeDate rewrites a date in any of the known layouts into the
+// canonical one, converting to UTC if the value carries a time zone. Dates
+// in unknown layouts are returned unchanged.
+func normalizeDate(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format(dateLayout)
+		}
+	}
+	return s
+}
+
+// migrateDates normalizes the date of every row and repairs rows written
+// by older versions of this tool, which formatted the GitHub creation time
+// using the broken layout "2006-01-01" (year-month-month). Such rows are
+// recognized by matching that broken rendering exactly, and get their
+// date from the selected source instead.
+func migrateDates(rows []*tableRow, releases []*github.RepositoryRelease, src string, audit *auditLog) {
+	byTag := make(map[string]*github.RepositoryRelease, len(releases))
+	for _, rel := range releases {
+		byTag[rel.GetTagName()] = rel
+	}
+
+	for _, row := range rows {
+		if d := normalizeDate(row.Date); d != row.Date {
+			log.Printf("%s: normalized date %q to %s", row.Version, row.Date, d)
+			audit.record(row.Version, "Date", d, methodNormal, row.Date)
+			row.Date = d
+		}
+
+		rel, ok := byTag[row.Version]
+		if !ok || rel.CreatedAt == nil {
+			continue
+		}
+		broken := rel.CreatedAt.Format("2006-01-01")
+		if row.Date != broken || broken == rel.CreatedAt.Format(dateLayout) {
+			continue
+		}
+		// We can't recover the build date without downloading the
+		// release again; the publish date is the closest thing.
+		fixSrc := src
+		if fixSrc == dateSourceBuild {
+			fixSrc = dateSourcePublished
+		}
+		fixed := releaseDate(rel, fixSrc, row.Date)
+		log.Printf("%s: repaired date %s to %s", row.Version, row.Date, fixed)
+		row.Date = fixed
+		audit.record(row.Version, "Date", fixed, methodRepaired, fixSrc+"_at of release "+rel.GetTagName())
+	}
+}
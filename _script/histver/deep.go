@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/cache"
+)
+
+// Archive name suffixes considered for deep inspection.
+var archiveSuffixes = []string{".zip", ".tar.gz", ".tgz", ".tar.bz2", ".tar.xz", ".tar.zst"}
+
+// assetInspector inspects every platform asset of a release, rather than
+// just the one for the host, and checks that they agree with the table
+// row. Assets whose digest has already been inspected (the same upload
+// attached to several releases, repeated architectures, or anything in
+// the caches from earlier runs) are not downloaded or inspected again.
+type assetInspector struct {
+	client    *github.Client
+	owner     string
+	repo      string
+	inspected map[string]inspection // by digest
+	// shared is the cache shared by the tools, where the inspections are
+	// kept for good as an asset with the same digest is the same asset.
+	shared *cache.Cache
+	// prefetch is how many downloaded assets may wait for inspection.
+	prefetch int
+}
+
+// inspection is the result of inspecting an asset.
+type inspection struct {
+	Asset     string `json:"asset"`
+	Version   string `json:"version,omitempty"`
+	GoVersion string `json:"goVersion,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (r inspection) String() string {
+	if r.Error != "" {
+		return r.Asset + ": " + r.Error
+	}
+	return fmt.Sprintf("%s: %s %s", r.Asset, r.Version, r.GoVersion)
+}
+
+func newAssetInspector(owner, repo string) *assetInspector {
+	return &assetInspector{
+		client:    githubClient(),
+		owner:     owner,
+		repo:      repo,
+		inspected: make(map[string]inspection),
+		shared:    cache.Default(),
+		prefetch:  1,
+	}
+}
+
+func (i *assetInspector) inspect(ctx context.Context, rel *github.RepositoryRelease, row *tableRow) {
+	digests, err := i.assetDigests(ctx, rel.GetID())
+	if err != nil {
+		// Not fatal; we'll compute our own digests after download.
+		log.Printf("%s: getting asset digests: %v", rel.GetTagName(), err)
+	}
+
+	// Assets with the digest of one already queued are checked against
+	// its result once it's in, rather than downloaded again.
+	var queue, later []*github.ReleaseAsset
+	queued := make(map[string]bool)
+	for _, asset := range releaseAssets(i.owner+"/"+i.repo, rel, "*", "*") {
+		if !isBinaryArchive(asset.GetName()) {
+			continue
+		}
+		d := digests[asset.GetID()]
+		if res, ok := i.lookup(d); ok {
+			log.Printf("%s: skipping %s, same digest as already inspected (%s)", rel.GetTagName(), asset.GetName(), res)
+			checkInspection(row, asset.GetName(), res)
+			continue
+		}
+		if d != "" && queued[d] {
+			later = append(later, asset)
+			continue
+		}
+		queued[d] = d != ""
+		queue = append(queue, asset)
+	}
+
+	for dl := range i.downloads(queue) {
+		if dl.err != nil {
+			log.Printf("%s: %v", rel.GetTagName(), dl.err)
+			continue
+		}
+		d := dl.data.digest
+		if res, ok := i.lookup(d); ok {
+			log.Printf("%s: not inspecting %s, same contents as already inspected (%s)", rel.GetTagName(), dl.asset.GetName(), res)
+			checkInspection(row, dl.asset.GetName(), res)
+			dl.data.Close()
+			continue
+		}
+
+		res := inspectArchive(dl.asset.GetName(), dl.data)
+		dl.data.Close()
+		checkInspection(row, dl.asset.GetName(), res)
+		i.record(d, res)
+		if gh := digests[dl.asset.GetID()]; gh != "" && gh != d {
+			log.Printf("%s: %s: digest %s does not match GitHub's %s", rel.GetTagName(), dl.asset.GetName(), d, gh)
+		}
+	}
+
+	for _, asset := range later {
+		if res, ok := i.lookup(digests[asset.GetID()]); ok {
+			log.Printf("%s: skipping %s, same digest as already inspected (%s)", rel.GetTagName(), asset.GetName(), res)
+			checkInspection(row, asset.GetName(), res)
+		} else {
+			log.Printf("%s: %s: not inspected, the asset with the same digest failed to download", rel.GetTagName(), asset.GetName())
+		}
+	}
+}
+
+// assetDownload is a downloaded asset, or the error downloading it.
+type assetDownload struct {
+	asset *github.ReleaseAsset
+	data  *assetData
+	err   error
+}
+
+// downloads downloads the assets in order in the background: while an
+// asset taken from the returned channel is inspected the next one is
+// downloading, and up to the prefetch count more may be waiting. The
+// channel is closed after the last asset and must be drained.
+func (i *assetInspector) downloads(assets []*github.ReleaseAsset) <-chan assetDownload {
+	prefetch := i.prefetch
+	if prefetch < 0 {
+		prefetch = 0
+	}
+	ch := make(chan assetDownload, prefetch)
+	go func() {
+		defer close(ch)
+		for _, asset := range assets {
+			data, err := fetchAsset(asset, archiveBudget)
+			ch <- assetDownload{asset: asset, data: data, err: err}
+		}
+	}()
+	return ch
+}
+
+func inspectArchive(name string, data *assetData) inspection {
+	res := inspection{Asset: name}
+	bin, err := archiveBinary(data, data.size)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer bin.Close()
+	res.Version, res.GoVersion, err = buildInfoVersion(bin.section())
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// checkInspection logs where the inspection of the asset disagrees with
+// the table row, or failed.
+func checkInspection(row *tableRow, name string, res inspection) {
+	switch {
+	case res.Error != "":
+		log.Printf("%s: %s: %s", row.Version, name, res.Error)
+	case res.Version != row.Version || res.GoVersion != row.Runtime:
+		log.Printf("%s: %s: has %s (%s), table says %s (%s)", row.Version, name, res.Version, res.GoVersion, row.Version, row.Runtime)
+	}
+}
+
+// lookup returns the inspection of the asset with the digest, made in
+// this run or found in the shared cache.
+func (i *assetInspector) lookup(digest string) (inspection, bool) {
+	if digest == "" {
+		return inspection{}, false
+	}
+	if res, ok := i.inspected[digest]; ok {
+		return res, true
+	}
+	var res inspection
+	if i.shared.GetJSON(cache.API, inspectionCacheKey(digest), &res) {
+		i.inspected[digest] = res
+		return res, true
+	}
+	return inspection{}, false
+}
+
+// record adds the inspection of the asset with the digest to those made,
+// and to the shared cache.
+func (i *assetInspector) record(digest string, res inspection) {
+	i.inspected[digest] = res
+	if err := i.shared.PutJSON(cache.API, inspectionCacheKey(digest), res, cache.Never); err != nil {
+		log.Printf("Caching inspection: %v", err)
+	}
+}
+
+func inspectionCacheKey(digest string) string {
+	return "histver/inspection:" + digest
+}
+
+// loadCache adds the inspections in the cache file, if it exists, to
+// those already made.
+func (i *assetInspector) loadCache(file string) error {
+	bs, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var cached map[string]inspection
+	if err := json.Unmarshal(bs, &cached); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	for d, res := range cached {
+		i.inspected[d] = res
+	}
+	return nil
+}
+
+// saveCache writes all the inspections made so far to the cache file.
+func (i *assetInspector) saveCache(file string) error {
+	bs, err := json.MarshalIndent(i.inspected, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(bs, '\n'), 0o644)
+}
+
+// assetDigests returns the GitHub provided digests ("sha256:...") of the
+// release assets, by asset ID. The client library predates the digest
+// field, so we make the request ourselves.
+func (i *assetInspector) assetDigests(ctx context.Context, releaseID int64) (map[int64]string, error) {
+	return releaseAssetDigests(ctx, i.client, i.owner, i.repo, releaseID)
+}
+
+func releaseAssetDigests(ctx context.Context, client *github.Client, owner, repo string, releaseID int64) (map[int64]string, error) {
+	u := fmt.Sprintf("repos/%s/%s/releases/%d/assets?per_page=100", owner, repo, releaseID)
+	req, err := client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	var assets []struct {
+		ID     int64  `json:"id"`
+		Digest string `json:"digest"`
+	}
+	if _, err := client.Do(ctx, req, &assets); err != nil {
+		return nil, err
+	}
+	digests := make(map[int64]string, len(assets))
+	for _, a := range assets {
+		if a.Digest != "" {
+			digests[a.ID] = a.Digest
+		}
+	}
+	return digests, nil
+}
+
+// hasBinaryAssets returns whether the release has any assets to inspect.
+func hasBinaryAssets(rel *github.RepositoryRelease) bool {
+	for _, asset := range releaseAssets(defaultAssetRepo, rel, "*", "*") {
+		if isBinaryArchive(asset.GetName()) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBinaryArchive(name string) bool {
+	if strings.Contains(name, "-source-") {
+		return false
+	}
+	for _, suf := range archiveSuffixes {
+		if strings.HasSuffix(name, suf) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/cache"
+)
+
+// derivedRow is the row derived for a release, or the error deriving it.
+type derivedRow struct {
+	row *tableRow
+	err error
+}
+
+// deriveRows derives the rows of the releases with derive, up to jobs of
+// them at once. It returns a channel per release, in the same order,
+// receiving its result; taking them in order gets the rows as soon as
+// those before them are in. Downloads held in memory at once are still
+// bound by the archive budget.
+func deriveRows(rels []*github.RepositoryRelease, jobs int, derive func(*github.RepositoryRelease) (*tableRow, error)) []chan derivedRow {
+	if jobs < 1 {
+		jobs = 1
+	}
+	res := make([]chan derivedRow, len(rels))
+	for i := range res {
+		res[i] = make(chan derivedRow, 1)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for j := 0; j < jobs; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				row, err := derive(rels[i])
+				res[i] <- derivedRow{row: row, err: err}
+			}
+		}()
+	}
+	go func() {
+		for i := range rels {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+	}()
+	return res
+}
+
+// cachedReleaseVersion is getReleaseVersion, with the rows kept in the
+// shared cache by the asset they were derived from, so that a run
+// repeated or resumed after an interruption doesn't download the assets
+// again. Assets are never changed under the same ID, only replaced.
+func cachedReleaseVersion(rel *github.RepositoryRelease, asset *github.ReleaseAsset, derive func() (*tableRow, error)) (*tableRow, error) {
+	c := cache.Default()
+	key := fmt.Sprintf("histver/row:%d", asset.GetID())
+	var row tableRow
+	if c.GetJSON(cache.API, key, &row) && row.Version == rel.GetTagName() {
+		log.Printf("%s: using the row derived earlier from %s", rel.GetTagName(), asset.GetName())
+		return &row, nil
+	}
+	derived, err := derive()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.PutJSON(cache.API, key, derived, cache.Never); err != nil {
+		log.Printf("Caching row: %v", err)
+	}
+	return derived, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/versions"
+)
+
+// renderFeatures writes the "what's new by release" page: for each
+// release mentioned by a version directive, newest first, the documents
+// mentioning it and what they say happened. Mentions of versions that
+// aren't releases are left out.
+func renderFeatures(w io.Writer, mentions []versions.Mention) error {
+	byTag := make(map[string]map[string][]string)
+	var rels []versions.Release
+	for _, m := range mentions {
+		if m.Problem != "" {
+			continue
+		}
+		docs := byTag[m.Release.Tag]
+		if docs == nil {
+			docs = make(map[string][]string)
+			byTag[m.Release.Tag] = docs
+			rels = append(rels, m.Release)
+		}
+		docs[m.Doc] = append(docs[m.Doc], versions.Directives[m.Directive])
+	}
+	sort.Slice(rels, func(a, b int) bool { return rels[b].Version.Less(rels[a].Version) })
+
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/histver; do not edit.\n\n")
+	sb.WriteString(rst.Heading("What's New by Release", '='))
+	sb.WriteString("The pages describing what was added, changed or deprecated in each release.\n\n")
+	for _, rel := range rels {
+		sb.WriteString(rst.Heading(rel.Tag, '-'))
+		docs := byTag[rel.Tag]
+		names := make([]string, 0, len(docs))
+		for name := range docs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&sb, "- :doc:`/%s` (%s)\n", name, strings.Join(uniqueSorted(docs[name]), ", "))
+		}
+		sb.WriteString("\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// uniqueSorted returns the strings in sorted order without duplicates.
+func uniqueSorted(ss []string) []string {
+	sort.Strings(ss)
+	var res []string
+	for i, s := range ss {
+		if i == 0 || s != ss[i-1] {
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// commitMessage describes the added rows, e.g. "Add v1.29.0 (go1.23.4)".
+// When several versions were added the subject lists them all and the
+// body has one line per version.
+func commitMessage(added []*tableRow) string {
+	if len(added) == 1 {
+		return fmt.Sprintf("Add %s (%s)", added[0].Version, added[0].Runtime)
+	}
+	versions := make([]string, len(added))
+	lines := make([]string, len(added))
+	for i, r := range added {
+		versions[i] = r.Version
+		lines[i] = fmt.Sprintf("- %s (%s, %s)", r.Version, r.Runtime, r.Date)
+	}
+	return fmt.Sprintf("Add %s\n\n%s\n", strings.Join(versions, ", "), strings.Join(lines, "\n"))
+}
+
+func gitCommit(files []string, msg string, extraArgs ...string) error {
+	if err := git(append([]string{"add", "--"}, files...)...); err != nil {
+		return err
+	}
+	args := append([]string{"commit", "-m", msg}, extraArgs...)
+	return git(append(args, "--")...)
+}
+
+func git(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+type pullRequestOptions struct {
+	Repo   string // owner/name of the docs repository
+	Base   string // branch to merge into
+	Branch string // branch to push the changes to
+	Token  string
+	// Signoff adds a Signed-off-by trailer to the commit.
+	Signoff bool
+}
+
+// openPullRequest commits the changed files to the PR branch, pushes it
+// and opens a pull request for it, or updates the one already open for
+// the branch.
+func openPullRequest(ctx context.Context, opts pullRequestOptions, files []string, added []*tableRow) error {
+	owner, repo, ok := strings.Cut(opts.Repo, "/")
+	if !ok {
+		return fmt.Errorf("repository %q is not owner/name", opts.Repo)
+	}
+	if opts.Token == "" {
+		return fmt.Errorf("a GitHub token is required")
+	}
+
+	msg := commitMessage(added)
+	if err := git("checkout", "-B", opts.Branch); err != nil {
+		return err
+	}
+	var args []string
+	if opts.Signoff {
+		args = append(args, "--signoff")
+	}
+	if err := gitCommit(files, msg, args...); err != nil {
+		return err
+	}
+	if err := gitRemote("push", "--force", "origin", opts.Branch); err != nil {
+		return err
+	}
+
+	client := github.NewClient(&http.Client{Transport: &httpclient.TokenTransport{Token: opts.Token}})
+	title, body, _ := strings.Cut(msg, "\n\n")
+	body = "Automatically generated by histver.\n\n" + body
+
+	prs, _, err := client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  owner + ":" + opts.Branch,
+		Base:  opts.Base,
+	})
+	if err != nil {
+		return err
+	}
+	if len(prs) > 0 {
+		pr := prs[0]
+		_, _, err := client.PullRequests.Edit(ctx, owner, repo, pr.GetNumber(), &github.PullRequest{
+			Title: &title,
+			Body:  &body,
+		})
+		if err == nil {
+			log.Println("Updated pull request", pr.GetHTMLURL())
+		}
+		return err
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: &title,
+		Body:  &body,
+		Head:  &opts.Branch,
+		Base:  &opts.Base,
+	})
+	if err == nil {
+		log.Println("Opened pull request", pr.GetHTMLURL())
+	}
+	return err
+}
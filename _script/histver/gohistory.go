@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"syncthing.net/docs/internal/cache"
+	"syncthing.net/docs/internal/versions"
+)
+
+// goHistoryURL is the Go release history, which says for each point
+// release when it was released and whether it includes security fixes.
+const goHistoryURL = "https://go.dev/doc/devel/release"
+
+// goHistoryClient caches the release history for a few hours, as it
+// changes with each Go release.
+var goHistoryClient = cache.Default().Client(6 * time.Hour)
+
+// goRelease is a Go release in the release history.
+type goRelease struct {
+	Version  string
+	Date     string
+	Security bool
+}
+
+var (
+	htmlTagExp = regexp.MustCompile(`<[^>]*>`)
+	spaceExp   = regexp.MustCompile(`\s+`)
+	// Each release is described as in "go1.21.1 (released 2023-09-06)
+	// includes four security fixes to ...", up to the next one.
+	goReleaseExp = regexp.MustCompile(`(go\d+\.\d+(?:\.\d+)?) \(released (\d{4}-\d{2}-\d{2})\)`)
+	securityExp  = regexp.MustCompile(`\bsecurity fix`)
+)
+
+// goReleaseHistory returns the Go releases in the release history.
+func goReleaseHistory() ([]goRelease, error) {
+	resp, err := goHistoryClient.Get(goHistoryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", goHistoryURL, resp.Status)
+	}
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	res := parseGoReleaseHistory(string(bs))
+	if len(res) == 0 {
+		return nil, fmt.Errorf("%s: no releases found", goHistoryURL)
+	}
+	return res, nil
+}
+
+func parseGoReleaseHistory(page string) []goRelease {
+	text := html.UnescapeString(htmlTagExp.ReplaceAllString(page, " "))
+	text = spaceExp.ReplaceAllString(text, " ")
+	ms := goReleaseExp.FindAllStringSubmatchIndex(text, -1)
+	var res []goRelease
+	for i, m := range ms {
+		end := len(text)
+		if i+1 < len(ms) {
+			end = ms[i+1][0]
+		}
+		res = append(res, goRelease{
+			Version:  text[m[2]:m[3]],
+			Date:     text[m[4]:m[5]],
+			Security: securityExp.MatchString(text[m[1]:end]),
+		})
+	}
+	return res
+}
+
+// goSupersededBy returns the earliest point release with security fixes
+// in the same minor series as the Go version, released before the date
+// the release was built, or the empty string if there is none.
+func goSupersededBy(history []goRelease, goVersion, date string) string {
+	v, ok := parseGoVersion(goVersion)
+	if !ok || len(v) < 2 {
+		return ""
+	}
+	var first *goRelease
+	for i, r := range history {
+		hv, ok := parseGoVersion(r.Version)
+		if !ok || len(hv) < 3 || hv[0] != v[0] || hv[1] != v[1] || versions.CompareParts(hv, v) <= 0 {
+			continue
+		}
+		if r.Security && r.Date < date && (first == nil || compareGoVersions(r.Version, first.Version) < 0) {
+			first = &history[i]
+		}
+	}
+	if first == nil {
+		return ""
+	}
+	return first.Version
+}
+
+// fillGoSuperseded marks the rows built with a Go point release that had
+// already been superseded by one with security fixes, from the Go release
+// history. All rows are looked at again, as the Go versions of rows can
+// be corrected by hand.
+func fillGoSuperseded(rows []*tableRow, audit *auditLog) {
+	history, err := goReleaseHistory()
+	if err != nil {
+		log.Printf("Go release history: %v", err)
+		return
+	}
+	for _, r := range rows {
+		by := goSupersededBy(history, r.Runtime, r.Date)
+		if by == r.GoSuperseded {
+			continue
+		}
+		r.GoSuperseded = by
+		audit.record(r.Version, "GoSuperseded", by, methodGoHistory, goHistoryURL)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+
+	"syncthing.net/docs/internal/cache"
+)
+
+// goModURL is where the go.mod of a release tag is read from.
+const goModURL = "https://raw.githubusercontent.com/syncthing/syncthing/%s/go.mod"
+
+// tagClient fetches what a release tag fixes for good, such as its go.mod
+// and module hash, keeping it in the shared cache.
+var tagClient = cache.Default().Client(cache.Never)
+
+// The go directive sets the language version the module is written for,
+// which is older than the toolchain it's built with more often than not.
+var goDirectiveExp = regexp.MustCompile(`(?m)^go\s+(\d+\.\d+(?:\.\d+)?)\s*$`)
+
+// goModLanguage returns the language version from the go directive in the
+// go.mod of the release tag, as in go1.21.
+func goModLanguage(tag string) (string, error) {
+	url := fmt.Sprintf(goModURL, tag)
+	resp, err := tagClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	m := goDirectiveExp.FindSubmatch(bs)
+	if m == nil {
+		return "", fmt.Errorf("%s: no go directive", url)
+	}
+	return "go" + string(m[1]), nil
+}
+
+// fillLanguage sets the language version of the rows that don't have one
+// yet. Releases from before Syncthing used modules have no go.mod and are
+// left as they are.
+func fillLanguage(rows []*tableRow, audit *auditLog) {
+	for _, r := range rows {
+		if r.Language != "" {
+			continue
+		}
+		lang, err := goModLanguage(r.Version)
+		if err != nil {
+			log.Printf("%s: language version: %v", r.Version, err)
+			continue
+		}
+		r.Language = lang
+		audit.record(r.Version, "Language", lang, methodGoMod, fmt.Sprintf(goModURL, r.Version))
+	}
+}
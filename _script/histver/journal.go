@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// A journal records the rows derived for new releases as we go, so that a
+// run that dies partway can be resumed without deriving them again. It
+// lives next to the versions table and is removed once the table has been
+// written.
+type journal struct {
+	file string
+	fd   *os.File
+}
+
+func journalFile(versionsFile string) string {
+	return versionsFile + ".journal"
+}
+
+// openJournal returns the journal for the versions table, and the rows
+// recorded in it by an earlier run that didn't finish.
+func openJournal(versionsFile string) (*journal, []*tableRow, error) {
+	file := journalFile(versionsFile)
+	rows, err := readJournal(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Start over with the rows read, leaving out any partial last line.
+	fd, err := os.Create(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	j := &journal{file: file, fd: fd}
+	for _, row := range rows {
+		if err := j.add(row); err != nil {
+			fd.Close()
+			return nil, nil, err
+		}
+	}
+	return j, rows, nil
+}
+
+func readJournal(file string) ([]*tableRow, error) {
+	fd, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var rows []*tableRow
+	sc := bufio.NewScanner(fd)
+	for n := 1; sc.Scan(); n++ {
+		var row tableRow
+		if err := json.Unmarshal(sc.Bytes(), &row); err != nil {
+			// The run may have died while writing the last line; the
+			// release will be derived again.
+			if !sc.Scan() {
+				break
+			}
+			return nil, fmt.Errorf("%s: line %d: %w", file, n, err)
+		}
+		rows = append(rows, &row)
+	}
+	return rows, sc.Err()
+}
+
+// add records the row, making sure it's on disk before returning.
+func (j *journal) add(row *tableRow) error {
+	bs, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if _, err := j.fd.Write(append(bs, '\n')); err != nil {
+		return err
+	}
+	return j.fd.Sync()
+}
+
+// finish removes the journal, once its rows are in the table.
+func (j *journal) finish() error {
+	j.fd.Close()
+	return os.Remove(j.file)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// goReleaseDates are the release dates of the Go minor versions, for
+// telling which runtimes a release could have been built with.
+var goReleaseDates = map[string]string{
+	"go1.0":  "2012-03-28",
+	"go1.1":  "2013-05-13",
+	"go1.2":  "2013-12-01",
+	"go1.3":  "2014-06-18",
+	"go1.4":  "2014-12-10",
+	"go1.5":  "2015-08-19",
+	"go1.6":  "2016-02-17",
+	"go1.7":  "2016-08-15",
+	"go1.8":  "2017-02-16",
+	"go1.9":  "2017-08-24",
+	"go1.10": "2018-02-16",
+	"go1.11": "2018-08-24",
+	"go1.12": "2019-02-25",
+	"go1.13": "2019-09-03",
+	"go1.14": "2020-02-25",
+	"go1.15": "2020-08-11",
+	"go1.16": "2021-02-16",
+	"go1.17": "2021-08-16",
+	"go1.18": "2022-03-15",
+	"go1.19": "2022-08-02",
+	"go1.20": "2023-02-01",
+	"go1.21": "2023-08-08",
+	"go1.22": "2024-02-06",
+	"go1.23": "2024-08-13",
+	"go1.24": "2025-02-11",
+	"go1.25": "2025-08-12",
+}
+
+// Releases have been built with Go betas and release candidates, which
+// report the version they lead up to and come out up to about three
+// months before it.
+const goPrereleaseMonths = 3
+
+// versionExp is what versions in the table look like: numeric
+// components without leading zeros, two of them for the earliest
+// releases and three or four since.
+var versionExp = regexp.MustCompile(`^v(0|[1-9]\d*)(\.(0|[1-9]\d*)){1,3}$`)
+
+// Characters left behind by formatting the table as Markdown or in a
+// word processor.
+const markupChars = "*_`"
+
+// lintTable checks the versions table file as it is, without touching
+// the network, and returns the problems found as file:line: messages.
+func lintTable(file string) ([]string, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	report := func(line int, format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("%s:%d: %s", file, line, fmt.Sprintf(format, args...)))
+	}
+
+	if bytes.HasPrefix(bs, []byte{0xef, 0xbb, 0xbf}) {
+		report(1, "byte order mark")
+	}
+	if bytes.Contains(bs, []byte("\r")) {
+		report(1, "CR line endings")
+	}
+	bs, err = normalizeText(bs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	cr := csv.NewReader(bytes.NewReader(bs))
+	cr.FieldsPerRecord = -1
+	cols := columnIndex(tableHeader)
+	type lintRow struct {
+		line int
+		row  tableRow
+	}
+	var rows []lintRow
+	lines := make(map[string]int)
+	for {
+		ss, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return problems, fmt.Errorf("%s: %w", file, err)
+		}
+		line, _ := cr.FieldPos(0)
+		if len(ss) == 0 {
+			continue
+		}
+		if cleanField(ss[0]) == tableHeader[0] {
+			cols = columnIndex(ss)
+			continue
+		}
+		notes, ok := cols[notesColumn]
+		for i, s := range ss {
+			switch {
+			case cleanField(s) != s:
+				report(line, "field %d %q has surrounding space or typographic characters", i+1, s)
+			case strings.ContainsAny(s, markupChars) && (!ok || i != notes):
+				report(line, "field %d %q has formatting characters", i+1, s)
+			}
+		}
+
+		var r tableRow
+		if err := r.fromStrings(ss, cols); err != nil {
+			report(line, "%v", err)
+			continue
+		}
+		if first, ok := lines[r.Version]; ok {
+			report(line, "%s is already on line %d", r.Version, first)
+		} else {
+			lines[r.Version] = line
+		}
+		if !versionExp.MatchString(r.Version) {
+			report(line, "version %q is not a vN.N.N version", r.Version)
+		}
+		if _, ok := parseGoVersion(r.Runtime); !ok {
+			report(line, "runtime %q is not a Go version", r.Runtime)
+		}
+		if r.Language != "" {
+			if _, ok := parseGoVersion(r.Language); !ok {
+				report(line, "language %q is not a Go version", r.Language)
+			}
+		}
+		if _, err := time.Parse(dateLayout, r.Date); err != nil {
+			report(line, "date %q is not in the %s format", r.Date, dateLayout)
+		} else if earliest := goEarliestDate(r.Runtime); earliest != "" && r.Date < earliest {
+			report(line, "dated %s, before even a prerelease of %s was out", r.Date, r.Runtime)
+		}
+		rows = append(rows, lintRow{line, r})
+	}
+
+	// Each version is expected to be released no earlier than the
+	// versions below it.
+	for i, a := range rows {
+		for _, b := range rows[i+1:] {
+			if compareVersions(a.row.Version, b.row.Version) > 0 && a.row.Date < b.row.Date && validDate(a.row.Date) && validDate(b.row.Date) {
+				report(a.line, "%s is dated %s, before %s on line %d (%s)", a.row.Version, a.row.Date, b.row.Version, b.line, b.row.Date)
+				break
+			}
+		}
+	}
+	return problems, nil
+}
+
+// goEarliestDate returns the earliest date a release could have been
+// built with the runtime, if known: the release date of its Go minor
+// version, less the prerelease period.
+func goEarliestDate(runtime string) string {
+	parts, ok := parseGoVersion(runtime)
+	if !ok || len(parts) < 2 {
+		return ""
+	}
+	released, err := time.Parse(dateLayout, goReleaseDates[fmt.Sprintf("go%d.%d", parts[0], parts[1])])
+	if err != nil {
+		return ""
+	}
+	return released.AddDate(0, -goPrereleaseMonths, 0).Format(dateLayout)
+}
+
+func validDate(s string) bool {
+	_, err := time.Parse(dateLayout, s)
+	return err == nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// The syncthing-macos wrapper ships the Syncthing core binary inside the
+// app bundle at this location, and declares its version and the minimum
+// macOS version in the app's Info.plist.
+const (
+	macosBundledBinary = ".app/Contents/Resources/syncthing/syncthing"
+	macosInfoPlist     = ".app/Contents/Info.plist"
+)
+
+var macosWrapper = wrapper{bundle: macosBundle}
+
+// macosBundle inspects the first app bundle asset, be it a zip or disk
+// image.
+func macosBundle(assets []*github.ReleaseAsset) (*bundle, error) {
+	for _, asset := range assets {
+		var files []bundleFile
+		var cleanup func()
+		var err error
+		switch strings.ToLower(path.Ext(*asset.Name)) {
+		case ".zip":
+			files, err = zipBundleFiles(asset)
+		case ".dmg":
+			files, cleanup, err = dmgBundleFiles(asset)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cleanup != nil {
+			defer cleanup()
+		}
+		return appBundle(files)
+	}
+	return nil, fmt.Errorf("no app bundle asset found")
+}
+
+// bundleFile is a file in an app bundle, with a way to read it.
+type bundleFile struct {
+	name string
+	read func() ([]byte, error)
+}
+
+func appBundle(files []bundleFile) (*bundle, error) {
+	var bin, plist *bundleFile
+	for i, f := range files {
+		if bin == nil && strings.HasSuffix(f.name, macosBundledBinary) {
+			bin = &files[i]
+		}
+		// The shortest match is the app itself rather than some nested
+		// framework or helper.
+		if strings.HasSuffix(f.name, macosInfoPlist) && (plist == nil || len(f.name) < len(plist.name)) {
+			plist = &files[i]
+		}
+	}
+	if bin == nil {
+		return nil, fmt.Errorf("no bundled syncthing binary found")
+	}
+
+	var b bundle
+	var err error
+	b.binary, err = bin.read()
+	if err != nil {
+		return nil, err
+	}
+	if plist != nil {
+		bs, err := plist.read()
+		if err != nil {
+			return nil, err
+		}
+		if v, err := plistString(bs, "LSMinimumSystemVersion"); err == nil {
+			b.minOS = "macOS " + v
+		}
+		if v, err := plistString(bs, "CFBundleShortVersionString"); err == nil {
+			b.version = v
+		}
+	}
+	return &b, nil
+}
+
+func zipBundleFiles(asset *github.ReleaseAsset) ([]bundleFile, error) {
+	bs, err := download(asset)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(bs), int64(len(bs)))
+	if err != nil {
+		return nil, err
+	}
+	var files []bundleFile
+	for _, f := range zr.File {
+		f := f
+		files = append(files, bundleFile{name: f.Name, read: func() ([]byte, error) {
+			rd, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rd.Close()
+			return io.ReadAll(rd)
+		}})
+	}
+	return files, nil
+}
+
+// dmgBundleFiles extracts the disk image using 7-Zip, which understands
+// both the UDIF container and the HFS+ file system inside it. The returned
+// function removes the extracted files.
+func dmgBundleFiles(asset *github.ReleaseAsset) ([]bundleFile, func(), error) {
+	bs, err := download(asset)
+	if err != nil {
+		return nil, nil, err
+	}
+	dir, err := os.MkdirTemp("", "syncthing-macos")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	dmg := filepath.Join(dir, "image.dmg")
+	if err := os.WriteFile(dmg, bs, 0o644); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	out := filepath.Join(dir, "out")
+	cmd := exec.Command("7z", "x", "-y", "-o"+out, dmg)
+	if bs, err := cmd.CombinedOutput(); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("7z: %w: %s", err, bs)
+	}
+
+	var files []bundleFile
+	err = filepath.WalkDir(out, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, bundleFile{
+				name: filepath.ToSlash(p),
+				read: func() ([]byte, error) { return os.ReadFile(p) },
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return files, cleanup, nil
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+// Output formats.
+const (
+	formatCSV    = "csv"      // presentation table, as CSV
+	formatRST    = "rst"      // presentation table, as an RST list-table include
+	formatMD     = "markdown" // presentation table, as a Markdown pipe table
+	formatJSON   = "json"     // all rows, as served at /versions.json
+	formatLatest = "latest"   // the highest version, as served at /latest
+	formatRSS    = "rss"      // RSS feed of the releases
+	// Summary table with a row per minor series, as CSV or RST
+	formatSeries    = "series"
+	formatSeriesRST = "series-rst"
+	// Releases built with a Go point release already superseded by a
+	// security fix, as CSV or RST
+	formatToolchain    = "toolchain"
+	formatToolchainRST = "toolchain-rst"
+	// The REST endpoints added by each release, as CSV or RST
+	formatAPI    = "api"
+	formatAPIRST = "api-rst"
+	// The minimum operating system versions and the platforms of each
+	// release, as CSV or RST
+	formatCompat    = "compat"
+	formatCompatRST = "compat-rst"
+	// The newest release for release announcements, as Markdown or
+	// BBCode
+	formatAnnouncement       = "announcement"
+	formatAnnouncementBBCode = "announcement-bbcode"
+)
+
+// outputConfig is a file generated from the versions table. The cutoff
+// and collapse settings apply to the table formats.
+type outputConfig struct {
+	renderConfig
+	Format string `json:"format"`
+	// Title is the caption of the RST table and the title of the RSS
+	// feed, instead of the default (translated) one.
+	Title string `json:"title"`
+	// Link is the page the RSS feed is about.
+	Link string `json:"link"`
+	// Items limits the number of releases in the RSS feed and the
+	// announcement; zero means the default of 20 and 1.
+	Items int `json:"items"`
+	// Overrides is the platform overrides file correcting the minimum
+	// operating system versions in the compat formats, as for
+	// _script/platforms.
+	Overrides string `json:"overrides"`
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case formatCSV, formatRST, formatMD, formatJSON, formatLatest, formatRSS, formatSeries, formatSeriesRST, formatToolchain, formatToolchainRST, formatAPI, formatAPIRST, formatCompat, formatCompatRST, formatAnnouncement, formatAnnouncementBBCode:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// writeOutputs writes all the outputs from the rows, which must already
+// be sorted as by writeTable, and returns the files written.
+func writeOutputs(outs []outputConfig, rows []*tableRow) ([]string, error) {
+	var files []string
+	for _, out := range outs {
+		fd, err := os.Create(out.File)
+		if err != nil {
+			return files, err
+		}
+		if err := writeOutput(fd, out, rows); err != nil {
+			fd.Close()
+			return files, fmt.Errorf("%s: %w", out.File, err)
+		}
+		if err := fd.Close(); err != nil {
+			return files, err
+		}
+		files = append(files, out.File)
+	}
+	return files, nil
+}
+
+func writeOutput(w io.Writer, out outputConfig, rows []*tableRow) error {
+	switch out.Format {
+	case formatCSV:
+		return renderTable(w, out.renderConfig, rows)
+	case formatRST:
+		return renderDocument(w, out, rows, rstDoc{})
+	case formatMD:
+		return renderDocument(w, out, rows, &markdownDoc{})
+	case formatJSON:
+		return writeJSONTo(w, rows)
+	case formatLatest:
+		latest := latestRow(rows)
+		if latest == nil {
+			return fmt.Errorf("no versions")
+		}
+		return writeJSONTo(w, latest)
+	case formatRSS:
+		return renderRSS(w, out, rows)
+	case formatSeries:
+		return renderSeries(w, out.renderConfig, rows)
+	case formatSeriesRST:
+		return renderSeriesRST(w, out, rows)
+	case formatToolchain:
+		return renderToolchain(w, out.renderConfig, rows)
+	case formatToolchainRST:
+		return renderToolchainRST(w, out, rows)
+	case formatAPI:
+		return renderAPI(w, out.renderConfig, rows)
+	case formatAPIRST:
+		return renderAPIRST(w, out, rows)
+	case formatCompat:
+		return renderCompat(w, out, rows)
+	case formatCompatRST:
+		return renderCompatRST(w, out, rows)
+	case formatAnnouncement:
+		return renderAnnouncement(w, out, rows, false)
+	case formatAnnouncementBBCode:
+		return renderAnnouncement(w, out, rows, true)
+	default:
+		return checkFormat(out.Format)
+	}
+}
+
+// renderDocument writes the presentation table in a document format,
+// applying the cutoff, or with GroupByYear a section and table per
+// year. Notes are footnotes to the version.
+func renderDocument(w io.Writer, out outputConfig, rows []*tableRow, doc tableDoc) error {
+	shown, hidden := applyCutoff(rows, out.Cutoff)
+	newest := latestRow(rows)
+	title := out.Title
+	if title == "" {
+		title = out.label("Syncthing Versions")
+	}
+
+	// All tables get the language, asset and platforms columns when any
+	// of them has a value to show, so that they line up.
+	cols := docColumns{
+		language:  hasLanguage(shown),
+		asset:     out.ShowAsset && hasAsset(shown),
+		platforms: out.ShowPlatforms && hasPlatforms(shown),
+	}
+	var summary []string
+	if out.Collapse && len(hidden) > 0 {
+		summary = summaryRow(hidden, out.renderConfig)
+		if cols.language {
+			summary = append(summary, languageSpan(hidden))
+		}
+		if cols.asset {
+			summary = append(summary, "")
+		}
+		if cols.platforms {
+			summary = append(summary, "")
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(doc.generated())
+	if !out.GroupByYear {
+		writeDocTable(doc.table(&sb, title), out.renderConfig, shown, newest, summary, cols)
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+
+	underline := '~'
+	if out.HeadingChar != "" {
+		underline = []rune(out.HeadingChar)[0]
+	}
+	for len(shown) > 0 {
+		// The rows are newest first, so a year's rows are together.
+		year := yearOf(shown[0].Date)
+		n := 1
+		for n < len(shown) && yearOf(shown[n].Date) == year {
+			n++
+		}
+		sb.WriteString(doc.heading(year, underline))
+		if out.YearCounts {
+			what := out.label("releases")
+			if n == 1 {
+				what = out.label("release")
+			}
+			fmt.Fprintf(&sb, "%d %s\n\n", n, what)
+		}
+		writeDocTable(doc.table(&sb, ""), out.renderConfig, shown[:n], newest, nil, cols)
+		shown = shown[n:]
+	}
+	if summary != nil {
+		sb.WriteString(doc.heading(out.label("Earlier Releases"), underline))
+		writeDocTable(doc.table(&sb, ""), out.renderConfig, nil, newest, summary, cols)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// docColumns are the optional columns of the document tables.
+type docColumns struct {
+	language  bool
+	asset     bool
+	platforms bool
+}
+
+// writeDocTable writes the rows, and the summary row if any, to the
+// table writer. It writes to a strings.Builder, which doesn't fail.
+func writeDocTable(tw tableWriter, rc renderConfig, rows []*tableRow, newest *tableRow, summary []string, cols docColumns) {
+	header := rc.header()
+	if cols.language {
+		header = append(header, rc.label(languageColumn))
+	}
+	if cols.asset {
+		header = append(header, rc.label(assetColumn))
+	}
+	if cols.platforms {
+		header = append(header, rc.label(platformsColumn))
+	}
+	_ = tw.header(header)
+	for _, r := range rows {
+		cells := rowCells(r, newest, rc)
+		if cols.language {
+			cells = append(cells, cell{text: r.Language})
+		}
+		if cols.asset {
+			cells = append(cells, cell{text: r.Asset})
+		}
+		if cols.platforms {
+			cells = append(cells, cell{text: strings.Join(strings.Fields(r.Platforms), ", ")})
+		}
+		_ = tw.row(cells)
+	}
+	if summary != nil {
+		_ = tw.row(plainCells(summary))
+	}
+	_ = tw.close()
+}
+
+// tableDoc is a document format for the presentation table: what goes
+// around the tables.
+type tableDoc interface {
+	// generated is the comment saying the file is generated.
+	generated() string
+	heading(title string, underline rune) string
+	// table returns the writer of a table with the title, if any.
+	table(w io.Writer, title string) tableWriter
+}
+
+// rstDoc writes RST includes.
+type rstDoc struct{}
+
+func (rstDoc) generated() string {
+	return ".. This file is generated by _script/histver; do not edit.\n\n"
+}
+
+func (rstDoc) heading(title string, underline rune) string {
+	return rst.Heading(rst.Escape(title), underline)
+}
+
+func (rstDoc) table(w io.Writer, title string) tableWriter {
+	return &rstTableWriter{w: w, table: rst.Table{Title: rst.Escape(title)}}
+}
+
+// markdownDoc writes Markdown, with the footnotes numbered through the
+// document.
+type markdownDoc struct {
+	notes int
+}
+
+func (*markdownDoc) generated() string {
+	return "<!-- This file is generated by _script/histver; do not edit. -->\n\n"
+}
+
+// heading is a third level heading, whatever the underline, as the
+// table sits below the headings of the page including it.
+func (*markdownDoc) heading(title string, _ rune) string {
+	return "### " + markdownEscaper.Replace(title) + "\n\n"
+}
+
+func (d *markdownDoc) table(w io.Writer, title string) tableWriter {
+	if title != "" {
+		fmt.Fprintf(w, "**%s**\n\n", markdownEscaper.Replace(title))
+	}
+	return &markdownTableWriter{w: w, next: &d.notes}
+}
+
+// yearOf returns the year of an ISO date, or the whole date if it isn't
+// one.
+func yearOf(date string) string {
+	if len(date) >= 4 {
+		return date[:4]
+	}
+	return date
+}
+
+// renderSeriesRST writes the series summary as a list-table.
+func renderSeriesRST(w io.Writer, out outputConfig, rows []*tableRow) error {
+	shown, _ := applyCutoff(rows, out.Cutoff)
+	title := out.Title
+	if title == "" {
+		title = out.label("Go Versions by Release Series")
+	}
+
+	return writeRSTTable(w, rst.Table{
+		Title:  rst.Escape(title),
+		Header: rstEscapeAll(seriesHeader(out.renderConfig)),
+		Rows:   rstEscapeRows(seriesRows(shown, out.renderConfig)),
+	})
+}
+
+// renderToolchainRST writes the releases built with a superseded Go
+// point release as a list-table.
+func renderToolchainRST(w io.Writer, out outputConfig, rows []*tableRow) error {
+	shown, _ := applyCutoff(rows, out.Cutoff)
+	title := out.Title
+	if title == "" {
+		title = out.label("Releases Built With an Outdated Go")
+	}
+
+	return writeRSTTable(w, rst.Table{
+		Title:  rst.Escape(title),
+		Header: rstEscapeAll(toolchainHeader(out.renderConfig)),
+		Rows:   rstEscapeRows(toolchainRows(shown, out.renderConfig)),
+	})
+}
+
+// renderAPIRST writes the REST endpoints added by each release as a
+// list-table, the endpoints as literals.
+func renderAPIRST(w io.Writer, out outputConfig, rows []*tableRow) error {
+	shown, _ := applyCutoff(rows, out.Cutoff)
+	title := out.Title
+	if title == "" {
+		title = out.label("REST Endpoints by Release")
+	}
+
+	var recs [][]string
+	for _, rec := range apiRows(shown, out.renderConfig) {
+		paths := strings.Fields(rec[2])
+		for i, p := range paths {
+			paths[i] = rst.Literal(p)
+		}
+		recs = append(recs, []string{rst.Escape(rec[0]), rst.Escape(rec[1]), strings.Join(paths, ", ")})
+	}
+	return writeRSTTable(w, rst.Table{
+		Title:  rst.Escape(title),
+		Header: rstEscapeAll(apiHeader(out.renderConfig)),
+		Rows:   recs,
+	})
+}
+
+// writeRSTTable writes a generated include of just the table.
+func writeRSTTable(w io.Writer, t rst.Table) error {
+	var sb strings.Builder
+	sb.WriteString(rstDoc{}.generated())
+	t.WriteTo(&sb)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// rstEscapeAll returns the plain texts escaped as RST.
+func rstEscapeAll(ss []string) []string {
+	res := make([]string, len(ss))
+	for i, s := range ss {
+		res[i] = rst.Escape(s)
+	}
+	return res
+}
+
+// rstEscapeRows returns the rows of plain texts escaped as RST.
+func rstEscapeRows(recs [][]string) [][]string {
+	res := make([][]string, len(recs))
+	for i, rec := range recs {
+		res[i] = rstEscapeAll(rec)
+	}
+	return res
+}
+
+func latestRow(rows []*tableRow) *tableRow {
+	var latest *tableRow
+	for _, r := range rows {
+		if latest == nil || compareVersions(r.Version, latest.Version) > 0 {
+			latest = r
+		}
+	}
+	return latest
+}
+
+func writeJSONTo(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate,omitempty"`
+	Description string `xml:"description"`
+}
+
+// renderRSS writes a feed with an item per release, newest first.
+func renderRSS(w io.Writer, out outputConfig, rows []*tableRow) error {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       out.Title,
+			Link:        out.Link,
+			Description: "Syncthing releases and the Go version they were built with",
+		},
+	}
+	if feed.Channel.Title == "" {
+		feed.Channel.Title = out.label("Syncthing Releases")
+	}
+	if feed.Channel.Link == "" {
+		feed.Channel.Link = "https://docs.syncthing.net/users/releases.html"
+	}
+	items := out.Items
+	if items == 0 {
+		items = 20
+	}
+	for _, r := range rows {
+		if len(feed.Channel.Items) == items {
+			break
+		}
+		link := releaseNotesURL + r.Version
+		item := rssItem{
+			Title:       "Syncthing " + r.Version,
+			Link:        link,
+			GUID:        link,
+			Description: fmt.Sprintf("Syncthing %s, built with %s.", r.Version, r.Runtime),
+		}
+		if r.Notes != "" {
+			item.Description += " " + r.Notes
+		}
+		if t, err := time.Parse(dateLayout, r.Date); err == nil {
+			item.PubDate = t.Format(time.RFC1123Z)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"unicode/utf16"
+)
+
+// plistString returns the string value for key in the top level
+// dictionary of a property list, in either the XML or the binary format.
+func plistString(bs []byte, key string) (string, error) {
+	if bytes.HasPrefix(bs, []byte("bplist00")) {
+		return bplistString(bs, key)
+	}
+	return xmlPlistString(bs, key)
+}
+
+func xmlPlistString(bs []byte, key string) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(bs))
+	depth := 0
+	wantValue := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("plist: key %s not found", key)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			// plist > dict > key/string
+			if depth != 3 {
+				wantValue = false
+				continue
+			}
+			var s string
+			if err := dec.DecodeElement(&s, &t); err != nil {
+				return "", err
+			}
+			depth--
+			switch {
+			case t.Name.Local == "key":
+				wantValue = s == key
+			case wantValue && t.Name.Local == "string":
+				return s, nil
+			default:
+				wantValue = false
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// bplistString implements just enough of the binary property list format
+// to look up a string in the top level dictionary.
+func bplistString(bs []byte, key string) (string, error) {
+	if len(bs) < 40 {
+		return "", fmt.Errorf("bplist: truncated")
+	}
+	trailer := bs[len(bs)-32:]
+	offsetSize := int(trailer[6])
+	refSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:])
+	topObject := binary.BigEndian.Uint64(trailer[16:])
+	offsetTable := binary.BigEndian.Uint64(trailer[24:])
+
+	readInt := func(off uint64, size int) (uint64, error) {
+		if off+uint64(size) > uint64(len(bs)) {
+			return 0, fmt.Errorf("bplist: truncated")
+		}
+		var v uint64
+		for _, b := range bs[off : off+uint64(size)] {
+			v = v<<8 | uint64(b)
+		}
+		return v, nil
+	}
+	objOffset := func(ref uint64) (uint64, error) {
+		if ref >= numObjects {
+			return 0, fmt.Errorf("bplist: bad object reference")
+		}
+		return readInt(offsetTable+ref*uint64(offsetSize), offsetSize)
+	}
+	// count returns the object's length and where its data starts.
+	count := func(off uint64) (uint64, uint64, error) {
+		n := uint64(bs[off] & 0x0f)
+		if n != 0x0f {
+			return n, off + 1, nil
+		}
+		// Length follows as an int object.
+		if off+2 > uint64(len(bs)) {
+			return 0, 0, fmt.Errorf("bplist: truncated")
+		}
+		size := 1 << (bs[off+1] & 0x0f)
+		n, err := readInt(off+2, size)
+		return n, off + 2 + uint64(size), err
+	}
+	str := func(ref uint64) (string, bool, error) {
+		off, err := objOffset(ref)
+		if err != nil || off >= uint64(len(bs)) {
+			return "", false, fmt.Errorf("bplist: bad object offset")
+		}
+		n, data, err := count(off)
+		if err != nil {
+			return "", false, err
+		}
+		switch bs[off] >> 4 {
+		case 0x5: // ASCII
+			if data+n > uint64(len(bs)) {
+				return "", false, fmt.Errorf("bplist: truncated")
+			}
+			return string(bs[data : data+n]), true, nil
+		case 0x6: // UTF-16BE
+			if data+2*n > uint64(len(bs)) {
+				return "", false, fmt.Errorf("bplist: truncated")
+			}
+			u16 := make([]uint16, n)
+			for i := range u16 {
+				u16[i] = binary.BigEndian.Uint16(bs[data+2*uint64(i):])
+			}
+			return string(utf16.Decode(u16)), true, nil
+		}
+		return "", false, nil
+	}
+
+	off, err := objOffset(topObject)
+	if err != nil || off >= uint64(len(bs)) || bs[off]>>4 != 0xd {
+		return "", fmt.Errorf("bplist: top object is not a dictionary")
+	}
+	n, data, err := count(off)
+	if err != nil {
+		return "", err
+	}
+	for i := uint64(0); i < n; i++ {
+		keyRef, err := readInt(data+i*uint64(refSize), refSize)
+		if err != nil {
+			return "", err
+		}
+		if k, ok, err := str(keyRef); err != nil || !ok || k != key {
+			continue
+		}
+		valRef, err := readInt(data+(n+i)*uint64(refSize), refSize)
+		if err != nil {
+			return "", err
+		}
+		v, ok, err := str(valRef)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("bplist: %s is not a string", key)
+		}
+		return v, nil
+	}
+	return "", fmt.Errorf("bplist: key %s not found", key)
+}
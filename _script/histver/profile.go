@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"runtime/trace"
+)
+
+// profiling writes the CPU profile, heap profile and execution trace of a
+// run, for those with a file set.
+type profiling struct {
+	CPUFile   string
+	MemFile   string
+	TraceFile string
+
+	cpu, trace *os.File
+}
+
+func (p *profiling) start() error {
+	if p.CPUFile != "" {
+		fd, err := os.Create(p.CPUFile)
+		if err != nil {
+			return err
+		}
+		if err := runtimepprof.StartCPUProfile(fd); err != nil {
+			fd.Close()
+			return err
+		}
+		p.cpu = fd
+	}
+	if p.TraceFile != "" {
+		fd, err := os.Create(p.TraceFile)
+		if err != nil {
+			return err
+		}
+		if err := trace.Start(fd); err != nil {
+			fd.Close()
+			return err
+		}
+		p.trace = fd
+	}
+	return nil
+}
+
+// stop finishes the profiles. It's deferred in run, so runs returning an
+// error get them too.
+func (p *profiling) stop() error {
+	if p.cpu != nil {
+		runtimepprof.StopCPUProfile()
+		if err := p.cpu.Close(); err != nil {
+			return err
+		}
+	}
+	if p.trace != nil {
+		trace.Stop()
+		if err := p.trace.Close(); err != nil {
+			return err
+		}
+	}
+	if p.MemFile != "" {
+		fd, err := os.Create(p.MemFile)
+		if err != nil {
+			return err
+		}
+		// Up to date statistics, as of the last garbage collection.
+		runtime.GC()
+		if err := runtimepprof.WriteHeapProfile(fd); err != nil {
+			fd.Close()
+			return err
+		}
+		return fd.Close()
+	}
+	return nil
+}
+
+// handlePprof adds the pprof handlers under /debug/pprof/.
+func handlePprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
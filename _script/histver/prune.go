@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// pruneRows returns the table without the rows that shouldn't be in it,
+// and why each removed row was: versions the tag pattern doesn't accept,
+// as after narrowing it, and those whose GitHub release is a prerelease
+// or a draft, as when a release was marked one after the fact. Rows
+// maintained by hand are kept, as are those without a GitHub release.
+func pruneRows(table []*tableRow, releases []*github.RepositoryRelease) ([]*tableRow, []string) {
+	byTag := make(map[string]*github.RepositoryRelease, len(releases))
+	for _, rel := range releases {
+		byTag[rel.GetTagName()] = rel
+	}
+	var kept []*tableRow
+	var pruned []string
+	for _, row := range table {
+		var why string
+		rel := byTag[row.Version]
+		_, matching := parseVersion(row.Version)
+		switch {
+		case row.Manual:
+		case !matching:
+			why = "not matching the tag pattern"
+		case rel.GetPrerelease():
+			why = "a prerelease"
+		case rel.GetDraft():
+			why = "a draft"
+		}
+		if why == "" {
+			kept = append(kept, row)
+			continue
+		}
+		pruned = append(pruned, fmt.Sprintf("%s: %s", row.Version, why))
+	}
+	return kept, pruned
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// publishConfig describes an S3 compatible bucket to upload the generated
+// files to. This works with AWS S3, Google Cloud Storage (using HMAC keys
+// and the storage.googleapis.com endpoint) and most self hosted object
+// stores. Credentials are taken from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and optionally AWS_SESSION_TOKEN environment
+// variables.
+type publishConfig struct {
+	Endpoint string `json:"endpoint"` // e.g. https://s3.eu-north-1.amazonaws.com
+	Region   string `json:"region"`   // signing region, "auto" or "us-east-1" for most non-AWS stores
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix"` // prepended to the file names to form object keys
+	// PathStyle addresses the bucket as endpoint/bucket/key rather than
+	// bucket.endpoint/key.
+	PathStyle bool `json:"pathStyle"`
+	// CacheControl is the Cache-Control header set on every object.
+	CacheControl string `json:"cacheControl"`
+}
+
+var contentTypes = map[string]string{
+	".csv":  "text/csv; charset=utf-8",
+	".json": "application/json",
+	".rss":  "application/rss+xml",
+	".xml":  "application/xml",
+	".html": "text/html; charset=utf-8",
+	".rst":  "text/x-rst; charset=utf-8",
+	".md":   "text/markdown; charset=utf-8",
+}
+
+// publishFiles uploads each of the files to the bucket, using the base
+// name of the file as the object name.
+func publishFiles(pc publishConfig, files []string) error {
+	creds := s3Credentials{
+		accessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		token:     os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.accessKey == "" || creds.secretKey == "" {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	cacheControl := pc.CacheControl
+	if cacheControl == "" {
+		cacheControl = "public, max-age=300"
+	}
+	region := pc.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	for _, file := range files {
+		bs, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		key := path.Join(pc.Prefix, filepath.Base(file))
+		u, err := objectURL(pc, key)
+		if err != nil {
+			return err
+		}
+		ct := contentTypes[strings.ToLower(filepath.Ext(file))]
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+
+		req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(bs))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", ct)
+		req.Header.Set("Cache-Control", cacheControl)
+		creds.sign(req, bs, region, time.Now())
+
+		resp, err := httpclient.Default.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("uploading %s: %s", key, resp.Status)
+		}
+		log.Println("Published", u.Redacted())
+	}
+	return nil
+}
+
+func objectURL(pc publishConfig, key string) (*url.URL, error) {
+	u, err := url.Parse(pc.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("endpoint %q is not an absolute URL", pc.Endpoint)
+	}
+	if pc.PathStyle {
+		u.Path = "/" + pc.Bucket + "/" + key
+	} else {
+		u.Host = pc.Bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	return u, nil
+}
+
+type s3Credentials struct {
+	accessKey string
+	secretKey string
+	token     string
+}
+
+// sign adds an AWS Signature Version 4 to the request.
+func (c s3Credentials) sign(req *http.Request, payload []byte, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if c.token != "" {
+		req.Header.Set("X-Amz-Security-Token", c.token)
+		signed = append(signed, "x-amz-security-token")
+	}
+
+	var canonHeaders strings.Builder
+	for _, h := range signed {
+		v := req.Header.Get(h)
+		if h == "host" {
+			v = req.URL.Host
+		}
+		fmt.Fprintf(&canonHeaders, "%s:%s\n", h, strings.TrimSpace(v))
+	}
+	signedHeaders := strings.Join(signed, ";")
+	canonReq := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	reqSum := sha256.Sum256([]byte(canonReq))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(reqSum[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", c.accessKey, scope, signedHeaders, sig))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EscapePath escapes each path segment the way the signature requires:
+// everything except unreserved characters is percent encoded.
+func s3EscapePath(p string) string {
+	var sb strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// remoteOptions is the docs repository to work in, instead of the
+// checkout we're run from.
+type remoteOptions struct {
+	URL    string // clone URL of the docs repository
+	Branch string // branch to update and push to
+	// Dir keeps the clone between runs, updating it to the branch each
+	// time; a temporary directory is used when empty.
+	Dir   string
+	Token string
+}
+
+// remoteCred is the basic authentication credential for the remote
+// repository, if any, and remoteConfig the configuration file of its
+// clone.
+var remoteCred, remoteConfig string
+
+// credPlaceholder stands in for the credential on the git command line.
+const credPlaceholder = "AUTHORIZATION: basic ***"
+
+// openRemote clones the docs repository, or updates the existing clone to
+// the remote branch, and changes to its _script directory so that the
+// paths given on the command line are relative to it as usual. It returns
+// a function removing a temporary clone.
+func openRemote(opts remoteOptions) (func(), error) {
+	if opts.Token != "" && strings.HasPrefix(opts.URL, "https://") {
+		remoteCred = base64.StdEncoding.EncodeToString([]byte("x-access-token:" + opts.Token))
+	}
+
+	dir, cleanup := opts.Dir, func() {}
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "histver-docs")
+		if err != nil {
+			return nil, err
+		}
+		dir, cleanup = tmp, func() { os.RemoveAll(tmp) }
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	remoteConfig = filepath.Join(dir, ".git", "config")
+	_, statErr := os.Stat(filepath.Join(dir, ".git"))
+	cloned := statErr != nil
+	if err = os.MkdirAll(dir, 0o755); err == nil {
+		err = os.Chdir(dir)
+	}
+	if err == nil && cloned {
+		log.Println("Cloning", opts.URL, "into", dir)
+		err = git("init", "--quiet")
+		if err == nil {
+			err = git("remote", "add", "origin", opts.URL)
+		}
+	} else if err == nil {
+		log.Println("Updating", dir)
+	}
+	if err == nil {
+		err = gitRemote("fetch", "--depth", "1", "origin", opts.Branch)
+	}
+	if err == nil {
+		err = git("checkout", "--force", "-B", opts.Branch, "FETCH_HEAD")
+	}
+	if err == nil {
+		err = os.Chdir("_script")
+	}
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("%s: %w", opts.URL, err)
+	}
+	return cleanup, nil
+}
+
+// pushRemote pushes the committed changes to the branch of the remote
+// repository.
+func pushRemote(opts remoteOptions) error {
+	return gitRemote("push", "origin", "HEAD:"+opts.Branch)
+}
+
+// gitRemote runs a git command talking to the remote repository, with
+// its credential, if any, in the clone's local configuration for the
+// duration. As actions/checkout does, the command line has a placeholder
+// that is then replaced in the configuration file, so that the token is
+// never on a command line.
+func gitRemote(args ...string) error {
+	if remoteCred == "" {
+		return git(args...)
+	}
+	if err := git("config", "--local", "http.extraheader", credPlaceholder); err != nil {
+		return err
+	}
+	err := replaceInFile(remoteConfig, credPlaceholder, "AUTHORIZATION: basic "+remoteCred)
+	if err == nil {
+		err = git(args...)
+	}
+	if unsetErr := git("config", "--local", "--unset-all", "http.extraheader"); err == nil {
+		err = unsetErr
+	}
+	return err
+}
+
+// replaceInFile replaces the first occurrence of old in the file.
+func replaceInFile(path, old, repl string) error {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(bs, []byte(old)) {
+		return fmt.Errorf("%s: %q not found", path, old)
+	}
+	return os.WriteFile(path, bytes.Replace(bs, []byte(old), []byte(repl), 1), 0o600)
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// renderTable writes the rows in table order as CSV, applying the
+// cutoff. The rows must already be sorted as by writeTable.
+func renderTable(w io.Writer, rc renderConfig, rows []*tableRow) error {
+	shown, hidden := applyCutoff(rows, rc.Cutoff)
+
+	// Language versions, assets, platforms and notes get columns of
+	// their own, if there are any to show.
+	withLanguage, withAsset, withNotes := hasLanguage(shown), rc.ShowAsset && hasAsset(shown), hasNotes(shown)
+	withPlatforms := rc.ShowPlatforms && hasPlatforms(shown)
+	header := rc.header()
+	if withLanguage {
+		header = append(header, rc.label(languageColumn))
+	}
+	if withAsset {
+		header = append(header, rc.label(assetColumn))
+	}
+	if withPlatforms {
+		header = append(header, rc.label(platformsColumn))
+	}
+	if withNotes {
+		header = append(header, rc.label(notesColumn))
+	}
+
+	tw := newCSVTableWriter(w)
+	if err := tw.header(header); err != nil {
+		return err
+	}
+	newest := latestRow(rows)
+	for _, r := range shown {
+		cells := rowCells(r, newest, rc)
+		if withLanguage {
+			cells = append(cells, cell{text: r.Language})
+		}
+		if withAsset {
+			cells = append(cells, cell{text: r.Asset})
+		}
+		if withPlatforms {
+			cells = append(cells, cell{text: r.Platforms})
+		}
+		if withNotes {
+			cells = append(cells, cell{text: r.Notes})
+		}
+		if err := tw.row(cells); err != nil {
+			return err
+		}
+	}
+	if rc.Collapse && len(hidden) > 0 {
+		rec := summaryRow(hidden, rc)
+		if withLanguage {
+			rec = append(rec, languageSpan(hidden))
+		}
+		if withAsset {
+			rec = append(rec, "")
+		}
+		if withPlatforms {
+			rec = append(rec, "")
+		}
+		if withNotes {
+			rec = append(rec, "")
+		}
+		if err := tw.row(plainCells(rec)); err != nil {
+			return err
+		}
+	}
+	return tw.close()
+}
+
+func hasLanguage(rows []*tableRow) bool {
+	for _, r := range rows {
+		if r.Language != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAsset(rows []*tableRow) bool {
+	for _, r := range rows {
+		if r.Asset != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// languageSpan returns the range of the known language versions of the
+// rows, or nothing if none are known.
+func languageSpan(rows []*tableRow) string {
+	var lo, hi string
+	for _, r := range rows {
+		if r.Language == "" {
+			continue
+		}
+		if lo == "" || compareGoVersions(r.Language, lo) < 0 {
+			lo = r.Language
+		}
+		if hi == "" || compareGoVersions(r.Language, hi) > 0 {
+			hi = r.Language
+		}
+	}
+	return span(lo, hi)
+}
+
+func hasPlatforms(rows []*tableRow) bool {
+	for _, r := range rows {
+		if r.Platforms != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNotes(rows []*tableRow) bool {
+	for _, r := range rows {
+		if r.Notes != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// renderSeries writes a summary with a row per minor series, newest
+// first: the Go versions used across its releases and the dates of its
+// first and last release.
+func renderSeries(w io.Writer, rc renderConfig, rows []*tableRow) error {
+	shown, _ := applyCutoff(rows, rc.Cutoff)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(seriesHeader(rc)); err != nil {
+		return err
+	}
+	for _, rec := range seriesRows(shown, rc) {
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func seriesHeader(rc renderConfig) []string {
+	return []string{rc.label("Series"), rc.label("Runtime"), rc.label("First Release"), rc.label("Last Release")}
+}
+
+// seriesRows returns the summary rows of each minor series in the rows.
+// Rows with versions not matching the tag pattern are left out.
+func seriesRows(rows []*tableRow, rc renderConfig) [][]string {
+	var order []string
+	bySeries := make(map[string][]*tableRow)
+	for _, r := range rows {
+		parts, ok := parseVersion(r.Version)
+		if !ok || len(parts) < 2 {
+			continue
+		}
+		series := fmt.Sprintf("v%d.%d", parts[0], parts[1])
+		if _, ok := bySeries[series]; !ok {
+			order = append(order, series)
+		}
+		bySeries[series] = append(bySeries[series], r)
+	}
+	sort.Slice(order, func(a, b int) bool { return compareVersions(order[a]+".0", order[b]+".0") > 0 })
+
+	var res [][]string
+	for _, series := range order {
+		rs := bySeries[series]
+		minGo, maxGo := rs[0].Runtime, rs[0].Runtime
+		first, last := rs[0].Date, rs[0].Date
+		for _, r := range rs[1:] {
+			if compareGoVersions(r.Runtime, minGo) < 0 {
+				minGo = r.Runtime
+			}
+			if compareGoVersions(r.Runtime, maxGo) > 0 {
+				maxGo = r.Runtime
+			}
+			if r.Date < first {
+				first = r.Date
+			}
+			if r.Date > last {
+				last = r.Date
+			}
+		}
+		res = append(res, []string{series, span(minGo, maxGo), rc.formatDate(first), rc.formatDate(last)})
+	}
+	return res
+}
+
+// renderToolchain writes the releases built with a Go point release that
+// had already been superseded by a security fix, newest first.
+func renderToolchain(w io.Writer, rc renderConfig, rows []*tableRow) error {
+	shown, _ := applyCutoff(rows, rc.Cutoff)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(toolchainHeader(rc)); err != nil {
+		return err
+	}
+	for _, rec := range toolchainRows(shown, rc) {
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func toolchainHeader(rc renderConfig) []string {
+	return []string{rc.label("Version"), rc.label("Date"), rc.label("Runtime"), rc.label("Superseded By")}
+}
+
+func toolchainRows(rows []*tableRow, rc renderConfig) [][]string {
+	var res [][]string
+	for _, r := range rows {
+		if r.GoSuperseded != "" {
+			res = append(res, []string{r.Version, rc.formatDate(r.Date), r.Runtime, r.GoSuperseded})
+		}
+	}
+	return res
+}
+
+// renderAPI writes the REST endpoints added by each release, newest
+// first.
+func renderAPI(w io.Writer, rc renderConfig, rows []*tableRow) error {
+	shown, _ := applyCutoff(rows, rc.Cutoff)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(apiHeader(rc)); err != nil {
+		return err
+	}
+	for _, rec := range apiRows(shown, rc) {
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func apiHeader(rc renderConfig) []string {
+	return []string{rc.label("Version"), rc.label("Date"), rc.label("Endpoints Added")}
+}
+
+func apiRows(rows []*tableRow, rc renderConfig) [][]string {
+	var res [][]string
+	for _, r := range rows {
+		if r.APIAdded != "" {
+			res = append(res, []string{r.Version, rc.formatDate(r.Date), r.APIAdded})
+		}
+	}
+	return res
+}
+
+// applyCutoff splits the rows into those at or after the cutoff version
+// and those before it.
+func applyCutoff(rows []*tableRow, cutoff string) (shown, hidden []*tableRow) {
+	if cutoff == "" {
+		return rows, nil
+	}
+	for _, r := range rows {
+		if compareVersions(r.Version, cutoff) < 0 {
+			hidden = append(hidden, r)
+		} else {
+			shown = append(shown, r)
+		}
+	}
+	return shown, hidden
+}
+
+// summaryRow describes a set of rows as the range of versions, runtimes
+// and dates they cover.
+func summaryRow(rows []*tableRow, rc renderConfig) []string {
+	oldest, newest := rows[0], rows[0]
+	minGo, maxGo := rows[0].Runtime, rows[0].Runtime
+	minDate, maxDate := rows[0].Date, rows[0].Date
+	for _, r := range rows[1:] {
+		if compareVersions(r.Version, oldest.Version) < 0 {
+			oldest = r
+		}
+		if compareVersions(r.Version, newest.Version) > 0 {
+			newest = r
+		}
+		if compareGoVersions(r.Runtime, minGo) < 0 {
+			minGo = r.Runtime
+		}
+		if compareGoVersions(r.Runtime, maxGo) > 0 {
+			maxGo = r.Runtime
+		}
+		if r.Date < minDate {
+			minDate = r.Date
+		}
+		if r.Date > maxDate {
+			maxDate = r.Date
+		}
+	}
+	return []string{
+		fmt.Sprintf("%s – %s (%d %s)", oldest.Version, newest.Version, len(rows), rc.label("releases")),
+		span(minGo, maxGo),
+		span(rc.formatDate(minDate), rc.formatDate(maxDate)),
+	}
+}
+
+func span(a, b string) string {
+	if a == b {
+		return a
+	}
+	return a + " – " + b
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// replacedGrace is how long after the release is published its assets
+// may still be updated, by the release process uploading them, before
+// an update counts as a replacement.
+const replacedGrace = 24 * time.Hour
+
+// checkReplaced compares the assets the rows were derived from to the
+// current release metadata and returns the rows whose asset has since
+// been removed or replaced: its digest differs from the one recorded, or
+// it was updated after the release was published.
+func checkReplaced(ctx context.Context, client *github.Client, owner, repo string, releases []*github.RepositoryRelease, rows []*tableRow) ([]string, error) {
+	byTag := make(map[string]*github.RepositoryRelease, len(releases))
+	for _, rel := range releases {
+		byTag[rel.GetTagName()] = rel
+	}
+
+	var problems []string
+	for _, row := range rows {
+		if row.Asset == "" || row.Frozen {
+			continue
+		}
+		rel, ok := byTag[row.Version]
+		if !ok {
+			continue
+		}
+		var asset *github.ReleaseAsset
+		for _, a := range rel.Assets {
+			if a.GetName() == row.Asset {
+				asset = a
+				break
+			}
+		}
+		if asset == nil {
+			problems = append(problems, fmt.Sprintf("%s: asset %s is no longer in the release", row.Version, row.Asset))
+			continue
+		}
+
+		if published := rel.GetPublishedAt(); !published.IsZero() && asset.GetUpdatedAt().After(published.Add(replacedGrace)) {
+			problems = append(problems, fmt.Sprintf("%s: asset %s was updated %s, after the release was published %s", row.Version, row.Asset, asset.GetUpdatedAt().UTC().Format(time.RFC3339), published.UTC().Format(time.RFC3339)))
+			continue
+		}
+
+		if row.AssetDigest == "" {
+			continue
+		}
+		digests, err := releaseAssetDigests(ctx, client, owner, repo, rel.GetID())
+		if err != nil {
+			return problems, fmt.Errorf("%s: %w", row.Version, err)
+		}
+		if d := digests[asset.GetID()]; d != "" && d != row.AssetDigest {
+			problems = append(problems, fmt.Sprintf("%s: asset %s has digest %s, the row was derived from %s", row.Version, row.Asset, d, row.AssetDigest))
+		}
+	}
+	return problems, nil
+}
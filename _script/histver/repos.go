@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"debug/buildinfo"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// repoConfig is a repository besides the core's whose releases are
+// tracked, each in a versions table of its own.
+type repoConfig struct {
+	// Repo is the repository as owner/name.
+	Repo string `json:"repo"`
+	// File is the versions CSV file.
+	File string `json:"file"`
+	// Kind is how the rows are derived from the release assets: macos
+	// and android for the apps bundling the core, reading the core's
+	// build info and the app's declared version, and binary for the
+	// releases of other Go programs, such as the relay and discovery
+	// servers, reading the program's build info. The default is by the
+	// repository, binary for those not known to be apps.
+	Kind string `json:"kind"`
+	// Assets are shell patterns (as for path.Match) for the names of
+	// the assets holding the program, for the binary kind, which
+	// otherwise takes the assets selected by the asset patterns. The
+	// first one with a Go binary is inspected.
+	Assets []string `json:"assets"`
+}
+
+// Kinds of tracked repositories.
+const (
+	repoKindMacOS   = "macos"
+	repoKindAndroid = "android"
+	repoKindBinary  = "binary"
+)
+
+// appRepoKinds are the kinds of the repositories of the apps.
+var appRepoKinds = map[string]string{
+	"syncthing/syncthing-macos":   repoKindMacOS,
+	"syncthing/syncthing-android": repoKindAndroid,
+}
+
+// repoStrategy is how the row for a release of a tracked repository is
+// derived.
+type repoStrategy interface {
+	releaseRow(repo string, rel *github.RepositoryRelease) (*wrapperRow, error)
+}
+
+// parseRepoFlag parses a -repo value, owner/name=file.
+func parseRepoFlag(s string) (repoConfig, error) {
+	repo, file, ok := strings.Cut(s, "=")
+	if !ok || file == "" {
+		return repoConfig{}, fmt.Errorf("%q is not owner/name=file", s)
+	}
+	rc := repoConfig{Repo: repo, File: file}
+	if _, err := rc.strategy(); err != nil {
+		return repoConfig{}, err
+	}
+	return rc, nil
+}
+
+// strategy returns the strategy for the kind of the repository.
+func (rc repoConfig) strategy() (repoStrategy, error) {
+	if owner, name, ok := strings.Cut(rc.Repo, "/"); !ok || owner == "" || name == "" {
+		return nil, fmt.Errorf("repository %q is not owner/name", rc.Repo)
+	}
+	for _, s := range rc.Assets {
+		if _, err := path.Match(s, ""); err != nil {
+			return nil, fmt.Errorf("%s: asset pattern %q: %w", rc.Repo, s, err)
+		}
+	}
+	kind := rc.Kind
+	if kind == "" {
+		kind = appRepoKinds[rc.Repo]
+	}
+	switch kind {
+	case repoKindMacOS:
+		return macosWrapper, nil
+	case repoKindAndroid:
+		return androidWrapper, nil
+	case repoKindBinary, "":
+		return binaryRepo{patterns: rc.Assets}, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown kind %q", rc.Repo, rc.Kind)
+	}
+}
+
+// syncRepo adds the releases of the repository missing from its
+// versions table.
+func syncRepo(ctx context.Context, rc repoConfig) error {
+	strategy, err := rc.strategy()
+	if err != nil {
+		return err
+	}
+	owner, name, _ := strings.Cut(rc.Repo, "/")
+	releases, err := getReleases(ctx, owner, name)
+	if err != nil {
+		return fmt.Errorf("listing GitHub releases: %w", err)
+	}
+
+	var table []*wrapperRow
+	fd, err := os.Open(rc.File)
+	if os.IsNotExist(err) {
+		// File doesn't exist yet. That's allright.
+	} else if err != nil {
+		return err
+	} else {
+		table, err = readWrapperTable(fd)
+		fd.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[string]struct{})
+	for _, row := range table {
+		seen[row.Version] = struct{}{}
+	}
+
+	for _, rel := range releases {
+		if _, ok := seen[*rel.TagName]; ok {
+			continue
+		}
+		log.Println("Checking", rc.Repo, *rel.TagName)
+		row, err := strategy.releaseRow(rc.Repo, rel)
+		if err != nil {
+			log.Printf("%s %s: %v", rc.Repo, *rel.TagName, err)
+			continue
+		}
+		table = append(table, row)
+	}
+
+	tw, err := os.Create(rc.File)
+	if err != nil {
+		return err
+	}
+	if err := writeWrapperTable(tw, table); err != nil {
+		tw.Close()
+		return err
+	}
+	return tw.Close()
+}
+
+// binaryRepo derives the rows of the releases of a Go program from the
+// build info of the program in the assets.
+type binaryRepo struct {
+	patterns []string
+}
+
+func (b binaryRepo) releaseRow(repo string, rel *github.RepositoryRelease) (*wrapperRow, error) {
+	for _, asset := range b.assets(repo, rel) {
+		data, err := fetchAsset(asset, archiveBudget)
+		if err != nil {
+			return nil, err
+		}
+		bin, err := goBinary(data, data.size)
+		if err != nil {
+			data.Close()
+			log.Printf("%s %s: %s: %v", repo, rel.GetTagName(), asset.GetName(), err)
+			continue
+		}
+		row, err := goBinaryRow(bin.section(), rel)
+		bin.Close()
+		data.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", asset.GetName(), err)
+		}
+		return row, nil
+	}
+	return nil, fmt.Errorf("no asset with a Go binary")
+}
+
+// goBinaryRow derives the row of the release from the build info of the
+// binary.
+func goBinaryRow(bin *io.SectionReader, rel *github.RepositoryRelease) (*wrapperRow, error) {
+	bin, err := thinMachO(bin)
+	if err != nil {
+		return nil, err
+	}
+	info, err := buildinfo.Read(bin)
+	if err != nil {
+		return nil, err
+	}
+	// The version is checked when the build script set one.
+	if version, _, err := buildInfoVersion(bin); err == nil && !sameVersion(version, rel.GetTagName()) {
+		return nil, fmt.Errorf("binary is %s", version)
+	}
+	return &wrapperRow{
+		Version: rel.GetTagName(),
+		Runtime: info.GoVersion,
+		Date:    releaseDate(rel, dateSourcePublished, ""),
+	}, nil
+}
+
+// assets returns the assets of the release matching the patterns, or the
+// asset patterns without any.
+func (b binaryRepo) assets(repo string, rel *github.RepositoryRelease) []*github.ReleaseAsset {
+	if len(b.patterns) == 0 {
+		return releaseAssets(repo, rel, "*", "*")
+	}
+	var res []*github.ReleaseAsset
+	for _, asset := range rel.Assets {
+		for _, s := range b.patterns {
+			if ok, _ := path.Match(s, asset.GetName()); ok {
+				res = append(res, asset)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// goBinary returns the first Go binary in the archive, or the asset
+// itself if it's one. Binaries in archives are spooled to disk when they
+// don't fit in archiveBudget. The returned binary must be closed.
+func goBinary(ra io.ReaderAt, size int64) (*assetData, error) {
+	if isExecutable(readHead(ra)) {
+		// Closing it leaves the asset alone.
+		return &assetData{ReaderAt: ra, size: size}, nil
+	}
+	var bin *assetData
+	err := walkArchive(ra, size, func(name string, size int64, r io.Reader) error {
+		if bin != nil {
+			return nil
+		}
+		var head [4]byte
+		n, _ := io.ReadFull(r, head[:])
+		if !isExecutable(head[:n]) {
+			return nil
+		}
+		data, err := spoolAsset(io.MultiReader(bytes.NewReader(head[:n]), r), size, archiveBudget)
+		if err != nil {
+			return err
+		}
+		if sec, err := thinMachO(data.section()); err == nil {
+			if _, err := buildinfo.Read(sec); err == nil {
+				bin = data
+				return nil
+			}
+		}
+		data.Close()
+		return nil
+	})
+	if err != nil {
+		bin.Close()
+		return nil, err
+	}
+	if bin == nil {
+		return nil, fmt.Errorf("no Go binary found")
+	}
+	return bin, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tableServer serves the versions table over HTTP:
+//
+//	/versions.json   all rows, newest first
+//	/latest          the highest version
+//	/version/{tag}   a single version
+//
+// The file is re-read when it changes on disk, so the server can keep
+// running while the table is updated.
+type tableServer struct {
+	file string
+
+	mut     sync.Mutex
+	modTime time.Time
+	rows    []*tableRow
+}
+
+// serveTable serves the table, and the pprof handlers when withPprof is
+// set.
+func serveTable(addr, file string, withPprof bool) error {
+	s := &tableServer{file: file}
+	if _, err := s.table(); err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/versions.json", s.handleVersions)
+	mux.HandleFunc("/latest", s.handleLatest)
+	mux.HandleFunc("/version/", s.handleVersion)
+	if withPprof {
+		handlePprof(mux)
+	}
+	log.Println("Serving", file, "on", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+func (s *tableServer) table() ([]*tableRow, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	info, err := os.Stat(s.file)
+	if err != nil {
+		return nil, err
+	}
+	if s.rows != nil && info.ModTime().Equal(s.modTime) {
+		return s.rows, nil
+	}
+	fd, err := os.Open(s.file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	rows, err := readTable(fd)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ = dedupeRows(rows)
+	sortRows(rows)
+	s.rows, s.modTime = rows, info.ModTime()
+	return rows, nil
+}
+
+func (s *tableServer) handleVersions(w http.ResponseWriter, req *http.Request) {
+	rows, err := s.table()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, rows)
+}
+
+func (s *tableServer) handleLatest(w http.ResponseWriter, req *http.Request) {
+	rows, err := s.table()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	latest := latestRow(rows)
+	if latest == nil {
+		http.NotFound(w, req)
+		return
+	}
+	writeJSON(w, latest)
+}
+
+func (s *tableServer) handleVersion(w http.ResponseWriter, req *http.Request) {
+	tag := strings.TrimPrefix(req.URL.Path, "/version/")
+	rows, err := s.table()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, r := range rows {
+		if r.Version == tag || strings.TrimPrefix(r.Version, "v") == tag {
+			writeJSON(w, r)
+			return
+		}
+	}
+	http.NotFound(w, req)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Println("Writing response:", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	modulePath = "github.com/syncthing/syncthing"
+	sumDBURL   = "https://sum.golang.org/lookup/%s@%s"
+)
+
+// moduleHash returns the hash of the module zip of the release tag, as in
+// go.sum (h1:...), from the Go checksum database.
+func moduleHash(tag string) (string, error) {
+	path := releaseModulePath(tag)
+	url := moduleLookupURL(tag)
+	resp, err := tagClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// The response is the record number, the go.sum lines for the module
+	// and its go.mod, and the signed tree head.
+	for _, line := range strings.Split(string(bs), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == path && fields[1] == tag {
+			return fields[2], nil
+		}
+	}
+	return "", fmt.Errorf("%s: no hash for the module", url)
+}
+
+// releaseModulePath returns the module path of the release tag. Major
+// versions from v2 on have the major version in the path.
+func releaseModulePath(tag string) string {
+	if parts, ok := parseVersion(tag); ok && parts[0] >= 2 {
+		return fmt.Sprintf("%s/v%d", modulePath, parts[0])
+	}
+	return modulePath
+}
+
+func moduleLookupURL(tag string) string {
+	return fmt.Sprintf(sumDBURL, releaseModulePath(tag), tag)
+}
+
+// fillModuleHashes sets the module hash of the rows that don't have one
+// yet. Releases from before Syncthing was a module, or that were never
+// fetched through the module proxy, aren't in the checksum database and
+// are left as they are.
+func fillModuleHashes(rows []*tableRow, audit *auditLog) {
+	for _, r := range rows {
+		if r.ModuleHash != "" {
+			continue
+		}
+		hash, err := moduleHash(r.Version)
+		if err != nil {
+			log.Printf("%s: module hash: %v", r.Version, err)
+			continue
+		}
+		r.ModuleHash = hash
+		audit.record(r.Version, "ModuleHash", hash, methodSumDB, moduleLookupURL(r.Version))
+	}
+}
@@ -1,49 +1,475 @@
 package main
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"bytes"
-	"compress/gzip"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
-	"path"
-	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/cache"
+	"syncthing.net/docs/internal/httpclient"
+	"syncthing.net/docs/internal/rstdoc"
+	"syncthing.net/docs/internal/signedsums"
+	"syncthing.net/docs/internal/versions"
 )
 
 func main() {
+	err := run()
+	var code exitCode
+	switch {
+	case errors.As(err, &code):
+		os.Exit(int(code))
+	case err != nil:
+		log.Fatalln(err)
+	}
+}
+
+// exitCode is an error making main exit with the code, for the commands
+// that have already printed why.
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+// run does the work of main, returning errors instead of exiting so that
+// the deferred calls, such as the one removing a temporary -remote clone,
+// are made.
+func run() (err error) {
 	versionsFile := flag.String("file", "versions.csv", "Path to versions CSV file")
+	macosFile := flag.String("macos-file", "", "Path to syncthing-macos versions CSV file (enables tracking of the macOS wrapper)")
+	androidFile := flag.String("android-file", "", "Path to syncthing-android versions CSV file (enables tracking of the Android app)")
+	var repos []repoConfig
+	flag.Func("repo", "Track the releases of another repository, as owner/name=file, in a versions CSV file of its own (repeatable)", func(s string) error {
+		rc, err := parseRepoFlag(s)
+		repos = append(repos, rc)
+		return err
+	})
+	tagPattern := flag.String("tag-pattern", defaultTagPattern, "Regular expression for release tags to include; the first group, if any, is the dotted version")
+	dateSource := flag.String("date-source", dateSourceBuild, "Source of release dates (build, published, created)")
+	fixDates := flag.Bool("fix-dates", false, "Normalize and repair dates of existing rows")
+	fillLang := flag.Bool("fill-language", false, "Look up the go.mod language version of existing rows without one")
+	force := flag.Bool("force", false, "Include frozen rows, whose release assets are gone, in -deep-all, -fill-language and -fill-hashes")
+	fillHashes := flag.Bool("fill-hashes", false, "Look up the module hash of existing rows without one")
+	fillAPI := flag.Bool("fill-api", false, "Look up the REST endpoints added in each minor release, in the -src checkout")
+	src := flag.String("src", "", "Syncthing source directory, a git clone with the release tags (-fill-api)")
+	fillPlats := flag.Bool("fill-platforms", false, "Look up the platforms of the release assets of existing rows without them")
+	fillGoSec := flag.Bool("fill-go-security", false, "Mark the existing rows built with a Go point release already superseded by a security fix, from the Go release history")
+	backfill := flag.String("backfill-dates", "", "Comma separated announcement sources, forum or the URL or file of an RSS or Atom feed, to date the releases without a GitHub release by")
+	configFile := flag.String("config", "", "Path to JSON configuration file")
+	deep := flag.Bool("deep", false, "Inspect the assets for all platforms of each new release")
+	deepAll := flag.Bool("deep-all", false, "Inspect the assets for all platforms of every release in the table, as -deep does for the new ones")
+	flag.StringVar(&githubToken, "token", "", "GitHub token for the API requests, for the higher rate limit, and for -pr and -remote (default $GITHUB_TOKEN)")
+	jobs := flag.Int("jobs", 4, "Number of new releases whose assets to download and inspect at once")
+	prefetch := flag.Int("prefetch", 1, "Number of downloaded assets that may wait for inspection while the next one downloads (-deep, -deep-all, audit)")
+	auditFile := flag.String("audit", "", "Path to a JSON file recording where the values in the table came from")
+	budgetMiB := flag.Int64("memory-budget", 0, "Memory in MiB for downloaded archives held at once, beyond which they're spooled to disk (0 for no limit)")
+	insecure := flag.Bool("insecure", false, "Don't check the downloaded assets of the releases against their signed checksums, for old releases without them")
+	keysFile := flag.String("keys", signedsums.Manifest, "Pinned key manifest, for the key the checksums are signed with")
+	cacheFile := flag.String("cache", "", "Path to a cache of inspection results by asset digest, to skip downloading assets already inspected (-deep, -deep-all)")
+	pr := flag.Bool("pr", false, "Commit the changes to a branch and open or update a pull request (requires -token)")
+	prRepo := flag.String("pr-repo", "syncthing/docs", "Repository to open the pull request against")
+	prBase := flag.String("pr-base", "main", "Base branch for the pull request")
+	prBranch := flag.String("pr-branch", "histver/update-versions", "Branch to push the changes to")
+	gitCommitFlag := flag.Bool("git-commit", false, "Commit the changed files to the current branch")
+	signoff := flag.Bool("signoff", false, "Add a Signed-off-by trailer to the commit")
+	remote := flag.String("remote", "", "Clone this docs repository and work in its _script directory, committing and pushing the changes, instead of the current checkout (uses -token if set)")
+	remoteBranch := flag.String("remote-branch", "main", "Branch of the -remote repository to update")
+	remoteDir := flag.String("remote-dir", "", "Directory to keep the -remote clone in between runs (default a temporary directory)")
+	format := flag.String("format", "", "Write the table in this output format, as in the configured outputs (such as csv, rst, markdown, json or announcement), to standard output instead of updating it")
+	serve := flag.String("serve", "", "Serve the versions table over HTTP on this address instead of updating it")
+	upgradeURL := flag.String("upgrade-url", upgradeMetaURL, "Upgrade server metadata to compare against (crosscheck)")
+	var prof profiling
+	flag.StringVar(&prof.CPUFile, "cpuprofile", "", "Write a CPU profile of the run to this file")
+	flag.StringVar(&prof.MemFile, "memprofile", "", "Write a heap profile at the end of the run to this file")
+	flag.StringVar(&prof.TraceFile, "trace", "", "Write an execution trace of the run to this file")
+	servePprof := flag.Bool("pprof", false, "Serve the pprof handlers under /debug/pprof/ (-serve)")
+	arch := flag.String("arch", "", "Comma separated architectures whose assets to derive rows from, in order of preference (default the host's, then "+strings.Join(defaultArches, ",")+")")
+	maxSkew := flag.Duration("max-date-skew", 48*time.Hour, "Largest acceptable date difference against the upgrade server (crosscheck)")
+	sample := flag.Int("sample", 0, "Number of releases, picked at random, to verify the rows of (verify; 0 for all)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [sync|verify [version|from..to...]|render|prune|features [root]|crosscheck|replaced|lint [file]|arches [version...]|audit [version|from..to...]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	switch flag.Arg(0) {
+	case "", "sync", "verify", "render", "prune", "features", "crosscheck", "replaced", "lint", "arches", "audit":
+	default:
+		fmt.Fprintf(flag.CommandLine.Output(), "Unknown command %q\n", flag.Arg(0))
+		flag.Usage()
+		return exitCode(2)
+	}
+
+	archiveBudget = newMemBudget(*budgetMiB << 20)
+	if !*insecure {
+		assetSums = newReleaseSums(*keysFile)
+	}
+	if githubToken == "" {
+		githubToken = os.Getenv("GITHUB_TOKEN")
+	}
+
+	if err := prof.start(); err != nil {
+		return fmt.Errorf("Profiling: %w", err)
+	}
+	defer func() {
+		if stopErr := prof.stop(); stopErr != nil && err == nil {
+			err = fmt.Errorf("Profiling: %w", stopErr)
+		}
+	}()
+
+	if archPrefs, err = archPreference(*arch); err != nil {
+		return err
+	}
+
+	remoteOpts := remoteOptions{
+		URL:    *remote,
+		Branch: *remoteBranch,
+		Dir:    *remoteDir,
+		Token:  githubToken,
+	}
+	if *remote != "" {
+		cleanup, err := openRemote(remoteOpts)
+		if err != nil {
+			return fmt.Errorf("Opening remote repository: %w", err)
+		}
+		defer cleanup()
+	}
+
+	if flag.Arg(0) == "crosscheck" {
+		rows, err := loadTable(*versionsFile)
+		if err != nil {
+			return fmt.Errorf("Reading existing versions: %w", err)
+		}
+		problems, err := crosscheck(*upgradeURL, rows, *maxSkew)
+		if err != nil {
+			return fmt.Errorf("Cross-checking: %w", err)
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			return exitCode(1)
+		}
+		return nil
+	}
+
+	if flag.Arg(0) == "lint" {
+		file := *versionsFile
+		if flag.NArg() > 1 {
+			file = flag.Arg(1)
+		}
+		problems, err := lintTable(file)
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if err != nil {
+			return fmt.Errorf("Linting: %w", err)
+		}
+		if len(problems) > 0 {
+			return exitCode(1)
+		}
+		return nil
+	}
+
+	if flag.Arg(0) == "replaced" {
+		rows, err := loadTable(*versionsFile)
+		if err != nil {
+			return fmt.Errorf("Reading existing versions: %w", err)
+		}
+		ctx := context.Background()
+		releases, err := getReleases(ctx, "syncthing", "syncthing")
+		if err != nil {
+			return fmt.Errorf("Listing GitHub releases: %w", err)
+		}
+		problems, err := checkReplaced(ctx, githubClient(), "syncthing", "syncthing", releases, rows)
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if err != nil {
+			return fmt.Errorf("Checking assets: %w", err)
+		}
+		if len(problems) > 0 {
+			log.Println("Inspect the rows above again with -deep-all")
+			return exitCode(1)
+		}
+		return nil
+	}
+
+	if flag.Arg(0) == "audit" {
+		rows, err := loadTable(*versionsFile)
+		if err != nil {
+			return fmt.Errorf("Reading existing versions: %w", err)
+		}
+		byVersion := make(map[string]*tableRow, len(rows))
+		for _, row := range rows {
+			byVersion[row.Version] = row
+		}
+		releases, err := getReleases(context.Background(), "syncthing", "syncthing")
+		if err != nil {
+			return fmt.Errorf("Listing GitHub releases: %w", err)
+		}
+		audited, err := auditReleases(releases, flag.Args()[1:])
+		if err != nil {
+			return err
+		}
+		inspector := newAssetInspector("syncthing", "syncthing")
+		inspector.prefetch = *prefetch
+		var problems []string
+		for _, rel := range audited {
+			problems = append(problems, inspector.auditRelease(rel, byVersion[rel.GetTagName()])...)
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			return exitCode(1)
+		}
+		return nil
+	}
+
+	if flag.Arg(0) == "arches" {
+		releases, err := getReleases(context.Background(), "syncthing", "syncthing")
+		if err != nil {
+			return fmt.Errorf("Listing GitHub releases: %w", err)
+		}
+		arches := defaultArches
+		if *arch != "" {
+			arches = archPrefs
+		}
+		audited, err := auditReleases(releases, flag.Args()[1:])
+		if err != nil {
+			return err
+		}
+		var problems []string
+		for _, rel := range audited {
+			problems = append(problems, auditArches(rel, assetOS(runtime.GOOS), arches)...)
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			return exitCode(1)
+		}
+		return nil
+	}
+
+	if *serve != "" {
+		if err := serveTable(*serve, *versionsFile, *servePprof); err != nil {
+			return fmt.Errorf("Serving: %w", err)
+		}
+		return nil
+	}
+
+	// The configuration is checked against the tag pattern, such as its
+	// cutoff version.
+	if err := setTagPattern(*tagPattern); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return fmt.Errorf("Loading configuration: %w", err)
+	}
+	if err := setAssetPatterns(cfg.Assets); err != nil {
+		return fmt.Errorf("Loading configuration: %w", err)
+	}
+
+	if *format != "" {
+		if err := checkFormat(*format); err != nil {
+			return err
+		}
+		table, err := loadTable(*versionsFile)
+		if err != nil {
+			return fmt.Errorf("Reading existing versions: %w", err)
+		}
+		table, _ = dedupeRows(table)
+		sortRows(table)
+		if err := writeOutput(os.Stdout, outputConfig{Format: *format}, table); err != nil {
+			return fmt.Errorf("Writing output: %w", err)
+		}
+		return nil
+	}
+
+	if flag.Arg(0) == "render" {
+		table, err := loadTable(*versionsFile)
+		if err != nil {
+			return fmt.Errorf("Reading existing versions: %w", err)
+		}
+		table, _ = dedupeRows(table)
+		sortRows(table)
+		if _, err := writeOutputs(cfg.outputs(), table); err != nil {
+			return fmt.Errorf("Writing outputs: %w", err)
+		}
+		return nil
+	}
+
+	if err := checkDateSource(*dateSource); err != nil {
+		return err
+	}
+
+	if flag.Arg(0) == "verify" {
+		rows, err := loadTable(*versionsFile)
+		if err != nil {
+			return fmt.Errorf("Reading existing versions: %w", err)
+		}
+		byVersion := make(map[string]*tableRow, len(rows))
+		for _, row := range rows {
+			byVersion[row.Version] = row
+		}
+		releases, err := getReleases(context.Background(), "syncthing", "syncthing")
+		if err != nil {
+			return fmt.Errorf("Listing GitHub releases: %w", err)
+		}
+		selected, err := verifySelection(releases, byVersion, flag.Args()[1:], *sample, *force)
+		if err != nil {
+			return err
+		}
+		verified, problems := verifyRows(selected, byVersion, *dateSource, *jobs)
+		for _, tag := range verified {
+			fmt.Printf("%s: ok\n", tag)
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			return exitCode(1)
+		}
+		return nil
+	}
+
+	if flag.Arg(0) == "features" {
+		table, err := versions.Load(*versionsFile)
+		if err != nil {
+			return fmt.Errorf("Reading existing versions: %w", err)
+		}
+		root := ".."
+		if flag.NArg() > 1 {
+			root = flag.Arg(1)
+		}
+		tree, err := rstdoc.Load(root)
+		if err != nil {
+			return fmt.Errorf("Reading the docs: %w", err)
+		}
+		mentions := table.Mentions(tree)
+		if err := renderFeatures(os.Stdout, mentions); err != nil {
+			return fmt.Errorf("Writing output: %w", err)
+		}
+		failed := false
+		for _, m := range mentions {
+			if m.Problem != "" {
+				log.Printf("%s: %s", m.Pos, m.Problem)
+				failed = true
+			}
+		}
+		if failed {
+			return exitCode(1)
+		}
+		return nil
+	}
+
+	if flag.Arg(0) == "prune" {
+		table, err := loadTable(*versionsFile)
+		if err != nil {
+			return fmt.Errorf("Reading existing versions: %w", err)
+		}
+		releases, err := listReleases(context.Background(), "syncthing", "syncthing")
+		if err != nil {
+			return fmt.Errorf("Listing GitHub releases: %w", err)
+		}
+		table, pruned := pruneRows(table, releases)
+		for _, p := range pruned {
+			log.Println("Pruned", p)
+		}
+		if len(pruned) == 0 {
+			return nil
+		}
+		tw, err := os.Create(*versionsFile)
+		if err != nil {
+			return fmt.Errorf("Creating versions table: %w", err)
+		}
+		if err := writeTable(tw, table); err != nil {
+			return fmt.Errorf("Writing versions table: %w", err)
+		}
+		if err := tw.Close(); err != nil {
+			return fmt.Errorf("Writing versions table: %w", err)
+		}
+		return nil
+	}
+
 	// Load all known releases
 	ctx := context.Background()
-	releases, err := getReleases(ctx)
+	releases, err := getReleases(ctx, "syncthing", "syncthing")
 	if err != nil {
-		log.Fatalln("Listing GitHub releases:", err)
+		return fmt.Errorf("Listing GitHub releases: %w", err)
 	}
 
 	// Load current versions table
-	var table []*tableRow
-	fd, err := os.Open(*versionsFile)
-	if os.IsNotExist(err) {
-		// File doesn't exist yet. That's allright.
-	} else if err != nil {
-		log.Fatalln("Reading existing versions:", err)
-	} else {
-		table, err = readTable(fd)
-		if err != nil {
-			log.Fatalln("Reading existing versions:", err)
+	table, err := loadTable(*versionsFile)
+	if err != nil {
+		return fmt.Errorf("Reading existing versions: %w", err)
+	}
+
+	var reconciled []string
+	table, reconciled = dedupeRows(table)
+	for _, msg := range reconciled {
+		log.Println("Reconciled", msg)
+	}
+
+	var audit *auditLog
+	if *auditFile != "" {
+		if audit, err = loadAuditLog(*auditFile); err != nil {
+			return fmt.Errorf("Reading audit log: %w", err)
+		}
+	}
+
+	if *fixDates {
+		migrateDates(table, releases, *dateSource, audit)
+	}
+
+	// Frozen rows can't be derived again, and aren't checked or filled
+	// in unless forced.
+	active := table
+	if !*force {
+		active = nil
+		for _, row := range table {
+			if !row.Frozen {
+				active = append(active, row)
+			}
+		}
+	}
+
+	if *fillLang {
+		fillLanguage(active, audit)
+	}
+	if *fillHashes {
+		fillModuleHashes(active, audit)
+	}
+	if *fillPlats {
+		fillPlatforms(active, releases, audit)
+	}
+	if *fillGoSec {
+		fillGoSuperseded(active, audit)
+	}
+	if *backfill != "" {
+		if err := backfillDates(active, releases, strings.Split(*backfill, ","), audit); err != nil {
+			return fmt.Errorf("Backfilling dates: %w", err)
+		}
+	}
+	if *fillAPI {
+		if *src == "" {
+			return errors.New("-fill-api needs the -src checkout")
+		}
+		if err := fillAPIAdded(table, *src, audit); err != nil {
+			return fmt.Errorf("Looking up REST endpoints: %w", err)
 		}
 	}
 
@@ -52,162 +478,320 @@ func main() {
 		seen[row.Version] = struct{}{}
 	}
 
+	// Pick up the releases derived by an earlier run that didn't finish.
+	// Those already in the table were written by a run that died before
+	// removing the journal.
+	jnl, resumed, err := openJournal(*versionsFile)
+	if err != nil {
+		return fmt.Errorf("Reading journal: %w", err)
+	}
+	var added []*tableRow
+	for _, row := range resumed {
+		if _, ok := seen[row.Version]; ok {
+			continue
+		}
+		seen[row.Version] = struct{}{}
+		table = append(table, row)
+		added = append(added, row)
+	}
+	if len(added) > 0 {
+		log.Printf("Resuming with %d releases from the journal", len(added))
+	}
+
+	var inspector *assetInspector
+	if *deep || *deepAll {
+		inspector = newAssetInspector("syncthing", "syncthing")
+		inspector.prefetch = *prefetch
+		if *cacheFile != "" {
+			if err := inspector.loadCache(*cacheFile); err != nil {
+				return fmt.Errorf("Reading inspection cache: %w", err)
+			}
+		}
+	}
+	if *deepAll {
+		byTag := make(map[string]*github.RepositoryRelease, len(releases))
+		for _, rel := range releases {
+			byTag[rel.GetTagName()] = rel
+		}
+		for _, row := range active {
+			rel, ok := byTag[row.Version]
+			if !ok || !hasBinaryAssets(rel) {
+				log.Printf("%s: release assets unavailable, freezing the row", row.Version)
+				row.Frozen = true
+				continue
+			}
+			log.Println("Verifying", row.Version)
+			inspector.inspect(ctx, rel, row)
+		}
+	}
+
 	// Get version information for all releases not yet in the versions
-	// table.
+	// table. The rows are derived concurrently and taken in order.
+	var pending []*github.RepositoryRelease
 	for _, rel := range releases {
 		if _, ok := seen[*rel.TagName]; ok {
 			continue
 		}
+		if _, ok := parseVersion(*rel.TagName); !ok {
+			log.Println("Skipping non-matching tag", *rel.TagName)
+			continue
+		}
+		pending = append(pending, rel)
+	}
+	derived := deriveRows(pending, *jobs, getReleaseVersion)
+	for i, rel := range pending {
 		log.Println("Checking", *rel.TagName)
-		if row, err := getReleaseVersion(rel); err != nil {
-			log.Printf("%s: %v", *rel.TagName, err)
+		if d := <-derived[i]; d.err != nil {
+			log.Printf("%s: %v", *rel.TagName, d.err)
 		} else {
+			row := d.row
+			audit.record(row.Version, "Version", row.Version, methodBinary, row.Asset)
+			audit.record(row.Version, "Runtime", row.Runtime, methodBinary, row.Asset)
+			buildDate := row.Date
+			if row.Date = releaseDate(rel, *dateSource, buildDate); row.Date == "" {
+				log.Printf("%s: no build time in the binary, nor a release date", *rel.TagName)
+				continue
+			}
+			recordDate(audit, row, rel, *dateSource, buildDate, row.Asset)
+			if row.Platforms = releasePlatforms(rel); row.Platforms != "" {
+				audit.record(row.Version, "Platforms", row.Platforms, methodGitHub, "assets of release "+rel.GetTagName())
+			}
+			if row.Language, err = goModLanguage(*rel.TagName); err != nil {
+				log.Printf("%s: language version: %v", *rel.TagName, err)
+			} else {
+				audit.record(row.Version, "Language", row.Language, methodGoMod, fmt.Sprintf(goModURL, *rel.TagName))
+			}
+			if row.ModuleHash, err = moduleHash(*rel.TagName); err != nil {
+				log.Printf("%s: module hash: %v", *rel.TagName, err)
+			} else {
+				audit.record(row.Version, "ModuleHash", row.ModuleHash, methodSumDB, moduleLookupURL(*rel.TagName))
+			}
+			if err := jnl.add(row); err != nil {
+				return fmt.Errorf("Writing journal: %w", err)
+			}
+			// Keep the records of the journaled rows too.
+			if err := audit.save(); err != nil {
+				return fmt.Errorf("Writing audit log: %w", err)
+			}
 			table = append(table, row)
+			added = append(added, row)
+			if inspector != nil {
+				inspector.inspect(ctx, rel, row)
+			}
 		}
 	}
 
+	if len(added) > 0 {
+		fillGoSuperseded(added, audit)
+	}
+
+	if inspector != nil && *cacheFile != "" {
+		if err := inspector.saveCache(*cacheFile); err != nil {
+			return fmt.Errorf("Writing inspection cache: %w", err)
+		}
+	}
+	if err := cache.Default().Trim(); err != nil {
+		log.Println("Trimming cache:", err)
+	}
+
 	// Save a new versions table.
 	tw, err := os.Create(*versionsFile)
 	if err != nil {
-		log.Fatalln("Creating versions table:", err)
+		return fmt.Errorf("Creating versions table: %w", err)
 	}
 	if err := writeTable(tw, table); err != nil {
-		log.Fatalln("Writing versions table:", err)
+		return fmt.Errorf("Writing versions table: %w", err)
 	}
 	if err := tw.Close(); err != nil {
-		log.Fatalln("Writing versions table:", err)
+		return fmt.Errorf("Writing versions table: %w", err)
+	}
+	if err := jnl.finish(); err != nil {
+		return fmt.Errorf("Removing journal: %w", err)
 	}
-}
 
-func getReleases(ctx context.Context) ([]*github.RepositoryRelease, error) {
-	client := github.NewClient(nil)
-	opts := &github.ListOptions{
-		PerPage: 100,
+	outputs := []string{*versionsFile}
+	if audit != nil {
+		if err := audit.save(); err != nil {
+			return fmt.Errorf("Writing audit log: %w", err)
+		}
+		outputs = append(outputs, *auditFile)
+	}
+	written, err := writeOutputs(cfg.outputs(), table)
+	if err != nil {
+		return fmt.Errorf("Writing outputs: %w", err)
 	}
+	outputs = append(outputs, written...)
 
-	var releases []*github.RepositoryRelease
-	for {
-		rels, resp, err := client.Repositories.ListReleases(ctx, "syncthing", "syncthing", opts)
-		if err != nil {
-			return nil, err
+	if *macosFile != "" {
+		repos = append(repos, repoConfig{Repo: "syncthing/syncthing-macos", File: *macosFile})
+	}
+	if *androidFile != "" {
+		repos = append(repos, repoConfig{Repo: "syncthing/syncthing-android", File: *androidFile})
+	}
+	for _, rc := range append(cfg.Repos, repos...) {
+		if err := syncRepo(ctx, rc); err != nil {
+			return fmt.Errorf("Updating %s versions: %w", rc.Repo, err)
 		}
-		for _, rel := range rels {
-			if *rel.Prerelease {
-				continue
+		outputs = append(outputs, rc.File)
+	}
+
+	if cfg.Publish.Bucket != "" {
+		if err := publishFiles(cfg.Publish, outputs); err != nil {
+			return fmt.Errorf("Publishing: %w", err)
+		}
+	}
+
+	if (*gitCommitFlag || *remote != "") && !*pr {
+		if len(added) == 0 {
+			log.Println("No new versions, not committing")
+			return nil
+		}
+		var args []string
+		if *signoff {
+			args = append(args, "--signoff")
+		}
+		if err := gitCommit(outputs, commitMessage(added), args...); err != nil {
+			return fmt.Errorf("Committing: %w", err)
+		}
+		if *remote != "" {
+			if err := pushRemote(remoteOpts); err != nil {
+				return fmt.Errorf("Pushing: %w", err)
 			}
-			releases = append(releases, rel)
 		}
-		if resp.NextPage == 0 {
-			break
+	}
+
+	if *pr {
+		if len(added) == 0 {
+			log.Println("No new versions, not opening a pull request")
+			return nil
+		}
+		opts := pullRequestOptions{
+			Repo:    *prRepo,
+			Base:    *prBase,
+			Branch:  *prBranch,
+			Token:   githubToken,
+			Signoff: *signoff,
+		}
+		if err := openPullRequest(ctx, opts, outputs, added); err != nil {
+			return fmt.Errorf("Opening pull request: %w", err)
 		}
-		opts.Page = resp.NextPage
 	}
+	return nil
+}
 
-	sort.Slice(releases, func(a, b int) bool {
-		return releases[a].GetPublishedAt().After(releases[b].GetPublishedAt().Time)
-	})
-	return releases, nil
+// loadTable reads the versions table file. A missing file is an empty
+// table.
+func loadTable(file string) ([]*tableRow, error) {
+	fd, err := os.Open(file)
+	if os.IsNotExist(err) {
+		// File doesn't exist yet. That's allright.
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return readTable(fd)
 }
 
-func getReleaseVersion(rel *github.RepositoryRelease) (*tableRow, error) {
-	goos := runtime.GOOS
-	if goos == "darwin" {
-		goos = "macos"
-	}
-	find := fmt.Sprintf("syncthing-%s-%s", goos, runtime.GOARCH)
-	for _, asset := range rel.Assets {
-		if strings.HasPrefix(*asset.Name, find) {
-			log.Println("Downloading", *asset.Name)
-			resp, err := http.Get(*asset.BrowserDownloadURL)
-			if err != nil {
-				return nil, err
-			}
-			bs, err := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			if err != nil {
-				return nil, err
-			}
-			switch filepath.Ext(*asset.Name) {
-			case ".zip":
-				return getReleaseVersionZip(bs)
-			default:
-				return getReleaseVersionTarGz(bs)
-			}
-		}
+// githubToken authenticates the requests to GitHub, set by the -token
+// flag or GITHUB_TOKEN.
+var githubToken string
+
+// githubCacheTTL is how long the GitHub API responses are used without
+// asking again; after that they're revalidated.
+const githubCacheTTL = time.Minute
+
+// githubClient returns the client for the GitHub API, authenticated with
+// githubToken when there is one. The responses are kept in the shared
+// cache and revalidated with conditional requests, which for
+// authenticated requests don't count against the rate limit when
+// nothing changed.
+func githubClient() *github.Client {
+	var base http.RoundTripper = httpclient.Default.Transport
+	if githubToken != "" {
+		base = &httpclient.TokenTransport{Token: githubToken}
 	}
-	return nil, fmt.Errorf("no asset found")
+	return github.NewClient(&http.Client{Transport: cache.Default().Transport(base, githubCacheTTL)})
 }
 
-func getReleaseVersionZip(bs []byte) (*tableRow, error) {
-	zr, err := zip.NewReader(bytes.NewReader(bs), int64(len(bs)))
+func getReleases(ctx context.Context, owner, repo string) ([]*github.RepositoryRelease, error) {
+	all, err := listReleases(ctx, owner, repo)
 	if err != nil {
 		return nil, err
 	}
-	for _, f := range zr.File {
-		if strings.Contains(path.Dir(f.Name), "/") {
-			// Skip files not at top level
-			continue
-		}
-		if path.Base(f.Name) != "syncthing" {
+	var releases []*github.RepositoryRelease
+	for _, rel := range all {
+		if rel.GetPrerelease() {
 			continue
 		}
-		rd, err := f.Open()
-		if err != nil {
-			return nil, err
-		}
-
-		return getVersionFromReader(rd)
+		releases = append(releases, rel)
 	}
-	return nil, fmt.Errorf("no syncthing binary found")
+	return releases, nil
 }
 
-func getReleaseVersionTarGz(bs []byte) (*tableRow, error) {
-	gr, err := gzip.NewReader(bytes.NewReader(bs))
-	if err != nil {
-		return nil, err
+// listReleases returns all the releases of the repository, prereleases
+// and drafts included, the most recently published first.
+func listReleases(ctx context.Context, owner, repo string) ([]*github.RepositoryRelease, error) {
+	client := githubClient()
+	opts := &github.ListOptions{
+		PerPage: 100,
 	}
-	tr := tar.NewReader(gr)
+
+	var releases []*github.RepositoryRelease
 	for {
-		hdr, err := tr.Next()
+		rels, resp, err := client.Repositories.ListReleases(ctx, owner, repo, opts)
 		if err != nil {
-			break
+			return nil, err
 		}
-		if path.Base(hdr.Name) != "syncthing" {
-			continue
+		releases = append(releases, rels...)
+		if resp.NextPage == 0 {
+			break
 		}
-
-		return getVersionFromReader(tr)
+		opts.Page = resp.NextPage
 	}
-	return nil, fmt.Errorf("no syncthing binary found")
+
+	sort.Slice(releases, func(a, b int) bool {
+		return releases[a].GetPublishedAt().After(releases[b].GetPublishedAt().Time)
+	})
+	return releases, nil
 }
 
-func getVersionFromReader(r io.Reader) (*tableRow, error) {
-	fd, err := os.CreateTemp("", "syncthing")
+// getReleaseVersion returns the row for the release, derived from the
+// binary in the first matching asset for the preferred architectures.
+func getReleaseVersion(rel *github.RepositoryRelease) (*tableRow, error) {
+	asset, err := preferredAsset(rel, assetOS(runtime.GOOS), archPrefs)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := io.Copy(fd, r); err != nil {
+	return cachedReleaseVersion(rel, asset, func() (*tableRow, error) {
+		return assetRow(rel, asset)
+	})
+}
+
+// assetRow derives the row for the release from the asset.
+func assetRow(rel *github.RepositoryRelease, asset *github.ReleaseAsset) (*tableRow, error) {
+	data, err := fetchAsset(asset, archiveBudget)
+	if err != nil {
 		return nil, err
 	}
-	fd.Close()
-	defer os.Remove(fd.Name())
-	if err := os.Chmod(fd.Name(), 0o755); err != nil {
+	defer data.Close()
+	row, err := getReleaseVersionArchive(data, data.size, rel.GetTagName())
+	if err != nil {
 		return nil, err
 	}
-
-	return getVersionFromCommand(fd.Name())
+	row.Asset, row.AssetDigest = asset.GetName(), data.digest
+	return row, nil
 }
 
-func getVersionFromCommand(name string) (*tableRow, error) {
-	cmd := exec.Command(name, "--version")
-	out, err := cmd.Output()
+func download(asset *github.ReleaseAsset) ([]byte, error) {
+	log.Println("Downloading", *asset.Name)
+	resp, err := httpclient.Download.Get(*asset.BrowserDownloadURL)
 	if err != nil {
 		return nil, err
 	}
-
-	var r tableRow
-	if err := r.fromVersion(string(out)); err != nil {
-		return nil, err
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", *asset.Name, resp.Status)
 	}
-	return &r, nil
+	return io.ReadAll(resp.Body)
 }
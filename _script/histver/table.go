@@ -1,61 +1,231 @@
 package main
 
 import (
+	"bytes"
 	"encoding/csv"
 	"fmt"
 	"io"
-	"regexp"
 	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"syncthing.net/docs/internal/rst"
 )
 
 type tableRow struct {
-	Version string
-	Runtime string
-	Date    string
+	Version string `json:"version"`
+	// Runtime is the Go toolchain the release was built with.
+	Runtime string `json:"runtime"`
+	Date    string `json:"date"`
+	// Language is the Go language version from the go directive in the
+	// release's go.mod, when known.
+	Language string `json:"language,omitempty"`
+	// ModuleHash is the go.sum hash of the release's module zip, as
+	// recorded in the Go checksum database.
+	ModuleHash string `json:"moduleHash,omitempty"`
+	// Asset is the name of the release asset the row was derived from.
+	Asset string `json:"asset,omitempty"`
+	// AssetDigest is the digest of the asset as downloaded, as
+	// sha256:<hex>, to notice when it's replaced.
+	AssetDigest string `json:"assetDigest,omitempty"`
+	Manual      bool   `json:"manual,omitempty"` // maintained by hand, wins over newer derived rows when reconciling
+	// Frozen marks rows whose release assets are no longer available, so
+	// the data can't be derived again and isn't verified.
+	Frozen bool `json:"frozen,omitempty"`
+	// GoSuperseded is the Go point release with security fixes that was
+	// already out, in the same minor series, when the release was built
+	// with an older one.
+	GoSuperseded string `json:"goSuperseded,omitempty"`
+	// APIAdded are the REST endpoints first in the release, space
+	// separated, as found in the source. Only minor releases have them.
+	APIAdded string `json:"apiAdded,omitempty"`
+	// Platforms are the os-arch platforms the release has binary assets
+	// for, space separated and sorted, as in linux-amd64.
+	Platforms string `json:"platforms,omitempty"`
+	// Notes is free text filled in by hand, such as "security release".
+	Notes string `json:"notes,omitempty"`
 }
 
-func (r *tableRow) fromStrings(ss []string) error {
-	if len(ss) < 3 {
+// fromStrings sets the row from a CSV record, given the column index of
+// each named column as read from the header.
+func (r *tableRow) fromStrings(ss []string, cols map[string]int) error {
+	get := func(name string) string {
+		if i, ok := cols[name]; ok && i < len(ss) {
+			return cleanField(ss[i])
+		}
+		return ""
+	}
+	r.Version = get("Version")
+	r.Runtime = get("Runtime")
+	r.Date = get("Date")
+	if len(ss) < 3 || r.Version == "" {
 		return fmt.Errorf("not enough fields")
 	}
-	r.Version = ss[0]
-	r.Runtime = ss[1]
-	r.Date = ss[2]
+	r.Language = get(languageColumn)
+	r.ModuleHash = get(hashColumn)
+	r.Asset = get(assetColumn)
+	r.AssetDigest = get(assetDigestColumn)
+	r.Manual = strings.EqualFold(get(manualColumn), "yes")
+	r.Frozen = strings.EqualFold(get(frozenColumn), "yes")
+	r.GoSuperseded = get(goSupersededColumn)
+	r.APIAdded = get(apiAddedColumn)
+	r.Platforms = get(platformsColumn)
+	r.Notes = get(notesColumn)
 	return nil
 }
 
-func (r *tableRow) fromVersion(ver string) error {
-	// syncthing v1.23.1-rc.1 "Fermium Flea" (go1.19.5 darwin-arm64) teamcity@build.syncthing.net 2023-01-12 03:30:17 UTC [stnoupgrade]
-	exp := regexp.MustCompile(`syncthing (v\d+\.\d+\.\d+).*(go\d+\.\d+(?:\.\d+)?).*(\d{4}-\d{2}-\d{2}) `)
-	m := exp.FindStringSubmatch(ver)
-	if len(m) < 3 {
-		return fmt.Errorf("failed to parse version")
+// tableColumns are the optional columns written to the table.
+type tableColumns struct {
+	language, hash, asset, manual, frozen, goSuperseded, apiAdded, platforms, notes bool
+}
+
+func (r *tableRow) toStrings(cols tableColumns) []string {
+	ss := []string{r.Version, r.Runtime, r.Date}
+	if cols.language {
+		ss = append(ss, r.Language)
 	}
-	r.Version = m[1]
-	r.Runtime = m[2]
-	r.Date = m[3]
-	return nil
+	if cols.hash {
+		ss = append(ss, r.ModuleHash)
+	}
+	if cols.asset {
+		ss = append(ss, r.Asset, r.AssetDigest)
+	}
+	if cols.manual {
+		ss = append(ss, yesOrEmpty(r.Manual))
+	}
+	if cols.frozen {
+		ss = append(ss, yesOrEmpty(r.Frozen))
+	}
+	if cols.goSuperseded {
+		ss = append(ss, r.GoSuperseded)
+	}
+	if cols.apiAdded {
+		ss = append(ss, r.APIAdded)
+	}
+	if cols.platforms {
+		ss = append(ss, r.Platforms)
+	}
+	if cols.notes {
+		ss = append(ss, r.Notes)
+	}
+	return ss
 }
 
-func (r *tableRow) toStrings() []string {
-	return []string{r.Version, r.Runtime, r.Date}
+func yesOrEmpty(b bool) string {
+	if b {
+		return "yes"
+	}
+	return ""
 }
 
 var tableHeader = []string{"Version", "Runtime", "Date"}
 
-func writeTable(w io.Writer, rows []*tableRow) error {
+// languageColumn is an optional column of the go.mod language versions,
+// written when any are known.
+const languageColumn = "Language"
+
+// hashColumn is an optional column of the module hashes, written when any
+// are known. They're data for the JSON output; the presentation formats,
+// such as the rst table the docs include, leave them out.
+const hashColumn = "Module Hash"
+
+// assetColumn is an optional column of the asset names the rows were
+// derived from, written when any are known, along with the column of
+// their digests.
+const (
+	assetColumn       = "Asset"
+	assetDigestColumn = "Asset Digest"
+)
+
+// manualColumn is an optional column marking rows maintained by hand. It's
+// only written when at least one row is so marked.
+const manualColumn = "Manual"
+
+// frozenColumn is an optional column marking rows whose assets are gone.
+// Like the manual column it's only written when any row is so marked.
+const frozenColumn = "Frozen"
+
+// goSupersededColumn is an optional column of the Go security releases
+// that superseded the one a release was built with, written when any
+// release was.
+const goSupersededColumn = "Go Superseded By"
+
+// apiAddedColumn is an optional column of the REST endpoints added in the
+// releases, written when any were.
+const apiAddedColumn = "API Added"
+
+// platformsColumn is an optional column of the platforms of the release
+// assets, written when any are known. The presentation formats only show
+// it with showPlatforms.
+const platformsColumn = "Platforms"
+
+// notesColumn is an optional column of notes about the releases, filled in
+// by hand. Like the manual column it's only written when there are any.
+const notesColumn = "Notes"
+
+// sortRows sorts the rows in table order, newest first.
+func sortRows(rows []*tableRow) {
 	sort.Slice(rows, func(a, b int) bool {
 		if rows[a].Date == rows[b].Date {
-			return rows[a].Version > rows[b].Version
+			return compareVersions(rows[a].Version, rows[b].Version) > 0
 		}
 		return rows[a].Date > rows[b].Date
 	})
+}
+
+func writeTable(w io.Writer, rows []*tableRow) error {
+	sortRows(rows)
+	// The canonical form is UTF-8 without BOM and with LF line endings,
+	// whatever we read.
 	cw := csv.NewWriter(w)
-	if err := cw.Write(tableHeader); err != nil {
+	cw.UseCRLF = false
+	var cols tableColumns
+	for _, r := range rows {
+		cols.language = cols.language || r.Language != ""
+		cols.hash = cols.hash || r.ModuleHash != ""
+		cols.asset = cols.asset || r.Asset != ""
+		cols.manual = cols.manual || r.Manual
+		cols.frozen = cols.frozen || r.Frozen
+		cols.goSuperseded = cols.goSuperseded || r.GoSuperseded != ""
+		cols.apiAdded = cols.apiAdded || r.APIAdded != ""
+		cols.platforms = cols.platforms || r.Platforms != ""
+		cols.notes = cols.notes || r.Notes != ""
+	}
+	header := tableHeader[:len(tableHeader):len(tableHeader)]
+	if cols.language {
+		header = append(header, languageColumn)
+	}
+	if cols.hash {
+		header = append(header, hashColumn)
+	}
+	if cols.asset {
+		header = append(header, assetColumn, assetDigestColumn)
+	}
+	if cols.manual {
+		header = append(header, manualColumn)
+	}
+	if cols.frozen {
+		header = append(header, frozenColumn)
+	}
+	if cols.goSuperseded {
+		header = append(header, goSupersededColumn)
+	}
+	if cols.apiAdded {
+		header = append(header, apiAddedColumn)
+	}
+	if cols.platforms {
+		header = append(header, platformsColumn)
+	}
+	if cols.notes {
+		header = append(header, notesColumn)
+	}
+	if err := cw.Write(header); err != nil {
 		return err
 	}
 	for _, r := range rows {
-		if err := cw.Write(r.toStrings()); err != nil {
+		if err := cw.Write(r.toStrings(cols)); err != nil {
 			return err
 		}
 	}
@@ -64,7 +234,18 @@ func writeTable(w io.Writer, rows []*tableRow) error {
 }
 
 func readTable(r io.Reader) ([]*tableRow, error) {
-	cr := csv.NewReader(r)
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	bs, err = normalizeText(bs)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(bytes.NewReader(bs))
+	cr.FieldsPerRecord = -1
+	cols := columnIndex(tableHeader)
 	var rows []*tableRow
 	for {
 		ss, err := cr.Read()
@@ -77,14 +258,325 @@ func readTable(r io.Reader) ([]*tableRow, error) {
 		if len(ss) == 0 {
 			continue
 		}
-		if ss[0] == tableHeader[0] {
+		if cleanField(ss[0]) == tableHeader[0] {
+			cols = columnIndex(ss)
 			continue
 		}
 		var row tableRow
-		if err := row.fromStrings(ss); err != nil {
+		if err := row.fromStrings(ss, cols); err != nil {
 			return nil, err
 		}
 		rows = append(rows, &row)
 	}
 	return rows, nil
 }
+
+func columnIndex(header []string) map[string]int {
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[cleanField(name)] = i
+	}
+	return cols
+}
+
+// dedupeRows merges rows describing the same version, so that a manual
+// edit and an automated append (or a retagged release) don't end up as
+// two rows. The precedence is:
+//
+//  1. A row marked manual wins over one that isn't, wherever it is in the
+//     input, as hand edits correct what was derived.
+//  2. Otherwise the later row wins, as syncs append the newest data.
+//
+// Fields missing from the winner are filled in from the other row. The
+// returned messages describe what was merged.
+func dedupeRows(rows []*tableRow) ([]*tableRow, []string) {
+	var out []*tableRow
+	var report []string
+	idx := make(map[string]int)
+	for _, row := range rows {
+		i, ok := idx[row.Version]
+		if !ok {
+			idx[row.Version] = len(out)
+			out = append(out, row)
+			continue
+		}
+
+		prev := out[i]
+		winner, loser := row, prev
+		if prev.Manual && !row.Manual {
+			winner, loser = prev, row
+		}
+		if *winner == *loser {
+			report = append(report, fmt.Sprintf("%s: dropped identical duplicate row", row.Version))
+			continue
+		}
+		if winner.Runtime == "" {
+			winner.Runtime = loser.Runtime
+		}
+		if winner.Date == "" {
+			winner.Date = loser.Date
+		}
+		if winner.Language == "" {
+			winner.Language = loser.Language
+		}
+		if winner.Asset == "" {
+			winner.Asset, winner.AssetDigest = loser.Asset, loser.AssetDigest
+		}
+		if winner.ModuleHash == "" {
+			winner.ModuleHash = loser.ModuleHash
+		}
+		if winner.GoSuperseded == "" {
+			winner.GoSuperseded = loser.GoSuperseded
+		}
+		if winner.APIAdded == "" {
+			winner.APIAdded = loser.APIAdded
+		}
+		if winner.Platforms == "" {
+			winner.Platforms = loser.Platforms
+		}
+		if winner.Notes == "" {
+			winner.Notes = loser.Notes
+		}
+		kind := "newer"
+		if winner.Manual && !loser.Manual {
+			kind = "manual"
+		}
+		report = append(report, fmt.Sprintf("%s: kept %s row (%s, %s) over (%s, %s)", row.Version, kind, winner.Runtime, winner.Date, loser.Runtime, loser.Date))
+		out[i] = winner
+	}
+	return out, report
+}
+
+// normalizeText returns the file contents as UTF-8 without a byte order
+// mark and with plain LF line endings, regardless of what the editor used
+// to save it.
+func normalizeText(bs []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(bs, []byte{0xef, 0xbb, 0xbf}):
+		bs = bs[3:]
+	case bytes.HasPrefix(bs, []byte{0xff, 0xfe}):
+		bs = decodeUTF16(bs[2:], false)
+	case bytes.HasPrefix(bs, []byte{0xfe, 0xff}):
+		bs = decodeUTF16(bs[2:], true)
+	}
+	if !utf8.Valid(bs) {
+		return nil, fmt.Errorf("file is not valid UTF-8")
+	}
+	bs = bytes.ReplaceAll(bs, []byte("\r\n"), []byte("\n"))
+	bs = bytes.ReplaceAll(bs, []byte("\r"), []byte("\n"))
+	return bs, nil
+}
+
+func decodeUTF16(bs []byte, bigEndian bool) []byte {
+	u16 := make([]uint16, len(bs)/2)
+	for i := range u16 {
+		if bigEndian {
+			u16[i] = uint16(bs[2*i])<<8 | uint16(bs[2*i+1])
+		} else {
+			u16[i] = uint16(bs[2*i+1])<<8 | uint16(bs[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(u16)))
+}
+
+// cleanField removes the invisible characters and typographic substitutions
+// that web editors and word processors like to introduce, so that a field
+// compares equal to what the tool itself would have written.
+func cleanField(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '\ufeff', '\u200b', '\u200c', '\u200d', '\u2060':
+			// BOM (when not at the start of the file) and zero width
+			// characters
+			return -1
+		case '\u00a0', '\u2007', '\u202f':
+			// Non-breaking spaces
+			return ' '
+		case '\u2010', '\u2011', '\u2012', '\u2013', '\u2014', '\u2212':
+			// Hyphens and dashes
+			return '-'
+		}
+		return r
+	}, s)
+	return strings.TrimFunc(s, unicode.IsSpace)
+}
+
+// The presentation tables are rendered from the rows through a
+// tableWriter per output format. The data file written by writeTable is
+// plain CSV, as is its presentation format; the other formats apply the
+// markup of the cells.
+
+// cell is a cell of a presentation table.
+type cell struct {
+	text string
+	// strong cells are shown in bold, by the bolding rules of
+	// rowCells.
+	strong bool
+	// note is a footnote to the cell, for formats that have them.
+	note string
+}
+
+// plainCells returns cells without markup.
+func plainCells(ss []string) []cell {
+	res := make([]cell, len(ss))
+	for i, s := range ss {
+		res[i] = cell{text: s}
+	}
+	return res
+}
+
+// rowCells returns the version, runtime and date cells of the row. The
+// newest release is in bold, as is a runtime already superseded by a
+// security fix when the release was built.
+func rowCells(r, newest *tableRow, rc renderConfig) []cell {
+	return []cell{
+		{text: r.Version, strong: r == newest, note: r.Notes},
+		{text: r.Runtime, strong: r.GoSuperseded != ""},
+		{text: rc.formatDate(r.Date)},
+	}
+}
+
+// tableWriter writes a table in an output format: the header, the rows,
+// and on close what follows them.
+type tableWriter interface {
+	header(names []string) error
+	row(cells []cell) error
+	close() error
+	// footnotes tells whether the notes of the cells are shown as
+	// footnotes; otherwise they get a column of their own.
+	footnotes() bool
+}
+
+// csvTableWriter writes the table as CSV, without markup.
+type csvTableWriter struct {
+	cw *csv.Writer
+}
+
+func newCSVTableWriter(w io.Writer) *csvTableWriter {
+	return &csvTableWriter{cw: csv.NewWriter(w)}
+}
+
+func (t *csvTableWriter) header(names []string) error {
+	return t.cw.Write(names)
+}
+
+func (t *csvTableWriter) row(cells []cell) error {
+	rec := make([]string, len(cells))
+	for i, c := range cells {
+		rec[i] = c.text
+	}
+	return t.cw.Write(rec)
+}
+
+func (t *csvTableWriter) close() error {
+	t.cw.Flush()
+	return t.cw.Error()
+}
+
+func (t *csvTableWriter) footnotes() bool { return false }
+
+// rstTableWriter writes the table as an RST list-table on close,
+// followed by the footnotes for the notes.
+type rstTableWriter struct {
+	w     io.Writer
+	table rst.Table
+	notes []string
+}
+
+func (t *rstTableWriter) header(names []string) error {
+	t.table.Header = rstEscapeAll(names)
+	return nil
+}
+
+func (t *rstTableWriter) row(cells []cell) error {
+	texts := make([]string, len(cells))
+	for i, c := range cells {
+		texts[i] = rst.Escape(c.text)
+		if c.strong && c.text != "" {
+			texts[i] = "**" + texts[i] + "**"
+		}
+		if c.note != "" {
+			texts[i] += " [#]_"
+			t.notes = append(t.notes, c.note)
+		}
+	}
+	t.table.Rows = append(t.table.Rows, texts)
+	return nil
+}
+
+func (t *rstTableWriter) close() error {
+	var sb strings.Builder
+	t.table.WriteTo(&sb)
+	if len(t.notes) > 0 {
+		for _, n := range t.notes {
+			fmt.Fprintf(&sb, ".. [#] %s\n", rst.Escape(n))
+		}
+		sb.WriteString("\n")
+	}
+	_, err := io.WriteString(t.w, sb.String())
+	return err
+}
+
+func (t *rstTableWriter) footnotes() bool { return true }
+
+// markdownTableWriter writes the table as a Markdown pipe table, with
+// the notes as footnotes numbered on from next, which is shared by the
+// tables of a document.
+type markdownTableWriter struct {
+	w     io.Writer
+	next  *int
+	notes []string
+}
+
+// markdownEscaper escapes the characters that are markup in Markdown
+// table cells.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "<", `\<`)
+
+func (t *markdownTableWriter) header(names []string) error {
+	seps := make([]string, len(names))
+	for i := range seps {
+		seps[i] = "---"
+	}
+	return t.write(names, seps)
+}
+
+func (t *markdownTableWriter) row(cells []cell) error {
+	texts := make([]string, len(cells))
+	for i, c := range cells {
+		texts[i] = markdownEscaper.Replace(c.text)
+		if c.strong && c.text != "" {
+			texts[i] = "**" + texts[i] + "**"
+		}
+		if c.note != "" {
+			*t.next++
+			texts[i] += fmt.Sprintf("[^%d]", *t.next)
+			t.notes = append(t.notes, c.note)
+		}
+	}
+	return t.write(texts)
+}
+
+func (t *markdownTableWriter) write(lines ...[]string) error {
+	var sb strings.Builder
+	for _, texts := range lines {
+		sb.WriteString("| " + strings.Join(texts, " | ") + " |\n")
+	}
+	_, err := io.WriteString(t.w, sb.String())
+	return err
+}
+
+func (t *markdownTableWriter) close() error {
+	var sb strings.Builder
+	sb.WriteString("\n")
+	if len(t.notes) > 0 {
+		first := *t.next - len(t.notes) + 1
+		for i, n := range t.notes {
+			fmt.Fprintf(&sb, "[^%d]: %s\n", first+i, n)
+		}
+		sb.WriteString("\n")
+	}
+	_, err := io.WriteString(t.w, sb.String())
+	return err
+}
+
+func (t *markdownTableWriter) footnotes() bool { return true }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// verifySelection returns the releases to verify the rows of: those of
+// the versions and ranges given, or all, narrowed to a random sample of
+// that many when sample is positive. Only releases with a row that isn't
+// frozen are taken, unless forced.
+func verifySelection(releases []*github.RepositoryRelease, byVersion map[string]*tableRow, versions []string, sample int, force bool) ([]*github.RepositoryRelease, error) {
+	if len(versions) > 0 {
+		var err error
+		if releases, err = auditReleases(releases, versions); err != nil {
+			return nil, err
+		}
+	}
+	var res []*github.RepositoryRelease
+	for _, rel := range releases {
+		if row, ok := byVersion[rel.GetTagName()]; ok && (force || !row.Frozen) {
+			res = append(res, rel)
+		}
+	}
+	if sample > 0 && sample < len(res) {
+		// The sample keeps the order of the releases.
+		picked := rand.Perm(len(res))[:sample]
+		sort.Ints(picked)
+		sampled := make([]*github.RepositoryRelease, sample)
+		for i, p := range picked {
+			sampled[i] = res[p]
+		}
+		res = sampled
+	}
+	return res, nil
+}
+
+// verifyRows derives the rows of the releases again, from the assets
+// rather than the cache, and returns the releases whose rows match and
+// how the other rows in the table differ: a runtime or date that's
+// changed upstream, as when a release was re-tagged or its assets
+// re-published, or a parsing bug was fixed.
+func verifyRows(releases []*github.RepositoryRelease, byVersion map[string]*tableRow, dateSource string, jobs int) (verified, problems []string) {
+	derived := deriveRows(releases, jobs, func(rel *github.RepositoryRelease) (*tableRow, error) {
+		asset, err := preferredAsset(rel, assetOS(runtime.GOOS), archPrefs)
+		if err != nil {
+			return nil, err
+		}
+		return assetRow(rel, asset)
+	})
+	for i, rel := range releases {
+		tag := rel.GetTagName()
+		row := byVersion[tag]
+		d := <-derived[i]
+		if d.err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", tag, d.err))
+			continue
+		}
+		got := d.row
+		if got.Date == "" && releaseTimestamp(rel, dateSource) == nil {
+			// Nothing to check the date against: the binary has no build
+			// date, and the date in the table may have come from anywhere.
+			got.Date = row.Date
+		} else {
+			got.Date = releaseDate(rel, dateSource, got.Date)
+		}
+		var diffs []string
+		if got.Runtime != row.Runtime {
+			diffs = append(diffs, fmt.Sprintf("runtime %s, table says %s", got.Runtime, row.Runtime))
+		}
+		if got.Date != row.Date {
+			diffs = append(diffs, fmt.Sprintf("date %s, table says %s", got.Date, row.Date))
+		}
+		// The same asset with other contents has been re-published.
+		if row.Asset == got.Asset && row.AssetDigest != "" && row.AssetDigest != got.AssetDigest {
+			diffs = append(diffs, fmt.Sprintf("%s digest %s, table says %s", got.Asset, got.AssetDigest, row.AssetDigest))
+		}
+		for _, diff := range diffs {
+			problems = append(problems, fmt.Sprintf("%s: %s", tag, diff))
+		}
+		if len(diffs) == 0 {
+			verified = append(verified, tag)
+		}
+	}
+	return verified, problems
+}
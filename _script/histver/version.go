@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/versions"
+)
+
+// defaultTagPattern accepts semver-like tags with an optional "v" prefix
+// and two to four numeric components, i.e. v1.27.0, 2.0.0 or v1.2.3.4, and
+// the two part tags of the earliest releases, such as v0.2.
+const defaultTagPattern = `^v?(\d+(?:\.\d+){1,3})$`
+
+// tagExp selects the release tags we care about. The first submatch, when
+// present, is the dotted numeric version; otherwise the whole match
+// (without any non-numeric prefix) is used.
+var tagExp = regexp.MustCompile(defaultTagPattern)
+
+func setTagPattern(pattern string) error {
+	exp, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("tag pattern: %w", err)
+	}
+	tagExp = exp
+	return nil
+}
+
+// parseVersion returns the numeric components of a tag accepted by the
+// tag pattern. A missing patch component is 0, so that v0.2 is v0.2.0.
+func parseVersion(tag string) ([]int, bool) {
+	m := tagExp.FindStringSubmatch(tag)
+	if m == nil {
+		return nil, false
+	}
+	num := m[0]
+	if len(m) > 1 && m[1] != "" {
+		num = m[1]
+	}
+	num = strings.TrimLeftFunc(num, func(r rune) bool { return r < '0' || r > '9' })
+
+	var parts []int
+	for _, s := range strings.Split(num, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	if len(parts) == 2 {
+		parts = append(parts, 0)
+	}
+	return parts, true
+}
+
+// compareVersions compares versions numerically, component by component,
+// so that v1.10.0 sorts after v1.9.0 and v2.0.0 after any v1. A missing
+// component sorts before a present one (v1.2.3 < v1.2.3.1). Tags not
+// matching the tag pattern sort before those that do, and as plain
+// strings among themselves.
+func compareVersions(a, b string) int {
+	av, aok := parseVersion(a)
+	bv, bok := parseVersion(b)
+	switch {
+	case !aok && !bok:
+		return strings.Compare(a, b)
+	case !aok:
+		return -1
+	case !bok:
+		return 1
+	}
+	return versions.CompareParts(av, bv)
+}
+
+// parseGoVersion returns the numeric components of a Go runtime version
+// such as go1.21.4 (or go1.21rc2, ignoring the prerelease part).
+func parseGoVersion(s string) ([]int, bool) {
+	s, ok := strings.CutPrefix(s, "go")
+	if !ok {
+		return nil, false
+	}
+	if i := strings.IndexFunc(s, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); i >= 0 {
+		s = s[:i]
+	}
+	var parts []int
+	for _, p := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
+
+// compareGoVersions compares Go runtime versions numerically. Unparseable
+// versions sort first.
+func compareGoVersions(a, b string) int {
+	av, aok := parseGoVersion(a)
+	bv, bok := parseGoVersion(b)
+	switch {
+	case !aok && !bok:
+		return strings.Compare(a, b)
+	case !aok:
+		return -1
+	case !bok:
+		return 1
+	}
+	return versions.CompareParts(av, bv)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v49/github"
+)
+
+// A wrapper is an app that bundles the Syncthing core, like
+// syncthing-macos and syncthing-android. For those we track which core
+// version each app release ships.
+type wrapper struct {
+	// bundle returns the bundled core binary and related metadata from
+	// the release assets matching the asset patterns.
+	bundle func(assets []*github.ReleaseAsset) (*bundle, error)
+}
+
+type bundle struct {
+	binary []byte
+	// version is the app's own version, as declared in its metadata,
+	// if known.
+	version string
+	minOS   string // minimum OS version declared by the app, if known
+}
+
+// wrapperRow is a row of the versions table of a repository other than
+// syncthing/syncthing's core releases.
+type wrapperRow struct {
+	Version   string // release version
+	Syncthing string // bundled core version, for wrappers
+	Runtime   string
+	Date      string
+	MinOS     string
+}
+
+var wrapperHeader = []string{"Version", "Syncthing", "Runtime", "Date"}
+
+// minOSColumn is written only when we know the minimum OS version for at
+// least one release.
+const minOSColumn = "Minimum OS"
+
+func (wr wrapper) releaseRow(repo string, rel *github.RepositoryRelease) (*wrapperRow, error) {
+	b, err := wr.bundle(releaseAssets(repo, rel, "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	if b.version != "" && !sameVersion(b.version, rel.GetTagName()) {
+		return nil, fmt.Errorf("the app declares version %s", b.version)
+	}
+	version, goVersion, err := buildInfoVersion(bytesSection(b.binary))
+	if err != nil {
+		return nil, err
+	}
+	return &wrapperRow{
+		Version:   *rel.TagName,
+		Syncthing: version,
+		Runtime:   goVersion,
+		Date:      releaseDate(rel, dateSourcePublished, ""),
+		MinOS:     b.minOS,
+	}, nil
+}
+
+// sameVersion reports whether a declared version is that of the tag,
+// which may have a v prefix it lacks.
+func sameVersion(declared, tag string) bool {
+	return strings.TrimPrefix(declared, "v") == strings.TrimPrefix(tag, "v")
+}
+
+func writeWrapperTable(w io.Writer, rows []*wrapperRow) error {
+	sort.Slice(rows, func(a, b int) bool {
+		if rows[a].Date == rows[b].Date {
+			return rows[a].Version > rows[b].Version
+		}
+		return rows[a].Date > rows[b].Date
+	})
+	// The core version is only there for wrappers.
+	withCore, withMinOS := false, false
+	for _, r := range rows {
+		withCore = withCore || r.Syncthing != ""
+		withMinOS = withMinOS || r.MinOS != ""
+	}
+	header := []string{"Version", "Runtime", "Date"}
+	if withCore {
+		header = wrapperHeader[:len(wrapperHeader):len(wrapperHeader)]
+	}
+	if withMinOS {
+		header = append(header, minOSColumn)
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		rec := []string{r.Version, r.Runtime, r.Date}
+		if withCore {
+			rec = []string{r.Version, r.Syncthing, r.Runtime, r.Date}
+		}
+		if withMinOS {
+			rec = append(rec, r.MinOS)
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func readWrapperTable(r io.Reader) ([]*wrapperRow, error) {
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	bs, err = normalizeText(bs)
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(bytes.NewReader(bs))
+	cr.FieldsPerRecord = -1
+	recs, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	cols := columnIndex(wrapperHeader)
+	var rows []*wrapperRow
+	for _, ss := range recs {
+		if len(ss) == 0 {
+			continue
+		}
+		if cleanField(ss[0]) == wrapperHeader[0] {
+			cols = columnIndex(ss)
+			continue
+		}
+		if len(ss) < 3 {
+			return nil, fmt.Errorf("not enough fields")
+		}
+		get := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(ss) {
+				return cleanField(ss[i])
+			}
+			return ""
+		}
+		rows = append(rows, &wrapperRow{
+			Version:   get("Version"),
+			Syncthing: get("Syncthing"),
+			Runtime:   get("Runtime"),
+			Date:      get("Date"),
+			MinOS:     get(minOSColumn),
+		})
+	}
+	return rows, nil
+}
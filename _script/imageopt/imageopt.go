@@ -0,0 +1,105 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./imageopt -html ../_build/html [-webp] [-avif]
+//
+// Optimizes the images in the built HTML documentation: images larger
+// than -max pixels are scaled down, PNGs are recompressed and JPEGs
+// re-encoded at no more than -jpeg-quality, keeping the result only if
+// it's smaller. With -webp and -avif, variants in those formats are made
+// using cwebp and avifenc, and the pages are rewritten to offer them to
+// browsers that support them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type options struct {
+	maxSize     int
+	jpegQuality int
+	dryRun      bool
+}
+
+func main() {
+	log.SetFlags(0)
+	dir := flag.String("html", "../_build/html", "Built HTML documentation")
+	maxSize := flag.Int("max", 1600, "Maximum width and height of images, in pixels")
+	quality := flag.Int("jpeg-quality", 85, "Maximum JPEG quality")
+	webp := flag.Bool("webp", false, "Make WebP variants, using cwebp")
+	avif := flag.Bool("avif", false, "Make AVIF variants, using avifenc")
+	dryRun := flag.Bool("dry-run", false, "Report the savings without changing anything")
+	flag.Parse()
+
+	opts := options{maxSize: *maxSize, jpegQuality: *quality, dryRun: *dryRun}
+	images, err := filepath.Glob(filepath.Join(*dir, "_images", "*"))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var before, after int64
+	variants := make(map[string][]variant)
+	for _, name := range images {
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+			continue
+		}
+		r, err := optimize(name, opts)
+		if err != nil {
+			log.Fatalf("%s: %v", filepath.Base(name), err)
+		}
+		before += r.before
+		after += r.after
+		if r.after < r.before {
+			log.Printf("%s: %s -> %s %s", filepath.Base(name), size(r.before), size(r.after), r.note)
+		}
+		if opts.dryRun {
+			continue
+		}
+		for _, f := range variantFormats(*webp, *avif) {
+			v, ok, err := makeVariant(name, f, r.after)
+			if err != nil {
+				log.Fatalf("%s: %v", filepath.Base(name), err)
+			}
+			if ok {
+				variants[filepath.Base(name)] = append(variants[filepath.Base(name)], v)
+			}
+		}
+	}
+	log.Printf("images: %s -> %s", size(before), size(after))
+
+	if len(variants) > 0 {
+		n, err := rewritePages(*dir, variants)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("rewrote %d pages to use the variants", n)
+	}
+}
+
+func size(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func fileSize(name string) (int64, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+
+	"golang.org/x/image/draw"
+)
+
+type result struct {
+	before, after int64
+	note          string
+}
+
+// optimize scales down and re-encodes an image in place, if that makes it
+// smaller or it's too large.
+func optimize(name string, opts options) (result, error) {
+	orig, err := os.ReadFile(name)
+	if err != nil {
+		return result{}, err
+	}
+	res := result{before: int64(len(orig)), after: int64(len(orig))}
+	img, format, err := image.Decode(bytes.NewReader(orig))
+	if err != nil {
+		return result{}, err
+	}
+
+	resized := false
+	if b := img.Bounds(); b.Dx() > opts.maxSize || b.Dy() > opts.maxSize {
+		img = scale(img, opts.maxSize)
+		resized = true
+		res.note = fmt.Sprintf("(scaled from %dx%d)", b.Dx(), b.Dy())
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		err = enc.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: opts.jpegQuality})
+	default:
+		return res, nil
+	}
+	if err != nil {
+		return result{}, err
+	}
+	if !resized && buf.Len() >= len(orig) {
+		return res, nil
+	}
+	res.after = int64(buf.Len())
+	if opts.dryRun {
+		return res, nil
+	}
+	return res, os.WriteFile(name, buf.Bytes(), 0o644)
+}
+
+// scale returns the image scaled down to fit within max by max pixels.
+func scale(img image.Image, max int) image.Image {
+	b := img.Bounds()
+	w, h := max, b.Dy()*max/b.Dx()
+	if b.Dy() > b.Dx() {
+		w, h = b.Dx()*max/b.Dy(), max
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
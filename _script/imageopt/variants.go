@@ -0,0 +1,115 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// variantFormat is an alternative image format, made with an external
+// encoder.
+type variantFormat struct {
+	ext, mime string
+	command   func(in, out string) *exec.Cmd
+}
+
+var (
+	webpFormat = variantFormat{".webp", "image/webp", func(in, out string) *exec.Cmd {
+		return exec.Command("cwebp", "-quiet", "-q", "80", in, "-o", out)
+	}}
+	avifFormat = variantFormat{".avif", "image/avif", func(in, out string) *exec.Cmd {
+		return exec.Command("avifenc", "--speed", "6", in, out)
+	}}
+)
+
+// variantFormats returns the formats to make variants in, best first as
+// browsers pick the first they support.
+func variantFormats(webp, avif bool) []variantFormat {
+	var res []variantFormat
+	if avif {
+		res = append(res, avifFormat)
+	}
+	if webp {
+		res = append(res, webpFormat)
+	}
+	return res
+}
+
+type variant struct {
+	file string
+	mime string
+}
+
+// makeVariant makes a variant of the image, keeping it only if it's
+// smaller than the image.
+func makeVariant(name string, f variantFormat, size int64) (variant, bool, error) {
+	out := strings.TrimSuffix(name, filepath.Ext(name)) + f.ext
+	cmd := f.command(name, out)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return variant{}, false, fmt.Errorf("%s: %w: %s", cmd.Path, err, strings.TrimSpace(stderr.String()))
+	}
+	vs, err := fileSize(out)
+	if err != nil {
+		return variant{}, false, err
+	}
+	if vs >= size {
+		return variant{}, false, os.Remove(out)
+	}
+	return variant{filepath.Base(out), f.mime}, true, nil
+}
+
+var imgExp = regexp.MustCompile(`<img [^>]*src="([^"]*_images/([^"/]+))"[^>]*>`)
+
+// rewritePages wraps the img elements for images with variants in
+// picture elements offering them, returning the number of pages changed.
+func rewritePages(dir string, variants map[string][]variant) (int, error) {
+	changed := 0
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(p, ".html") {
+			return err
+		}
+		bs, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var out []byte
+		prev := 0
+		for _, m := range imgExp.FindAllSubmatchIndex(bs, -1) {
+			vs := variants[string(bs[m[4]:m[5]])]
+			if len(vs) == 0 || bytes.HasSuffix(bs[:m[0]], []byte(`type="`+vs[len(vs)-1].mime+`">`)) {
+				// No variants, or already rewritten.
+				continue
+			}
+			out = append(out, bs[prev:m[0]]...)
+			out = append(out, "<picture>"...)
+			for _, v := range vs {
+				src := path.Join(path.Dir(string(bs[m[2]:m[3]])), v.file)
+				out = append(out, fmt.Sprintf(`<source srcset="%s" type="%s">`, src, v.mime)...)
+			}
+			out = append(out, bs[m[0]:m[1]]...)
+			out = append(out, "</picture>"...)
+			prev = m[1]
+		}
+		out = append(out, bs[prev:]...)
+		if bytes.Equal(out, bs) {
+			return nil
+		}
+		changed++
+		return os.WriteFile(p, out, 0o644)
+	})
+	return changed, err
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./infrastatus > ../includes/infrastructure.rst
+//
+// Writes the table of the public services Syncthing uses by default, from
+// infrastructure.json, checking that each responds. Services that don't
+// are marked so in the table and listed on stderr, and the exit status is
+// nonzero. With -probe=false, nothing is checked and the status column is
+// left out.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+type service struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Protocol string `json:"protocol"`
+	Operator string `json:"operator"`
+	// Probe is the kind of check, one of those in probes; empty when
+	// the service can't be checked as one.
+	Probe string `json:"probe"`
+}
+
+// status is the result of checking a service.
+type status struct {
+	checked bool
+	note    string
+	err     error
+}
+
+func main() {
+	log.SetFlags(0)
+	file := flag.String("data", "infrastatus/infrastructure.json", "Service manifest")
+	probe := flag.Bool("probe", true, "Check that the services respond")
+	flag.Parse()
+
+	services, err := load(*file)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var statuses []status
+	dead := 0
+	if *probe {
+		for _, s := range services {
+			st := check(s)
+			if st.err != nil {
+				log.Printf("not responding: %s (%s): %v", s.Name, s.Address, st.err)
+				dead++
+			}
+			statuses = append(statuses, st)
+		}
+	}
+
+	if err := writeTable(os.Stdout, services, statuses, time.Now().UTC()); err != nil {
+		log.Fatalln(err)
+	}
+	if dead > 0 {
+		os.Exit(1)
+	}
+}
+
+func load(path string) ([]service, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var data struct {
+		Services []service `json:"services"`
+	}
+	if err := json.Unmarshal(bs, &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, s := range data.Services {
+		if _, ok := probes[s.Probe]; s.Probe != "" && !ok {
+			return nil, fmt.Errorf("%s: %s: unknown probe %q", path, s.Name, s.Probe)
+		}
+	}
+	return data.Services, nil
+}
+
+func check(s service) status {
+	if s.Probe == "" {
+		return status{}
+	}
+	note, err := probes[s.Probe](s.Address)
+	return status{checked: true, note: note, err: err}
+}
+
+func (st status) String() string {
+	switch {
+	case !st.checked:
+		return "Not checked"
+	case st.err != nil:
+		return "**Not responding**"
+	case st.note != "":
+		return "Responding, " + st.note
+	default:
+		return "Responding"
+	}
+}
+
+func writeTable(w io.Writer, services []service, statuses []status, now time.Time) error {
+	t := rst.Table{
+		Header: []string{"Service", "Address", "Protocol", "Operator"},
+		Widths: []int{25, 35, 20, 20},
+	}
+	for i, s := range services {
+		// Addresses as literals, descriptions in their place as text.
+		addr := rst.Literal(s.Address)
+		if strings.Contains(s.Address, " ") {
+			addr = rst.Escape(s.Address)
+		}
+		row := []string{s.Name, addr, s.Protocol, s.Operator}
+		if statuses != nil {
+			row = append(row, statuses[i].String())
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	if statuses != nil {
+		t.Header = append(t.Header, "Status")
+		t.Widths = []int{20, 30, 15, 15, 20}
+	}
+
+	if _, err := fmt.Fprint(w, ".. This file is generated by _script/infrastatus; do not edit.\n\n"); err != nil {
+		return err
+	}
+	if _, err := t.WriteTo(w); err != nil {
+		return err
+	}
+	if statuses != nil {
+		_, err := fmt.Fprintf(w, "Status as checked on %s.\n", now.Format("2006-01-02"))
+		return err
+	}
+	return nil
+}
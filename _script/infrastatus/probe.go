@@ -0,0 +1,112 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// probeTimeout bounds each probe.
+const probeTimeout = 15 * time.Second
+
+// probes check that a service responds, returning a note for the status
+// column.
+var probes = map[string]func(address string) (string, error){
+	"https":     probeHTTPS,
+	"relaypool": probeRelayPool,
+	"stun":      probeSTUN,
+}
+
+// client doesn't retry, as the probes are to find the services that
+// don't answer.
+var client = httpclient.New(httpclient.Options{Timeout: probeTimeout})
+
+// probeHTTPS checks that the server answers. Most endpoints expect
+// parameters or a POST and answer a plain GET with a client error, which
+// is fine; server errors are not.
+func probeHTTPS(address string) (string, error) {
+	resp, err := client.Get(address)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+	return "", nil
+}
+
+// probeRelayPool checks that the pool lists relays, noting how many.
+func probeRelayPool(address string) (string, error) {
+	resp, err := client.Get(address)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+	var pool struct {
+		Relays []struct {
+			URL string `json:"url"`
+		} `json:"relays"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pool); err != nil {
+		return "", err
+	}
+	if len(pool.Relays) == 0 {
+		return "", fmt.Errorf("no relays listed")
+	}
+	return fmt.Sprintf("%d relays listed", len(pool.Relays)), nil
+}
+
+// STUN binding request, RFC 5389.
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingResponse = 0x0101
+	stunMagicCookie     = 0x2112a442
+)
+
+// probeSTUN sends a binding request and waits for the response.
+func probeSTUN(address string) (string, error) {
+	conn, err := net.DialTimeout("udp", address, probeTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	req := make([]byte, 20)
+	binary.BigEndian.PutUint16(req[0:], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:], stunMagicCookie)
+	if _, err := rand.Read(req[8:]); err != nil {
+		return "", err
+	}
+	if err := conn.SetDeadline(time.Now().Add(probeTimeout)); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return "", err
+	}
+	resp := make([]byte, 1500)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return "", err
+	}
+	if n < 20 || binary.BigEndian.Uint16(resp) != stunBindingResponse || !bytes.Equal(resp[8:20], req[8:20]) {
+		return "", fmt.Errorf("not a binding response")
+	}
+	return "", nil
+}
@@ -0,0 +1,359 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package cache is the on-disk cache shared by the tools: HTTP responses,
+// API objects and downloaded artifacts, each entry kept for its own time
+// to live, with a size cap over all of them. The cache is in
+// syncthing-docs under the user cache directory, or the directory in
+// $SYNCTHING_DOCS_CACHE; setting that to "off" disables caching.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The kinds of entries, which are kept in separate directories.
+const (
+	HTTP     = "http"
+	API      = "api"
+	Artifact = "artifact"
+)
+
+// Never is the time to live of entries that don't expire, such as those
+// keyed by a digest of their contents.
+const Never time.Duration = 0
+
+// DefaultMaxSize is the size cap of the default cache.
+const DefaultMaxSize = 2 << 30
+
+// stagingPrefix starts the names of the directories that store fills
+// before moving them into place.
+const stagingPrefix = ".tmp-"
+
+// stagingGrace is how old a staging directory must be for Trim to take
+// it for one left behind by an interrupted store, rather than one another
+// process is still filling.
+const stagingGrace = 24 * time.Hour
+
+// EnvVar overrides the directory of the default cache.
+const EnvVar = "SYNCTHING_DOCS_CACHE"
+
+// Cache is a cache directory. The nil Cache is disabled: nothing is found
+// in it, and storing is a no-op.
+type Cache struct {
+	dir string
+	// MaxSize is the total size in bytes that Trim cuts the cache down to.
+	MaxSize int64
+	mut     sync.Mutex
+}
+
+var (
+	defaultOnce  sync.Once
+	defaultCache *Cache
+)
+
+// Default returns the cache shared by the tools, or nil if it's disabled
+// or there's no user cache directory.
+func Default() *Cache {
+	defaultOnce.Do(func() {
+		dir := os.Getenv(EnvVar)
+		switch dir {
+		case "off":
+			return
+		case "":
+			base, err := os.UserCacheDir()
+			if err != nil {
+				return
+			}
+			dir = filepath.Join(base, "syncthing-docs")
+		}
+		defaultCache = New(dir)
+	})
+	return defaultCache
+}
+
+// New returns the cache in the directory, with the default size cap.
+func New(dir string) *Cache {
+	return &Cache{dir: dir, MaxSize: DefaultMaxSize}
+}
+
+// meta is stored with each entry.
+type meta struct {
+	Key    string    `json:"key"`
+	Stored time.Time `json:"stored"`
+	// Expires is zero for entries that never expire.
+	Expires time.Time `json:"expires,omitempty"`
+	// Revalidate entries are kept after they expire, until trimmed for
+	// size, as they can be revalidated with the server.
+	Revalidate bool `json:"revalidate,omitempty"`
+}
+
+func (m meta) expired(now time.Time) bool {
+	return !m.Expires.IsZero() && now.After(m.Expires)
+}
+
+const (
+	metaFile = "meta.json"
+	dataFile = "data"
+)
+
+// entryDir is the directory of an entry, named by the hash of its key.
+func (c *Cache) entryDir(kind, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	h := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, kind, h[:2], h)
+}
+
+// lookup returns the directory of the entry if it's there and hasn't
+// expired.
+func (c *Cache) lookup(kind, key string) (string, bool) {
+	dir, m, ok := c.lookupStale(kind, key)
+	if !ok || m.expired(time.Now()) {
+		return "", false
+	}
+	return dir, true
+}
+
+// lookupStale returns the directory and metadata of the entry if it's
+// there, expired or not.
+func (c *Cache) lookupStale(kind, key string) (string, meta, bool) {
+	if c == nil {
+		return "", meta{}, false
+	}
+	dir := c.entryDir(kind, key)
+	m, err := readMeta(dir)
+	if err != nil || m.Key != key {
+		return "", meta{}, false
+	}
+	return dir, m, true
+}
+
+func readMeta(dir string) (meta, error) {
+	var m meta
+	bs, err := os.ReadFile(filepath.Join(dir, metaFile))
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(bs, &m)
+	return m, err
+}
+
+// store replaces the entry with what fill writes into a new directory.
+func (c *Cache) store(kind, key string, ttl time.Duration, revalidate bool, fill func(dir string) error) (string, error) {
+	dir := c.entryDir(kind, key)
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), stagingPrefix)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	if err := fill(tmp); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	m := meta{Key: key, Stored: now, Revalidate: revalidate}
+	if ttl != Never {
+		m.Expires = now.Add(ttl)
+	}
+	bs, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, metaFile), bs, 0o644); err != nil {
+		return "", err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Get returns the data of the entry, if it's there and hasn't expired.
+func (c *Cache) Get(kind, key string) ([]byte, bool) {
+	dir, ok := c.lookup(kind, key)
+	if !ok {
+		return nil, false
+	}
+	bs, err := os.ReadFile(filepath.Join(dir, dataFile))
+	if err != nil {
+		return nil, false
+	}
+	return bs, true
+}
+
+// Put stores the data as the entry, for the time to live.
+func (c *Cache) Put(kind, key string, data []byte, ttl time.Duration) error {
+	if c == nil {
+		return nil
+	}
+	_, err := c.store(kind, key, ttl, false, func(dir string) error {
+		return os.WriteFile(filepath.Join(dir, dataFile), data, 0o644)
+	})
+	return err
+}
+
+// getStale returns the data of a revalidate entry, expired or not, and
+// whether it has expired.
+func (c *Cache) getStale(kind, key string) ([]byte, bool, bool) {
+	dir, m, ok := c.lookupStale(kind, key)
+	if !ok {
+		return nil, false, false
+	}
+	bs, err := os.ReadFile(filepath.Join(dir, dataFile))
+	if err != nil {
+		return nil, false, false
+	}
+	return bs, m.expired(time.Now()), true
+}
+
+// putRevalidate is Put for an entry kept after it expires, to be
+// revalidated.
+func (c *Cache) putRevalidate(kind, key string, data []byte, ttl time.Duration) error {
+	_, err := c.store(kind, key, ttl, true, func(dir string) error {
+		return os.WriteFile(filepath.Join(dir, dataFile), data, 0o644)
+	})
+	return err
+}
+
+// GetJSON unmarshals the entry into v, returning whether it was there.
+func (c *Cache) GetJSON(kind, key string, v any) bool {
+	bs, ok := c.Get(kind, key)
+	return ok && json.Unmarshal(bs, v) == nil
+}
+
+// PutJSON stores v marshalled as JSON as the entry.
+func (c *Cache) PutJSON(kind, key string, v any, ttl time.Duration) error {
+	if c == nil {
+		return nil
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Put(kind, key, bs, ttl)
+}
+
+// ErrDisabled is returned by Dir of the nil Cache.
+var ErrDisabled = errors.New("cache disabled")
+
+// Dir returns the directory of an artifact entry, filling it with fill if
+// it isn't there or has expired. The directory is only valid until the
+// entry is replaced or trimmed.
+func (c *Cache) Dir(key string, ttl time.Duration, fill func(dir string) error) (string, error) {
+	if c == nil {
+		return "", ErrDisabled
+	}
+	if dir, ok := c.lookup(Artifact, key); ok {
+		return filepath.Join(dir, dataFile), nil
+	}
+	dir, err := c.store(Artifact, key, ttl, false, func(dir string) error {
+		data := filepath.Join(dir, dataFile)
+		if err := os.Mkdir(data, 0o755); err != nil {
+			return err
+		}
+		return fill(data)
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+	return filepath.Join(dir, dataFile), nil
+}
+
+// Trim removes the expired entries, except those to be revalidated, then
+// the oldest ones until the cache is within its size cap.
+func (c *Cache) Trim() error {
+	if c == nil {
+		return nil
+	}
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	type entry struct {
+		dir    string
+		stored time.Time
+		size   int64
+	}
+	var entries []entry
+	var total int64
+	now := time.Now()
+	for _, kind := range []string{HTTP, API, Artifact} {
+		dirs, _ := filepath.Glob(filepath.Join(c.dir, kind, "*", "*"))
+		for _, dir := range dirs {
+			if strings.HasPrefix(filepath.Base(dir), stagingPrefix) {
+				if fi, err := os.Stat(dir); err == nil && now.Sub(fi.ModTime()) > stagingGrace {
+					if err := os.RemoveAll(dir); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			m, err := readMeta(dir)
+			if err != nil || m.expired(now) && !m.Revalidate {
+				// Expired, or without readable metadata.
+				if err := os.RemoveAll(dir); err != nil {
+					return err
+				}
+				continue
+			}
+			size, err := dirSize(dir)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry{dir, m.Stored, size})
+			total += size
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].stored.Before(entries[b].stored)
+	})
+	for _, e := range entries {
+		if total <= c.MaxSize {
+			break
+		}
+		if err := os.RemoveAll(e.dir); err != nil {
+			return err
+		}
+		total -= e.size
+	}
+	return nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httputil"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// Client returns an HTTP client that answers GET requests from the cache
+// when it can, and caches the successful responses for the time to live.
+// Responses with an ETag or Last-Modified header are kept after they
+// expire and then revalidated with a conditional request, which GitHub's
+// API doesn't count against the rate limit of authenticated requests
+// when nothing changed. Requests with an Authorization header are cached by URL like
+// any other, so the client should only be used for what every caller may
+// see.
+func (c *Cache) Client(ttl time.Duration) *http.Client {
+	return &http.Client{Transport: c.Transport(httpclient.Default.Transport, ttl)}
+}
+
+// Transport returns a round tripper caching the successful responses to
+// GET requests made through base, as Client.
+func (c *Cache) Transport(base http.RoundTripper, ttl time.Duration) http.RoundTripper {
+	if c == nil {
+		return base
+	}
+	return &transport{cache: c, base: base, ttl: ttl}
+}
+
+type transport struct {
+	cache *Cache
+	base  http.RoundTripper
+	ttl   time.Duration
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String()
+	var cached *http.Response
+	stale, expired, ok := t.cache.getStale(HTTP, key)
+	if ok {
+		cached, _ = readResponse(stale, req)
+	}
+	if cached != nil && !expired {
+		return cached, nil
+	}
+
+	// An expired response is revalidated, when it has a validator.
+	var etag, modified string
+	if cached != nil {
+		etag, modified = cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+	}
+	if etag != "" || modified != "" {
+		req = req.Clone(req.Context())
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusNotModified && (etag != "" || modified != "") {
+		resp.Body.Close()
+		// Stored again for another time to live.
+		_ = t.cache.putRevalidate(HTTP, key, stale, t.ttl)
+		return cached, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	bs, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	// A failure to cache the response is no reason to fail the request.
+	if resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "" {
+		_ = t.cache.putRevalidate(HTTP, key, bs, t.ttl)
+	} else {
+		_ = t.cache.Put(HTTP, key, bs, t.ttl)
+	}
+	return readResponse(bs, req)
+}
+
+func readResponse(bs []byte, req *http.Request) (*http.Response, error) {
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(bs)), req)
+}
@@ -0,0 +1,218 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package discourse fetches topics and posts from the Discourse API of
+// the forum.
+package discourse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// DefaultURL is the Syncthing forum.
+const DefaultURL = "https://forum.syncthing.net"
+
+// Client talks to the API of a Discourse forum. Only public content is
+// read, so no authentication is needed.
+type Client struct {
+	URL string
+}
+
+// NewClient returns a client for the forum at the given URL.
+func NewClient(forumURL string) *Client {
+	return &Client{URL: forumURL}
+}
+
+// Post is a forum post.
+type Post struct {
+	ID         int    `json:"id"`
+	PostNumber int    `json:"post_number"`
+	TopicID    int    `json:"topic_id"`
+	Username   string `json:"username"`
+	Name       string `json:"name"`
+	// Raw is the Markdown source of the post.
+	Raw string `json:"raw"`
+	// Version counts the edits of the post, from 1.
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Author returns the display name of the post's author.
+func (p *Post) Author() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Username
+}
+
+// Topic is a topic with its first posts.
+type Topic struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	// AcceptedAnswer is set by the solved plugin when a reply is marked
+	// as the solution.
+	AcceptedAnswer *struct {
+		PostNumber int `json:"post_number"`
+	} `json:"accepted_answer"`
+	PostStream struct {
+		Posts []struct {
+			ID         int `json:"id"`
+			PostNumber int `json:"post_number"`
+		} `json:"posts"`
+	} `json:"post_stream"`
+}
+
+func (t *Topic) postID(number int) int {
+	for _, p := range t.PostStream.Posts {
+		if p.PostNumber == number {
+			return p.ID
+		}
+	}
+	return 0
+}
+
+// Answer returns the topic and its canonical answer: the given post
+// number, or else the accepted answer, or else the first post.
+func (c *Client) Answer(ctx context.Context, topicID, number int) (*Topic, *Post, error) {
+	var t Topic
+	if err := c.get(ctx, fmt.Sprintf("t/%d.json", topicID), nil, &t); err != nil {
+		return nil, nil, err
+	}
+	if number == 0 && t.AcceptedAnswer != nil {
+		number = t.AcceptedAnswer.PostNumber
+	}
+	if number == 0 {
+		number = 1
+	}
+
+	id := t.postID(number)
+	if id == 0 {
+		// Only the first posts come with the topic; ask for those
+		// around the one we want.
+		var around Topic
+		if err := c.get(ctx, fmt.Sprintf("t/%d/%d.json", topicID, number), nil, &around); err != nil {
+			return nil, nil, err
+		}
+		if id = around.postID(number); id == 0 {
+			return nil, nil, fmt.Errorf("topic %d: no post %d", topicID, number)
+		}
+	}
+
+	var p Post
+	if err := c.get(ctx, "posts/"+strconv.Itoa(id)+".json", nil, &p); err != nil {
+		return nil, nil, err
+	}
+	return &t, &p, nil
+}
+
+// PostURL returns the link to a post.
+func (c *Client) PostURL(p *Post) string {
+	u, _ := url.JoinPath(c.URL, "t", strconv.Itoa(p.TopicID), strconv.Itoa(p.PostNumber))
+	return u
+}
+
+// TopicURL returns the link to a topic.
+func (c *Client) TopicURL(id int) string {
+	u, _ := url.JoinPath(c.URL, "t", strconv.Itoa(id))
+	return u
+}
+
+// TopicSummary is a topic as listed in a category.
+type TopicSummary struct {
+	ID                int       `json:"id"`
+	Title             string    `json:"title"`
+	PostsCount        int       `json:"posts_count"`
+	Views             int       `json:"views"`
+	CreatedAt         time.Time `json:"created_at"`
+	HasAcceptedAnswer bool      `json:"has_accepted_answer"`
+	Tags              Tags      `json:"tags"`
+}
+
+// Tags are the names of the tags of a topic, which newer Discourse
+// versions list as objects.
+type Tags []string
+
+func (t *Tags) UnmarshalJSON(bs []byte) error {
+	var names []string
+	if err := json.Unmarshal(bs, &names); err == nil {
+		*t = names
+		return nil
+	}
+	var objs []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(bs, &objs); err != nil {
+		return err
+	}
+	*t = nil
+	for _, o := range objs {
+		*t = append(*t, o.Name)
+	}
+	return nil
+}
+
+// CategoryTopics returns the topics of the category, by its slug, that
+// were created after the given time, newest first.
+func (c *Client) CategoryTopics(ctx context.Context, category string, since time.Time) ([]TopicSummary, error) {
+	var res []TopicSummary
+	for page := 0; ; page++ {
+		var list struct {
+			TopicList struct {
+				Topics        []TopicSummary `json:"topics"`
+				MoreTopicsURL string         `json:"more_topics_url"`
+			} `json:"topic_list"`
+		}
+		q := url.Values{"order": {"created"}, "page": {strconv.Itoa(page)}}
+		if err := c.get(ctx, "c/"+category+".json", q, &list); err != nil {
+			return nil, err
+		}
+		for _, t := range list.TopicList.Topics {
+			if t.CreatedAt.Before(since) {
+				// Pinned topics come first regardless of age.
+				continue
+			}
+			res = append(res, t)
+		}
+		topics := list.TopicList.Topics
+		if list.TopicList.MoreTopicsURL == "" || len(topics) == 0 || topics[len(topics)-1].CreatedAt.Before(since) {
+			return res, nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, q url.Values, v any) error {
+	u, err := url.JoinPath(c.URL, path)
+	if err != nil {
+		return err
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpclient.Default.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package httpclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limit is how many requests a host gets at a time, and how long after
+// one starts the next may.
+type Limit struct {
+	Concurrency int
+	Delay       time.Duration
+}
+
+// DefaultLimit is the limit of the hosts not in Limits.
+var DefaultLimit = Limit{Concurrency: 4}
+
+// Limits are those of the hosts that ask for less than DefaultLimit in
+// their API terms, or are run by the project on modest servers. They
+// don't extend to subdomains.
+var Limits = map[string]Limit{
+	"api.github.com":      {Concurrency: 4, Delay: 100 * time.Millisecond},
+	"repology.org":        {Concurrency: 1, Delay: time.Second},
+	"forum.syncthing.net": {Concurrency: 2, Delay: 250 * time.Millisecond},
+	"hosted.weblate.org":  {Concurrency: 2, Delay: 250 * time.Millisecond},
+	"web.archive.org":     {Concurrency: 1, Delay: time.Second},
+	"archive.org":         {Concurrency: 1, Delay: time.Second},
+	"hub.docker.com":      {Concurrency: 2, Delay: 250 * time.Millisecond},
+}
+
+// hostState is shared by all the clients, so that the limits hold for the
+// process as a whole.
+type hostState struct {
+	sem  chan struct{}
+	mut  sync.Mutex
+	next time.Time
+}
+
+var (
+	hostsMut sync.Mutex
+	hosts    = make(map[string]*hostState)
+)
+
+func host(name string) *hostState {
+	hostsMut.Lock()
+	defer hostsMut.Unlock()
+	h, ok := hosts[name]
+	if !ok {
+		lim := limit(name)
+		h = &hostState{sem: make(chan struct{}, lim.Concurrency)}
+		hosts[name] = h
+	}
+	return h
+}
+
+func limit(name string) Limit {
+	lim, ok := Limits[name]
+	if !ok {
+		lim = DefaultLimit
+	}
+	if lim.Concurrency < 1 {
+		lim.Concurrency = 1
+	}
+	return lim
+}
+
+// acquire waits until a request to the host may start, at least delay
+// after the previous one, returning the function to call once it's done.
+func acquire(ctx context.Context, name string, delay time.Duration) (func(), error) {
+	h := host(name)
+	select {
+	case h.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if d := limit(name).Delay; d > delay {
+		delay = d
+	}
+
+	h.mut.Lock()
+	now := time.Now()
+	at := h.next
+	if at.Before(now) {
+		at = now
+	}
+	h.next = at.Add(delay)
+	h.mut.Unlock()
+
+	select {
+	case <-time.After(time.Until(at)):
+		return func() { <-h.sem }, nil
+	case <-ctx.Done():
+		<-h.sem
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package httpclient is the HTTP client of the tools that use the network,
+// so that together they behave well toward the services they use.
+// Requests identify the tools by their user agent, each host gets a
+// limited number of requests at a time, spaced out as it asks, and the
+// failures that may be temporary, GitHub's rate limits among them, are
+// retried after backing off, or as long as the server asks. Proxies are
+// taken from $HTTPS_PROXY, $HTTP_PROXY and $NO_PROXY.
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// UserAgent is sent with the requests that don't set their own.
+const UserAgent = "syncthing-docs-tools (+https://github.com/syncthing/docs)"
+
+// Options are the per client settings.
+type Options struct {
+	// Timeout bounds each attempt, including reading the body.
+	Timeout time.Duration
+	// HeaderTimeout bounds each attempt until the response headers are
+	// in, leaving the body as long as it takes, for downloads.
+	HeaderTimeout time.Duration
+	// Retries is how many more times a request is tried after a
+	// connection error, timeout, 429, 5xx or rate limit response,
+	// waiting twice as long each time from Backoff, or as long as the
+	// response asks or its rate limit resets.
+	// Only idempotent requests whose body can be sent again are
+	// retried.
+	Retries int
+	Backoff time.Duration
+	// HostDelay is the time between requests to the same host, when
+	// longer than the host's Limit asks for.
+	HostDelay time.Duration
+	// UserAgent replaces the package UserAgent.
+	UserAgent string
+}
+
+// DefaultOptions are those of Default.
+var DefaultOptions = Options{
+	Timeout: 30 * time.Second,
+	Retries: 2,
+	Backoff: 2 * time.Second,
+}
+
+// Default is the client for the tools without needs of their own.
+var Default = New(DefaultOptions)
+
+// DownloadOptions are those of Download.
+var DownloadOptions = Options{
+	HeaderTimeout: 30 * time.Second,
+	Retries:       2,
+	Backoff:       2 * time.Second,
+}
+
+// Download is the client for downloading files, such as release archives,
+// that may take longer than the Default client's timeout on a slow link.
+var Download = New(DownloadOptions)
+
+// maxRetryWait is the longest a response may ask to wait before retrying;
+// asking for longer fails the request instead.
+const maxRetryWait = 5 * time.Minute
+
+// New returns a client with the options.
+func New(opts Options) *http.Client {
+	return &http.Client{Transport: NewTransport(http.DefaultTransport, opts)}
+}
+
+// NewTransport returns a round tripper making the requests through base
+// with the options, for clients that need more settings of their own.
+func NewTransport(base http.RoundTripper, opts Options) http.RoundTripper {
+	if opts.UserAgent == "" {
+		opts.UserAgent = UserAgent
+	}
+	return &transport{base: base, opts: opts}
+}
+
+type transport struct {
+	base http.RoundTripper
+	opts Options
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := t.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(req, attempt)
+		if attempt == t.opts.Retries || !retryable(resp, err) || !rewindable(req) {
+			return resp, err
+		}
+		wait := backoff
+		if resp != nil {
+			if w, ok := retryAfter(resp); ok {
+				if w > maxRetryWait {
+					return resp, nil
+				}
+				wait = w
+			}
+			resp.Body.Close()
+		}
+		backoff *= 2
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func (t *transport) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if t.opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.opts.Timeout)
+	}
+	if t.opts.HeaderTimeout > 0 {
+		var cancelAttempt context.CancelFunc
+		ctx, cancelAttempt = context.WithCancel(ctx)
+		parent := cancel
+		cancel = func() { cancelAttempt(); parent() }
+	}
+	r := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+	if r.Header.Get("User-Agent") == "" {
+		r.Header.Set("User-Agent", t.opts.UserAgent)
+	}
+
+	release, err := acquire(ctx, r.URL.Hostname(), t.opts.HostDelay)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The header timeout starts once the request may go, not while it
+	// waits its turn.
+	var headers *time.Timer
+	if t.opts.HeaderTimeout > 0 {
+		headers = time.AfterFunc(t.opts.HeaderTimeout, cancel)
+	}
+	resp, err := t.base.RoundTrip(r)
+	release()
+	if headers != nil && !headers.Stop() && err == nil {
+		// The timeout went off just as the headers came in.
+		resp.Body.Close()
+		err = ctx.Err()
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// TokenTransport authenticates the requests it makes through Base, or the
+// Default client's transport when nil, with a GitHub token.
+type TokenTransport struct {
+	Token string
+	Base  http.RoundTripper
+}
+
+func (t *TokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = Default.Transport
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.Token)
+	return base.RoundTrip(req)
+}
+
+// cancelBody ends the context of an attempt when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryable reports whether the failure may go away by itself.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 || rateLimited(resp)
+}
+
+// rateLimited reports whether the response is GitHub's refusal over a
+// rate limit: a 403 with no requests remaining until the reset, or with
+// a Retry-After header for the secondary limits.
+func rateLimited(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusForbidden &&
+		(resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != "")
+}
+
+// rewindable reports whether the request can be sent again: it's
+// idempotent, and has no body or one that can be had again.
+func rewindable(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryAfter returns how long the Retry-After header of the response asks
+// to wait, in seconds or until a date, or else until the rate limit reset
+// when no requests remain.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+			return 0, false
+		}
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return time.Until(time.Unix(reset, 0)), true
+	}
+	if s, err := strconv.Atoi(h); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
@@ -0,0 +1,69 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package platform
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Minimum is the oldest operating system versions a release runs on.
+type Minimum struct {
+	Windows string
+	MacOS   string
+	Linux   string
+}
+
+// goMinimum is the oldest operating system versions supported by the Go
+// releases from Minor on, until the next entry, as given in the Go
+// release notes.
+type goMinimum struct {
+	Minor int
+	Minimum
+}
+
+// goMinimums are in Go release order. Older Go releases aren't listed,
+// so the requirements of Syncthing releases built with them are unknown.
+var goMinimums = []goMinimum{
+	{10, Minimum{Windows: "XP", MacOS: "10.8", Linux: "2.6.23"}},
+	{11, Minimum{Windows: "7", MacOS: "10.10", Linux: "2.6.23"}},
+	{13, Minimum{Windows: "7", MacOS: "10.11", Linux: "2.6.23"}},
+	{15, Minimum{Windows: "7", MacOS: "10.12", Linux: "2.6.23"}},
+	{17, Minimum{Windows: "7", MacOS: "10.13", Linux: "2.6.23"}},
+	{18, Minimum{Windows: "7", MacOS: "10.13", Linux: "2.6.32"}},
+	{21, Minimum{Windows: "10", MacOS: "10.15", Linux: "2.6.32"}},
+	{23, Minimum{Windows: "10", MacOS: "11", Linux: "2.6.32"}},
+	{24, Minimum{Windows: "10", MacOS: "11", Linux: "3.2"}},
+	{25, Minimum{Windows: "10", MacOS: "12", Linux: "3.2"}},
+}
+
+var runtimeExp = regexp.MustCompile(`^go1\.(\d+)`)
+
+// GoMinor returns the minor version of a Go runtime version, such as 22
+// for go1.22.3.
+func GoMinor(runtime string) (int, bool) {
+	m := runtimeExp.FindStringSubmatch(runtime)
+	if m == nil {
+		return 0, false
+	}
+	minor, _ := strconv.Atoi(m[1])
+	return minor, true
+}
+
+// ForGo returns the requirements of the Go release with the given minor
+// version.
+func ForGo(minor int) (Minimum, bool) {
+	var res Minimum
+	ok := false
+	for _, m := range goMinimums {
+		if m.Minor > minor {
+			break
+		}
+		res, ok = m.Minimum, true
+	}
+	return res, ok
+}
@@ -0,0 +1,195 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package platform describes what the Syncthing releases run on: the
+// oldest operating system versions, which follow from the Go version a
+// release was built with and Go's requirements, corrected by the curated
+// overrides, and the platforms binaries were released for.
+package platform
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+)
+
+// The data files, relative to the _script directory.
+const (
+	OverridesFile = "../users/platform-overrides.csv"
+	PlatformsFile = "../users/release-platforms.csv"
+)
+
+// assetExp matches the names of binary release assets, like
+// syncthing-linux-amd64-v1.27.0.tar.gz.
+var assetExp = regexp.MustCompile(`^syncthing-([a-z0-9]+)-([a-z0-9_]+)-v\d`)
+
+// osNames are the operating systems in asset names as they're known.
+var osNames = map[string]string{
+	"darwin":    "macos",
+	"macosx":    "macos",
+	"dragonfly": "dragonflybsd",
+}
+
+// AssetPlatforms returns the os-arch platforms of the binary release
+// assets among the asset names, sorted.
+func AssetPlatforms(assets []string) []string {
+	seen := make(map[string]bool)
+	for _, a := range assets {
+		m := assetExp.FindStringSubmatch(a)
+		if m == nil || m[1] == "source" {
+			continue
+		}
+		goos := m[1]
+		if name, ok := osNames[goos]; ok {
+			goos = name
+		}
+		seen[goos+"-"+m[2]] = true
+	}
+	res := make([]string, 0, len(seen))
+	for p := range seen {
+		res = append(res, p)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Override is a row of the overrides file, setting the minimum version
+// of an operating system for a range of releases.
+type Override struct {
+	From, To relnotes.Version
+	OS       string
+	Minimum  string
+}
+
+// For returns the Go minor version the release was built with, zero if
+// unknown, and the oldest operating system versions it runs on.
+func For(v relnotes.Version, runtime string, overrides []Override) (int, Minimum) {
+	var min Minimum
+	minor, ok := GoMinor(runtime)
+	if ok {
+		min, _ = ForGo(minor)
+	}
+	for _, o := range overrides {
+		if v.Less(o.From) || o.To != (relnotes.Version{}) && o.To.Less(v) {
+			continue
+		}
+		switch o.OS {
+		case "windows":
+			min.Windows = o.Minimum
+		case "macos":
+			min.MacOS = o.Minimum
+		case "linux":
+			min.Linux = o.Minimum
+		}
+	}
+	return minor, min
+}
+
+// ReadOverrides reads the overrides file, where each row sets the
+// minimum version of windows, macos or linux for the releases from From
+// up to and including To, or all later ones if To is empty.
+func ReadOverrides(file string) ([]Override, error) {
+	records, err := readCSV(file)
+	if err != nil {
+		return nil, err
+	}
+	var res []Override
+	for i, rec := range records {
+		if i == 0 {
+			// Header
+			continue
+		}
+		if len(rec) < 4 {
+			return nil, fmt.Errorf("%s: row %d: expected From, To, OS and Minimum", file, i+1)
+		}
+		o := Override{OS: rec[2], Minimum: rec[3]}
+		var ok bool
+		if o.From, ok = relnotes.ParseVersion(rec[0]); !ok {
+			return nil, fmt.Errorf("%s: row %d: bad version %q", file, i+1, rec[0])
+		}
+		if rec[1] != "" {
+			if o.To, ok = relnotes.ParseVersion(rec[1]); !ok {
+				return nil, fmt.Errorf("%s: row %d: bad version %q", file, i+1, rec[1])
+			}
+		}
+		switch o.OS {
+		case "windows", "macos", "linux":
+		default:
+			return nil, fmt.Errorf("%s: row %d: unknown operating system %q", file, i+1, o.OS)
+		}
+		res = append(res, o)
+	}
+	return res, nil
+}
+
+// ReadPlatforms returns the os-arch platforms of the release assets of
+// each release in the platforms file.
+func ReadPlatforms(file string) (map[relnotes.Version][]string, error) {
+	res := make(map[relnotes.Version][]string)
+	records, err := readCSV(file)
+	if err != nil {
+		return res, err
+	}
+	for i, rec := range records {
+		if i == 0 {
+			// Header
+			continue
+		}
+		v, ok := relnotes.ParseVersion(rec[0])
+		if !ok || len(rec) != 2 {
+			return nil, fmt.Errorf("%s: row %d: expected a version and platforms", file, i+1)
+		}
+		res[v] = strings.Fields(rec[1])
+	}
+	return res, nil
+}
+
+// WritePlatforms writes the platforms file, newest releases first.
+func WritePlatforms(file string, platforms map[relnotes.Version][]string) error {
+	versions := make([]relnotes.Version, 0, len(platforms))
+	for v := range platforms {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(a, b int) bool { return versions[b].Less(versions[a]) })
+
+	fd, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(fd)
+	_ = cw.Write([]string{"Version", "Platforms"})
+	for _, v := range versions {
+		_ = cw.Write([]string{v.String(), strings.Join(platforms[v], " ")})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// readCSV reads a CSV file where lines starting with # are comments.
+func readCSV(file string) ([][]string, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	cr := csv.NewReader(fd)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return records, nil
+}
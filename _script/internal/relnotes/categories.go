@@ -0,0 +1,95 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package relnotes
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Category is the kind of change an item of the release notes is.
+type Category int
+
+const (
+	Breaking Category = iota
+	Feature
+	Fix
+	Other
+)
+
+// CategoryTitles are the section titles of the categories, in order.
+var CategoryTitles = []string{
+	Breaking: "Breaking Changes",
+	Feature:  "New Features",
+	Fix:      "Fixes",
+	Other:    "Other Changes",
+}
+
+// Item is a top level list item of the release notes.
+type Item struct {
+	Category Category
+	// Text is Markdown, as written.
+	Text string
+	// Refs are the issue and pull request numbers it mentions.
+	Refs []string
+}
+
+var (
+	noteHeadingExp = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	noteBulletExp  = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	refExp         = regexp.MustCompile(`#(\d+)\b|/(?:pull|issues)/(\d+)\b`)
+)
+
+// HeadingCategory sorts the headings of the release notes, such as
+// "Bugfixes", "Enhancements" or "Major changes in 1.27".
+func HeadingCategory(h string) Category {
+	h = strings.ToLower(h)
+	switch {
+	case strings.Contains(h, "breaking") || strings.Contains(h, "major") || strings.Contains(h, "compatib"):
+		return Breaking
+	case strings.Contains(h, "bug") || strings.Contains(h, "fix"):
+		return Fix
+	case strings.Contains(h, "enhancement") || strings.Contains(h, "feature") || strings.Contains(h, "new"):
+		return Feature
+	}
+	return Other
+}
+
+// Items returns the list items of the release notes, each in the
+// category of the heading above it, up to the boilerplate.
+func (r Release) Items() []Item {
+	cat := Other
+	var res []Item
+	for _, line := range strings.Split(strings.ReplaceAll(r.Notes, "\r\n", "\n"), "\n") {
+		nested := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		line = strings.TrimSpace(line)
+		for _, b := range Boilerplate {
+			if strings.HasPrefix(line, b) {
+				return res
+			}
+		}
+		if m := noteHeadingExp.FindStringSubmatch(line); m != nil {
+			cat = HeadingCategory(m[1])
+			continue
+		}
+		// Only top level items; nested ones are details of them.
+		if m := noteBulletExp.FindStringSubmatch(line); m != nil && !nested {
+			res = append(res, Item{Category: cat, Text: m[1], Refs: Refs(m[1])})
+		}
+	}
+	return res
+}
+
+// Refs returns the issue and pull request numbers the text mentions, by
+// number or URL.
+func Refs(s string) []string {
+	var res []string
+	for _, m := range refExp.FindAllStringSubmatch(s, -1) {
+		res = append(res, m[1]+m[2])
+	}
+	return res
+}
@@ -0,0 +1,247 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package relnotes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+// Boilerplate are the starts of lines from which on the notes are the
+// same for every release, such as the list of other places to get it
+// from, and are left out.
+var Boilerplate = []string{
+	"This release is also available as",
+	"**Full Changelog**",
+}
+
+var (
+	headingExp  = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*$`)
+	bulletExp   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	fenceExp    = regexp.MustCompile("^\\s*(```|~~~)")
+	commentExp  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	codeExp     = regexp.MustCompile("`+([^`]+?)`+")
+	mdLinkExp   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	urlExp      = regexp.MustCompile(`https?://[^\s<>()]*[^\s<>().,;:!?'"]`)
+	issueExp    = regexp.MustCompile(`(^|[^\w&/])#(\d+)\b`)
+	mentionExp  = regexp.MustCompile(`(^|[^\w/@.])@([A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?(?:\[bot\])?)`)
+	boldExp     = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	issueURLExp = regexp.MustCompile(`^https://github\.com/([\w.-]+/[\w.-]+)/(?:pull|issues)/(\d+)$`)
+)
+
+// Converter converts release notes of a repository to reStructuredText.
+type Converter struct {
+	// Repo is the repository ("owner/name") that bare issue numbers
+	// refer to.
+	Repo string
+}
+
+// level is an enclosing list item, with the indentation of its content
+// in the source and the output.
+type level struct {
+	src, out int
+}
+
+// Convert returns the Markdown notes as reStructuredText. Headings become
+// rubrics, so the result fits under any section level. Issue and pull
+// request numbers and URLs become "#1234" links, and @mentions links to
+// the user's profile.
+func (c Converter) Convert(md string) string {
+	md = commentExp.ReplaceAllString(strings.ReplaceAll(md, "\r\n", "\n"), "")
+	var out []string
+	prevBlank := func() bool {
+		return len(out) == 0 || out[len(out)-1] == ""
+	}
+	blank := func() {
+		if !prevBlank() {
+			out = append(out, "")
+		}
+	}
+	var levels []level
+	indent := func() string {
+		if len(levels) == 0 {
+			return ""
+		}
+		return strings.Repeat(" ", levels[len(levels)-1].out)
+	}
+	// pop leaves the list items the indentation is outside of, returning
+	// how many.
+	pop := func(ind int) int {
+		n := len(levels)
+		for len(levels) > 0 && ind < levels[len(levels)-1].src {
+			levels = levels[:len(levels)-1]
+		}
+		return n - len(levels)
+	}
+	inCode := false
+
+lines:
+	for _, line := range strings.Split(md, "\n") {
+		if fenceExp.MatchString(line) {
+			if !inCode {
+				blank()
+				out = append(out, indent()+"::", "")
+			} else {
+				out = append(out, "")
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out = append(out, indent()+"   "+strings.TrimRight(line, " \t"))
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		for _, b := range Boilerplate {
+			if strings.HasPrefix(trimmed, b) {
+				break lines
+			}
+		}
+		if trimmed == "" {
+			blank()
+			continue
+		}
+		ind := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		if m := headingExp.FindStringSubmatch(trimmed); m != nil {
+			levels = nil
+			blank()
+			out = append(out, ".. rubric:: "+c.Inline(m[1]), "")
+			continue
+		}
+		if m := bulletExp.FindStringSubmatch(line); m != nil {
+			// Lists, and nested lists, are separated from the text
+			// around them by blank lines; items of the same list
+			// aren't.
+			n := len(levels)
+			if popped := pop(ind); n == 0 || popped != 1 {
+				blank()
+			}
+			prefix := indent()
+			out = append(out, prefix+"- "+c.Inline(m[2]))
+			levels = append(levels, level{src: len(m[1]) + 2, out: len(prefix) + 2})
+			continue
+		}
+		// Without a blank line before it, the text continues the
+		// paragraph, as in Markdown.
+		if prevBlank() {
+			pop(ind)
+		}
+		out = append(out, indent()+c.Inline(trimmed))
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// Inline converts the inline markup of a line of text, escaping the rest.
+func (c Converter) Inline(s string) string {
+	var parts []string
+	hold := func(markup string) string {
+		parts = append(parts, markup)
+		return fmt.Sprintf("\x00%d\x00", len(parts)-1)
+	}
+
+	s = codeExp.ReplaceAllStringFunc(s, func(m string) string {
+		return hold(rst.Literal(strings.TrimSpace(codeExp.FindStringSubmatch(m)[1])))
+	})
+	s = mdLinkExp.ReplaceAllStringFunc(s, func(m string) string {
+		sm := mdLinkExp.FindStringSubmatch(m)
+		return hold(rst.Link(sm[1], sm[2]))
+	})
+	s = urlExp.ReplaceAllStringFunc(s, func(url string) string {
+		if m := issueURLExp.FindStringSubmatch(url); m != nil {
+			text := "#" + m[2]
+			if m[1] != c.Repo {
+				text = m[1] + text
+			}
+			return hold(rst.Link(text, url))
+		}
+		return hold(rst.Link("", url))
+	})
+	s = issueExp.ReplaceAllStringFunc(s, func(m string) string {
+		sm := issueExp.FindStringSubmatch(m)
+		return sm[1] + hold(rst.Link("#"+sm[2], fmt.Sprintf("https://github.com/%s/issues/%s", c.Repo, sm[2])))
+	})
+	s = mentionExp.ReplaceAllStringFunc(s, func(m string) string {
+		sm := mentionExp.FindStringSubmatch(m)
+		user := strings.TrimSuffix(sm[2], "[bot]")
+		return sm[1] + hold(rst.Link("@"+sm[2], "https://github.com/"+user))
+	})
+	s = boldExp.ReplaceAllStringFunc(s, func(m string) string {
+		return hold("**" + rst.Escape(boldExp.FindStringSubmatch(m)[1]) + "**")
+	})
+
+	s = rst.Escape(s)
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(s, 0)
+		if start < 0 {
+			sb.WriteString(s)
+			break
+		}
+		end := start + 1 + strings.IndexByte(s[start+1:], 0)
+		var idx int
+		fmt.Sscan(s[start+1:end], &idx)
+		before, after := s[:start], s[end+1:]
+		sb.WriteString(before)
+		// Inline markup must be delimited by whitespace or punctuation.
+		if before != "" && isWordByte(before[len(before)-1]) {
+			sb.WriteString(`\ `)
+		}
+		sb.WriteString(parts[idx])
+		if after != "" && isWordByte(after[0]) {
+			sb.WriteString(`\ `)
+		}
+		s = after
+	}
+	return sb.String()
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+var heldExp = regexp.MustCompile("\x00(\\d+)\x00")
+
+// MarkdownLinks returns the Markdown with bare issue and pull request
+// numbers and @mentions made into links, for posting it where they
+// aren't linked automatically, such as on the forum, where @mentions
+// would refer to forum users instead.
+func (c Converter) MarkdownLinks(md string) string {
+	var parts []string
+	hold := func(markup string) string {
+		parts = append(parts, markup)
+		return fmt.Sprintf("\x00%d\x00", len(parts)-1)
+	}
+
+	md = codeExp.ReplaceAllStringFunc(md, hold)
+	md = mdLinkExp.ReplaceAllStringFunc(md, hold)
+	md = urlExp.ReplaceAllStringFunc(md, hold)
+	md = issueExp.ReplaceAllStringFunc(md, func(m string) string {
+		sm := issueExp.FindStringSubmatch(m)
+		return sm[1] + hold(fmt.Sprintf("[#%s](https://github.com/%s/issues/%s)", sm[2], c.Repo, sm[2]))
+	})
+	md = mentionExp.ReplaceAllStringFunc(md, func(m string) string {
+		sm := mentionExp.FindStringSubmatch(m)
+		user := strings.TrimSuffix(sm[2], "[bot]")
+		return sm[1] + hold(fmt.Sprintf("[@%s](https://github.com/%s)", sm[2], user))
+	})
+
+	return heldExp.ReplaceAllStringFunc(md, func(m string) string {
+		var idx int
+		fmt.Sscan(heldExp.FindStringSubmatch(m)[1], &idx)
+		return parts[idx]
+	})
+}
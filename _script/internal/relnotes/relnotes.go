@@ -0,0 +1,128 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package relnotes lists the GitHub releases of a repository and converts
+// their Markdown release notes to reStructuredText.
+package relnotes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// Release is a published, non-prerelease release with a version tag.
+type Release struct {
+	Tag       string
+	Version   Version
+	Published time.Time
+	URL       string
+	// Notes are the release notes as written, in Markdown.
+	Notes string
+	// Assets are the file names of the release assets.
+	Assets []string
+}
+
+// Version is a parsed vX.Y.Z tag.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+var versionExp = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)$`)
+
+// ParseVersion parses a vX.Y.Z tag. Prerelease tags such as v1.27.0-rc.1
+// aren't versions.
+func ParseVersion(tag string) (Version, bool) {
+	m := versionExp.FindStringSubmatch(tag)
+	if m == nil {
+		return Version{}, false
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, true
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Series returns the minor release series, such as "v1.27".
+func (v Version) Series() string {
+	return fmt.Sprintf("v%d.%d", v.Major, v.Minor)
+}
+
+// Less reports whether v is older than o.
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// Client returns a GitHub client, authenticated with GITHUB_TOKEN if it's
+// set to get the higher rate limit.
+func Client() *github.Client {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return github.NewClient(httpclient.Default)
+	}
+	return github.NewClient(&http.Client{Transport: &httpclient.TokenTransport{Token: token}})
+}
+
+// List returns the releases of the repository ("owner/name"), newest
+// first.
+func List(ctx context.Context, client *github.Client, repository string) ([]Release, error) {
+	owner, repo, ok := strings.Cut(repository, "/")
+	if !ok {
+		return nil, fmt.Errorf("repository %q is not owner/name", repository)
+	}
+	opts := &github.ListOptions{PerPage: 100}
+	var res []Release
+	for {
+		rels, resp, err := client.Repositories.ListReleases(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range rels {
+			v, ok := ParseVersion(rel.GetTagName())
+			if !ok || rel.GetPrerelease() || rel.GetDraft() {
+				continue
+			}
+			var assets []string
+			for _, a := range rel.Assets {
+				assets = append(assets, a.GetName())
+			}
+			res = append(res, Release{
+				Tag:       rel.GetTagName(),
+				Version:   v,
+				Published: rel.GetPublishedAt().Time,
+				URL:       rel.GetHTMLURL(),
+				Notes:     rel.GetBody(),
+				Assets:    assets,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.Slice(res, func(a, b int) bool { return res[b].Version.Less(res[a].Version) })
+	return res, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package rst contains helpers for generating reStructuredText for
+// inclusion in the docs.
+package rst
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Heading returns a section title underlined with the given character,
+// followed by a blank line.
+func Heading(title string, underline rune) string {
+	under := strings.Repeat(string(underline), utf8.RuneCountInString(title))
+	return fmt.Sprintf("%s\n%s\n\n", title, under)
+}
+
+// Link returns an anonymous hyperlink reference.
+func Link(text, url string) string {
+	if text == "" {
+		return fmt.Sprintf("`<%s>`__", url)
+	}
+	return fmt.Sprintf("`%s <%s>`__", Escape(text), url)
+}
+
+// Literal returns s as inline literal text.
+func Literal(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "``" + s + "``"
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"`", "\\`",
+	"|", `\|`,
+	"_", `\_`,
+)
+
+// Escape escapes the characters that would otherwise be interpreted as
+// inline markup.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// Table is a table rendered as a list-table directive. Cells are written
+// as is, so they may contain inline markup; use Escape for plain text.
+type Table struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+	// Widths optionally sets the relative column widths.
+	Widths []int
+	// Class optionally sets the class option, for styling.
+	Class string
+}
+
+// WriteTo writes the table followed by a blank line.
+func (t Table) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	sb.WriteString(".. list-table::")
+	if t.Title != "" {
+		sb.WriteString(" " + t.Title)
+	}
+	sb.WriteString("\n")
+	if len(t.Header) > 0 {
+		sb.WriteString("   :header-rows: 1\n")
+	}
+	if len(t.Widths) > 0 {
+		ws := make([]string, len(t.Widths))
+		for i, w := range t.Widths {
+			ws[i] = fmt.Sprint(w)
+		}
+		fmt.Fprintf(&sb, "   :widths: %s\n", strings.Join(ws, " "))
+	}
+	if t.Class != "" {
+		fmt.Fprintf(&sb, "   :class: %s\n", t.Class)
+	}
+	sb.WriteString("\n")
+	rows := t.Rows
+	if len(t.Header) > 0 {
+		rows = append([][]string{t.Header}, rows...)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			prefix := "     - "
+			if i == 0 {
+				prefix = "   * - "
+			}
+			cell = strings.ReplaceAll(cell, "\n", "\n       ")
+			if cell == "" {
+				prefix = strings.TrimRight(prefix, " ")
+			}
+			sb.WriteString(prefix + cell + "\n")
+		}
+	}
+	sb.WriteString("\n")
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
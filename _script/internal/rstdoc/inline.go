@@ -0,0 +1,101 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rstdoc
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	literalExp   = regexp.MustCompile("(?s)``.+?``")
+	roleExp      = regexp.MustCompile("(?s):([a-zA-Z0-9_.+-]+(?::[a-zA-Z0-9_.+-]+)?):`([^`]+)`")
+	linkExp      = regexp.MustCompile("(?s)`([^`]+)`(__?)")
+	embeddedExp  = regexp.MustCompile(`(?s)^(.*?)\s*<([^<>]+)>$`)
+	simpleRefExp = regexp.MustCompile(`(?:^|[\s(\["'])([A-Za-z0-9]+(?:[-_.+][A-Za-z0-9]+)*)(__?)`)
+)
+
+// inline records the references in a paragraph starting at the given
+// line.
+func (s *scanner) inline(file string, line int, text string) {
+	pos := func(offset int) Pos {
+		return Pos{file, line + strings.Count(text[:offset], "\n")}
+	}
+	buf := []byte(text)
+	blank := func(start, end int) {
+		for i := start; i < end; i++ {
+			if buf[i] != '\n' {
+				buf[i] = ' '
+			}
+		}
+	}
+
+	for _, m := range literalExp.FindAllStringIndex(text, -1) {
+		blank(m[0], m[1])
+	}
+	for _, m := range roleExp.FindAllSubmatchIndex(buf, -1) {
+		r := &Ref{
+			Role: string(buf[m[2]:m[3]]),
+			Pos:  pos(m[0]),
+		}
+		r.Text, r.Target = splitEmbedded(string(buf[m[4]:m[5]]))
+		s.doc.Refs = append(s.doc.Refs, r)
+		blank(m[0], m[1])
+	}
+	for _, m := range linkExp.FindAllSubmatchIndex(buf, -1) {
+		r := &Ref{
+			Anonymous: m[5]-m[4] == 2,
+			Pos:       pos(m[0]),
+		}
+		content := string(buf[m[2]:m[3]])
+		r.Text, r.Target = splitEmbedded(content)
+		switch {
+		case r.Text == "" && r.Target == content:
+			r.Named = true
+			r.Target = NormalizeName(content)
+		case strings.HasSuffix(r.Target, "_"):
+			r.Named = true
+			r.Target = NormalizeName(strings.TrimSuffix(r.Target, "_"))
+		default:
+			r.Target = strings.Join(strings.Fields(r.Target), "")
+			if !r.Anonymous && r.Text != "" {
+				s.doc.Targets = append(s.doc.Targets, &Target{
+					Name:   NormalizeName(r.Text),
+					URL:    r.Target,
+					Inline: true,
+					Pos:    r.Pos,
+				})
+			}
+		}
+		s.doc.Refs = append(s.doc.Refs, r)
+		blank(m[0], m[1])
+	}
+	for _, m := range simpleRefExp.FindAllSubmatchIndex(buf, -1) {
+		if m[5] < len(buf) && isNameChar(buf[m[5]]) {
+			continue
+		}
+		s.doc.Refs = append(s.doc.Refs, &Ref{
+			Target:    NormalizeName(string(buf[m[2]:m[3]])),
+			Named:     true,
+			Anonymous: m[5]-m[4] == 2,
+			Pos:       pos(m[2]),
+		})
+	}
+}
+
+// splitEmbedded splits "title <target>" into its parts. Without an
+// embedded target, the whole text is the target.
+func splitEmbedded(s string) (string, string) {
+	if m := embeddedExp.FindStringSubmatch(s); m != nil {
+		return strings.Join(strings.Fields(m[1]), " "), m[2]
+	}
+	return "", strings.TrimSpace(s)
+}
+
+func isNameChar(b byte) bool {
+	return b == '_' || b == '/' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
@@ -0,0 +1,108 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rstdoc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// displayedRoles show their target as the text, so it's prose.
+var displayedRoles = map[string]bool{
+	"term":          true,
+	"abbr":          true,
+	"guilabel":      true,
+	"menuselection": true,
+	"emphasis":      true,
+	"strong":        true,
+}
+
+var (
+	urlExp          = regexp.MustCompile(`\b(?:https?|ftp|mailto):[^\s<>]+`)
+	substitutionExp = regexp.MustCompile(`\|[^|\s][^|]*\|_{0,2}`)
+	footnoteRefExp  = regexp.MustCompile(`\[(?:#[\w-]*|\*|\d+|[\w-]+)\]_`)
+)
+
+// prose returns the text with the markup and the parts that aren't
+// prose, such as literals, role targets and URLs, replaced by spaces.
+// Offsets and line breaks stay the same, so positions in it are
+// positions in the source.
+func prose(text string) string {
+	buf := []byte(text)
+	blank := func(start, end int) {
+		for i := start; i < end; i++ {
+			if buf[i] != '\n' {
+				buf[i] = ' '
+			}
+		}
+	}
+	// keep blanks the span except for the shown text inside it.
+	keep := func(start, end, showStart, showEnd int) {
+		blank(start, showStart)
+		blank(showEnd, end)
+	}
+
+	for _, m := range literalExp.FindAllStringIndex(text, -1) {
+		blank(m[0], m[1])
+	}
+	for _, m := range roleExp.FindAllSubmatchIndex(buf, -1) {
+		role := string(buf[m[2]:m[3]])
+		if title, ok := embeddedTitle(buf, m[4], m[5]); ok {
+			keep(m[0], m[1], title[0], title[1])
+		} else if displayedRoles[role] {
+			keep(m[0], m[1], m[4], m[5])
+		} else {
+			blank(m[0], m[1])
+		}
+	}
+	for _, m := range linkExp.FindAllSubmatchIndex(buf, -1) {
+		if title, ok := embeddedTitle(buf, m[2], m[3]); ok {
+			keep(m[0], m[1], title[0], title[1])
+		} else if embeddedExp.Match(buf[m[2]:m[3]]) {
+			blank(m[0], m[1])
+		} else {
+			keep(m[0], m[1], m[2], m[3])
+		}
+	}
+	for _, exp := range []*regexp.Regexp{urlExp, substitutionExp, footnoteRefExp} {
+		for _, m := range exp.FindAllIndex(buf, -1) {
+			blank(m[0], m[1])
+		}
+	}
+	// The remaining markup: emphasis, strong and hyperlink reference
+	// suffixes.
+	for i, b := range buf {
+		switch b {
+		case '*', '`':
+			buf[i] = ' '
+		case '_':
+			if i+1 == len(buf) || !isNameChar(buf[i+1]) {
+				buf[i] = ' '
+			}
+		}
+	}
+	return string(buf)
+}
+
+// embeddedTitle returns the span of the title in "title <target>" within
+// buf[start:end], if there is one.
+func embeddedTitle(buf []byte, start, end int) ([2]int, bool) {
+	m := embeddedExp.FindSubmatchIndex(buf[start:end])
+	if m == nil || m[3] == m[2] {
+		return [2]int{}, false
+	}
+	return [2]int{start + m[2], start + m[3]}, true
+}
+
+// newText returns a Text of the lines starting at the given line.
+func newText(file string, line int, lines []string) *Text {
+	return &Text{
+		Lines: lines,
+		Prose: strings.Split(prose(strings.Join(lines, "\n")), "\n"),
+		Pos:   Pos{file, line},
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package rstdoc reads the documentation sources, finding the sections,
+// targets, references and directives of each document. It's not a full
+// reStructuredText parser, but knows enough of the syntax to tell text
+// from literal blocks and comments.
+package rstdoc
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Pos is a position in a source file, relative to the docs root.
+type Pos struct {
+	File string
+	Line int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
+}
+
+// Doc is a document, including the files it includes.
+type Doc struct {
+	// Name is the document name as used by :doc: and toctrees, e.g.
+	// "users/config".
+	Name string
+	File string
+	// Meta are the fields of the field list at the start of the
+	// document, such as orphan.
+	Meta       map[string]string
+	Sections   []*Section
+	Targets    []*Target
+	Refs       []*Ref
+	Directives []*Directive
+	CodeBlocks []*CodeBlock
+	// Texts are the paragraphs and section titles, in order.
+	Texts []*Text
+}
+
+// Dir returns the directory of the document, relative to the root.
+func (d *Doc) Dir() string {
+	return path.Dir(d.Name)
+}
+
+// Section is a section title.
+type Section struct {
+	Title string
+	// Level is the nesting level, starting at one for the document
+	// title.
+	Level int
+	// Labels are the internal targets directly preceding the section.
+	Labels []string
+	Pos    Pos
+}
+
+// ID returns the HTML anchor of the section.
+func (s *Section) ID() string {
+	return MakeID(s.Title)
+}
+
+// Target is a hyperlink target. Internal targets, or labels, have no URL.
+type Target struct {
+	Name string
+	URL  string
+	// Anonymous targets have no name.
+	Anonymous bool
+	// Section is the section an internal target precedes, if any.
+	Section *Section
+	// Inline targets are defined by a reference with an embedded URL.
+	Inline bool
+	Pos    Pos
+}
+
+// Ref is a reference: an interpreted text role such as :doc:, or a
+// hyperlink reference.
+type Ref struct {
+	// Role is the role name, or empty for hyperlink references.
+	Role string
+	// Text is the explicit title of the reference, if any.
+	Text string
+	// Target is the role target, the embedded URL of a hyperlink
+	// reference, or the name of the target it refers to.
+	Target string
+	// Named is set for hyperlink references to a named target rather
+	// than an embedded URL.
+	Named     bool
+	Anonymous bool
+	Pos       Pos
+}
+
+// Directive is a directive, including substitution definitions.
+type Directive struct {
+	Name    string
+	Arg     string
+	Options map[string]string
+	Content []string
+	// ContentLine is the line the content starts at.
+	ContentLine int
+	Pos         Pos
+}
+
+// CodeBlock is a literal block or the content of a code-block directive.
+type CodeBlock struct {
+	// Lang is the language of a code-block, or for literal blocks the
+	// one set by the last highlight directive. It's empty if unknown.
+	Lang    string
+	Content []string
+	// Pos is the position of the first line of the content.
+	Pos Pos
+}
+
+// Text is a paragraph or section title.
+type Text struct {
+	// Lines are the source lines, including their indentation.
+	Lines []string
+	// Prose are the lines with the markup and everything that isn't
+	// prose, such as literals, role targets and URLs, replaced by
+	// spaces, so a column in them is the column in the source.
+	Prose []string
+	// Pos is the position of the first line.
+	Pos Pos
+}
+
+// Tree is the set of documents in the docs.
+type Tree struct {
+	Root   string
+	Docs   []*Doc
+	byName map[string]*Doc
+	// Included are the files included by documents, relative to the
+	// root.
+	Included map[string]bool
+	exclude  []string
+}
+
+// Doc returns the named document, or nil.
+func (t *Tree) Doc(name string) *Doc {
+	return t.byName[name]
+}
+
+// Load reads the documents under root, skipping the excluded paths and
+// the files included by other documents.
+func Load(root string) (*Tree, error) {
+	exclude, err := excludePatterns(filepath.Join(root, "conf.py"))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tree{
+		Root:     root,
+		byName:   make(map[string]*Doc),
+		Included: make(map[string]bool),
+		exclude:  exclude,
+	}
+	var files []string
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && t.Excluded(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(rel, ".rst") && !t.Excluded(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var docs []*Doc
+	for _, f := range files {
+		d, err := t.parse(f)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	for _, d := range docs {
+		if t.Included[d.File] {
+			continue
+		}
+		t.Docs = append(t.Docs, d)
+		t.byName[d.Name] = d
+	}
+	return t, nil
+}
+
+func (t *Tree) parse(file string) (*Doc, error) {
+	d := &Doc{
+		Name: strings.TrimSuffix(file, ".rst"),
+		File: file,
+		Meta: make(map[string]string),
+	}
+	s := &scanner{tree: t, doc: d, seen: map[string]bool{file: true}}
+	if err := s.file(file); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+var excludeExp = regexp.MustCompile(`(?s)exclude_patterns\s*=\s*\[(.*?)\]`)
+var quotedExp = regexp.MustCompile(`'([^']*)'|"([^"]*)"`)
+
+// excludePatterns returns the exclude_patterns set in conf.py.
+func excludePatterns(conf string) ([]string, error) {
+	bs, err := os.ReadFile(conf)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	m := excludeExp.FindSubmatch(bs)
+	if m == nil {
+		return nil, nil
+	}
+	var pats []string
+	for _, q := range quotedExp.FindAllSubmatch(m[1], -1) {
+		pats = append(pats, string(q[1])+string(q[2]))
+	}
+	return pats, nil
+}
+
+// Excluded reports whether a path relative to the root is outside the
+// documentation sources: in a directory starting with an underscore or
+// dot, or matching the exclude_patterns.
+func (t *Tree) Excluded(rel string) bool {
+	for p := rel; p != "." && p != "/"; p = path.Dir(p) {
+		if name := path.Base(p); strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+			return true
+		}
+		for _, pat := range t.exclude {
+			if ok, _ := path.Match(pat, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var nonIDExp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// MakeID returns the HTML id docutils makes from a section title or
+// target name.
+func MakeID(s string) string {
+	id := nonIDExp.ReplaceAllString(strings.ToLower(s), "-")
+	return strings.Trim(strings.TrimLeft(id, "-0123456789"), "-")
+}
+
+// NormalizeName returns a reference or target name as docutils compares
+// them: lower case, with whitespace collapsed.
+func NormalizeName(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,337 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rstdoc
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// literalDirectives have content that isn't reStructuredText, and
+// isn't scanned for references.
+var literalDirectives = map[string]bool{
+	"code":           true,
+	"code-block":     true,
+	"sourcecode":     true,
+	"literalinclude": true,
+	"graphviz":       true,
+	"digraph":        true,
+	"graph":          true,
+	"raw":            true,
+	"math":           true,
+	"toctree":        true,
+}
+
+var (
+	directiveExp = regexp.MustCompile(`^\s*\.\.\s+(?:\|[^|]+\|\s+)?([a-zA-Z0-9_:-]+)::(?:\s+(.*))?$`)
+	targetExp    = regexp.MustCompile("^\\s*\\.\\.\\s+_(`[^`]+`|[^:`]+|_):\\s*(.*)$")
+	optionExp    = regexp.MustCompile(`^:([a-zA-Z0-9_-]+):(?:\s+(.*))?$`)
+)
+
+type scanner struct {
+	tree *Tree
+	doc  *Doc
+	seen map[string]bool
+	// styles are the section adornments in order of appearance.
+	styles  []string
+	pending []*Target
+	// highlight is the language of literal blocks.
+	highlight string
+}
+
+func (s *scanner) file(file string) error {
+	bs, err := os.ReadFile(filepath.Join(s.tree.Root, filepath.FromSlash(file)))
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.ReplaceAll(string(bs), "\r\n", "\n"), "\n")
+	return s.lines(file, lines)
+}
+
+func (s *scanner) lines(file string, lines []string) error {
+	var para []string
+	paraLine, paraIndent := 0, 0
+	flush := func() bool {
+		if len(para) == 0 {
+			return false
+		}
+		s.attach(nil)
+		s.inline(file, paraLine, strings.Join(para, "\n"))
+		s.doc.Texts = append(s.doc.Texts, newText(file, paraLine, para))
+		literal := strings.HasSuffix(strings.TrimSpace(para[len(para)-1]), "::")
+		para = nil
+		return literal
+	}
+
+	i := 0
+	if file == s.doc.File {
+		i = s.meta(lines)
+	}
+	for i < len(lines) {
+		l := lines[i]
+		trimmed := strings.TrimSpace(l)
+		ind := indent(l)
+
+		switch {
+		case trimmed == "":
+			if flush() {
+				end := blockEnd(lines, i+1, paraIndent)
+				if first, content := dedented(lines, i+1, end); content != nil {
+					s.doc.CodeBlocks = append(s.doc.CodeBlocks, &CodeBlock{
+						Lang:    s.highlight,
+						Content: content,
+						Pos:     Pos{file, first + 1},
+					})
+				}
+				i = end
+				continue
+			}
+			i++
+
+		case len(para) == 0 && ind == 0 && i+1 < len(lines) && !isAdornment(l) && isAdornment(lines[i+1]) &&
+			utf8.RuneCountInString(strings.TrimSpace(lines[i+1])) >= utf8.RuneCountInString(trimmed):
+			s.section(file, i+1, trimmed, strings.TrimSpace(lines[i+1])[:1])
+			s.doc.Texts = append(s.doc.Texts, newText(file, i+1, lines[i:i+1]))
+			i += 2
+
+		case len(para) == 0 && isAdornment(l) && i+2 < len(lines) && isAdornment(lines[i+2]) && strings.TrimSpace(lines[i+1]) != "":
+			s.section(file, i+2, strings.TrimSpace(lines[i+1]), "o"+trimmed[:1])
+			s.doc.Texts = append(s.doc.Texts, newText(file, i+2, lines[i+1:i+2]))
+			i += 3
+
+		case len(para) == 0 && isAdornment(l):
+			// A transition.
+			i++
+
+		case strings.HasPrefix(trimmed, "..") && (trimmed == ".." || strings.HasPrefix(trimmed, ".. ")):
+			flush()
+			end := blockEnd(lines, i+1, ind)
+			if m := targetExp.FindStringSubmatch(l); m != nil {
+				s.target(file, i+1, m[1], m[2], lines[i+1:end])
+				i = end
+				continue
+			}
+			m := directiveExp.FindStringSubmatch(l)
+			if m == nil {
+				// A comment, footnote or citation.
+				i = end
+				continue
+			}
+			next, err := s.directive(file, lines, i, end, m[1], strings.TrimSpace(m[2]))
+			if err != nil {
+				return err
+			}
+			i = next
+
+		default:
+			if len(para) > 0 && ind != paraIndent {
+				flush()
+			}
+			if len(para) == 0 {
+				paraLine, paraIndent = i+1, ind
+			}
+			para = append(para, l)
+			i++
+		}
+	}
+	flush()
+	return nil
+}
+
+// meta records the field list at the start of the document, returning
+// the line after it.
+func (s *scanner) meta(lines []string) int {
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	for ; i < len(lines); i++ {
+		m := optionExp.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		s.doc.Meta[m[1]] = strings.TrimSpace(m[2])
+	}
+	return i
+}
+
+func (s *scanner) section(file string, line int, title, style string) {
+	level := 0
+	for i, st := range s.styles {
+		if st == style {
+			level = i + 1
+		}
+	}
+	if level == 0 {
+		s.styles = append(s.styles, style)
+		level = len(s.styles)
+	}
+	sec := &Section{
+		Title: title,
+		Level: level,
+		Pos:   Pos{file, line},
+	}
+	s.doc.Sections = append(s.doc.Sections, sec)
+	s.attach(sec)
+}
+
+// attach lets the pending internal targets refer to the next element,
+// the given section or something else.
+func (s *scanner) attach(sec *Section) {
+	for _, t := range s.pending {
+		t.Section = sec
+		if sec != nil {
+			sec.Labels = append(sec.Labels, t.Name)
+		}
+	}
+	s.pending = nil
+}
+
+func (s *scanner) target(file string, line int, name, url string, rest []string) {
+	t := &Target{Pos: Pos{file, line}}
+	if name == "_" {
+		t.Anonymous = true
+	} else {
+		t.Name = strings.Trim(name, "`")
+	}
+	for _, l := range rest {
+		url += strings.TrimSpace(l)
+	}
+	t.URL = url
+	s.doc.Targets = append(s.doc.Targets, t)
+	if url == "" && !t.Anonymous {
+		s.pending = append(s.pending, t)
+	}
+}
+
+// directive records the directive starting at lines[i] with its block
+// ending at end, and returns the line to continue scanning at.
+func (s *scanner) directive(file string, lines []string, i, end int, name, arg string) (int, error) {
+	s.attach(nil)
+	d := &Directive{
+		Name:    name,
+		Arg:     arg,
+		Options: make(map[string]string),
+		Pos:     Pos{file, i + 1},
+	}
+	k := i + 1
+	for ; k < end; k++ {
+		m := optionExp.FindStringSubmatch(strings.TrimSpace(lines[k]))
+		if m == nil {
+			break
+		}
+		d.Options[m[1]] = strings.TrimSpace(m[2])
+	}
+	if first, content := dedented(lines, k, end); content != nil {
+		d.ContentLine = first + 1
+		d.Content = content
+	}
+	s.doc.Directives = append(s.doc.Directives, d)
+
+	switch name {
+	case "highlight":
+		s.highlight = arg
+	case "code-block", "code", "sourcecode":
+		if d.Content != nil {
+			s.doc.CodeBlocks = append(s.doc.CodeBlocks, &CodeBlock{
+				Lang:    arg,
+				Content: d.Content,
+				Pos:     Pos{file, d.ContentLine},
+			})
+		}
+	}
+
+	if name == "include" && arg != "" {
+		inc := IncludePath(file, arg)
+		s.tree.Included[inc] = true
+		if !s.seen[inc] {
+			if _, err := os.Stat(filepath.Join(s.tree.Root, filepath.FromSlash(inc))); err == nil {
+				s.seen[inc] = true
+				err := s.file(inc)
+				delete(s.seen, inc)
+				if err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	if literalDirectives[name] || name == "include" {
+		return end, nil
+	}
+	// The content of other directives is scanned as text.
+	return k, nil
+}
+
+// dedented returns the index of the first non-blank line of lines[i:end]
+// and the lines from there, without their common indentation or trailing
+// blank lines. The lines are nil if all are blank.
+func dedented(lines []string, i, end int) (int, []string) {
+	for i < end && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i == end {
+		return i, nil
+	}
+	minIndent := -1
+	for _, l := range lines[i:end] {
+		if strings.TrimSpace(l) != "" && (minIndent < 0 || indent(l) < minIndent) {
+			minIndent = indent(l)
+		}
+	}
+	var res []string
+	for _, l := range lines[i:end] {
+		if len(l) >= minIndent {
+			l = l[minIndent:]
+		}
+		res = append(res, strings.TrimRight(l, " \t"))
+	}
+	for len(res) > 0 && res[len(res)-1] == "" {
+		res = res[:len(res)-1]
+	}
+	return i, res
+}
+
+// IncludePath returns the file an include directive in the given file
+// refers to, relative to the root.
+func IncludePath(file, arg string) string {
+	if strings.HasPrefix(arg, "/") {
+		return strings.TrimPrefix(path.Clean(arg), "/")
+	}
+	return path.Join(path.Dir(file), arg)
+}
+
+// blockEnd returns the index of the first line from i on that is indented
+// no more than ind, skipping blank lines.
+func blockEnd(lines []string, i, ind int) int {
+	end := i
+	for j := i; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == "" {
+			continue
+		}
+		if indent(lines[j]) <= ind {
+			break
+		}
+		end = j + 1
+	}
+	return end
+}
+
+func indent(l string) int {
+	return len(l) - len(strings.TrimLeft(l, " \t"))
+}
+
+func isAdornment(l string) bool {
+	l = strings.TrimSpace(l)
+	if len(l) < 3 {
+		return false
+	}
+	return strings.Count(l, l[:1]) == len(l) && strings.ContainsAny(l[:1], "=-~^\"'`#*+_.:")
+}
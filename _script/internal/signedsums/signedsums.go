@@ -0,0 +1,146 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package signedsums reads the signed checksum files of the Syncthing
+// releases, verifying their signatures with the Release Management GPG
+// key. The key is fetched from where it's published, and its fingerprints
+// checked against those pinned in the key manifest read by signingkeys.
+package signedsums
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// Asset is the signed checksum file of each release.
+const Asset = "sha256sum.txt.asc"
+
+// Manifest is the key manifest, relative to the _script directory.
+const Manifest = "../dev/release-keys.csv"
+
+// Sum is a line of the checksum file.
+type Sum struct {
+	Name   string
+	SHA256 string
+}
+
+// Fetch downloads the checksum file at the URL and returns its checksums,
+// after verifying its signature with the keyring.
+func Fetch(keyring openpgp.EntityList, url string) ([]Sum, error) {
+	bs, err := download(url)
+	if err != nil {
+		return nil, err
+	}
+	text, err := Verify(keyring, bs)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(text)
+}
+
+// download returns the contents at the URL.
+func download(url string) ([]byte, error) {
+	resp, err := httpclient.Default.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// Keyring returns the GPG keyring of the key manifest, fetched from its
+// source, after checking that its fingerprints are the pinned ones.
+func Keyring(manifest string) (openpgp.EntityList, error) {
+	fd, err := os.Open(manifest)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	cr := csv.NewReader(fd)
+	cr.Comment = '#'
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", manifest, err)
+	}
+	for _, rec := range records {
+		// Name, Type, Source, Fingerprints, Expires
+		if len(rec) != 5 || rec[1] != "gpg" {
+			continue
+		}
+		bs, err := download(rec[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rec[0], err)
+		}
+		ring, err := readKeyRing(bs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rec[0], err)
+		}
+		var got []string
+		for _, e := range ring {
+			got = append(got, fmt.Sprintf("%X", e.PrimaryKey.Fingerprint))
+		}
+		if pinned := strings.Fields(rec[3]); strings.Join(got, " ") != strings.Join(pinned, " ") {
+			return nil, fmt.Errorf("%s: published fingerprints %s differ from the pinned %s; see signingkeys", rec[0], strings.Join(got, " "), strings.Join(pinned, " "))
+		}
+		return ring, nil
+	}
+	return nil, fmt.Errorf("%s: no GPG key", manifest)
+}
+
+func readKeyRing(bs []byte) (openpgp.EntityList, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(bs), []byte("-----BEGIN PGP")) {
+		return openpgp.ReadArmoredKeyRing(bytes.NewReader(bs))
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(bs))
+}
+
+// Verify checks the signature of a clearsigned file, returning the signed
+// text.
+func Verify(keyring openpgp.EntityList, bs []byte) ([]byte, error) {
+	block, _ := clearsign.Decode(bs)
+	if block == nil {
+		return nil, errors.New("not clearsigned")
+	}
+	if _, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body); err != nil {
+		return nil, err
+	}
+	return block.Plaintext, nil
+}
+
+// Parse parses the "checksum  name" lines written by sha256sum.
+func Parse(text []byte) ([]Sum, error) {
+	var res []Sum
+	for i, line := range strings.Split(string(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		// The name is marked with a star in binary mode.
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		if !ok || len(hash) != 64 || name == "" {
+			return nil, fmt.Errorf("line %d: not a SHA-256 checksum: %q", i+1, line)
+		}
+		res = append(res, Sum{Name: name, SHA256: strings.ToLower(hash)})
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no checksums")
+	}
+	return res, nil
+}
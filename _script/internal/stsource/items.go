@@ -0,0 +1,207 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package stsource
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// apiDirs are where the REST endpoints have been registered over time;
+// the API moved from the main package to lib/api.
+var apiDirs = []string{"lib/api", "cmd/syncthing"}
+
+const configDir = "lib/config"
+
+// traceDirs are searched for the debug facilities.
+var traceDirs = []string{"cmd/syncthing", "lib"}
+
+// ConfigSections are the configuration structs by the section name used
+// in option names ("folder.rescanIntervalS").
+var ConfigSections = []struct {
+	Name   string
+	Struct string
+}{
+	{"folder", "FolderConfiguration"},
+	{"device", "DeviceConfiguration"},
+	{"options", "OptionsConfiguration"},
+	{"gui", "GUIConfiguration"},
+	{"ldap", "LDAPConfiguration"},
+}
+
+// Endpoints returns the REST paths in string literals in the API code,
+// such as /rest/db/status. The way handlers are registered has changed
+// too often to go by the calls, but the paths are always written out.
+func Endpoints(root string) ([]string, error) {
+	var paths []string
+	for _, dir := range apiDirs {
+		if _, err := os.Stat(filepath.Join(root, dir)); os.IsNotExist(err) {
+			continue
+		}
+		pkg, err := ParseDir(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range pkg.SortedFiles() {
+			ast.Inspect(f, func(n ast.Node) bool {
+				lit, ok := n.(*ast.BasicLit)
+				if !ok {
+					return true
+				}
+				s, ok := StringLit(lit)
+				if ok && strings.HasPrefix(s, "/rest/") && s != "/rest/" && !strings.ContainsAny(s, "* ") {
+					paths = append(paths, strings.TrimSuffix(s, "/"))
+				}
+				return true
+			})
+		}
+	}
+	return paths, nil
+}
+
+// Options returns the names of the configuration options, such as
+// folder.rescanIntervalS, by the XML names of the fields. Deprecated
+// fields and those not in the JSON are skipped, as they can't be set
+// through the API or GUI. Sections that don't exist yet in the version
+// have none.
+func Options(root string) ([]string, error) {
+	pkg, err := ParseDir(root, configDir)
+	if err != nil {
+		return nil, err
+	}
+	var opts []string
+	for _, sec := range ConfigSections {
+		for _, name := range structOptions(pkg, sec.Struct) {
+			opts = append(opts, sec.Name+"."+name)
+		}
+	}
+	return opts, nil
+}
+
+func structOptions(pkg *Package, structName string) []string {
+	var opts []string
+	for _, f := range pkg.SortedFiles() {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || ts.Name.Name != structName {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, fl := range st.Fields.List {
+					if len(fl.Names) != 1 || fl.Tag == nil || strings.HasPrefix(fl.Names[0].Name, "Deprecated") {
+						continue
+					}
+					tagValue, err := strconv.Unquote(fl.Tag.Value)
+					if err != nil {
+						continue
+					}
+					tags := reflect.StructTag(tagValue)
+					xmlName, _, _ := strings.Cut(tags.Get("xml"), ",")
+					jsonName, _, _ := strings.Cut(tags.Get("json"), ",")
+					if xmlName == "" || xmlName == "-" || jsonName == "-" {
+						continue
+					}
+					opts = append(opts, xmlName)
+				}
+			}
+		}
+	}
+	return opts
+}
+
+// Facility is a debug facility, enabled by naming it in STTRACE.
+type Facility struct {
+	Name        string
+	Description string
+}
+
+// Facilities returns the debug facilities registered with NewFacility in
+// the main package and the libraries, by name.
+func Facilities(root string) ([]Facility, error) {
+	seen := make(map[string]bool)
+	var res []Facility
+	for _, dir := range traceDirs {
+		pkgs, err := ParseTree(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range pkgs {
+			for _, f := range pkg.SortedFiles() {
+				ast.Inspect(f, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok || len(call.Args) != 2 {
+						return true
+					}
+					if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "NewFacility" {
+						return true
+					}
+					name, ok := StringLit(call.Args[0])
+					if !ok || seen[name] {
+						return true
+					}
+					desc, _ := StringLit(call.Args[1])
+					seen[name] = true
+					res = append(res, Facility{name, desc})
+					return true
+				})
+			}
+		}
+	}
+	sort.Slice(res, func(a, b int) bool { return res[a].Name < res[b].Name })
+	return res, nil
+}
+
+// EnumValues finds the enumerated types of a package by their String
+// methods: a switch returning a string literal per value, the zero value
+// usually first.
+func EnumValues(pkg *Package) map[string][]string {
+	enums := make(map[string][]string)
+	for _, f := range pkg.SortedFiles() {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "String" || fn.Body == nil {
+				continue
+			}
+			recv, ok := fn.Recv.List[0].Type.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			var values []string
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				cc, ok := n.(*ast.CaseClause)
+				if !ok || cc.List == nil {
+					return true
+				}
+				for _, stmt := range cc.Body {
+					if ret, ok := stmt.(*ast.ReturnStmt); ok && len(ret.Results) == 1 {
+						if v, ok := StringLit(ret.Results[0]); ok {
+							values = append(values, v)
+						}
+					}
+				}
+				return false
+			})
+			if len(values) > 0 {
+				enums[recv.Name] = values
+			}
+		}
+	}
+	return enums
+}
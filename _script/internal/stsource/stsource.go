@@ -0,0 +1,252 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package stsource gives the generators access to the Syncthing source
+// code at a given version, and parses Go packages from it.
+package stsource
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/cache"
+)
+
+// RepoURL is where the source is cloned from.
+var RepoURL = "https://github.com/syncthing/syncthing.git"
+
+// Open returns the path to a Syncthing source tree. If dir is given it's
+// used as is (typically the _syncthing checkout made by the refresh
+// scripts), unless a tag is also given in which case that tag is checked
+// out in it. Without a dir the tag is cloned into the shared cache, as
+// tags don't move, or into a temporary directory which the returned
+// function removes when the cache is disabled.
+func Open(dir, tag string) (string, func(), error) {
+	if dir != "" {
+		if tag != "" {
+			cmd := exec.Command("git", "-C", dir, "checkout", "--quiet", tag)
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				return "", nil, fmt.Errorf("checking out %s: %w", tag, err)
+			}
+		}
+		return dir, func() {}, nil
+	}
+	if tag == "" {
+		return "", nil, fmt.Errorf("either a source directory or a tag is required")
+	}
+
+	c := cache.Default()
+	src, err := c.Dir(RepoURL+"@"+tag, cache.Never, func(dir string) error {
+		return clone(tag, dir)
+	})
+	if err == nil {
+		// The clone is the newest entry, so it's the last one trimming
+		// removes; a cache that can't be trimmed is no reason to fail.
+		_ = c.Trim()
+		return src, func() {}, nil
+	} else if !errors.Is(err, cache.ErrDisabled) {
+		return "", nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", "syncthing-src")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(tmp) }
+	if err := clone(tag, tmp); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp, cleanup, nil
+}
+
+func clone(tag, dir string) error {
+	cmd := exec.Command("git", "clone", "--quiet", "--depth", "1", "--branch", tag, RepoURL, dir)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("cloning %s: %w", tag, err)
+	}
+	return nil
+}
+
+// Package is a parsed Go package.
+type Package struct {
+	Name  string
+	Dir   string // relative to the source root, slash separated
+	Fset  *token.FileSet
+	Files map[string]*ast.File // by path relative to the source root
+}
+
+// ParseDir parses the non-test Go files in the directory (relative to the
+// source root), including comments.
+func ParseDir(root, dir string) (*Package, error) {
+	fset := token.NewFileSet()
+	abs := filepath.Join(root, filepath.FromSlash(dir))
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &Package{Dir: dir, Fset: fset, Files: make(map[string]*ast.File)}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(abs, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(f.Name.Name, "_test") {
+			continue
+		}
+		pkg.Name = f.Name.Name
+		pkg.Files[dir+"/"+name] = f
+	}
+	if len(pkg.Files) == 0 {
+		return nil, fmt.Errorf("%s: no Go files", dir)
+	}
+	return pkg, nil
+}
+
+// ParseTree parses every package below the directory (relative to the
+// source root), skipping vendored code and test data.
+func ParseTree(root, dir string) ([]*Package, error) {
+	var pkgs []*Package
+	err := filepath.WalkDir(filepath.Join(root, filepath.FromSlash(dir)), func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		switch d.Name() {
+		case "vendor", "testdata", "node_modules", ".git":
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		pkg, err := ParseDir(root, filepath.ToSlash(rel))
+		if err != nil {
+			// Directories without Go files are fine.
+			return nil
+		}
+		pkgs = append(pkgs, pkg)
+		return nil
+	})
+	return pkgs, err
+}
+
+// SortedFiles returns the package's files in name order, for
+// deterministic output.
+func (p *Package) SortedFiles() []*ast.File {
+	names := make([]string, 0, len(p.Files))
+	for name := range p.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := make([]*ast.File, len(names))
+	for i, name := range names {
+		files[i] = p.Files[name]
+	}
+	return files
+}
+
+// Position returns the file (relative to the source root) and line of a
+// node.
+func (p *Package) Position(n ast.Node) (string, int) {
+	pos := p.Fset.Position(n.Pos())
+	for name, f := range p.Files {
+		if p.Fset.File(f.Pos()).Name() == pos.Filename {
+			return name, pos.Line
+		}
+	}
+	return pos.Filename, pos.Line
+}
+
+// StringLit returns the value of a string literal expression, or false if
+// it isn't one. Concatenations of literals are folded.
+func StringLit(e ast.Expr) (string, bool) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s := e.Value
+		if strings.HasPrefix(s, "`") {
+			return strings.Trim(s, "`"), true
+		}
+		var out string
+		if _, err := fmt.Sscanf(s, "%q", &out); err != nil {
+			return "", false
+		}
+		return out, true
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		a, ok := StringLit(e.X)
+		if !ok {
+			return "", false
+		}
+		b, ok := StringLit(e.Y)
+		if !ok {
+			return "", false
+		}
+		return a + b, true
+	case *ast.ParenExpr:
+		return StringLit(e.X)
+	}
+	return "", false
+}
+
+// DocText returns the text of a comment group with the comment markers
+// removed, or the empty string.
+func DocText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.TrimSpace(cg.Text())
+}
+
+// LineComment returns the text of a comment on the same line as the end
+// of the node, if there is one.
+func (p *Package) LineComment(f *ast.File, n ast.Node) string {
+	line := p.Fset.Position(n.End()).Line
+	for _, cg := range f.Comments {
+		if cg.Pos() < n.End() {
+			continue
+		}
+		if p.Fset.Position(cg.Pos()).Line != line {
+			if p.Fset.Position(cg.Pos()).Line > line {
+				break
+			}
+			continue
+		}
+		return DocText(cg)
+	}
+	return ""
+}
+
+// Head returns the commit checked out in a source directory, to return to
+// after checking out other versions.
+func Head(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("reading HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
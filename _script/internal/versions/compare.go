@@ -0,0 +1,48 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package versions
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Compare compares dotted versions, with or without the v, numerically
+// component by component, so that v1.10.0 sorts after v1.9.0. Components
+// that aren't numbers count as 0.
+func Compare(a, b string) int {
+	return CompareParts(splitParts(a), splitParts(b))
+}
+
+func splitParts(version string) []int {
+	ss := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	parts := make([]int, len(ss))
+	for i, s := range ss {
+		parts[i], _ = strconv.Atoi(s)
+	}
+	return parts
+}
+
+// CompareParts compares versions given as their numeric components. A
+// missing component sorts before a present one (v1.2.3 < v1.2.3.1).
+func CompareParts(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
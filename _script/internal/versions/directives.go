@@ -0,0 +1,67 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package versions
+
+import (
+	"fmt"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// Directives are the directives taking the version as argument, and what
+// they say happened in it.
+var Directives = map[string]string{
+	"versionadded":   "added",
+	"versionchanged": "changed",
+	"deprecated":     "deprecated",
+}
+
+// Mention is a version directive in a document.
+type Mention struct {
+	Directive string
+	// Doc is the name of the document.
+	Doc string
+	// Release is the release of the version, when it's in the table.
+	Release Release
+	// Problem is why the version isn't a release in the table, if it
+	// isn't: not a version at all, newer than the latest release, or
+	// a typo such as "1.23.10" for "1.23.1".
+	Problem string
+	Pos     rstdoc.Pos
+}
+
+// Mentions returns the version directives in the documents of the tree,
+// with the releases they refer to. The version is taken with or without
+// the v.
+func (t *Table) Mentions(tree *rstdoc.Tree) []Mention {
+	latest, _ := t.Latest()
+	var res []Mention
+	for _, d := range tree.Docs {
+		for _, dir := range d.Directives {
+			if _, ok := Directives[dir.Name]; !ok {
+				continue
+			}
+			m := Mention{Directive: dir.Name, Doc: d.Name, Pos: dir.Pos}
+			arg, _, _ := strings.Cut(strings.TrimSpace(dir.Arg), " ")
+			tag := "v" + strings.TrimPrefix(arg, "v")
+			rel, released := t.Release(tag)
+			switch v, ok := parseVersion(tag); {
+			case released:
+				m.Release = rel
+			case !ok:
+				m.Problem = fmt.Sprintf("%s %q is not a version", dir.Name, arg)
+			case latest.Version.Less(v):
+				m.Problem = fmt.Sprintf("%s %s is newer than the latest release, %s", dir.Name, arg, latest.Tag)
+			default:
+				m.Problem = fmt.Sprintf("%s %s is not a release in the versions table", dir.Name, arg)
+			}
+			res = append(res, m)
+		}
+	}
+	return res
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package versions reads the versions table kept by histver
+// (users/releases.csv), so that the generators answer questions about
+// the releases from the one canonical source.
+package versions
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/relnotes"
+)
+
+// File is the versions table, relative to the _script directory.
+const File = "../users/releases.csv"
+
+// Release is a row of the versions table.
+type Release struct {
+	// Tag is the version as written in the table; the earliest
+	// versions have two parts, as in v0.9.
+	Tag     string
+	Version relnotes.Version
+	// Runtime is the Go version the release was built with, as in
+	// go1.22.3.
+	Runtime string
+	Date    time.Time
+	// Language is the go.mod language version, when known.
+	Language string
+}
+
+// Table is the releases in the versions table, oldest first.
+type Table struct {
+	releases []Release
+}
+
+// Load reads the versions table file.
+func Load(file string) (*Table, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	t, err := Read(fd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return t, nil
+}
+
+// Read reads a versions table. The columns are found by the names in the
+// header, as histver writes optional ones only when they have values.
+// Prerelease versions are left out, as are repeated ones.
+func Read(r io.Reader) (*Table, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return &Table{}, nil
+	}
+	cols := make(map[string]int)
+	for i, name := range records[0] {
+		cols[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"Version", "Runtime", "Date"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("no %s column", name)
+		}
+	}
+
+	var t Table
+	seen := make(map[relnotes.Version]bool)
+	for i, rec := range records[1:] {
+		get := func(name string) string {
+			if c, ok := cols[name]; ok && c < len(rec) {
+				return strings.TrimSpace(rec[c])
+			}
+			return ""
+		}
+		tag := get("Version")
+		v, ok := parseVersion(tag)
+		if !ok {
+			continue
+		}
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		date, err := time.Parse(time.DateOnly, get("Date"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		t.releases = append(t.releases, Release{
+			Tag:      tag,
+			Version:  v,
+			Runtime:  get("Runtime"),
+			Date:     date,
+			Language: get("Language"),
+		})
+	}
+	sort.SliceStable(t.releases, func(a, b int) bool { return t.releases[a].Version.Less(t.releases[b].Version) })
+	return &t, nil
+}
+
+// parseVersion parses a version tag, including the two part ones of the
+// earliest versions.
+func parseVersion(tag string) (relnotes.Version, bool) {
+	if v, ok := relnotes.ParseVersion(tag); ok {
+		return v, true
+	}
+	return relnotes.ParseVersion(tag + ".0")
+}
+
+// Releases returns all the releases, oldest first.
+func (t *Table) Releases() []Release {
+	return t.releases
+}
+
+// Release returns the release of the version, as in v1.27.3 (or v0.9
+// for the earliest ones).
+func (t *Table) Release(version string) (Release, bool) {
+	v, ok := parseVersion(version)
+	if !ok {
+		return Release{}, false
+	}
+	i := sort.Search(len(t.releases), func(i int) bool { return !t.releases[i].Version.Less(v) })
+	if i == len(t.releases) || t.releases[i].Version != v {
+		return Release{}, false
+	}
+	return t.releases[i], true
+}
+
+// RuntimeFor returns the Go version the release was built with.
+func (t *Table) RuntimeFor(version string) (string, bool) {
+	r, ok := t.Release(version)
+	return r.Runtime, ok && r.Runtime != ""
+}
+
+// Latest returns the highest version.
+func (t *Table) Latest() (Release, bool) {
+	if len(t.releases) == 0 {
+		return Release{}, false
+	}
+	return t.releases[len(t.releases)-1], true
+}
+
+// ReleasedBetween returns the releases made from one date to the other,
+// inclusive, oldest first.
+func (t *Table) ReleasedBetween(from, to time.Time) []Release {
+	var res []Release
+	for _, r := range t.releases {
+		if !r.Date.Before(from) && !r.Date.After(to) {
+			res = append(res, r)
+		}
+	}
+	sort.SliceStable(res, func(a, b int) bool { return res[a].Date.Before(res[b].Date) })
+	return res
+}
+
+// Versions returns the versions of all the releases, oldest first.
+func (t *Table) Versions() []relnotes.Version {
+	res := make([]relnotes.Version, len(t.releases))
+	for i, r := range t.releases {
+		res[i] = r.Version
+	}
+	return res
+}
@@ -0,0 +1,199 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package weblate fetches translation statistics and credits from the
+// Weblate API, and reads and adds to the project glossary.
+package weblate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// DefaultURL is the API root of the Weblate instance the Syncthing
+// translations are on.
+const DefaultURL = "https://hosted.weblate.org/api/"
+
+// Client talks to the Weblate API, authenticated with WEBLATE_TOKEN if
+// it's set to get the higher rate limit.
+type Client struct {
+	URL   string
+	Token string
+}
+
+// NewClient returns a client for the given API root.
+func NewClient(apiURL string) *Client {
+	return &Client{URL: apiURL, Token: os.Getenv("WEBLATE_TOKEN")}
+}
+
+// Language is the translation state of a language across a project or
+// component.
+type Language struct {
+	Name              string    `json:"language"`
+	Code              string    `json:"code"`
+	Total             int       `json:"total"`
+	Translated        int       `json:"translated"`
+	TranslatedPercent float64   `json:"translated_percent"`
+	LastChange        time.Time `json:"last_change"`
+	URL               string    `json:"url"`
+}
+
+// Languages returns the translation state per language of the project.
+func (c *Client) Languages(ctx context.Context, project string) ([]Language, error) {
+	var res []Language
+	err := c.get(ctx, []string{"projects", project, "languages/"}, nil, &res)
+	return res, err
+}
+
+// Credit is a translator with the number of changes they made.
+type Credit struct {
+	Name    string `json:"full_name"`
+	Email   string `json:"email"`
+	Changes int    `json:"change_count"`
+}
+
+// Credits returns the translators of the project between the times, by
+// language name.
+func (c *Client) Credits(ctx context.Context, project string, start, end time.Time) (map[string][]Credit, error) {
+	q := url.Values{
+		"start": {start.Format(time.RFC3339)},
+		"end":   {end.Format(time.RFC3339)},
+	}
+	// The response is a list of single entry objects, language name to
+	// translators.
+	var res []map[string][]Credit
+	if err := c.get(ctx, []string{"projects", project, "credits/"}, q, &res); err != nil {
+		return nil, err
+	}
+	credits := make(map[string][]Credit)
+	for _, m := range res {
+		for lang, cs := range m {
+			credits[lang] = append(credits[lang], cs...)
+		}
+	}
+	return credits, nil
+}
+
+// Unit is a string of a translation; in a glossary, a term.
+type Unit struct {
+	ID     int      `json:"id"`
+	Source []string `json:"source"`
+	Target []string `json:"target"`
+	// Explanation is the definition of a glossary term.
+	Explanation string `json:"explanation"`
+	// ExtraFlags mark glossary terms, as in "read-only" for terms not
+	// to translate or "forbidden" for translations not to use.
+	ExtraFlags string `json:"extra_flags"`
+	WebURL     string `json:"web_url"`
+}
+
+// page is a page of a paginated list.
+type page[T any] struct {
+	Next    string `json:"next"`
+	Results []T    `json:"results"`
+}
+
+// Units returns the units of the translation of the component into the
+// language.
+func (c *Client) Units(ctx context.Context, project, component, lang string) ([]Unit, error) {
+	u, err := url.JoinPath(c.URL, "translations", project, component, lang, "units/")
+	if err != nil {
+		return nil, err
+	}
+	var res []Unit
+	for u != "" {
+		var p page[Unit]
+		if err := c.do(ctx, http.MethodGet, u, nil, &p); err != nil {
+			return nil, err
+		}
+		res = append(res, p.Results...)
+		u = p.Next
+	}
+	return res, nil
+}
+
+// AddTerm adds a term, with its definition, to the translation of a
+// glossary component into its source language. It needs a token.
+func (c *Client) AddTerm(ctx context.Context, project, component, lang, term, explanation string) error {
+	if c.Token == "" {
+		return errors.New("adding glossary terms needs WEBLATE_TOKEN")
+	}
+	u, err := url.JoinPath(c.URL, "translations", project, component, lang, "units/")
+	if err != nil {
+		return err
+	}
+	var unit Unit
+	add := map[string][]string{"source": {term}, "target": {term}}
+	if err := c.do(ctx, http.MethodPost, u, add, &unit); err != nil {
+		return err
+	}
+	if explanation == "" {
+		return nil
+	}
+	// The explanation can't be set when adding the unit.
+	u, err = url.JoinPath(c.URL, "units", fmt.Sprint(unit.ID), "/")
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPatch, u, map[string]string{"explanation": explanation}, nil)
+}
+
+func (c *Client) get(ctx context.Context, path []string, q url.Values, v any) error {
+	u, err := url.JoinPath(c.URL, path...)
+	if err != nil {
+		return err
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return c.do(ctx, http.MethodGet, u, nil, v)
+}
+
+// do makes a request with the body, if any, as JSON, and decodes the
+// response into v, if set.
+func (c *Client) do(ctx context.Context, method, u string, body, v any) error {
+	var rd io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(bs)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Token "+c.Token)
+	}
+	resp, err := httpclient.Default.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s", method, u, resp.Status)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./issuestats > ../includes/project-status.rst
+//
+// Writes the numbers on the open issues for the project status page, so
+// prospective contributors can see where help is needed: the open bugs
+// per area label, the enhancement backlog, and how old the open issues
+// are. The area labels are those described on the issue management page.
+// Set GITHUB_TOKEN to avoid the rate limit for unauthenticated requests.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/relnotes"
+)
+
+// The labels giving the kind of an issue.
+const (
+	labelBug         = "bug"
+	labelEnhancement = "enhancement"
+	labelGoodFirst   = "good first issue"
+)
+
+// issue is an open issue, with what the statistics need of it.
+type issue struct {
+	Created  time.Time
+	Labels   map[string]bool
+	Assigned bool
+}
+
+func main() {
+	log.SetFlags(0)
+	repo := flag.String("repo", "syncthing/syncthing", "Repository to take the issues from")
+	areas := flag.String("areas", "api,build,documentation,protocol,ui", "Comma separated labels marking the area of an issue")
+	flag.Parse()
+
+	owner, name, ok := strings.Cut(*repo, "/")
+	if !ok {
+		log.Fatalf("bad repository %q, expected owner/name", *repo)
+	}
+
+	ctx := context.Background()
+	issues, err := listOpen(ctx, relnotes.Client(), owner, name)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	st := tally(issues, strings.Split(*areas, ","), time.Now().UTC())
+	if err := writeStatus(os.Stdout, *repo, st); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// listOpen returns the open issues of the repository, leaving out pull
+// requests.
+func listOpen(ctx context.Context, client *github.Client, owner, repo string) ([]issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var res []issue
+	for {
+		issues, resp, err := client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, iss := range issues {
+			if iss.IsPullRequest() {
+				continue
+			}
+			it := issue{
+				Created:  iss.GetCreatedAt(),
+				Labels:   make(map[string]bool),
+				Assigned: len(iss.Assignees) > 0,
+			}
+			for _, l := range iss.Labels {
+				it.Labels[l.GetName()] = true
+			}
+			res = append(res, it)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return res, nil
+}
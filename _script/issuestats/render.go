@@ -0,0 +1,179 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+// ages are the buckets of the age distribution, each up to the given
+// age; the last takes the rest.
+var ages = []struct {
+	name string
+	max  time.Duration
+}{
+	{"Less than a month", 30 * 24 * time.Hour},
+	{"One to six months", 182 * 24 * time.Hour},
+	{"Six months to a year", 365 * 24 * time.Hour},
+	{"One to two years", 2 * 365 * 24 * time.Hour},
+	{"More than two years", 0},
+}
+
+// kinds counts issues by kind.
+type kinds struct {
+	Bugs, Enhancements, Other int
+}
+
+func (k *kinds) add(iss issue) {
+	switch {
+	case iss.Labels[labelBug]:
+		k.Bugs++
+	case iss.Labels[labelEnhancement]:
+		k.Enhancements++
+	default:
+		k.Other++
+	}
+}
+
+// area is the open bugs and good first issues with an area label, or
+// with none when the label is empty.
+type area struct {
+	Label     string
+	Bugs      int
+	GoodFirst int
+}
+
+type status struct {
+	Date  time.Time
+	Total kinds
+	// Assigned is the number of enhancements someone is working on.
+	Assigned  int
+	GoodFirst int
+	Areas     []area
+	Ages      []kinds
+}
+
+func tally(issues []issue, labels []string, now time.Time) status {
+	st := status{Date: now, Ages: make([]kinds, len(ages))}
+	for _, l := range labels {
+		st.Areas = append(st.Areas, area{Label: strings.TrimSpace(l)})
+	}
+	st.Areas = append(st.Areas, area{})
+
+	for _, iss := range issues {
+		st.Total.add(iss)
+		if iss.Labels[labelEnhancement] && iss.Assigned {
+			st.Assigned++
+		}
+		if iss.Labels[labelGoodFirst] {
+			st.GoodFirst++
+		}
+
+		labelled := false
+		for i := range st.Areas[:len(st.Areas)-1] {
+			a := &st.Areas[i]
+			if !iss.Labels[a.Label] {
+				continue
+			}
+			labelled = true
+			if iss.Labels[labelBug] {
+				a.Bugs++
+			}
+			if iss.Labels[labelGoodFirst] {
+				a.GoodFirst++
+			}
+		}
+		if !labelled {
+			other := &st.Areas[len(st.Areas)-1]
+			if iss.Labels[labelBug] {
+				other.Bugs++
+			}
+			if iss.Labels[labelGoodFirst] {
+				other.GoodFirst++
+			}
+		}
+
+		age := now.Sub(iss.Created)
+		for i, b := range ages {
+			if b.max == 0 || age < b.max {
+				st.Ages[i].add(iss)
+				break
+			}
+		}
+	}
+	return st
+}
+
+func writeStatus(w io.Writer, repo string, st status) error {
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/issuestats; do not edit.\n\n")
+	fmt.Fprintf(&sb, "These are the numbers as of %s.\n\n", st.Date.Format(time.DateOnly))
+	fmt.Fprintf(&sb, "There are %s and %s, %d of which someone is working on. ",
+		rst.Link(count(st.Total.Bugs, "open bug"), searchURL(repo, labelBug)),
+		rst.Link(count(st.Total.Enhancements, "open enhancement request"), searchURL(repo, labelEnhancement)),
+		st.Assigned)
+	fmt.Fprintf(&sb, "Issues labelled %s are a good place to start; %d are open.\n\n",
+		rst.Link(labelGoodFirst, searchURL(repo, labelGoodFirst)), st.GoodFirst)
+
+	areas := rst.Table{
+		Title:  "Open Bugs by Area",
+		Header: []string{"Area", "Open Bugs", "Good First Issues"},
+		Widths: []int{40, 30, 30},
+	}
+	for _, a := range st.Areas {
+		name := "Other"
+		if a.Label != "" {
+			name = rst.Link(a.Label, searchURL(repo, labelBug, a.Label))
+		}
+		areas.Rows = append(areas.Rows, []string{name, strconv.Itoa(a.Bugs), strconv.Itoa(a.GoodFirst)})
+	}
+	if _, err := areas.WriteTo(&sb); err != nil {
+		return err
+	}
+
+	byAge := rst.Table{
+		Title:  "Age of Open Issues",
+		Header: []string{"Age", "Bugs", "Enhancements", "Other"},
+		Widths: []int{40, 20, 20, 20},
+	}
+	for i, b := range ages {
+		k := st.Ages[i]
+		byAge.Rows = append(byAge.Rows, []string{b.name, strconv.Itoa(k.Bugs), strconv.Itoa(k.Enhancements), strconv.Itoa(k.Other)})
+	}
+	if _, err := byAge.WriteTo(&sb); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// searchURL returns the link to the open issues with all the labels.
+func searchURL(repo string, labels ...string) string {
+	q := []string{"is:issue", "is:open"}
+	for _, l := range labels {
+		if strings.Contains(l, " ") {
+			l = `"` + l + `"`
+		}
+		q = append(q, "label:"+l)
+	}
+	return "https://github.com/" + repo + "/issues?" + url.Values{"q": {strings.Join(q, " ")}}.Encode()
+}
+
+func count(n int, what string) string {
+	if n == 1 {
+		return "1 " + what
+	}
+	return fmt.Sprintf("%d %ss", n, what)
+}
@@ -0,0 +1,208 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./linkarchive [-check] [-limit 50]
+//
+// Keeps archived copies of the external pages the docs link to. Every
+// external URL in the docs is listed in the manifest, links-archive.csv
+// at the root of the docs, with the Wayback Machine copy of it. URLs
+// without a copy, or with one older than -age, get a new snapshot taken;
+// at most -limit of them per run, as the Wayback Machine limits the rate
+// of snapshot requests. URLs no longer in the docs are dropped.
+//
+// With -check, each URL is also fetched and the ones that fail are marked
+// as dead, with the date they were first found to be. The link_archive
+// Sphinx extension can then point the links to those at their archived
+// copies instead; see conf.py.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// link is a row of the manifest.
+type link struct {
+	URL string
+	// Archived is the URL of the latest archived copy, and Snapshot the
+	// date it was taken.
+	Archived string
+	Snapshot string
+	// Dead is the date the URL was first found not to work, if it
+	// doesn't.
+	Dead string
+}
+
+var manifestHeader = []string{"URL", "Archived", "Snapshot", "Dead"}
+
+// skipHosts are hosts of URLs that aren't worth archiving: examples, and
+// the docs and the archive themselves.
+var skipHosts = map[string]bool{
+	"localhost":          true,
+	"127.0.0.1":          true,
+	"example.com":        true,
+	"example.org":        true,
+	"example.net":        true,
+	"docs.syncthing.net": true,
+	"web.archive.org":    true,
+}
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	manifest := flag.String("manifest", "../links-archive.csv", "Link archive manifest")
+	age := flag.Int("age", 365, "Take a new snapshot when the last one is older than this many days (zero for never)")
+	limit := flag.Int("limit", 50, "Maximum number of snapshots to take")
+	delay := flag.Duration("delay", 10*time.Second, "Delay between snapshot requests")
+	check := flag.Bool("check", false, "Check which URLs are dead")
+	flag.Parse()
+
+	tree, err := rstdoc.Load(*root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	old, err := readManifest(*manifest)
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalln(err)
+	}
+
+	var links []*link
+	for _, u := range externalURLs(tree) {
+		l := old[u]
+		if l == nil {
+			l = &link{URL: u}
+		}
+		links = append(links, l)
+	}
+
+	ctx := context.Background()
+	today := time.Now().Format(time.DateOnly)
+	stale := time.Now().AddDate(0, 0, -*age).Format(time.DateOnly)
+	taken := 0
+	for _, l := range links {
+		if *check {
+			if err := fetch(ctx, l.URL); err != nil {
+				log.Printf("Dead: %v", err)
+				if l.Dead == "" {
+					l.Dead = today
+				}
+			} else {
+				l.Dead = ""
+			}
+		}
+
+		if l.Archived != "" && (*age == 0 || l.Snapshot >= stale) {
+			continue
+		}
+		if l.Dead != "" && l.Archived != "" {
+			// A new snapshot of a dead page would only archive the
+			// error.
+			continue
+		}
+		if taken >= *limit {
+			continue
+		}
+		if taken > 0 {
+			time.Sleep(*delay)
+		}
+		taken++
+
+		archived, date, err := snapshot(ctx, l.URL)
+		if err != nil {
+			log.Printf("%s: %v", l.URL, err)
+			continue
+		}
+		l.Archived, l.Snapshot = archived, date
+	}
+
+	if err := writeManifest(*manifest, links); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// externalURLs returns the external URLs linked to from the docs, sorted
+// and without duplicates.
+func externalURLs(t *rstdoc.Tree) []string {
+	seen := make(map[string]bool)
+	add := func(u string) {
+		u = strings.Join(strings.Fields(u), "")
+		pu, err := url.Parse(u)
+		if err != nil || pu.Scheme != "http" && pu.Scheme != "https" || skipHosts[pu.Hostname()] {
+			return
+		}
+		seen[u] = true
+	}
+	for _, d := range t.Docs {
+		for _, r := range d.Refs {
+			if r.Role == "" && !r.Named {
+				add(r.Target)
+			}
+		}
+		for _, tg := range d.Targets {
+			add(tg.URL)
+		}
+	}
+	res := make([]string, 0, len(seen))
+	for u := range seen {
+		res = append(res, u)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// readManifest returns the links in the manifest, by URL.
+func readManifest(file string) (map[string]*link, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	records, err := csv.NewReader(fd).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	res := make(map[string]*link)
+	for i, rec := range records {
+		if i == 0 {
+			// Header
+			continue
+		}
+		res[rec[0]] = &link{URL: rec[0], Archived: rec[1], Snapshot: rec[2], Dead: rec[3]}
+	}
+	return res, nil
+}
+
+func writeManifest(file string, links []*link) error {
+	tmp := file + ".tmp"
+	fd, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(fd)
+	_ = cw.Write(manifestHeader)
+	for _, l := range links {
+		_ = cw.Write([]string{l.URL, l.Archived, l.Snapshot, l.Dead})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		fd.Close()
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
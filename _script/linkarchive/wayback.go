@@ -0,0 +1,135 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+)
+
+const (
+	saveURL      = "https://web.archive.org/save/"
+	availableURL = "https://archive.org/wayback/available"
+	userAgent    = "Mozilla/5.0 (compatible; syncthing-docs-linkarchive)"
+)
+
+// snapshotExp matches the URL of an archived copy, with the timestamp it
+// was taken at.
+var snapshotExp = regexp.MustCompile(`^https?://web\.archive\.org/web/(\d{14})/`)
+
+// snapshot asks the Wayback Machine to archive the URL, and returns the
+// URL of the archived copy and the date it was taken. If a snapshot
+// can't be taken right now, the latest existing one is returned.
+func snapshot(ctx context.Context, u string) (string, string, error) {
+	archived, err := save(ctx, u)
+	if err != nil {
+		var aerr error
+		if archived, aerr = available(ctx, u); aerr != nil {
+			return "", "", fmt.Errorf("%v; %v", err, aerr)
+		}
+	}
+	m := snapshotExp.FindStringSubmatch(archived)
+	if m == nil {
+		return "", "", fmt.Errorf("unexpected archive URL %q", archived)
+	}
+	t, err := time.Parse("20060102150405", m[1])
+	if err != nil {
+		return "", "", err
+	}
+	return archived, t.Format(time.DateOnly), nil
+}
+
+// save requests a snapshot with Save Page Now, which redirects to the
+// archived copy when it's done.
+func save(ctx context.Context, u string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, saveURL+u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := httpclient.Default.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("save: %s", resp.Status)
+	}
+	if loc := resp.Header.Get("Content-Location"); loc != "" {
+		return "https://web.archive.org" + loc, nil
+	}
+	if final := resp.Request.URL.String(); snapshotExp.MatchString(final) {
+		return final, nil
+	}
+	return "", errors.New("save: no archived copy in the response")
+}
+
+// available returns the latest archived copy of the URL.
+func available(ctx context.Context, u string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, availableURL+"?url="+url.QueryEscape(u), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := httpclient.Default.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("available: %s", resp.Status)
+	}
+	var res struct {
+		ArchivedSnapshots struct {
+			Closest struct {
+				Available bool   `json:"available"`
+				URL       string `json:"url"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", fmt.Errorf("available: %w", err)
+	}
+	if !res.ArchivedSnapshots.Closest.Available {
+		return "", errors.New("available: not archived")
+	}
+	// The API returns http URLs.
+	return "https://" + snapshotExp.ReplaceAllString(res.ArchivedSnapshots.Closest.URL, "web.archive.org/web/$1/"), nil
+}
+
+// fetch returns an error if the URL can't be fetched.
+func fetch(ctx context.Context, u string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	// Some sites refuse requests without a browser-like user agent.
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := httpclient.Default.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	return nil
+}
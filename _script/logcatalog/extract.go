@@ -0,0 +1,129 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// sourceDirs are searched for log calls.
+var sourceDirs = []string{"lib", "cmd"}
+
+// levels maps the logging methods of the Syncthing logger (and the
+// standard library logger used by the servers) to message levels. Debug
+// output isn't user facing and is left out.
+var levels = map[string]string{
+	"Warnln":  "warning",
+	"Warnf":   "warning",
+	"Infoln":  "info",
+	"Infof":   "info",
+	"Fatal":   "fatal",
+	"Fatalln": "fatal",
+	"Fatalf":  "fatal",
+}
+
+// placeholder replaces the formatted values in messages.
+const placeholder = "<...>"
+
+type message struct {
+	Text    string
+	Level   string
+	Sources []string
+}
+
+// ID returns a stable identifier for the message, for link targets.
+func (m message) ID() string {
+	sum := sha256.Sum256([]byte(m.Text))
+	return fmt.Sprintf("%x", sum[:4])
+}
+
+var verbExp = regexp.MustCompile(`%[-+# 0-9.*]*[a-zA-Z]`)
+
+// extract returns the log messages in the source tree, sorted by text.
+func extract(root string) ([]message, error) {
+	byText := make(map[string]*message)
+	for _, dir := range sourceDirs {
+		pkgs, err := stsource.ParseTree(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range pkgs {
+			for _, f := range pkg.SortedFiles() {
+				ast.Inspect(f, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok || len(call.Args) == 0 {
+						return true
+					}
+					sel, ok := call.Fun.(*ast.SelectorExpr)
+					if !ok {
+						return true
+					}
+					level, ok := levels[sel.Sel.Name]
+					if !ok {
+						return true
+					}
+					if _, ok := sel.X.(*ast.Ident); !ok {
+						return true
+					}
+					text := messageText(sel.Sel.Name, call.Args)
+					if strings.Trim(strings.ReplaceAll(text, placeholder, ""), " :") == "" {
+						// Only formatted values, such as l.Infof(msg).
+						return true
+					}
+					file, line := pkg.Position(call)
+					m, ok := byText[text]
+					if !ok {
+						m = &message{Text: text, Level: level}
+						byText[text] = m
+					}
+					m.Sources = append(m.Sources, file+":"+strconv.Itoa(line))
+					return true
+				})
+			}
+		}
+	}
+
+	msgs := make([]message, 0, len(byText))
+	for _, m := range byText {
+		msgs = append(msgs, *m)
+	}
+	sort.Slice(msgs, func(a, b int) bool {
+		return strings.ToLower(msgs[a].Text) < strings.ToLower(msgs[b].Text)
+	})
+	return msgs, nil
+}
+
+// messageText returns the message with the formatted values replaced by
+// placeholders: the format string for the printf style methods, the
+// arguments joined by spaces for the others.
+func messageText(method string, args []ast.Expr) string {
+	if strings.HasSuffix(method, "f") {
+		format, ok := stsource.StringLit(args[0])
+		if !ok {
+			return placeholder
+		}
+		format = verbExp.ReplaceAllString(strings.ReplaceAll(format, "%%", "\x00"), placeholder)
+		return strings.TrimSpace(strings.ReplaceAll(format, "\x00", "%"))
+	}
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		if s, ok := stsource.StringLit(arg); ok {
+			parts[i] = strings.TrimSpace(s)
+		} else {
+			parts[i] = placeholder
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,150 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./logcatalog -tag v1.27.0 > ../includes/log-messages.rst
+//
+// Extracts the warning, informational and fatal log messages from the
+// Syncthing source and writes a reference of them, each with a link
+// target and the explanation from explanations.json, or a note that it's
+// not yet documented. With -compare, the messages added and removed
+// since an older version are listed instead.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/stsource"
+)
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	compare := flag.String("compare", "", "Older version to list the changed messages against")
+	explanationsFile := flag.String("explanations", "logcatalog/explanations.json", "JSON file mapping messages to explanations")
+	flag.Parse()
+
+	var old []message
+	if *compare != "" {
+		root, cleanup, err := stsource.Open(*src, *compare)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		old, err = extract(root)
+		cleanup()
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	root, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+	msgs, err := extract(root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *compare != "" {
+		added, removed := diff(old, msgs)
+		for _, m := range added {
+			fmt.Printf("+ %s: %s (%s)\n", m.Level, m.Text, strings.Join(m.Sources, ", "))
+		}
+		for _, m := range removed {
+			fmt.Printf("- %s: %s\n", m.Level, m.Text)
+		}
+		return
+	}
+
+	explanations, err := loadExplanations(*explanationsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := writeCatalog(os.Stdout, msgs, explanations); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func loadExplanations(path string) (map[string]string, error) {
+	bs, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var explanations map[string]string
+	if err := json.Unmarshal(bs, &explanations); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return explanations, nil
+}
+
+// diff returns the messages only in the new version and those only in
+// the old one.
+func diff(old, cur []message) (added, removed []message) {
+	inOld := make(map[string]bool)
+	for _, m := range old {
+		inOld[m.Text] = true
+	}
+	inCur := make(map[string]bool)
+	for _, m := range cur {
+		inCur[m.Text] = true
+		if !inOld[m.Text] {
+			added = append(added, m)
+		}
+	}
+	for _, m := range old {
+		if !inCur[m.Text] {
+			removed = append(removed, m)
+		}
+	}
+	return added, removed
+}
+
+var levelTitles = []struct{ level, title string }{
+	{"fatal", "Fatal Errors"},
+	{"warning", "Warnings"},
+	{"info", "Informational Messages"},
+}
+
+func writeCatalog(w io.Writer, msgs []message, explanations map[string]string) error {
+	var sb strings.Builder
+	for _, lt := range levelTitles {
+		first := true
+		for _, m := range msgs {
+			if m.Level != lt.level {
+				continue
+			}
+			if first {
+				sb.WriteString(rst.Heading(lt.title, '~'))
+				first = false
+			}
+			fmt.Fprintf(&sb, ".. _log-%s:\n\n", m.ID())
+			sb.WriteString(rst.Literal(m.Text) + "\n")
+			text := explanations[m.Text]
+			if text == "" {
+				text = "Not yet documented."
+			}
+			for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+				sb.WriteString(strings.TrimRight("    "+line, " ") + "\n")
+			}
+			sb.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
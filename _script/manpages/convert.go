@@ -0,0 +1,457 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// converter turns the block structure of the RST pages into roff. It
+// handles the subset of RST used by the pages shipped as man pages;
+// tables and images are left out.
+type converter struct {
+	root     string // of the docs
+	showURLs bool
+	out      strings.Builder
+	// level is the section level of the last heading written, so that
+	// documents pulled in by a toctree nest below it.
+	level int
+	// para starts a paragraph: .PP, or an untagged .IP within list
+	// items. The first paragraph of an item follows the item's tag
+	// directly.
+	para      string
+	inItemTag bool
+}
+
+// docState tracks the heading underline characters of a document, in
+// the order they appear, which gives their levels.
+type docState struct {
+	dir     string
+	offset  int
+	titles  []rune
+	skipped bool // the document title
+}
+
+func (d *docState) headingLevel(r rune) int {
+	for i, t := range d.titles {
+		if t == r {
+			return i + 1 + d.offset
+		}
+	}
+	d.titles = append(d.titles, r)
+	return len(d.titles) + d.offset
+}
+
+// document converts a document (path relative to the docs root, without
+// the .rst extension) with its headings nested offset levels down.
+func (c *converter) document(name string, offset int) error {
+	bs, err := os.ReadFile(filepath.Join(c.root, filepath.FromSlash(name)+".rst"))
+	if err != nil {
+		return err
+	}
+	d := &docState{dir: filepath.Dir(filepath.FromSlash(name)), offset: offset}
+	return c.blocks(splitLines(string(bs)), d)
+}
+
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\t", "        ")
+	return strings.Split(s, "\n")
+}
+
+var (
+	directiveExp = regexp.MustCompile(`^\.\.\s+([a-zA-Z0-9_:-]+)::\s*(.*)$`)
+	bulletExp    = regexp.MustCompile(`^([-*+])( +)\S`)
+	enumExp      = regexp.MustCompile(`^(\d+|#)\.( +)\S`)
+	optionExp    = regexp.MustCompile(`^:([a-zA-Z-]+):\s*(.*)$`)
+)
+
+func (c *converter) write(format string, args ...any) {
+	fmt.Fprintf(&c.out, format, args...)
+}
+
+// blocks converts a sequence of dedented lines.
+func (c *converter) blocks(lines []string, d *docState) error {
+	for i := 0; i < len(lines); {
+		l := lines[i]
+		trimmed := strings.TrimSpace(l)
+		switch {
+		case trimmed == "":
+			i++
+
+		case indent(l) > 0:
+			// A block quote.
+			block, n := indented(lines[i:])
+			c.write(".RS 4\n")
+			if err := c.blocks(block, d); err != nil {
+				return err
+			}
+			c.write(".RE\n")
+			i += n
+
+		case i+1 < len(lines) && isUnderline(lines[i+1]) && !isUnderline(l) &&
+			utf8.RuneCountInString(strings.TrimSpace(lines[i+1])) >= utf8.RuneCountInString(trimmed):
+			r, _ := utf8.DecodeRuneInString(strings.TrimSpace(lines[i+1]))
+			c.heading(trimmed, d.headingLevel(r))
+			i += 2
+
+		case isUnderline(l) && i+2 < len(lines) && isUnderline(lines[i+2]):
+			// An overlined title; the overline gives it its own level.
+			r, _ := utf8.DecodeRuneInString(trimmed)
+			c.heading(strings.TrimSpace(lines[i+1]), d.headingLevel(r+0x10000))
+			i += 3
+
+		case strings.HasPrefix(l, ".. "):
+			block, n := indented(lines[i+1:])
+			if m := directiveExp.FindStringSubmatch(l); m != nil {
+				if err := c.directive(m[1], m[2], block, d); err != nil {
+					return err
+				}
+			}
+			// Anything else is a comment or target.
+			i += 1 + n
+
+		case bulletExp.MatchString(l) || enumExp.MatchString(l):
+			marker, width := listMarker(l)
+			item := []string{l[width:]}
+			j := i + 1
+			for j < len(lines) && (strings.TrimSpace(lines[j]) == "" || indent(lines[j]) >= width) {
+				item = append(item, dedent(lines[j], width))
+				j++
+			}
+			c.write(".IP %s %d\n", marker, width)
+			para := c.para
+			c.para, c.inItemTag = fmt.Sprintf(`.IP "" %d`, width), true
+			err := c.blocks(trimTrailing(item), d)
+			c.para, c.inItemTag = para, false
+			if err != nil {
+				return err
+			}
+			i = j
+
+		default:
+			j := i
+			for j < len(lines) && strings.TrimSpace(lines[j]) != "" && indent(lines[j]) == 0 {
+				j++
+			}
+			if j == i+1 && j < len(lines) && indent(lines[j]) > 0 && !strings.HasSuffix(trimmed, "::") {
+				// A definition list item.
+				block, n := indented(lines[j:])
+				c.write(".PP\n%s\n.RS 4\n", line(inline(trimmed, c.showURLs)))
+				if err := c.blocks(block, d); err != nil {
+					return err
+				}
+				c.write(".RE\n")
+				i = j + n
+				continue
+			}
+			text := strings.Join(trimAll(lines[i:j]), " ")
+			literal := strings.HasSuffix(text, "::")
+			switch {
+			case text == "::":
+				text = ""
+			case strings.HasSuffix(text, " ::"):
+				text = strings.TrimSuffix(text, " ::")
+			case literal:
+				text = strings.TrimSuffix(text, ":")
+			}
+			if text != "" {
+				c.paragraph(inline(text, c.showURLs))
+			}
+			i = j
+			if literal {
+				for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+					i++
+				}
+				block, n := indented(lines[i:])
+				c.literal(block)
+				i += n
+			}
+		}
+	}
+	return nil
+}
+
+func (c *converter) paragraph(text string) {
+	switch {
+	case c.inItemTag:
+		c.inItemTag = false
+	case c.para != "":
+		c.write("%s\n", c.para)
+	default:
+		c.write(".PP\n")
+	}
+	c.write("%s\n", line(text))
+}
+
+func (c *converter) heading(title string, level int) {
+	c.level = level
+	switch {
+	case level <= 1:
+		// The document title, given in the NAME section.
+	case level == 2:
+		c.write(".SH %s\n", strings.ToUpper(plain(title)))
+	default:
+		c.write(".SS %s\n", plain(title))
+	}
+}
+
+func (c *converter) literal(lines []string) {
+	c.inItemTag = false
+	c.write(".RS 4\n.nf\n")
+	for _, l := range lines {
+		c.write("%s\n", line(escape(l)))
+	}
+	c.write(".fi\n.RE\n")
+}
+
+var admonitions = map[string]string{
+	"note":      "Note",
+	"warning":   "Warning",
+	"tip":       "Tip",
+	"important": "Important",
+	"caution":   "Caution",
+	"attention": "Attention",
+	"hint":      "Hint",
+	"danger":    "Danger",
+	"seealso":   "See also",
+}
+
+var versionNotes = map[string]string{
+	"versionadded":   "New in version %s.",
+	"versionchanged": "Changed in version %s.",
+	"deprecated":     "Deprecated since version %s.",
+}
+
+func (c *converter) directive(name, args string, block []string, d *docState) error {
+	opts, content := directiveOptions(block)
+	if name != "include" && name != "toctree" {
+		c.inItemTag = false
+	}
+	switch name {
+	case "option", "cmdoption", "describe", "envvar", "stconf:option":
+		c.write(".PP\n\\fB%s\\fP\n.RS 4\n", line(escape(args)))
+		if m, ok := opts["mandatory"]; ok {
+			if m == "" {
+				c.write(".PP\n(mandatory)\n")
+			} else {
+				c.write(".PP\n%s\n", line("(mandatory: "+inline(m, c.showURLs)+")"))
+			}
+		}
+		if err := c.blocks(content, d); err != nil {
+			return err
+		}
+		c.write(".RE\n")
+
+	case "code-block", "code", "sourcecode", "parsed-literal":
+		c.literal(content)
+
+	case "include":
+		sub, err := c.resolve(args, d)
+		if err != nil {
+			return err
+		}
+		bs, err := os.ReadFile(sub)
+		if err != nil {
+			return err
+		}
+		return c.blocks(splitLines(string(bs)), d)
+
+	case "literalinclude":
+		sub, err := c.resolve(args, d)
+		if err != nil {
+			return err
+		}
+		bs, err := os.ReadFile(sub)
+		if err != nil {
+			return err
+		}
+		c.literal(splitLines(strings.TrimRight(string(bs), "\n")))
+
+	case "toctree":
+		return c.toctree(opts, content, d)
+
+	case "rubric":
+		c.write(".PP\n\\fB%s\\fP\n", line(inline(args, c.showURLs)))
+
+	default:
+		if title, ok := admonitions[name]; ok {
+			c.write(".PP\n\\fB%s:\\fP\n.RS 4\n", title)
+			if args != "" {
+				content = append([]string{args, ""}, content...)
+			}
+			if err := c.blocks(content, d); err != nil {
+				return err
+			}
+			c.write(".RE\n")
+			return nil
+		}
+		if format, ok := versionNotes[name]; ok {
+			c.write(".PP\n%s\n", line(escape(fmt.Sprintf(format, strings.TrimPrefix(args, "v")))))
+			if len(content) > 0 {
+				c.write(".RS 4\n")
+				if err := c.blocks(content, d); err != nil {
+					return err
+				}
+				c.write(".RE\n")
+			}
+		}
+		// Images, tables, roles and the like are left out.
+	}
+	return nil
+}
+
+// toctree includes the listed documents as subsections.
+func (c *converter) toctree(opts map[string]string, content []string, d *docState) error {
+	_, glob := opts["glob"]
+	level := c.level
+	for _, entry := range content {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if m := targetExp.FindStringSubmatch(entry); m != nil {
+			entry = m[2]
+		}
+		entry = strings.TrimSuffix(entry, ".rst")
+		var names []string
+		if glob && strings.ContainsAny(entry, "*?[") {
+			matches, err := filepath.Glob(filepath.Join(c.root, d.dir, filepath.FromSlash(entry)+".rst"))
+			if err != nil {
+				return err
+			}
+			sort.Strings(matches)
+			for _, m := range matches {
+				rel, err := filepath.Rel(c.root, m)
+				if err != nil {
+					return err
+				}
+				names = append(names, strings.TrimSuffix(filepath.ToSlash(rel), ".rst"))
+			}
+		} else if strings.HasPrefix(entry, "/") {
+			names = []string{strings.TrimPrefix(entry, "/")}
+		} else {
+			names = []string{filepath.ToSlash(filepath.Join(d.dir, filepath.FromSlash(entry)))}
+		}
+		for _, name := range names {
+			if err := c.document(name, level); err != nil {
+				return err
+			}
+		}
+	}
+	c.level = level
+	return nil
+}
+
+// resolve returns the file an include refers to: relative to the
+// including document, or to the docs root if absolute.
+func (c *converter) resolve(arg string, d *docState) (string, error) {
+	if arg == "" {
+		return "", fmt.Errorf("include without a file name")
+	}
+	if strings.HasPrefix(arg, "/") {
+		return filepath.Join(c.root, filepath.FromSlash(arg)), nil
+	}
+	return filepath.Join(c.root, d.dir, filepath.FromSlash(arg)), nil
+}
+
+// directiveOptions splits a directive body into its field list options
+// and the content.
+func directiveOptions(block []string) (map[string]string, []string) {
+	opts := make(map[string]string)
+	i := 0
+	for ; i < len(block); i++ {
+		m := optionExp.FindStringSubmatch(strings.TrimSpace(block[i]))
+		if m == nil {
+			break
+		}
+		opts[m[1]] = m[2]
+	}
+	for i < len(block) && strings.TrimSpace(block[i]) == "" {
+		i++
+	}
+	return opts, block[i:]
+}
+
+// indented returns the indented block at the start of the lines, dedented,
+// and the number of lines it spans.
+func indented(lines []string) ([]string, int) {
+	n := 0
+	minIndent := -1
+	for n < len(lines) {
+		l := lines[n]
+		if strings.TrimSpace(l) != "" {
+			in := indent(l)
+			if in == 0 {
+				break
+			}
+			if minIndent < 0 || in < minIndent {
+				minIndent = in
+			}
+		}
+		n++
+	}
+	if minIndent < 0 {
+		// Only blank lines.
+		return nil, n
+	}
+	block := make([]string, n)
+	for i := range block {
+		block[i] = dedent(lines[i], minIndent)
+	}
+	return trimTrailing(block), n
+}
+
+func indent(l string) int {
+	return len(l) - len(strings.TrimLeft(l, " "))
+}
+
+func dedent(l string, n int) string {
+	if indent(l) < n {
+		return strings.TrimLeft(l, " ")
+	}
+	return l[n:]
+}
+
+func trimTrailing(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func trimAll(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.TrimSpace(l)
+	}
+	return out
+}
+
+func isUnderline(l string) bool {
+	l = strings.TrimSpace(l)
+	if len(l) < 3 {
+		return false
+	}
+	return strings.Count(l, l[:1]) == len(l) && strings.ContainsAny(l[:1], "=-~^\"'`#*+_.:")
+}
+
+// listMarker returns the roff tag for a list item and the width of its
+// marker.
+func listMarker(l string) (string, int) {
+	if m := bulletExp.FindStringSubmatch(l); m != nil {
+		return `\(bu`, len(m[1]) + len(m[2])
+	}
+	m := enumExp.FindStringSubmatch(l)
+	return `"` + m[1] + `."`, len(m[1]) + 1 + len(m[2])
+}
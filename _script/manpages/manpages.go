@@ -0,0 +1,93 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./manpages -version v1.27.0 -out ../_build/man
+//
+// Converts the docs pages listed below into the man pages shipped with
+// the releases, one file per page named after it and its manual section.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type manPage struct {
+	Source      string // document, relative to the docs root
+	Name        string
+	Description string
+	Section     int
+}
+
+var manPages = []manPage{
+	{"users/syncthing", "syncthing", "Syncthing", 1},
+	{"users/stdiscosrv", "stdiscosrv", "Syncthing Discovery Server", 1},
+	{"users/strelaysrv", "strelaysrv", "Syncthing Relay Server", 1},
+	{"users/config", "syncthing-config", "Syncthing Configuration", 5},
+	{"users/ignoring", "syncthing-stignore", "Prevent files from being synchronized to other nodes", 5},
+	{"dev/rest", "syncthing-rest-api", "REST API", 7},
+	{"dev/events", "syncthing-event-api", "Event API", 7},
+	{"dev/device-ids", "syncthing-device-ids", "Understanding Device IDs", 7},
+	{"users/security", "syncthing-security", "Security Principles", 7},
+	{"users/firewall", "syncthing-networking", "Firewall Setup", 7},
+	{"users/versioning", "syncthing-versioning", "Keep automatic backups of deleted files by other nodes", 7},
+	{"users/faq", "syncthing-faq", "Frequently Asked Questions", 7},
+	{"specs/bep-v1", "syncthing-bep", "Block Exchange Protocol v1", 7},
+	{"specs/localdisco-v4", "syncthing-localdisco", "Local Discovery Protocol v4", 7},
+	{"specs/globaldisco-v3", "syncthing-globaldisco", "Global Discovery Protocol v3", 7},
+	{"specs/relay-v1", "syncthing-relay", "Relay Protocol v1", 7},
+}
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Docs root directory")
+	out := flag.String("out", "../_build/man", "Directory to write the man pages to")
+	version := flag.String("version", "", "Syncthing version for the page footers")
+	only := flag.String("page", "", "Only write the named page")
+	showURLs := flag.Bool("urls", true, "Show link URLs after the link text")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalln(err)
+	}
+	date := buildDate().Format("2006-01-02")
+	for _, p := range manPages {
+		if *only != "" && p.Name != *only {
+			continue
+		}
+		c := &converter{root: *root, showURLs: *showURLs}
+		if err := c.document(p.Source, 0); err != nil {
+			log.Fatalf("%s: %v", p.Source, err)
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf(".\\\" Generated from %s.rst; do not edit.\n", p.Source))
+		sb.WriteString(fmt.Sprintf(".TH %q %q %q %q %q\n", strings.ToUpper(p.Name), strconv.Itoa(p.Section), date, *version, "Syncthing"))
+		sb.WriteString(".SH NAME\n")
+		sb.WriteString(fmt.Sprintf("%s \\- %s\n", escape(p.Name), escape(p.Description)))
+		sb.WriteString(c.out.String())
+		file := filepath.Join(*out, fmt.Sprintf("%s.%d", p.Name, p.Section))
+		if err := os.WriteFile(file, []byte(sb.String()), 0o644); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+// buildDate returns SOURCE_DATE_EPOCH if set, for reproducible builds,
+// otherwise the current time.
+func buildDate() time.Time {
+	if s := os.Getenv("SOURCE_DATE_EPOCH"); s != "" {
+		if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC()
+		}
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var roffEscaper = strings.NewReplacer(`\`, `\e`, "-", `\-`)
+
+// escape escapes text for roff. Control characters at the start of lines
+// are handled when writing the lines.
+func escape(s string) string {
+	return roffEscaper.Replace(s)
+}
+
+// inlineExp matches the inline markup handled: literals, strong and
+// emphasis, roles, hyperlink references, interpreted text and escapes.
+var inlineExp = regexp.MustCompile("``(.+?)``" +
+	`|\*\*(.+?)\*\*` +
+	`|\*([^*\s][^*]*?)\*` +
+	"|:[a-zA-Z:-]+:`([^`]+)`" +
+	"|`([^`]+)`__?" +
+	"|`([^`]+)`" +
+	`|\\(.)`)
+
+var targetExp = regexp.MustCompile(`^(.*?)\s*<([^>]+)>$`)
+
+// inline converts a paragraph of RST text to roff. Links keep their URL
+// if showURLs is set, as the Sphinx man builder did.
+func inline(s string, showURLs bool) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range inlineExp.FindAllStringSubmatchIndex(s, -1) {
+		sb.WriteString(escape(s[last:m[0]]))
+		last = m[1]
+		group := func(i int) (string, bool) {
+			if m[2*i] < 0 {
+				return "", false
+			}
+			return s[m[2*i]:m[2*i+1]], true
+		}
+		if g, ok := group(1); ok {
+			sb.WriteString(`\fB` + escape(g) + `\fP`)
+		} else if g, ok := group(2); ok {
+			sb.WriteString(`\fB` + escape(g) + `\fP`)
+		} else if g, ok := group(3); ok {
+			sb.WriteString(`\fI` + escape(g) + `\fP`)
+		} else if g, ok := group(4); ok {
+			sb.WriteString(escape(roleText(g)))
+		} else if g, ok := group(5); ok {
+			text, url := g, ""
+			if tm := targetExp.FindStringSubmatch(g); tm != nil {
+				text, url = tm[1], tm[2]
+			}
+			switch {
+			case text == "":
+				sb.WriteString(escape(url))
+			case showURLs && strings.Contains(url, "://"):
+				sb.WriteString(escape(text) + " <" + escape(url) + ">")
+			default:
+				sb.WriteString(escape(text))
+			}
+		} else if g, ok := group(6); ok {
+			sb.WriteString(`\fI` + escape(g) + `\fP`)
+		} else if g, ok := group(7); ok {
+			sb.WriteString(escape(g))
+		}
+	}
+	sb.WriteString(escape(s[last:]))
+	return sb.String()
+}
+
+// roleText returns the displayed text of a role: the explicit title, or
+// the target with a leading ~ shortening it to the last component.
+func roleText(s string) string {
+	if m := targetExp.FindStringSubmatch(s); m != nil && m[1] != "" {
+		return m[1]
+	}
+	if t, ok := strings.CutPrefix(s, "~"); ok {
+		if i := strings.LastIndexAny(t, "./"); i >= 0 {
+			return t[i+1:]
+		}
+		return t
+	}
+	return s
+}
+
+// plain returns the text without inline markup, for section titles.
+func plain(s string) string {
+	return strings.NewReplacer(`\fB`, "", `\fI`, "", `\fP`, "").Replace(inline(s, false))
+}
+
+// line protects a line of text from being taken as a roff request.
+func line(s string) string {
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		return `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,507 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// converter converts the blocks of a document. The block structure of
+// reStructuredText is indentation based, so the content of list items
+// and directives is dedented and converted recursively.
+type converter struct {
+	tree *rstdoc.Tree
+	doc  *rstdoc.Doc
+	// base is the URL of the published docs when exporting, or empty.
+	base string
+	// styles are the section adornment styles in the order they were
+	// first seen, which gives the section levels.
+	styles []string
+	// targets are the URLs of the named hyperlink targets, by normalized
+	// name, and anonymous are those of the anonymous ones, in order.
+	targets   map[string]string
+	anonymous []string
+	// footnotes counts the auto-numbered footnotes and their
+	// references.
+	footnotes, footnoteRefs int
+	// lang is the language of literal blocks, as set by the highlight
+	// directive.
+	lang string
+}
+
+func newConverter(tree *rstdoc.Tree, doc *rstdoc.Doc) *converter {
+	c := &converter{tree: tree, doc: doc, targets: make(map[string]string)}
+	for _, t := range doc.Targets {
+		switch {
+		case t.URL == "" || t.Inline:
+		case t.Anonymous:
+			c.anonymous = append(c.anonymous, t.URL)
+		default:
+			c.targets[rstdoc.NormalizeName(t.Name)] = t.URL
+		}
+	}
+	return c
+}
+
+var (
+	labelExp     = regexp.MustCompile(`^\.\. _([^:]+|` + "`[^`]+`" + `):\s*$`)
+	targetExp    = regexp.MustCompile(`^\.\. (_[^:]+|__):\s*\S`)
+	footnoteExp  = regexp.MustCompile(`^\.\. \[(#?[\w-]*|\d+)\]\s*(.*)$`)
+	directiveExp = regexp.MustCompile(`^\.\. ([\w:-]+)::\s*(.*)$`)
+	optionExp    = regexp.MustCompile(`^:([\w-]+):\s*(.*)$`)
+	bulletExp    = regexp.MustCompile(`^([-*+•])( +)\S`)
+	enumExp      = regexp.MustCompile(`^(\d+|#)\.( +)\S`)
+	simpleTblExp = regexp.MustCompile(`^=+( +=+)+$`)
+	fieldExp     = regexp.MustCompile(`^:([\w-]+):(\s.*)?$`)
+)
+
+// admonitions are the directives whose content is converted as part of
+// the page.
+var admonitions = map[string]bool{
+	"note": true, "warning": true, "seealso": true, "todo": true, "tip": true,
+	"important": true, "caution": true, "attention": true, "danger": true,
+	"hint": true, "error": true, "versionadded": true, "versionchanged": true,
+	"deprecated": true,
+}
+
+// rawContent are the directives whose content isn't markup and is kept
+// as is. The content of other directives is converted.
+var rawContent = map[string]bool{
+	"code-block": true, "code": true, "sourcecode": true, "graphviz": true,
+	"toctree": true, "csv-table": true, "raw": true, "literalinclude": true,
+}
+
+// convert converts top level lines of the document, starting with the
+// field list of document metadata, which becomes front matter.
+func (c *converter) convert(lines []string) []string {
+	var res []string
+	i := 0
+	for i < len(lines) && fieldExp.MatchString(lines[i]) {
+		i++
+	}
+	if i > 0 {
+		res = append(res, "---")
+		for _, l := range lines[:i] {
+			m := optionExp.FindStringSubmatch(l)
+			val := m[2]
+			if val == "" {
+				val = "true"
+			}
+			res = append(res, m[1]+": "+val)
+		}
+		res = append(res, "---")
+	}
+	return append(res, c.blocks(lines[i:], true)...)
+}
+
+// blocks converts a sequence of blocks. Sections are only recognized at
+// the top level.
+func (c *converter) blocks(lines []string, top bool) []string {
+	var res []string
+	blank := func() {
+		if len(res) > 0 && res[len(res)-1] != "" {
+			res = append(res, "")
+		}
+	}
+	for i := 0; i < len(lines); {
+		l := lines[i]
+		trimmed := strings.TrimSpace(l)
+		switch {
+		case trimmed == "":
+			blank()
+			i++
+
+		case top && sectionLevel(lines, i) > 0:
+			title, style, n := sectionAt(lines, i)
+			level := c.level(style)
+			blank()
+			res = append(res, strings.Repeat("#", level)+" "+c.inline(title), "")
+			i += n
+
+		case strings.HasPrefix(l, ".. "):
+			end := blockEnd(lines, i+1)
+			blank()
+			res = append(res, c.explicit(lines[i:end])...)
+			blank()
+			i = end
+
+		case strings.HasPrefix(l, "+-") || simpleTblExp.MatchString(l):
+			end := tableEnd(lines, i)
+			c.warnf("table at %q kept as reStructuredText", trimmed)
+			blank()
+			res = append(res, fenced("{eval-rst}", lines[i:end])...)
+			blank()
+			i = end
+
+		case strings.HasPrefix(l, " "):
+			// A block quote
+			end := blockEnd(lines, i)
+			ind := minIndent(lines[i:end])
+			blank()
+			for _, ql := range c.blocks(dedent(lines[i:end], ind), false) {
+				res = append(res, strings.TrimRight("> "+ql, " "))
+			}
+			blank()
+			i = end
+
+		case bulletExp.MatchString(l) || enumExp.MatchString(l):
+			marker := "- "
+			m := bulletExp.FindStringSubmatch(l)
+			if m == nil {
+				m = enumExp.FindStringSubmatch(l)
+				marker = "1. "
+			}
+			w := len(m[1]) + len(m[2])
+			end := itemEnd(lines, i, w)
+			item := append([]string{strings.Repeat(" ", w) + l[w:]}, lines[i+1:end]...)
+			for j, il := range c.blocks(dedent(item, w), false) {
+				switch {
+				case j == 0:
+					il = marker + il
+				case il != "":
+					il = strings.Repeat(" ", len(marker)) + il
+				}
+				res = append(res, il)
+			}
+			i = end
+
+		case i+1 < len(lines) && strings.HasPrefix(lines[i+1], " ") && strings.TrimSpace(lines[i+1]) != "" && !strings.HasSuffix(trimmed, "::"):
+			// A definition list item
+			end := blockEnd(lines, i+1)
+			body := dedent(lines[i+1:end], minIndent(lines[i+1:end]))
+			res = append(res, c.inline(trimmed))
+			for j, dl := range c.blocks(body, false) {
+				switch {
+				case j == 0:
+					dl = ": " + dl
+				case dl != "":
+					dl = "  " + dl
+				}
+				res = append(res, dl)
+			}
+			i = end
+
+		default:
+			end := i
+			for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+				end++
+			}
+			para := strings.Join(lines[i:end], "\n")
+			literal := strings.HasSuffix(para, "::")
+			if literal {
+				// "Text::" becomes "Text:", and a lone or separate "::"
+				// goes away.
+				para = strings.TrimSuffix(para, ":")
+				if strings.HasSuffix(para, " :") || para == ":" || strings.HasSuffix(para, "\n:") {
+					para = strings.TrimRight(strings.TrimSuffix(para, ":"), " \n")
+				}
+			}
+			if para != "" {
+				res = append(res, strings.Split(c.inline(para), "\n")...)
+			}
+			i = end
+			if literal {
+				for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+					i++
+				}
+				lend := blockEnd(lines, i)
+				blank()
+				res = append(res, fenced(c.lang, dedent(lines[i:lend], minIndent(lines[i:lend])))...)
+				i = lend
+			}
+		}
+	}
+	for len(res) > 0 && res[len(res)-1] == "" {
+		res = res[:len(res)-1]
+	}
+	return res
+}
+
+// explicit converts an explicit markup block: a label, hyperlink target,
+// footnote, directive or comment.
+func (c *converter) explicit(lines []string) []string {
+	first := lines[0]
+	if m := labelExp.FindStringSubmatch(first); m != nil {
+		return []string{"(" + strings.Trim(m[1], "`") + ")="}
+	}
+	if targetExp.MatchString(first) {
+		// Named and anonymous targets are resolved in the references.
+		return nil
+	}
+	if m := footnoteExp.FindStringSubmatch(first); m != nil {
+		label := m[1]
+		if strings.HasPrefix(label, "#") {
+			c.footnotes++
+			label = fmt.Sprint(c.footnotes)
+		}
+		text := append([]string{m[2]}, dedent(lines[1:], minIndent(lines[1:]))...)
+		var res []string
+		for j, fl := range c.blocks(text, false) {
+			switch {
+			case j == 0:
+				fl = "[^" + label + "]: " + fl
+			case fl != "":
+				fl = "    " + fl
+			}
+			res = append(res, fl)
+		}
+		return res
+	}
+	if m := directiveExp.FindStringSubmatch(first); m != nil {
+		return c.directive(m[1], m[2], lines[1:])
+	}
+	if strings.HasPrefix(first, ".. |") {
+		c.warnf("substitution definition %q kept as reStructuredText", first)
+		return fenced("{eval-rst}", lines)
+	}
+
+	// A comment
+	var res []string
+	for _, l := range append([]string{strings.TrimPrefix(first, "..")}, lines[1:]...) {
+		res = append(res, strings.TrimRight("% "+strings.TrimSpace(l), " "))
+	}
+	return res
+}
+
+// directive converts a directive with the given argument and following
+// lines.
+func (c *converter) directive(name, arg string, lines []string) []string {
+	body := dedent(lines, minIndent(lines))
+	// The argument may continue on the next lines, up to the options or
+	// a blank line.
+	for len(body) > 0 && body[0] != "" && !optionExp.MatchString(body[0]) {
+		arg = strings.TrimSpace(arg + " " + body[0])
+		body = body[1:]
+	}
+	var opts []string
+	for len(body) > 0 && optionExp.MatchString(body[0]) {
+		opts = append(opts, body[0])
+		body = body[1:]
+	}
+	for len(body) > 0 && body[0] == "" {
+		body = body[1:]
+	}
+
+	switch {
+	case name == "highlight":
+		c.lang = arg
+		return nil
+
+	case name == "role":
+		if arg == "strike" {
+			// Converted to ~~strikethrough~~ in the text.
+			return nil
+		}
+		c.warnf("role definition %q kept as reStructuredText; the role can't be used in Markdown", arg)
+		return fenced("{eval-rst}", append([]string{".. role:: " + arg}, indented(append(opts, body...))...))
+
+	case (name == "code-block" || name == "code" || name == "sourcecode") && len(opts) == 0:
+		return fenced(arg, body)
+
+	case admonitions[name] && c.base != "" && len(opts) == 0:
+		// Outside Sphinx admonitions are quotes, with the directive name
+		// as the title.
+		title := strings.ToUpper(name[:1]) + name[1:]
+		if arg != "" {
+			title += " " + arg
+		}
+		res := []string{"> **" + title + "**"}
+		if content := c.blocks(body, false); len(content) > 0 {
+			res = append(res, ">")
+			for _, l := range content {
+				res = append(res, strings.TrimRight("> "+l, " "))
+			}
+		}
+		return res
+	}
+
+	head := "{" + name + "}"
+	if arg != "" {
+		if admonitions[name] {
+			arg = c.inline(arg)
+		}
+		head += " " + arg
+	}
+	content := append([]string(nil), opts...)
+	if len(body) > 0 {
+		if len(opts) > 0 {
+			content = append(content, "")
+		}
+		if rawContent[name] {
+			content = append(content, body...)
+		} else {
+			content = append(content, c.blocks(body, false)...)
+		}
+	}
+	return fenced(head, content)
+}
+
+// level returns the Markdown heading level of a section adornment style.
+func (c *converter) level(style string) int {
+	for i, s := range c.styles {
+		if s == style {
+			return i + 1
+		}
+	}
+	c.styles = append(c.styles, style)
+	return len(c.styles)
+}
+
+func (c *converter) warnf(format string, args ...interface{}) {
+	log.Printf("%s: "+format, append([]interface{}{c.doc.Name}, args...)...)
+}
+
+// sectionLevel returns a non-zero value if there's a section title at
+// line i.
+func sectionLevel(lines []string, i int) int {
+	_, _, n := sectionAt(lines, i)
+	return n
+}
+
+// fenced returns the lines in a code fence with the given info string,
+// using a fence longer than any inside.
+func fenced(info string, lines []string) []string {
+	n := 3
+	for _, l := range lines {
+		t := strings.TrimLeft(l, " ")
+		if k := len(t) - len(strings.TrimLeft(t, "`")); k >= n {
+			n = k + 1
+		}
+	}
+	fence := strings.Repeat("`", n)
+	res := append([]string{fence + info}, lines...)
+	return append(res, fence)
+}
+
+// blockEnd returns the index of the first line from i on that isn't
+// indented, skipping blank lines.
+func blockEnd(lines []string, i int) int {
+	end := i
+	for j := i; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == "" {
+			continue
+		}
+		if !strings.HasPrefix(lines[j], " ") {
+			break
+		}
+		end = j + 1
+	}
+	return end
+}
+
+// itemEnd returns the end of the list item at line i, whose content is
+// indented by w.
+func itemEnd(lines []string, i, w int) int {
+	end := i + 1
+	for j := i + 1; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == "" {
+			continue
+		}
+		if indentOf(lines[j]) < w {
+			break
+		}
+		end = j + 1
+	}
+	return end
+}
+
+// tableEnd returns the end of a grid or simple table starting at line i.
+func tableEnd(lines []string, i int) int {
+	if strings.HasPrefix(lines[i], "+-") {
+		end := i
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+		return end
+	}
+	// A simple table ends at the border that's followed by a blank line,
+	// after at least the top and one more border.
+	borders := 0
+	for j := i; j < len(lines); j++ {
+		if simpleTblExp.MatchString(lines[j]) {
+			borders++
+			if borders >= 2 && (j+1 == len(lines) || strings.TrimSpace(lines[j+1]) == "") {
+				return j + 1
+			}
+		}
+	}
+	return len(lines)
+}
+
+func indentOf(l string) int {
+	return len(l) - len(strings.TrimLeft(l, " "))
+}
+
+func minIndent(lines []string) int {
+	ind := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if n := indentOf(l); ind < 0 || n < ind {
+			ind = n
+		}
+	}
+	if ind < 0 {
+		return 0
+	}
+	return ind
+}
+
+// dedent removes n columns of indentation and the trailing blank lines.
+func dedent(lines []string, n int) []string {
+	res := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if len(l) >= n {
+			l = l[n:]
+		} else {
+			l = strings.TrimLeft(l, " ")
+		}
+		res = append(res, l)
+	}
+	for len(res) > 0 && strings.TrimSpace(res[len(res)-1]) == "" {
+		res = res[:len(res)-1]
+	}
+	return res
+}
+
+func indented(lines []string) []string {
+	res := make([]string, len(lines))
+	for i, l := range lines {
+		if l != "" {
+			l = "   " + l
+		}
+		res[i] = l
+	}
+	return res
+}
+
+// labelTarget returns the document and anchor a label refers to, and the
+// title of what it refers to, if it's a section.
+func (c *converter) labelTarget(label string) (doc *rstdoc.Doc, anchor, title string, ok bool) {
+	label = rstdoc.NormalizeName(label)
+	docs := append([]*rstdoc.Doc(nil), c.tree.Docs...)
+	sort.Slice(docs, func(a, b int) bool { return docs[a].Name < docs[b].Name })
+	for _, d := range docs {
+		for _, t := range d.Targets {
+			if t.URL != "" || t.Anonymous || rstdoc.NormalizeName(t.Name) != label {
+				continue
+			}
+			if t.Section != nil {
+				return d, t.Section.ID(), t.Section.Title, true
+			}
+			return d, rstdoc.MakeID(t.Name), "", true
+		}
+	}
+	return nil, "", "", false
+}
@@ -0,0 +1,189 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// inlineExp matches the inline markup that differs between
+// reStructuredText and Markdown. Emphasis and strong emphasis are the
+// same in both.
+var inlineExp = regexp.MustCompile("(?s)" +
+	"``(.+?)``" + // 1: literal
+	"|:([\\w:+.-]+):`((?:[^`\\\\]|\\\\.)+)`" + // 2, 3: role
+	"|`([^`]+)`(__?)" + // 4, 5: hyperlink reference
+	"|`([^`]+)`" + // 6: default role
+	"|\\[(#[\\w-]*|\\d+)\\]_" + // 7: footnote reference
+	"|\\b(\\w[\\w.-]*?)(__?)\\b" + // 8, 9: simple hyperlink reference
+	"|\\\\ ") // escaped space
+
+var embeddedExp = regexp.MustCompile(`(?s)^(.*?)\s*<([^<>]+)>$`)
+
+// inline converts the inline markup in text, which may span lines.
+func (c *converter) inline(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range inlineExp.FindAllStringSubmatchIndex(text, -1) {
+		sb.WriteString(text[last:m[0]])
+		last = m[1]
+		group := func(n int) string {
+			if m[2*n] < 0 {
+				return ""
+			}
+			return text[m[2*n]:m[2*n+1]]
+		}
+		switch {
+		case m[2] >= 0:
+			sb.WriteString(literal(group(1)))
+		case m[4] >= 0:
+			sb.WriteString(c.role(group(2), group(3)))
+		case m[8] >= 0:
+			sb.WriteString(c.reference(group(4), group(5) == "__"))
+		case m[12] >= 0:
+			sb.WriteString(literal(group(6)))
+		case m[14] >= 0:
+			label := group(7)
+			if strings.HasPrefix(label, "#") {
+				c.footnoteRefs++
+				label = fmt.Sprint(c.footnoteRefs)
+			}
+			sb.WriteString("[^" + label + "]")
+		case m[16] >= 0:
+			name := group(8)
+			url, ok := c.targets[rstdoc.NormalizeName(name)]
+			switch {
+			case group(9) == "__" && len(c.anonymous) > 0:
+				url, c.anonymous = c.anonymous[0], c.anonymous[1:]
+			case !ok:
+				// Not a reference after all, like a word ending in
+				// an underscore.
+				sb.WriteString(text[m[0]:m[1]])
+				continue
+			}
+			sb.WriteString("[" + name + "](" + url + ")")
+		}
+		// An escaped space is dropped.
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// reference converts a hyperlink reference, with an embedded URL or to a
+// target.
+func (c *converter) reference(ref string, anonymous bool) string {
+	text, url := ref, ""
+	if m := embeddedExp.FindStringSubmatch(ref); m != nil {
+		text, url = m[1], m[2]
+		if strings.HasSuffix(url, "_") {
+			// An embedded reference to a named target
+			url = c.targetURL(strings.TrimSuffix(url, "_"))
+		}
+		if text == "" {
+			text = url
+		}
+	} else if anonymous && len(c.anonymous) > 0 {
+		url, c.anonymous = c.anonymous[0], c.anonymous[1:]
+	} else {
+		url = c.targetURL(ref)
+	}
+	return "[" + c.inline(text) + "](" + strings.Join(strings.Fields(url), "") + ")"
+}
+
+// targetURL returns the URL of a named target, or a link to a section on
+// the same page.
+func (c *converter) targetURL(name string) string {
+	if url, ok := c.targets[rstdoc.NormalizeName(name)]; ok {
+		return url
+	}
+	return "#" + rstdoc.MakeID(name)
+}
+
+// role converts an interpreted text role. Cross references stay roles,
+// which MyST supports, unless exporting, when they become links.
+func (c *converter) role(name, content string) string {
+	if name == "strike" {
+		return "~~" + content + "~~"
+	}
+	if c.base == "" {
+		return "{" + name + "}`" + content + "`"
+	}
+
+	text, target := content, content
+	if m := embeddedExp.FindStringSubmatch(content); m != nil {
+		text, target = m[1], m[2]
+	}
+	explicit := text != target
+	switch name {
+	case "doc":
+		docName := target
+		if strings.HasPrefix(docName, "/") {
+			docName = strings.TrimPrefix(docName, "/")
+		} else {
+			docName = path.Join(c.doc.Dir(), docName)
+		}
+		if d := c.tree.Doc(docName); d != nil && !explicit && len(d.Sections) > 0 {
+			text = d.Sections[0].Title
+		}
+		return "[" + text + "](" + docLink(c.base, docName) + ")"
+
+	case "ref":
+		d, anchor, title, ok := c.labelTarget(target)
+		if !ok {
+			c.warnf("unknown label %q", target)
+			return text
+		}
+		if !explicit && title != "" {
+			text = title
+		}
+		return "[" + text + "](" + docLink(c.base, d.Name) + "#" + anchor + ")"
+
+	case "opt", "stconf:opt":
+		url := docLink(c.base, "users/config") + "#config-option-" + strings.ToLower(target)
+		if !explicit {
+			return "[" + literal(text) + "](" + url + ")"
+		}
+		return "[" + text + "](" + url + ")"
+
+	case "issue":
+		return "[issue #" + target + "](https://github.com/syncthing/syncthing/issues/" + target + ")"
+	case "user":
+		return "[@" + target + "](https://github.com/" + target + ")"
+	case "commit":
+		short := target
+		if len(short) > 8 {
+			short = short[:8]
+		}
+		return "[" + short + "](https://github.com/syncthing/syncthing/commit/" + target + ")"
+	case "rfc":
+		return "[RFC " + target + "](https://datatracker.ietf.org/doc/html/rfc" + target + ")"
+	case "abbr":
+		if i := strings.Index(content, " ("); i > 0 {
+			return content[:i]
+		}
+		return content
+
+	case "option", "cmdoption", "file", "code", "command", "program", "envvar", "manpage", "kbd", "samp":
+		return literal(text)
+	}
+	c.warnf("role %q kept as literal text", name)
+	return literal(text)
+}
+
+// literal returns inline code, using a longer delimiter if the text
+// contains backticks.
+func literal(s string) string {
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return "`` " + s + " ``"
+}
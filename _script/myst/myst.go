@@ -0,0 +1,205 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./myst [-section label] [-base https://docs.syncthing.net/] users/faq
+//
+// Converts a page from reStructuredText to MyST Markdown, for moving
+// pages over gradually and for reusing parts of the docs elsewhere. The
+// page is given by its document name, and included files are converted
+// in place.
+//
+// The markup we use is converted to its MyST equivalent: sections,
+// labels, lists, literal and code blocks, hyperlinks, footnotes, roles,
+// and directives, with admonitions and the directives that take content
+// converted recursively. The output uses the deflist, fieldlist and
+// strikethrough extensions. Anything else, such as tables, is kept as is
+// in an eval-rst block, with a warning.
+//
+// With -section, only the section with the given label or title is
+// converted, e.g. a single FAQ entry. With -base, cross references to
+// other pages are turned into plain links to the published docs under
+// the given URL, so the output can be pasted into the forum or a wiki
+// where the Sphinx roles don't work.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	section := flag.String("section", "", "Convert only the section with this label or title")
+	base := flag.String("base", "", "Turn cross references into links to the docs published here")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatalln("Usage: myst [flags] <document name>")
+	}
+	name := strings.TrimSuffix(flag.Arg(0), ".rst")
+
+	tree, err := rstdoc.Load(*root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	doc := tree.Doc(name)
+	if doc == nil {
+		log.Fatalf("%s: no such document", name)
+	}
+
+	lines, err := readExpanded(*root, doc.File)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *section != "" {
+		lines = sectionLines(lines, *section)
+		if lines == nil {
+			log.Fatalf("%s: no section %q", name, *section)
+		}
+	}
+
+	c := newConverter(tree, doc)
+	if *base != "" {
+		c.base = strings.TrimSuffix(*base, "/") + "/"
+	}
+	for _, l := range c.convert(lines) {
+		fmt.Println(l)
+	}
+}
+
+var includeExp = regexp.MustCompile(`^(\s*)\.\. include:: (\S+)\s*$`)
+
+// readExpanded returns the lines of the file, relative to the root, with
+// include directives replaced by the lines of the included files. Includes
+// with options, which only take part of a file, are left alone.
+func readExpanded(root, file string) ([]string, error) {
+	bs, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(bs), "\r\n", "\n"), "\n"), "\n")
+	var res []string
+	for i, l := range lines {
+		m := includeExp.FindStringSubmatch(l)
+		if m == nil || i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), ":") {
+			res = append(res, l)
+			continue
+		}
+		inc, err := readExpanded(root, rstdoc.IncludePath(file, m[2]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, i+1, err)
+		}
+		for _, il := range inc {
+			if il != "" {
+				il = m[1] + il
+			}
+			res = append(res, il)
+		}
+	}
+	return res, nil
+}
+
+// sectionLines returns the lines of the section with the given label or
+// title, including its labels, up to the next section at the same or a
+// higher level. It returns nil if there's no such section.
+func sectionLines(lines []string, want string) []string {
+	want = rstdoc.NormalizeName(want)
+
+	type section struct {
+		// start is the first line of the section, including the labels
+		// directly before it.
+		start, level int
+		title        string
+		labels       []string
+	}
+	var styles []string
+	var sections []section
+	var labels []string
+	labelStart := -1
+	for i := 0; i < len(lines); i++ {
+		if m := labelExp.FindStringSubmatch(lines[i]); m != nil {
+			if labelStart < 0 {
+				labelStart = i
+			}
+			labels = append(labels, m[1])
+			continue
+		}
+		title, style, n := sectionAt(lines, i)
+		if n == 0 {
+			if strings.TrimSpace(lines[i]) != "" {
+				labels, labelStart = nil, -1
+			}
+			continue
+		}
+		level := len(styles)
+		for j, st := range styles {
+			if st == style {
+				level = j
+			}
+		}
+		if level == len(styles) {
+			styles = append(styles, style)
+		}
+		start := i
+		if labelStart >= 0 {
+			start = labelStart
+		}
+		sections = append(sections, section{start: start, level: level, title: title, labels: labels})
+		labels, labelStart = nil, -1
+		i += n - 1
+	}
+
+	for i, sec := range sections {
+		match := rstdoc.NormalizeName(sec.title) == want
+		for _, l := range sec.labels {
+			match = match || rstdoc.NormalizeName(l) == want
+		}
+		if !match {
+			continue
+		}
+		end := len(lines)
+		for _, next := range sections[i+1:] {
+			if next.level <= sec.level {
+				end = next.start
+				break
+			}
+		}
+		return lines[sec.start:end]
+	}
+	return nil
+}
+
+// sectionAt returns the title and adornment style of a section title at
+// line i, and the number of lines it takes, or zero if there's none.
+func sectionAt(lines []string, i int) (title, style string, n int) {
+	if i+2 < len(lines) && isAdornment(lines[i]) && isAdornment(lines[i+2]) && lines[i] == lines[i+2] && strings.TrimSpace(lines[i+1]) != "" {
+		return strings.TrimSpace(lines[i+1]), "over" + lines[i][:1], 3
+	}
+	if i+1 < len(lines) && strings.TrimSpace(lines[i]) != "" && !strings.HasPrefix(lines[i], " ") && isAdornment(lines[i+1]) && len(lines[i+1]) >= len(strings.TrimSpace(lines[i])) && !isAdornment(lines[i]) {
+		return strings.TrimSpace(lines[i]), lines[i+1][:1], 2
+	}
+	return "", "", 0
+}
+
+func isAdornment(l string) bool {
+	if len(l) < 3 || strings.HasPrefix(l, " ") {
+		return false
+	}
+	return strings.Count(l, l[:1]) == len(l) && strings.ContainsAny(l[:1], "=-~^\"'`#*+_.:")
+}
+
+// docLink returns the published URL of a document, given the base URL.
+func docLink(base, name string) string {
+	return base + path.Clean(name) + ".html"
+}
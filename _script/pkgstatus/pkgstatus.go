@@ -0,0 +1,237 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./pkgstatus > ../includes/packaging-status.rst
+//
+// This script queries Repology for the packaging status of Syncthing across
+// distributions and prints it as an RST table for the installation docs.
+// The answer is cached for -cache-ttl (see internal/cache), as Repology
+// asks API users not to poll it.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/cache"
+	"syncthing.net/docs/internal/httpclient"
+	"syncthing.net/docs/internal/rst"
+)
+
+// A package as reported by the Repology project API.
+type repoPackage struct {
+	Repo        string `json:"repo"`
+	Subrepo     string `json:"subrepo"`
+	BinName     string `json:"binname"`
+	VisibleName string `json:"visiblename"`
+	Version     string `json:"version"`
+	Status      string `json:"status"`
+}
+
+// Repology repository name prefixes and how to present them.
+var repoNames = map[string]string{
+	"alpine":       "Alpine Linux",
+	"altlinux":     "ALT Linux",
+	"arch":         "Arch Linux",
+	"aur":          "Arch User Repository",
+	"centos":       "CentOS",
+	"chocolatey":   "Chocolatey",
+	"conda":        "conda-forge",
+	"debian":       "Debian",
+	"entware":      "Entware",
+	"epel":         "EPEL",
+	"fedora":       "Fedora",
+	"freebsd":      "FreeBSD",
+	"freshports":   "FreshPorts",
+	"gentoo":       "Gentoo",
+	"guix":         "GNU Guix",
+	"homebrew":     "Homebrew",
+	"kaos":         "KaOS",
+	"linuxmint":    "Linux Mint",
+	"macports":     "MacPorts",
+	"mageia":       "Mageia",
+	"manjaro":      "Manjaro",
+	"netbsd":       "NetBSD",
+	"nix":          "nixpkgs",
+	"openbsd":      "OpenBSD",
+	"openmandriva": "OpenMandriva",
+	"opensuse":     "openSUSE",
+	"openwrt":      "OpenWrt",
+	"pclinuxos":    "PCLinuxOS",
+	"pkgsrc":       "pkgsrc",
+	"raspbian":     "Raspbian",
+	"rosa":         "ROSA",
+	"scoop":        "Scoop",
+	"slackbuilds":  "SlackBuilds",
+	"solus":        "Solus",
+	"termux":       "Termux",
+	"ubuntu":       "Ubuntu",
+	"void":         "Void Linux",
+	"winget":       "winget",
+}
+
+// mainPackages are the names of the packages of Syncthing itself, as
+// opposed to discosrv, relaysrv and the third party frontends listed
+// under the same project.
+var mainPackages = map[string]bool{
+	"syncthing":     true,
+	"syncthing-bin": true,
+}
+
+func main() {
+	project := flag.String("project", "syncthing", "Repology project name")
+	apiURL := flag.String("api", "https://repology.org/api/v1/project/", "Repology project API base URL")
+	ttl := flag.Duration("cache-ttl", time.Hour, "How long to cache the Repology answer for")
+	flag.Parse()
+
+	pkgs, err := cachedPackages(cache.Default(), *apiURL+*project, *ttl)
+	if err != nil {
+		log.Fatalln("Querying Repology:", err)
+	}
+	if err := printTable(*project, pkgs); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func cachedPackages(c *cache.Cache, url string, ttl time.Duration) ([]repoPackage, error) {
+	var pkgs []repoPackage
+	if c.GetJSON(cache.API, url, &pkgs) {
+		return pkgs, nil
+	}
+	pkgs, err := getPackages(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.PutJSON(cache.API, url, pkgs, ttl); err != nil {
+		log.Println(err)
+	}
+	if err := c.Trim(); err != nil {
+		log.Println(err)
+	}
+	return pkgs, nil
+}
+
+func getPackages(url string) ([]repoPackage, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Repology asks API users to identify themselves.
+	req.Header.Set("User-Agent", "syncthing-docs-pkgstatus (+https://github.com/syncthing/docs)")
+	resp, err := httpclient.Default.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	var pkgs []repoPackage
+	if err := json.NewDecoder(resp.Body).Decode(&pkgs); err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+func printTable(project string, pkgs []repoPackage) error {
+	// Keep one entry per repository and package name; Repology lists the
+	// same package once per subrepository (e.g. updates, backports) and
+	// the most interesting one is the newest.
+	type key struct{ repo, name string }
+	best := make(map[key]repoPackage)
+	for _, p := range pkgs {
+		if p.Status == "ignored" || p.Status == "rolling" && p.Version == "" {
+			continue
+		}
+		name := p.BinName
+		if name == "" {
+			name = p.VisibleName
+		}
+		if !mainPackages[name] {
+			continue
+		}
+		k := key{p.Repo, name}
+		if prev, ok := best[k]; !ok || statusRank(p.Status) < statusRank(prev.Status) {
+			p.BinName = name
+			best[k] = p
+		}
+	}
+
+	var sorted []repoPackage
+	for _, p := range best {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].Repo != sorted[b].Repo {
+			return sorted[a].Repo < sorted[b].Repo
+		}
+		return sorted[a].BinName < sorted[b].BinName
+	})
+
+	t := rst.Table{
+		Title:  "Syncthing packaging status",
+		Header: []string{"Distribution", "Repository", "Package", "Version", "Up to date"},
+		Widths: []int{25, 20, 20, 15, 10},
+	}
+	for _, p := range sorted {
+		distro, release := repoName(p.Repo)
+		upToDate := "no"
+		switch p.Status {
+		case "newest", "unique", "devel":
+			upToDate = "yes"
+		case "legacy":
+			upToDate = "legacy"
+		}
+		t.Rows = append(t.Rows, []string{
+			rst.Escape(distro),
+			rst.Escape(strings.TrimSpace(release + " " + p.Subrepo)),
+			rst.Literal(p.BinName),
+			rst.Escape(p.Version),
+			upToDate,
+		})
+	}
+
+	fmt.Println(".. This file is generated by _script/pkgstatus; do not edit.")
+	fmt.Println()
+	fmt.Printf("Data from %s.\n", rst.Link("Repology", "https://repology.org/project/"+url.PathEscape(project)+"/versions"))
+	fmt.Println()
+	_, err := t.WriteTo(os.Stdout)
+	return err
+}
+
+// repoName splits a Repology repository name like "debian_12" into a
+// display name and release.
+func repoName(repo string) (string, string) {
+	base, release, _ := strings.Cut(repo, "_")
+	if name, ok := repoNames[base]; ok {
+		return name, strings.ReplaceAll(release, "_", " ")
+	}
+	return repo, ""
+}
+
+// statusRank orders Repology statuses from most to least current.
+func statusRank(status string) int {
+	switch status {
+	case "newest", "unique":
+		return 0
+	case "devel":
+		return 1
+	case "outdated":
+		return 2
+	case "legacy":
+		return 3
+	default:
+		return 4
+	}
+}
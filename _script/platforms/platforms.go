@@ -0,0 +1,209 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./platforms [-update] > ../includes/platform-support.rst
+//
+// Writes the platform support matrix: for each Syncthing release, the
+// oldest Windows, macOS and Linux kernel versions it runs on, and the
+// operating systems and architectures it was released for. The minimum
+// versions follow from the Go version the release was built with, from
+// the versions table, and Go's requirements; platform-overrides.csv
+// corrects them where Syncthing differs. The architectures are the
+// platforms of the release assets, kept in release-platforms.csv; with
+// -update, the releases missing from it are added from GitHub first.
+// Consecutive releases with the same support share a row.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/platform"
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/versions"
+)
+
+// support is what a release runs on.
+type support struct {
+	goMinor               int
+	windows, macOS, linux string
+	// platforms are the architectures released for, by operating
+	// system.
+	platforms map[string][]string
+}
+
+func (s support) key() string {
+	return fmt.Sprint(s.windows, s.macOS, s.linux, s.platformList())
+}
+
+func (s support) platformList() []string {
+	var res []string
+	for _, os := range sortedKeys(s.platforms) {
+		res = append(res, os+": "+strings.Join(s.platforms[os], ", "))
+	}
+	return res
+}
+
+func main() {
+	log.SetFlags(0)
+	versionsFile := flag.String("versions", "../users/releases.csv", "Versions table of the releases page")
+	platformsFile := flag.String("platforms", platform.PlatformsFile, "Release asset platforms")
+	overridesFile := flag.String("overrides", platform.OverridesFile, "Curated overrides")
+	update := flag.Bool("update", false, "Add the platforms of releases missing from the platforms file from GitHub")
+	repo := flag.String("repo", "syncthing/syncthing", "Repository to get the release assets from, with -update")
+	flag.Parse()
+
+	table, err := versions.Load(*versionsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	runtimes := make(map[relnotes.Version]string)
+	for _, r := range table.Releases() {
+		runtimes[r.Version] = r.Runtime
+	}
+	platforms, err := platform.ReadPlatforms(*platformsFile)
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalln(err)
+	}
+	if *update {
+		if err := updatePlatforms(context.Background(), *repo, runtimes, platforms); err != nil {
+			log.Fatalln(err)
+		}
+		if err := platform.WritePlatforms(*platformsFile, platforms); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	overrides, err := platform.ReadOverrides(*overridesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := writeMatrix(os.Stdout, runtimes, platforms, overrides); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// writeMatrix writes the support matrix, newest releases first.
+func writeMatrix(w io.Writer, runtimes map[relnotes.Version]string, platforms map[relnotes.Version][]string, overrides []platform.Override) error {
+	versions := make([]relnotes.Version, 0, len(runtimes))
+	for v := range runtimes {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(a, b int) bool { return versions[b].Less(versions[a]) })
+
+	type group struct {
+		newest, oldest relnotes.Version
+		goMin, goMax   int
+		support        support
+	}
+	var groups []group
+	for _, v := range versions {
+		s := supportOf(v, runtimes[v], platforms[v], overrides)
+		if n := len(groups); n > 0 && groups[n-1].support.key() == s.key() {
+			g := &groups[n-1]
+			g.oldest = v
+			if s.goMinor > 0 && (g.goMin == 0 || s.goMinor < g.goMin) {
+				g.goMin = s.goMinor
+			}
+			if s.goMinor > g.goMax {
+				g.goMax = s.goMinor
+			}
+			continue
+		}
+		groups = append(groups, group{newest: v, oldest: v, goMin: s.goMinor, goMax: s.goMinor, support: s})
+	}
+
+	tbl := rst.Table{
+		Title:  "Platform Support",
+		Header: []string{"Releases", "Go", "Windows", "macOS", "Linux kernel", "Released for"},
+		Widths: []int{20, 10, 10, 10, 10, 40},
+	}
+	for _, g := range groups {
+		releases := g.newest.String()
+		if g.oldest != g.newest {
+			releases = g.oldest.String() + " – " + g.newest.String()
+		}
+		goVersions := "-"
+		switch {
+		case g.goMin == 0:
+		case g.goMin == g.goMax:
+			goVersions = fmt.Sprintf("1.%d", g.goMin)
+		default:
+			goVersions = fmt.Sprintf("1.%d – 1.%d", g.goMin, g.goMax)
+		}
+		released := "-"
+		if list := g.support.platformList(); len(list) > 0 {
+			released = "- " + strings.Join(list, "\n- ")
+		}
+		tbl.Rows = append(tbl.Rows, []string{releases, goVersions, orDash(g.support.windows), orDash(g.support.macOS), orDash(g.support.linux), released})
+	}
+
+	fmt.Fprintln(w, ".. This file is generated by _script/platforms; do not edit.")
+	fmt.Fprintln(w)
+	_, err := tbl.WriteTo(w)
+	return err
+}
+
+// supportOf returns what the release runs on.
+func supportOf(v relnotes.Version, runtime string, assets []string, overrides []platform.Override) support {
+	var s support
+	var min platform.Minimum
+	s.goMinor, min = platform.For(v, runtime, overrides)
+	s.windows, s.macOS, s.linux = min.Windows, min.MacOS, min.Linux
+	s.platforms = make(map[string][]string)
+	for _, p := range assets {
+		os, arch, _ := strings.Cut(p, "-")
+		s.platforms[os] = append(s.platforms[os], arch)
+	}
+	for _, archs := range s.platforms {
+		sort.Strings(archs)
+	}
+	return s
+}
+
+// updatePlatforms adds the asset platforms of the releases in the
+// versions table that are missing from platforms.
+func updatePlatforms(ctx context.Context, repo string, runtimes map[relnotes.Version]string, platforms map[relnotes.Version][]string) error {
+	rels, err := relnotes.List(ctx, relnotes.Client(), repo)
+	if err != nil {
+		return err
+	}
+	for _, rel := range rels {
+		if _, ok := runtimes[rel.Version]; !ok {
+			continue
+		}
+		if _, ok := platforms[rel.Version]; ok {
+			continue
+		}
+		if ps := platform.AssetPlatforms(rel.Assets); len(ps) > 0 {
+			platforms[rel.Version] = ps
+		}
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,232 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./ports -version v1.27.0 -out ../includes
+//
+// Writes the table of default ports used by Syncthing and its servers to
+// ports.rst, and for each program the list of its ports, those to forward
+// on a router and example firewall rules, as its templates list, to
+// firewall-<program>-<template>.rst, from the data in ports.json and the
+// templates in firewall.tmpl. The overrides in the data file give the
+// ports as they were before a given version, and are applied when
+// documenting an older -version.
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"syncthing.net/docs/internal/versions"
+)
+
+//go:embed templates/firewall.tmpl
+var templateFS embed.FS
+
+// firewalls are the templates, in the order they're documented, and the
+// language of the code blocks they're rendered in; the lists of ports
+// are written as text.
+var firewalls = []struct {
+	name, lang string
+}{
+	{"list", ""},
+	{"forward", ""},
+	{"ufw", "shell"},
+	{"firewalld", "shell"},
+	{"iptables", "shell"},
+	{"pf", "text"},
+	{"netsh", "bat"},
+}
+
+type program struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	// Templates are those of firewalls written for the program, as
+	// included in the docs.
+	Templates []string `json:"templates"`
+}
+
+func (p program) writes(template string) bool {
+	for _, t := range p.Templates {
+		if t == template {
+			return true
+		}
+	}
+	return false
+}
+
+type port struct {
+	Program  string `json:"program"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Purpose  string `json:"purpose"`
+	Optional bool   `json:"optional"`
+	// LAN ports are only used on the local network, and not
+	// forwarded by routers.
+	LAN bool `json:"lan"`
+	// Absent, in an override, means the port wasn't used before the
+	// version.
+	Absent bool `json:"absent"`
+}
+
+func (p port) key() string {
+	return fmt.Sprintf("%s %d/%s", p.Program, p.Port, p.Protocol)
+}
+
+type data struct {
+	Programs  []program         `json:"programs"`
+	Ports     []port            `json:"ports"`
+	Overrides map[string][]port `json:"overrides"`
+}
+
+func main() {
+	log.SetFlags(0)
+	file := flag.String("data", "ports/ports.json", "Port data file")
+	version := flag.String("version", "", "Syncthing version to document, to apply the overrides for")
+	out := flag.String("out", "", "Directory to write the include files to, instead of writing the table to stdout")
+	flag.Parse()
+
+	d, err := load(*file)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *version != "" {
+		d.Ports = d.at(*version)
+	}
+
+	if *out == "" {
+		if err := writeTable(os.Stdout, d); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	tpl, err := template.New("").Funcs(template.FuncMap{"upper": strings.ToUpper}).ParseFS(templateFS, "templates/firewall.tmpl")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := writeFile(filepath.Join(*out, "ports.rst"), func(f *os.File) error {
+		return writeTable(f, d)
+	}); err != nil {
+		log.Fatalln(err)
+	}
+	for _, prog := range d.Programs {
+		for _, fw := range firewalls {
+			if !prog.writes(fw.name) {
+				continue
+			}
+			name := filepath.Join(*out, fmt.Sprintf("firewall-%s-%s.rst", prog.Name, fw.name))
+			if err := writeFile(name, func(f *os.File) error {
+				return writeRules(f, tpl.Lookup(fw.name), fw.lang, prog, d.ports(prog.Name))
+			}); err != nil {
+				log.Fatalln(err)
+			}
+		}
+	}
+}
+
+func load(name string) (*data, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var d data
+	if err := json.Unmarshal(bs, &d); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	programs := make(map[string]bool)
+	templates := make(map[string]bool)
+	for _, fw := range firewalls {
+		templates[fw.name] = true
+	}
+	for _, p := range d.Programs {
+		programs[p.Name] = true
+		for _, t := range p.Templates {
+			if !templates[t] {
+				return nil, fmt.Errorf("%s: program %s: unknown template %q", name, p.Name, t)
+			}
+		}
+	}
+	for _, p := range d.Ports {
+		if !programs[p.Program] {
+			return nil, fmt.Errorf("%s: port %s: unknown program", name, p.key())
+		}
+	}
+	return &d, nil
+}
+
+// at returns the ports as of the given version, applying the overrides for
+// the versions after it, newest first.
+func (d *data) at(version string) []port {
+	var later []string
+	for v := range d.Overrides {
+		if versions.Compare(version, v) < 0 {
+			later = append(later, v)
+		}
+	}
+	sort.Slice(later, func(a, b int) bool {
+		return versions.Compare(later[a], later[b]) > 0
+	})
+
+	ports := append([]port(nil), d.Ports...)
+	for _, v := range later {
+		for _, o := range d.Overrides[v] {
+			i := indexOf(ports, o.key())
+			switch {
+			case o.Absent && i >= 0:
+				ports = append(ports[:i], ports[i+1:]...)
+			case o.Absent:
+			case i >= 0:
+				ports[i] = o
+			default:
+				ports = append(ports, o)
+			}
+		}
+	}
+	return ports
+}
+
+func (d *data) ports(prog string) []port {
+	var res []port
+	for _, p := range d.Ports {
+		if p.Program == prog {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+func indexOf(ports []port, key string) int {
+	for i, p := range ports {
+		if p.key() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func writeFile(name string, fn func(*os.File) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(f, ".. This file is generated by _script/ports; do not edit.\n\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+func writeTable(w io.Writer, d *data) error {
+	titles := make(map[string]string)
+	for _, p := range d.Programs {
+		titles[p.Name] = p.Title
+	}
+	t := rst.Table{
+		Title:  "Default Ports",
+		Header: []string{"Port", "Program", "Purpose"},
+		Widths: []int{15, 20, 65},
+	}
+	for _, p := range d.Ports {
+		purpose := rst.Escape(p.Purpose)
+		if p.Optional {
+			purpose += " (optional)"
+		}
+		t.Rows = append(t.Rows, []string{
+			fmt.Sprintf("**%d/%s**", p.Port, strings.ToUpper(p.Protocol)),
+			titles[p.Program],
+			purpose,
+		})
+	}
+	_, err := t.WriteTo(w)
+	return err
+}
+
+// writeRules writes the firewall rules for the program's ports as a code
+// block, or the text as is without a language.
+func writeRules(w io.Writer, tpl *template.Template, lang string, prog program, ports []port) error {
+	var sb strings.Builder
+	err := tpl.Execute(&sb, map[string]any{
+		"Program": prog,
+		"Ports":   ports,
+	})
+	if err != nil {
+		return err
+	}
+	if lang == "" {
+		_, err := fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(sb.String()))
+		return err
+	}
+	fmt.Fprintf(w, ".. code-block:: %s\n\n", lang)
+	for _, line := range strings.Split(strings.TrimSpace(sb.String()), "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
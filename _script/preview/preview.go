@@ -0,0 +1,106 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./preview [-addr localhost:8000]
+//
+// Serves a preview of the documentation that follows the sources. The
+// sources are watched, and on every change Sphinx rebuilds the HTML
+// output; since the build environment is kept between builds, only the
+// changed pages are rebuilt. Pages in the browser reload themselves over
+// a websocket when a build finishes, or show the build errors when it
+// fails.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+func main() {
+	log.SetFlags(log.Ltime)
+	root := flag.String("root", "..", "Documentation root")
+	out := flag.String("out", "../_build/preview", "Build directory")
+	addr := flag.String("addr", "localhost:8000", "Listen address")
+	sphinx := flag.String("sphinx", "sphinx-build", "Sphinx build command")
+	interval := flag.Duration("interval", 250*time.Millisecond, "How often to look for changes")
+	flag.Parse()
+
+	b := &builder{
+		sphinx:   *sphinx,
+		root:     *root,
+		doctrees: filepath.Join(*out, "doctrees"),
+		html:     filepath.Join(*out, "html"),
+	}
+	h := newHub()
+
+	// The first build is a full one, unless there's a previous preview
+	// build to start from.
+	if err := b.build(); err != nil {
+		log.Println("Build failed:", err)
+	}
+
+	w := &watcher{root: *root, skip: []string{*out}}
+	go w.run(*interval, func() {
+		log.Println("Change detected, building")
+		t0 := time.Now()
+		if err := b.build(); err != nil {
+			log.Println("Build failed:", err)
+			h.send(message{Type: "error", Text: err.Error()})
+			return
+		}
+		log.Printf("Built in %v", time.Since(t0).Truncate(time.Millisecond))
+		h.send(message{Type: "reload"})
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle(reloadPath, h)
+	mux.Handle("/", &pageServer{dir: b.html})
+	log.Printf("Serving the preview on http://%s/", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+// builder runs Sphinx to build the HTML output.
+type builder struct {
+	sphinx   string
+	root     string
+	doctrees string
+	html     string
+}
+
+// build runs an incremental build. On failure, the error includes the
+// warnings and errors Sphinx printed.
+func (b *builder) build() error {
+	// -q leaves only warnings and errors in the output.
+	cmd := exec.Command(b.sphinx, "-q", "-b", "html", "-d", b.doctrees, b.root, b.html)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	if output.Len() > 0 {
+		log.Printf("%s", output.Bytes())
+	}
+	if err != nil {
+		return &buildError{err: err, output: output.String()}
+	}
+	return nil
+}
+
+type buildError struct {
+	err    error
+	output string
+}
+
+func (e *buildError) Error() string {
+	if e.output == "" {
+		return e.err.Error()
+	}
+	return e.err.Error() + "\n" + e.output
+}
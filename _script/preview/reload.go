@@ -0,0 +1,148 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// reloadPath is where pages connect to get told about builds.
+const reloadPath = "/_preview/ws"
+
+// message is sent to the pages after a build.
+type message struct {
+	// Type is "reload" after a successful build, or "error".
+	Type string `json:"type"`
+	// Text is the build output, for errors.
+	Text string `json:"text,omitempty"`
+}
+
+// reloadScript is added to each served page. It reloads the page after a
+// build, shows build errors at the bottom of the page, and reconnects
+// when the server restarts.
+const reloadScript = `<script>
+(function () {
+  var proto = location.protocol === "https:" ? "wss:" : "ws:";
+  function show(text) {
+    var pre = document.getElementById("preview-error") || document.createElement("pre");
+    pre.id = "preview-error";
+    pre.style.cssText = "position:fixed;bottom:0;left:0;right:0;max-height:50%;overflow:auto;margin:0;padding:1em;background:#fee;color:#900;z-index:1000;white-space:pre-wrap";
+    pre.textContent = text;
+    document.body.appendChild(pre);
+  }
+  function connect() {
+    var ws = new WebSocket(proto + "//" + location.host + "` + reloadPath + `");
+    ws.onmessage = function (ev) {
+      var msg = JSON.parse(ev.data);
+      if (msg.type === "reload") {
+        location.reload();
+      } else if (msg.type === "error") {
+        show(msg.text);
+      }
+    };
+    ws.onclose = function () { setTimeout(connect, 1000); };
+  }
+  connect();
+})();
+</script>
+`
+
+// hub keeps track of the connected pages and sends them messages.
+type hub struct {
+	mut   sync.Mutex
+	conns map[*websocket.Conn]struct{}
+	// failed is the last message if the last build failed, so pages
+	// opened after it show the errors too.
+	failed *message
+}
+
+func newHub() *hub {
+	return &hub{conns: make(map[*websocket.Conn]struct{})}
+}
+
+func (h *hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	websocket.Handler(h.serve).ServeHTTP(w, r)
+}
+
+func (h *hub) serve(conn *websocket.Conn) {
+	h.mut.Lock()
+	h.conns[conn] = struct{}{}
+	if h.failed != nil {
+		_ = websocket.JSON.Send(conn, h.failed)
+	}
+	h.mut.Unlock()
+
+	// Pages don't send anything; reading returns when they go away.
+	_, _ = io.Copy(io.Discard, conn)
+
+	h.mut.Lock()
+	delete(h.conns, conn)
+	h.mut.Unlock()
+	conn.Close()
+}
+
+func (h *hub) send(msg message) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if msg.Type == "error" {
+		h.failed = &msg
+	} else {
+		h.failed = nil
+	}
+	for conn := range h.conns {
+		if err := websocket.JSON.Send(conn, msg); err != nil {
+			conn.Close()
+			delete(h.conns, conn)
+		}
+	}
+}
+
+// pageServer serves the build output, with the reload script added to the
+// HTML pages. Nothing is cached, as everything can change with the next
+// build.
+type pageServer struct {
+	dir string
+}
+
+func (s *pageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	name := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	if !strings.HasSuffix(name, ".html") {
+		http.FileServer(http.Dir(s.dir)).ServeHTTP(w, r)
+		return
+	}
+
+	fd, err := http.Dir(s.dir).Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer fd.Close()
+	page, err := io.ReadAll(fd)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if i := bytes.LastIndex(page, []byte("</body>")); i >= 0 {
+		page = append(page[:i:i], append([]byte(reloadScript), page[i:]...)...)
+	} else {
+		page = append(page, reloadScript...)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// watcher looks for changes in the documentation sources by scanning the
+// tree for modification times. The tree is small enough that this is
+// cheap, and it works the same everywhere, including in containers and on
+// network file systems where change notifications don't.
+type watcher struct {
+	root string
+	// skip are directories that aren't sources, in addition to the hidden
+	// and _build and _script ones.
+	skip []string
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// run calls changed after each change, once the tree has stopped
+// changing, so that saving several files or an editor's write and rename
+// results in one build. It doesn't return.
+func (w *watcher) run(interval time.Duration, changed func()) {
+	prev := w.scan()
+	pending := false
+	for {
+		time.Sleep(interval)
+		cur := w.scan()
+		if !sameFiles(prev, cur) {
+			prev = cur
+			pending = true
+			continue
+		}
+		if pending {
+			pending = false
+			changed()
+			// Whatever changed during the build is picked up next.
+		}
+	}
+}
+
+func (w *watcher) scan() map[string]fileState {
+	skip := make(map[string]bool)
+	for _, dir := range w.skip {
+		if abs, err := filepath.Abs(dir); err == nil {
+			skip[abs] = true
+		}
+	}
+	res := make(map[string]fileState)
+	err := filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can go away during the scan.
+			return nil
+		}
+		if d.IsDir() {
+			if path == w.root {
+				return nil
+			}
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || name == "_build" || name == "_script" {
+				return filepath.SkipDir
+			}
+			if abs, err := filepath.Abs(path); err == nil && skip[abs] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || strings.HasSuffix(d.Name(), "~") {
+			// Editor swap and backup files
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		res[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	if err != nil {
+		log.Println("Scanning sources:", err)
+	}
+	return res
+}
+
+func sameFiles(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, sa := range a {
+		sb, ok := b[path]
+		if !ok || sb.size != sa.size || !sb.modTime.Equal(sa.modTime) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// jsonKeyExp matches the keys in the examples, which aren't always
+// quoted.
+var jsonKeyExp = regexp.MustCompile(`^"?(\w+)"?\s*:`)
+
+// jsonSpec returns the keys of the JSON objects in the page's literal
+// blocks, with the line they're first on.
+func jsonSpec(doc *rstdoc.Doc) map[string]int {
+	keys := make(map[string]int)
+	for _, cb := range doc.CodeBlocks {
+		if len(cb.Content) == 0 || strings.TrimSpace(cb.Content[0]) != "{" {
+			continue
+		}
+		for i, line := range cb.Content {
+			if m := jsonKeyExp.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+				if _, ok := keys[m[1]]; !ok {
+					keys[m[1]] = cb.Pos.Line + i
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// jsonSource returns the JSON keys of the named struct type in the file.
+func jsonSource(file, typeName string) (map[string]bool, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	var keys map[string]bool
+	ast.Inspect(f, func(n ast.Node) bool {
+		ts, ok := n.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != typeName {
+			return true
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return false
+		}
+		keys = make(map[string]bool)
+		for _, fl := range st.Fields.List {
+			key := ""
+			if fl.Tag != nil {
+				tag, _ := strconv.Unquote(fl.Tag.Value)
+				key, _, _ = strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+			}
+			for _, n := range fl.Names {
+				switch {
+				case key == "-" || !n.IsExported():
+				case key != "":
+					keys[key] = true
+				default:
+					keys[n.Name] = true
+				}
+			}
+		}
+		return false
+	})
+	if keys == nil {
+		return nil, fmt.Errorf("%s: no struct type %s", file, typeName)
+	}
+	return keys, nil
+}
+
+// compareJSON returns the differences between the documented keys and
+// the source.
+func compareJSON(doc map[string]int, src map[string]bool, first int) []problem {
+	var res []problem
+	for _, k := range sortedKeys(src) {
+		if _, ok := doc[k]; !ok {
+			res = append(res, problem{first, fmt.Sprintf("field %q is not documented", k)})
+		}
+	}
+	for _, k := range sortedKeys(doc) {
+		if !src[k] {
+			res = append(res, problem{doc[k], fmt.Sprintf("field %q is not in the source", k)})
+		}
+	}
+	return res
+}
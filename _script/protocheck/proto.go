@@ -0,0 +1,227 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// schema is the messages and enums of a protocol buffer schema, by
+// name. Nested types are named Outer.Inner.
+type schema struct {
+	messages map[string]*message
+	enums    map[string]*enum
+}
+
+type message struct {
+	fields map[int]protoField
+	line   int
+}
+
+type protoField struct {
+	name, typ string
+	repeated  bool
+}
+
+func (f protoField) String() string {
+	if f.repeated {
+		return "repeated " + f.typ + " " + f.name
+	}
+	return f.typ + " " + f.name
+}
+
+type enum struct {
+	values map[string]int
+	line   int
+}
+
+var (
+	blockExp      = regexp.MustCompile(`^(message|enum)\s+(\w+)\s*\{$`)
+	protoFieldExp = regexp.MustCompile(`^(repeated\s+|optional\s+)?([\w.]+)\s+(\w+)\s*=\s*(\d+)`)
+	enumValueExp  = regexp.MustCompile(`^(\w+)\s*=\s*(-?\d+)`)
+)
+
+// Fields numbered from localFieldBase are only stored in the local
+// database and never sent over the wire, so they're not part of the
+// protocol.
+const localFieldBase = 1000
+
+// parseProto parses the messages and enums in proto3 source. Lines are
+// numbered from first, so positions in a code block can be given
+// relative to the page. Options, imports and reserved statements are
+// skipped.
+func parseProto(lines []string, first int) (*schema, error) {
+	s := &schema{messages: make(map[string]*message), enums: make(map[string]*enum)}
+	type open struct {
+		kind, name string
+	}
+	var stack []open
+	for i, line := range lines {
+		if c := strings.Index(line, "//"); c >= 0 {
+			line = line[:c]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lineNo := first + i
+
+		if m := blockExp.FindStringSubmatch(line); m != nil {
+			name := m[2]
+			if len(stack) > 0 {
+				name = stack[len(stack)-1].name + "." + name
+			}
+			stack = append(stack, open{m[1], name})
+			if m[1] == "message" {
+				s.messages[name] = &message{fields: make(map[int]protoField), line: lineNo}
+			} else {
+				s.enums[name] = &enum{values: make(map[string]int), line: lineNo}
+			}
+			continue
+		}
+		if line == "}" {
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: unbalanced }", lineNo)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if len(stack) == 0 {
+			continue
+		}
+
+		cur := stack[len(stack)-1]
+		switch {
+		case cur.kind == "message":
+			m := protoFieldExp.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			num, _ := strconv.Atoi(m[4])
+			if num >= localFieldBase {
+				continue
+			}
+			s.messages[cur.name].fields[num] = protoField{name: m[3], typ: m[2], repeated: strings.TrimSpace(m[1]) == "repeated"}
+		case cur.kind == "enum":
+			m := enumValueExp.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			num, _ := strconv.Atoi(m[2])
+			s.enums[cur.name].values[m[1]] = num
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%s %s is not closed", stack[len(stack)-1].kind, stack[len(stack)-1].name)
+	}
+	return s, nil
+}
+
+// compareSchemas returns the differences between the documented schema
+// and the one in the source, at the documented lines. Types only in the
+// source are reported at the first line of the documented schema.
+func compareSchemas(doc, src *schema, first int) []problem {
+	var res []problem
+	for _, name := range sortedKeys(doc.messages) {
+		dm := doc.messages[name]
+		sm, ok := src.messages[name]
+		if !ok {
+			res = append(res, problem{dm.line, fmt.Sprintf("message %s is not in the source", name)})
+			continue
+		}
+		for _, num := range sortedKeys(sm.fields) {
+			sf := sm.fields[num]
+			df, ok := dm.fields[num]
+			switch {
+			case !ok:
+				res = append(res, problem{dm.line, fmt.Sprintf("message %s: field %d (%s) is not documented", name, num, sf)})
+			case df != sf:
+				res = append(res, problem{dm.line, fmt.Sprintf("message %s: field %d is documented as %s but is %s", name, num, df, sf)})
+			}
+		}
+		for _, num := range sortedKeys(dm.fields) {
+			if _, ok := sm.fields[num]; !ok {
+				res = append(res, problem{dm.line, fmt.Sprintf("message %s: field %d (%s) is not in the source", name, num, dm.fields[num])})
+			}
+		}
+	}
+	for _, name := range sortedKeys(src.messages) {
+		if _, ok := doc.messages[name]; !ok {
+			res = append(res, problem{first, fmt.Sprintf("message %s is not documented", name)})
+		}
+	}
+
+	for _, name := range sortedKeys(doc.enums) {
+		de := doc.enums[name]
+		se, ok := src.enums[name]
+		if !ok {
+			res = append(res, problem{de.line, fmt.Sprintf("enum %s is not in the source", name)})
+			continue
+		}
+		se = &enum{values: unprefixed(name, se.values), line: se.line}
+		for _, v := range sortedKeys(se.values) {
+			dn, ok := de.values[v]
+			switch {
+			case !ok:
+				res = append(res, problem{de.line, fmt.Sprintf("enum %s: value %s = %d is not documented", name, v, se.values[v])})
+			case dn != se.values[v]:
+				res = append(res, problem{de.line, fmt.Sprintf("enum %s: value %s is documented as %d but is %d", name, v, dn, se.values[v])})
+			}
+		}
+		for _, v := range sortedKeys(de.values) {
+			if _, ok := se.values[v]; !ok {
+				res = append(res, problem{de.line, fmt.Sprintf("enum %s: value %s is not in the source", name, v)})
+			}
+		}
+	}
+	for _, name := range sortedKeys(src.enums) {
+		if _, ok := doc.enums[name]; !ok {
+			res = append(res, problem{first, fmt.Sprintf("enum %s is not documented", name)})
+		}
+	}
+	return res
+}
+
+// unprefixed returns the enum values with the enum name prefix, as in
+// MESSAGE_TYPE_CLUSTER_CONFIG for MessageType, removed. The specs leave
+// the prefix out.
+func unprefixed(name string, values map[string]int) map[string]int {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	var prefix strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			prefix.WriteByte('_')
+		}
+		prefix.WriteRune(unicode.ToUpper(r))
+	}
+	prefix.WriteByte('_')
+	res := make(map[string]int, len(values))
+	for v, n := range values {
+		res[strings.TrimPrefix(v, prefix.String())] = n
+	}
+	return res
+}
+
+func sortedKeys[K int | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool { return keys[a] < keys[b] })
+	return keys
+}
+
+func splitLines(s string) []string {
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,147 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./protocheck -tag v1.27.0
+//
+// Compares the protocol specifications to their implementation in the
+// Syncthing source, so the spec pages can't silently drift from the wire
+// format:
+//
+//   - the BEP and local discovery schemas in the pages' proto code
+//     blocks, message by message and field number by field number,
+//     against the .proto files;
+//   - the relay protocol's XDR structs and message type numbers against
+//     the Go types they're generated from;
+//   - the keys of the global discovery JSON examples against the
+//     discovery server's announcement type.
+//
+// Each difference is printed with the page position it's about, and the
+// exit status is 1 if there are any.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"syncthing.net/docs/internal/rstdoc"
+	"syncthing.net/docs/internal/stsource"
+)
+
+// problem is a difference between a page and the source, at a line of
+// the page.
+type problem struct {
+	line int
+	msg  string
+}
+
+// spec is a specification page and the source implementing it.
+type spec struct {
+	Doc    string
+	Kind   string
+	Source string
+	// Type is the Go type of JSON specs.
+	Type string
+}
+
+var specs = []spec{
+	{Doc: "specs/bep-v1", Kind: "proto", Source: "proto/lib/protocol/bep.proto"},
+	{Doc: "specs/localdisco-v4", Kind: "proto", Source: "proto/lib/discover/local.proto"},
+	{Doc: "specs/relay-v1", Kind: "xdr", Source: "lib/relay/protocol/packets.go"},
+	{Doc: "specs/globaldisco-v3", Kind: "json", Source: "cmd/stdiscosrv/apisrv.go", Type: "announcement"},
+}
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to check against")
+	flag.Parse()
+
+	tree, err := rstdoc.Load(*root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	srcRoot, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	failed := false
+	for _, s := range specs {
+		doc := tree.Doc(s.Doc)
+		if doc == nil {
+			log.Fatalf("%s: no such document", s.Doc)
+		}
+		problems, err := check(doc, s, filepath.Join(srcRoot, s.Source))
+		if err != nil {
+			log.Fatalf("%s: %v", s.Doc, err)
+		}
+		sort.SliceStable(problems, func(a, b int) bool { return problems[a].line < problems[b].line })
+		for _, p := range problems {
+			fmt.Printf("%s:%d: %s (%s)\n", doc.File, p.line, p.msg, s.Source)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func check(doc *rstdoc.Doc, s spec, source string) ([]problem, error) {
+	first := 1
+	if len(doc.Sections) > 0 {
+		first = doc.Sections[0].Pos.Line
+	}
+	switch s.Kind {
+	case "proto":
+		bs, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		srcSchema, err := parseProto(splitLines(string(bs)), 1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Source, err)
+		}
+		docSchema := &schema{messages: make(map[string]*message), enums: make(map[string]*enum)}
+		for _, cb := range doc.CodeBlocks {
+			if cb.Lang != "proto" {
+				continue
+			}
+			sc, err := parseProto(cb.Content, cb.Pos.Line)
+			if err != nil {
+				return nil, err
+			}
+			for name, m := range sc.messages {
+				docSchema.messages[name] = m
+			}
+			for name, e := range sc.enums {
+				docSchema.enums[name] = e
+			}
+		}
+		return compareSchemas(docSchema, srcSchema, first), nil
+
+	case "xdr":
+		srcStructs, srcTypes, err := relaySource(source)
+		if err != nil {
+			return nil, err
+		}
+		docStructs, docTypes, typeLines := relaySpec(doc)
+		return compareRelay(docStructs, docTypes, typeLines, srcStructs, srcTypes, first), nil
+
+	case "json":
+		keys, err := jsonSource(source, s.Type)
+		if err != nil {
+			return nil, err
+		}
+		return compareJSON(jsonSpec(doc), keys, first), nil
+	}
+	return nil, fmt.Errorf("unknown spec kind %q", s.Kind)
+}
@@ -0,0 +1,210 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+// xdrStruct is a struct in XDR language, as in the relay protocol spec,
+// or the Go struct it's generated from.
+type xdrStruct struct {
+	fields []xdrField
+	line   int
+}
+
+type xdrField struct {
+	name, typ string
+}
+
+var (
+	structExp     = regexp.MustCompile(`^struct\s+(\w+)\s*\{$`)
+	xdrFieldExp   = regexp.MustCompile(`^(.+?)\s+(\w+)(<\d*>)?;$`)
+	msgHeadingExp = regexp.MustCompile(`^(\w+) message \(Type = (\d+)\)$`)
+)
+
+// xdrTypes are the XDR types the relay protocol's Go types are encoded
+// as.
+var xdrTypes = map[string][]string{
+	"[]byte": {"opaque"},
+	"string": {"string"},
+	"bool":   {"bool"},
+	"int32":  {"int"},
+	"uint32": {"unsigned int"},
+	// Padded to four bytes.
+	"uint16": {"unsigned int", "unsigned short"},
+	"int64":  {"hyper"},
+	"uint64": {"unsigned hyper"},
+}
+
+// relaySpec returns the structs and the message types in the page. The
+// message types are from the "Ping message (Type = 0)" titles.
+func relaySpec(doc *rstdoc.Doc) (map[string]*xdrStruct, map[string]int, map[string]int) {
+	structs := make(map[string]*xdrStruct)
+	for _, cb := range doc.CodeBlocks {
+		var cur *xdrStruct
+		for i, line := range cb.Content {
+			line = strings.TrimSpace(line)
+			if m := structExp.FindStringSubmatch(line); m != nil {
+				cur = &xdrStruct{line: cb.Pos.Line + i}
+				structs[m[1]] = cur
+				continue
+			}
+			if cur == nil {
+				continue
+			}
+			if line == "}" {
+				cur = nil
+				continue
+			}
+			if m := xdrFieldExp.FindStringSubmatch(line); m != nil {
+				cur.fields = append(cur.fields, xdrField{name: m[2], typ: m[1]})
+			}
+		}
+	}
+	types := make(map[string]int)
+	lines := make(map[string]int)
+	for _, sec := range doc.Sections {
+		if m := msgHeadingExp.FindStringSubmatch(sec.Title); m != nil {
+			types[m[1]], _ = strconv.Atoi(m[2])
+			lines[m[1]] = sec.Pos.Line
+		}
+	}
+	return structs, types, lines
+}
+
+// relaySource returns the structs and the message types in the relay
+// protocol package. Message types are the messageTypeX constants,
+// numbered by iota.
+func relaySource(file string) (map[string]*xdrStruct, map[string]int, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	structs := make(map[string]*xdrStruct)
+	types := make(map[string]int)
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for i, spec := range gd.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				st, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				s := &xdrStruct{}
+				for _, fl := range st.Fields.List {
+					for _, n := range fl.Names {
+						s.fields = append(s.fields, xdrField{name: n.Name, typ: exprString(fl.Type)})
+					}
+				}
+				structs[spec.Name.Name] = s
+			case *ast.ValueSpec:
+				if gd.Tok != token.CONST {
+					continue
+				}
+				for _, n := range spec.Names {
+					if name, ok := strings.CutPrefix(n.Name, "messageType"); ok {
+						types[name] = i
+					}
+				}
+			}
+		}
+	}
+	return structs, types, nil
+}
+
+func exprString(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.ArrayType:
+		return "[]" + exprString(e.Elt)
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	}
+	return fmt.Sprintf("%T", e)
+}
+
+// compareRelay returns the differences between the documented messages
+// and the source.
+func compareRelay(docStructs map[string]*xdrStruct, docTypes, typeLines map[string]int, srcStructs map[string]*xdrStruct, srcTypes map[string]int, first int) []problem {
+	var res []problem
+	for _, name := range sortedKeys(srcTypes) {
+		num := srcTypes[name]
+		dn, ok := docTypes[name]
+		switch {
+		case !ok:
+			res = append(res, problem{first, fmt.Sprintf("message %s (type %d) is not documented", name, num)})
+		case dn != num:
+			res = append(res, problem{typeLines[name], fmt.Sprintf("message %s is documented as type %d but is %d", name, dn, num)})
+		}
+	}
+	for _, name := range sortedKeys(docTypes) {
+		if _, ok := srcTypes[name]; !ok {
+			res = append(res, problem{typeLines[name], fmt.Sprintf("message %s is not in the source", name)})
+		}
+	}
+
+	for _, name := range sortedKeys(docStructs) {
+		ds := docStructs[name]
+		ss, ok := srcStructs[name]
+		if !ok {
+			// The header is unexported in the source.
+			ss, ok = srcStructs[strings.ToLower(name[:1])+name[1:]]
+		}
+		if !ok {
+			res = append(res, problem{ds.line, fmt.Sprintf("struct %s is not in the source", name)})
+			continue
+		}
+		n := len(ds.fields)
+		if len(ss.fields) > n {
+			n = len(ss.fields)
+		}
+		for i := 0; i < n; i++ {
+			switch {
+			case i >= len(ds.fields):
+				res = append(res, problem{ds.line, fmt.Sprintf("struct %s: field %s %s is not documented", name, ss.fields[i].name, ss.fields[i].typ)})
+			case i >= len(ss.fields):
+				res = append(res, problem{ds.line, fmt.Sprintf("struct %s: field %s is not in the source", name, ds.fields[i].name)})
+			case !strings.EqualFold(ds.fields[i].name, ss.fields[i].name):
+				res = append(res, problem{ds.line, fmt.Sprintf("struct %s: field %d is documented as %s but is %s", name, i+1, ds.fields[i].name, ss.fields[i].name)})
+			case !compatible(ss.fields[i].typ, ds.fields[i].typ):
+				res = append(res, problem{ds.line, fmt.Sprintf("struct %s: field %s is documented as %s but is %s", name, ds.fields[i].name, ds.fields[i].typ, ss.fields[i].typ)})
+			}
+		}
+	}
+	for _, name := range sortedKeys(srcStructs) {
+		if _, ok := docStructs[name]; !ok {
+			if _, ok := srcTypes[name]; ok {
+				res = append(res, problem{first, fmt.Sprintf("struct %s is not documented", name)})
+			}
+		}
+	}
+	return res
+}
+
+func compatible(goType, xdrType string) bool {
+	for _, t := range xdrTypes[goType] {
+		if t == xdrType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./redirects [-git] [-format nginx] [-stubs ../_build/html]
+//
+// Writes the redirects for moved and renamed pages, from the moves
+// manifest and optionally the renames in the git history, as a web server
+// redirect map to stdout. With -stubs, an HTML page redirecting to the
+// new location is written in place of each old page in the given build
+// directory.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type move struct {
+	from, to string
+}
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	manifest := flag.String("moves", "redirects/moves.txt", "Moves manifest")
+	useGit := flag.Bool("git", false, "Include the renames in the git history")
+	format := flag.String("format", "nginx", "Redirect map format (nginx, apache, netlify)")
+	stubs := flag.String("stubs", "", "HTML build directory to write redirect pages to")
+	flag.Parse()
+
+	moves, err := readManifest(*manifest)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *useGit {
+		renames, err := gitRenames(*root)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		moves = append(renames, moves...)
+	}
+	moves, err = resolve(moves, *root)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *stubs != "" {
+		for _, m := range moves {
+			if err := writeStub(*stubs, m); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		return
+	}
+	if err := writeMap(os.Stdout, *format, moves); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func readManifest(name string) ([]move, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var moves []move
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected old and new document name", name, n)
+		}
+		moves = append(moves, move{fields[0], fields[1]})
+	}
+	return moves, sc.Err()
+}
+
+// gitRenames returns the renamed documents in the git history, oldest
+// first.
+func gitRenames(root string) ([]move, error) {
+	cmd := exec.Command("git", "-C", root, "log", "--reverse", "--diff-filter=R", "-M", "--name-status", "--format=", "--", "*.rst")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	var moves []move
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || !strings.HasPrefix(fields[0], "R") {
+			continue
+		}
+		moves = append(moves, move{strings.TrimSuffix(fields[1], ".rst"), strings.TrimSuffix(fields[2], ".rst")})
+	}
+	return moves, nil
+}
+
+// resolve follows chains of moves to the final location, drops those
+// that have been undone, and checks the targets exist.
+func resolve(moves []move, root string) ([]move, error) {
+	next := make(map[string]string)
+	for _, m := range moves {
+		next[m.from] = m.to
+	}
+	var res []move
+	for from := range next {
+		to := from
+		for seen := map[string]bool{}; next[docName(to)] != "" && !seen[to]; {
+			seen[to] = true
+			to = next[docName(to)]
+		}
+		if to == from || exists(root, from) {
+			continue
+		}
+		if !exists(root, docName(to)) {
+			return nil, fmt.Errorf("%s moved to %s, which does not exist", from, to)
+		}
+		res = append(res, move{from, to})
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].from < res[b].from
+	})
+	return res, nil
+}
+
+func docName(s string) string {
+	name, _, _ := strings.Cut(s, "#")
+	return name
+}
+
+func exists(root, doc string) bool {
+	_, err := os.Stat(filepath.Join(root, filepath.FromSlash(doc)+".rst"))
+	return err == nil
+}
+
+// htmlPath returns the URL path of a document, with any anchor.
+func htmlPath(doc string) string {
+	name, anchor, ok := strings.Cut(doc, "#")
+	p := "/" + name + ".html"
+	if ok {
+		p += "#" + anchor
+	}
+	return p
+}
+
+func writeMap(w io.Writer, format string, moves []move) error {
+	var line string
+	switch format {
+	case "nginx":
+		line = "location = %s { return 301 %s; }\n"
+	case "apache":
+		line = "Redirect permanent %s %s\n"
+	case "netlify":
+		line = "%s %s 301\n"
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	for _, m := range moves {
+		if _, err := fmt.Fprintf(w, line, htmlPath(m.from), htmlPath(m.to)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var stubTemplate = template.Must(template.New("stub").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Moved</title>
+<link rel="canonical" href="{{.}}">
+<meta http-equiv="refresh" content="0; url={{.}}">
+</head>
+<body>
+<p>This page has moved to <a href="{{.}}">{{.}}</a>.</p>
+</body>
+</html>
+`))
+
+// writeStub writes a page redirecting from the old location to the new,
+// using a relative URL so that it works for every version of the docs.
+func writeStub(dir string, m move) error {
+	name, anchor, _ := strings.Cut(m.to, "#")
+	rel, err := filepath.Rel(path.Dir(m.from), name+".html")
+	if err != nil {
+		return err
+	}
+	url := filepath.ToSlash(rel)
+	if anchor != "" {
+		url += "#" + anchor
+	}
+	var buf bytes.Buffer
+	if err := stubTemplate.Execute(&buf, url); err != nil {
+		return err
+	}
+	out := filepath.Join(dir, filepath.FromSlash(m.from)+".html")
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(out, buf.Bytes(), 0o644)
+}
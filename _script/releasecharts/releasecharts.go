@@ -0,0 +1,259 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./releasecharts > ../includes/release-history.rst
+//
+// Charts the release history from the versions table of the releases
+// page: the releases per year, the Go versions they were built with over
+// time, and how many were released on schedule, the first Tuesday of the
+// month. The charts are written as SVG images to the -images directory
+// of the docs, and the include with them and a table of the yearly
+// numbers to stdout.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/versions"
+)
+
+type release = versions.Release
+
+// The chart files, in the images directory.
+const (
+	perYearImage  = "release-history-per-year.svg"
+	runtimeImage  = "release-history-go.svg"
+	scheduleImage = "release-history-schedule.svg"
+)
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	versionsFile := flag.String("versions", "../users/releases.csv", "Versions table of the releases page")
+	images := flag.String("images", "dev", "Directory under the root to write the charts to")
+	flag.Parse()
+
+	rels, err := readReleases(*versionsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(rels) == 0 {
+		log.Fatalf("%s: no releases", *versionsFile)
+	}
+	years := tallyYears(rels)
+
+	charts := map[string]io.WriterTo{
+		perYearImage:  perYearChart(years),
+		runtimeImage:  runtimeChart(rels),
+		scheduleImage: scheduleChart(years),
+	}
+	for name, chart := range charts {
+		if err := writeChart(filepath.Join(*root, filepath.FromSlash(*images), name), chart); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if err := writeInclude(os.Stdout, "/"+path.Clean(*images), rels, years); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// readReleases returns the releases in the versions table, in the order
+// they were made.
+func readReleases(file string) ([]release, error) {
+	table, err := versions.Load(file)
+	if err != nil {
+		return nil, err
+	}
+	res := append([]release(nil), table.Releases()...)
+	sort.SliceStable(res, func(a, b int) bool { return res[a].Date.Before(res[b].Date) })
+	return res, nil
+}
+
+// year is the numbers for the releases of a year.
+type year struct {
+	Year       int
+	Feature    int // x.y.0
+	Patch      int
+	OnSchedule int
+	// MedianGap is the median number of days since the previous
+	// release.
+	MedianGap int
+}
+
+func (y year) total() int {
+	return y.Feature + y.Patch
+}
+
+func tallyYears(rels []release) []year {
+	first, last := rels[0].Date.Year(), rels[len(rels)-1].Date.Year()
+	years := make([]year, last-first+1)
+	gaps := make([][]int, len(years))
+	for i := range years {
+		years[i].Year = first + i
+	}
+	for i, r := range rels {
+		y := &years[r.Date.Year()-first]
+		if r.Version.Patch == 0 {
+			y.Feature++
+		} else {
+			y.Patch++
+		}
+		if onSchedule(r.Date) {
+			y.OnSchedule++
+		}
+		if i > 0 {
+			gaps[r.Date.Year()-first] = append(gaps[r.Date.Year()-first], int(r.Date.Sub(rels[i-1].Date).Hours()/24))
+		}
+	}
+	for i := range years {
+		years[i].MedianGap = median(gaps[i])
+	}
+	return years
+}
+
+// onSchedule reports whether the date is within a day of the first
+// Tuesday of a month, as the tag is often made the day before the
+// release.
+func onSchedule(d time.Time) bool {
+	for _, month := range []time.Time{d, d.AddDate(0, 1, 1-d.Day())} {
+		diff := d.Sub(firstTuesday(month.Year(), month.Month())).Hours() / 24
+		if diff >= -1 && diff <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func firstTuesday(year int, month time.Month) time.Time {
+	t := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return t.AddDate(0, 0, (int(time.Tuesday)-int(t.Weekday())+7)%7)
+}
+
+func median(vs []int) int {
+	if len(vs) == 0 {
+		return 0
+	}
+	sort.Ints(vs)
+	return vs[len(vs)/2]
+}
+
+func perYearChart(years []year) barChart {
+	c := barChart{
+		Height: 280,
+		Series: []series{
+			{Name: "Feature releases (x.y.0)", Color: "#0891b2"},
+			{Name: "Patch releases", Color: "#94a3b8"},
+		},
+	}
+	for _, y := range years {
+		c.Labels = append(c.Labels, strconv.Itoa(y.Year))
+		c.Series[0].Values = append(c.Series[0].Values, y.Feature)
+		c.Series[1].Values = append(c.Series[1].Values, y.Patch)
+	}
+	return c
+}
+
+func scheduleChart(years []year) barChart {
+	c := barChart{
+		Height: 280,
+		Series: []series{
+			{Name: "Within a day of the first Tuesday", Color: "#16a34a"},
+			{Name: "Other days", Color: "#f59e0b"},
+		},
+	}
+	for _, y := range years {
+		c.Labels = append(c.Labels, strconv.Itoa(y.Year))
+		c.Series[0].Values = append(c.Series[0].Values, y.OnSchedule)
+		c.Series[1].Values = append(c.Series[1].Values, y.total()-y.OnSchedule)
+	}
+	return c
+}
+
+// runtimeChart charts the period each Go version was used in, from the
+// first to the last release built with it.
+func runtimeChart(rels []release) timeline {
+	c := timeline{Color: "#0891b2"}
+	index := make(map[string]int)
+	for _, r := range rels {
+		minor := goMinor(r.Runtime)
+		if minor == "" {
+			continue
+		}
+		i, ok := index[minor]
+		if !ok {
+			i = len(c.Spans)
+			index[minor] = i
+			c.Spans = append(c.Spans, span{Label: minor, From: r.Date})
+		}
+		c.Spans[i].To = r.Date
+	}
+	return c
+}
+
+// goMinor returns the Go release of a version, such as go1.21 for
+// go1.21.6, or the empty string if it isn't one.
+func goMinor(runtime string) string {
+	parts := strings.Split(strings.TrimSpace(runtime), ".")
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "go") {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
+func writeChart(file string, chart io.WriterTo) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err := chart.WriteTo(f); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return f.Close()
+}
+
+func writeInclude(w io.Writer, images string, rels []release, years []year) error {
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/releasecharts; do not edit.\n\n")
+	fmt.Fprintf(&sb, "These are the numbers for the %d releases in the :ref:`versions table <releases>`, from %s on %s to %s on %s.\n\n",
+		len(rels), rels[0].Tag, rels[0].Date.Format(time.DateOnly),
+		rels[len(rels)-1].Tag, rels[len(rels)-1].Date.Format(time.DateOnly))
+
+	figure := func(title, image, alt, caption string) {
+		sb.WriteString(rst.Heading(title, '-'))
+		fmt.Fprintf(&sb, ".. figure:: %s/%s\n   :alt: %s\n\n   %s\n\n", images, image, alt, caption)
+	}
+	figure("Releases per Year", perYearImage, "Bar chart of the releases per year",
+		"Releases per year, feature releases with a new minor or major version and patch releases.")
+	figure("Go Versions", runtimeImage, "Timeline of the Go versions used to build the releases",
+		"The Go versions the releases were built with, from the first to the last release built with each.")
+	figure("Release Schedule", scheduleImage, "Bar chart of the releases per year on and off schedule",
+		"Releases per year on schedule, within a day of the first Tuesday of a month, and on other days.")
+
+	t := rst.Table{
+		Header: []string{"Year", "Releases", "On Schedule", "Median Days Between Releases"},
+		Widths: []int{15, 20, 25, 40},
+	}
+	for _, y := range years {
+		t.Rows = append(t.Rows, []string{strconv.Itoa(y.Year), strconv.Itoa(y.total()), strconv.Itoa(y.OnSchedule), strconv.Itoa(y.MedianGap)})
+	}
+	if _, err := t.WriteTo(&sb); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
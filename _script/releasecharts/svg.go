@@ -0,0 +1,181 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// The chart dimensions, in pixels; the SVG scales with the page.
+const (
+	chartWidth = 720
+	marginTop  = 36
+	marginBot  = 28
+	marginR    = 12
+	fontStyle  = `font-family="sans-serif" font-size="12" fill="#333"`
+	gridColor  = "#ddd"
+)
+
+// series is a set of values for a bar chart, stacked in order.
+type series struct {
+	Name   string
+	Color  string
+	Values []int
+}
+
+// barChart is a chart of stacked vertical bars, one per label.
+type barChart struct {
+	Labels []string
+	Series []series
+	Height int
+}
+
+func (c barChart) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	const left = 44
+	plotW := float64(chartWidth - left - marginR)
+	plotH := float64(c.Height - marginTop - marginBot)
+	openSVG(&sb, c.Height)
+	legend(&sb, left, c.Series)
+
+	max := 0
+	for i := range c.Labels {
+		total := 0
+		for _, s := range c.Series {
+			total += s.Values[i]
+		}
+		if total > max {
+			max = total
+		}
+	}
+	tick := niceStep(float64(max) / 5)
+	top := math.Max(tick, math.Ceil(float64(max)/tick)*tick)
+	y := func(v float64) float64 {
+		return marginTop + plotH - v/top*plotH
+	}
+	for v := 0.0; v <= top; v += tick {
+		fmt.Fprintf(&sb, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="%s"/>`+"\n", left, y(v), chartWidth-marginR, y(v), gridColor)
+		fmt.Fprintf(&sb, `<text x="%d" y="%.1f" text-anchor="end" %s>%g</text>`+"\n", left-6, y(v)+4, fontStyle, v)
+	}
+
+	step := plotW / float64(len(c.Labels))
+	every := int(math.Ceil(36 / step))
+	for i, label := range c.Labels {
+		x := float64(left) + float64(i)*step + step*0.15
+		base := 0.0
+		for _, s := range c.Series {
+			v := float64(s.Values[i])
+			if v == 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s %s: %d</title></rect>`+"\n",
+				x, y(base+v), step*0.7, y(base)-y(base+v), s.Color, esc(label), esc(s.Name), s.Values[i])
+			base += v
+		}
+		if i%every == 0 {
+			fmt.Fprintf(&sb, `<text x="%.1f" y="%d" text-anchor="middle" %s>%s</text>`+"\n", x+step*0.35, c.Height-marginBot+16, fontStyle, esc(label))
+		}
+	}
+	sb.WriteString("</svg>\n")
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// span is a labelled period of time.
+type span struct {
+	Label    string
+	From, To time.Time
+}
+
+// timeline is a chart of horizontal bars for periods of time, one per
+// row, over an axis of years.
+type timeline struct {
+	Spans []span
+	Color string
+}
+
+func (c timeline) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	const left, rowH = 64, 18
+	height := marginTop + rowH*len(c.Spans) + marginBot
+	openSVG(&sb, height)
+
+	first, last := c.Spans[0].From, c.Spans[0].To
+	for _, s := range c.Spans {
+		if s.From.Before(first) {
+			first = s.From
+		}
+		if s.To.After(last) {
+			last = s.To
+		}
+	}
+	start := time.Date(first.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(last.Year()+1, 1, 1, 0, 0, 0, 0, time.UTC)
+	plotW := float64(chartWidth - left - marginR)
+	x := func(t time.Time) float64 {
+		return float64(left) + float64(t.Sub(start))/float64(end.Sub(start))*plotW
+	}
+
+	years := end.Year() - start.Year()
+	every := int(math.Ceil(40 / (plotW / float64(years))))
+	for yr := start.Year(); yr <= end.Year(); yr++ {
+		t := time.Date(yr, 1, 1, 0, 0, 0, 0, time.UTC)
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="%s"/>`+"\n", x(t), marginTop-4, x(t), height-marginBot, gridColor)
+		if (yr-start.Year())%every == 0 && yr < end.Year() {
+			fmt.Fprintf(&sb, `<text x="%.1f" y="%d" %s>%d</text>`+"\n", x(t)+3, height-marginBot+16, fontStyle, yr)
+		}
+	}
+	for i, s := range c.Spans {
+		top := marginTop + i*rowH
+		width := math.Max(2, x(s.To)-x(s.From))
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end" %s>%s</text>`+"\n", left-6, top+rowH-5, fontStyle, esc(s.Label))
+		fmt.Fprintf(&sb, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"><title>%s: %s to %s</title></rect>`+"\n",
+			x(s.From), top+3, width, rowH-6, c.Color, esc(s.Label), s.From.Format(time.DateOnly), s.To.Format(time.DateOnly))
+	}
+	sb.WriteString("</svg>\n")
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func openSVG(sb *strings.Builder, height int) {
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`+"\n", chartWidth, height, chartWidth, height)
+	fmt.Fprintf(sb, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", chartWidth, height)
+}
+
+// legend writes the names of the series along the top.
+func legend(sb *strings.Builder, left int, ss []series) {
+	x := left
+	for _, s := range ss {
+		fmt.Fprintf(sb, `<rect x="%d" y="10" width="12" height="12" fill="%s"/>`+"\n", x, s.Color)
+		fmt.Fprintf(sb, `<text x="%d" y="20" %s>%s</text>`+"\n", x+16, fontStyle, esc(s.Name))
+		x += 16 + 7*len(s.Name) + 20
+	}
+}
+
+// niceStep returns the step of 1, 2 or 5 times a power of ten closest
+// above the given one, for axis ticks.
+func niceStep(raw float64) float64 {
+	if raw <= 1 {
+		return 1
+	}
+	pow := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*pow >= raw {
+			return m * pow
+		}
+	}
+	return 10 * pow
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
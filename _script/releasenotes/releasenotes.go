@@ -0,0 +1,153 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./releasenotes [-since v1.0.0] [-out ../users/release-notes] [-force]
+//
+// Fetches the release notes of the Syncthing releases from GitHub and
+// writes a page per release, converted from Markdown as by changelog,
+// along with an index page with a toctree per minor version. Running it
+// again adds the pages of new releases. Pages are generated as long as
+// they start with the generated comment: to edit one by hand, remove the
+// comment, and the page is left alone unless -force is given. The index
+// lists every release page in the directory. Set GITHUB_TOKEN to avoid
+// the rate limit for unauthenticated requests.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+)
+
+const generated = ".. This file is generated by _script/releasenotes; do not edit.\n"
+
+func main() {
+	log.SetFlags(0)
+	repo := flag.String("repo", "syncthing/syncthing", "Repository to take the releases from")
+	out := flag.String("out", "../users/release-notes", "Directory to write the pages to")
+	since := flag.String("since", "", "Oldest version to write a page for")
+	force := flag.Bool("force", false, "Overwrite the pages edited by hand")
+	flag.Parse()
+
+	var oldest relnotes.Version
+	if *since != "" {
+		var ok bool
+		if oldest, ok = relnotes.ParseVersion(*since); !ok {
+			log.Fatalf("%q is not a vX.Y.Z version", *since)
+		}
+	}
+
+	rels, err := relnotes.List(context.Background(), relnotes.Client(), *repo)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalln(err)
+	}
+
+	conv := relnotes.Converter{Repo: *repo}
+	written := 0
+	for _, rel := range rels {
+		if rel.Version.Less(oldest) {
+			continue
+		}
+		changed, err := writePage(filepath.Join(*out, rel.Tag+".rst"), releasePage(conv, rel), *force)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if changed {
+			written++
+		}
+	}
+
+	versions, err := pageVersions(*out)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if _, err := writePage(filepath.Join(*out, "index.rst"), indexPage(*repo, versions), *force); err != nil {
+		log.Fatalln(err)
+	}
+	log.Printf("Wrote %d release pages", written)
+}
+
+// writePage writes the page to the file, unless the file has been edited
+// by hand, having lost the generated comment, and isn't forced. It
+// reports whether the file changed.
+func writePage(file, page string, force bool) (bool, error) {
+	old, err := os.ReadFile(file)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return false, err
+	case string(old) == page:
+		return false, nil
+	case !strings.HasPrefix(string(old), generated) && !force:
+		log.Printf("%s: edited by hand; leaving it", file)
+		return false, nil
+	}
+	return true, os.WriteFile(file, []byte(page), 0o644)
+}
+
+// releasePage returns the page of the release. Each release gets a label
+// such as release-notes-v1.27.1 for other pages to refer to.
+func releasePage(conv relnotes.Converter, rel relnotes.Release) string {
+	var sb strings.Builder
+	sb.WriteString(generated + "\n")
+	fmt.Fprintf(&sb, ".. _release-notes-%s:\n\n", rel.Tag)
+	sb.WriteString(rst.Heading(rel.Tag, '='))
+	fmt.Fprintf(&sb, "Released %s (%s).\n\n", rel.Published.Format("2006-01-02"), rst.Link("release page", rel.URL))
+	if notes := conv.Convert(rel.Notes); notes != "" {
+		sb.WriteString(notes + "\n")
+	}
+	return sb.String()
+}
+
+// pageVersions returns the versions of the release pages in the
+// directory, newest first.
+func pageVersions(dir string) ([]relnotes.Version, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var res []relnotes.Version
+	for _, e := range entries {
+		if v, ok := relnotes.ParseVersion(strings.TrimSuffix(e.Name(), ".rst")); ok && !e.IsDir() && strings.HasSuffix(e.Name(), ".rst") {
+			res = append(res, v)
+		}
+	}
+	sort.Slice(res, func(a, b int) bool { return res[b].Less(res[a]) })
+	return res, nil
+}
+
+// indexPage returns the index of the release pages, with a section and
+// toctree per minor version.
+func indexPage(repo string, versions []relnotes.Version) string {
+	var sb strings.Builder
+	sb.WriteString(generated + "\n")
+	sb.WriteString(".. _release-notes:\n\n")
+	sb.WriteString(rst.Heading("Release Notes", '='))
+	fmt.Fprintf(&sb, "The release notes of each release, as published on %s.\n",
+		rst.Link("GitHub", "https://github.com/"+repo+"/releases"))
+
+	series := ""
+	for _, v := range versions {
+		if s := v.Series(); s != series {
+			series = s
+			sb.WriteString("\n" + rst.Heading(series, '-'))
+			sb.WriteString(".. toctree::\n   :maxdepth: 1\n\n")
+		}
+		fmt.Fprintf(&sb, "   %s\n", v)
+	}
+	return sb.String()
+}
@@ -0,0 +1,223 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./releasespage > ../users/releases.rst
+//
+// Writes the Versions & Releases page from templates/releases.rst.tmpl
+// and the versions table, so that it needs no edits after a release: the
+// current release is called out at the top, the release channels section
+// says which release candidate is being tested, according to the upgrade
+// server, and the historical releases are summarized per major version
+// before the table of all of them. Edit the template rather than the
+// page. With -meta set to the empty string the upgrade server isn't
+// asked and the candidate status is left out.
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/versions"
+)
+
+//go:embed templates/releases.rst.tmpl
+var templateFS embed.FS
+
+// releaseNotesURL is where the notes of a release are.
+const releaseNotesURL = "https://github.com/syncthing/syncthing/releases/tag/"
+
+// upgradeRelease is the part of the upgrade server's release metadata we
+// use.
+type upgradeRelease struct {
+	Tag         string    `json:"tag_name"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// page is what the template is executed with.
+type page struct {
+	Latest versions.Release
+	// Candidate is the newest release candidate, when newer than the
+	// latest release; HasCandidateStatus says whether the upgrade
+	// server was asked.
+	Candidate          *upgradeRelease
+	HasCandidateStatus bool
+	Majors             []major
+}
+
+// major is the releases of a major version.
+type major struct {
+	Major       int
+	First, Last versions.Release
+	Count       int
+	// Series is the table of its minor series, newest first.
+	Series string
+}
+
+func main() {
+	log.SetFlags(0)
+	versionsFile := flag.String("versions", versions.File, "Versions table of the releases page")
+	meta := flag.String("meta", "https://upgrades.syncthing.net/meta.json", "Upgrade server metadata, for the release candidate status")
+	flag.Parse()
+
+	table, err := versions.Load(*versionsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	latest, ok := table.Latest()
+	if !ok {
+		log.Fatalf("%s: no releases", *versionsFile)
+	}
+	p := page{Latest: latest, Majors: majors(table.Releases())}
+	if *meta != "" {
+		p.Candidate, err = candidate(*meta, latest.Version)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		p.HasCandidateStatus = true
+	}
+
+	tpl, err := template.New("").Funcs(template.FuncMap{
+		"date":    func(t time.Time) string { return t.Format(time.DateOnly) },
+		"notes":   func(tag string) string { return rst.Link("release notes", releaseNotesURL+tag) },
+		"heading": func(title, underline string) string { return rst.Heading(title, []rune(underline)[0]) },
+	}).ParseFS(templateFS, "templates/releases.rst.tmpl")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "releases.rst.tmpl", p); err != nil {
+		log.Fatalln(err)
+	}
+	os.Stdout.Write(buf.Bytes())
+}
+
+// candidate returns the newest release candidate on the upgrade server,
+// or nil if there is none newer than the latest release.
+func candidate(url string, latest relnotes.Version) (*upgradeRelease, error) {
+	resp, err := httpclient.Default.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	var rels []upgradeRelease
+	if err := json.NewDecoder(resp.Body).Decode(&rels); err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	var res *upgradeRelease
+	for i, rel := range rels {
+		base, _, _ := strings.Cut(rel.Tag, "-")
+		v, ok := relnotes.ParseVersion(base)
+		if !rel.Prerelease || !ok || !latest.Less(v) {
+			continue
+		}
+		if res == nil || rel.PublishedAt.After(res.PublishedAt) {
+			res = &rels[i]
+		}
+	}
+	return res, nil
+}
+
+// majors summarizes the releases, oldest first, per major version,
+// newest first.
+func majors(rels []versions.Release) []major {
+	var res []major
+	for _, r := range rels {
+		if len(res) == 0 || res[0].Major != r.Version.Major {
+			res = append([]major{{Major: r.Version.Major, First: r}}, res...)
+		}
+		m := &res[0]
+		m.Last = r
+		m.Count++
+	}
+	for i := range res {
+		res[i].Series = seriesTable(rels, res[i].Major)
+	}
+	return res
+}
+
+// seriesTable returns the table of the minor series of the major
+// version: the number of releases, the first and latest, and the Go
+// versions they were built with.
+func seriesTable(rels []versions.Release, maj int) string {
+	t := rst.Table{
+		Title:  fmt.Sprintf("Syncthing %d.x Release Series", maj),
+		Header: []string{"Series", "Releases", "First Release", "Latest Release", "Go"},
+	}
+	// Newest first, as the table of all releases.
+	for i := len(rels) - 1; i >= 0; {
+		r := rels[i]
+		if r.Version.Major != maj {
+			i--
+			continue
+		}
+		series := r.Version.Series()
+		latest, first := r, r
+		minGo, maxGo := r.Runtime, r.Runtime
+		n := 0
+		for ; i >= 0 && rels[i].Version.Series() == series; i-- {
+			first = rels[i]
+			n++
+			if goLess(rels[i].Runtime, minGo) {
+				minGo = rels[i].Runtime
+			}
+			if goLess(maxGo, rels[i].Runtime) {
+				maxGo = rels[i].Runtime
+			}
+		}
+		goSpan := minGo
+		if maxGo != minGo {
+			goSpan += " – " + maxGo
+		}
+		t.Rows = append(t.Rows, []string{
+			series,
+			fmt.Sprint(n),
+			fmt.Sprintf("%s (%s)", first.Tag, first.Date.Format(time.DateOnly)),
+			fmt.Sprintf("%s (%s)", latest.Tag, latest.Date.Format(time.DateOnly)),
+			goSpan,
+		})
+	}
+	var sb strings.Builder
+	t.WriteTo(&sb)
+	return strings.TrimSpace(sb.String())
+}
+
+// goLess reports whether the Go version a, as in go1.21.6, is older
+// than b.
+func goLess(a, b string) bool {
+	av, bv := goVersion(a), goVersion(b)
+	for i := 0; i < len(av) && i < len(bv); i++ {
+		if av[i] != bv[i] {
+			return av[i] < bv[i]
+		}
+	}
+	return len(av) < len(bv)
+}
+
+func goVersion(s string) []int {
+	var res []int
+	for _, p := range strings.Split(strings.TrimPrefix(s, "go"), ".") {
+		var n int
+		fmt.Sscan(p, &n)
+		res = append(res, n)
+	}
+	return res
+}
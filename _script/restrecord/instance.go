@@ -0,0 +1,257 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"text/template"
+	"time"
+)
+
+// startTimeout is how long to wait for Syncthing to start serving the
+// API, and scanTimeout for the folders to be scanned.
+const (
+	startTimeout = 30 * time.Second
+	scanTimeout  = time.Minute
+)
+
+type instance struct {
+	cmd    *exec.Cmd
+	tmp    string
+	user   string // the user's home directory, containing the folders
+	home   string // Syncthing's
+	addr   string
+	url    string
+	apiKey string
+	myID   string
+	done   chan struct{}
+}
+
+var folderPathExp = regexp.MustCompile(`<folder [^>]*path="([^"]+)"`)
+
+// start runs Syncthing with the seed configuration and files, in a
+// temporary directory standing in for the user's home directory.
+func start(binary, configTemplate string, files map[string]int) (*instance, error) {
+	tpl, err := template.ParseFiles(configTemplate)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "restrecord-")
+	if err != nil {
+		return nil, err
+	}
+	addr, err := freeAddress()
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	user := filepath.Join(tmp, "user")
+	inst := &instance{
+		tmp:    tmp,
+		user:   user,
+		home:   filepath.Join(user, ".local", "state", "syncthing"),
+		addr:   addr,
+		url:    "http://" + addr + "/",
+		apiKey: hex.EncodeToString(key),
+		done:   make(chan struct{}),
+	}
+	if err := os.MkdirAll(inst.home, 0o700); err != nil {
+		return nil, err
+	}
+
+	var cfg bytes.Buffer
+	err = tpl.Execute(&cfg, map[string]string{
+		"Data":    user,
+		"Address": addr,
+		"APIKey":  inst.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range folderPathExp.FindAllSubmatch(cfg.Bytes(), -1) {
+		if err := os.MkdirAll(filepath.Join(html.UnescapeString(string(m[1])), ".stfolder"), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(inst.home, "config.xml"), cfg.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+	for name, size := range files {
+		if err := writeFile(filepath.Join(user, filepath.FromSlash(name)), size); err != nil {
+			return nil, err
+		}
+	}
+
+	inst.cmd = exec.Command(binary, "serve", "--home="+inst.home, "--no-browser", "--no-restart", "--no-upgrade", "--logfile="+filepath.Join(inst.home, "syncthing.log"))
+	inst.cmd.Env = append(os.Environ(), "HOME="+user, "STNODEFAULTFOLDER=1", "STNOUPGRADE=1")
+	if err := inst.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		inst.cmd.Wait()
+		close(inst.done)
+	}()
+
+	if err := inst.waitReady(); err != nil {
+		inst.stop()
+		return nil, err
+	}
+	var status struct {
+		MyID string `json:"myID"`
+	}
+	if err := inst.get("rest/system/status", &status); err != nil {
+		inst.stop()
+		return nil, err
+	}
+	inst.myID = status.MyID
+	if err := inst.waitScanned(); err != nil {
+		inst.stop()
+		return nil, err
+	}
+	return inst, nil
+}
+
+// writeFile writes a file of the given size, with content that doesn't
+// compress or deduplicate to nothing.
+func writeFile(path string, size int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	bs := make([]byte, size)
+	if _, err := rand.Read(bs); err != nil {
+		return err
+	}
+	return os.WriteFile(path, bs, 0o644)
+}
+
+func (i *instance) waitReady() error {
+	deadline := time.Now().Add(startTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-i.done:
+			return fmt.Errorf("syncthing exited, see %s", filepath.Join(i.home, "syncthing.log"))
+		case <-time.After(250 * time.Millisecond):
+		}
+		if i.get("rest/noauth/health", nil) == nil {
+			return nil
+		}
+	}
+	return errors.New("timeout waiting for syncthing to start")
+}
+
+// waitScanned waits for every folder to have been scanned and become
+// idle, so that the database calls have something to show.
+func (i *instance) waitScanned() error {
+	var folders []struct {
+		ID string `json:"id"`
+	}
+	if err := i.get("rest/config/folders", &folders); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(scanTimeout)
+	for _, f := range folders {
+		for {
+			var stats map[string]struct {
+				LastScan time.Time `json:"lastScan"`
+			}
+			var status struct {
+				State string `json:"state"`
+			}
+			if err := i.get("rest/stats/folder", &stats); err != nil {
+				return err
+			}
+			if err := i.get("rest/db/status?folder="+f.ID, &status); err != nil {
+				return err
+			}
+			if status.State == "idle" && !stats[f.ID].LastScan.IsZero() {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout waiting for folder %s to be scanned", f.ID)
+			}
+			time.Sleep(250 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// stop shuts Syncthing down and removes the temporary directory.
+func (i *instance) stop() {
+	if i.cmd.Process != nil {
+		if _, _, err := i.request(http.MethodPost, "rest/system/shutdown", nil); err != nil {
+			i.cmd.Process.Kill()
+		}
+		select {
+		case <-i.done:
+		case <-time.After(10 * time.Second):
+			i.cmd.Process.Kill()
+			<-i.done
+		}
+	}
+	os.RemoveAll(i.tmp)
+}
+
+// get makes a GET request, decoding the response into res if given.
+func (i *instance) get(path string, res any) error {
+	status, bs, err := i.request(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, http.StatusText(status))
+	}
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(bs, res)
+}
+
+// request makes a REST API request, returning the status code and body
+// of the response.
+func (i *instance) request(method, path string, body []byte) (int, []byte, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, i.url+path, r)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("X-API-Key", i.apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, bs, err
+}
+
+func freeAddress() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return l.Addr().String(), nil
+}
@@ -0,0 +1,168 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./restrecord -syncthing path/to/syncthing [-out ../includes/rest]
+//
+// Records the example responses of the REST API pages. A Syncthing
+// instance is started with the seed configuration and files, the setup
+// requests in calls.json are made, and then each call there, saving the
+// response as a JSON include file for the page. The responses are
+// sanitised: the temporary paths, local device ID, addresses and times
+// are replaced with fixed values, keeping the recordings readable and
+// the differences between them to what matters.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type callList struct {
+	// DeviceName is the name given to the local device.
+	DeviceName string `json:"deviceName"`
+	// Files are created in the user's home directory before starting,
+	// with random content of the given size.
+	Files map[string]int `json:"files"`
+	Setup []request      `json:"setup"`
+	Calls []call         `json:"calls"`
+}
+
+type request struct {
+	Method string `json:"method"`
+	// Path may refer to the local device ID as {myID}.
+	Path string `json:"path"`
+	Body any    `json:"body"`
+}
+
+type call struct {
+	// File is the include file to write, relative to the output
+	// directory.
+	File string `json:"file"`
+	Path string `json:"path"`
+}
+
+func main() {
+	log.SetFlags(0)
+	binary := flag.String("syncthing", "syncthing", "Syncthing binary to run")
+	list := flag.String("calls", "restrecord/calls.json", "Calls to record")
+	config := flag.String("config", "restrecord/seed-config.xml", "Seed configuration template")
+	out := flag.String("out", "../includes/rest", "Directory to write the responses to")
+	only := flag.String("only", "", "Comma separated files to record, instead of all")
+	flag.Parse()
+
+	calls, err := readCalls(*list)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *only != "" {
+		calls.Calls = selectCalls(calls.Calls, strings.Split(*only, ","))
+	}
+
+	inst, err := start(*binary, *config, calls.Files)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	err = record(inst, calls, *out)
+	inst.stop()
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func readCalls(path string) (*callList, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var calls callList
+	if err := json.Unmarshal(bs, &calls); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &calls, nil
+}
+
+func selectCalls(calls []call, files []string) []call {
+	var res []call
+	for _, f := range files {
+		found := false
+		for _, c := range calls {
+			if c.File == f {
+				res = append(res, c)
+				found = true
+			}
+		}
+		if !found {
+			log.Fatalln("no such call:", f)
+		}
+	}
+	return res
+}
+
+func record(inst *instance, calls *callList, out string) error {
+	if calls.DeviceName != "" {
+		name := request{Method: http.MethodPatch, Path: "/rest/config/devices/{myID}", Body: map[string]string{"name": calls.DeviceName}}
+		calls.Setup = append([]request{name}, calls.Setup...)
+	}
+	for _, r := range calls.Setup {
+		var body []byte
+		if r.Body != nil {
+			var err error
+			if body, err = json.Marshal(r.Body); err != nil {
+				return err
+			}
+		}
+		status, _, err := inst.request(r.Method, inst.expand(r.Path), body)
+		if err != nil {
+			return err
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("%s %s: %s", r.Method, r.Path, http.StatusText(status))
+		}
+	}
+	if err := inst.waitScanned(); err != nil {
+		return err
+	}
+
+	s := newSanitizer(inst)
+	for _, c := range calls.Calls {
+		status, bs, err := inst.request(http.MethodGet, inst.expand(c.Path), nil)
+		if err != nil {
+			return err
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("GET %s: %s", c.Path, http.StatusText(status))
+		}
+		var v any
+		if err := json.Unmarshal(bs, &v); err != nil {
+			return fmt.Errorf("GET %s: %w", c.Path, err)
+		}
+		bs, err = json.MarshalIndent(s.value(v), "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(out, filepath.FromSlash(c.File))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, append(bs, '\n'), 0o644); err != nil {
+			return err
+		}
+		log.Println("recorded", c.Path)
+	}
+	return nil
+}
+
+// expand fills in the local device ID and strips the leading slash, the
+// instance URL having one.
+func (i *instance) expand(path string) string {
+	return strings.TrimPrefix(strings.ReplaceAll(path, "{myID}", i.myID), "/")
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The fixed values put in place of those that change from run to run.
+const (
+	exampleUser    = "/home/user"
+	exampleID      = "MFZWI3D-BONSGYC-YLTMRWG-C43ENR5-QXGZDMM-FZWI3DP-BONSGYY-LTMRWAD"
+	exampleAddress = "127.0.0.1:8384"
+	exampleTime    = "2024-01-01T12:00:00.000000000+01:00"
+)
+
+var (
+	timeExp   = regexp.MustCompile(`\b\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:\.\d+)?(?:Z|[+-]\d\d:\d\d)`)
+	listenExp = regexp.MustCompile(`\b127\.0\.0\.1:\d+\b`)
+)
+
+type sanitizer struct {
+	replacer *strings.Replacer
+}
+
+func newSanitizer(inst *instance) *sanitizer {
+	// Longest first, the home directory being inside the user's.
+	pairs := [][2]string{
+		{inst.user, exampleUser},
+		{inst.myID, exampleID},
+		{inst.myID[:7], exampleID[:7]},
+		{inst.addr, exampleAddress},
+		{inst.apiKey, "abc123"},
+	}
+	sort.Slice(pairs, func(a, b int) bool { return len(pairs[a][0]) > len(pairs[b][0]) })
+	var args []string
+	for _, p := range pairs {
+		args = append(args, p[0], p[1])
+	}
+	return &sanitizer{strings.NewReplacer(args...)}
+}
+
+// value returns a sanitised copy of a decoded JSON value, including the
+// keys of objects.
+func (s *sanitizer) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, e := range v {
+			res[s.string(k)] = s.value(e)
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, e := range v {
+			res[i] = s.value(e)
+		}
+		return res
+	case string:
+		return s.string(v)
+	}
+	return v
+}
+
+func (s *sanitizer) string(str string) string {
+	str = s.replacer.Replace(str)
+	str = timeExp.ReplaceAllString(str, exampleTime)
+	return listenExp.ReplaceAllStringFunc(str, func(addr string) string {
+		if addr == exampleAddress {
+			return addr
+		}
+		return "127.0.0.1:22000"
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+func writeRoadmap(w io.Writer, rm roadmap) error {
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/roadmap; do not edit.\n\n")
+	fmt.Fprintf(&sb, "This is a snapshot of the roadmap as of %s.\n\n", rm.Date.Format(time.DateOnly))
+
+	sb.WriteString(rst.Heading("Planned for the Next Release", '-'))
+	if rm.Next == nil {
+		sb.WriteString("There's no milestone for the next release yet.\n\n")
+	} else {
+		writeMilestone(&sb, *rm.Next)
+	}
+
+	sb.WriteString(rst.Heading("In Progress", '-'))
+	if len(rm.InProgress) == 0 && len(rm.Assigned) == 0 {
+		sb.WriteString("Nothing beyond the next release is in progress.\n\n")
+	}
+	for _, m := range rm.InProgress {
+		sb.WriteString(rst.Heading(m.Title, '~'))
+		writeMilestone(&sb, m)
+	}
+	if len(rm.Assigned) > 0 {
+		if len(rm.InProgress) > 0 {
+			sb.WriteString(rst.Heading("Other Work", '~'))
+		}
+		writeItems(&sb, rm.Assigned)
+	}
+
+	sb.WriteString(rst.Heading("Long-Term", '-'))
+	if len(rm.LongTerm) == 0 && len(rm.Other) == 0 {
+		sb.WriteString("There are no long-term plans listed.\n\n")
+	}
+	if len(rm.LongTerm) > 0 {
+		writeItems(&sb, rm.LongTerm)
+	}
+	if len(rm.Other) > 0 {
+		sb.WriteString("Issues are also collected in these milestones, without a release planned:\n\n")
+		for _, m := range rm.Other {
+			fmt.Fprintf(&sb, "- %s, %s\n", rst.Link(m.Title, m.URL), count(m.Open, "open issue"))
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeMilestone writes the progress of the milestone and its open items.
+func writeMilestone(sb *strings.Builder, m milestone) {
+	fmt.Fprintf(sb, "The %s milestone", rst.Link(m.Title, m.URL))
+	if !m.DueOn.IsZero() {
+		fmt.Fprintf(sb, ", due %s,", m.DueOn.UTC().Format(time.DateOnly))
+	}
+	fmt.Fprintf(sb, " has %d of %s done.\n\n", m.Closed, count(m.Open+m.Closed, "item"))
+	var open []item
+	for _, it := range m.Items {
+		if it.Open {
+			open = append(open, it)
+		}
+	}
+	if len(open) > 0 {
+		writeItems(sb, open)
+	}
+}
+
+func writeItems(sb *strings.Builder, items []item) {
+	for _, it := range items {
+		title := it.Title
+		if it.Note != "" {
+			title = it.Note
+		}
+		fmt.Fprintf(sb, "- %s %s", rst.Link(fmt.Sprintf("#%d", it.Number), it.URL), rst.Escape(title))
+		var details []string
+		if it.Pull {
+			details = append(details, "pull request")
+		}
+		if len(it.Assignees) > 0 {
+			details = append(details, "assigned to "+strings.Join(it.Assignees, ", "))
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(sb, " (%s)", strings.Join(details, "; "))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+}
+
+func count(n int, what string) string {
+	if n == 1 {
+		return "1 " + what
+	}
+	return fmt.Sprintf("%d %ss", n, what)
+}
@@ -0,0 +1,247 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./roadmap > ../includes/roadmap.rst
+//
+// Writes a snapshot of the Syncthing roadmap from the GitHub milestones
+// and the tracking issues listed in roadmap.csv on the roadmap page:
+// what's planned for the next release (the open milestone for the lowest
+// version), what's in progress (the other version milestones, and the
+// assigned tracking issues) and the long-term plans (the other tracking
+// issues, and milestones not named after a version). Set GITHUB_TOKEN to
+// avoid the rate limit for unauthenticated requests.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v49/github"
+	"syncthing.net/docs/internal/relnotes"
+)
+
+// item is an issue or pull request on the roadmap.
+type item struct {
+	Number    int
+	Title     string
+	URL       string
+	Pull      bool
+	Open      bool
+	Assignees []string
+	// Note is the curated description of a tracking issue, if any.
+	Note string
+}
+
+// milestone is a milestone with its issues and pull requests.
+type milestone struct {
+	number  int
+	Title   string
+	URL     string
+	DueOn   time.Time
+	Open    int
+	Closed  int
+	Version relnotes.Version
+	// Versioned is whether the milestone is named after a release.
+	Versioned bool
+	Items     []item
+}
+
+// tracking is a row of the tracking issues file.
+type tracking struct {
+	Number int
+	Note   string
+}
+
+// roadmap is the snapshot written to the page.
+type roadmap struct {
+	Date       time.Time
+	Next       *milestone
+	InProgress []milestone
+	Assigned   []item
+	LongTerm   []item
+	Other      []milestone
+}
+
+func main() {
+	log.SetFlags(0)
+	repo := flag.String("repo", "syncthing/syncthing", "Repository to take the milestones and issues from")
+	trackingFile := flag.String("tracking", "../dev/roadmap.csv", "Tracking issues to list")
+	flag.Parse()
+
+	tracked, err := readTracking(*trackingFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	owner, name, ok := strings.Cut(*repo, "/")
+	if !ok {
+		log.Fatalf("bad repository %q, expected owner/name", *repo)
+	}
+
+	ctx := context.Background()
+	client := relnotes.Client()
+	ms, err := listMilestones(ctx, client, owner, name)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	rm := roadmap{Date: time.Now().UTC()}
+	for i := range ms {
+		m := &ms[i]
+		if !m.Versioned {
+			rm.Other = append(rm.Other, *m)
+			continue
+		}
+		if m.Items, err = listItems(ctx, client, owner, name, m.number); err != nil {
+			log.Fatalf("%s: %v", m.Title, err)
+		}
+		if rm.Next == nil {
+			rm.Next = m
+			continue
+		}
+		rm.InProgress = append(rm.InProgress, *m)
+	}
+	for _, t := range tracked {
+		iss, _, err := client.Issues.Get(ctx, owner, name, t.Number)
+		if err != nil {
+			log.Fatalf("#%d: %v", t.Number, err)
+		}
+		it := newItem(iss)
+		it.Note = t.Note
+		switch {
+		case !it.Open:
+			log.Printf("tracking issue #%d is closed; consider removing it from %s", t.Number, *trackingFile)
+		case len(it.Assignees) > 0:
+			rm.Assigned = append(rm.Assigned, it)
+		default:
+			rm.LongTerm = append(rm.LongTerm, it)
+		}
+	}
+
+	if err := writeRoadmap(os.Stdout, rm); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// listMilestones returns the open milestones, the ones named after a
+// release first, in version order, then the others by title.
+func listMilestones(ctx context.Context, client *github.Client, owner, repo string) ([]milestone, error) {
+	opts := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	var res []milestone
+	for {
+		ms, resp, err := client.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range ms {
+			v, ok := relnotes.ParseVersion(strings.TrimSpace(m.GetTitle()))
+			res = append(res, milestone{
+				number:    m.GetNumber(),
+				Title:     m.GetTitle(),
+				URL:       m.GetHTMLURL(),
+				DueOn:     m.GetDueOn(),
+				Open:      m.GetOpenIssues(),
+				Closed:    m.GetClosedIssues(),
+				Version:   v,
+				Versioned: ok,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.Slice(res, func(a, b int) bool {
+		if res[a].Versioned != res[b].Versioned {
+			return res[a].Versioned
+		}
+		if res[a].Versioned {
+			return res[a].Version.Less(res[b].Version)
+		}
+		return res[a].Title < res[b].Title
+	})
+	return res, nil
+}
+
+// listItems returns the issues and pull requests in the milestone, open
+// ones first, each by number.
+func listItems(ctx context.Context, client *github.Client, owner, repo string, number int) ([]item, error) {
+	opts := &github.IssueListByRepoOptions{
+		Milestone:   strconv.Itoa(number),
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var res []item
+	for {
+		issues, resp, err := client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, iss := range issues {
+			res = append(res, newItem(iss))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.Slice(res, func(a, b int) bool {
+		if res[a].Open != res[b].Open {
+			return res[a].Open
+		}
+		return res[a].Number < res[b].Number
+	})
+	return res, nil
+}
+
+func newItem(iss *github.Issue) item {
+	it := item{
+		Number: iss.GetNumber(),
+		Title:  iss.GetTitle(),
+		URL:    iss.GetHTMLURL(),
+		Pull:   iss.IsPullRequest(),
+		Open:   iss.GetState() == "open",
+	}
+	for _, a := range iss.Assignees {
+		it.Assignees = append(it.Assignees, a.GetLogin())
+	}
+	return it
+}
+
+// readTracking reads the tracking issues file, with columns Issue and
+// Note. Lines starting with # are comments.
+func readTracking(file string) ([]tracking, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	cr := csv.NewReader(fd)
+	cr.Comment = '#'
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	var res []tracking
+	for i, rec := range records {
+		if i == 0 {
+			// Header
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(rec[0], "#"))
+		if err != nil || len(rec) != 2 {
+			return nil, fmt.Errorf("%s: row %d: expected an issue number and a note", file, i+1)
+		}
+		res = append(res, tracking{Number: n, Note: rec[1]})
+	}
+	return res, nil
+}
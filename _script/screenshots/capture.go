@@ -0,0 +1,69 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// readySelector is an element visible once the GUI has loaded.
+	readySelector = "#folders"
+	// settle is how long to let the GUI load its state before running
+	// the steps.
+	settle = 2 * time.Second
+)
+
+func capture(allocCtx context.Context, url string, s shot, file string, scale float64) error {
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+	ctx, cancel = context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	tasks := chromedp.Tasks{
+		chromedp.EmulateViewport(int64(s.Width), int64(s.Height), chromedp.EmulateScale(scale)),
+		chromedp.Navigate(url),
+		chromedp.WaitVisible(readySelector, chromedp.ByQuery),
+		chromedp.Sleep(settle),
+	}
+	for _, st := range s.Steps {
+		switch {
+		case st.Click != "":
+			tasks = append(tasks, chromedp.Click(st.Click, chromedp.ByQuery))
+		case st.Wait != "":
+			tasks = append(tasks, chromedp.WaitVisible(st.Wait, chromedp.ByQuery))
+		case st.Sleep != "":
+			d, _ := time.ParseDuration(st.Sleep)
+			tasks = append(tasks, chromedp.Sleep(d))
+		case st.Highlight != "":
+			sel, _ := json.Marshal(st.Highlight)
+			js := fmt.Sprintf(`document.querySelector(%s).style.outline = "2px solid red"`, sel)
+			tasks = append(tasks, chromedp.WaitVisible(st.Highlight, chromedp.ByQuery), chromedp.Evaluate(js, nil))
+		}
+	}
+
+	var buf []byte
+	if s.Clip != "" {
+		tasks = append(tasks, chromedp.Screenshot(s.Clip, &buf, chromedp.ByQuery))
+	} else {
+		tasks = append(tasks, chromedp.CaptureScreenshot(&buf))
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf, 0o644)
+}
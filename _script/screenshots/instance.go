@@ -0,0 +1,195 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"text/template"
+	"time"
+)
+
+// startTimeout is how long to wait for Syncthing to start serving the
+// GUI.
+const startTimeout = 30 * time.Second
+
+type instance struct {
+	cmd     *exec.Cmd
+	home    string
+	url     string
+	apiKey  string
+	version string
+	done    chan struct{}
+}
+
+var folderPathExp = regexp.MustCompile(`<folder [^>]*path="([^"]+)"`)
+
+// start runs Syncthing in a temporary home directory with the demo
+// configuration, and names the local device.
+func start(binary, configTemplate, deviceName string) (*instance, error) {
+	tpl, err := template.ParseFiles(configTemplate)
+	if err != nil {
+		return nil, err
+	}
+	home, err := os.MkdirTemp("", "screenshots-")
+	if err != nil {
+		return nil, err
+	}
+	addr, err := freeAddress()
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	inst := &instance{
+		home:   home,
+		url:    "http://" + addr + "/",
+		apiKey: hex.EncodeToString(key),
+		done:   make(chan struct{}),
+	}
+
+	var cfg bytes.Buffer
+	err = tpl.Execute(&cfg, map[string]string{
+		"Data":    filepath.Join(home, "data"),
+		"Address": addr,
+		"APIKey":  inst.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range folderPathExp.FindAllSubmatch(cfg.Bytes(), -1) {
+		if err := os.MkdirAll(filepath.Join(html.UnescapeString(string(m[1])), ".stfolder"), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(home, "config.xml"), cfg.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+
+	inst.cmd = exec.Command(binary, "serve", "--home="+home, "--no-browser", "--no-restart", "--no-upgrade", "--logfile="+filepath.Join(home, "syncthing.log"))
+	inst.cmd.Env = append(os.Environ(), "STNODEFAULTFOLDER=1", "STNOUPGRADE=1")
+	if err := inst.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		inst.cmd.Wait()
+		close(inst.done)
+	}()
+
+	if err := inst.waitReady(); err != nil {
+		inst.stop()
+		return nil, err
+	}
+	var status struct {
+		MyID string `json:"myID"`
+	}
+	if err := inst.request(http.MethodGet, "rest/system/status", nil, &status); err != nil {
+		inst.stop()
+		return nil, err
+	}
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := inst.request(http.MethodGet, "rest/system/version", nil, &version); err != nil {
+		inst.stop()
+		return nil, err
+	}
+	inst.version = version.Version
+	if err := inst.request(http.MethodPatch, "rest/config/devices/"+status.MyID, map[string]string{"name": deviceName}, nil); err != nil {
+		inst.stop()
+		return nil, err
+	}
+	return inst, nil
+}
+
+func (i *instance) waitReady() error {
+	deadline := time.Now().Add(startTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-i.done:
+			return fmt.Errorf("syncthing exited, see %s", filepath.Join(i.home, "syncthing.log"))
+		case <-time.After(250 * time.Millisecond):
+		}
+		if i.request(http.MethodGet, "rest/noauth/health", nil, nil) == nil {
+			return nil
+		}
+	}
+	return errors.New("timeout waiting for syncthing to start")
+}
+
+func (i *instance) setTheme(theme string) error {
+	return i.request(http.MethodPatch, "rest/config/gui", map[string]string{"theme": theme}, nil)
+}
+
+// stop shuts Syncthing down and removes its home directory.
+func (i *instance) stop() {
+	if i.cmd.Process != nil {
+		if i.request(http.MethodPost, "rest/system/shutdown", nil, nil) != nil {
+			i.cmd.Process.Kill()
+		}
+		select {
+		case <-i.done:
+		case <-time.After(10 * time.Second):
+			i.cmd.Process.Kill()
+			<-i.done
+		}
+	}
+	os.RemoveAll(i.home)
+}
+
+// request makes a REST API request, decoding the response into res if
+// given.
+func (i *instance) request(method, path string, body, res any) error {
+	var r io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(bs)
+	}
+	req, err := http.NewRequest(method, i.url+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", i.apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if res == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+func freeAddress() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return l.Addr().String(), nil
+}
@@ -0,0 +1,196 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./screenshots -syncthing path/to/syncthing [-only intro/gui1.png]
+//
+// Captures the screenshots of the GUI used in the docs. A Syncthing
+// instance is started with the demo configuration and driven with
+// headless Chrome through the steps given for each screenshot in
+// shots.json, at the size and in the themes given there. Screenshots in
+// themes other than the default get the theme name appended to the file
+// name. Next to each screenshot, a .json file records the version it was
+// captured with and the GUI elements involved, for shotcheck.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+type shotList struct {
+	// DeviceName is the name given to the local device.
+	DeviceName string `json:"deviceName"`
+	Shots      []shot `json:"shots"`
+}
+
+type shot struct {
+	// File is the path of the screenshot, relative to the docs root.
+	File   string `json:"file"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	// Themes defaults to the default theme only.
+	Themes []string `json:"themes"`
+	Steps  []step   `json:"steps"`
+	// Clip is a selector for the element to capture, instead of the
+	// whole viewport.
+	Clip string `json:"clip"`
+}
+
+// step is one thing to do in the GUI before the capture. A step has one
+// of its fields set.
+type step struct {
+	Click     string `json:"click"`
+	Wait      string `json:"wait"`
+	Sleep     string `json:"sleep"`
+	Highlight string `json:"highlight"`
+}
+
+func main() {
+	log.SetFlags(0)
+	binary := flag.String("syncthing", "syncthing", "Syncthing binary to run")
+	list := flag.String("shots", "screenshots/shots.json", "Screenshot definitions")
+	config := flag.String("config", "screenshots/demo-config.xml", "Demo configuration template")
+	out := flag.String("out", "..", "Documentation root to write the screenshots to")
+	only := flag.String("only", "", "Comma separated screenshot files to capture, instead of all")
+	scale := flag.Float64("scale", 1, "Device pixel ratio")
+	chrome := flag.String("chrome", "", "Chrome binary, instead of the one found on the path")
+	flag.Parse()
+
+	shots, err := readShots(*list)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *only != "" {
+		shots.Shots = selectShots(shots.Shots, strings.Split(*only, ","))
+	}
+
+	inst, err := start(*binary, *config, shots.DeviceName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer inst.stop()
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.Flag("hide-scrollbars", true))
+	if *chrome != "" {
+		opts = append(opts, chromedp.ExecPath(*chrome))
+	}
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	defer cancel()
+
+	failed := false
+	for _, s := range shots.Shots {
+		themes := s.Themes
+		if len(themes) == 0 {
+			themes = []string{"default"}
+		}
+		for _, theme := range themes {
+			file := themedFile(s.File, theme)
+			if err := inst.setTheme(theme); err != nil {
+				log.Fatalln(err)
+			}
+			name := filepath.Join(*out, filepath.FromSlash(file))
+			if err := capture(allocCtx, inst.url, s, name, *scale); err != nil {
+				log.Printf("%s: %v", file, err)
+				failed = true
+				continue
+			}
+			if err := writeMeta(name, inst.version, theme, s); err != nil {
+				log.Fatalln(err)
+			}
+			log.Printf("%s: captured", file)
+		}
+	}
+	if failed {
+		inst.stop()
+		os.Exit(1)
+	}
+}
+
+func readShots(name string) (*shotList, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var l shotList
+	if err := json.Unmarshal(bs, &l); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, s := range l.Shots {
+		for _, st := range s.Steps {
+			if st.Sleep != "" {
+				if _, err := time.ParseDuration(st.Sleep); err != nil {
+					return nil, fmt.Errorf("%s: %s: %w", name, s.File, err)
+				}
+			}
+		}
+	}
+	return &l, nil
+}
+
+func selectShots(shots []shot, files []string) []shot {
+	want := make(map[string]bool)
+	for _, f := range files {
+		want[strings.TrimSpace(f)] = true
+	}
+	var res []shot
+	for _, s := range shots {
+		if want[s.File] {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// meta is the sidecar metadata of a screenshot.
+type meta struct {
+	Version  string `json:"version"`
+	Captured string `json:"captured"`
+	Theme    string `json:"theme"`
+	// Selectors are those of the elements the screenshot depends on.
+	Selectors []string `json:"selectors"`
+}
+
+func writeMeta(name, version, theme string, s shot) error {
+	m := meta{
+		Version:   version,
+		Captured:  time.Now().UTC().Format("2006-01-02"),
+		Theme:     theme,
+		Selectors: []string{readySelector},
+	}
+	for _, st := range s.Steps {
+		for _, sel := range []string{st.Click, st.Wait, st.Highlight} {
+			if sel != "" {
+				m.Selectors = append(m.Selectors, sel)
+			}
+		}
+	}
+	if s.Clip != "" {
+		m.Selectors = append(m.Selectors, s.Clip)
+	}
+	bs, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name+".json", append(bs, '\n'), 0o644)
+}
+
+// themedFile returns the file name for a screenshot in the given theme.
+func themedFile(file, theme string) string {
+	if theme == "default" {
+		return file
+	}
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "-" + theme + ext
+}
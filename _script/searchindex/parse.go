@@ -0,0 +1,129 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// parsePage returns the title, headings and text of the main content of
+// a page, and its keywords, or false if the page has no main content,
+// such as a redirect.
+func parsePage(r io.Reader) (page, bool, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return page{}, false, err
+	}
+	main := find(doc, func(n *html.Node) bool {
+		return n.DataAtom == atom.Div && attr(n, "role") == "main"
+	})
+	if main == nil {
+		return page{}, false, nil
+	}
+
+	var pg page
+	if m := find(doc, func(n *html.Node) bool {
+		return n.DataAtom == atom.Meta && attr(n, "name") == "keywords"
+	}); m != nil {
+		pg.Keywords = attr(m, "content")
+	}
+	var body strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			body.WriteString(n.Data)
+			body.WriteByte(' ')
+			return
+		case n.Type != html.ElementNode && n.Type != html.DocumentNode:
+			return
+		case n.DataAtom == atom.Script || n.DataAtom == atom.Style:
+			return
+		case n.DataAtom == atom.A && hasClass(n, "headerlink"):
+			return
+		case isHeading(n):
+			text := collapse(textOf(n))
+			if n.DataAtom == atom.H1 && pg.Title == "" {
+				pg.Title = text
+			} else {
+				pg.Headings = append(pg.Headings, text)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(main)
+	pg.Body = collapse(body.String())
+	return pg, true, nil
+}
+
+func isHeading(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
+
+// textOf returns the text of a node, without header links.
+func textOf(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		if n.DataAtom == atom.A && hasClass(n, "headerlink") {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+func find(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if m := find(c, match); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./searchindex -html ../_build/html > ../_build/html/search-index.json
+//
+// Reads the pages of the built HTML documentation and writes a search
+// index of their titles, keywords, headings and text, either as the
+// documents and field weights for lunr to index on the client, or as a
+// stork configuration with the contents inline. The keywords are those of
+// the meta directive of the page.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// skipped are pages generated by Sphinx that have no content to search.
+var skipped = map[string]bool{
+	"genindex.html": true,
+	"search.html":   true,
+}
+
+type page struct {
+	URL      string   `json:"id"`
+	Title    string   `json:"title"`
+	Headings []string `json:"headings"`
+	Keywords string   `json:"keywords"`
+	Body     string   `json:"body"`
+}
+
+type field struct {
+	Name  string `json:"name"`
+	Boost int    `json:"boost"`
+}
+
+// fields are the indexed fields, with the weight of a match in each.
+var fields = []field{
+	{"title", 10},
+	{"keywords", 8},
+	{"headings", 5},
+	{"body", 1},
+}
+
+func main() {
+	log.SetFlags(0)
+	dir := flag.String("html", "../_build/html", "Built HTML documentation")
+	format := flag.String("format", "lunr", "Index format (lunr, stork)")
+	maxBody := flag.Int("max-body", 0, "Maximum length of the body text of a page, or zero for no limit")
+	flag.Parse()
+
+	pages, err := readPages(*dir, *maxBody)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	switch *format {
+	case "lunr":
+		err = writeLunr(os.Stdout, pages)
+	case "stork":
+		err = writeStork(os.Stdout, pages)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func readPages(dir string, maxBody int) ([]page, error) {
+	var pages []page
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != dir && strings.HasPrefix(d.Name(), "_") {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() || !strings.HasSuffix(rel, ".html") || skipped[rel] {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		pg, ok, err := parsePage(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		if !ok {
+			return nil
+		}
+		pg.URL = rel
+		if maxBody > 0 && len(pg.Body) > maxBody {
+			pg.Body = truncate(pg.Body, maxBody)
+		}
+		pages = append(pages, pg)
+		return nil
+	})
+	sort.Slice(pages, func(a, b int) bool {
+		return pages[a].URL < pages[b].URL
+	})
+	return pages, err
+}
+
+// truncate shortens s to at most n bytes, at a word boundary.
+func truncate(s string, n int) string {
+	s = s[:n]
+	if i := strings.LastIndexByte(s, ' '); i > 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+func writeLunr(w io.Writer, pages []page) error {
+	enc := json.NewEncoder(w)
+	return enc.Encode(map[string]any{
+		"fields":    fields,
+		"documents": pages,
+	})
+}
+
+// writeStork writes the pages as a stork configuration, for building the
+// index with "stork build".
+func writeStork(w io.Writer, pages []page) error {
+	var sb strings.Builder
+	sb.WriteString("[input]\n")
+	for _, p := range pages {
+		// A JSON string is a valid TOML basic string.
+		contents := p.Title + "\n" + p.Keywords + "\n" + strings.Join(p.Headings, "\n") + "\n" + p.Body
+		fmt.Fprintf(&sb, "\n[[input.files]]\ntitle = %s\nurl = %s\ncontents = %s\n", quote(p.Title), quote(p.URL), quote(contents))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func quote(s string) string {
+	bs, _ := json.Marshal(s)
+	return string(bs)
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// readGUI returns the concatenated HTML and JavaScript sources of the GUI.
+func readGUI(dir string) (string, error) {
+	var sb strings.Builder
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ext := filepath.Ext(p); ext != ".html" && ext != ".js" {
+			return nil
+		}
+		if strings.Contains(filepath.ToSlash(p), "/vendor/") {
+			return nil
+		}
+		bs, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sb.Write(bs)
+		return nil
+	})
+	return sb.String(), err
+}
+
+var (
+	selectorTokenExp = regexp.MustCompile(`[#.]([a-zA-Z0-9_-]+)|\[([a-zA-Z0-9_-]+)(?:[~|^$*]?="([^"]*)")?\]`)
+	// indexSuffixExp matches the index in ids generated per item, such
+	// as folder-0 from folder-{{$index}}.
+	indexSuffixExp = regexp.MustCompile(`[0-9]+$`)
+)
+
+// missingToken returns the first id, class or attribute of a CSS
+// selector that doesn't appear anywhere in the GUI source. This is a
+// heuristic: a token being present doesn't mean the selector matches.
+func missingToken(sel, gui string) string {
+	for _, m := range selectorTokenExp.FindAllStringSubmatch(sel, -1) {
+		for _, tok := range m[1:] {
+			if tok == "" {
+				continue
+			}
+			if !strings.Contains(gui, indexSuffixExp.ReplaceAllString(tok, "")) {
+				return tok
+			}
+		}
+	}
+	return ""
+}
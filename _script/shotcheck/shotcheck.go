@@ -0,0 +1,134 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./shotcheck -tag v1.27.0 [-max-minors 3] [-all]
+//
+// Checks the screenshots captured by the screenshots tool against the
+// given Syncthing version, using the metadata saved next to each:
+// screenshots more than -max-minors minor versions old are reported, as
+// are those depending on GUI elements that no longer seem to exist in the
+// GUI source. With -all, screenshots without metadata are reported too.
+// Exits with status 1 if anything was reported.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+var imageExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+}
+
+type meta struct {
+	Version   string   `json:"version"`
+	Captured  string   `json:"captured"`
+	Selectors []string `json:"selectors"`
+}
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to check against")
+	version := flag.String("version", "", "Version of the given source, if not checking out -tag")
+	maxMinors := flag.Int("max-minors", 3, "Number of minor versions a screenshot may be behind")
+	all := flag.Bool("all", false, "Also report screenshots without metadata")
+	flag.Parse()
+	current := *tag
+	if current == "" {
+		current = *version
+	}
+	if current == "" {
+		log.Fatalln("-tag or -version is required")
+	}
+
+	dir, cleanup, err := stsource.Open(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+	gui, err := readGUI(filepath.Join(dir, "gui", "default"))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var problems []string
+	err = filepath.WalkDir(*root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(*root, p)
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), "_") || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !imageExts[strings.ToLower(filepath.Ext(p))] {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		bs, err := os.ReadFile(p + ".json")
+		if os.IsNotExist(err) {
+			if *all {
+				problems = append(problems, fmt.Sprintf("%s: no capture metadata", rel))
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var m meta
+		if err := json.Unmarshal(bs, &m); err != nil {
+			return fmt.Errorf("%s.json: %w", rel, err)
+		}
+		if behind := minorsBehind(m.Version, current); behind > *maxMinors {
+			problems = append(problems, fmt.Sprintf("%s: captured with %s, %d minor versions behind %s", rel, m.Version, behind, current))
+		}
+		for _, sel := range m.Selectors {
+			if tok := missingToken(sel, gui); tok != "" {
+				problems = append(problems, fmt.Sprintf("%s: GUI element %q no longer found (%s)", rel, sel, tok))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// minorsBehind returns how many minor versions v is behind current,
+// counting each major version as a hundred minors.
+func minorsBehind(v, current string) int {
+	return minorIndex(current) - minorIndex(v)
+}
+
+func minorIndex(v string) int {
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	major, _ := strconv.Atoi(parts[0])
+	minor := 0
+	if len(parts) > 1 {
+		minor, _ = strconv.Atoi(parts[1])
+	}
+	return major*100 + minor
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"image"
+	"image/color"
+)
+
+// maxDelta is the largest possible color difference, between black and
+// white.
+const maxDelta = 35215
+
+// changedColor marks the changed pixels in the diff image.
+var changedColor = color.NRGBA{R: 230, G: 40, B: 40, A: 255}
+
+// diffImages returns an image of the changed pixels of two images of the
+// same size, drawn over a faded gray copy of the first, and the fraction
+// of pixels that changed.
+func diffImages(a, b image.Image, threshold float64) (*image.NRGBA, float64) {
+	bounds := a.Bounds()
+	ob := b.Bounds().Min
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	limit := maxDelta * threshold * threshold
+	changed := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ya, ia, qa := yiq(a.At(x, y))
+			yb, ib, qb := yiq(b.At(x-bounds.Min.X+ob.X, y-bounds.Min.Y+ob.Y))
+			dy, di, dq := ya-yb, ia-ib, qa-qb
+			px, py := x-bounds.Min.X, y-bounds.Min.Y
+			if 0.5053*dy*dy+0.299*di*di+0.1957*dq*dq > limit {
+				changed++
+				out.SetNRGBA(px, py, changedColor)
+				continue
+			}
+			// Faded to a tenth, towards white.
+			v := uint8(255 - (255-clamp(ya))/10)
+			out.SetNRGBA(px, py, color.NRGBA{v, v, v, 255})
+		}
+	}
+	return out, float64(changed) / float64(bounds.Dx()*bounds.Dy())
+}
+
+// yiq returns the color in the YIQ space, where distances are closer to
+// the perceived difference than in RGB, after blending it onto white.
+func yiq(c color.Color) (y, i, q float64) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	alpha := float64(n.A) / 255
+	blend := func(v uint8) float64 {
+		return 255 + (float64(v)-255)*alpha
+	}
+	r, g, b := blend(n.R), blend(n.G), blend(n.B)
+	y = r*0.29889531 + g*0.58662247 + b*0.11448223
+	i = r*0.59597799 - g*0.27417610 - b*0.32180189
+	q = r*0.21147017 - g*0.52261711 + b*0.31114694
+	return y, i, q
+}
+
+func clamp(v float64) uint8 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return uint8(v)
+}
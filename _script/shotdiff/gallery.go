@@ -0,0 +1,66 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+)
+
+var galleryTemplate = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Screenshot changes</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+figure { display: inline-block; margin: 0 1em 1em 0; vertical-align: top; }
+img { max-width: 40em; border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>Screenshot changes</h1>
+{{- if not .Changed}}
+<p>No screenshots changed since {{.Ref}}.</p>
+{{- end}}
+{{- range .Changed}}
+<h2>{{.File}} ({{.Summary}})</h2>
+{{- if .Old}}
+<figure><img src="{{.Old}}" alt=""><figcaption>At {{$.Ref}}</figcaption></figure>
+{{- end}}
+<figure><img src="{{.New}}" alt=""><figcaption>New</figcaption></figure>
+{{- if .Diff}}
+<figure><img src="{{.Diff}}" alt=""><figcaption>Changed pixels</figcaption></figure>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// galleryEntry is a result as shown in the gallery.
+type galleryEntry struct {
+	result
+	Summary string
+}
+
+// writeGallery writes the review page of the changed screenshots.
+func writeGallery(name, ref string, changed []result) error {
+	entries := make([]galleryEntry, len(changed))
+	for i := range changed {
+		entries[i] = galleryEntry{changed[i], changed[i].summary()}
+	}
+	var buf bytes.Buffer
+	err := galleryTemplate.Execute(&buf, map[string]any{
+		"Ref":     ref,
+		"Changed": entries,
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./shotdiff [-ref HEAD] [-out ../_build/shotdiff] [-revert]
+//
+// Compares the screenshots captured by the screenshots tool with the ones
+// committed at the git ref, and writes a gallery of those that changed to
+// index.html in the output directory, showing the committed and new
+// screenshot side by side with the changed pixels marked. Pixels count as
+// changed when their colors differ perceptibly, so that rendering noise
+// doesn't; a screenshot counts as changed when more than -min-changed of
+// its pixels did. With -revert, screenshots that didn't change are
+// restored to the committed version, so that only real changes are
+// committed.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type shotList struct {
+	Shots []struct {
+		File   string   `json:"file"`
+		Themes []string `json:"themes"`
+	} `json:"shots"`
+}
+
+// result is the comparison of a screenshot with the committed one.
+type result struct {
+	File string
+	// Old, New and Diff are the images in the gallery, relative to it.
+	Old, New, Diff string
+	// Changed is the fraction of pixels that changed, one if the size
+	// did, and Added is set for screenshots that weren't committed.
+	Changed float64
+	Added   bool
+	Resized bool
+}
+
+func main() {
+	log.SetFlags(0)
+	root := flag.String("root", "..", "Documentation root")
+	list := flag.String("shots", "screenshots/shots.json", "Screenshot definitions")
+	ref := flag.String("ref", "HEAD", "Git ref of the committed screenshots")
+	out := flag.String("out", "../_build/shotdiff", "Directory to write the gallery to")
+	threshold := flag.Float64("threshold", 0.1, "Color difference, from 0 to 1, above which a pixel counts as changed")
+	minChanged := flag.Float64("min-changed", 0.001, "Fraction of changed pixels above which a screenshot counts as changed")
+	revert := flag.Bool("revert", false, "Restore the committed version of the screenshots that didn't change")
+	flag.Parse()
+
+	files, err := shotFiles(*list)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalln(err)
+	}
+
+	var changed []result
+	for _, file := range files {
+		r, err := compare(*root, *ref, file, *out, *threshold)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		switch {
+		case r == nil:
+		case r.Added || r.Resized || r.Changed > *minChanged:
+			changed = append(changed, *r)
+			fmt.Printf("%s: %s\n", file, r.summary())
+		case *revert:
+			if err := restore(*root, *ref, file); err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Printf("%s: reverted, %s\n", file, r.summary())
+		}
+	}
+
+	if err := writeGallery(filepath.Join(*out, "index.html"), *ref, changed); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Printf("%d of %d screenshots changed; see %s\n", len(changed), len(files), filepath.Join(*out, "index.html"))
+}
+
+// shotFiles returns the files of the screenshots in the definitions, in
+// all their themes.
+func shotFiles(name string) ([]string, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var list shotList
+	if err := json.Unmarshal(bs, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	var files []string
+	for _, s := range list.Shots {
+		if len(s.Themes) == 0 {
+			files = append(files, s.File)
+		}
+		for _, theme := range s.Themes {
+			files = append(files, themedFile(s.File, theme))
+		}
+	}
+	return files, nil
+}
+
+// themedFile returns the file of a screenshot in a theme, as written by
+// the screenshots tool.
+func themedFile(file, theme string) string {
+	if theme == "" || theme == "default" {
+		return file
+	}
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "-" + theme + ext
+}
+
+func (r *result) summary() string {
+	switch {
+	case r.Added:
+		return "new"
+	case r.Resized:
+		return "size changed"
+	}
+	return fmt.Sprintf("%.2f%% of pixels changed", r.Changed*100)
+}
+
+// compare compares a screenshot with the committed one, writing the
+// images for the gallery to the output directory. It returns nil if
+// the screenshot is the same as the committed one, or doesn't exist.
+func compare(root, ref, file, out string, threshold float64) (*result, error) {
+	cur, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	old, ok, err := committed(root, ref, file)
+	if err != nil {
+		return nil, err
+	}
+	if ok && bytes.Equal(old, cur) {
+		return nil, nil
+	}
+
+	base := strings.ReplaceAll(strings.TrimSuffix(file, filepath.Ext(file)), "/", "_")
+	r := &result{File: file, New: base + "-new" + filepath.Ext(file)}
+	if err := os.WriteFile(filepath.Join(out, r.New), cur, 0o644); err != nil {
+		return nil, err
+	}
+	if !ok {
+		r.Added = true
+		return r, nil
+	}
+	r.Old = base + "-old" + filepath.Ext(file)
+	if err := os.WriteFile(filepath.Join(out, r.Old), old, 0o644); err != nil {
+		return nil, err
+	}
+
+	oldImg, _, err := image.Decode(bytes.NewReader(old))
+	if err != nil {
+		return nil, fmt.Errorf("%s at %s: %w", file, ref, err)
+	}
+	curImg, _, err := image.Decode(bytes.NewReader(cur))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if oldImg.Bounds().Size() != curImg.Bounds().Size() {
+		r.Resized = true
+		r.Changed = 1
+		return r, nil
+	}
+	diff, changed := diffImages(oldImg, curImg, threshold)
+	r.Changed = changed
+	r.Diff = base + "-diff.png"
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, diff); err != nil {
+		return nil, err
+	}
+	return r, os.WriteFile(filepath.Join(out, r.Diff), buf.Bytes(), 0o644)
+}
+
+// committed returns the contents of a file at the git ref, or false if it
+// isn't there.
+func committed(root, ref, file string) ([]byte, bool, error) {
+	cmd := exec.Command("git", "-C", root, "cat-file", "-e", ref+":"+file)
+	if err := cmd.Run(); err != nil {
+		return nil, false, nil
+	}
+	cmd = exec.Command("git", "-C", root, "show", ref+":"+file)
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		return nil, false, fmt.Errorf("git show %s:%s: %w", ref, file, err)
+	}
+	return bs, true, nil
+}
+
+// restore replaces a screenshot with the committed version.
+func restore(root, ref, file string) error {
+	bs, _, err := committed(root, ref, file)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(root, filepath.FromSlash(file)), bs, 0o644)
+}
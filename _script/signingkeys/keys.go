@@ -0,0 +1,171 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+	"syncthing.net/docs/internal/httpclient"
+)
+
+// download returns the contents at the URL.
+func download(url string) ([]byte, error) {
+	resp, err := httpclient.Default.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// gpgKeys returns the keys in a GPG keyring, armored or not.
+func gpgKeys(bs []byte) ([]key, error) {
+	armored := bytes.HasPrefix(bytes.TrimSpace(bs), []byte("-----BEGIN PGP"))
+	var ring openpgp.EntityList
+	var err error
+	if armored {
+		ring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(bs))
+	} else {
+		ring, err = openpgp.ReadKeyRing(bytes.NewReader(bs))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(ring) == 0 {
+		return nil, errors.New("empty keyring")
+	}
+
+	var res []key
+	for _, e := range ring {
+		pk := e.PrimaryKey
+		k := key{
+			fingerprint: fmt.Sprintf("%X", pk.Fingerprint),
+			created:     pk.CreationTime,
+			algoName:    algoName(pk),
+		}
+		var primary *openpgp.Identity
+		for name, id := range e.Identities {
+			k.uids = append(k.uids, name)
+			if primary == nil || id.SelfSignature.IsPrimaryId != nil && *id.SelfSignature.IsPrimaryId {
+				primary = id
+			}
+		}
+		sort.Strings(k.uids)
+		if primary != nil {
+			k.expires = lifetimeEnd(pk.CreationTime, primary.SelfSignature.KeyLifetimeSecs)
+		}
+		for _, sk := range e.Subkeys {
+			k.subkeys = append(k.subkeys, subkey{
+				fingerprint: fmt.Sprintf("%X", sk.PublicKey.Fingerprint),
+				created:     sk.PublicKey.CreationTime,
+				expires:     lifetimeEnd(sk.PublicKey.CreationTime, sk.Sig.KeyLifetimeSecs),
+			})
+		}
+		if k.block, err = armoredKey(e); err != nil {
+			return nil, err
+		}
+		res = append(res, k)
+	}
+	return res, nil
+}
+
+// lifetimeEnd returns when a key created at the given time with the given
+// lifetime expires, or the zero time if it doesn't.
+func lifetimeEnd(created time.Time, secs *uint32) time.Time {
+	if secs == nil || *secs == 0 {
+		return time.Time{}
+	}
+	return created.Add(time.Duration(*secs) * time.Second)
+}
+
+func algoName(pk *packet.PublicKey) string {
+	name := "unknown algorithm"
+	switch pk.PubKeyAlgo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSASignOnly:
+		name = "RSA"
+	case packet.PubKeyAlgoDSA:
+		name = "DSA"
+	case packet.PubKeyAlgoECDSA:
+		name = "ECDSA"
+	}
+	if bits, err := pk.BitLength(); err == nil {
+		name = fmt.Sprintf("%s %d", name, bits)
+	}
+	return name
+}
+
+// armoredKey returns the public key of the entity, armored.
+func armoredKey(e *openpgp.Entity) (string, error) {
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := e.Serialize(w); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sourceKeys returns the PEM encoded ECDSA public key in the file in the
+// Syncthing source.
+func sourceKeys(root, file string) ([]key, error) {
+	bs, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, err
+	}
+	// The key is in a string literal, with the quotes on the same lines
+	// as its first and last line.
+	if i := bytes.Index(bs, []byte("-----BEGIN")); i > 0 {
+		bs = bs[i:]
+	}
+	if i := bytes.Index(bs, []byte("-----END")); i > 0 {
+		if j := bytes.Index(bs[i+len("-----END"):], []byte("-----")); j >= 0 {
+			bs = bs[:i+len("-----END")+j+len("-----")]
+		}
+	}
+	block, _ := pem.Decode(bs)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM encoded key", file)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	ec, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ECDSA key", file)
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return []key{{
+		fingerprint: fmt.Sprintf("%X", sum[:]),
+		algoName:    "ECDSA " + ec.Curve.Params().Name,
+		block:       strings.TrimSpace(string(pem.EncodeToMemory(block))) + "\n",
+	}}, nil
+}
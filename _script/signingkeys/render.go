@@ -0,0 +1,89 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rst"
+)
+
+// writeKeys writes a section per pinned key, from the fetched keys where
+// there are any and the manifest otherwise.
+func writeKeys(w io.Writer, pins []pinned, fetched [][]key, ref string) error {
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/signingkeys; do not edit.\n\n")
+	for i, p := range pins {
+		sb.WriteString(rst.Heading(p.Name, '~'))
+		switch p.Type {
+		case typeGPG:
+			fmt.Fprintf(&sb, "The checksum files are signed with this GPG key, published at %s. Import it with ``gpg --import`` and verify a checksum file with ``gpg --verify sha256sum.txt.asc``.\n\n", rst.Link("", p.Source))
+		case typeECDSA:
+			srcURL := "https://github.com/syncthing/syncthing/blob/" + ref + "/" + p.Source
+			fmt.Fprintf(&sb, "The binaries are signed with this key, which is compiled into Syncthing from %s and checked on upgrade.\n\n", rst.Link(p.Source, srcURL))
+		}
+
+		if fetched[i] == nil {
+			for _, fp := range p.Fingerprints {
+				fmt.Fprintf(&sb, ":Fingerprint: %s\n", rst.Literal(formatFingerprint(fp)))
+			}
+			fmt.Fprintf(&sb, ":Expires: %s\n\n", orNever(p.Expires))
+			continue
+		}
+		for _, k := range fetched[i] {
+			writeKey(&sb, p, k)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeKey(sb *strings.Builder, p pinned, k key) {
+	fmt.Fprintf(sb, ":Fingerprint: %s\n", rst.Literal(formatFingerprint(k.fingerprint)))
+	if p.Type == typeECDSA {
+		sb.WriteString(":Fingerprint type: SHA-256 of the DER encoded key\n")
+	}
+	fmt.Fprintf(sb, ":Algorithm: %s\n", k.algoName)
+	for _, uid := range k.uids {
+		fmt.Fprintf(sb, ":User ID: %s\n", rst.Escape(uid))
+	}
+	if !k.created.IsZero() {
+		fmt.Fprintf(sb, ":Created: %s\n", k.created.UTC().Format(time.DateOnly))
+	}
+	if p.Type == typeGPG {
+		fmt.Fprintf(sb, ":Expires: %s\n", orNever(dateOf(k.expires)))
+	}
+	for _, sk := range k.subkeys {
+		fmt.Fprintf(sb, ":Subkey: %s, created %s, expires %s\n", rst.Literal(formatFingerprint(sk.fingerprint)), sk.created.UTC().Format(time.DateOnly), orNever(dateOf(sk.expires)))
+	}
+	sb.WriteString("\n.. code-block:: none\n\n")
+	for _, line := range strings.Split(strings.TrimRight(k.block, "\n"), "\n") {
+		if line == "" {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString("    " + line + "\n")
+	}
+	sb.WriteString("\n")
+}
+
+func dateOf(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.DateOnly)
+}
+
+func orNever(date string) string {
+	if date == "" {
+		return "never"
+	}
+	return date
+}
@@ -0,0 +1,279 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./signingkeys [-update] [-offline] [-src dir] [-tag vX.Y.Z] > ../includes/release-keys.rst
+//
+// Writes the release signing keys section of the release signing page:
+// the key blocks, fingerprints and expiry of the keys releases are signed
+// with. The keys are fetched from where they're published, the GPG key
+// from the Syncthing website and the upgrade signing key from the
+// Syncthing source, and their fingerprints verified against the pinned
+// manifest, release-keys.csv next to the page. If a key changed, the
+// script fails; after making sure the change is genuine, run it with
+// -update to pin the new keys.
+//
+// With -offline, keys that can't be fetched are described from the
+// manifest alone, without their key blocks and unverified.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+const (
+	typeGPG   = "gpg"
+	typeECDSA = "ecdsa"
+)
+
+// pinned is a row of the manifest: a published key, or set of keys for a
+// GPG keyring.
+type pinned struct {
+	Name   string
+	Type   string
+	Source string
+	// Fingerprints are of the primary keys, space separated in the
+	// manifest.
+	Fingerprints []string
+	// Expires is the date the key expires, or empty for never.
+	Expires string
+}
+
+// key is a key as fetched from its source.
+type key struct {
+	fingerprint string
+	// uids are the user IDs, for GPG keys.
+	uids     []string
+	created  time.Time
+	expires  time.Time
+	subkeys  []subkey
+	block    string
+	algoName string
+}
+
+type subkey struct {
+	fingerprint string
+	created     time.Time
+	expires     time.Time
+}
+
+func main() {
+	log.SetFlags(0)
+	manifest := flag.String("manifest", "../dev/release-keys.csv", "Pinned key manifest")
+	update := flag.Bool("update", false, "Pin the fetched keys in the manifest, even if they changed")
+	offline := flag.Bool("offline", false, "Describe keys that can't be fetched from the manifest")
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to take the upgrade signing key from")
+	flag.Parse()
+
+	pins, err := readManifest(*manifest)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fetched, changed, err := fetchAll(pins, *src, *tag, *offline)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *update {
+		for i, keys := range fetched {
+			if keys != nil {
+				pins[i] = pin(pins[i], keys)
+			}
+		}
+	}
+
+	switch {
+	case *update:
+		if err := writeManifest(*manifest, pins); err != nil {
+			log.Fatalln(err)
+		}
+	case changed:
+		log.Fatalf("the published keys differ from %s; if the change is expected, pin them with -update", *manifest)
+	}
+
+	ref := *tag
+	if ref == "" {
+		ref = "main"
+	}
+	if err := writeKeys(os.Stdout, pins, fetched, ref); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// fetchAll returns the keys published at the source of each pin, nil for
+// those that couldn't be fetched offline, and whether any differ from the
+// pinned ones.
+func fetchAll(pins []pinned, src, tag string, offline bool) ([][]key, bool, error) {
+	// The Syncthing source is only needed, and cloned, for the upgrade
+	// signing key.
+	var root string
+	var cleanup func()
+	defer func() {
+		if cleanup != nil {
+			cleanup()
+		}
+	}()
+
+	res := make([][]key, len(pins))
+	changed := false
+	for i, p := range pins {
+		var keys []key
+		var err error
+		switch p.Type {
+		case typeGPG:
+			var bs []byte
+			if bs, err = download(p.Source); err == nil {
+				keys, err = gpgKeys(bs)
+			}
+		case typeECDSA:
+			if root == "" {
+				root, cleanup, err = stsource.Open(src, tag)
+			}
+			if err == nil {
+				keys, err = sourceKeys(root, p.Source)
+			}
+		default:
+			return nil, false, fmt.Errorf("%s: unknown key type %q", p.Name, p.Type)
+		}
+		if err != nil {
+			if offline {
+				log.Printf("%s: %v; describing it from the manifest", p.Name, err)
+				continue
+			}
+			return nil, false, fmt.Errorf("%s: %w", p.Name, err)
+		}
+		res[i] = keys
+		if diff := compare(p, keys); diff != "" {
+			log.Printf("%s: %s", p.Name, diff)
+			changed = true
+		}
+	}
+	return res, changed, nil
+}
+
+// compare describes how the fetched keys differ from the pinned ones, or
+// returns the empty string if they don't.
+func compare(p pinned, keys []key) string {
+	var got []string
+	for _, k := range keys {
+		got = append(got, k.fingerprint)
+	}
+	if strings.Join(got, " ") != strings.Join(p.Fingerprints, " ") {
+		return fmt.Sprintf("published fingerprints %s, pinned %s", orNone(got), orNone(p.Fingerprints))
+	}
+	if exp := expiry(keys); exp != p.Expires {
+		return fmt.Sprintf("published expiry %q, pinned %q", exp, p.Expires)
+	}
+	return ""
+}
+
+// pin returns the pin updated to the fetched keys.
+func pin(p pinned, keys []key) pinned {
+	p.Fingerprints = nil
+	for _, k := range keys {
+		p.Fingerprints = append(p.Fingerprints, k.fingerprint)
+	}
+	p.Expires = expiry(keys)
+	return p
+}
+
+// expiry returns the earliest expiry date of the keys, or the empty
+// string if they don't expire.
+func expiry(keys []key) string {
+	var first time.Time
+	for _, k := range keys {
+		if !k.expires.IsZero() && (first.IsZero() || k.expires.Before(first)) {
+			first = k.expires
+		}
+	}
+	if first.IsZero() {
+		return ""
+	}
+	return first.UTC().Format(time.DateOnly)
+}
+
+func readManifest(file string) ([]pinned, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	cr := csv.NewReader(fd)
+	cr.Comment = '#'
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%s: no keys", file)
+	}
+	var res []pinned
+	for i, rec := range records[1:] {
+		if len(rec) != 5 {
+			return nil, fmt.Errorf("%s: row %d: expected Name, Type, Source, Fingerprints and Expires", file, i+2)
+		}
+		res = append(res, pinned{
+			Name:         rec[0],
+			Type:         rec[1],
+			Source:       rec[2],
+			Fingerprints: strings.Fields(rec[3]),
+			Expires:      rec[4],
+		})
+	}
+	return res, nil
+}
+
+// writeManifest rewrites the manifest, keeping its comment header.
+func writeManifest(file string, pins []pinned) error {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, line := range strings.SplitAfter(string(bs), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		buf.WriteString(line)
+	}
+	cw := csv.NewWriter(&buf)
+	_ = cw.Write([]string{"Name", "Type", "Source", "Fingerprints", "Expires"})
+	for _, p := range pins {
+		_ = cw.Write([]string{p.Name, p.Type, p.Source, strings.Join(p.Fingerprints, " "), p.Expires})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0o644)
+}
+
+// formatFingerprint groups the fingerprint in blocks of four, the way gpg
+// shows them.
+func formatFingerprint(fp string) string {
+	var groups []string
+	for len(fp) > 4 {
+		groups = append(groups, fp[:4])
+		fp = fp[4:]
+	}
+	return strings.Join(append(groups, fp), " ")
+}
+
+func orNone(ss []string) string {
+	if len(ss) == 0 {
+		return "none"
+	}
+	return strings.Join(ss, ", ")
+}
@@ -0,0 +1,294 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./sitecheck [-site https://docs.syncthing.net] [-map redirects.txt] [-sample 200]
+//
+// Checks the published site: fetches a random sample of the URLs it has
+// had over time, from urls.txt, and reports those that don't end up at a
+// page. Redirects are followed, both HTTP ones and the redirect pages the
+// redirects tool writes for moved pages. URLs in the redirect map, as
+// written by the redirects tool in the netlify format, must redirect to
+// their new location. This catches breakage in the deployment that the
+// checks of the sources can't see. Exits with status 1 if anything was
+// reported.
+//
+// With -update, urls.txt is refreshed instead: the pages the docs have
+// had in their git history, and those the Wayback Machine has archived,
+// are added to it.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+)
+
+const userAgent = "Mozilla/5.0 (compatible; syncthing-docs-sitecheck)"
+
+// maxHops is the number of redirects followed before giving up.
+const maxHops = 10
+
+var (
+	// refreshExp matches the meta refresh of a redirect page.
+	refreshExp = regexp.MustCompile(`(?i)<meta\s+http-equiv="refresh"\s+content="\d+;\s*url=([^"]+)"`)
+	// versionPrefixExp matches the directory of a version of the docs.
+	versionPrefixExp = regexp.MustCompile(`^/v[0-9]+\.[0-9]+\.[0-9]+/`)
+)
+
+func main() {
+	log.SetFlags(0)
+	site := flag.String("site", "https://docs.syncthing.net", "Published site to check")
+	urlsFile := flag.String("urls", "sitecheck/urls.txt", "Paths the site has had")
+	mapFile := flag.String("map", "", "Redirect map in the netlify format, from the redirects tool")
+	sample := flag.Int("sample", 200, "Number of paths to check, picked at random, or zero for all")
+	seed := flag.Int64("seed", 0, "Seed for picking the sample, instead of the time")
+	concurrency := flag.Int("concurrency", 4, "Number of paths checked at once")
+	update := flag.Bool("update", false, "Add the pages in the git history and the Wayback Machine to the paths, instead of checking")
+	root := flag.String("root", "..", "Documentation root, for -update")
+	wayback := flag.Bool("wayback", true, "Ask the Wayback Machine for the pages it has archived, with -update")
+	flag.Parse()
+	siteURL := strings.TrimSuffix(*site, "/")
+
+	paths, err := readPaths(*urlsFile)
+	if err != nil && !(*update && errors.Is(err, os.ErrNotExist)) {
+		log.Fatalln(err)
+	}
+
+	if *update {
+		n, err := updatePaths(*urlsFile, paths, *root, siteURL, *wayback)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Added %d paths\n", n)
+		return
+	}
+
+	redirects := make(map[string]string)
+	if *mapFile != "" {
+		if redirects, err = readMap(*mapFile); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if *sample > 0 && *sample < len(paths) {
+		s := *seed
+		if s == 0 {
+			s = time.Now().UnixNano()
+		}
+		r := rand.New(rand.NewSource(s))
+		r.Shuffle(len(paths), func(a, b int) { paths[a], paths[b] = paths[b], paths[a] })
+		paths = paths[:*sample]
+		sort.Strings(paths)
+		log.Printf("Checking %d paths, seed %d", len(paths), s)
+	}
+
+	c := &checker{
+		site:      siteURL,
+		redirects: redirects,
+		client: &http.Client{
+			Transport: httpclient.Default.Transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	problems := c.checkAll(paths, *concurrency)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// readPaths returns the paths in a file, one per line, leaving out
+// comments.
+func readPaths(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var res []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			res = append(res, line)
+		}
+	}
+	return res, sc.Err()
+}
+
+// readMap returns the redirects of a map in the netlify format, from the
+// old path to the new, without anchors.
+func readMap(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected old and new path", name, n)
+		}
+		to, _, _ := strings.Cut(fields[1], "#")
+		res[fields[0]] = to
+	}
+	return res, sc.Err()
+}
+
+type checker struct {
+	site      string
+	redirects map[string]string
+	client    *http.Client
+}
+
+func (c *checker) checkAll(paths []string, concurrency int) []string {
+	var (
+		mut      sync.Mutex
+		wg       sync.WaitGroup
+		problems []string
+	)
+	queue := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range queue {
+				if msg := c.check(p); msg != "" {
+					mut.Lock()
+					problems = append(problems, p+": "+msg)
+					mut.Unlock()
+				}
+			}
+		}()
+	}
+	for _, p := range paths {
+		queue <- p
+	}
+	close(queue)
+	wg.Wait()
+	sort.Strings(problems)
+	return problems
+}
+
+// check fetches the path, following the redirects, and returns what is
+// wrong with where it ends up, if anything.
+func (c *checker) check(p string) string {
+	final, status, err := c.follow(c.site + p)
+	if err != nil {
+		return err.Error()
+	}
+	if status != http.StatusOK {
+		return fmt.Sprintf("ends at %s with %d %s", final, status, http.StatusText(status))
+	}
+	want, ok := c.expected(p)
+	if !ok {
+		return ""
+	}
+	u, err := url.Parse(final)
+	if err != nil {
+		return err.Error()
+	}
+	if u.Path != want {
+		return fmt.Sprintf("ends at %s, not the new location %s", u.Path, want)
+	}
+	return ""
+}
+
+// expected returns where a path should redirect to according to the
+// redirect map, in the current docs or a version of them.
+func (c *checker) expected(p string) (string, bool) {
+	if to, ok := c.redirects[p]; ok {
+		return to, true
+	}
+	if prefix := versionPrefixExp.FindString(p); prefix != "" {
+		if to, ok := c.redirects["/"+strings.TrimPrefix(p, prefix)]; ok {
+			return strings.TrimSuffix(prefix, "/") + to, true
+		}
+	}
+	return "", false
+}
+
+// follow fetches the URL, following HTTP redirects and redirect pages,
+// and returns the URL it ends at with its status.
+func (c *checker) follow(u string) (string, int, error) {
+	for hop := 0; hop < maxHops; hop++ {
+		status, next, err := c.fetch(u)
+		if err != nil {
+			return u, 0, err
+		}
+		if next == "" {
+			return u, status, nil
+		}
+		base, err := url.Parse(u)
+		if err != nil {
+			return u, 0, err
+		}
+		ref, err := url.Parse(next)
+		if err != nil {
+			return u, 0, fmt.Errorf("%s: bad redirect %q", u, next)
+		}
+		resolved := base.ResolveReference(ref)
+		resolved.Fragment = ""
+		u = resolved.String()
+	}
+	return u, 0, fmt.Errorf("more than %d redirects", maxHops)
+}
+
+// fetch requests the URL and returns the status and where it redirects
+// to, if anywhere.
+func (c *checker) fetch(u string) (int, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		return resp.StatusCode, resp.Header.Get("Location"), nil
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return resp.StatusCode, "", nil
+	}
+	// Redirect pages are small; the refresh is in the head.
+	head, err := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if err != nil {
+		return 0, "", err
+	}
+	if m := refreshExp.FindSubmatch(head); m != nil {
+		return resp.StatusCode, string(m[1]), nil
+	}
+	return resp.StatusCode, "", nil
+}
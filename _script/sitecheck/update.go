@@ -0,0 +1,141 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/httpclient"
+	"syncthing.net/docs/internal/rstdoc"
+)
+
+const cdxURL = "https://web.archive.org/cdx/search/cdx"
+
+const urlsHeader = `# Paths of the pages the site has had, as checked by sitecheck. Refreshed
+# with -update from the git history and the Wayback Machine; paths can
+# also be added by hand.
+`
+
+// updatePaths adds the paths of the pages in the git history of the
+// docs, and those the Wayback Machine has archived of the site, to the
+// file, returning how many were new.
+func updatePaths(file string, paths []string, root, site string, wayback bool) (int, error) {
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		seen[p] = true
+	}
+	n := 0
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+			n++
+		}
+	}
+
+	hist, err := historyPaths(root)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range hist {
+		add(p)
+	}
+	if wayback {
+		archived, err := archivedPaths(site)
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range archived {
+			add(p)
+		}
+	}
+
+	sort.Strings(paths)
+	var sb strings.Builder
+	sb.WriteString(urlsHeader)
+	for _, p := range paths {
+		sb.WriteString(p + "\n")
+	}
+	return n, os.WriteFile(file, []byte(sb.String()), 0o644)
+}
+
+// historyPaths returns the HTML paths of the documents that have been in
+// the docs, leaving out the includes and other files that aren't pages
+// by the current exclusions.
+func historyPaths(root string) ([]string, error) {
+	tree, err := rstdoc.Load(root)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("git", "-C", root, "log", "--name-only", "--format=", "--", "*.rst")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	var res []string
+	for _, file := range strings.Split(string(out), "\n") {
+		if file == "" || tree.Excluded(file) || tree.Included[file] || strings.HasPrefix(file, "includes/") {
+			continue
+		}
+		res = append(res, "/"+strings.TrimSuffix(file, ".rst")+".html")
+	}
+	return res, nil
+}
+
+// archivedPaths returns the paths of the pages of the site the Wayback
+// Machine has archived.
+func archivedPaths(site string) ([]string, error) {
+	su, err := url.Parse(site)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{
+		"url":      {su.Host + "/*"},
+		"fl":       {"original"},
+		"collapse": {"urlkey"},
+		"filter":   {"statuscode:200", "mimetype:text/html"},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cdxURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := httpclient.Default.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wayback: %s", resp.Status)
+	}
+	var res []string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		u, err := url.Parse(strings.TrimSpace(sc.Text()))
+		if err != nil || u.Path == "" {
+			continue
+		}
+		// Pages only, not the static files or search results.
+		if p := path.Clean(u.Path); p == "/" || path.Ext(p) == ".html" && !strings.Contains(p, "/_") {
+			res = append(res, p)
+		}
+	}
+	return res, sc.Err()
+}
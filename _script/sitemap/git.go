@@ -0,0 +1,36 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// sourceDates returns the date of the last commit touching each file, as
+// of the given revision, in one pass over the history.
+func sourceDates(repo, rev string) (map[string]string, error) {
+	cmd := exec.Command("git", "-C", repo, "log", "--format=%x00%cs", "--name-only", rev, "--", "*.rst")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	dates := make(map[string]string)
+	for _, commit := range strings.Split(string(out), "\x00") {
+		date, files, _ := strings.Cut(commit, "\n")
+		for _, f := range strings.Fields(files) {
+			if _, ok := dates[f]; !ok {
+				dates[f] = date
+			}
+		}
+	}
+	return dates, nil
+}
@@ -0,0 +1,175 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./sitemap -site ../_site
+//
+// Writes sitemap.xml and robots.txt into the published site, listing the
+// pages of the current docs at the root and those of each versioned
+// subtree. The current docs get the highest priority, so that search
+// engines prefer them to older versions. The last modification dates come
+// from the git history of the page sources, at the tag for each version.
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var versionExp = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+$`)
+
+// skipped are pages generated by Sphinx that shouldn't be indexed.
+var skipped = map[string]bool{
+	"genindex.html": true,
+	"search.html":   true,
+}
+
+type urlEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority"`
+}
+
+type urlSet struct {
+	XMLName xml.Name   `xml:"urlset"`
+	XMLNS   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+func main() {
+	log.SetFlags(0)
+	site := flag.String("site", "../_site", "Published site directory")
+	repo := flag.String("repo", "..", "Documentation git repository, for the modification dates")
+	base := flag.String("base", "https://docs.syncthing.net", "Base URL of the site")
+	current := flag.String("current", "HEAD", "Git revision the docs at the site root are built from")
+	flag.Parse()
+	baseURL := strings.TrimSuffix(*base, "/")
+
+	set := urlSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+
+	dates, err := sourceDates(*repo, *current)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	entries, err := pages(*site, "", dates, baseURL, "1.0", "weekly")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	set.URLs = append(set.URLs, entries...)
+
+	versions, err := versionDirs(*site)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, v := range versions {
+		dates, err := sourceDates(*repo, v)
+		if err != nil {
+			log.Printf("%s: %v", v, err)
+		}
+		entries, err := pages(*site, v, dates, baseURL, "0.3", "never")
+		if err != nil {
+			log.Fatalln(err)
+		}
+		set.URLs = append(set.URLs, entries...)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(set); err != nil {
+		log.Fatalln(err)
+	}
+	buf.WriteByte('\n')
+	if err := os.WriteFile(filepath.Join(*site, "sitemap.xml"), buf.Bytes(), 0o644); err != nil {
+		log.Fatalln(err)
+	}
+
+	robots := fmt.Sprintf("User-agent: *\nDisallow: /_sources/\nDisallow: /*/_sources/\n\nSitemap: %s/sitemap.xml\n", baseURL)
+	if err := os.WriteFile(filepath.Join(*site, "robots.txt"), []byte(robots), 0o644); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// pages returns the entries for the pages in the given subtree of the
+// site, or its root if empty, excluding the versioned subtrees.
+func pages(site, sub string, dates map[string]string, base, priority, freq string) ([]urlEntry, error) {
+	dir := filepath.Join(site, sub)
+	var res []urlEntry
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != dir && (strings.HasPrefix(d.Name(), "_") || strings.HasPrefix(d.Name(), ".") || sub == "" && versionExp.MatchString(d.Name())) {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() || !strings.HasSuffix(rel, ".html") || skipped[rel] {
+			return nil
+		}
+		if redirect, err := isRedirect(p); err != nil {
+			return err
+		} else if redirect {
+			return nil
+		}
+
+		loc := base + "/"
+		if sub != "" {
+			loc += sub + "/"
+		}
+		if rel != "index.html" {
+			loc += rel
+		}
+		res = append(res, urlEntry{
+			Loc:        loc,
+			LastMod:    dates[strings.TrimSuffix(rel, ".html")+".rst"],
+			ChangeFreq: freq,
+			Priority:   priority,
+		})
+		return nil
+	})
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Loc < res[b].Loc
+	})
+	return res, err
+}
+
+// isRedirect reports whether the page only redirects elsewhere, as the
+// stubs for moved pages do.
+func isRedirect(name string) (bool, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Contains(bs, []byte(`http-equiv="refresh"`)), nil
+}
+
+func versionDirs(site string) ([]string, error) {
+	entries, err := os.ReadDir(site)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, e := range entries {
+		if e.IsDir() && versionExp.MatchString(e.Name()) {
+			res = append(res, e.Name())
+		}
+	}
+	return res, nil
+}
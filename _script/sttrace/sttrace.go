@@ -0,0 +1,154 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./sttrace -tag v1.27.0 -since v1.0.0,v1.10.0 > ../includes/debug-facilities.rst
+//
+// Reads the debug facilities registered with the logger in the Syncthing
+// source and writes the table of the values accepted by STTRACE, with
+// the description given at registration.
+//
+// Given -since, a list of older versions, facilities that were added or
+// removed since are noted with the version of the change.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/stsource"
+)
+
+// all is the STTRACE value enabling every facility.
+const all = "all"
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory")
+	tag := flag.String("tag", "", "Syncthing version to document")
+	since := flag.String("since", "", "Comma separated older versions, oldest first, to find added and removed facilities")
+	flag.Parse()
+
+	// The older versions are checked out first, leaving a source
+	// directory at the requested version.
+	var history []map[string]bool
+	var historyTags []string
+	if *since != "" {
+		for _, t := range strings.Split(*since, ",") {
+			facs, err := facilitiesAt(*src, t)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			history = append(history, namesOf(facs))
+			historyTags = append(historyTags, t)
+		}
+	}
+
+	facs, err := facilitiesAt(*src, *tag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(facs) == 0 {
+		log.Fatalln("no debug facilities found")
+	}
+	history = append(history, namesOf(facs))
+	historyTags = append(historyTags, *tag)
+
+	if err := writeFacilities(os.Stdout, facs, history, historyTags); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// facilitiesAt returns the facilities of a version.
+func facilitiesAt(dir, tag string) ([]stsource.Facility, error) {
+	root, cleanup, err := stsource.Open(dir, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	facs, err := stsource.Facilities(root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	return facs, nil
+}
+
+func namesOf(facs []stsource.Facility) map[string]bool {
+	names := make(map[string]bool)
+	for _, f := range facs {
+		names[f.Name] = true
+	}
+	return names
+}
+
+func writeFacilities(w io.Writer, facs []stsource.Facility, history []map[string]bool, tags []string) error {
+	changed := changes(history, tags)
+	t := rst.Table{
+		Title:  "Debug Facilities",
+		Header: []string{"Facility", "Description", "Changes"},
+		Widths: []int{20, 50, 30},
+	}
+	for _, f := range facs {
+		desc := rst.Escape(f.Description)
+		if desc == "" {
+			desc = "(No description.)"
+		}
+		t.Rows = append(t.Rows, []string{rst.Literal(f.Name), desc, strings.Join(changed[f.Name], " ")})
+	}
+
+	// Facilities that are gone, for those still setting them.
+	current := history[len(history)-1]
+	var removed []string
+	for name := range changed {
+		if !current[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		t.Rows = append(t.Rows, []string{rst.Literal(name), "No longer available.", strings.Join(changed[name], " ")})
+	}
+	t.Rows = append(t.Rows, []string{rst.Literal(all), "All of the above.", ""})
+
+	if len(tags) < 2 {
+		// No history to compare with.
+		t.Header, t.Widths = t.Header[:2], []int{20, 80}
+		for i := range t.Rows {
+			t.Rows[i] = t.Rows[i][:2]
+		}
+	}
+
+	if _, err := fmt.Fprint(w, ".. This file is generated by _script/sttrace; do not edit.\n\n"); err != nil {
+		return err
+	}
+	_, err := t.WriteTo(w)
+	return err
+}
+
+// changes returns the facilities added and removed between the versions,
+// oldest first, as sentences.
+func changes(history []map[string]bool, tags []string) map[string][]string {
+	res := make(map[string][]string)
+	for i := 1; i < len(history); i++ {
+		prev, cur := history[i-1], history[i]
+		for name := range cur {
+			if !prev[name] {
+				res[name] = append(res[name], fmt.Sprintf("Added in %s.", tags[i]))
+			}
+		}
+		for name := range prev {
+			if !cur[name] {
+				res[name] = append(res[name], fmt.Sprintf("Removed in %s.", tags[i]))
+			}
+		}
+	}
+	return res
+}
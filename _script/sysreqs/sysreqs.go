@@ -0,0 +1,173 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./sysreqs > ../users/requirements.rst
+//
+// Writes the System Requirements page from
+// templates/requirements.rst.tmpl, for the current release: the oldest
+// operating system versions it runs on, which follow from the Go version
+// it was built with as on the platform support matrix, noting those
+// raised since the previous release series; the architectures it was
+// released for, from release-platforms.csv; and the memory guidance of
+// memory-guidance.csv. Edit the template or the data rather than the
+// page.
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"syncthing.net/docs/internal/platform"
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/versions"
+)
+
+//go:embed templates/requirements.rst.tmpl
+var templateFS embed.FS
+
+// page is what the template is executed with.
+type page struct {
+	Latest  versions.Release
+	Minimum platform.Minimum
+	// Previous is the latest release of the previous release series, and
+	// Raised the oldest supported versions raised since it.
+	Previous versions.Release
+	Raised   []raise
+	// Platforms are the architectures released for, by operating system.
+	Platforms []string
+	Memory    string
+}
+
+type raise struct {
+	OS, From, To string
+}
+
+func main() {
+	log.SetFlags(0)
+	versionsFile := flag.String("versions", versions.File, "Versions table of the releases page")
+	platformsFile := flag.String("platforms", platform.PlatformsFile, "Release asset platforms")
+	overridesFile := flag.String("overrides", platform.OverridesFile, "Curated overrides")
+	memoryFile := flag.String("memory", "../users/memory-guidance.csv", "Memory guidance")
+	flag.Parse()
+
+	table, err := versions.Load(*versionsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	latest, ok := table.Latest()
+	if !ok {
+		log.Fatalf("%s: no releases", *versionsFile)
+	}
+	overrides, err := platform.ReadOverrides(*overridesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	platforms, err := platform.ReadPlatforms(*platformsFile)
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalln(err)
+	}
+	memory, err := memoryTable(*memoryFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	p := page{Latest: latest, Platforms: byOS(platforms[latest.Version]), Memory: memory}
+	_, p.Minimum = platform.For(latest.Version, latest.Runtime, overrides)
+	if prev, ok := previousSeries(table.Releases(), latest); ok {
+		p.Previous = prev
+		_, min := platform.For(prev.Version, prev.Runtime, overrides)
+		p.Raised = raised(min, p.Minimum)
+	}
+
+	tpl, err := template.ParseFS(templateFS, "templates/requirements.rst.tmpl")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "requirements.rst.tmpl", p); err != nil {
+		log.Fatalln(err)
+	}
+	os.Stdout.Write(buf.Bytes())
+}
+
+// previousSeries returns the latest release of the series before that of
+// the release, from the releases oldest first.
+func previousSeries(rels []versions.Release, r versions.Release) (versions.Release, bool) {
+	for i := len(rels) - 1; i >= 0; i-- {
+		if rels[i].Version.Less(r.Version) && rels[i].Version.Series() != r.Version.Series() {
+			return rels[i], true
+		}
+	}
+	return versions.Release{}, false
+}
+
+// raised returns the operating systems whose oldest supported version
+// differs between the requirements, when both are known.
+func raised(from, to platform.Minimum) []raise {
+	var res []raise
+	for _, c := range []raise{
+		{"Windows", from.Windows, to.Windows},
+		{"macOS", from.MacOS, to.MacOS},
+		{"Linux kernel", from.Linux, to.Linux},
+	} {
+		if c.From != "" && c.To != "" && c.From != c.To {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// byOS returns the os-arch platforms as a line per operating system, as
+// in "linux: 386, amd64, arm64".
+func byOS(platforms []string) []string {
+	arches := make(map[string][]string)
+	for _, p := range platforms {
+		os, arch, _ := strings.Cut(p, "-")
+		arches[os] = append(arches[os], arch)
+	}
+	var res []string
+	for os, as := range arches {
+		sort.Strings(as)
+		res = append(res, os+": "+strings.Join(as, ", "))
+	}
+	sort.Strings(res)
+	return res
+}
+
+// memoryTable returns the memory guidance as a table.
+func memoryTable(file string) (string, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	cr := csv.NewReader(fd)
+	cr.Comment = '#'
+	records, err := cr.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", file, err)
+	}
+	if len(records) < 2 || len(records[0]) != 3 {
+		return "", fmt.Errorf("%s: expected Setup, Memory and Note, and rows", file)
+	}
+	t := rst.Table{
+		Title:  "Memory Guidance",
+		Header: records[0],
+		Rows:   records[1:],
+		Widths: []int{40, 20, 40},
+	}
+	var sb strings.Builder
+	t.WriteTo(&sb)
+	return strings.TrimSpace(sb.String()), nil
+}
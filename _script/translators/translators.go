@@ -0,0 +1,105 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./translators > ../includes/translators.rst
+//
+// Fetches the translators of the Syncthing project on Weblate and how
+// complete each language is, and writes the translation credits
+// included on the translating page: the languages in alphabetical order,
+// each with its translators, most changes first. Set WEBLATE_TOKEN to
+// avoid the rate limit for anonymous requests.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/weblate"
+)
+
+// excluded are Weblate accounts that aren't translators.
+var excluded = map[string]bool{
+	"Anonymous":      true,
+	"Weblate":        true,
+	"Hosted Weblate": true,
+}
+
+func main() {
+	log.SetFlags(0)
+	api := flag.String("api", weblate.DefaultURL, "Weblate API root")
+	project := flag.String("project", "syncthing", "Weblate project")
+	since := flag.String("since", "2000-01-01", "Credit changes made since this date (YYYY-MM-DD)")
+	flag.Parse()
+
+	start, err := time.Parse(time.DateOnly, *since)
+	if err != nil {
+		log.Fatalln("-since:", err)
+	}
+
+	ctx := context.Background()
+	client := weblate.NewClient(*api)
+	langs, err := client.Languages(ctx, *project)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	credits, err := client.Credits(ctx, *project, start, time.Now())
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprintln(w, ".. This file is generated by _script/translators; do not edit.")
+	fmt.Fprintln(w)
+	sort.Slice(langs, func(a, b int) bool { return langs[a].Name < langs[b].Name })
+	for _, lang := range langs {
+		names := translators(credits[lang.Name])
+		if len(names) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s (%d%% translated)\n", rst.Escape(lang.Name), int(math.Floor(lang.TranslatedPercent)))
+		fmt.Fprintf(w, "    %s\n\n", strings.Join(names, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// translators returns the escaped names of the translators, most changes
+// first. Translators are listed once, even if they translated several
+// components.
+func translators(credits []weblate.Credit) []string {
+	changes := make(map[string]int)
+	for _, c := range credits {
+		name := strings.TrimSpace(c.Name)
+		if name == "" || excluded[name] {
+			continue
+		}
+		changes[name] += c.Changes
+	}
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		if changes[names[a]] != changes[names[b]] {
+			return changes[names[a]] > changes[names[b]]
+		}
+		return strings.ToLower(names[a]) < strings.ToLower(names[b])
+	})
+	for i, name := range names {
+		names[i] = rst.Escape(name)
+	}
+	return names
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./transstatus > ../includes/translation-status.rst
+//
+// Fetches how far along each language of the Syncthing project on
+// Weblate is and writes the status table included on the translating
+// page, least translated first, so it's easy to see which languages need
+// help. Languages that are incomplete, or that nobody has worked on for a
+// year, are marked as needing attention. The documentation itself is
+// only in English, so only the GUI strings are covered. Set
+// WEBLATE_TOKEN to avoid the rate limit for anonymous requests.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/weblate"
+)
+
+// staleAfter is how long a language can go without changes before it
+// needs attention, even when it's complete: new strings come with most
+// releases.
+const staleAfter = 365 * 24 * time.Hour
+
+func main() {
+	log.SetFlags(0)
+	api := flag.String("api", weblate.DefaultURL, "Weblate API root")
+	project := flag.String("project", "syncthing", "Weblate project")
+	flag.Parse()
+
+	langs, err := weblate.NewClient(*api).Languages(context.Background(), *project)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	sort.Slice(langs, func(a, b int) bool {
+		if langs[a].TranslatedPercent != langs[b].TranslatedPercent {
+			return langs[a].TranslatedPercent < langs[b].TranslatedPercent
+		}
+		return langs[a].Name < langs[b].Name
+	})
+
+	// The translation pages are under the web root the API is in.
+	web := strings.TrimSuffix(strings.TrimSuffix(*api, "/"), "/api") + "/projects/" + *project + "/-/"
+	now := time.Now()
+
+	var sb strings.Builder
+	sb.WriteString(".. This file is generated by _script/transstatus; do not edit.\n\n")
+	t := rst.Table{
+		Header: []string{"Language", "Translated", "Untranslated strings", "Last activity", "Status"},
+		Widths: []int{30, 15, 20, 15, 20},
+	}
+	for _, l := range langs {
+		last, status := "never", "complete"
+		if !l.LastChange.IsZero() {
+			last = l.LastChange.Format("2006-01-02")
+		}
+		switch {
+		case l.Translated < l.Total:
+			status = "**needs translation**"
+		case l.LastChange.IsZero() || now.Sub(l.LastChange) > staleAfter:
+			status = "**needs review**"
+		}
+		t.Rows = append(t.Rows, []string{
+			rst.Link(l.Name, web+l.Code+"/"),
+			fmt.Sprintf("%d%%", int(math.Floor(l.TranslatedPercent))),
+			fmt.Sprint(l.Total - l.Translated),
+			last,
+			status,
+		})
+	}
+	if _, err := os.Stdout.WriteString(sb.String()); err != nil {
+		log.Fatalln(err)
+	}
+	if _, err := t.WriteTo(os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+}
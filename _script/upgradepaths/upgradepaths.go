@@ -0,0 +1,235 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./upgradepaths > ../includes/upgrade-paths.rst
+//
+// Writes a table of what upgrading from each earlier release to the
+// latest one involves: whether the upgrade can be done directly or needs
+// an intermediate version, which database and configuration migrations
+// it crosses, after which the older version can no longer be used, and
+// which platforms are no longer supported. The releases come from
+// releases.csv, the versions table on the releases page, and the changes
+// from upgrade-rules.csv next to it. Consecutive releases with the same
+// upgrade path share a row.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+	"syncthing.net/docs/internal/versions"
+)
+
+// A rule is a change made in a release that matters when upgrading across
+// it.
+type rule struct {
+	version relnotes.Version
+	kind    string
+	via     relnotes.Version
+	note    string
+}
+
+const (
+	kindDatabase = "database"
+	kindConfig   = "config"
+	kindPlatform = "platform"
+	kindRequires = "requires"
+)
+
+// path is what upgrading from a release to the target involves.
+type path struct {
+	via       []string
+	database  []string
+	config    []string
+	platforms []string
+}
+
+func (p path) key() string {
+	return fmt.Sprint(p.via, p.database, p.config, p.platforms)
+}
+
+func main() {
+	log.SetFlags(0)
+	versionsFile := flag.String("versions", "../users/releases.csv", "Versions table of the releases page")
+	rulesFile := flag.String("rules", "../users/upgrade-rules.csv", "Upgrade rules")
+	flag.Parse()
+
+	table, err := versions.Load(*versionsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	released := table.Versions()
+	if len(released) == 0 {
+		log.Fatalf("%s: no versions", *versionsFile)
+	}
+	rules, err := readRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := writePaths(os.Stdout, released, rules); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// readRules returns the upgrade rules, oldest first.
+func readRules(file string) ([]rule, error) {
+	records, err := readCSV(file)
+	if err != nil {
+		return nil, err
+	}
+	var res []rule
+	for i, rec := range records {
+		if i == 0 {
+			// Header
+			continue
+		}
+		if len(rec) != 4 {
+			return nil, fmt.Errorf("%s: row %d: expected 4 fields, not %d", file, i+1, len(rec))
+		}
+		v, ok := relnotes.ParseVersion(rec[0])
+		if !ok {
+			return nil, fmt.Errorf("%s: row %d: bad version %q", file, i+1, rec[0])
+		}
+		r := rule{version: v, kind: rec[1], note: rec[3]}
+		switch r.kind {
+		case kindDatabase, kindConfig:
+		case kindPlatform:
+			if r.note == "" {
+				return nil, fmt.Errorf("%s: row %d: platform rule without platforms", file, i+1)
+			}
+		case kindRequires:
+			if r.via, ok = relnotes.ParseVersion(rec[2]); !ok {
+				return nil, fmt.Errorf("%s: row %d: bad intermediate version %q", file, i+1, rec[2])
+			}
+			if !r.via.Less(r.version) {
+				return nil, fmt.Errorf("%s: row %d: intermediate version %s is not older than %s", file, i+1, r.via, r.version)
+			}
+		default:
+			return nil, fmt.Errorf("%s: row %d: unknown kind %q", file, i+1, r.kind)
+		}
+		res = append(res, r)
+	}
+	sort.SliceStable(res, func(a, b int) bool { return res[a].version.Less(res[b].version) })
+	return res, nil
+}
+
+// readCSV reads a CSV file where lines starting with # are comments.
+func readCSV(file string) ([][]string, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	cr := csv.NewReader(fd)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return records, nil
+}
+
+// pathFrom returns what upgrading from the release to the target
+// involves, given the rules. Rules apply when they're for a release after
+// from, up to and including the target.
+func pathFrom(from, target relnotes.Version, rules []rule) path {
+	var p path
+	for _, r := range rules {
+		if !from.Less(r.version) || target.Less(r.version) {
+			continue
+		}
+		switch r.kind {
+		case kindDatabase:
+			p.database = append(p.database, r.version.String())
+		case kindConfig:
+			p.config = append(p.config, r.version.String())
+		case kindPlatform:
+			p.platforms = append(p.platforms, r.note)
+		case kindRequires:
+			// An intermediate version the release is already past, or
+			// that's already on the way, isn't needed.
+			if from.Less(r.via) && !contains(p.via, r.via.String()) {
+				p.via = append(p.via, r.via.String())
+			}
+		}
+	}
+	sort.Slice(p.via, func(a, b int) bool {
+		va, _ := relnotes.ParseVersion(p.via[a])
+		vb, _ := relnotes.ParseVersion(p.via[b])
+		return va.Less(vb)
+	})
+	return p
+}
+
+// writePaths writes the table of upgrade paths to the latest version,
+// newest releases first.
+func writePaths(w io.Writer, versions []relnotes.Version, rules []rule) error {
+	target := versions[len(versions)-1]
+
+	// Group the consecutive releases with the same path, leaving out the
+	// target itself.
+	type group struct {
+		first, last relnotes.Version
+		path        path
+	}
+	var groups []group
+	for _, v := range versions[:len(versions)-1] {
+		p := pathFrom(v, target, rules)
+		if n := len(groups); n > 0 && groups[n-1].path.key() == p.key() {
+			groups[n-1].last = v
+			continue
+		}
+		groups = append(groups, group{first: v, last: v, path: p})
+	}
+
+	tbl := rst.Table{
+		Title:  fmt.Sprintf("Upgrading to %s", target),
+		Header: []string{"From", "Upgrade", "Database migrated in", "Configuration migrated in", "No longer supported"},
+		Widths: []int{20, 15, 25, 20, 20},
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		g := groups[i]
+		from := g.first.String()
+		if g.last != g.first {
+			from += " – " + g.last.String()
+		}
+		upgrade := "Direct"
+		if len(g.path.via) > 0 {
+			upgrade = "Via " + strings.Join(g.path.via, ", then ")
+		}
+		tbl.Rows = append(tbl.Rows, []string{
+			from,
+			upgrade,
+			strings.Join(g.path.database, ", "),
+			strings.Join(g.path.config, ", "),
+			strings.Join(g.path.platforms, ", "),
+		})
+	}
+
+	fmt.Fprintln(w, ".. This file is generated by _script/upgradepaths; do not edit.")
+	fmt.Fprintln(w)
+	_, err := tbl.WriteTo(w)
+	return err
+}
+
+func contains(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"strings"
+
+	"syncthing.net/docs/internal/stsource"
+)
+
+// collect returns the include names of the endpoints and options in the
+// source tree.
+func collect(root string) (map[string]bool, error) {
+	items := make(map[string]bool)
+	paths, err := stsource.Endpoints(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		items[includeName("rest", path)] = true
+	}
+	opts, err := stsource.Options(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		items[includeName("config", opt)] = true
+	}
+	return items, nil
+}
+
+// includeName returns the file name, without extension, of the include
+// for an endpoint path or option: rest-db-status, config-folder-type.
+func includeName(kind, name string) string {
+	name = strings.Trim(name, "/")
+	name = strings.TrimPrefix(name, "rest/")
+	name = strings.NewReplacer("/", "-", ".", "-", ":", "").Replace(name)
+	return kind + "-" + name
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./versionadded -src ../_syncthing -out ../includes/since
+//
+// Finds the version each REST endpoint and configuration option first
+// appeared in, by checking out every minor release in the versions table
+// (../users/releases.csv) from -from on in the source tree, and writes an
+// include per endpoint and option with its versionadded directive:
+// rest-db-status.rst for /rest/db/status, config-folder-rescanIntervalS.rst
+// for folder.rescanIntervalS. Those already in the oldest version get an
+// include without a directive, so that the pages can include the snippet
+// regardless. The source directory must be a git clone with the tags.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/stsource"
+)
+
+func main() {
+	log.SetFlags(0)
+	src := flag.String("src", "", "Syncthing source directory (a git clone)")
+	versions := flag.String("versions", "../users/releases.csv", "Versions table listing the releases")
+	from := flag.String("from", "v1.0.0", "Oldest version to look at; what it has is not annotated")
+	out := flag.String("out", "../includes/since", "Directory to write the includes to")
+	flag.Parse()
+	if *src == "" {
+		fmt.Println("Usage: versionadded -src <path> [-versions <file>] [-from <version>] [-out <dir>]")
+		os.Exit(1)
+	}
+	oldest, ok := relnotes.ParseVersion(*from)
+	if !ok {
+		log.Fatalf("-from %q is not a version", *from)
+	}
+
+	tags, err := minorReleases(*versions, oldest)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(tags) == 0 {
+		log.Fatalf("%s: no releases from %s on", *versions, *from)
+	}
+
+	// Check out every version in turn, then return to what was checked
+	// out.
+	head, err := stsource.Head(*src)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var history []map[string]bool
+	for _, tag := range tags {
+		root, _, err := stsource.Open(*src, tag)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		items, err := collect(root)
+		if err != nil {
+			log.Fatalf("%s: %v", tag, err)
+		}
+		history = append(history, items)
+	}
+	if _, _, err := stsource.Open(*src, head); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := writeIncludes(*out, history, tags); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// minorReleases returns the vX.Y.0 releases in the versions table from the
+// oldest on, oldest first. Features are only added in those.
+func minorReleases(file string, oldest relnotes.Version) ([]string, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	recs, err := csv.NewReader(fd).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	var vers []relnotes.Version
+	for _, rec := range recs {
+		v, ok := relnotes.ParseVersion(strings.TrimSpace(rec[0]))
+		if !ok || v.Patch != 0 || v.Less(oldest) {
+			continue
+		}
+		vers = append(vers, v)
+	}
+	sort.Slice(vers, func(a, b int) bool { return vers[a].Less(vers[b]) })
+	tags := make([]string, len(vers))
+	for i, v := range vers {
+		tags[i] = v.String()
+	}
+	return tags, nil
+}
+
+// writeIncludes replaces the includes in the directory with one for each
+// item in the newest version.
+func writeIncludes(dir string, history []map[string]bool, tags []string) error {
+	old, err := filepath.Glob(filepath.Join(dir, "*.rst"))
+	if err != nil {
+		return err
+	}
+	for _, f := range old {
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range history[len(history)-1] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var sb strings.Builder
+		sb.WriteString(".. This file is generated by _script/versionadded; do not edit.\n")
+		if since := firstSeen(name, history, tags); since != "" {
+			fmt.Fprintf(&sb, "\n.. versionadded:: %s\n", strings.TrimPrefix(since, "v"))
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".rst"), []byte(sb.String()), 0o644); err != nil {
+			return err
+		}
+	}
+	log.Printf("wrote %d includes for %s to %s", len(names), tags[len(tags)-1], dir)
+	return nil
+}
+
+// firstSeen returns the first version with the item, or the empty string
+// if it's in the oldest one.
+func firstSeen(name string, history []map[string]bool, tags []string) string {
+	for i, items := range history {
+		if items[name] {
+			if i == 0 {
+				return ""
+			}
+			return tags[i]
+		}
+	}
+	return ""
+}
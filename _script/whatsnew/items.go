@@ -0,0 +1,134 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+	"syncthing.net/docs/internal/rst"
+)
+
+// item is a change, from the release notes or a commit.
+type item struct {
+	Category relnotes.Category
+	// Text is reStructuredText.
+	Text string
+	// Refs are the issue and pull request numbers it mentions.
+	Refs []string
+	// Source is the release the item is from, or the commit range.
+	Source string
+}
+
+var (
+	// Conventional commit subjects, "feat(gui)!: ...".
+	conventionalExp = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?:\s*(.*)$`)
+	// Older subjects name the package changed, "lib/model: ...".
+	packageExp = regexp.MustCompile(`^[\w./-]+(?:, ?[\w./-]+)*:\s*(.*)$`)
+)
+
+// noteItems returns the items of the release notes.
+func noteItems(conv relnotes.Converter, rel relnotes.Release) []item {
+	var res []item
+	for _, it := range rel.Items() {
+		res = append(res, item{Category: it.Category, Text: conv.Inline(it.Text), Refs: it.Refs, Source: rel.Tag})
+	}
+	return res
+}
+
+// commitItem sorts a commit subject by its conventional commit type, or
+// failing that its wording.
+func commitItem(conv relnotes.Converter, subject, tag string) item {
+	it := item{Category: relnotes.Other, Refs: relnotes.Refs(subject), Source: "commits up to " + tag}
+	text := subject
+	if m := conventionalExp.FindStringSubmatch(subject); m != nil {
+		text = m[3]
+		switch {
+		case m[2] == "!":
+			it.Category = relnotes.Breaking
+		case m[1] == "feat":
+			it.Category = relnotes.Feature
+		case m[1] == "fix":
+			it.Category = relnotes.Fix
+		}
+	} else {
+		if m := packageExp.FindStringSubmatch(subject); m != nil {
+			text = m[1]
+		}
+		lower := strings.ToLower(text)
+		switch {
+		case strings.Contains(lower, "breaking"):
+			it.Category = relnotes.Breaking
+		case strings.HasPrefix(lower, "fix") || strings.Contains(lower, "(fixes #"):
+			it.Category = relnotes.Fix
+		case strings.HasPrefix(lower, "add") || strings.HasPrefix(lower, "support") || strings.HasPrefix(lower, "allow") || strings.HasPrefix(lower, "implement"):
+			it.Category = relnotes.Feature
+		}
+	}
+	it.Text = conv.Inline(text)
+	return it
+}
+
+// dedupe drops the items referring only to issues or pull requests an
+// earlier item already did. Release notes come first, so they're kept
+// over the commits.
+func dedupe(items []item) []item {
+	seen := make(map[string]bool)
+	var res []item
+	for _, it := range items {
+		dup := len(it.Refs) > 0
+		for _, r := range it.Refs {
+			if !seen[r] {
+				dup = false
+			}
+		}
+		if dup {
+			continue
+		}
+		for _, r := range it.Refs {
+			seen[r] = true
+		}
+		res = append(res, it)
+	}
+	return res
+}
+
+func writePage(w io.Writer, series relnotes.Version, rels []relnotes.Release, items []item) error {
+	name := strings.TrimPrefix(series.Series(), "v")
+	var sb strings.Builder
+	sb.WriteString(".. Generated by _script/whatsnew as a starting point: rewrite the\n")
+	sb.WriteString("   items below as prose for users, merge related ones and drop the\n")
+	sb.WriteString("   ones that don't matter to them, then remove this comment.\n\n")
+	fmt.Fprintf(&sb, ".. _whatsnew-%s:\n\n", name)
+	sb.WriteString(rst.Heading("What's New in "+name, '='))
+	fmt.Fprintf(&sb, "Syncthing %s was released on %s.\n\n", name, rels[len(rels)-1].Published.Format("2006-01-02"))
+
+	for cat := range relnotes.CategoryTitles {
+		var lines []string
+		for _, it := range items {
+			if it.Category == relnotes.Category(cat) {
+				lines = append(lines, fmt.Sprintf("- %s\n\n  .. Source: %s", it.Text, it.Source))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		sb.WriteString(rst.Heading(relnotes.CategoryTitles[cat], '-'))
+		sb.WriteString(strings.Join(lines, "\n\n") + "\n\n")
+	}
+
+	sb.WriteString(rst.Heading("Releases", '-'))
+	for i := len(rels) - 1; i >= 0; i-- {
+		rel := rels[i]
+		fmt.Fprintf(&sb, "- %s, %s\n", rst.Link(rel.Tag, rel.URL), rel.Published.Format("2006-01-02"))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
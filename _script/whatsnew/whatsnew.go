@@ -0,0 +1,103 @@
+// Copyright (C) 2024 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Usage: go run ./whatsnew -series v1.27 [-src ../_syncthing] > whatsnew-1.27.rst
+//
+// Writes the skeleton of a "what's new" page for a minor release series,
+// for the docs writers to turn into prose. The items of the release
+// notes of each release in the series, and with -src the subjects of the
+// commits since the previous series, are sorted into breaking changes,
+// new features and fixes; what can't be sorted is listed as other
+// changes. Items referring to the same issue or pull request are listed
+// once. The source directory needs the full history and tags, so not a
+// shallow clone.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"syncthing.net/docs/internal/relnotes"
+)
+
+func main() {
+	log.SetFlags(0)
+	seriesFlag := flag.String("series", "", "Minor release series, such as v1.27 (required)")
+	repo := flag.String("repo", "syncthing/syncthing", "Repository to take the releases from")
+	src := flag.String("src", "", "Syncthing source directory with full history, to include the commits")
+	flag.Parse()
+	series, ok := relnotes.ParseVersion(strings.TrimSpace(*seriesFlag) + ".0")
+	if !ok {
+		log.Fatalln("-series must be like v1.27")
+	}
+
+	rels, err := relnotes.List(context.Background(), relnotes.Client(), *repo)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// The releases are newest first: the series, then the previous one.
+	var inSeries []relnotes.Release
+	var previous *relnotes.Release
+	for i, rel := range rels {
+		v := rel.Version
+		switch {
+		case v.Series() == series.Series():
+			inSeries = append(inSeries, rel)
+		case v.Less(series) && previous == nil:
+			previous = &rels[i]
+		}
+	}
+	if len(inSeries) == 0 {
+		log.Fatalln("no releases in", series.Series())
+	}
+
+	conv := relnotes.Converter{Repo: *repo}
+	var items []item
+	// Oldest first, as the changes were made.
+	for i := len(inSeries) - 1; i >= 0; i-- {
+		items = append(items, noteItems(conv, inSeries[i])...)
+	}
+	if *src != "" {
+		if previous == nil {
+			log.Fatalln("no release before", series.Series(), "to take the commits from")
+		}
+		subjects, err := commitSubjects(*src, previous.Tag, inSeries[0].Tag)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, s := range subjects {
+			items = append(items, commitItem(conv, s, inSeries[0].Tag))
+		}
+	}
+
+	if err := writePage(os.Stdout, series, inSeries, dedupe(items)); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// commitSubjects returns the subjects of the non-merge commits after
+// from up to and including to, oldest first.
+func commitSubjects(src, from, to string) ([]string, error) {
+	cmd := exec.Command("git", "-C", src, "log", "--no-merges", "--reverse", "--format=%s", from+".."+to)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log %s..%s: %w", from, to, err)
+	}
+	var res []string
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if s := strings.TrimSpace(string(line)); s != "" {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
@@ -36,11 +36,19 @@ extensions = [
     'edit_on_github',
     'syncthing_config',
     'sphinx.ext.graphviz',
+    # Converts the SVG images for the PDF manual; needs ImageMagick.
+    'sphinx.ext.imgconverter',
+    'link_archive',
+    'dark_images',
 ]
 
 edit_on_github_project = 'syncthing/docs'
 edit_on_github_branch = 'main'
 
+# Set LINK_ARCHIVE_REWRITE=1 to point links to dead pages at their archived
+# copies in links-archive.csv.
+link_archive_rewrite = os.environ.get('LINK_ARCHIVE_REWRITE') == '1'
+
 # Add any paths that contain templates here, relative to this directory.
 templates_path = ['_templates']
 
@@ -90,7 +98,7 @@ version = release.partition('-')[0]
 
 # List of patterns, relative to source directory, that match files and
 # directories to ignore when looking for source files.
-exclude_patterns = ['_build', '_syncthing', 'draft', 'README.rst', 'users/faq-parts']
+exclude_patterns = ['_build', '_syncthing', '_translations', 'draft', 'README.rst', 'users/faq-parts']
 
 # The reST default role (used for this markup: `text`) to use for all
 # documents.
@@ -260,6 +268,9 @@ latex_elements = {
 # (source start file, target name, title,
 #  author, documentclass [howto, manual, or own class]).
 latex_documents = [
+    # The whole manual, published by _script/build (make manual).
+    ('index', u'Syncthing.tex', u'Syncthing Documentation',
+     u'The Syncthing Authors', 'manual'),
     ('intro/getting-started', u'Getting-Started.tex',
      u'Getting Started', u'The Syncthing Authors', 'manual'),
     ('users/faq', u'FAQ.tex', u'FAQ', u'The Syncthing Authors', 'manual'),
@@ -286,63 +297,21 @@ latex_documents = [
 #latex_domain_indices = True
 
 
-# -- Options for manual page output ---------------------------------------
+# -- Options for Epub output ----------------------------------------------
 
-# One entry per manual page. List of tuples
-# (source start file, name, description, authors, manual section).
-man_pages = [
-    ('users/syncthing', 'syncthing',
-     'Syncthing',
-     ['The Syncthing Authors'], 1),
-    ('users/stdiscosrv', 'stdiscosrv',
-     'Syncthing Discovery Server',
-     ['The Syncthing Authors'], 1),
-    ('users/strelaysrv', 'strelaysrv',
-     'Syncthing Relay Server',
-     ['The Syncthing Authors'], 1),
-    ('users/config', 'syncthing-config',
-     'Syncthing Configuration',
-     ['The Syncthing Authors'], 5),
-    ('users/ignoring', 'syncthing-stignore',
-     'Prevent files from being synchronized to other nodes',
-     ['The Syncthing Authors'], 5),
-    ('dev/rest', 'syncthing-rest-api',
-     'REST API',
-     ['The Syncthing Authors'], 7),
-    ('dev/events', 'syncthing-event-api',
-     'Event API',
-     ['The Syncthing Authors'], 7),
-    ('dev/device-ids', 'syncthing-device-ids',
-     'Understanding Device IDs',
-     ['The Syncthing Authors'], 7),
-    ('users/security', 'syncthing-security',
-     'Security Principles',
-     ['The Syncthing Authors'], 7),
-    ('users/firewall', 'syncthing-networking',
-     'Firewall Setup',
-     ['The Syncthing Authors'], 7),
-    ('users/versioning', 'syncthing-versioning',
-     'Keep automatic backups of deleted files by other nodes',
-     ['The Syncthing Authors'], 7),
-    ('users/faq', 'syncthing-faq',
-     'Frequently Asked Questions',
-     ['The Syncthing Authors'], 7),
-    ('specs/bep-v1', 'syncthing-bep',
-     'Block Exchange Protocol v1',
-     ['The Syncthing Authors'], 7),
-    ('specs/localdisco-v4', 'syncthing-localdisco',
-     'Local Discovery Protocol v4',
-     ['The Syncthing Authors'], 7),
-    ('specs/globaldisco-v3', 'syncthing-globaldisco',
-     'Global Discovery Protocol v3',
-     ['The Syncthing Authors'], 7),
-    ('specs/relay-v1', 'syncthing-relay',
-     'Relay Protocol v1',
-     ['The Syncthing Authors'], 7),
-]
+epub_basename = 'Syncthing'
+epub_title = u'Syncthing Documentation'
+epub_author = u'The Syncthing Authors'
+epub_publisher = u'The Syncthing Authors'
+epub_copyright = copyright
+epub_identifier = 'https://docs.syncthing.net/'
+epub_scheme = 'URL'
+epub_exclude_files = ['search.html']
 
-# If true, show URL addresses after external links.
-man_show_urls = True
+
+# -- Options for manual page output ---------------------------------------
+
+# The man pages are generated by _script/manpages (make man).
 
 
 # -- Options for Texinfo output -------------------------------------------
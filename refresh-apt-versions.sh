@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./aptversions > ../includes/apt-versions.rst
+popd
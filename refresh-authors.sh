@@ -7,4 +7,11 @@ grep \# AUTHORS > authors-hdr
 cat authors-hdr authors-new > AUTHORS
 rm authors-hdr authors-new
 
-git-contributors --read-authors=AUTHORS --names --geekrank | sed 's/_/\\_/g' > thanks.txt
+rm -rf _syncthing
+git clone --quiet https://github.com/syncthing/syncthing.git _syncthing
+
+pushd _script
+go run ./contributors -repos ..,../_syncthing -releases ../_syncthing > ../thanks.txt
+popd
+
+rm -rf _syncthing
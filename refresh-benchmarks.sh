@@ -0,0 +1,12 @@
+#!/bin/sh
+set -euo pipefail
+
+# Add any result files given as arguments, then regenerate the page.
+for f in "$@"; do
+	set -- "$@" "$(realpath "$f")"
+	shift
+done
+
+pushd _script
+go run ./benchreport -add "$@" > ../includes/benchmark-results.rst
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./changelog -since v1.0.0 > ../users/changelog.rst
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./clitree -tag "$1" > ../includes/cli-reference.rst
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./configexample -tag "$1" > ../includes/config-example.rst
+popd
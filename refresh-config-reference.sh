@@ -0,0 +1,7 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./configref -tag "$1" -out ../includes
+go run ./configref -tag "$1" -advanced > ../includes/advanced-options.rst
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./sttrace -tag "$1" -since v1.0.0,v1.10.0,v1.20.0 > ../includes/debug-facilities.rst
+popd
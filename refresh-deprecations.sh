@@ -0,0 +1,8 @@
+#!/bin/sh
+set -euo pipefail
+
+rm -rf _syncthing
+git clone --depth 1 https://github.com/syncthing/syncthing.git _syncthing
+pushd _script
+go run ./deprecations -src ../_syncthing -out ../includes -inject
+popd
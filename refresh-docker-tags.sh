@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./dockertags > ../includes/docker-tags.rst
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./errorindex -tag "$1" > ../includes/error-index.rst
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./eventrecord -syncthing "$1" -out ../includes/events
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./eventref -tag "$1" > ../includes/event-types.rst
+popd
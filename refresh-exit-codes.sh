@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./exitcodes -tag "$1" -since v1.0.0 > ../includes/exit-codes.rst
+popd
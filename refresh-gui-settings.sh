@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./guisettings -tag "$1" > ../includes/gui-settings.rst
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./guistrings -tag "$1" > ../includes/gui-strings.rst
+popd
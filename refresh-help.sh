@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./helpcapture -version "$1" -out ../includes/help
+popd
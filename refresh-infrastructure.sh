@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./infrastatus > ../includes/infrastructure.rst
+popd
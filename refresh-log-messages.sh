@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./logcatalog -tag "$1" > ../includes/log-messages.rst
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./pkgstatus > ../includes/packaging-status.rst
+popd
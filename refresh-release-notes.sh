@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./releasenotes -since v1.0.0 -out ../users/release-notes
+popd
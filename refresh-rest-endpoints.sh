@@ -0,0 +1,7 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./apigen -tag "$1" -params ../includes/rest-params > ../includes/rest-endpoints.rst
+go run ./apigen -tag "$1" -openapi > ../_static/openapi.json
+popd
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./restrecord -syncthing "$1" -out ../includes/rest
+popd
@@ -0,0 +1,7 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./screenshots -syncthing "$1"
+go run ./shotdiff -revert
+popd
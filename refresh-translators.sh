@@ -0,0 +1,7 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./translators > ../includes/translators.rst
+go run ./transstatus > ../includes/translation-status.rst
+popd
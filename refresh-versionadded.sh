@@ -0,0 +1,9 @@
+#!/bin/sh
+set -euo pipefail
+
+# Needs a full clone, with the tags, to check out the older versions.
+rm -rf _syncthing
+git clone https://github.com/syncthing/syncthing.git _syncthing
+pushd _script
+go run ./versionadded -src ../_syncthing -out ../includes/since
+popd
@@ -1,4 +1,11 @@
 #!/bin/sh
 
 pushd _script
-go run ./histver -file ../users/releases.csv
+go run ./histver -file ../users/releases.csv -config histver/docs.json -audit histver/audit.json -fill-go-security
+go run ./releasespage > ../users/releases.rst
+go run ./checksums > ../includes/release-checksums.rst
+go run ./upgradepaths > ../includes/upgrade-paths.rst
+go run ./platforms > ../includes/platform-support.rst
+go run ./sysreqs > ../users/requirements.rst
+go run ./releasecharts > ../includes/release-history.rst
+go run ./darkimages
@@ -0,0 +1,6 @@
+#!/bin/sh
+set -euo pipefail
+
+pushd _script
+go run ./histver -file ../users/releases.csv features .. > ../users/whats-new.rst
+popd